@@ -0,0 +1,76 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ClusterGitOpsProjectTemplateSpec defines the guardrails of GitOpsProjects stamped from a template.
+type ClusterGitOpsProjectTemplateSpec struct {
+	// AllowedURLs are glob patterns matching the gitops repositories projects are allowed to reconcile,
+	// e.g. "git@github.com:my-org/*". Empty allows every repository.
+	// +optional
+	AllowedURLs []string `json:"allowedURLs,omitempty"`
+
+	// AllowedNamespaces are glob patterns matching the namespaces projects are allowed to be created in,
+	// e.g. "team-*". Empty allows every namespace.
+	// +optional
+	AllowedNamespaces []string `json:"allowedNamespaces,omitempty"`
+
+	// ShardAffinity selects the controller shards by their pod labels, which are allowed to reconcile stamped projects.
+	// It is enforced in addition to the shard affinity of a project.
+	// +optional
+	ShardAffinity *metav1.LabelSelector `json:"shardAffinity,omitempty"`
+
+	//+kubebuilder:validation:Minimum=5
+	// MinPullIntervalSeconds is the lower bound of the pull interval of stamped projects.
+	// +optional
+	MinPullIntervalSeconds int `json:"minPullIntervalSeconds,omitempty"`
+
+	//+kubebuilder:validation:Minimum=5
+	// MaxPullIntervalSeconds is the upper bound of the pull interval of stamped projects.
+	// +optional
+	MaxPullIntervalSeconds int `json:"maxPullIntervalSeconds,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster,shortName=gopt
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+
+// ClusterGitOpsProjectTemplate is the Schema for the clustergitopsprojecttemplates API.
+// Admins define templates and tenants stamp GitOpsProjects from them by setting spec.templateName,
+// while the controller refuses projects violating the template.
+type ClusterGitOpsProjectTemplate struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec ClusterGitOpsProjectTemplateSpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// ClusterGitOpsProjectTemplateList contains a list of ClusterGitOpsProjectTemplate
+type ClusterGitOpsProjectTemplateList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ClusterGitOpsProjectTemplate `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ClusterGitOpsProjectTemplate{}, &ClusterGitOpsProjectTemplateList{})
+}
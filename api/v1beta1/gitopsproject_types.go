@@ -27,10 +27,13 @@ type GitOpsProjectSpec struct {
 
 	//+kubebuilder:validation:MinLength=1
 	// The url to the gitops repository.
+	// Urls prefixed with oci://, e.g. oci://ghcr.io/org/project-config:tag, reference a project bundle
+	// published with 'declcd publish' instead, which is pulled and verified against its digest every interval.
 	URL string `json:"url"`
 
 	//+kubebuilder:validation:MinLength=1
 	// The branch of the gitops repository holding the declcd configuration.
+	// It is ignored for project bundles.
 	Branch string `json:"branch"`
 
 	//+kubebuilder:validation:Minimum=5
@@ -41,11 +44,234 @@ type GitOpsProjectSpec struct {
 	// not apply to already started executions.  Defaults to false.
 	// +optional
 	Suspend *bool `json:"suspend,omitempty"`
+
+	// Notification posts status transitions of this project to an external system.
+	// +optional
+	Notification *GitOpsProjectNotification `json:"notification,omitempty"`
+
+	// Path to a component artifact inside the gitops repository, produced by 'declcd build --output'.
+	// When set, components are read from the artifact instead of compiling CUE in-cluster.
+	// +optional
+	ArtifactPath string `json:"artifactPath,omitempty"`
+
+	// Suppressions exclude fields from being applied and from drift detection,
+	// because they are legitimately managed by other controllers.
+	// +optional
+	Suppressions []GitOpsProjectSuppression `json:"suppressions,omitempty"`
+
+	// ShardAffinity selects the controller shards by their pod labels, which are allowed to reconcile this project.
+	// A shard assigned via the 'declcd/shard' label, which does not match, refuses the project.
+	// Anti-affinity is expressed with the NotIn and DoesNotExist operators.
+	// +optional
+	ShardAffinity *metav1.LabelSelector `json:"shardAffinity,omitempty"`
+
+	// Guardrails refuse objects before they are applied, failing only the component declaring them.
+	// +optional
+	Guardrails *GitOpsProjectGuardrails `json:"guardrails,omitempty"`
+
+	//+kubebuilder:validation:Minimum=1
+	// MaxConcurrentApplies limits the components of this project applied in parallel,
+	// e.g. to keep a huge project from saturating the API server for other projects of the shard.
+	// Defaults to and never exceeds the worker pool size of the controller.
+	// +optional
+	MaxConcurrentApplies int `json:"maxConcurrentApplies,omitempty"`
+
+	//+kubebuilder:validation:Enum=Ignore;Warn;Strict
+	// FieldValidation instructs the API server how to handle unknown or duplicate fields of applied manifests and hooks.
+	// Strict rejects them and fails the component with the paths of the offending fields,
+	// catching schema drift between the cluster and the schemas the project was validated against.
+	// Defaults to the behavior of the API server.
+	// +optional
+	FieldValidation string `json:"fieldValidation,omitempty"`
+
+	// Revision pins reconciliation to a commit SHA or tag regardless of the branch head,
+	// e.g. to roll back an environment to the last known good commit.
+	// For project bundles it is a tag or digest overriding the one of the url.
+	// +optional
+	Revision string `json:"revision,omitempty"`
+
+	// Promotions are the component versions promoted by operators via 'declcd promote'.
+	// +optional
+	Promotions []GitOpsProjectPromotion `json:"promotions,omitempty"`
+
+	// Webhook allows Git providers to trigger a reconciliation on push events
+	// instead of waiting for the pull interval.
+	// +optional
+	Webhook *GitOpsProjectWebhook `json:"webhook,omitempty"`
+
+	// RequiredCheck requires a successful commit status or check run on the Git provider,
+	// e.g. of a CI job validating the project, before a new commit is applied.
+	// Commits without it are skipped and the previously applied commit stays in place.
+	// +optional
+	RequiredCheck *GitOpsProjectRequiredCheck `json:"requiredCheck,omitempty"`
+
+	// Previews spawn a preview project per branch matching a glob, e.g. for pull requests.
+	// Preview projects are torn down, when their branch is deleted.
+	// +optional
+	Previews *GitOpsProjectPreviews `json:"previews,omitempty"`
+
+	// TargetNamespace moves all namespaced objects and Helm releases into this namespace.
+	// Namespaces declared by the project are replaced by it and all other cluster scoped objects are not applied,
+	// as they would be shared with other projects.
+	// It is set on preview projects.
+	// +optional
+	TargetNamespace string `json:"targetNamespace,omitempty"`
+
+	// TemplateName is the name of the ClusterGitOpsProjectTemplate this project is stamped from.
+	// Projects violating the template are refused.
+	// +optional
+	TemplateName string `json:"templateName,omitempty"`
+}
+
+// GitOpsProjectPreviews defines the branches preview projects are spawned for.
+type GitOpsProjectPreviews struct {
+	//+kubebuilder:validation:MinLength=1
+	// Branches is a glob matching the branches to preview, e.g. "preview/*".
+	Branches string `json:"branches"`
+
+	//+kubebuilder:validation:MinLength=1
+	// NamespaceTemplate is a Go template of the target namespace of a preview,
+	// e.g. "{{ .Project }}-{{ .Branch }}".
+	// Branch is the branch name converted to a DNS label.
+	NamespaceTemplate string `json:"namespaceTemplate"`
+}
+
+// GitOpsProjectRequiredCheck defines the commit check gating a project.
+type GitOpsProjectRequiredCheck struct {
+	//+kubebuilder:validation:MinLength=1
+	// Name of the commit status or check run, e.g. "declcd-verify".
+	Name string `json:"name"`
+
+	//+kubebuilder:validation:Enum=github;gitlab
+	// Provider hosting the repository.
+	Provider string `json:"provider"`
+
+	//+kubebuilder:validation:MinLength=1
+	// Repository is the owner/repo on GitHub or the project id or path on GitLab.
+	Repository string `json:"repository"`
+
+	// BaseURL of the provider API for GitHub Enterprise or self-managed GitLab instances.
+	// +optional
+	BaseURL string `json:"baseURL,omitempty"`
+
+	//+kubebuilder:validation:MinLength=1
+	// Name of a Secret in the project namespace with a 'token' key, which is allowed to read commit statuses.
+	TokenSecretName string `json:"tokenSecretName"`
+}
+
+// GitOpsProjectWebhook defines how push events for this project are authenticated.
+type GitOpsProjectWebhook struct {
+	//+kubebuilder:validation:MinLength=1
+	// Name of a Secret in the project namespace with a 'secret' key, which is configured as webhook secret at the Git provider.
+	SecretName string `json:"secretName"`
+}
+
+// GitOpsProjectPromotion releases a deferred version of a component.
+type GitOpsProjectPromotion struct {
+	//+kubebuilder:validation:MinLength=1
+	// Component is the id of the promoted component.
+	Component string `json:"component"`
+
+	//+kubebuilder:validation:MinLength=1
+	// Digest identifies the promoted version of the component.
+	Digest string `json:"digest"`
+}
+
+// GitOpsProjectGuardrails restrict the objects a project is allowed to apply.
+type GitOpsProjectGuardrails struct {
+	//+kubebuilder:validation:Minimum=0
+	// MaxObjectSizeBytes is the maximum size of an encoded object, e.g. to catch ConfigMaps exceeding the etcd limits.
+	// Zero disables the limit.
+	// +optional
+	MaxObjectSizeBytes int64 `json:"maxObjectSizeBytes,omitempty"`
+
+	// DeniedKinds are kinds the project must not apply, e.g. Nodes or PriorityClasses for tenant projects.
+	// +optional
+	DeniedKinds []GitOpsProjectDeniedKind `json:"deniedKinds,omitempty"`
+}
+
+// GitOpsProjectDeniedKind matches objects by their kind.
+type GitOpsProjectDeniedKind struct {
+	// APIGroup of matching objects. Empty matches every group.
+	// +optional
+	APIGroup string `json:"apiGroup,omitempty"`
+
+	//+kubebuilder:validation:MinLength=1
+	// Kind of matching objects.
+	Kind string `json:"kind"`
+}
+
+// GitOpsProjectSuppression excludes fields of matching objects.
+type GitOpsProjectSuppression struct {
+	// APIGroup of matching objects. Empty matches every group.
+	// +optional
+	APIGroup string `json:"apiGroup,omitempty"`
+
+	// Kind of matching objects. Empty matches every kind.
+	// +optional
+	Kind string `json:"kind,omitempty"`
+
+	//+kubebuilder:validation:MinItems=1
+	// Dot separated field paths, e.g. "spec.replicas".
+	// A "*" segment matches every key of an object or every element of a list.
+	Paths []string `json:"paths"`
+}
+
+// GitOpsProjectNotification defines where status transitions are sent to.
+type GitOpsProjectNotification struct {
+	//+kubebuilder:validation:MinLength=1
+	// The url receiving a POST request on every status transition.
+	URL string `json:"url"`
+
+	// Name of a Secret in the project namespace with a 'secret' key used to sign requests via HMAC-SHA256.
+	// +optional
+	SecretName string `json:"secretName,omitempty"`
 }
 
 type GitOpsProjectRevision struct {
 	CommitHash    string      `json:"commitHash,omitempty"`
 	ReconcileTime metav1.Time `json:"reconcileTime,omitempty"`
+	// Pinned is the spec revision the commit was resolved from. Empty while following the branch head.
+	// +optional
+	Pinned string `json:"pinned,omitempty"`
+}
+
+// GitOpsProjectNamespaceStatus summarizes the outcome of applying all components targeting a namespace.
+type GitOpsProjectNamespaceStatus struct {
+	// The name of the namespace. Empty for cluster scoped components.
+	// +optional
+	Name string `json:"name"`
+	// Succeeded reports whether all components of the namespace were applied.
+	Succeeded bool `json:"succeeded"`
+	// Message describes the first failure of the namespace.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// GitOpsProjectComponentStatus summarizes the outcome of reconciling a component.
+type GitOpsProjectComponentStatus struct {
+	// ID of the component.
+	ID string `json:"id"`
+	// Type of the component, e.g. Manifest or HelmRelease.
+	Type string `json:"type"`
+	// Revision is the commit hash the component was last applied from.
+	// +optional
+	Revision string `json:"revision,omitempty"`
+	// LastAppliedTime is the last time the component was applied. Nil, when it has never been applied.
+	// +optional
+	LastAppliedTime *metav1.Time `json:"lastAppliedTime,omitempty"`
+	// Digest identifies the declared content of the component, when it was last applied.
+	// +optional
+	Digest string `json:"digest,omitempty"`
+	// ChangedRevision is the commit hash the declared content of the component last changed with.
+	// +optional
+	ChangedRevision string `json:"changedRevision,omitempty"`
+	// ChangedTime is the time the changed content of the component was first applied.
+	// +optional
+	ChangedTime *metav1.Time `json:"changedTime,omitempty"`
+	// Message describes why the component could not be applied by the last reconciliation.
+	// +optional
+	Message string `json:"message,omitempty"`
 }
 
 // GitOpsProjectStatus defines the observed state of GitOpsProject
@@ -54,10 +280,106 @@ type GitOpsProjectStatus struct {
 	Revision GitOpsProjectRevision `json:"revision,omitempty"`
 	// +optional
 	Conditions []metav1.Condition `json:"conditions,omitempty"`
+	// +optional
+	Namespaces []GitOpsProjectNamespaceStatus `json:"namespaces,omitempty"`
+	// Components reports the outcome of every component of the last reconciliation, sorted by their id.
+	// +optional
+	Components []GitOpsProjectComponentStatus `json:"components,omitempty"`
+	// LastSkippedAt is the last time a reconciliation was skipped, because the revision did not change.
+	// +optional
+	LastSkippedAt *metav1.Time `json:"lastSkippedAt,omitempty"`
+	// SkippedCount is the number of skipped reconciliations.
+	// +optional
+	SkippedCount int64 `json:"skippedCount,omitempty"`
+	// ConsecutiveFailures is the number of failed reconciliations since the last successful one.
+	// +optional
+	ConsecutiveFailures int64 `json:"consecutiveFailures,omitempty"`
+	// NextRetryAt is the time the next reconciliation is scheduled after a failure.
+	// +optional
+	NextRetryAt *metav1.Time `json:"nextRetryAt,omitempty"`
+	// Phase is the stage the current reconciliation is in, or Idle between reconciliations.
+	// +optional
+	Phase string `json:"phase,omitempty"`
+	// Stages lists the stages of the current or last reconciliation in the order they were entered.
+	// +optional
+	Stages []GitOpsProjectStage `json:"stages,omitempty"`
+	// GitFailures counts failed Git operations by their class.
+	// +optional
+	GitFailures []GitOpsProjectGitFailure `json:"gitFailures,omitempty"`
+	// BuildErrors lists the first CUE errors of the last reconciliation, if it failed to build the project.
+	// +optional
+	BuildErrors []GitOpsProjectBuildError `json:"buildErrors,omitempty"`
+	// PendingPromotions lists the component versions, which have been committed, but are not applied yet.
+	// +optional
+	PendingPromotions []GitOpsProjectPendingPromotion `json:"pendingPromotions,omitempty"`
+	// Ready reports whether the last reconciliation applied all components, either True or False.
+	// +optional
+	Ready string `json:"ready,omitempty"`
+	// Shard is the name of the controller shard, which reconciled the project last.
+	// +optional
+	Shard string `json:"shard,omitempty"`
+}
+
+// GitOpsProjectPendingPromotion is a deferred version of a component.
+type GitOpsProjectPendingPromotion struct {
+	// Component is the id of the deferred component.
+	Component string `json:"component"`
+	// Digest identifies the deferred version of the component.
+	Digest string `json:"digest"`
+	// PromoteAfter is the time the version is applied. Nil for versions, which have to be promoted manually.
+	// +optional
+	PromoteAfter *metav1.Time `json:"promoteAfter,omitempty"`
+}
+
+// GitOpsProjectBuildError is a CUE error of a failed build.
+type GitOpsProjectBuildError struct {
+	// File relative to the repository. Empty for errors without a position.
+	// +optional
+	File string `json:"file,omitempty"`
+	// +optional
+	Line int `json:"line,omitempty"`
+	// +optional
+	Column int `json:"column,omitempty"`
+	// Message is the truncated error message prefixed with the path of the erroneous value.
+	Message string `json:"message"`
+	// Source is the truncated line of the file the error was reported at.
+	// +optional
+	Source string `json:"source,omitempty"`
+}
+
+// GitOpsProjectGitFailure records the failures of one class of Git operations.
+type GitOpsProjectGitFailure struct {
+	// Class of the failure, one of Auth, Network, RefNotFound, Corruption and Unknown.
+	Class string `json:"class"`
+	// Count is the number of failures of this class.
+	Count int64 `json:"count"`
+	// LastOccurredAt is the time of the last failure of this class.
+	LastOccurredAt metav1.Time `json:"lastOccurredAt"`
+	// Message of the last failure of this class.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// GitOpsProjectStage records when a stage of a reconciliation ran.
+type GitOpsProjectStage struct {
+	// Name of the stage, one of Cloning, Building, Pruning and Applying.
+	Name string `json:"name"`
+	// StartedAt is the time the stage was entered.
+	StartedAt metav1.Time `json:"startedAt"`
+	// FinishedAt is the time the stage was left. Nil while the stage is running.
+	// +optional
+	FinishedAt *metav1.Time `json:"finishedAt,omitempty"`
 }
 
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
+// +kubebuilder:resource:shortName=gop
+// +kubebuilder:printcolumn:name="Last Sync",type="date",JSONPath=".status.revision.reconcileTime"
+// +kubebuilder:printcolumn:name="Revision",type="string",JSONPath=".status.revision.commitHash"
+// +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.ready"
+// +kubebuilder:printcolumn:name="Suspended",type="boolean",JSONPath=".spec.suspend"
+// +kubebuilder:printcolumn:name="Shard",type="string",JSONPath=".status.shard"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
 
 // GitOpsProject is the Schema for the gitopsprojects API
 type GitOpsProject struct {
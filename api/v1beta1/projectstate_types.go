@@ -0,0 +1,97 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ProjectStateInventory summarizes the objects and Helm releases managed by a project.
+type ProjectStateInventory struct {
+	// Size is the number of objects and Helm releases in the inventory.
+	Size int `json:"size"`
+
+	// Kinds counts the inventory items by their kind, sorted by kind.
+	// Helm releases are counted as kind HelmRelease.
+	// +optional
+	Kinds []ProjectStateKindCount `json:"kinds,omitempty"`
+}
+
+// ProjectStateKindCount is the number of inventory items of a kind.
+type ProjectStateKindCount struct {
+	Kind  string `json:"kind"`
+	Count int    `json:"count"`
+}
+
+// ProjectStateFailingComponent is a component, which failed to reconcile.
+type ProjectStateFailingComponent struct {
+	ID string `json:"id"`
+
+	// Type is the component type, e.g. Manifest, HelmRelease or the type of a custom component.
+	Type string `json:"type"`
+
+	// Message describes why the component failed.
+	Message string `json:"message"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:shortName=gops
+// +kubebuilder:printcolumn:name="Revision",type="string",JSONPath=".revision"
+// +kubebuilder:printcolumn:name="Inventory",type="integer",JSONPath=".inventory.size"
+// +kubebuilder:printcolumn:name="Reconciled",type="date",JSONPath=".reconcileTime"
+
+// ProjectState is the Schema for the projectstates API.
+// It is a read-only view of the delivery state of the GitOpsProject of the same name,
+// which the controller refreshes on every reconciliation.
+// Granting read access to project states does not require access to the inventory or logs of the controller.
+type ProjectState struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// Revision is the reconciled commit hash.
+	// +optional
+	Revision string `json:"revision,omitempty"`
+
+	// ReconcileTime is the time of the reconciliation.
+	// +optional
+	ReconcileTime metav1.Time `json:"reconcileTime,omitempty"`
+
+	// Inventory summarizes the objects and Helm releases managed by the project after the reconciliation.
+	Inventory ProjectStateInventory `json:"inventory"`
+
+	// FailingComponents are the components, which failed to reconcile, sorted by id.
+	// +optional
+	FailingComponents []ProjectStateFailingComponent `json:"failingComponents,omitempty"`
+
+	// PendingPrunes are the ids of inventory items, which are no longer declared,
+	// but have not been removed from the cluster yet, sorted by id.
+	// +optional
+	PendingPrunes []string `json:"pendingPrunes,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// ProjectStateList contains a list of ProjectState
+type ProjectStateList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ProjectState `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ProjectState{}, &ProjectStateList{})
+}
@@ -23,6 +23,94 @@ import (
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterGitOpsProjectTemplate) DeepCopyInto(out *ClusterGitOpsProjectTemplate) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterGitOpsProjectTemplate.
+func (in *ClusterGitOpsProjectTemplate) DeepCopy() *ClusterGitOpsProjectTemplate {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterGitOpsProjectTemplate)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClusterGitOpsProjectTemplate) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterGitOpsProjectTemplateList) DeepCopyInto(out *ClusterGitOpsProjectTemplateList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ClusterGitOpsProjectTemplate, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterGitOpsProjectTemplateList.
+func (in *ClusterGitOpsProjectTemplateList) DeepCopy() *ClusterGitOpsProjectTemplateList {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterGitOpsProjectTemplateList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClusterGitOpsProjectTemplateList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterGitOpsProjectTemplateSpec) DeepCopyInto(out *ClusterGitOpsProjectTemplateSpec) {
+	*out = *in
+	if in.AllowedURLs != nil {
+		in, out := &in.AllowedURLs, &out.AllowedURLs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.AllowedNamespaces != nil {
+		in, out := &in.AllowedNamespaces, &out.AllowedNamespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ShardAffinity != nil {
+		in, out := &in.ShardAffinity, &out.ShardAffinity
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterGitOpsProjectTemplateSpec.
+func (in *ClusterGitOpsProjectTemplateSpec) DeepCopy() *ClusterGitOpsProjectTemplateSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterGitOpsProjectTemplateSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GitOpsProject) DeepCopyInto(out *GitOpsProject) {
 	*out = *in
@@ -50,6 +138,95 @@ func (in *GitOpsProject) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GitOpsProjectBuildError) DeepCopyInto(out *GitOpsProjectBuildError) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitOpsProjectBuildError.
+func (in *GitOpsProjectBuildError) DeepCopy() *GitOpsProjectBuildError {
+	if in == nil {
+		return nil
+	}
+	out := new(GitOpsProjectBuildError)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GitOpsProjectComponentStatus) DeepCopyInto(out *GitOpsProjectComponentStatus) {
+	*out = *in
+	if in.LastAppliedTime != nil {
+		in, out := &in.LastAppliedTime, &out.LastAppliedTime
+		*out = (*in).DeepCopy()
+	}
+	if in.ChangedTime != nil {
+		in, out := &in.ChangedTime, &out.ChangedTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitOpsProjectComponentStatus.
+func (in *GitOpsProjectComponentStatus) DeepCopy() *GitOpsProjectComponentStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(GitOpsProjectComponentStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GitOpsProjectDeniedKind) DeepCopyInto(out *GitOpsProjectDeniedKind) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitOpsProjectDeniedKind.
+func (in *GitOpsProjectDeniedKind) DeepCopy() *GitOpsProjectDeniedKind {
+	if in == nil {
+		return nil
+	}
+	out := new(GitOpsProjectDeniedKind)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GitOpsProjectGitFailure) DeepCopyInto(out *GitOpsProjectGitFailure) {
+	*out = *in
+	in.LastOccurredAt.DeepCopyInto(&out.LastOccurredAt)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitOpsProjectGitFailure.
+func (in *GitOpsProjectGitFailure) DeepCopy() *GitOpsProjectGitFailure {
+	if in == nil {
+		return nil
+	}
+	out := new(GitOpsProjectGitFailure)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GitOpsProjectGuardrails) DeepCopyInto(out *GitOpsProjectGuardrails) {
+	*out = *in
+	if in.DeniedKinds != nil {
+		in, out := &in.DeniedKinds, &out.DeniedKinds
+		*out = make([]GitOpsProjectDeniedKind, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitOpsProjectGuardrails.
+func (in *GitOpsProjectGuardrails) DeepCopy() *GitOpsProjectGuardrails {
+	if in == nil {
+		return nil
+	}
+	out := new(GitOpsProjectGuardrails)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GitOpsProjectList) DeepCopyInto(out *GitOpsProjectList) {
 	*out = *in
@@ -82,6 +259,100 @@ func (in *GitOpsProjectList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GitOpsProjectNamespaceStatus) DeepCopyInto(out *GitOpsProjectNamespaceStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitOpsProjectNamespaceStatus.
+func (in *GitOpsProjectNamespaceStatus) DeepCopy() *GitOpsProjectNamespaceStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(GitOpsProjectNamespaceStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GitOpsProjectNotification) DeepCopyInto(out *GitOpsProjectNotification) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitOpsProjectNotification.
+func (in *GitOpsProjectNotification) DeepCopy() *GitOpsProjectNotification {
+	if in == nil {
+		return nil
+	}
+	out := new(GitOpsProjectNotification)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GitOpsProjectPendingPromotion) DeepCopyInto(out *GitOpsProjectPendingPromotion) {
+	*out = *in
+	if in.PromoteAfter != nil {
+		in, out := &in.PromoteAfter, &out.PromoteAfter
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitOpsProjectPendingPromotion.
+func (in *GitOpsProjectPendingPromotion) DeepCopy() *GitOpsProjectPendingPromotion {
+	if in == nil {
+		return nil
+	}
+	out := new(GitOpsProjectPendingPromotion)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GitOpsProjectPreviews) DeepCopyInto(out *GitOpsProjectPreviews) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitOpsProjectPreviews.
+func (in *GitOpsProjectPreviews) DeepCopy() *GitOpsProjectPreviews {
+	if in == nil {
+		return nil
+	}
+	out := new(GitOpsProjectPreviews)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GitOpsProjectPromotion) DeepCopyInto(out *GitOpsProjectPromotion) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitOpsProjectPromotion.
+func (in *GitOpsProjectPromotion) DeepCopy() *GitOpsProjectPromotion {
+	if in == nil {
+		return nil
+	}
+	out := new(GitOpsProjectPromotion)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GitOpsProjectRequiredCheck) DeepCopyInto(out *GitOpsProjectRequiredCheck) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitOpsProjectRequiredCheck.
+func (in *GitOpsProjectRequiredCheck) DeepCopy() *GitOpsProjectRequiredCheck {
+	if in == nil {
+		return nil
+	}
+	out := new(GitOpsProjectRequiredCheck)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GitOpsProjectSpec) DeepCopyInto(out *GitOpsProjectSpec) {
 	*out = *in
@@ -90,6 +361,48 @@ func (in *GitOpsProjectSpec) DeepCopyInto(out *GitOpsProjectSpec) {
 		*out = new(bool)
 		**out = **in
 	}
+	if in.Notification != nil {
+		in, out := &in.Notification, &out.Notification
+		*out = new(GitOpsProjectNotification)
+		**out = **in
+	}
+	if in.Suppressions != nil {
+		in, out := &in.Suppressions, &out.Suppressions
+		*out = make([]GitOpsProjectSuppression, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ShardAffinity != nil {
+		in, out := &in.ShardAffinity, &out.ShardAffinity
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Guardrails != nil {
+		in, out := &in.Guardrails, &out.Guardrails
+		*out = new(GitOpsProjectGuardrails)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Promotions != nil {
+		in, out := &in.Promotions, &out.Promotions
+		*out = make([]GitOpsProjectPromotion, len(*in))
+		copy(*out, *in)
+	}
+	if in.Webhook != nil {
+		in, out := &in.Webhook, &out.Webhook
+		*out = new(GitOpsProjectWebhook)
+		**out = **in
+	}
+	if in.RequiredCheck != nil {
+		in, out := &in.RequiredCheck, &out.RequiredCheck
+		*out = new(GitOpsProjectRequiredCheck)
+		**out = **in
+	}
+	if in.Previews != nil {
+		in, out := &in.Previews, &out.Previews
+		*out = new(GitOpsProjectPreviews)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitOpsProjectSpec.
@@ -102,6 +415,46 @@ func (in *GitOpsProjectSpec) DeepCopy() *GitOpsProjectSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GitOpsProjectSuppression) DeepCopyInto(out *GitOpsProjectSuppression) {
+	*out = *in
+	if in.Paths != nil {
+		in, out := &in.Paths, &out.Paths
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitOpsProjectSuppression.
+func (in *GitOpsProjectSuppression) DeepCopy() *GitOpsProjectSuppression {
+	if in == nil {
+		return nil
+	}
+	out := new(GitOpsProjectSuppression)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GitOpsProjectStage) DeepCopyInto(out *GitOpsProjectStage) {
+	*out = *in
+	in.StartedAt.DeepCopyInto(&out.StartedAt)
+	if in.FinishedAt != nil {
+		in, out := &in.FinishedAt, &out.FinishedAt
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitOpsProjectStage.
+func (in *GitOpsProjectStage) DeepCopy() *GitOpsProjectStage {
+	if in == nil {
+		return nil
+	}
+	out := new(GitOpsProjectStage)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GitOpsProjectStatus) DeepCopyInto(out *GitOpsProjectStatus) {
 	*out = *in
@@ -112,6 +465,52 @@ func (in *GitOpsProjectStatus) DeepCopyInto(out *GitOpsProjectStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Namespaces != nil {
+		in, out := &in.Namespaces, &out.Namespaces
+		*out = make([]GitOpsProjectNamespaceStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.Components != nil {
+		in, out := &in.Components, &out.Components
+		*out = make([]GitOpsProjectComponentStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.LastSkippedAt != nil {
+		in, out := &in.LastSkippedAt, &out.LastSkippedAt
+		*out = (*in).DeepCopy()
+	}
+	if in.NextRetryAt != nil {
+		in, out := &in.NextRetryAt, &out.NextRetryAt
+		*out = (*in).DeepCopy()
+	}
+	if in.Stages != nil {
+		in, out := &in.Stages, &out.Stages
+		*out = make([]GitOpsProjectStage, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.GitFailures != nil {
+		in, out := &in.GitFailures, &out.GitFailures
+		*out = make([]GitOpsProjectGitFailure, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.BuildErrors != nil {
+		in, out := &in.BuildErrors, &out.BuildErrors
+		*out = make([]GitOpsProjectBuildError, len(*in))
+		copy(*out, *in)
+	}
+	if in.PendingPromotions != nil {
+		in, out := &in.PendingPromotions, &out.PendingPromotions
+		*out = make([]GitOpsProjectPendingPromotion, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitOpsProjectStatus.
@@ -123,3 +522,137 @@ func (in *GitOpsProjectStatus) DeepCopy() *GitOpsProjectStatus {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GitOpsProjectWebhook) DeepCopyInto(out *GitOpsProjectWebhook) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitOpsProjectWebhook.
+func (in *GitOpsProjectWebhook) DeepCopy() *GitOpsProjectWebhook {
+	if in == nil {
+		return nil
+	}
+	out := new(GitOpsProjectWebhook)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProjectState) DeepCopyInto(out *ProjectState) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.ReconcileTime.DeepCopyInto(&out.ReconcileTime)
+	in.Inventory.DeepCopyInto(&out.Inventory)
+	if in.FailingComponents != nil {
+		in, out := &in.FailingComponents, &out.FailingComponents
+		*out = make([]ProjectStateFailingComponent, len(*in))
+		copy(*out, *in)
+	}
+	if in.PendingPrunes != nil {
+		in, out := &in.PendingPrunes, &out.PendingPrunes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProjectState.
+func (in *ProjectState) DeepCopy() *ProjectState {
+	if in == nil {
+		return nil
+	}
+	out := new(ProjectState)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ProjectState) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProjectStateFailingComponent) DeepCopyInto(out *ProjectStateFailingComponent) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProjectStateFailingComponent.
+func (in *ProjectStateFailingComponent) DeepCopy() *ProjectStateFailingComponent {
+	if in == nil {
+		return nil
+	}
+	out := new(ProjectStateFailingComponent)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProjectStateInventory) DeepCopyInto(out *ProjectStateInventory) {
+	*out = *in
+	if in.Kinds != nil {
+		in, out := &in.Kinds, &out.Kinds
+		*out = make([]ProjectStateKindCount, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProjectStateInventory.
+func (in *ProjectStateInventory) DeepCopy() *ProjectStateInventory {
+	if in == nil {
+		return nil
+	}
+	out := new(ProjectStateInventory)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProjectStateKindCount) DeepCopyInto(out *ProjectStateKindCount) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProjectStateKindCount.
+func (in *ProjectStateKindCount) DeepCopy() *ProjectStateKindCount {
+	if in == nil {
+		return nil
+	}
+	out := new(ProjectStateKindCount)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProjectStateList) DeepCopyInto(out *ProjectStateList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ProjectState, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProjectStateList.
+func (in *ProjectStateList) DeepCopy() *ProjectStateList {
+	if in == nil {
+		return nil
+	}
+	out := new(ProjectStateList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ProjectStateList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
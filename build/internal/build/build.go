@@ -48,7 +48,10 @@ func (g apigen) run(ctx context.Context, request stepRequest) (*stepResult, erro
 		).
 		WithExec([]string{"go", "install", cueDep}).
 		WithExec([]string{controllerGen, "crd", "paths=./api/v1beta1/...", "output:crd:artifacts:config=internal/manifest"}).
-		WithExec([]string{"bin/cue", "import", "-f", "-o", "internal/manifest/crd.cue", "internal/manifest/gitops.declcd.io_gitopsprojects.yaml", "-l", "_crd:", "-p", "declcd"})
+		WithExec([]string{"bin/cue", "import", "-f", "-o", "internal/manifest/crd.cue", "internal/manifest/gitops.declcd.io_gitopsprojects.yaml", "-l", "_crd:", "-p", "declcd"}).
+		// cue import overwrites its output, so the template and state CRDs are appended without their package clause.
+		WithExec([]string{"sh", "-c", "bin/cue import -o - internal/manifest/gitops.declcd.io_clustergitopsprojecttemplates.yaml -l _templateCrd: -p declcd | tail -n +2 >> internal/manifest/crd.cue"}).
+		WithExec([]string{"sh", "-c", "bin/cue import -o - internal/manifest/gitops.declcd.io_projectstates.yaml -l _projectStateCrd: -p declcd | tail -n +2 >> internal/manifest/crd.cue"})
 	_, err := gen.File("internal/manifest/crd.cue").
 		Export(ctx, "internal/manifest/crd.cue", dagger.FileExportOpts{AllowParentDirPath: false})
 	if err != nil {
@@ -0,0 +1,125 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"text/tabwriter"
+	"time"
+
+	gitops "github.com/kharf/declcd/api/v1beta1"
+	"github.com/kharf/declcd/pkg/project"
+	"github.com/spf13/cobra"
+	k8sRuntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/duration"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/config"
+)
+
+type AgeCommandBuilder struct{}
+
+func (builder AgeCommandBuilder) Build() *cobra.Command {
+	var projectName string
+	var namespace string
+	var staleAfter time.Duration
+	cmd := &cobra.Command{
+		Use:   "age",
+		Short: "List the managed components with the revision they last changed with and how long they stayed unchanged",
+		Args:  cobra.NoArgs,
+		RunE: func(cobraCmd *cobra.Command, args []string) error {
+			kubeConfig, err := config.GetConfig()
+			if err != nil {
+				return err
+			}
+			scheme := k8sRuntime.NewScheme()
+			if err := gitops.AddToScheme(scheme); err != nil {
+				return err
+			}
+			kubeClient, err := client.New(kubeConfig, client.Options{Scheme: scheme})
+			if err != nil {
+				return err
+			}
+
+			var projects gitops.GitOpsProjectList
+			if err := kubeClient.List(context.Background(), &projects, client.InNamespace(namespace)); err != nil {
+				return err
+			}
+			if projectName != "" {
+				projects.Items = slices.DeleteFunc(projects.Items, func(gProject gitops.GitOpsProject) bool {
+					return gProject.GetName() != projectName
+				})
+			}
+
+			return writeAgeReport(cobraCmd.OutOrStdout(), projects.Items, time.Now(), staleAfter)
+		},
+	}
+	cmd.Flags().
+		StringVar(&projectName, "project", "", "Name of the GitOpsProject to report. All projects are reported by default")
+	cmd.Flags().
+		StringVarP(&namespace, "namespace", "n", project.ControllerNamespace, "Namespace of the GitOpsProjects")
+	cmd.Flags().
+		DurationVar(&staleAfter, "stale-after", 0, "Only report components, which have been unchanged for at least this duration")
+	return cmd
+}
+
+type componentAge struct {
+	project string
+	status  gitops.GitOpsProjectComponentStatus
+}
+
+// writeAgeReport writes the components of the projects as a table, starting with the longest unchanged component.
+// Components, which have never been applied, are omitted.
+func writeAgeReport(
+	out io.Writer,
+	projects []gitops.GitOpsProject,
+	now time.Time,
+	staleAfter time.Duration,
+) error {
+	ages := make([]componentAge, 0)
+	for _, gProject := range projects {
+		for _, status := range gProject.Status.Components {
+			if status.ChangedTime == nil || now.Sub(status.ChangedTime.Time) < staleAfter {
+				continue
+			}
+			ages = append(ages, componentAge{project: gProject.GetName(), status: status})
+		}
+	}
+	slices.SortStableFunc(ages, func(a, b componentAge) int {
+		return a.status.ChangedTime.Compare(b.status.ChangedTime.Time)
+	})
+
+	writer := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "PROJECT\tCOMPONENT\tTYPE\tCHANGED REVISION\tUNCHANGED FOR\tLAST APPLIED")
+	for _, age := range ages {
+		lastApplied := "<never>"
+		if age.status.LastAppliedTime != nil {
+			lastApplied = duration.HumanDuration(now.Sub(age.status.LastAppliedTime.Time)) + " ago"
+		}
+		fmt.Fprintf(
+			writer,
+			"%s\t%s\t%s\t%s\t%s\t%s\n",
+			age.project,
+			age.status.ID,
+			age.status.Type,
+			shortRevision(age.status.ChangedRevision),
+			duration.HumanDuration(now.Sub(age.status.ChangedTime.Time)),
+			lastApplied,
+		)
+	}
+	return writer.Flush()
+}
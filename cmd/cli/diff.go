@@ -0,0 +1,141 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strings"
+
+	"github.com/go-logr/logr"
+	"github.com/kharf/declcd/pkg/component"
+	"github.com/kharf/declcd/pkg/helm"
+	"github.com/kharf/declcd/pkg/kube"
+	"github.com/kharf/declcd/pkg/project"
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/controller-runtime/pkg/client/config"
+)
+
+const (
+	colorReset = "\033[0m"
+	colorBold  = "\033[1m"
+	colorRed   = "\033[31m"
+	colorGreen = "\033[32m"
+	colorCyan  = "\033[36m"
+)
+
+type DiffCommandBuilder struct{}
+
+func (builder DiffCommandBuilder) Build() *cobra.Command {
+	var shard string
+	var noColor bool
+	cmd := &cobra.Command{
+		Use:   "diff",
+		Short: "Preview the changes a reconciliation of the Declcd Project in the current directory would apply to the cluster",
+		Args:  cobra.NoArgs,
+		RunE: func(cobraCmd *cobra.Command, args []string) error {
+			cwd, err := os.Getwd()
+			if err != nil {
+				return err
+			}
+			projectManager := project.NewManager(
+				component.NewBuilder(),
+				logr.Discard(),
+				runtime.GOMAXPROCS(0),
+			)
+			dag, err := projectManager.Load(cwd)
+			if err != nil {
+				return err
+			}
+			instances, err := dag.TopologicalSort()
+			if err != nil {
+				return err
+			}
+
+			kubeConfig, err := config.GetConfig()
+			if err != nil {
+				return err
+			}
+			client, err := kube.NewDynamicClient(kubeConfig)
+			if err != nil {
+				return err
+			}
+
+			fieldManager := project.ControllerName(shard)
+			planner := project.Planner{
+				Client:       client,
+				FieldManager: fieldManager,
+				ChartReconciler: &helm.ChartReconciler{
+					Log:          logr.Discard(),
+					KubeConfig:   kubeConfig,
+					Client:       client,
+					FieldManager: fieldManager,
+				},
+			}
+			// Without access to the inventory of the controller, removed components are not planned.
+			plan, planErr := planner.Plan(context.Background(), instances, nil)
+			writePlan(cobraCmd.OutOrStdout(), plan, !noColor)
+			return planErr
+		},
+	}
+	cmd.Flags().
+		StringVar(&shard, "shard", "primary", "Instance reconciling the Declcd Project, whose field manager is used for the dry-run")
+	cmd.Flags().
+		BoolVar(&noColor, "no-color", false, "Print the diff without colors")
+	return cmd
+}
+
+// writePlan prints the colored diff of every change.
+func writePlan(out io.Writer, plan *project.Plan, color bool) {
+	write := func(style string, text string) {
+		if !color {
+			fmt.Fprint(out, text)
+			return
+		}
+		// Keep the line break outside of the colored text, so that the reset applies before it.
+		line := strings.TrimSuffix(text, "\n")
+		fmt.Fprint(out, style, line, colorReset, text[len(line):])
+	}
+
+	for _, change := range plan.Changes {
+		write(colorBold, fmt.Sprintf(
+			"%s %s %s/%s\n",
+			change.Component,
+			change.Kind,
+			change.Namespace,
+			change.Name,
+		))
+		for _, line := range difflib.SplitLines(change.Diff) {
+			switch {
+			case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+				write(colorBold, line)
+			case strings.HasPrefix(line, "+"):
+				write(colorGreen, line)
+			case strings.HasPrefix(line, "-"):
+				write(colorRed, line)
+			case strings.HasPrefix(line, "@@"):
+				write(colorCyan, line)
+			default:
+				fmt.Fprint(out, line)
+			}
+		}
+	}
+
+	fmt.Fprintf(out, "%d objects changed\n", len(plan.Changes))
+}
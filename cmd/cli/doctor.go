@@ -0,0 +1,543 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"cuelang.org/go/mod/modfile"
+	gitops "github.com/kharf/declcd/api/v1beta1"
+	"github.com/kharf/declcd/pkg/component"
+	"github.com/kharf/declcd/pkg/project"
+	"github.com/kharf/declcd/pkg/vcs"
+	"github.com/spf13/cobra"
+	"golang.org/x/mod/semver"
+	appsv1 "k8s.io/api/apps/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	k8sRuntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/config"
+)
+
+var (
+	ErrDiagnosticsFailed = errors.New("Diagnostics failed")
+)
+
+const (
+	schemaModule       = "github.com/kharf/declcd/schema@v0"
+	controlPlaneLabel  = "declcd/control-plane"
+	projectCRDName     = "gitopsprojects.gitops.declcd.io"
+	defaultCUERegistry = "registry.cue.works"
+)
+
+type checkStatus string
+
+const (
+	checkOK      checkStatus = "OK"
+	checkWarning checkStatus = "WARN"
+	checkFailed  checkStatus = "FAIL"
+)
+
+// check is the result of a single diagnosis.
+// Hint tells the user how to fix a warning or failure.
+type check struct {
+	Name    string
+	Status  checkStatus
+	Message string
+	Hint    string
+}
+
+type DoctorCommandBuilder struct{}
+
+func (builder DoctorCommandBuilder) Build() *cobra.Command {
+	var timeout time.Duration
+	var namespace string
+	cmd := &cobra.Command{
+		Use:   "doctor",
+		Short: "Diagnose the local environment and the Declcd installation of the current Kubernetes context",
+		Args:  cobra.NoArgs,
+		RunE: func(cobraCmd *cobra.Command, args []string) error {
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+
+			cwd, err := os.Getwd()
+			if err != nil {
+				return err
+			}
+
+			httpClient := &http.Client{Timeout: timeout}
+			checks := []check{
+				checkSchemaVersion(cwd, Version),
+				checkLanguageVersion(cwd),
+			}
+			checks = append(checks, checkCUERegistries(ctx, httpClient, os.Getenv("CUE_REGISTRY"))...)
+			checks = append(checks, checkCluster(ctx, Version, namespace)...)
+
+			return renderChecks(cobraCmd.OutOrStdout(), checks)
+		},
+	}
+	cmd.Flags().
+		DurationVar(&timeout, "timeout", 30*time.Second, "Time all diagnoses are allowed to take")
+	cmd.Flags().
+		StringVarP(&namespace, "namespace", "n", project.ControllerNamespace, "Namespace of the diagnosed Declcd instance")
+	return cmd
+}
+
+// renderChecks writes the results followed by the hints of all unsuccessful checks.
+// It fails if any check failed, so that doctor can be used in scripts.
+func renderChecks(out io.Writer, checks []check) error {
+	writer := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "CHECK\tSTATUS\tDETAILS")
+	failed := false
+	for _, check := range checks {
+		fmt.Fprintf(writer, "%s\t%s\t%s\n", check.Name, check.Status, check.Message)
+		if check.Status == checkFailed {
+			failed = true
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+
+	hints := make([]string, 0, len(checks))
+	for _, check := range checks {
+		if check.Status != checkOK && check.Hint != "" {
+			hints = append(hints, fmt.Sprintf("  %s: %s", check.Name, check.Hint))
+		}
+	}
+	if len(hints) > 0 {
+		fmt.Fprintf(out, "\nRemediation:\n%s\n", strings.Join(hints, "\n"))
+	}
+
+	if failed {
+		return ErrDiagnosticsFailed
+	}
+	return nil
+}
+
+func checkSchemaVersion(projectRoot string, cliVersion string) check {
+	result := check{Name: "schema module"}
+	content, err := os.ReadFile(filepath.Join(projectRoot, "cue.mod", "module.cue"))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			result.Status = checkWarning
+			result.Message = "no cue.mod/module.cue found"
+			result.Hint = "run doctor in the root of a Declcd Project or create one with 'declcd init'"
+			return result
+		}
+		result.Status = checkFailed
+		result.Message = err.Error()
+		return result
+	}
+
+	moduleFile, err := modfile.Parse(content, "module.cue")
+	if err != nil {
+		result.Status = checkFailed
+		result.Message = err.Error()
+		result.Hint = "fix the syntax of cue.mod/module.cue"
+		return result
+	}
+
+	dep, found := moduleFile.Deps[schemaModule]
+	if !found {
+		result.Status = checkFailed
+		result.Message = fmt.Sprintf("%s is not a dependency", schemaModule)
+		result.Hint = fmt.Sprintf("add %s to the deps of cue.mod/module.cue", schemaModule)
+		return result
+	}
+
+	if cliVersion == "" {
+		result.Status = checkOK
+		result.Message = fmt.Sprintf("%s, alignment not checked for development builds", dep.Version)
+		return result
+	}
+
+	expected := "v" + cliVersion
+	if dep.Version != expected {
+		result.Status = checkWarning
+		result.Message = fmt.Sprintf("project uses %s, cli is %s", dep.Version, expected)
+		result.Hint = fmt.Sprintf("set the version of %s in cue.mod/module.cue to %s", schemaModule, expected)
+		return result
+	}
+
+	result.Status = checkOK
+	result.Message = dep.Version
+	return result
+}
+
+func checkLanguageVersion(projectRoot string) check {
+	result := check{Name: "cue language"}
+	version, err := component.LanguageVersion(projectRoot)
+	if err != nil {
+		result.Status = checkFailed
+		result.Message = err.Error()
+		return result
+	}
+	if version == "" {
+		result.Status = checkOK
+		result.Message = "not pinned"
+		return result
+	}
+
+	result.Status = checkOK
+	result.Message = version
+	if !semver.IsValid(version) ||
+		semver.Compare(version, component.MinLanguageVersion) < 0 ||
+		semver.Compare(version, component.MaxLanguageVersion) > 0 {
+		result.Status = checkFailed
+		result.Hint = fmt.Sprintf(
+			"pin a language version from %s to %s in cue.mod/module.cue",
+			component.MinLanguageVersion,
+			component.MaxLanguageVersion,
+		)
+	}
+	return result
+}
+
+// checkCUERegistries probes every registry configured in CUE_REGISTRY.
+// Every response, including unauthorized ones, proves the registry to be reachable.
+func checkCUERegistries(ctx context.Context, httpClient *http.Client, cueRegistry string) []check {
+	if cueRegistry == "" {
+		cueRegistry = defaultCUERegistry
+	}
+
+	var checks []check
+	for _, registry := range parseCUERegistries(cueRegistry) {
+		result := check{Name: fmt.Sprintf("cue registry %s", registry)}
+		scheme := "https"
+		host, insecure := strings.CutSuffix(registry, "+insecure")
+		if insecure {
+			scheme = "http"
+		}
+		host, _ = strings.CutSuffix(host, "+secure")
+		host, _, _ = strings.Cut(host, "/")
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s://%s/v2/", scheme, host), nil)
+		if err != nil {
+			result.Status = checkFailed
+			result.Message = err.Error()
+			checks = append(checks, result)
+			continue
+		}
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			result.Status = checkFailed
+			result.Message = err.Error()
+			result.Hint = "check your network, proxy settings and the CUE_REGISTRY environment variable"
+			checks = append(checks, result)
+			continue
+		}
+		resp.Body.Close()
+
+		result.Status = checkOK
+		result.Message = resp.Status
+		checks = append(checks, result)
+	}
+	return checks
+}
+
+// parseCUERegistries extracts the registries of a CUE_REGISTRY value,
+// which is either a single registry or a comma separated list of module prefixes mapped to registries.
+func parseCUERegistries(cueRegistry string) []string {
+	var registries []string
+	for _, entry := range strings.Split(cueRegistry, ",") {
+		entry = strings.TrimSpace(entry)
+		if _, registry, found := strings.Cut(entry, "="); found {
+			entry = registry
+		}
+		if entry == "" || slices.Contains(registries, entry) {
+			continue
+		}
+		registries = append(registries, entry)
+	}
+	return registries
+}
+
+// checkCluster diagnoses the permissions of the current Kubernetes context and the Declcd installation.
+// Installation checks are skipped, when the cluster cannot be reached.
+func checkCluster(ctx context.Context, cliVersion string, namespace string) []check {
+	kubeContext := check{Name: "kubeconfig"}
+	kubeConfig, err := config.GetConfig()
+	if err != nil {
+		kubeContext.Status = checkFailed
+		kubeContext.Message = err.Error()
+		kubeContext.Hint = "set KUBECONFIG or select a context with 'kubectl config use-context'"
+		return []check{kubeContext}
+	}
+
+	scheme := k8sRuntime.NewScheme()
+	for _, addToScheme := range []func(*k8sRuntime.Scheme) error{
+		gitops.AddToScheme,
+		appsv1.AddToScheme,
+		corev1.AddToScheme,
+		authorizationv1.AddToScheme,
+		apiextensionsv1.AddToScheme,
+	} {
+		if err := addToScheme(scheme); err != nil {
+			kubeContext.Status = checkFailed
+			kubeContext.Message = err.Error()
+			return []check{kubeContext}
+		}
+	}
+	kubeClient, err := client.New(kubeConfig, client.Options{Scheme: scheme})
+	if err != nil {
+		kubeContext.Status = checkFailed
+		kubeContext.Message = err.Error()
+		kubeContext.Hint = "verify the server and credentials of your current context"
+		return []check{kubeContext}
+	}
+	kubeContext.Status = checkOK
+	kubeContext.Message = kubeConfig.Host
+
+	permissions, reachable := checkPermissions(ctx, kubeClient, namespace)
+	if !reachable {
+		kubeContext.Status = checkFailed
+		kubeContext.Hint = "verify that the cluster of your current context is running and reachable"
+		return []check{kubeContext, permissions}
+	}
+
+	return []check{
+		kubeContext,
+		permissions,
+		checkCRD(ctx, kubeClient),
+		checkController(ctx, kubeClient, cliVersion, namespace),
+		checkVolumes(ctx, kubeClient, namespace),
+		checkGitCredentials(ctx, kubeClient, namespace),
+	}
+}
+
+// checkPermissions reviews whether the current context is allowed to install Declcd.
+// It reports whether the cluster could be reached at all.
+func checkPermissions(ctx context.Context, kubeClient client.Client, namespace string) (check, bool) {
+	result := check{Name: "permissions"}
+	required := []authorizationv1.ResourceAttributes{
+		{Verb: "create", Group: "apiextensions.k8s.io", Resource: "customresourcedefinitions"},
+		{Verb: "create", Group: "rbac.authorization.k8s.io", Resource: "clusterroles"},
+		{Verb: "create", Resource: "namespaces"},
+		{Verb: "create", Group: "apps", Resource: "deployments", Namespace: namespace},
+		{Verb: "create", Resource: "secrets", Namespace: namespace},
+		{Verb: "list", Group: gitops.GroupVersion.Group, Resource: "gitopsprojects"},
+	}
+
+	var denied []string
+	for _, attributes := range required {
+		review := &authorizationv1.SelfSubjectAccessReview{
+			Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+				ResourceAttributes: &attributes,
+			},
+		}
+		if err := kubeClient.Create(ctx, review); err != nil {
+			result.Status = checkFailed
+			result.Message = err.Error()
+			return result, false
+		}
+		if !review.Status.Allowed {
+			resource := attributes.Resource
+			if attributes.Group != "" {
+				resource = fmt.Sprintf("%s.%s", resource, attributes.Group)
+			}
+			denied = append(denied, fmt.Sprintf("%s %s", attributes.Verb, resource))
+		}
+	}
+
+	if len(denied) > 0 {
+		result.Status = checkWarning
+		result.Message = fmt.Sprintf("not allowed to %s", strings.Join(denied, ", "))
+		result.Hint = "'declcd install' needs cluster-admin like permissions; switch to a context with more privileges"
+		return result, true
+	}
+
+	result.Status = checkOK
+	result.Message = "allowed to install Declcd"
+	return result, true
+}
+
+func checkCRD(ctx context.Context, kubeClient client.Client) check {
+	result := check{Name: "crd"}
+	var crd apiextensionsv1.CustomResourceDefinition
+	if err := kubeClient.Get(ctx, types.NamespacedName{Name: projectCRDName}, &crd); err != nil {
+		result.Status = checkFailed
+		result.Message = err.Error()
+		if k8sErrors.IsNotFound(err) {
+			result.Message = fmt.Sprintf("%s not installed", projectCRDName)
+			result.Hint = "install Declcd with 'declcd install'"
+		}
+		return result
+	}
+
+	served := make([]string, 0, len(crd.Spec.Versions))
+	for _, version := range crd.Spec.Versions {
+		if version.Served {
+			served = append(served, version.Name)
+		}
+	}
+	if !slices.Contains(served, gitops.GroupVersion.Version) {
+		result.Status = checkFailed
+		result.Message = fmt.Sprintf("serves %s, cli requires %s", strings.Join(served, ", "), gitops.GroupVersion.Version)
+		result.Hint = "upgrade Declcd in the cluster to the version of the cli"
+		return result
+	}
+
+	result.Status = checkOK
+	result.Message = fmt.Sprintf("serves %s", strings.Join(served, ", "))
+	return result
+}
+
+// checkController compares the image version of every controller with the cli version.
+func checkController(ctx context.Context, kubeClient client.Client, cliVersion string, namespace string) check {
+	result := check{Name: "controller"}
+	var deployments appsv1.DeploymentList
+	if err := kubeClient.List(
+		ctx,
+		&deployments,
+		client.InNamespace(namespace),
+		client.HasLabels{controlPlaneLabel},
+	); err != nil {
+		result.Status = checkFailed
+		result.Message = err.Error()
+		return result
+	}
+	if len(deployments.Items) == 0 {
+		result.Status = checkFailed
+		result.Message = fmt.Sprintf("no controller found in %s", namespace)
+		result.Hint = "install Declcd with 'declcd install'"
+		return result
+	}
+
+	result.Status = checkOK
+	versions := make([]string, 0, len(deployments.Items))
+	for _, deployment := range deployments.Items {
+		version := "unknown"
+		for _, container := range deployment.Spec.Template.Spec.Containers {
+			if _, tag, found := strings.Cut(container.Image, "declcd:"); found {
+				version = tag
+			}
+		}
+		versions = append(versions, fmt.Sprintf("%s=%s", deployment.Name, version))
+
+		if deployment.Status.ReadyReplicas == 0 {
+			result.Status = checkFailed
+			result.Hint = fmt.Sprintf(
+				"inspect the controller with 'kubectl -n %s describe deployment %s'",
+				namespace,
+				deployment.Name,
+			)
+			continue
+		}
+		if cliVersion != "" && version != cliVersion && result.Status == checkOK {
+			result.Status = checkWarning
+			result.Hint = "align the cli and controller versions by upgrading the older one"
+		}
+	}
+	result.Message = strings.Join(versions, ", ")
+	return result
+}
+
+// checkVolumes verifies that the volumes of the controllers, which hold repositories and charts, are bound.
+func checkVolumes(ctx context.Context, kubeClient client.Client, namespace string) check {
+	result := check{Name: "volumes"}
+	var claims corev1.PersistentVolumeClaimList
+	if err := kubeClient.List(
+		ctx,
+		&claims,
+		client.InNamespace(namespace),
+		client.HasLabels{controlPlaneLabel},
+	); err != nil {
+		result.Status = checkFailed
+		result.Message = err.Error()
+		return result
+	}
+	if len(claims.Items) == 0 {
+		result.Status = checkWarning
+		result.Message = fmt.Sprintf("no PersistentVolumeClaims found in %s", namespace)
+		result.Hint = "install Declcd with 'declcd install'"
+		return result
+	}
+
+	var unbound []string
+	for _, claim := range claims.Items {
+		if claim.Status.Phase != corev1.ClaimBound {
+			unbound = append(unbound, fmt.Sprintf("%s=%s", claim.Name, claim.Status.Phase))
+		}
+	}
+	if len(unbound) > 0 {
+		result.Status = checkFailed
+		result.Message = fmt.Sprintf("not bound: %s", strings.Join(unbound, ", "))
+		result.Hint = "verify that the cluster has a default StorageClass able to provision ReadWriteOnce volumes"
+		return result
+	}
+
+	result.Status = checkOK
+	result.Message = fmt.Sprintf("%d bound", len(claims.Items))
+	return result
+}
+
+// checkGitCredentials verifies that every GitOps Project has the deploy key the controller pulls its repository with.
+func checkGitCredentials(ctx context.Context, kubeClient client.Client, namespace string) check {
+	result := check{Name: "git credentials"}
+	var projects gitops.GitOpsProjectList
+	if err := kubeClient.List(ctx, &projects, client.InNamespace(namespace)); err != nil {
+		result.Status = checkFailed
+		result.Message = err.Error()
+		return result
+	}
+
+	var missing []string
+	for _, gProject := range projects.Items {
+		var secret corev1.Secret
+		err := kubeClient.Get(
+			ctx,
+			types.NamespacedName{Name: vcs.SecretName(gProject.Name), Namespace: namespace},
+			&secret,
+		)
+		if err != nil {
+			if !k8sErrors.IsNotFound(err) {
+				result.Status = checkFailed
+				result.Message = err.Error()
+				return result
+			}
+			missing = append(missing, gProject.Name)
+			continue
+		}
+		if len(secret.Data[vcs.SSHKey]) == 0 {
+			missing = append(missing, gProject.Name)
+		}
+	}
+	if len(missing) > 0 {
+		result.Status = checkFailed
+		result.Message = fmt.Sprintf("no deploy key for %s", strings.Join(missing, ", "))
+		result.Hint = "run 'declcd install' with an access token to create the deploy keys"
+		return result
+	}
+
+	result.Status = checkOK
+	result.Message = fmt.Sprintf("%d projects", len(projects.Items))
+	return result
+}
@@ -0,0 +1,55 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"os"
+	"runtime"
+
+	"github.com/go-logr/logr"
+	"github.com/kharf/declcd/pkg/component"
+	"github.com/kharf/declcd/pkg/project"
+	"github.com/spf13/cobra"
+)
+
+type GraphCommandBuilder struct{}
+
+func (builder GraphCommandBuilder) Build() *cobra.Command {
+	var format string
+	cmd := &cobra.Command{
+		Use:   "graph",
+		Short: "Print the component dependency graph of the Declcd Project in the current directory",
+		Args:  cobra.NoArgs,
+		RunE: func(cobraCmd *cobra.Command, args []string) error {
+			cwd, err := os.Getwd()
+			if err != nil {
+				return err
+			}
+			projectManager := project.NewManager(
+				component.NewBuilder(),
+				logr.Discard(),
+				runtime.GOMAXPROCS(0),
+			)
+			dag, err := projectManager.Load(cwd)
+			if err != nil {
+				return err
+			}
+			return project.WriteGraph(cobraCmd.OutOrStdout(), dag, project.GraphFormat(format))
+		},
+	}
+	cmd.Flags().
+		StringVarP(&format, "format", "f", string(project.GraphDOT), "Output format of the graph: dot, mermaid or json")
+	return cmd
+}
@@ -0,0 +1,247 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	gitops "github.com/kharf/declcd/api/v1beta1"
+	"github.com/kharf/declcd/pkg/inventory"
+	"github.com/kharf/declcd/pkg/project"
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	k8sRuntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/config"
+	"sigs.k8s.io/yaml"
+)
+
+var (
+	ErrUnknownInventoryFormat = errors.New("Unknown inventory format")
+)
+
+// inventoryEncryptionSecret is the Secret holding the key, which encrypts the inventory at rest.
+const inventoryEncryptionSecret = "inventory-encryption"
+
+type InventoryCommandBuilder struct{}
+
+func (builder InventoryCommandBuilder) Build() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "inventory",
+		Short: "Export and import the inventory of a GitOpsProject, e.g. to move it to another cluster without orphaning its objects",
+	}
+	cmd.AddCommand(builder.buildExport())
+	cmd.AddCommand(builder.buildImport())
+	return cmd
+}
+
+// inventoryFlags locate the inventory of a project.
+type inventoryFlags struct {
+	projectName         string
+	namespace           string
+	controllerNamespace string
+	path                string
+	keyPath             string
+}
+
+func (flags *inventoryFlags) register(cmd *cobra.Command) {
+	cmd.Flags().
+		StringVar(&flags.projectName, "project", "", "Name of the GitOpsProject owning the inventory")
+	cmd.Flags().
+		StringVarP(&flags.namespace, "namespace", "n", project.ControllerNamespace, "Namespace of the GitOpsProject")
+	cmd.Flags().
+		StringVar(&flags.controllerNamespace, "controller-namespace", project.ControllerNamespace, "Namespace of the controller storing the inventory in Secrets")
+	cmd.Flags().
+		StringVar(&flags.path, "path", "", "Directory holding the inventory of the project, e.g. copied from the /inventory volume of the controller. Defaults to the Secrets of the controller")
+	cmd.Flags().
+		StringVar(&flags.keyPath, "key-path", "", "File holding the base64 encoded AES-256 key, which encrypts the inventory. Defaults to the inventory-encryption Secret of the controller")
+	_ = cmd.MarkFlagRequired("project")
+}
+
+// instance resolves the inventory of the project.
+// The cluster of the current kube context is only accessed for inventories or keys stored in Secrets.
+func (flags *inventoryFlags) instance(ctx context.Context) (*inventory.Instance, error) {
+	var kubeClient client.Client
+	if flags.path == "" || flags.keyPath == "" {
+		kubeConfig, err := config.GetConfig()
+		if err != nil {
+			return nil, err
+		}
+		scheme := k8sRuntime.NewScheme()
+		if err := gitops.AddToScheme(scheme); err != nil {
+			return nil, err
+		}
+		if err := corev1.AddToScheme(scheme); err != nil {
+			return nil, err
+		}
+		kubeClient, err = client.New(kubeConfig, client.Options{Scheme: scheme})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	instance := &inventory.Instance{
+		Path: flags.path,
+	}
+	var err error
+	if flags.keyPath != "" {
+		instance.EncryptionKey, err = inventory.ReadEncryptionKey(flags.keyPath)
+	} else {
+		instance.EncryptionKey, err = readEncryptionKey(ctx, kubeClient, flags.controllerNamespace)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if flags.path != "" {
+		return instance, nil
+	}
+	// Inventories in Secrets are named by the UID of their project, which differs between clusters.
+	var gProject gitops.GitOpsProject
+	if err := kubeClient.Get(
+		ctx,
+		types.NamespacedName{Name: flags.projectName, Namespace: flags.namespace},
+		&gProject,
+	); err != nil {
+		return nil, err
+	}
+	instance.Backend = &inventory.SecretBackend{
+		Client:    kubeClient,
+		Namespace: flags.controllerNamespace,
+		Name:      string(gProject.GetUID()),
+	}
+	return instance, nil
+}
+
+// readEncryptionKey reads the inventory encryption key of the controller.
+// A missing Secret disables encryption and results in a nil key.
+func readEncryptionKey(ctx context.Context, kubeClient client.Client, controllerNamespace string) ([]byte, error) {
+	var secret corev1.Secret
+	if err := kubeClient.Get(
+		ctx,
+		types.NamespacedName{Name: inventoryEncryptionSecret, Namespace: controllerNamespace},
+		&secret,
+	); err != nil {
+		if k8sErrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return inventory.ParseEncryptionKey(secret.Data["key"])
+}
+
+func (builder InventoryCommandBuilder) buildExport() *cobra.Command {
+	var flags inventoryFlags
+	var format string
+	var output string
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export the inventory of a GitOpsProject with the decrypted content of its items",
+		Long: `Export the inventory of a GitOpsProject with the decrypted content of its items.
+The export contains the stored objects including Secrets in plaintext and has to be handled as confidential.`,
+		Args: cobra.NoArgs,
+		RunE: func(cobraCmd *cobra.Command, args []string) error {
+			ctx := context.Background()
+			instance, err := flags.instance(ctx)
+			if err != nil {
+				return err
+			}
+			exported, err := instance.Export()
+			if err != nil {
+				return err
+			}
+
+			var data []byte
+			switch format {
+			case "yaml":
+				data, err = yaml.Marshal(exported)
+			case "json":
+				data, err = json.MarshalIndent(exported, "", "  ")
+				data = append(data, '\n')
+			default:
+				return fmt.Errorf("%w: %s", ErrUnknownInventoryFormat, format)
+			}
+			if err != nil {
+				return err
+			}
+
+			if output == "" {
+				_, err := cobraCmd.OutOrStdout().Write(data)
+				return err
+			}
+			return os.WriteFile(output, data, 0600)
+		},
+	}
+	flags.register(cmd)
+	cmd.Flags().
+		StringVarP(&format, "format", "f", "yaml", "Format of the export: yaml or json")
+	cmd.Flags().
+		StringVarP(&output, "output", "o", "", "File the export is written to. Defaults to stdout")
+	return cmd
+}
+
+func (builder InventoryCommandBuilder) buildImport() *cobra.Command {
+	var flags inventoryFlags
+	var input string
+	cmd := &cobra.Command{
+		Use:   "import",
+		Short: "Import an exported inventory into the inventory of a GitOpsProject",
+		Long: `Import an exported inventory into the inventory of a GitOpsProject.
+Items with the same id are replaced and all other items of the inventory are kept.
+Import the inventory before the controller reconciles the project for the first time,
+so that objects, which are no longer declared, are pruned instead of orphaned.`,
+		Args: cobra.NoArgs,
+		RunE: func(cobraCmd *cobra.Command, args []string) error {
+			var data []byte
+			var err error
+			if input == "-" {
+				data, err = io.ReadAll(cobraCmd.InOrStdin())
+			} else {
+				data, err = os.ReadFile(input)
+			}
+			if err != nil {
+				return err
+			}
+			// JSON is valid YAML.
+			var exported inventory.Export
+			if err := yaml.Unmarshal(data, &exported); err != nil {
+				return err
+			}
+
+			ctx := context.Background()
+			instance, err := flags.instance(ctx)
+			if err != nil {
+				return err
+			}
+			if err := instance.Import(&exported); err != nil {
+				return err
+			}
+
+			fmt.Fprintf(cobraCmd.OutOrStdout(), "Imported %d items\n", len(exported.Items))
+			return nil
+		},
+	}
+	flags.register(cmd)
+	cmd.Flags().
+		StringVarP(&input, "input", "i", "-", "File the export is read from. Defaults to stdin")
+	return cmd
+}
@@ -16,16 +16,24 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
 	"runtime"
+	"text/tabwriter"
 
 	"github.com/go-logr/logr"
+	"github.com/kharf/declcd/pkg/audit"
 	"github.com/kharf/declcd/pkg/component"
+	"github.com/kharf/declcd/pkg/helm"
 	"github.com/kharf/declcd/pkg/kube"
 	"github.com/kharf/declcd/pkg/project"
+	"github.com/kharf/declcd/pkg/rbac"
 	"github.com/spf13/cobra"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/config"
 )
 
@@ -42,10 +50,24 @@ func main() {
 }
 
 type RootCommandBuilder struct {
-	initCommandBuilder    InitCommandBuilder
-	verifyCommandBuilder  VerifyCommandBuilder
-	versionCommandBuilder VersionCommandBuilder
-	installCommandBuilder InstallCommandBuilder
+	initCommandBuilder      InitCommandBuilder
+	verifyCommandBuilder    VerifyCommandBuilder
+	versionCommandBuilder   VersionCommandBuilder
+	installCommandBuilder   InstallCommandBuilder
+	rbacCommandBuilder      RBACCommandBuilder
+	inspectCommandBuilder   InspectCommandBuilder
+	buildCommandBuilder     BuildCommandBuilder
+	uiCommandBuilder        UICommandBuilder
+	doctorCommandBuilder    DoctorCommandBuilder
+	diffCommandBuilder      DiffCommandBuilder
+	promoteCommandBuilder   PromoteCommandBuilder
+	ageCommandBuilder       AgeCommandBuilder
+	graphCommandBuilder     GraphCommandBuilder
+	mirrorCommandBuilder    MirrorCommandBuilder
+	publishCommandBuilder   PublishCommandBuilder
+	smokeTestCommandBuilder SmokeTestCommandBuilder
+	inventoryCommandBuilder InventoryCommandBuilder
+	pruneCommandBuilder     PruneCommandBuilder
 }
 
 func (builder RootCommandBuilder) Build() *cobra.Command {
@@ -57,6 +79,20 @@ func (builder RootCommandBuilder) Build() *cobra.Command {
 	rootCmd.AddCommand(builder.verifyCommandBuilder.Build())
 	rootCmd.AddCommand(builder.versionCommandBuilder.Build())
 	rootCmd.AddCommand(builder.installCommandBuilder.Build())
+	rootCmd.AddCommand(builder.rbacCommandBuilder.Build())
+	rootCmd.AddCommand(builder.inspectCommandBuilder.Build())
+	rootCmd.AddCommand(builder.buildCommandBuilder.Build())
+	rootCmd.AddCommand(builder.uiCommandBuilder.Build())
+	rootCmd.AddCommand(builder.doctorCommandBuilder.Build())
+	rootCmd.AddCommand(builder.diffCommandBuilder.Build())
+	rootCmd.AddCommand(builder.promoteCommandBuilder.Build())
+	rootCmd.AddCommand(builder.ageCommandBuilder.Build())
+	rootCmd.AddCommand(builder.graphCommandBuilder.Build())
+	rootCmd.AddCommand(builder.mirrorCommandBuilder.Build())
+	rootCmd.AddCommand(builder.publishCommandBuilder.Build())
+	rootCmd.AddCommand(builder.smokeTestCommandBuilder.Build())
+	rootCmd.AddCommand(builder.inventoryCommandBuilder.Build())
+	rootCmd.AddCommand(builder.pruneCommandBuilder.Build())
 	return &rootCmd
 }
 
@@ -65,6 +101,13 @@ type InitCommandBuilder struct{}
 func (builder InitCommandBuilder) Build() *cobra.Command {
 	var shard string
 	var isSecondary bool
+	var metricsSecure bool
+	var certManagerClusterIssuer string
+	var imageRegistry string
+	var imagePullSecrets []string
+	var namespace string
+	var skipCRD bool
+	var cueRegistry string
 	cmd := &cobra.Command{
 		Use:   "init",
 		Short: "Init a Declcd Project in the current directory",
@@ -80,6 +123,13 @@ func (builder InitCommandBuilder) Build() *cobra.Command {
 				isSecondary,
 				cwd,
 				Version,
+				project.MetricsSecure(metricsSecure),
+				project.CertManagerClusterIssuer(certManagerClusterIssuer),
+				project.ImageRegistry(imageRegistry),
+				project.ImagePullSecrets(imagePullSecrets),
+				project.Namespace(namespace),
+				project.SkipCRD(skipCRD),
+				project.CUERegistry(cueRegistry),
 			)
 		},
 	}
@@ -87,6 +137,20 @@ func (builder InitCommandBuilder) Build() *cobra.Command {
 		StringVar(&shard, "shard", "primary", "Instance of the Declcd Project")
 	cmd.Flags().
 		BoolVar(&isSecondary, "secondary", false, "Indicates a secondary Declcd instance")
+	cmd.Flags().
+		BoolVar(&metricsSecure, "metrics-secure", false, "Serve controller metrics via https with a self-signed certificate")
+	cmd.Flags().
+		StringVar(&certManagerClusterIssuer, "cert-manager-cluster-issuer", "", "cert-manager ClusterIssuer used to issue the controller metrics serving certificate")
+	cmd.Flags().
+		StringVar(&imageRegistry, "image-registry", project.DefaultImageRegistry, "Registry the controller image is pulled from")
+	cmd.Flags().
+		StringSliceVar(&imagePullSecrets, "image-pull-secret", nil, "Secret in the controller namespace used to pull the controller image. Can be repeated")
+	cmd.Flags().
+		StringVarP(&namespace, "namespace", "n", project.ControllerNamespace, "Namespace of the Declcd instance. Instances outside of the default namespace only reconcile projects in their own namespace")
+	cmd.Flags().
+		BoolVar(&skipCRD, "skip-crd", false, "Leave the GitOpsProject CRD to another Declcd instance on the cluster, which owns it")
+	cmd.Flags().
+		StringVar(&cueRegistry, "cue-registry", project.DefaultCUERegistry, "CUE registry configuration the controller resolves CUE modules with, e.g. a mirror created by 'declcd mirror cue-modules'")
 	return cmd
 }
 
@@ -114,18 +178,241 @@ func (builder VerifyCommandBuilder) Build() *cobra.Command {
 	return cmd
 }
 
+type BuildCommandBuilder struct{}
+
+func (builder BuildCommandBuilder) Build() *cobra.Command {
+	var output string
+	cmd := &cobra.Command{
+		Use:   "build",
+		Short: "Compile the Declcd Project in the current directory to a component artifact, which the controller can consume without compiling CUE",
+		Args:  cobra.MinimumNArgs(0),
+		RunE: func(cobraCmd *cobra.Command, args []string) error {
+			cwd, err := os.Getwd()
+			if err != nil {
+				return err
+			}
+			// Secrets stay encrypted in the artifact and are decrypted by the controller.
+			componentBuilder := component.NewBuilder()
+			componentBuilder.KeepEncrypted = true
+			projectManager := project.NewManager(
+				componentBuilder,
+				logr.Discard(),
+				runtime.GOMAXPROCS(0),
+			)
+			dag, err := projectManager.Load(cwd)
+			if err != nil {
+				return err
+			}
+			instances, err := dag.TopologicalSort()
+			if err != nil {
+				return err
+			}
+			content, err := json.Marshal(component.BuildResult{
+				Instances: instances,
+			})
+			if err != nil {
+				return err
+			}
+			if output == "" {
+				_, err = cobraCmd.OutOrStdout().Write(content)
+				return err
+			}
+			return os.WriteFile(output, content, 0666)
+		},
+	}
+	cmd.Flags().
+		StringVarP(&output, "output", "o", "", "File the artifact is written to. Defaults to stdout")
+	return cmd
+}
+
+type RBACCommandBuilder struct{}
+
+func (builder RBACCommandBuilder) Build() *cobra.Command {
+	var serviceAccount string
+	var namespace string
+	var pkg string
+	cmd := &cobra.Command{
+		Use:   "rbac",
+		Short: "Generate the minimal RBAC objects needed by the impersonated ServiceAccount of the Declcd Project in the current directory",
+		Long: `Generate the minimal RBAC objects needed by the impersonated ServiceAccount of the Declcd Project in the current directory.
+The scope of objects is resolved with the current cluster or the CustomResourceDefinitions declared by the project.
+HelmReleases are only granted rights in their release namespace.
+Charts containing cluster scoped objects need additional clusterRules in the generated policy.`,
+		Args: cobra.MinimumNArgs(0),
+		RunE: func(cobraCmd *cobra.Command, args []string) error {
+			cwd, err := os.Getwd()
+			if err != nil {
+				return err
+			}
+			projectManager := project.NewManager(
+				component.NewBuilder(),
+				logr.Discard(),
+				runtime.GOMAXPROCS(0),
+			)
+			dag, err := projectManager.Load(cwd)
+			if err != nil {
+				return err
+			}
+			instances, err := dag.TopologicalSort()
+			if err != nil {
+				return err
+			}
+			kubeConfig, err := config.GetConfig()
+			if err != nil {
+				return err
+			}
+			kubeClient, err := client.New(kubeConfig, client.Options{})
+			if err != nil {
+				return err
+			}
+			policy, err := rbac.Generate(instances, kubeClient.RESTMapper())
+			if err != nil {
+				return err
+			}
+			content, err := policy.CUE(pkg, rbac.ServiceAccount{
+				Name:      serviceAccount,
+				Namespace: namespace,
+			})
+			if err != nil {
+				return err
+			}
+			_, err = cobraCmd.OutOrStdout().Write(content)
+			return err
+		},
+	}
+	cmd.Flags().
+		StringVar(&serviceAccount, "service-account", "", "Name of the ServiceAccount impersonated by the GitOps Project")
+	cmd.Flags().
+		StringVarP(&namespace, "namespace", "n", project.ControllerNamespace, "Namespace of the impersonated ServiceAccount")
+	cmd.Flags().
+		StringVar(&pkg, "package", "rbac", "CUE package of the generated components")
+
+	_ = cmd.MarkFlagRequired("service-account")
+	return cmd
+}
+
+var (
+	ErrReleaseNotFound = errors.New("HelmRelease component not found")
+)
+
+type InspectCommandBuilder struct{}
+
+func (builder InspectCommandBuilder) Build() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "inspect",
+		Short: "Inspect components of the Declcd Project in the current directory",
+	}
+	cmd.AddCommand(builder.buildValues())
+	return cmd
+}
+
+func (builder InspectCommandBuilder) buildValues() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "values <component-id>",
+		Short: "Show which effective values of a HelmRelease are set by the chart defaults, valuesFrom references or the declaration",
+		Long: `Show which effective values of a HelmRelease are set by the chart defaults, valuesFrom references or the declaration.
+Values read from Secrets are redacted.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cobraCmd *cobra.Command, args []string) error {
+			cwd, err := os.Getwd()
+			if err != nil {
+				return err
+			}
+			projectManager := project.NewManager(
+				component.NewBuilder(),
+				logr.Discard(),
+				runtime.GOMAXPROCS(0),
+			)
+			dag, err := projectManager.Load(cwd)
+			if err != nil {
+				return err
+			}
+			releaseComponent, ok := dag.Get(args[0]).(*helm.ReleaseComponent)
+			if !ok {
+				return fmt.Errorf("%w: %s", ErrReleaseNotFound, args[0])
+			}
+
+			chartReconciler := helm.ChartReconciler{
+				Log: logr.Discard(),
+			}
+			auth := releaseComponent.Content.Chart.Auth
+			if (auth != nil && auth.WorkloadIdentity == nil) || len(releaseComponent.Content.ValuesFrom) != 0 {
+				// credentials and valuesFrom references are read from the cluster.
+				kubeConfig, err := config.GetConfig()
+				if err != nil {
+					return err
+				}
+				client, err := kube.NewDynamicClient(kubeConfig)
+				if err != nil {
+					return err
+				}
+				chartReconciler.Client = client
+			}
+
+			origins, err := chartReconciler.ValuesProvenance(context.Background(), releaseComponent.Content)
+			if err != nil {
+				return err
+			}
+
+			writer := tabwriter.NewWriter(cobraCmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+			fmt.Fprintln(writer, "PATH\tSOURCE\tOVERRIDES\tVALUE")
+			for _, origin := range origins {
+				if origin.Reference != nil && origin.Reference.Kind == "Secret" {
+					origin.Value = audit.RedactedValue
+				}
+				value, err := json.Marshal(origin.Value)
+				if err != nil {
+					return err
+				}
+				source := string(origin.Source)
+				if origin.Reference != nil {
+					source = fmt.Sprintf(
+						"%s(%s %s/%s:%s)",
+						origin.Source,
+						origin.Reference.Kind,
+						origin.Reference.Namespace,
+						origin.Reference.Name,
+						origin.Reference.Key,
+					)
+				}
+				fmt.Fprintf(writer, "%s\t%s\t%t\t%s\n", origin.Path, source, origin.Overrides, value)
+			}
+			return writer.Flush()
+		},
+	}
+	return cmd
+}
+
 type VersionCommandBuilder struct{}
 
 func (builder VersionCommandBuilder) Build() *cobra.Command {
+	var remote bool
+	var namespace string
 	cmd := &cobra.Command{
 		Use:   "version",
 		Short: "Print declcd version",
 		Args:  cobra.MinimumNArgs(0),
 		RunE: func(cobraCmd *cobra.Command, args []string) error {
 			fmt.Printf("declcd v%s\non %s_%s\n", Version, OS, Arch)
-			return nil
+			if !remote {
+				return nil
+			}
+			kubeConfig, err := config.GetConfig()
+			if err != nil {
+				return err
+			}
+			clientset, err := kubernetes.NewForConfig(kubeConfig)
+			if err != nil {
+				return err
+			}
+			fmt.Println()
+			return printControllerVersions(context.Background(), cobraCmd.OutOrStdout(), clientset, namespace, Version)
 		},
 	}
+	cmd.Flags().
+		BoolVar(&remote, "remote", false, "Also print the build info of every controller of the current Kubernetes context")
+	cmd.Flags().
+		StringVarP(&namespace, "namespace", "n", project.ControllerNamespace, "Namespace of the Declcd instance")
 	return cmd
 }
 
@@ -139,33 +426,67 @@ func (builder InstallCommandBuilder) Build() *cobra.Command {
 	var token string
 	var interval int
 	var shard string
+	var dryRun bool
+	var sshKeyFile string
+	var sshKnownHostsFile string
+	var namespace string
 	cmd := &cobra.Command{
 		Use:   "install",
 		Short: "Install Declcd on a Kubernetes Cluster",
 		Args:  cobra.MinimumNArgs(0),
 		RunE: func(cobraCmd *cobra.Command, args []string) error {
-			kubeConfig, err := config.GetConfig()
-			if err != nil {
-				return err
-			}
-			client, err := kube.NewDynamicClient(kubeConfig)
-			if err != nil {
-				return err
+			var client *kube.DynamicClient
+			if !dryRun {
+				kubeConfig, err := config.GetConfig()
+				if err != nil {
+					return err
+				}
+				client, err = kube.NewDynamicClient(kubeConfig)
+				if err != nil {
+					return err
+				}
 			}
 			wd, err := os.Getwd()
 			if err != nil {
 				return err
 			}
+			var sshKey []byte
+			if sshKeyFile != "" {
+				sshKey, err = os.ReadFile(sshKeyFile)
+				if err != nil {
+					return err
+				}
+			}
+			var sshKnownHosts []byte
+			if sshKnownHostsFile != "" {
+				sshKnownHosts, err = os.ReadFile(sshKnownHostsFile)
+				if err != nil {
+					return err
+				}
+			}
 			httpClient := http.DefaultClient
 			action := project.NewInstallAction(client, httpClient, wd)
+			var progress func(project.InstallProgress)
+			if !dryRun {
+				printer := newInstallProgressPrinter(cobraCmd.ErrOrStderr())
+				defer printer.Summary()
+				progress = printer.Report
+			}
 			if err := action.Install(ctx,
 				project.InstallOptions{
-					Url:      url,
-					Branch:   branch,
-					Name:     name,
-					Interval: interval,
-					Token:    token,
-					Shard:    shard,
+					Url:              url,
+					Branch:           branch,
+					Name:             name,
+					Interval:         interval,
+					Token:            token,
+					Shard:            shard,
+					Namespace:        namespace,
+					SSHKey:           sshKey,
+					SSHKeyPassphrase: []byte(os.Getenv("DECLCD_SSH_KEY_PASSPHRASE")),
+					SSHKnownHosts:    sshKnownHosts,
+					DryRun:           dryRun,
+					Output:           cobraCmd.OutOrStdout(),
+					Progress:         progress,
 				},
 			); err != nil {
 				return err
@@ -183,6 +504,15 @@ func (builder InstallCommandBuilder) Build() *cobra.Command {
 		IntVarP(&interval, "interval", "i", 30, "Definition of how often Declcd will reconcile its cluster state. Value is defined in seconds")
 	cmd.Flags().
 		StringVar(&shard, "shard", "primary", "Instance associated with the Declcd Project")
+	cmd.Flags().
+		StringVarP(&namespace, "namespace", "n", project.ControllerNamespace, "Namespace of the Declcd instance, which has been passed to init")
+	cmd.Flags().
+		BoolVar(&dryRun, "dry-run", false, "Print all manifests the installation would apply to stdout without touching the cluster. The generated deploy key has to be registered at the Git provider manually")
+
+	cmd.Flags().
+		StringVar(&sshKeyFile, "ssh-key-file", "", "Private key used for authentication instead of registering a generated deploy key. A passphrase protected key is decrypted with the DECLCD_SSH_KEY_PASSPHRASE environment variable")
+	cmd.Flags().
+		StringVar(&sshKnownHostsFile, "ssh-known-hosts-file", "", "known_hosts file the host key of the Git server is verified against")
 
 	_ = cmd.MarkFlagRequired("name")
 	_ = cmd.MarkFlagRequired("url")
@@ -0,0 +1,157 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"cuelang.org/go/mod/modfile"
+	"cuelang.org/go/mod/module"
+	"github.com/kharf/declcd/pkg/mirror"
+	"github.com/kharf/declcd/pkg/project"
+	"github.com/spf13/cobra"
+)
+
+type MirrorCommandBuilder struct{}
+
+func (builder MirrorCommandBuilder) Build() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "mirror",
+		Short: "Mirror artifacts Declcd depends on into internal registries, e.g. for air-gapped environments",
+	}
+	cmd.AddCommand(builder.buildCUEModules())
+	return cmd
+}
+
+func (builder MirrorCommandBuilder) buildCUEModules() *cobra.Command {
+	var from string
+	var to string
+	var modules []string
+	var allVersions bool
+	cmd := &cobra.Command{
+		Use:   "cue-modules",
+		Short: "Copy the CUE modules required by the Declcd Project in the current directory, including their dependencies, into another registry",
+		Long: `Copy the CUE modules required by the Declcd Project in the current directory, including their dependencies, into another registry.
+Without a project, the declcd schema module matching the version of this CLI is copied.
+Registries are configured like CUE_REGISTRY, e.g. registry.internal/cue or registry.internal/cue+insecure for plain http.
+Afterwards every copied module is resolved via the target registry to validate the CUE_REGISTRY configuration pointing to it.`,
+		Args: cobra.MinimumNArgs(0),
+		RunE: func(cobraCmd *cobra.Command, args []string) error {
+			fromClient, err := mirror.NewModuleClient(from)
+			if err != nil {
+				return err
+			}
+			toClient, err := mirror.NewModuleClient(to)
+			if err != nil {
+				return err
+			}
+			moduleMirror := mirror.ModuleMirror{
+				From: fromClient,
+				To:   toClient,
+			}
+
+			ctx := context.Background()
+			versions, err := requiredModules(modules)
+			if err != nil {
+				return err
+			}
+			if allVersions {
+				paths := make(map[string]struct{}, len(versions))
+				for _, version := range versions {
+					paths[version.Path()] = struct{}{}
+				}
+				for path := range paths {
+					published, err := moduleMirror.AllVersions(ctx, path)
+					if err != nil {
+						return err
+					}
+					versions = append(versions, published...)
+				}
+			}
+
+			mirrored, err := moduleMirror.Mirror(ctx, versions)
+			out := cobraCmd.OutOrStdout()
+			for _, mirroredModule := range mirrored {
+				state := "copied"
+				if mirroredModule.Existing {
+					state = "exists"
+				}
+				fmt.Fprintf(out, "%s %s\n", mirroredModule.Version, state)
+			}
+			if err != nil {
+				return err
+			}
+
+			verified := make([]module.Version, 0, len(mirrored))
+			for _, mirroredModule := range mirrored {
+				verified = append(verified, mirroredModule.Version)
+			}
+			if err := mirror.Verify(ctx, toClient, verified); err != nil {
+				return err
+			}
+			fmt.Fprintf(out, "\nResolve the modules via the mirror with:\n  export CUE_REGISTRY=%s\n", to)
+			fmt.Fprintf(out, "and point the controller to it with 'declcd init --cue-registry=%s'.\n", to)
+			return nil
+		},
+	}
+	cmd.Flags().
+		StringVar(&from, "from", project.DefaultCUERegistry, "CUE registry configuration the modules are copied from")
+	cmd.Flags().
+		StringVar(&to, "to", "", "CUE registry configuration the modules are copied to, e.g. registry.internal/cue")
+	cmd.Flags().
+		StringSliceVar(&modules, "module", nil, "Module version to copy instead of the project requirements, e.g. github.com/kharf/declcd/schema@v0.9.1. Can be repeated")
+	cmd.Flags().
+		BoolVar(&allVersions, "all-versions", false, "Copy every published version of the required modules")
+	_ = cmd.MarkFlagRequired("to")
+	return cmd
+}
+
+// requiredModules returns the given module versions,
+// the dependencies of the project in the current directory or the declcd schema matching this CLI.
+func requiredModules(modules []string) ([]module.Version, error) {
+	if len(modules) != 0 {
+		versions := make([]module.Version, 0, len(modules))
+		for _, m := range modules {
+			version, err := module.ParseVersion(m)
+			if err != nil {
+				return nil, err
+			}
+			versions = append(versions, version)
+		}
+		return versions, nil
+	}
+
+	moduleFilePath := filepath.Join("cue.mod", "module.cue")
+	content, err := os.ReadFile(moduleFilePath)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+		version, err := module.NewVersion(schemaModule, "v"+Version)
+		if err != nil {
+			return nil, err
+		}
+		return []module.Version{version}, nil
+	}
+	moduleFile, err := modfile.Parse(content, moduleFilePath)
+	if err != nil {
+		return nil, err
+	}
+	return moduleFile.DepVersions(), nil
+}
@@ -0,0 +1,194 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/kharf/declcd/pkg/project"
+	"k8s.io/apimachinery/pkg/util/duration"
+)
+
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// installProgressPrinter streams the state of every installed object to out.
+// On terminals a spinner animates objects waiting for their namespace or custom resource definition.
+type installProgressPrinter struct {
+	out         io.Writer
+	interactive bool
+
+	mu      sync.Mutex
+	started map[string]time.Time
+	results []installResult
+	waiting *project.InstallProgress
+	frame   int
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+type installResult struct {
+	progress project.InstallProgress
+	took     time.Duration
+}
+
+func newInstallProgressPrinter(out io.Writer) *installProgressPrinter {
+	printer := &installProgressPrinter{
+		out:         out,
+		interactive: isTerminal(out),
+		started:     make(map[string]time.Time),
+		stop:        make(chan struct{}),
+		done:        make(chan struct{}),
+	}
+	if printer.interactive {
+		go printer.animate()
+	} else {
+		close(printer.done)
+	}
+	return printer
+}
+
+// isTerminal reports whether w is a character device, e.g. not redirected to a file or a pipe.
+func isTerminal(w io.Writer) bool {
+	file, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := file.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// Report is meant to be passed as [project.InstallOptions.Progress].
+func (printer *installProgressPrinter) Report(progress project.InstallProgress) {
+	printer.mu.Lock()
+	defer printer.mu.Unlock()
+
+	key := objectKey(progress)
+	if _, found := printer.started[key]; !found {
+		printer.started[key] = time.Now()
+	}
+	printer.clearLine()
+	switch progress.State {
+	case project.InstallWaiting:
+		printer.waiting = &progress
+		if !printer.interactive {
+			fmt.Fprintln(printer.out, printer.line("…", progress))
+		}
+		return
+	case project.InstallApplied:
+		fmt.Fprintln(printer.out, printer.line("✓", progress))
+	case project.InstallFailed:
+		fmt.Fprintf(printer.out, "%s: %v\n", printer.line("✗", progress), progress.Err)
+	}
+	printer.waiting = nil
+	printer.results = append(printer.results, installResult{
+		progress: progress,
+		took:     time.Since(printer.started[key]),
+	})
+}
+
+// Summary stops the spinner and writes a table of all installed objects.
+func (printer *installProgressPrinter) Summary() error {
+	close(printer.stop)
+	<-printer.done
+
+	printer.mu.Lock()
+	defer printer.mu.Unlock()
+	printer.clearLine()
+	if len(printer.results) == 0 {
+		return nil
+	}
+	fmt.Fprintln(printer.out)
+	writer := tabwriter.NewWriter(printer.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "STATE\tKIND\tNAMESPACE\tNAME\tTOOK")
+	applied := 0
+	for _, result := range printer.results {
+		if result.progress.State == project.InstallApplied {
+			applied++
+		}
+		fmt.Fprintf(
+			writer,
+			"%s\t%s\t%s\t%s\t%s\n",
+			result.progress.State,
+			result.progress.Kind,
+			result.progress.Namespace,
+			result.progress.Name,
+			duration.HumanDuration(result.took),
+		)
+	}
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+	total := printer.results[len(printer.results)-1].progress.Total
+	_, err := fmt.Fprintf(printer.out, "\n%d/%d objects applied\n", applied, total)
+	return err
+}
+
+func (printer *installProgressPrinter) animate() {
+	defer close(printer.done)
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-printer.stop:
+			return
+		case <-ticker.C:
+			printer.mu.Lock()
+			if printer.waiting != nil {
+				printer.frame = (printer.frame + 1) % len(spinnerFrames)
+				printer.clearLine()
+				fmt.Fprintf(
+					printer.out,
+					"%s (waiting %s)",
+					printer.line(spinnerFrames[printer.frame], *printer.waiting),
+					duration.HumanDuration(time.Since(printer.started[objectKey(*printer.waiting)])),
+				)
+			}
+			printer.mu.Unlock()
+		}
+	}
+}
+
+func (printer *installProgressPrinter) clearLine() {
+	if printer.interactive {
+		fmt.Fprint(printer.out, "\r\033[K")
+	}
+}
+
+func (printer *installProgressPrinter) line(symbol string, progress project.InstallProgress) string {
+	return fmt.Sprintf(
+		"%s [%d/%d] %s %s",
+		symbol,
+		progress.Index,
+		progress.Total,
+		strings.ToLower(string(progress.State)),
+		objectKey(progress),
+	)
+}
+
+func objectKey(progress project.InstallProgress) string {
+	if progress.Namespace == "" {
+		return fmt.Sprintf("%s/%s", progress.Kind, progress.Name)
+	}
+	return fmt.Sprintf("%s/%s/%s", progress.Kind, progress.Namespace, progress.Name)
+}
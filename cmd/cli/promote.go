@@ -0,0 +1,113 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	gitops "github.com/kharf/declcd/api/v1beta1"
+	"github.com/kharf/declcd/pkg/project"
+	"github.com/spf13/cobra"
+	k8sRuntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/config"
+)
+
+var (
+	ErrNoPendingPromotion = errors.New("Component has no pending promotion")
+)
+
+type PromoteCommandBuilder struct{}
+
+func (builder PromoteCommandBuilder) Build() *cobra.Command {
+	var projectName string
+	var namespace string
+	cmd := &cobra.Command{
+		Use:   "promote <component-id>",
+		Short: "Promote the deferred version of a component, so that it is applied by the next reconciliation",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cobraCmd *cobra.Command, args []string) error {
+			kubeConfig, err := config.GetConfig()
+			if err != nil {
+				return err
+			}
+			scheme := k8sRuntime.NewScheme()
+			if err := gitops.AddToScheme(scheme); err != nil {
+				return err
+			}
+			kubeClient, err := client.New(kubeConfig, client.Options{Scheme: scheme})
+			if err != nil {
+				return err
+			}
+
+			componentID := args[0]
+			var digest string
+			ctx := context.Background()
+			if err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+				var gProject gitops.GitOpsProject
+				if err := kubeClient.Get(
+					ctx,
+					types.NamespacedName{Name: projectName, Namespace: namespace},
+					&gProject,
+				); err != nil {
+					return err
+				}
+				digest, err = promote(&gProject, componentID)
+				if err != nil {
+					return err
+				}
+				return kubeClient.Update(ctx, &gProject)
+			}); err != nil {
+				return err
+			}
+
+			fmt.Fprintf(cobraCmd.OutOrStdout(), "Promoted %s %s\n", componentID, digest)
+			return nil
+		},
+	}
+	cmd.Flags().
+		StringVar(&projectName, "project", "", "Name of the GitOpsProject reconciling the component")
+	cmd.Flags().
+		StringVarP(&namespace, "namespace", "n", project.ControllerNamespace, "Namespace of the GitOpsProject")
+
+	_ = cmd.MarkFlagRequired("project")
+	return cmd
+}
+
+// promote records the pending version of the component in the spec of the project
+// and replaces previous promotions of the component.
+func promote(gProject *gitops.GitOpsProject, componentID string) (string, error) {
+	for _, pending := range gProject.Status.PendingPromotions {
+		if pending.Component != componentID {
+			continue
+		}
+		promotions := make([]gitops.GitOpsProjectPromotion, 0, len(gProject.Spec.Promotions)+1)
+		for _, promotion := range gProject.Spec.Promotions {
+			if promotion.Component != componentID {
+				promotions = append(promotions, promotion)
+			}
+		}
+		gProject.Spec.Promotions = append(promotions, gitops.GitOpsProjectPromotion{
+			Component: componentID,
+			Digest:    pending.Digest,
+		})
+		return pending.Digest, nil
+	}
+	return "", fmt.Errorf("%w: %s", ErrNoPendingPromotion, componentID)
+}
@@ -0,0 +1,156 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"slices"
+	"strings"
+
+	"github.com/go-logr/logr"
+	"github.com/kharf/declcd/pkg/component"
+	"github.com/kharf/declcd/pkg/garbage"
+	"github.com/kharf/declcd/pkg/inventory"
+	"github.com/kharf/declcd/pkg/kube"
+	"github.com/kharf/declcd/pkg/project"
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/discovery"
+	"sigs.k8s.io/controller-runtime/pkg/client/config"
+)
+
+var (
+	ErrPruneRequiresDryRun = errors.New("Pruning is done by the controller, only --dry-run is supported")
+)
+
+type PruneCommandBuilder struct{}
+
+func (builder PruneCommandBuilder) Build() *cobra.Command {
+	var flags inventoryFlags
+	var dryRun bool
+	cmd := &cobra.Command{
+		Use:   "prune",
+		Short: "List the objects and Helm releases a reconciliation of the Declcd Project in the current directory would prune",
+		Long: `List the objects and Helm releases a reconciliation of the Declcd Project in the current directory would prune.
+Candidates are managed by the project according to its inventory, but are no longer declared.
+Objects labeled with app.kubernetes.io/managed-by=declcd and declcd/project=<project>, which are absent from the inventory, are listed as unmanaged,
+because they are neither applied nor pruned.`,
+		Args: cobra.NoArgs,
+		RunE: func(cobraCmd *cobra.Command, args []string) error {
+			if !dryRun {
+				return ErrPruneRequiresDryRun
+			}
+
+			cwd, err := os.Getwd()
+			if err != nil {
+				return err
+			}
+			projectManager := project.NewManager(
+				component.NewBuilder(),
+				logr.Discard(),
+				runtime.GOMAXPROCS(0),
+			)
+			dag, err := projectManager.Load(cwd)
+			if err != nil {
+				return err
+			}
+
+			ctx := context.Background()
+			instance, err := flags.instance(ctx)
+			if err != nil {
+				return err
+			}
+			collector := garbage.Collector{
+				InventoryInstance: instance,
+			}
+			removals, err := collector.Dangling(dag)
+			if err != nil {
+				return err
+			}
+
+			kubeConfig, err := config.GetConfig()
+			if err != nil {
+				return err
+			}
+			client, err := kube.NewDynamicClient(kubeConfig)
+			if err != nil {
+				return err
+			}
+			discoveryClient, err := discovery.NewDiscoveryClientForConfig(kubeConfig)
+			if err != nil {
+				return err
+			}
+			kinds, err := garbage.ListableKinds(discoveryClient)
+			if err != nil {
+				return err
+			}
+			storage, err := instance.Load()
+			if err != nil {
+				return err
+			}
+			unmanaged, err := garbage.Unmanaged(ctx, client, kinds, flags.projectName, *storage)
+			if err != nil {
+				return err
+			}
+
+			writePruneReport(cobraCmd.OutOrStdout(), removals, unmanaged)
+			return nil
+		},
+	}
+	flags.register(cmd)
+	cmd.Flags().
+		BoolVar(&dryRun, "dry-run", false, "Only list the candidates without deleting them")
+	return cmd
+}
+
+// writePruneReport prints the candidate deletions and the unmanaged objects.
+func writePruneReport(out io.Writer, removals []garbage.Removal, unmanaged []garbage.UnmanagedObject) {
+	slices.SortFunc(removals, func(a, b garbage.Removal) int {
+		return strings.Compare(a.Item.GetID(), b.Item.GetID())
+	})
+	for _, removal := range removals {
+		action := "delete"
+		if removal.Orphan {
+			action = "orphan"
+		}
+		fmt.Fprintf(out, "%s %s %s\n", action, describeItem(removal.Item), removal.Item.GetID())
+	}
+	fmt.Fprintf(out, "%d candidates\n", len(removals))
+
+	for _, object := range unmanaged {
+		fmt.Fprintf(out, "unmanaged %s %s\n", object.Kind, namespacedName(object.Namespace, object.Name))
+	}
+	fmt.Fprintf(out, "%d unmanaged objects\n", len(unmanaged))
+}
+
+// describeItem returns the kind and namespaced name of an inventory item, e.g. "Deployment shop/api".
+func describeItem(item inventory.Item) string {
+	kind := "HelmRelease"
+	if manifest, ok := item.(*inventory.ManifestItem); ok {
+		kind = manifest.TypeMeta.Kind
+	}
+	return fmt.Sprintf("%s %s", kind, namespacedName(item.GetNamespace(), item.GetName()))
+}
+
+func namespacedName(namespace string, name string) string {
+	if namespace == "" {
+		return name
+	}
+	return namespace + "/" + name
+}
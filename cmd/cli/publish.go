@@ -0,0 +1,76 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"runtime"
+
+	"github.com/go-logr/logr"
+	"github.com/kharf/declcd/pkg/bundle"
+	"github.com/kharf/declcd/pkg/component"
+	"github.com/kharf/declcd/pkg/project"
+	"github.com/spf13/cobra"
+)
+
+type PublishCommandBuilder struct{}
+
+func (builder PublishCommandBuilder) Build() *cobra.Command {
+	var plainHTTP bool
+	var insecureSkipTLSverify bool
+	cmd := &cobra.Command{
+		Use:   "publish oci://<registry>/<repository>:<tag>",
+		Short: "Validate the Declcd Project in the current directory and publish it as bundle to an OCI registry",
+		Long: `Validate the Declcd Project in the current directory and publish it as bundle to an OCI registry.
+GitOpsProjects with the bundle url reconcile the published configuration instead of a Git branch.
+Credentials are read from the Docker config file.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cobraCmd *cobra.Command, args []string) error {
+			ref, err := bundle.ParseReference(args[0])
+			if err != nil {
+				return err
+			}
+			cwd, err := os.Getwd()
+			if err != nil {
+				return err
+			}
+			projectManager := project.NewManager(
+				component.NewBuilder(),
+				logr.Discard(),
+				runtime.GOMAXPROCS(0),
+			)
+			if _, err := projectManager.Load(cwd); err != nil {
+				return err
+			}
+			registry, err := bundle.NewRegistry(ref.Host, plainHTTP, insecureSkipTLSverify)
+			if err != nil {
+				return err
+			}
+			desc, err := bundle.Publish(context.Background(), registry, ref, cwd)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(cobraCmd.OutOrStdout(), "%s%s/%s@%s\n", bundle.Scheme, ref.Host, ref.Repository, desc.Digest)
+			return nil
+		},
+	}
+	cmd.Flags().
+		BoolVar(&plainHTTP, "plain-http", false, "Reach the registry via http")
+	cmd.Flags().
+		BoolVar(&insecureSkipTLSverify, "insecure-skip-tls-verify", false, "Skip verification of the registry certificate")
+	return cmd
+}
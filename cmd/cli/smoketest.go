@@ -0,0 +1,400 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"cuelang.org/go/mod/modfile"
+	"github.com/go-logr/logr"
+	gitops "github.com/kharf/declcd/api/v1beta1"
+	"github.com/kharf/declcd/pkg/component"
+	"github.com/kharf/declcd/pkg/kube"
+	"github.com/kharf/declcd/pkg/project"
+	"github.com/kharf/declcd/pkg/vcs"
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sRuntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/config"
+)
+
+var (
+	ErrSmokeTestFailed = errors.New("Smoke test failed")
+)
+
+const smokeTestComponent = `package smoketest
+
+import (
+	"github.com/kharf/declcd/schema/component"
+)
+
+configMap: component.#Manifest & {
+	content: {
+		apiVersion: "v1"
+		kind:       "ConfigMap"
+		metadata: {
+			name:      "%s"
+			namespace: "%s"
+		}
+		data: {
+			revision: "%s"
+		}
+	}
+}
+`
+
+type SmokeTestCommandBuilder struct{}
+
+func (builder SmokeTestCommandBuilder) Build() *cobra.Command {
+	var projectName string
+	var namespace string
+	var schemaVersion string
+	var timeout time.Duration
+	cmd := &cobra.Command{
+		Use:   "smoke-test",
+		Short: "Verify that the Declcd installation of the current Kubernetes context reconciles and prunes components",
+		Long: `Verify that the Declcd installation of the current Kubernetes context reconciles and prunes components.
+A throwaway branch with a single ConfigMap component is pushed to the repository of an installed GitOpsProject
+with its credentials. A temporary GitOpsProject reconciling the branch has to apply the ConfigMap
+and prune it after it has been removed from the branch.
+The branch, the temporary GitOpsProject and its credentials are deleted afterwards.`,
+		Args: cobra.NoArgs,
+		RunE: func(cobraCmd *cobra.Command, args []string) error {
+			if schemaVersion == "" {
+				return fmt.Errorf("%w: --schema-version is required for development builds", ErrSmokeTestFailed)
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+
+			kubeConfig, err := config.GetConfig()
+			if err != nil {
+				return err
+			}
+			scheme := k8sRuntime.NewScheme()
+			for _, addToScheme := range []func(*k8sRuntime.Scheme) error{
+				gitops.AddToScheme,
+				corev1.AddToScheme,
+			} {
+				if err := addToScheme(scheme); err != nil {
+					return err
+				}
+			}
+			kubeClient, err := client.New(kubeConfig, client.Options{Scheme: scheme})
+			if err != nil {
+				return err
+			}
+			dynamicClient, err := kube.NewDynamicClient(kubeConfig)
+			if err != nil {
+				return err
+			}
+
+			smokeTest := smokeTest{
+				kubeClient:        kubeClient,
+				repositoryManager: vcs.NewRepositoryManager(namespace, dynamicClient, logr.Discard()),
+				namespace:         namespace,
+				schemaVersion:     schemaVersion,
+				out:               cobraCmd.ErrOrStderr(),
+			}
+			checks := smokeTest.run(ctx, projectName)
+			if err := renderChecks(cobraCmd.OutOrStdout(), checks); err != nil {
+				if errors.Is(err, ErrDiagnosticsFailed) {
+					return ErrSmokeTestFailed
+				}
+				return err
+			}
+			return nil
+		},
+	}
+	defaultSchemaVersion := ""
+	if Version != "" {
+		defaultSchemaVersion = "v" + Version
+	}
+	cmd.Flags().
+		StringVar(&projectName, "project", "", "Installed GitOpsProject, whose repository and credentials are used for the throwaway branch")
+	cmd.Flags().
+		StringVarP(&namespace, "namespace", "n", project.ControllerNamespace, "Namespace of the tested Declcd instance and the installed GitOpsProject")
+	cmd.Flags().
+		StringVar(&schemaVersion, "schema-version", defaultSchemaVersion, "Version of the Declcd schema module the throwaway branch depends on")
+	cmd.Flags().
+		DurationVar(&timeout, "timeout", 5*time.Minute, "Time the smoke test is allowed to take")
+
+	_ = cmd.MarkFlagRequired("project")
+	return cmd
+}
+
+// smokeTest reconciles a throwaway branch with a temporary GitOpsProject.
+// Progress is written to out, while the results are returned as checks.
+type smokeTest struct {
+	kubeClient        client.Client
+	repositoryManager vcs.RepositoryManager
+	namespace         string
+	schemaVersion     string
+	out               io.Writer
+}
+
+func (test smokeTest) run(ctx context.Context, projectName string) []check {
+	var installed gitops.GitOpsProject
+	source := check{Name: "project"}
+	if err := test.kubeClient.Get(
+		ctx,
+		types.NamespacedName{Name: projectName, Namespace: test.namespace},
+		&installed,
+	); err != nil {
+		source.Status = checkFailed
+		source.Message = err.Error()
+		source.Hint = "pass an installed GitOpsProject with --project"
+		return []check{source}
+	}
+	source.Status = checkOK
+	source.Message = installed.Spec.URL
+
+	suffix, err := randomSuffix()
+	if err != nil {
+		source.Status = checkFailed
+		source.Message = err.Error()
+		return []check{source}
+	}
+	name := "declcd-smoke-test-" + suffix
+
+	checks := []check{source}
+	push := check{Name: "push"}
+	localRepository, err := os.MkdirTemp("", name)
+	if err != nil {
+		push.Status = checkFailed
+		push.Message = err.Error()
+		return append(checks, push)
+	}
+	defer os.RemoveAll(localRepository)
+	branch, err := test.repositoryManager.NewScratchBranch(ctx, installed.Spec.URL, localRepository, projectName, name)
+	if err != nil {
+		push.Status = checkFailed
+		push.Message = err.Error()
+		return append(checks, push)
+	}
+	moduleFile := modfile.File{
+		Module: "declcd.io/smoketest@v0",
+		Language: &modfile.Language{
+			Version: component.MaxLanguageVersion,
+		},
+		Deps: map[string]*modfile.Dep{
+			schemaModule: {
+				Version: test.schemaVersion,
+			},
+		},
+	}
+	module, err := moduleFile.Format()
+	if err != nil {
+		push.Status = checkFailed
+		push.Message = err.Error()
+		return append(checks, push)
+	}
+	files := map[string][]byte{
+		"cue.mod/module.cue":      module,
+		"smoketest/configmap.cue": []byte(fmt.Sprintf(smokeTestComponent, name, test.namespace, name)),
+	}
+	fmt.Fprintf(test.out, "Pushing branch %s\n", name)
+	applyRevision, err := branch.Commit(ctx, files, "smoke test: add component")
+	if err != nil {
+		push.Status = checkFailed
+		push.Message = err.Error()
+		push.Hint = "the credentials of the project need write access to the repository"
+		return append(checks, push)
+	}
+	// The cleanup has to succeed even if the smoke test timed out.
+	cleanupCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 30*time.Second)
+	defer cancel()
+	defer func() {
+		if err := branch.Delete(cleanupCtx); err != nil {
+			fmt.Fprintf(test.out, "Unable to delete branch %s: %s\n", name, err)
+		}
+	}()
+	push.Status = checkOK
+	push.Message = name
+	checks = append(checks, push)
+
+	create := check{Name: "create"}
+	temporary, err := test.createProject(ctx, &installed, name)
+	if err != nil {
+		create.Status = checkFailed
+		create.Message = err.Error()
+		return append(checks, create)
+	}
+	defer test.deleteProject(cleanupCtx, temporary)
+	create.Status = checkOK
+	create.Message = fmt.Sprintf("GitOpsProject %s/%s", temporary.Namespace, temporary.Name)
+	checks = append(checks, create)
+
+	configMap := types.NamespacedName{Name: name, Namespace: test.namespace}
+	fmt.Fprintf(test.out, "Waiting for revision %s to be applied\n", applyRevision)
+	apply := test.waitForRevision(ctx, "apply", temporary, applyRevision, func(ctx context.Context) (bool, error) {
+		err := test.kubeClient.Get(ctx, configMap, &corev1.ConfigMap{})
+		if k8sErrors.IsNotFound(err) {
+			return false, nil
+		}
+		return err == nil, err
+	})
+	checks = append(checks, apply)
+	if apply.Status != checkOK {
+		return checks
+	}
+
+	prune := check{Name: "prune"}
+	delete(files, "smoketest/configmap.cue")
+	pruneRevision, err := branch.Commit(ctx, files, "smoke test: remove component")
+	if err != nil {
+		prune.Status = checkFailed
+		prune.Message = err.Error()
+		return append(checks, prune)
+	}
+	fmt.Fprintf(test.out, "Waiting for revision %s to be pruned\n", pruneRevision)
+	prune = test.waitForRevision(ctx, "prune", temporary, pruneRevision, func(ctx context.Context) (bool, error) {
+		err := test.kubeClient.Get(ctx, configMap, &corev1.ConfigMap{})
+		if k8sErrors.IsNotFound(err) {
+			return true, nil
+		}
+		return false, err
+	})
+	return append(checks, prune)
+}
+
+// createProject reconciles the throwaway branch with a copy of the credentials of the installed project,
+// as credentials are looked up by project name.
+func (test smokeTest) createProject(
+	ctx context.Context,
+	installed *gitops.GitOpsProject,
+	name string,
+) (*gitops.GitOpsProject, error) {
+	var secret corev1.Secret
+	err := test.kubeClient.Get(
+		ctx,
+		types.NamespacedName{Name: vcs.SecretName(installed.Name), Namespace: test.namespace},
+		&secret,
+	)
+	switch {
+	case err == nil:
+		if err := test.kubeClient.Create(ctx, &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      vcs.SecretName(name),
+				Namespace: test.namespace,
+			},
+			Data: secret.Data,
+		}); err != nil {
+			return nil, err
+		}
+	// Public repositories are accessed anonymously.
+	case !k8sErrors.IsNotFound(err):
+		return nil, err
+	}
+
+	suspend := false
+	temporary := &gitops.GitOpsProject{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: installed.Namespace,
+			// The shard labels assign the project to the same controller.
+			Labels: installed.Labels,
+		},
+		Spec: gitops.GitOpsProjectSpec{
+			ServiceAccountName:  installed.Spec.ServiceAccountName,
+			URL:                 installed.Spec.URL,
+			Branch:              name,
+			PullIntervalSeconds: 5,
+			Suspend:             &suspend,
+		},
+	}
+	if err := test.kubeClient.Create(ctx, temporary); err != nil {
+		return nil, err
+	}
+	return temporary, nil
+}
+
+func (test smokeTest) deleteProject(ctx context.Context, temporary *gitops.GitOpsProject) {
+	if err := client.IgnoreNotFound(test.kubeClient.Delete(ctx, temporary)); err != nil {
+		fmt.Fprintf(test.out, "Unable to delete GitOpsProject %s: %s\n", temporary.Name, err)
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      vcs.SecretName(temporary.Name),
+			Namespace: test.namespace,
+		},
+	}
+	if err := client.IgnoreNotFound(test.kubeClient.Delete(ctx, secret)); err != nil {
+		fmt.Fprintf(test.out, "Unable to delete Secret %s: %s\n", secret.Name, err)
+	}
+}
+
+// waitForRevision polls until the project reconciled the revision successfully and the cluster is in the expected state.
+// The message of the last failed reconciliation is reported on timeout.
+func (test smokeTest) waitForRevision(
+	ctx context.Context,
+	name string,
+	temporary *gitops.GitOpsProject,
+	revision string,
+	expected func(ctx context.Context) (bool, error),
+) check {
+	result := check{Name: name}
+	start := time.Now()
+	lastMessage := "revision not reconciled"
+	err := wait.PollUntilContextCancel(ctx, 2*time.Second, true, func(ctx context.Context) (bool, error) {
+		var current gitops.GitOpsProject
+		if err := test.kubeClient.Get(ctx, client.ObjectKeyFromObject(temporary), &current); err != nil {
+			return false, err
+		}
+		if current.Status.Revision.CommitHash != revision {
+			return false, nil
+		}
+		if current.Status.Ready != "True" {
+			if conditions := current.Status.Conditions; len(conditions) > 0 {
+				lastMessage = conditions[len(conditions)-1].Message
+			}
+			return false, nil
+		}
+		lastMessage = "revision reconciled, but the ConfigMap was not " + name + "d"
+		return expected(ctx)
+	})
+	if err != nil {
+		result.Status = checkFailed
+		result.Message = lastMessage
+		if errors.Is(err, context.DeadlineExceeded) || wait.Interrupted(err) {
+			result.Hint = "inspect the status of the temporary GitOpsProject and the controller logs or raise --timeout"
+		} else {
+			result.Message = err.Error()
+		}
+		return result
+	}
+	result.Status = checkOK
+	result.Message = fmt.Sprintf("revision %s after %s", revision[:7], time.Since(start).Round(time.Second))
+	return result
+}
+
+func randomSuffix() (string, error) {
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(suffix), nil
+}
@@ -0,0 +1,224 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os/signal"
+	"slices"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	gitops "github.com/kharf/declcd/api/v1beta1"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sRuntime "k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/config"
+)
+
+// clearScreen moves the cursor to the top left corner and erases the terminal.
+const clearScreen = "\033[H\033[2J"
+
+type UICommandBuilder struct{}
+
+func (builder UICommandBuilder) Build() *cobra.Command {
+	var namespace string
+	var refresh time.Duration
+	var once bool
+	cmd := &cobra.Command{
+		Use:   "ui",
+		Short: "Monitor the reconciliation of GitOps Projects in the terminal",
+		Args:  cobra.NoArgs,
+		RunE: func(cobraCmd *cobra.Command, args []string) error {
+			kubeConfig, err := config.GetConfig()
+			if err != nil {
+				return err
+			}
+			scheme := k8sRuntime.NewScheme()
+			if err := gitops.AddToScheme(scheme); err != nil {
+				return err
+			}
+			kubeClient, err := client.New(kubeConfig, client.Options{Scheme: scheme})
+			if err != nil {
+				return err
+			}
+
+			ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+			defer stop()
+
+			out := cobraCmd.OutOrStdout()
+			ticker := time.NewTicker(refresh)
+			defer ticker.Stop()
+			for {
+				var projects gitops.GitOpsProjectList
+				if err := kubeClient.List(ctx, &projects, client.InNamespace(namespace)); err != nil {
+					if ctx.Err() != nil {
+						return nil
+					}
+					return err
+				}
+				if !once {
+					fmt.Fprint(out, clearScreen)
+				}
+				if err := renderProjects(out, projects.Items, time.Now()); err != nil {
+					return err
+				}
+				if once {
+					return nil
+				}
+				select {
+				case <-ctx.Done():
+					return nil
+				case <-ticker.C:
+				}
+			}
+		},
+	}
+	cmd.Flags().
+		StringVarP(&namespace, "namespace", "n", "", "Namespace of the GitOps Projects. Defaults to all namespaces")
+	cmd.Flags().
+		DurationVar(&refresh, "refresh", 2*time.Second, "Interval in which the view is refreshed")
+	cmd.Flags().
+		BoolVar(&once, "once", false, "Print the view a single time instead of refreshing it")
+	return cmd
+}
+
+// renderProjects writes an overview of the projects followed by their components and the namespaces, which failed in their last reconciliation.
+func renderProjects(out io.Writer, projects []gitops.GitOpsProject, now time.Time) error {
+	slices.SortFunc(projects, func(a, b gitops.GitOpsProject) int {
+		if c := strings.Compare(a.Namespace, b.Namespace); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	fmt.Fprintf(out, "declcd - %d projects - %s\n\n", len(projects), now.Format(time.TimeOnly))
+
+	writer := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "NAMESPACE\tNAME\tPHASE\tSTATE\tREVISION\tLAST RECONCILE\tFAILURES\tNEXT RETRY")
+	for _, project := range projects {
+		status := project.Status
+		fmt.Fprintf(
+			writer,
+			"%s\t%s\t%s\t%s\t%s\t%s\t%d\t%s\n",
+			project.Namespace,
+			project.Name,
+			phase(status, now),
+			state(status.Conditions),
+			shortRevision(status.Revision.CommitHash),
+			since(now, &status.Revision.ReconcileTime),
+			status.ConsecutiveFailures,
+			until(now, status.NextRetryAt),
+		)
+	}
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(out, "\nComponents")
+	writer = tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "PROJECT\tCOMPONENT\tTYPE\tREVISION\tLAST APPLIED\tMESSAGE")
+	for _, project := range projects {
+		for _, component := range project.Status.Components {
+			message := component.Message
+			if message == "" {
+				message = "-"
+			}
+			fmt.Fprintf(
+				writer,
+				"%s/%s\t%s\t%s\t%s\t%s\t%s\n",
+				project.Namespace,
+				project.Name,
+				component.ID,
+				component.Type,
+				shortRevision(component.Revision),
+				since(now, component.LastAppliedTime),
+				message,
+			)
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(out, "\nRecent failures")
+	writer = tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "PROJECT\tTARGET NAMESPACE\tMESSAGE")
+	for _, project := range projects {
+		for _, ns := range project.Status.Namespaces {
+			if ns.Succeeded {
+				continue
+			}
+			target := ns.Name
+			if target == "" {
+				target = "<cluster>"
+			}
+			fmt.Fprintf(writer, "%s/%s\t%s\t%s\n", project.Namespace, project.Name, target, ns.Message)
+		}
+	}
+	return writer.Flush()
+}
+
+// state describes the most recently transitioned condition.
+func state(conditions []metav1.Condition) string {
+	if len(conditions) == 0 {
+		return "Pending"
+	}
+	latest := slices.MaxFunc(conditions, func(a, b metav1.Condition) int {
+		return a.LastTransitionTime.Compare(b.LastTransitionTime.Time)
+	})
+	return fmt.Sprintf("%s/%s", latest.Type, latest.Reason)
+}
+
+// phase describes the current stage and how long it has been running.
+func phase(status gitops.GitOpsProjectStatus, now time.Time) string {
+	if status.Phase == "" {
+		return "-"
+	}
+	running := len(status.Stages) - 1
+	if running < 0 || status.Stages[running].FinishedAt != nil {
+		return status.Phase
+	}
+	return fmt.Sprintf("%s (%s)", status.Phase, now.Sub(status.Stages[running].StartedAt.Time).Truncate(time.Second))
+}
+
+func shortRevision(hash string) string {
+	if len(hash) > 7 {
+		return hash[:7]
+	}
+	if hash == "" {
+		return "-"
+	}
+	return hash
+}
+
+func since(now time.Time, t *metav1.Time) string {
+	if t == nil || t.IsZero() {
+		return "-"
+	}
+	return now.Sub(t.Time).Truncate(time.Second).String() + " ago"
+}
+
+func until(now time.Time, t *metav1.Time) string {
+	if t == nil || t.IsZero() || !t.After(now) {
+		return "-"
+	}
+	return "in " + t.Sub(now).Truncate(time.Second).String()
+}
@@ -0,0 +1,168 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	gitops "github.com/kharf/declcd/api/v1beta1"
+	"gotest.tools/v3/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestRenderProjects(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	applied := metav1.NewTime(now.Add(-90 * time.Second))
+	projects := []gitops.GitOpsProject{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "shop", Namespace: "declcd-system"},
+			Status: gitops.GitOpsProjectStatus{
+				Revision: gitops.GitOpsProjectRevision{
+					CommitHash:    "0123456789abcdef",
+					ReconcileTime: applied,
+				},
+				Components: []gitops.GitOpsProjectComponentStatus{
+					{
+						ID:              "api__apps_Deployment",
+						Type:            "Manifest",
+						Revision:        "0123456789abcdef",
+						LastAppliedTime: &applied,
+					},
+					{
+						ID:      "cache_shop_HelmRelease",
+						Type:    "HelmRelease",
+						Message: "chart not found",
+					},
+				},
+				Namespaces: []gitops.GitOpsProjectNamespaceStatus{
+					{Name: "shop", Message: "chart not found"},
+					{Name: "", Succeeded: true},
+				},
+			},
+		},
+	}
+
+	var out bytes.Buffer
+	assert.NilError(t, renderProjects(&out, projects, now))
+	lines := strings.Split(out.String(), "\n")
+	assert.Equal(t, lines[0], "declcd - 1 projects - 12:00:00")
+	assert.Assert(t, strings.Contains(out.String(), "Components\n"))
+
+	rows := make(map[string][]string)
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		if len(fields) > 1 {
+			rows[fields[0]+" "+fields[1]] = fields
+		}
+	}
+	assert.DeepEqual(
+		t,
+		rows["declcd-system/shop api__apps_Deployment"],
+		[]string{"declcd-system/shop", "api__apps_Deployment", "Manifest", "0123456", "1m30s", "ago", "-"},
+	)
+	assert.DeepEqual(
+		t,
+		rows["declcd-system/shop cache_shop_HelmRelease"],
+		[]string{"declcd-system/shop", "cache_shop_HelmRelease", "HelmRelease", "-", "-", "chart", "not", "found"},
+	)
+	assert.DeepEqual(t, rows["declcd-system/shop shop"], []string{"declcd-system/shop", "shop", "chart", "not", "found"})
+	assert.DeepEqual(
+		t,
+		rows["declcd-system shop"],
+		[]string{"declcd-system", "shop", "-", "Pending", "0123456", "1m30s", "ago", "0", "-"},
+	)
+	assert.Assert(t, !strings.Contains(out.String(), "<cluster>"))
+}
+
+func TestRenderProjects_Transitions(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	started := metav1.NewTime(now.Add(-5 * time.Second))
+	finished := metav1.NewTime(now.Add(-time.Second))
+	retry := metav1.NewTime(now.Add(time.Minute))
+
+	testCases := []struct {
+		name          string
+		status        gitops.GitOpsProjectStatus
+		expectedPhase string
+		expectedState string
+		expectedRetry string
+	}{
+		{
+			name:          "Pending",
+			status:        gitops.GitOpsProjectStatus{},
+			expectedPhase: "-",
+			expectedState: "Pending",
+			expectedRetry: "-",
+		},
+		{
+			name: "Running",
+			status: gitops.GitOpsProjectStatus{
+				Phase: "Applying",
+				Stages: []gitops.GitOpsProjectStage{
+					{Name: "Cloning", StartedAt: started, FinishedAt: &finished},
+					{Name: "Applying", StartedAt: started},
+				},
+				Conditions: []metav1.Condition{
+					{Type: "Running", Reason: "Progressing", LastTransitionTime: started},
+				},
+			},
+			expectedPhase: "Applying (5s)",
+			expectedState: "Running/Progressing",
+			expectedRetry: "-",
+		},
+		{
+			name: "Finished",
+			status: gitops.GitOpsProjectStatus{
+				Phase: "Idle",
+				Stages: []gitops.GitOpsProjectStage{
+					{Name: "Applying", StartedAt: started, FinishedAt: &finished},
+				},
+				Conditions: []metav1.Condition{
+					{Type: "Running", Reason: "Progressing", LastTransitionTime: started},
+					{Type: "Finished", Reason: "Success", LastTransitionTime: finished},
+				},
+			},
+			expectedPhase: "Idle",
+			expectedState: "Finished/Success",
+			expectedRetry: "-",
+		},
+		{
+			name: "Failed",
+			status: gitops.GitOpsProjectStatus{
+				Phase: "Idle",
+				Conditions: []metav1.Condition{
+					{Type: "Finished", Reason: "Success", LastTransitionTime: started},
+					{Type: "Failed", Reason: "ApplyFailed", LastTransitionTime: finished},
+				},
+				ConsecutiveFailures: 2,
+				NextRetryAt:         &retry,
+			},
+			expectedPhase: "Idle",
+			expectedState: "Failed/ApplyFailed",
+			expectedRetry: "in 1m0s",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, phase(tc.status, now), tc.expectedPhase)
+			assert.Equal(t, state(tc.status.Conditions), tc.expectedState)
+			assert.Equal(t, until(now, tc.status.NextRetryAt), tc.expectedRetry)
+		})
+	}
+}
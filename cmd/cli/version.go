@@ -0,0 +1,110 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"text/tabwriter"
+
+	"github.com/kharf/declcd/internal/buildinfo"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+var (
+	ErrControllerNotFound = errors.New("Controller not found")
+)
+
+// printControllerVersions queries the build info of every controller pod through the API server proxy
+// and marks controllers, whose version differs from the cli.
+func printControllerVersions(
+	ctx context.Context,
+	out io.Writer,
+	clientset kubernetes.Interface,
+	namespace string,
+	cliVersion string,
+) error {
+	pods, err := clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: controlPlaneLabel,
+	})
+	if err != nil {
+		return err
+	}
+	if len(pods.Items) == 0 {
+		return fmt.Errorf("%w: %s", ErrControllerNotFound, namespace)
+	}
+
+	writer := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "POD\tVERSION\tCOMMIT\tGO\tSCHEMA\tCUE LANGUAGE\tSKEW")
+	for _, pod := range pods.Items {
+		info, err := controllerBuildInfo(ctx, clientset, &pod)
+		if err != nil {
+			fmt.Fprintf(writer, "%s\t%s\t\t\t\t\t\n", pod.Name, err)
+			continue
+		}
+		skew := ""
+		if cliVersion != "" && info.Version != cliVersion {
+			skew = fmt.Sprintf("cli is v%s", cliVersion)
+		}
+		commit := info.Commit
+		if len(commit) > 7 {
+			commit = commit[:7]
+		}
+		fmt.Fprintf(
+			writer,
+			"%s\tv%s\t%s\t%s\t%s\t%s - %s\t%s\n",
+			pod.Name,
+			info.Version,
+			commit,
+			info.GoVersion,
+			info.SchemaVersion,
+			info.MinLanguageVersion,
+			info.MaxLanguageVersion,
+			skew,
+		)
+	}
+	return writer.Flush()
+}
+
+func controllerBuildInfo(
+	ctx context.Context,
+	clientset kubernetes.Interface,
+	pod *corev1.Pod,
+) (*buildinfo.Info, error) {
+	scheme := "http"
+	for _, container := range pod.Spec.Containers {
+		if slices.Contains(container.Args, "--metrics-secure=true") {
+			scheme = "https"
+		}
+	}
+	content, err := clientset.CoreV1().
+		Pods(pod.Namespace).
+		ProxyGet(scheme, pod.Name, "8080", buildinfo.Path, nil).
+		DoRaw(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var info buildinfo.Info
+	if err := json.Unmarshal(content, &info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
@@ -21,6 +21,7 @@ import (
 	"fmt"
 	_ "net/http/pprof"
 	"os"
+	"time"
 
 	_ "go.uber.org/automaxprocs"
 
@@ -33,27 +34,67 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 
 	"github.com/kharf/declcd/internal/controller"
+	"github.com/kharf/declcd/pkg/helm"
+	"github.com/kharf/declcd/pkg/project"
 )
 
 var (
 	Version string
+	Commit  string
 )
 
 func main() {
 	var metricsAddr string
+	var metricsSecure bool
+	var metricsCertDir string
 	var probeAddr string
 	var logLevel int
 	var namespacePodinfoPath string
 	var namePodinfoPath string
+	var podNamePodinfoPath string
 	var shardPodinfoPath string
+	var shardLabelsPodinfoPath string
 	var insecureSkipTLSverify bool
+	var insecureSkipTLSverifyHosts []string
 	var plainHTTP bool
+	var plainHTTPHosts []string
+	var auditRepository string
+	var skipUnchangedRevisions bool
+	var pruneDryRun bool
+	var reportDir string
+	var reportWebhookURL string
+	var reportFormat string
+	var sopsKeyDir string
+	var inventoryKeyPath string
+	var inventoryStorage string
+	var webhookReceiverAddr string
+	var projectNamespaces []string
+	var cueRegistry string
+	var helmMaxHistory int
+	var maxConcurrentProjects int
+	var serializeSharedCRDs bool
+	var leaderElect bool
+	var leaseDuration time.Duration
+	var renewDeadline time.Duration
+	var retryPeriod time.Duration
 	flag.StringVar(
 		&metricsAddr,
 		"metrics-bind-address",
 		"",
 		"The address the metric endpoint binds to.",
 	)
+	flag.BoolVar(
+		&metricsSecure,
+		"metrics-secure",
+		false,
+		"Serve the metric endpoint via https. Uses a self-signed certificate if no certificate is found in the metrics cert dir.",
+	)
+	flag.StringVar(
+		&metricsCertDir,
+		"metrics-cert-dir",
+		"",
+		"The directory containing the tls.crt and tls.key used by the metric endpoint.",
+	)
 	flag.StringVar(
 		&probeAddr,
 		"health-probe-bind-address",
@@ -73,27 +114,171 @@ func main() {
 		"",
 		"The file which holds the controller name.",
 	)
+	flag.StringVar(
+		&podNamePodinfoPath,
+		"pod-name-podinfo-path",
+		"",
+		"The file which holds the controller pod name, which identifies the replica holding project locks.",
+	)
 	flag.StringVar(
 		&shardPodinfoPath,
 		"shard-podinfo-path",
 		"",
 		"The file which holds the controller shard.",
 	)
+	flag.StringVar(
+		&shardLabelsPodinfoPath,
+		"shard-labels-podinfo-path",
+		"",
+		"The file which holds the controller pod labels matched against the shard affinity of projects.",
+	)
 	flag.BoolVar(
 		&insecureSkipTLSverify,
 		"insecure-skip-tls-verify",
 		false,
 		"InsecureSkipVerify controls whether the Helm client verifies the server's certificate chain and host name.",
 	)
+	flag.Func(
+		"insecure-skip-tls-verify-host",
+		"Registry host, optionally with port, whose certificate is not verified. Can be repeated. Prefer it over insecure-skip-tls-verify, which affects every host.",
+		func(host string) error {
+			insecureSkipTLSverifyHosts = append(insecureSkipTLSverifyHosts, host)
+			return nil
+		},
+	)
 	flag.BoolVar(
 		&plainHTTP,
 		"plain-http",
 		false,
 		"Force http for Helm registries.",
 	)
+	flag.Func(
+		"plain-http-host",
+		"Registry host, optionally with port, which is reached via http. Can be repeated. Prefer it over plain-http, which affects every registry.",
+		func(host string) error {
+			plainHTTPHosts = append(plainHTTPHosts, host)
+			return nil
+		},
+	)
+	flag.StringVar(
+		&auditRepository,
+		"audit-repository",
+		"",
+		"OCI repository the rendered cluster state of every reconciled revision is published to, e.g. registry.example.com/declcd/audit.",
+	)
+	flag.BoolVar(
+		&skipUnchangedRevisions,
+		"skip-unchanged-revisions",
+		false,
+		"Skip applying components when the pulled commit equals the last reconciled commit. Drift is then only corrected on new commits.",
+	)
+	flag.BoolVar(
+		&pruneDryRun,
+		"prune-dry-run",
+		false,
+		"Only report the objects and Helm releases, which are no longer declared, and the objects labeled as managed by declcd, which are absent from the inventory, instead of pruning them.",
+	)
+	flag.StringVar(
+		&reportDir,
+		"report-dir",
+		"",
+		"Directory, e.g. a mounted PVC, every reconciliation report is written to.",
+	)
+	flag.StringVar(
+		&reportWebhookURL,
+		"report-webhook-url",
+		"",
+		"Url every reconciliation report is posted to.",
+	)
+	flag.StringVar(
+		&reportFormat,
+		"report-format",
+		"markdown",
+		"Format of reconciliation reports. One of markdown or html.",
+	)
+	flag.StringVar(
+		&sopsKeyDir,
+		"sops-key-dir",
+		"/sops",
+		"Directory holding the PGP private keys, which decrypt SOPS encrypted components.",
+	)
+	flag.StringVar(
+		&inventoryKeyPath,
+		"inventory-key-path",
+		"/inventory-encryption/key",
+		"File holding the base64 encoded AES-256 key, which encrypts the inventory at rest. Encryption is disabled, when the file does not exist. The repository cache in the temporary directory is not encrypted.",
+	)
+	flag.StringVar(
+		&webhookReceiverAddr,
+		"webhook-receiver-bind-address",
+		"",
+		"The address the receiver of GitHub, GitLab and Bitbucket push events binds to. Empty disables the receiver.",
+	)
+	flag.Func(
+		"project-namespace",
+		"Namespace of the GitOpsProjects the controller reconciles. Can be repeated. Defaults to all namespaces.",
+		func(namespace string) error {
+			projectNamespaces = append(projectNamespaces, namespace)
+			return nil
+		},
+	)
+	flag.StringVar(
+		&cueRegistry,
+		"cue-registry",
+		project.DefaultCUERegistry,
+		"CUE registry configuration CUE modules are resolved with, e.g. a mirror in air-gapped environments. Same syntax as CUE_REGISTRY.",
+	)
+	flag.IntVar(
+		&helmMaxHistory,
+		"helm-max-history",
+		helm.DefaultMaxHistory,
+		"Number of stored versions kept per Helm release. Superseded and failed versions beyond it are deleted on every reconciliation.",
+	)
+	flag.StringVar(
+		&inventoryStorage,
+		"inventory-storage",
+		"volume",
+		"Where the inventories of projects are stored: 'volume' stores them in the /inventory volume and 'secret' in Secrets in the namespace of the controller, which needs no volume.",
+	)
+	flag.IntVar(
+		&maxConcurrentProjects,
+		"max-concurrent-projects",
+		1,
+		"Number of GitOpsProjects reconciled in parallel. Components of a project are applied in parallel up to its maxConcurrentApplies.",
+	)
+	flag.BoolVar(
+		&serializeSharedCRDs,
+		"serialize-shared-crds",
+		false,
+		"Install and upgrade Helm releases bundling the same CRDs one at a time instead of letting them race writing the CRDs.",
+	)
+	flag.BoolVar(
+		&leaderElect,
+		"leader-elect",
+		true,
+		"Elect a leader among the replicas of a shard. Only the leader reconciles, while standby replicas take over once it fails. Only disable it with a single replica per shard.",
+	)
+	flag.DurationVar(
+		&leaseDuration,
+		"leader-elect-lease-duration",
+		15*time.Second,
+		"Time standby replicas wait before taking over the lease of a leader, which stopped renewing it.",
+	)
+	flag.DurationVar(
+		&renewDeadline,
+		"leader-elect-renew-deadline",
+		10*time.Second,
+		"Time the leader retries renewing its lease before it stops reconciling.",
+	)
+	flag.DurationVar(
+		&retryPeriod,
+		"leader-elect-retry-period",
+		2*time.Second,
+		"Time replicas wait between attempts to acquire or renew the lease.",
+	)
 	flag.Parse()
 
-	if err := os.Setenv("CUE_REGISTRY", "ghcr.io/kharf"); err != nil {
+	if err := os.Setenv("CUE_REGISTRY", cueRegistry); err != nil {
 		fmt.Println(err)
 		os.Exit(1)
 	}
@@ -103,13 +288,39 @@ func main() {
 	mgr, err := controller.Setup(
 		cfg,
 		controller.NamePodinfoPath(namePodinfoPath),
+		controller.PodNamePodinfoPath(podNamePodinfoPath),
 		controller.NamespacePodinfoPath(namespacePodinfoPath),
 		controller.ShardPodinfoPath(shardPodinfoPath),
+		controller.ShardLabelsPodinfoPath(shardLabelsPodinfoPath),
 		controller.MetricsAddr(metricsAddr),
+		controller.MetricsSecure(metricsSecure),
+		controller.MetricsCertDir(metricsCertDir),
 		controller.ProbeAddr(probeAddr),
 		controller.LogLevel(logLevel),
 		controller.PlainHTTP(plainHTTP),
 		controller.InsecureSkipTLSverify(insecureSkipTLSverify),
+		controller.PlainHTTPHosts(plainHTTPHosts),
+		controller.InsecureSkipTLSverifyHosts(insecureSkipTLSverifyHosts),
+		controller.AuditRepository(auditRepository),
+		controller.SkipUnchangedRevisions(skipUnchangedRevisions),
+		controller.PruneDryRun(pruneDryRun),
+		controller.ReportDir(reportDir),
+		controller.ReportWebhookURL(reportWebhookURL),
+		controller.ReportFormat(reportFormat),
+		controller.SOPSKeyDir(sopsKeyDir),
+		controller.InventoryKeyPath(inventoryKeyPath),
+		controller.InventoryStorage(inventoryStorage),
+		controller.WebhookReceiverAddr(webhookReceiverAddr),
+		controller.ProjectNamespaces(projectNamespaces),
+		controller.HelmMaxHistory(helmMaxHistory),
+		controller.MaxConcurrentProjects(maxConcurrentProjects),
+		controller.SerializeSharedCRDs(serializeSharedCRDs),
+		controller.LeaderElection(leaderElect),
+		controller.LeaseDuration(leaseDuration),
+		controller.RenewDeadline(renewDeadline),
+		controller.RetryPeriod(retryPeriod),
+		controller.Version(Version),
+		controller.Commit(Commit),
 	)
 	if err != nil {
 		os.Exit(1)
@@ -0,0 +1,92 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package buildinfo
+
+import (
+	"encoding/json"
+	"net/http"
+	"runtime"
+	"runtime/debug"
+
+	"github.com/kharf/declcd/pkg/component"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Path is the path of the endpoint serving the build info of the controller on its metrics server.
+const Path = "/version"
+
+// Info describes the build of a Declcd binary.
+type Info struct {
+	// Version is empty for development builds.
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	GoVersion string `json:"goVersion"`
+	// SchemaVersion is the version of the github.com/kharf/declcd/schema module released with the build.
+	SchemaVersion string `json:"schemaVersion"`
+	// MinLanguageVersion and MaxLanguageVersion bound the CUE language versions projects can pin in their module.cue.
+	MinLanguageVersion string `json:"minLanguageVersion"`
+	MaxLanguageVersion string `json:"maxLanguageVersion"`
+}
+
+// New describes the running binary released as version.
+// Without a commit passed at link time, the VCS revision recorded by the Go toolchain is used.
+func New(version string, commit string) Info {
+	if commit == "" {
+		if build, ok := debug.ReadBuildInfo(); ok {
+			for _, setting := range build.Settings {
+				if setting.Key == "vcs.revision" {
+					commit = setting.Value
+				}
+			}
+		}
+	}
+	info := Info{
+		Version:            version,
+		Commit:             commit,
+		GoVersion:          runtime.Version(),
+		MinLanguageVersion: component.MinLanguageVersion,
+		MaxLanguageVersion: component.MaxLanguageVersion,
+	}
+	if version != "" {
+		info.SchemaVersion = "v" + version
+	}
+	return info
+}
+
+// Handler serves the build info as JSON.
+func (info Info) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(info)
+	})
+}
+
+// Collector reports the build info as declcd_build_info metric with a constant value of 1.
+func (info Info) Collector() prometheus.Collector {
+	gauge := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "declcd",
+		Name:      "build_info",
+		Help:      "Build information of the controller with a constant value of 1",
+	}, []string{"version", "commit", "go_version", "schema_version", "min_language_version", "max_language_version"})
+	gauge.With(prometheus.Labels{
+		"version":              info.Version,
+		"commit":               info.Commit,
+		"go_version":           info.GoVersion,
+		"schema_version":       info.SchemaVersion,
+		"min_language_version": info.MinLanguageVersion,
+		"max_language_version": info.MaxLanguageVersion,
+	}).Set(1)
+	return gauge
+}
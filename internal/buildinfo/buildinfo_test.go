@@ -0,0 +1,55 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package buildinfo_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/kharf/declcd/internal/buildinfo"
+	"github.com/kharf/declcd/pkg/component"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"gotest.tools/v3/assert"
+)
+
+func TestInfo(t *testing.T) {
+	info := buildinfo.New("0.24.0", "abc")
+	assert.DeepEqual(t, info, buildinfo.Info{
+		Version:            "0.24.0",
+		Commit:             "abc",
+		GoVersion:          runtime.Version(),
+		SchemaVersion:      "v0.24.0",
+		MinLanguageVersion: component.MinLanguageVersion,
+		MaxLanguageVersion: component.MaxLanguageVersion,
+	})
+
+	recorder := httptest.NewRecorder()
+	info.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, buildinfo.Path, nil))
+	assert.Equal(t, recorder.Code, http.StatusOK)
+	var served buildinfo.Info
+	assert.NilError(t, json.NewDecoder(recorder.Body).Decode(&served))
+	assert.DeepEqual(t, served, info)
+
+	expected := `
+# HELP declcd_build_info Build information of the controller with a constant value of 1
+# TYPE declcd_build_info gauge
+declcd_build_info{commit="abc",go_version="` + runtime.Version() + `",max_language_version="` + component.MaxLanguageVersion + `",min_language_version="` + component.MinLanguageVersion + `",schema_version="v0.24.0",version="0.24.0"} 1
+`
+	assert.NilError(t, testutil.CollectAndCompare(info.Collector(), strings.NewReader(expected)))
+}
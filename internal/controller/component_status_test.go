@@ -0,0 +1,80 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"errors"
+	"time"
+
+	gitops "github.com/kharf/declcd/api/v1beta1"
+	"github.com/kharf/declcd/pkg/project"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("Component statuses", func() {
+	changedAt := v1.NewTime(time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC))
+	appliedAt := time.Date(2024, 6, 2, 0, 0, 0, 0, time.UTC)
+	previous := []gitops.GitOpsProjectComponentStatus{
+		{
+			ID:              "a",
+			Type:            "Manifest",
+			Revision:        "1",
+			LastAppliedTime: &changedAt,
+			Digest:          "sha256:a",
+			ChangedRevision: "1",
+			ChangedTime:     &changedAt,
+		},
+	}
+
+	It("Should keep the changed revision of unchanged components", func() {
+		statuses := componentStatuses(previous, &project.ReconcileResult{
+			CommitHash: "2",
+			Components: []project.ComponentResult{
+				{ID: "a", Type: "Manifest", AppliedAt: appliedAt, Digest: "sha256:a"},
+			},
+		})
+		Expect(statuses).To(HaveLen(1))
+		Expect(statuses[0].Revision).To(Equal("2"))
+		Expect(statuses[0].LastAppliedTime.Time).To(Equal(appliedAt))
+		Expect(statuses[0].ChangedRevision).To(Equal("1"))
+		Expect(statuses[0].ChangedTime.Time).To(Equal(changedAt.Time))
+	})
+
+	It("Should move the changed revision of changed components", func() {
+		statuses := componentStatuses(previous, &project.ReconcileResult{
+			CommitHash: "2",
+			Components: []project.ComponentResult{
+				{ID: "a", Type: "Manifest", AppliedAt: appliedAt, Digest: "sha256:b"},
+			},
+		})
+		Expect(statuses[0].Digest).To(Equal("sha256:b"))
+		Expect(statuses[0].ChangedRevision).To(Equal("2"))
+		Expect(statuses[0].ChangedTime.Time).To(Equal(appliedAt))
+	})
+
+	It("Should keep the previous digest of failed components", func() {
+		statuses := componentStatuses(previous, &project.ReconcileResult{
+			CommitHash: "2",
+			Components: []project.ComponentResult{
+				{ID: "a", Type: "Manifest", Err: errors.New("conflict")},
+			},
+		})
+		Expect(statuses[0].Message).To(Equal("conflict"))
+		Expect(statuses[0].Digest).To(Equal("sha256:a"))
+		Expect(statuses[0].ChangedRevision).To(Equal("1"))
+	})
+})
@@ -0,0 +1,44 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	gitops "github.com/kharf/declcd/api/v1beta1"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+var _ = Describe("Project events", func() {
+	gProject := &gitops.GitOpsProject{
+		ObjectMeta: v1.ObjectMeta{Name: "shop", Namespace: "declcd-system"},
+	}
+
+	It("Should emit events on the project", func() {
+		recorder := record.NewFakeRecorder(1)
+		controller := &GitOpsProjectController{Recorder: recorder}
+		onEvent := controller.eventRecorderFor(gProject)
+		Expect(onEvent).NotTo(BeNil())
+		onEvent(corev1.EventTypeNormal, "Pruned", "Deleted Deployment shop/api, which is no longer declared")
+		Expect(recorder.Events).To(Receive(Equal("Normal Pruned Deleted Deployment shop/api, which is no longer declared")))
+	})
+
+	It("Should not emit events without a recorder", func() {
+		controller := &GitOpsProjectController{}
+		Expect(controller.eventRecorderFor(gProject)).To(BeNil())
+	})
+})
@@ -18,8 +18,12 @@ package controller
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io/fs"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
@@ -34,23 +38,42 @@ import (
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 
+	coordinationv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	ctrlController "sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/metrics/server"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	"github.com/go-logr/logr"
 	gitops "github.com/kharf/declcd/api/v1beta1"
+	"github.com/kharf/declcd/internal/buildinfo"
+	"github.com/kharf/declcd/pkg/audit"
+	"github.com/kharf/declcd/pkg/bundle"
 	"github.com/kharf/declcd/pkg/component"
+	"github.com/kharf/declcd/pkg/health"
+	"github.com/kharf/declcd/pkg/helm"
+	"github.com/kharf/declcd/pkg/inventory"
 	"github.com/kharf/declcd/pkg/kube"
+	"github.com/kharf/declcd/pkg/lock"
+	"github.com/kharf/declcd/pkg/notification"
 	"github.com/kharf/declcd/pkg/project"
+	"github.com/kharf/declcd/pkg/report"
 	"github.com/kharf/declcd/pkg/vcs"
 	"github.com/prometheus/client_golang/prometheus"
 	helmKube "helm.sh/helm/v3/pkg/kube"
@@ -58,7 +81,22 @@ import (
 )
 
 var (
+	// ErrUnknownInventoryStorage occurs when the controller is configured with an unsupported [InventoryStorage].
+	ErrUnknownInventoryStorage = errors.New("Unknown inventory storage")
+
 	scheme = runtime.NewScheme()
+
+	// failureBackoffSteps are the delays before retrying after consecutive failed reconciliations.
+	failureBackoffSteps = []time.Duration{30 * time.Second, time.Minute, 5 * time.Minute}
+)
+
+const (
+	// maxBuildErrors is the number of build errors reported in the status of a project.
+	maxBuildErrors = 10
+	// maxBuildErrorMessageLength is the number of characters of a build error message reported in the status of a project.
+	maxBuildErrorMessageLength = 256
+	// httpTimeout bounds every request to external systems, so that an unresponsive system does not block reconciliations.
+	httpTimeout = 10 * time.Second
 )
 
 func init() {
@@ -78,6 +116,45 @@ type GitOpsProjectController struct {
 	Reconciler project.Reconciler
 
 	ReconciliationHistogram *prometheus.HistogramVec
+
+	// SkippedCounter counts reconciliations skipped because the revision did not change.
+	SkippedCounter *prometheus.CounterVec
+
+	// UnsupportedLanguageCounter counts reconciliations failed because a project pins an unsupported CUE language version.
+	UnsupportedLanguageCounter *prometheus.CounterVec
+
+	// GitFailureCounter counts failed Git operations by their class.
+	GitFailureCounter *prometheus.CounterVec
+
+	// ComponentFailureCounter counts components, which could not be applied, by their type.
+	ComponentFailureCounter *prometheus.CounterVec
+
+	// InventoryGauge reports the number of objects and Helm releases managed by a project.
+	InventoryGauge *prometheus.GaugeVec
+
+	// Notifier posts status transitions to the notification url of a project and to Notification components.
+	Notifier *notification.Notifier
+
+	// Notifications holds the Notification components declared by the reconciled projects.
+	Notifications *NotificationRegistry
+
+	// Lock prevents concurrent reconciliations of the same project by multiple controllers.
+	Lock *lock.ProjectLock
+
+	// Reporter optionally exports a report of every reconciliation.
+	Reporter *report.Reporter
+
+	// ShardLabels are the pod labels of this controller shard, which are matched against the shard affinity of projects.
+	ShardLabels labels.Set
+
+	// Recorder optionally emits events for projects, e.g. when they fail to build.
+	Recorder record.EventRecorder
+
+	// Triggers optionally receives projects to reconcile immediately, e.g. from the [WebhookReceiver].
+	Triggers <-chan event.GenericEvent
+
+	// MaxConcurrentProjects is the number of projects reconciled in parallel. Defaults to one.
+	MaxConcurrentProjects int
 }
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
@@ -94,14 +171,105 @@ func (controller *GitOpsProjectController) Reconcile(
 	var gProject gitops.GitOpsProject
 	if err := controller.Client.Get(ctx, req.NamespacedName, &gProject); err != nil {
 		log.Error(err, "Unable to fetch GitOpsProject resource from cluster")
+		if k8sErrors.IsNotFound(err) {
+			controller.Notifications.Deregister(req.NamespacedName)
+		}
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
 
+	if !gProject.GetDeletionTimestamp().IsZero() {
+		controller.Notifications.Deregister(req.NamespacedName)
+		if err := controller.teardown(ctx, &gProject); err != nil {
+			log.Error(err, "Unable to tear down GitOpsProject")
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{}, nil
+	}
+
+	if matches, err := matchesShardAffinity(gProject.Spec.ShardAffinity, controller.ShardLabels); !matches {
+		message := "Shard does not match the shard affinity"
+		if err != nil {
+			message = err.Error()
+		}
+		log.Info("Project refused by shard affinity", "reason", message)
+		gProject.Status.Conditions = make([]v1.Condition, 0, 1)
+		if err := controller.updateCondition(ctx, &gProject, v1.Condition{
+			Type:               "ShardAffinity",
+			Reason:             "Mismatch",
+			Message:            message,
+			Status:             "False",
+			LastTransitionTime: triggerTime,
+		}); err != nil {
+			log.Error(err, "Unable to update GitOpsProject status condition to 'ShardAffinity'")
+		}
+		// Changing the affinity or reassigning the shard triggers a new reconciliation.
+		return ctrl.Result{}, nil
+	}
+
+	template, err := controller.readTemplate(ctx, &gProject)
+	if err == nil && template != nil {
+		err = checkTemplate(template, &gProject, controller.ShardLabels)
+	}
+	if err != nil {
+		reason := "Unavailable"
+		switch {
+		case errors.Is(err, ErrTemplateViolation):
+			reason = "Violation"
+		case k8sErrors.IsNotFound(err):
+			reason = "NotFound"
+		}
+		log.Info("Project refused by template", "template", gProject.Spec.TemplateName, "reason", err.Error())
+		gProject.Status.Conditions = make([]v1.Condition, 0, 1)
+		if err := controller.updateCondition(ctx, &gProject, v1.Condition{
+			Type:               "Template",
+			Reason:             reason,
+			Message:            err.Error(),
+			Status:             "False",
+			LastTransitionTime: triggerTime,
+		}); err != nil {
+			log.Error(err, "Unable to update GitOpsProject status condition to 'Template'")
+		}
+		if reason == "Unavailable" {
+			return ctrl.Result{RequeueAfter: pullInterval(&gProject, nil)}, nil
+		}
+		// Changing the project or creating and changing its template triggers a new reconciliation.
+		return ctrl.Result{}, nil
+	}
+
 	requeueResult := ctrl.Result{
-		RequeueAfter: time.Duration(gProject.Spec.PullIntervalSeconds) * time.Second,
+		RequeueAfter: pullInterval(&gProject, template),
+	}
+
+	previousCondition := findCondition(gProject.Status.Conditions, "Finished")
+	previousRevision := gProject.Status.Revision.CommitHash
+
+	if controller.Lock != nil {
+		unlock, err := controller.Lock.Acquire(ctx, gProject.GetNamespace(), gProject.GetName())
+		if err != nil {
+			if !errors.Is(err, lock.ErrLocked) {
+				log.Error(err, "Unable to acquire project lock")
+				return requeueResult, nil
+			}
+			log.Info("Project is reconciled by another controller", "reason", err.Error())
+			// The status belongs to the controller holding the lock, so only the Locked condition is set.
+			if meta.SetStatusCondition(&gProject.Status.Conditions, v1.Condition{
+				Type:               "Locked",
+				Reason:             "HeldByOtherController",
+				Message:            err.Error(),
+				Status:             "True",
+				LastTransitionTime: triggerTime,
+			}) {
+				if err := controller.Client.Status().Update(ctx, &gProject); err != nil {
+					log.Error(err, "Unable to update GitOpsProject status condition to 'Locked'")
+				}
+			}
+			return requeueResult, nil
+		}
+		defer unlock()
 	}
 
 	gProject.Status.Conditions = make([]v1.Condition, 0, 2)
+	gProject.Status.Shard = controller.ShardLabels["declcd/shard"]
 	if err := controller.updateCondition(ctx, &gProject, v1.Condition{
 		Type:               "Running",
 		Reason:             "Interval",
@@ -113,28 +281,212 @@ func (controller *GitOpsProjectController) Reconcile(
 		return requeueResult, nil
 	}
 
-	result, err := controller.Reconciler.Reconcile(ctx, gProject)
+	// Bundles have no branches to preview.
+	if gProject.Spec.Previews != nil && !bundle.IsBundle(gProject.Spec.URL) {
+		// Previews are reconciled on their own, so they do not fail this project.
+		if err := controller.syncPreviews(ctx, &gProject); err != nil {
+			log.Error(err, "Unable to sync previews")
+			if controller.Recorder != nil {
+				controller.Recorder.Event(&gProject, corev1.EventTypeWarning, "PreviewsFailed", err.Error())
+			}
+		}
+	}
+
+	// The reconciler is copied per request, so that stage transitions update this project only.
+	reconciler := controller.Reconciler
+	reconciler.OnStage = func(stage project.Stage) {
+		enterStage(&gProject, stage, v1.Now())
+		if err := controller.Client.Status().Update(ctx, &gProject); err != nil {
+			log.Error(err, "Unable to update GitOpsProject status stage", "stage", stage)
+		}
+	}
+	if reconciler.ReadRequiredCheck == nil {
+		reconciler.ReadRequiredCheck = controller.readRequiredCheck
+	}
+	reconciler.OnEvent = controller.eventRecorderFor(&gProject)
+	if !controller.Notifications.Registered(req.NamespacedName) {
+		// Notifications are only known after building the project.
+		reconciler.SkipUnchangedRevision = false
+	}
+	result, err := reconciler.Reconcile(ctx, gProject)
+	// Persisted with the final condition.
+	enterStage(&gProject, project.StageIdle, v1.Now())
 	if err != nil {
 		log.Error(err, "Reconciling failed")
+		if errors.Is(err, component.ErrUnsupportedLanguageVersion) {
+			controller.UnsupportedLanguageCounter.With(prometheus.Labels{
+				"project": gProject.GetName(),
+				"url":     gProject.Spec.URL,
+			}).Inc()
+		}
+		gProject.Status.BuildErrors = nil
+		message := err.Error()
+		var buildErr *project.BuildError
+		if errors.As(err, &buildErr) {
+			gProject.Status.BuildErrors = buildErrorStatuses(buildErr)
+			message = buildErrorMessage(gProject.Status.BuildErrors, len(buildErr.Issues))
+			if controller.Recorder != nil {
+				controller.Recorder.Event(&gProject, corev1.EventTypeWarning, "BuildFailed", message)
+			}
+		}
+		var gitErr *vcs.GitError
+		if errors.As(err, &gitErr) {
+			recordGitFailure(&gProject, gitErr, v1.Now())
+			controller.GitFailureCounter.With(prometheus.Labels{
+				"project": gProject.GetName(),
+				"url":     gProject.Spec.URL,
+				"class":   string(gitErr.Class),
+			}).Inc()
+		}
+		failedCondition := v1.Condition{
+			Type:               "Finished",
+			Reason:             "Failed",
+			Message:            message,
+			Status:             "False",
+			LastTransitionTime: v1.Now(),
+		}
+		requeueResult = recordFailure(&gProject, requeueResult.RequeueAfter)
+		if err := controller.updateCondition(ctx, &gProject, failedCondition); err != nil {
+			log.Error(err, "Unable to update GitOpsProject status")
+			return requeueResult, nil
+		}
+		controller.notify(ctx, &gProject, previousCondition, previousRevision, failedCondition)
 		return requeueResult, nil
 	}
 
+	gProject.Status.BuildErrors = nil
 	reconciledTime := v1.Now()
+	if result.Unverified {
+		resetFailures(&gProject)
+		if err := controller.updateCondition(ctx, &gProject, v1.Condition{
+			Type:   "Finished",
+			Reason: "Unverified",
+			Message: fmt.Sprintf(
+				"Skipped commit %s, because check %s is %s",
+				result.CommitHash,
+				gProject.Spec.RequiredCheck.Name,
+				strings.ToLower(string(result.CheckState)),
+			),
+			Status:             "True",
+			LastTransitionTime: reconciledTime,
+		}); err != nil {
+			log.Error(err, "Unable to update GitOpsProject status")
+			return requeueResult, nil
+		}
+
+		log.Info("Reconciling skipped", "reason", "Unverified")
+		return requeueResult, nil
+	}
+
+	if result.Skipped {
+		resetFailures(&gProject)
+		gProject.Status.LastSkippedAt = &reconciledTime
+		gProject.Status.SkippedCount++
+		if err := controller.updateCondition(ctx, &gProject, v1.Condition{
+			Type:               "Finished",
+			Reason:             "Unchanged",
+			Message:            "Skipped unchanged revision",
+			Status:             "True",
+			LastTransitionTime: reconciledTime,
+		}); err != nil {
+			log.Error(err, "Unable to update GitOpsProject status")
+			return requeueResult, nil
+		}
+
+		controller.SkippedCounter.With(prometheus.Labels{
+			"project": gProject.GetName(),
+			"url":     gProject.Spec.URL,
+		}).Inc()
+
+		log.Info("Reconciling skipped")
+		return requeueResult, nil
+	}
+
+	controller.Notifications.Register(req.NamespacedName, result.Notifications)
+
 	gProject.Status.Revision = gitops.GitOpsProjectRevision{
 		CommitHash:    result.CommitHash,
 		ReconcileTime: reconciledTime,
+		Pinned:        gProject.Spec.Revision,
 	}
 
-	if err := controller.updateCondition(ctx, &gProject, v1.Condition{
+	gProject.Status.Namespaces = make([]gitops.GitOpsProjectNamespaceStatus, 0, len(result.Namespaces))
+	for _, namespaceResult := range result.Namespaces {
+		namespaceStatus := gitops.GitOpsProjectNamespaceStatus{
+			Name:      namespaceResult.Namespace,
+			Succeeded: namespaceResult.Err == nil,
+		}
+		if namespaceResult.Err != nil {
+			namespaceStatus.Message = namespaceResult.Err.Error()
+		}
+		gProject.Status.Namespaces = append(gProject.Status.Namespaces, namespaceStatus)
+	}
+
+	gProject.Status.Components = componentStatuses(gProject.Status.Components, result)
+	for _, componentResult := range result.Components {
+		if componentResult.Err == nil {
+			continue
+		}
+		controller.ComponentFailureCounter.With(prometheus.Labels{
+			"project": gProject.GetName(),
+			"type":    componentResult.Type,
+		}).Inc()
+	}
+	controller.InventoryGauge.With(prometheus.Labels{
+		"project": gProject.GetName(),
+	}).Set(float64(result.InventorySize))
+
+	gProject.Status.PendingPromotions = make([]gitops.GitOpsProjectPendingPromotion, 0, len(result.PendingPromotions))
+	for _, pendingPromotion := range result.PendingPromotions {
+		pendingStatus := gitops.GitOpsProjectPendingPromotion{
+			Component: pendingPromotion.Component,
+			Digest:    pendingPromotion.Digest,
+		}
+		if pendingPromotion.PromoteAfter != nil {
+			promoteAfter := v1.NewTime(*pendingPromotion.PromoteAfter)
+			pendingStatus.PromoteAfter = &promoteAfter
+			// Apply the version as soon as it is due instead of waiting for the next pull interval.
+			if due := time.Until(*pendingPromotion.PromoteAfter); due < requeueResult.RequeueAfter {
+				requeueResult.RequeueAfter = due
+			}
+		}
+		gProject.Status.PendingPromotions = append(gProject.Status.PendingPromotions, pendingStatus)
+	}
+
+	// Persisted with the final condition.
+	gProject.Status.Conditions = append(gProject.Status.Conditions, healthCondition(result.Health, reconciledTime))
+
+	finishedCondition := v1.Condition{
 		Type:               "Finished",
 		Reason:             "Success",
 		Message:            "Reconciled",
 		Status:             "True",
 		LastTransitionTime: reconciledTime,
-	}); err != nil {
+	}
+	if failed := result.Failed(); len(failed) != 0 {
+		failedNamespaces := make([]string, 0, len(failed))
+		for _, namespaceResult := range failed {
+			failedNamespaces = append(failedNamespaces, namespaceResult.Namespace)
+		}
+		finishedCondition.Reason = "PartialFailure"
+		finishedCondition.Message = fmt.Sprintf(
+			"Reconciled with failures in namespaces: %s",
+			strings.Join(failedNamespaces, ", "),
+		)
+		requeueResult = recordFailure(&gProject, requeueResult.RequeueAfter)
+	} else {
+		resetFailures(&gProject)
+	}
+
+	if err := controller.updateCondition(ctx, &gProject, finishedCondition); err != nil {
 		log.Error(err, "Unable to update GitOpsProject status")
 		return requeueResult, nil
 	}
+	controller.notify(ctx, &gProject, previousCondition, previousRevision, finishedCondition)
+	controller.report(ctx, &gProject, result)
+	if err := controller.refreshState(ctx, &gProject, result, reconciledTime); err != nil {
+		log.Error(err, "Unable to refresh ProjectState")
+	}
 
 	controller.ReconciliationHistogram.With(prometheus.Labels{
 		"project": gProject.GetName(),
@@ -145,35 +497,468 @@ func (controller *GitOpsProjectController) Reconcile(
 	return requeueResult, nil
 }
 
+// enterStage finishes the running stage and starts the given one.
+// Cloning starts a new reconciliation and drops the stages of the previous one.
+func enterStage(gProject *gitops.GitOpsProject, stage project.Stage, now v1.Time) {
+	status := &gProject.Status
+	if stage == project.StageCloning {
+		status.Stages = nil
+	}
+	if running := len(status.Stages) - 1; running >= 0 && status.Stages[running].FinishedAt == nil {
+		status.Stages[running].FinishedAt = &now
+	}
+	status.Phase = string(stage)
+	if stage != project.StageIdle {
+		status.Stages = append(status.Stages, gitops.GitOpsProjectStage{
+			Name:      string(stage),
+			StartedAt: now,
+		})
+	}
+}
+
+// recordFailure counts a failed reconciliation and schedules the retry with the failure backoff.
+func recordFailure(gProject *gitops.GitOpsProject, interval time.Duration) ctrl.Result {
+	gProject.Status.ConsecutiveFailures++
+	backoff := failureBackoff(gProject.Status.ConsecutiveFailures, interval)
+	nextRetryAt := v1.NewTime(time.Now().Add(backoff))
+	gProject.Status.NextRetryAt = &nextRetryAt
+	return ctrl.Result{
+		RequeueAfter: backoff,
+	}
+}
+
+// matchesShardAffinity reports whether a shard with the given labels is allowed to reconcile a project.
+// Projects without affinity match every shard.
+func matchesShardAffinity(affinity *v1.LabelSelector, shardLabels labels.Set) (bool, error) {
+	if affinity == nil {
+		return true, nil
+	}
+	selector, err := v1.LabelSelectorAsSelector(affinity)
+	if err != nil {
+		return false, err
+	}
+	return selector.Matches(shardLabels), nil
+}
+
+// readShardLabels parses the pod labels of the controller, which the downward API writes as key="value" lines.
+// Installations not mounting the labels identify the shard by its name only.
+func readShardLabels(path string, shard string) (labels.Set, error) {
+	shardLabels := labels.Set{
+		"declcd/shard": shard,
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return shardLabels, nil
+		}
+		return nil, err
+	}
+	for _, line := range strings.Split(string(content), "\n") {
+		key, value, found := strings.Cut(strings.TrimSpace(line), "=")
+		if !found {
+			continue
+		}
+		unquoted, err := strconv.Unquote(value)
+		if err != nil {
+			return nil, err
+		}
+		shardLabels[key] = unquoted
+	}
+	return shardLabels, nil
+}
+
+// recordGitFailure counts a failed Git operation in the status entry of its class.
+func recordGitFailure(gProject *gitops.GitOpsProject, gitErr *vcs.GitError, now v1.Time) {
+	status := &gProject.Status
+	for i := range status.GitFailures {
+		failure := &status.GitFailures[i]
+		if failure.Class == string(gitErr.Class) {
+			failure.Count++
+			failure.LastOccurredAt = now
+			failure.Message = gitErr.Error()
+			return
+		}
+	}
+	status.GitFailures = append(status.GitFailures, gitops.GitOpsProjectGitFailure{
+		Class:          string(gitErr.Class),
+		Count:          1,
+		LastOccurredAt: now,
+		Message:        gitErr.Error(),
+	})
+}
+
+func resetFailures(gProject *gitops.GitOpsProject) {
+	gProject.Status.ConsecutiveFailures = 0
+	gProject.Status.NextRetryAt = nil
+}
+
+// failureBackoff returns the delay before retrying after the given number of consecutive failures.
+// It grows with every failure, so transient failures recover quickly while persistent failures don't hot-loop,
+// but it never exceeds the pull interval.
+func failureBackoff(failures int64, interval time.Duration) time.Duration {
+	step := int(failures) - 1
+	if step < 0 {
+		step = 0
+	}
+	if step >= len(failureBackoffSteps) {
+		step = len(failureBackoffSteps) - 1
+	}
+	backoff := failureBackoffSteps[step]
+	if backoff > interval {
+		return interval
+	}
+	return backoff
+}
+
+// buildErrorStatuses reports the first issues of a failed build with truncated messages,
+// so that the status stays small and stable for large projects.
+func buildErrorStatuses(buildErr *project.BuildError) []gitops.GitOpsProjectBuildError {
+	issues := buildErr.Issues[:min(len(buildErr.Issues), maxBuildErrors)]
+	statuses := make([]gitops.GitOpsProjectBuildError, 0, len(issues))
+	for _, issue := range issues {
+		statuses = append(statuses, gitops.GitOpsProjectBuildError{
+			File:    issue.File,
+			Line:    issue.Line,
+			Column:  issue.Column,
+			Message: truncate(issue.Message, maxBuildErrorMessageLength),
+			Source:  issue.Source,
+		})
+	}
+	return statuses
+}
+
+// buildErrorMessage formats the reported issues as file:line:column: message lines
+// and notes how many issues were left out.
+func buildErrorMessage(statuses []gitops.GitOpsProjectBuildError, total int) string {
+	lines := make([]string, 0, len(statuses)+1)
+	for _, status := range statuses {
+		if status.File == "" {
+			lines = append(lines, status.Message)
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("%s:%d:%d: %s", status.File, status.Line, status.Column, status.Message))
+	}
+	if omitted := total - len(statuses); omitted > 0 {
+		lines = append(lines, fmt.Sprintf("%d more errors", omitted))
+	}
+	return "Build failed:\n" + strings.Join(lines, "\n")
+}
+
+func truncate(s string, length int) string {
+	runes := []rune(s)
+	if len(runes) <= length {
+		return s
+	}
+	return string(runes[:length]) + "..."
+}
+
+// eventRecorderFor returns a function emitting events on the project, if the controller has a recorder.
+func (controller *GitOpsProjectController) eventRecorderFor(
+	gProject *gitops.GitOpsProject,
+) func(eventType string, reason string, message string) {
+	if controller.Recorder == nil {
+		return nil
+	}
+	return func(eventType string, reason string, message string) {
+		controller.Recorder.Event(gProject, eventType, reason, message)
+	}
+}
+
+// componentStatuses reports the outcome of every reconciled component.
+// Components, which could not be applied, keep the revision and time they were last applied at.
+// The changed revision and time only move on, when the digest of the declared content changes.
+func componentStatuses(
+	previous []gitops.GitOpsProjectComponentStatus,
+	result *project.ReconcileResult,
+) []gitops.GitOpsProjectComponentStatus {
+	previousByID := make(map[string]gitops.GitOpsProjectComponentStatus, len(previous))
+	for _, componentStatus := range previous {
+		previousByID[componentStatus.ID] = componentStatus
+	}
+	statuses := make([]gitops.GitOpsProjectComponentStatus, 0, len(result.Components))
+	for _, componentResult := range result.Components {
+		componentStatus := gitops.GitOpsProjectComponentStatus{
+			ID:   componentResult.ID,
+			Type: componentResult.Type,
+		}
+		previousStatus, found := previousByID[componentResult.ID]
+		if componentResult.Err != nil {
+			componentStatus.Message = componentResult.Err.Error()
+			if found {
+				componentStatus.Revision = previousStatus.Revision
+				componentStatus.LastAppliedTime = previousStatus.LastAppliedTime
+				componentStatus.Digest = previousStatus.Digest
+				componentStatus.ChangedRevision = previousStatus.ChangedRevision
+				componentStatus.ChangedTime = previousStatus.ChangedTime
+			}
+		} else {
+			appliedAt := v1.NewTime(componentResult.AppliedAt)
+			componentStatus.Revision = result.CommitHash
+			componentStatus.LastAppliedTime = &appliedAt
+			componentStatus.Digest = componentResult.Digest
+			componentStatus.ChangedRevision = result.CommitHash
+			componentStatus.ChangedTime = &appliedAt
+			// Unchanged components keep the revision they last changed with.
+			if found && previousStatus.Digest == componentResult.Digest && previousStatus.ChangedTime != nil {
+				componentStatus.ChangedRevision = previousStatus.ChangedRevision
+				componentStatus.ChangedTime = previousStatus.ChangedTime
+			}
+		}
+		statuses = append(statuses, componentStatus)
+	}
+	return statuses
+}
+
+// healthCondition summarizes the readiness of all applied objects.
+// Objects, which are still progressing, leave the health unknown, because they may become ready without intervention.
+func healthCondition(report health.Report, now v1.Time) v1.Condition {
+	condition := v1.Condition{
+		Type:               "Healthy",
+		Reason:             string(report.Status),
+		Message:            "All objects are ready",
+		Status:             "True",
+		LastTransitionTime: now,
+	}
+	switch report.Status {
+	case health.Healthy:
+		return condition
+	case health.Degraded:
+		condition.Status = "False"
+	default:
+		condition.Status = "Unknown"
+	}
+	unhealthy := make([]string, 0, len(report.Unhealthy))
+	for _, objHealth := range report.Unhealthy {
+		unhealthy = append(unhealthy, fmt.Sprintf(
+			"%s %s/%s: %s",
+			objHealth.Kind,
+			objHealth.Namespace,
+			objHealth.Name,
+			objHealth.Message,
+		))
+	}
+	condition.Message = fmt.Sprintf("Unhealthy objects: %s", strings.Join(unhealthy, "; "))
+	return condition
+}
+
+func findCondition(conditions []v1.Condition, conditionType string) *v1.Condition {
+	for i := range conditions {
+		if conditions[i].Type == conditionType {
+			return &conditions[i]
+		}
+	}
+	return nil
+}
+
+// notify posts the outcome of a reconciliation to the notification url of the project and all matching Notification components,
+// when the outcome or the applied revision changed.
+// Failing to notify does not fail the reconciliation.
+func (controller *GitOpsProjectController) notify(
+	ctx context.Context,
+	gProject *gitops.GitOpsProject,
+	previousCondition *v1.Condition,
+	previousRevision string,
+	condition v1.Condition,
+) {
+	if controller.Notifier == nil {
+		return
+	}
+
+	if previousCondition != nil && previousRevision == gProject.Status.Revision.CommitHash {
+		previousReason := previousCondition.Reason
+		// A skipped reconciliation keeps the previously applied state.
+		if previousReason == "Unchanged" {
+			previousReason = "Success"
+		}
+		if previousReason == condition.Reason {
+			return
+		}
+	}
+
+	severity := notification.SeverityInfo
+	if condition.Status != "True" || condition.Reason == "PartialFailure" {
+		severity = notification.SeverityError
+	}
+	event := notification.Event{
+		Project:   gProject.GetName(),
+		Namespace: gProject.GetNamespace(),
+		URL:       gProject.Spec.URL,
+		Revision:  gProject.Status.Revision.CommitHash,
+		Reason:    condition.Reason,
+		Message:   condition.Message,
+		Succeeded: condition.Status == "True",
+		Severity:  severity,
+		Shard:     gProject.Status.Shard,
+		Time:      condition.LastTransitionTime.Time,
+	}
+	controller.dispatch(ctx, event)
+
+	if gProject.Spec.Notification == nil {
+		return
+	}
+
+	log := controller.Log.WithValues("project", gProject.GetName())
+
+	var secret []byte
+	if gProject.Spec.Notification.SecretName != "" {
+		var secretObj corev1.Secret
+		if err := controller.Client.Get(ctx, types.NamespacedName{
+			Name:      gProject.Spec.Notification.SecretName,
+			Namespace: gProject.GetNamespace(),
+		}, &secretObj); err != nil {
+			log.Error(err, "Unable to read notification secret")
+			return
+		}
+		secret = secretObj.Data["secret"]
+	}
+
+	if err := controller.Notifier.Notify(ctx, gProject.Spec.Notification.URL, secret, event); err != nil {
+		log.Error(err, "Unable to send notification")
+	}
+}
+
+// readRequiredCheck reads the state of the required check of a commit with the token of the project.
+func (controller *GitOpsProjectController) readRequiredCheck(
+	ctx context.Context,
+	gProject gitops.GitOpsProject,
+	commit string,
+) (vcs.CheckState, error) {
+	requiredCheck := gProject.Spec.RequiredCheck
+	var secret corev1.Secret
+	if err := controller.Client.Get(ctx, types.NamespacedName{
+		Name:      requiredCheck.TokenSecretName,
+		Namespace: gProject.GetNamespace(),
+	}, &secret); err != nil {
+		return "", err
+	}
+
+	checkReader, err := vcs.NewCheckReader(
+		&http.Client{Timeout: httpTimeout},
+		requiredCheck.Provider,
+		requiredCheck.BaseURL,
+		string(secret.Data["token"]),
+	)
+	if err != nil {
+		return "", err
+	}
+	return checkReader.ReadCheck(ctx, requiredCheck.Repository, commit, requiredCheck.Name)
+}
+
+// report exports the outcome of a reconciliation per namespace.
+// Failing to export does not fail the reconciliation.
+func (controller *GitOpsProjectController) report(
+	ctx context.Context,
+	gProject *gitops.GitOpsProject,
+	result *project.ReconcileResult,
+) {
+	if controller.Reporter == nil {
+		return
+	}
+
+	findings := make([]report.Finding, 0, len(result.Namespaces))
+	for _, namespaceResult := range result.Namespaces {
+		finding := report.Finding{
+			Namespace: namespaceResult.Namespace,
+			Succeeded: namespaceResult.Err == nil,
+		}
+		if namespaceResult.Err != nil {
+			finding.Message = namespaceResult.Err.Error()
+		}
+		findings = append(findings, finding)
+	}
+
+	if err := controller.Reporter.Publish(ctx, report.Report{
+		Project:  gProject.GetName(),
+		URL:      gProject.Spec.URL,
+		Revision: result.CommitHash,
+		Time:     gProject.Status.Revision.ReconcileTime.Time,
+		Findings: findings,
+	}); err != nil {
+		controller.Log.Error(err, "Unable to publish report", "project", gProject.GetName())
+	}
+}
+
 func (reconciler *GitOpsProjectController) updateCondition(
 	ctx context.Context,
 	gProject *gitops.GitOpsProject,
 	condition v1.Condition,
 ) error {
 	gProject.Status.Conditions = append(gProject.Status.Conditions, condition)
+	if condition.Type == "Finished" {
+		gProject.Status.Ready = "False"
+		if condition.Status == "True" && condition.Reason != "PartialFailure" {
+			gProject.Status.Ready = "True"
+		}
+	}
 	if err := reconciler.Client.Status().Update(ctx, gProject); err != nil {
 		return err
 	}
 	return nil
 }
 
+// controllerOptions bounds the projects reconciled in parallel,
+// so that a huge project occupies a single worker instead of starving the other projects of the shard.
+func (reconciler *GitOpsProjectController) controllerOptions() ctrlController.Options {
+	return ctrlController.Options{
+		MaxConcurrentReconciles: max(reconciler.MaxConcurrentProjects, 1),
+	}
+}
+
 // SetupWithManager sets up the controller with the Manager.
 func (reconciler *GitOpsProjectController) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
+	builder := ctrl.NewControllerManagedBy(mgr).
 		For(&gitops.GitOpsProject{}).
+		Watches(
+			&gitops.ClusterGitOpsProjectTemplate{},
+			handler.EnqueueRequestsFromMapFunc(reconciler.projectsForTemplate),
+		).
 		WithEventFilter(predicate.GenerationChangedPredicate{}).
-		Complete(reconciler)
+		WithOptions(reconciler.controllerOptions())
+	if reconciler.Triggers != nil {
+		// Raw sources bypass the event filter, as triggered projects did not change.
+		builder = builder.WatchesRawSource(
+			source.Channel(reconciler.Triggers, &handler.EnqueueRequestForObject{}),
+		)
+	}
+	return builder.Complete(reconciler)
 }
 
 type setupOptions struct {
-	NamePodinfoPath       string
-	NamespacePodinfoPath  string
-	ShardPodinfoPath      string
-	MetricsAddr           string
-	ProbeAddr             string
-	LogLevel              int
-	InsecureSkipTLSverify bool
-	PlainHTTP             bool
+	NamePodinfoPath            string
+	PodNamePodinfoPath         string
+	NamespacePodinfoPath       string
+	ShardPodinfoPath           string
+	ShardLabelsPodinfoPath     string
+	MetricsAddr                string
+	MetricsSecure              bool
+	MetricsCertDir             string
+	ProbeAddr                  string
+	LogLevel                   int
+	InsecureSkipTLSverify      bool
+	InsecureSkipTLSverifyHosts []string
+	PlainHTTP                  bool
+	PlainHTTPHosts             []string
+	AuditRepository            string
+	SkipUnchangedRevisions     bool
+	PruneDryRun                bool
+	ComponentRegistry          *component.Registry
+	ReportDir                  string
+	ReportWebhookURL           string
+	ReportFormat               string
+	SOPSKeyDir                 string
+	InventoryKeyPath           string
+	InventoryStorage           string
+	WebhookReceiverAddr        string
+	ProjectNamespaces          []string
+	HelmMaxHistory             int
+	MaxConcurrentProjects      int
+	SerializeSharedCRDs        bool
+	LeaderElection             bool
+	LeaseDuration              time.Duration
+	RenewDeadline              time.Duration
+	RetryPeriod                time.Duration
+	Version                    string
+	Commit                     string
 }
 
 type option interface {
@@ -188,6 +973,16 @@ func (opt NamePodinfoPath) apply(options *setupOptions) {
 	}
 }
 
+// PodNamePodinfoPath is the file holding the name of the controller pod,
+// which identifies the replica holding project locks.
+type PodNamePodinfoPath string
+
+func (opt PodNamePodinfoPath) apply(options *setupOptions) {
+	if opt != "" {
+		options.PodNamePodinfoPath = string(opt)
+	}
+}
+
 type NamespacePodinfoPath string
 
 func (opt NamespacePodinfoPath) apply(options *setupOptions) {
@@ -204,6 +999,14 @@ func (opt ShardPodinfoPath) apply(options *setupOptions) {
 	}
 }
 
+type ShardLabelsPodinfoPath string
+
+func (opt ShardLabelsPodinfoPath) apply(options *setupOptions) {
+	if opt != "" {
+		options.ShardLabelsPodinfoPath = string(opt)
+	}
+}
+
 type MetricsAddr string
 
 func (opt MetricsAddr) apply(options *setupOptions) {
@@ -212,6 +1015,20 @@ func (opt MetricsAddr) apply(options *setupOptions) {
 	}
 }
 
+type MetricsSecure bool
+
+func (opt MetricsSecure) apply(options *setupOptions) {
+	options.MetricsSecure = bool(opt)
+}
+
+type MetricsCertDir string
+
+func (opt MetricsCertDir) apply(options *setupOptions) {
+	if opt != "" {
+		options.MetricsCertDir = string(opt)
+	}
+}
+
 type ProbeAddr string
 
 func (opt ProbeAddr) apply(options *setupOptions) {
@@ -226,12 +1043,191 @@ func (opt InsecureSkipTLSverify) apply(options *setupOptions) {
 	options.InsecureSkipTLSverify = bool(opt)
 }
 
+// InsecureSkipTLSverifyHosts skips certificate verification only for the listed registry hosts.
+type InsecureSkipTLSverifyHosts []string
+
+func (opt InsecureSkipTLSverifyHosts) apply(options *setupOptions) {
+	options.InsecureSkipTLSverifyHosts = append(options.InsecureSkipTLSverifyHosts, opt...)
+}
+
 type PlainHTTP bool
 
 func (opt PlainHTTP) apply(options *setupOptions) {
 	options.PlainHTTP = bool(opt)
 }
 
+// PlainHTTPHosts forces http only for the listed registry hosts.
+type PlainHTTPHosts []string
+
+func (opt PlainHTTPHosts) apply(options *setupOptions) {
+	options.PlainHTTPHosts = append(options.PlainHTTPHosts, opt...)
+}
+
+type AuditRepository string
+
+func (opt AuditRepository) apply(options *setupOptions) {
+	options.AuditRepository = string(opt)
+}
+
+type SkipUnchangedRevisions bool
+
+func (opt SkipUnchangedRevisions) apply(options *setupOptions) {
+	options.SkipUnchangedRevisions = bool(opt)
+}
+
+// PruneDryRun only reports the objects and Helm releases garbage collection would remove instead of removing them.
+type PruneDryRun bool
+
+func (opt PruneDryRun) apply(options *setupOptions) {
+	options.PruneDryRun = bool(opt)
+}
+
+type ReportDir string
+
+func (opt ReportDir) apply(options *setupOptions) {
+	options.ReportDir = string(opt)
+}
+
+type ReportWebhookURL string
+
+func (opt ReportWebhookURL) apply(options *setupOptions) {
+	options.ReportWebhookURL = string(opt)
+}
+
+type ReportFormat string
+
+func (opt ReportFormat) apply(options *setupOptions) {
+	if opt != "" {
+		options.ReportFormat = string(opt)
+	}
+}
+
+// SOPSKeyDir is the directory holding the PGP private keys, which decrypt SOPS encrypted components.
+type SOPSKeyDir string
+
+func (opt SOPSKeyDir) apply(options *setupOptions) {
+	if opt != "" {
+		options.SOPSKeyDir = string(opt)
+	}
+}
+
+// InventoryKeyPath is the file holding the base64 encoded AES-256 key, which encrypts the inventory at rest.
+// Encryption is disabled, when the file does not exist.
+// The repository cache in the temporary directory of the container is not encrypted.
+type InventoryKeyPath string
+
+func (opt InventoryKeyPath) apply(options *setupOptions) {
+	if opt != "" {
+		options.InventoryKeyPath = string(opt)
+	}
+}
+
+// InventoryStorage is where the inventories of projects are stored:
+// "volume" stores them in the /inventory volume and "secret" in Secrets in the namespace of the controller,
+// which lets the controller run without a volume. Defaults to "volume".
+type InventoryStorage string
+
+func (opt InventoryStorage) apply(options *setupOptions) {
+	if opt != "" {
+		options.InventoryStorage = string(opt)
+	}
+}
+
+// ComponentRegistry registers handlers for custom component types.
+type ComponentRegistry struct {
+	Registry *component.Registry
+}
+
+func (opt ComponentRegistry) apply(options *setupOptions) {
+	options.ComponentRegistry = opt.Registry
+}
+
+// WebhookReceiverAddr is the address the [WebhookReceiver] binds to. Empty disables the receiver.
+type WebhookReceiverAddr string
+
+func (opt WebhookReceiverAddr) apply(options *setupOptions) {
+	options.WebhookReceiverAddr = string(opt)
+}
+
+// ProjectNamespaces restricts the controller to GitOpsProjects in these namespaces, e.g. to isolate multiple declcd instances on one cluster.
+// Empty watches all namespaces.
+type ProjectNamespaces []string
+
+func (opt ProjectNamespaces) apply(options *setupOptions) {
+	options.ProjectNamespaces = append(options.ProjectNamespaces, opt...)
+}
+
+// HelmMaxHistory limits the stored versions per Helm release. Defaults to [helm.DefaultMaxHistory].
+type HelmMaxHistory int
+
+func (opt HelmMaxHistory) apply(options *setupOptions) {
+	options.HelmMaxHistory = int(opt)
+}
+
+// MaxConcurrentProjects is the number of projects a shard reconciles in parallel.
+// Components of a project are applied with the concurrency of the project on top of it. Defaults to one.
+type MaxConcurrentProjects int
+
+func (opt MaxConcurrentProjects) apply(options *setupOptions) {
+	options.MaxConcurrentProjects = int(opt)
+}
+
+// SerializeSharedCRDs serializes installs and upgrades of Helm releases, which bundle the same CRDs,
+// so that they do not race writing them. Releases without shared CRDs are still applied in parallel.
+type SerializeSharedCRDs bool
+
+func (opt SerializeSharedCRDs) apply(options *setupOptions) {
+	options.SerializeSharedCRDs = bool(opt)
+}
+
+// LeaderElection lets only one replica of a shard reconcile, while standby replicas serve health probes
+// and take over once the lease of the leader expires. Enabled by default.
+// Disabling it is only safe with a single replica per shard.
+type LeaderElection bool
+
+func (opt LeaderElection) apply(options *setupOptions) {
+	options.LeaderElection = bool(opt)
+}
+
+// LeaseDuration is the time standby replicas wait before taking over the lease of a leader, which stopped renewing it.
+// Zero keeps the default of controller-runtime.
+type LeaseDuration time.Duration
+
+func (opt LeaseDuration) apply(options *setupOptions) {
+	options.LeaseDuration = time.Duration(opt)
+}
+
+// RenewDeadline is the time the leader retries renewing its lease before it stops reconciling.
+// Zero keeps the default of controller-runtime.
+type RenewDeadline time.Duration
+
+func (opt RenewDeadline) apply(options *setupOptions) {
+	options.RenewDeadline = time.Duration(opt)
+}
+
+// RetryPeriod is the time replicas wait between attempts to acquire or renew the lease.
+// Zero keeps the default of controller-runtime.
+type RetryPeriod time.Duration
+
+func (opt RetryPeriod) apply(options *setupOptions) {
+	options.RetryPeriod = time.Duration(opt)
+}
+
+// Version is the release of the controller, which is served with its build info. Empty for development builds.
+type Version string
+
+func (opt Version) apply(options *setupOptions) {
+	options.Version = string(opt)
+}
+
+// Commit is the commit the controller is built from, which is served with its build info.
+// Defaults to the VCS revision recorded by the Go toolchain.
+type Commit string
+
+func (opt Commit) apply(options *setupOptions) {
+	options.Commit = string(opt)
+}
+
 type LogLevel int
 
 func (opt LogLevel) apply(options *setupOptions) {
@@ -240,14 +1236,23 @@ func (opt LogLevel) apply(options *setupOptions) {
 
 func Setup(cfg *rest.Config, options ...option) (manager.Manager, error) {
 	opts := &setupOptions{
-		NamePodinfoPath:       "/podinfo/name",
-		NamespacePodinfoPath:  "/podinfo/namespace",
-		ShardPodinfoPath:      "/podinfo/shard",
-		MetricsAddr:           ":8080",
-		ProbeAddr:             ":8081",
-		InsecureSkipTLSverify: false,
-		PlainHTTP:             false,
-		LogLevel:              0,
+		NamePodinfoPath:        "/podinfo/name",
+		PodNamePodinfoPath:     "/podinfo/pod",
+		NamespacePodinfoPath:   "/podinfo/namespace",
+		ShardPodinfoPath:       "/podinfo/shard",
+		ShardLabelsPodinfoPath: "/podinfo/labels",
+		MetricsAddr:            ":8080",
+		MetricsSecure:          false,
+		MetricsCertDir:         "",
+		ProbeAddr:              ":8081",
+		InsecureSkipTLSverify:  false,
+		PlainHTTP:              false,
+		LogLevel:               0,
+		ReportFormat:           string(report.Markdown),
+		SOPSKeyDir:             "/sops",
+		InventoryKeyPath:       "/inventory-encryption/key",
+		InventoryStorage:       "volume",
+		LeaderElection:         true,
 	}
 
 	for _, opt := range options {
@@ -268,6 +1273,12 @@ func Setup(cfg *rest.Config, options ...option) (manager.Manager, error) {
 
 	controllerName := strings.TrimSpace(string(nameBytes))
 
+	podName, err := readPodName(opts.PodNamePodinfoPath)
+	if err != nil {
+		log.Error(err, "Unable to read pod name")
+		return nil, err
+	}
+
 	namespaceBytes, err := os.ReadFile(opts.NamespacePodinfoPath)
 	if err != nil {
 		log.Error(err, "Unable to read namespace")
@@ -284,32 +1295,66 @@ func Setup(cfg *rest.Config, options ...option) (manager.Manager, error) {
 
 	shard := strings.TrimSpace(string(shardBytes))
 
+	shardLabels, err := readShardLabels(opts.ShardLabelsPodinfoPath, shard)
+	if err != nil {
+		log.Error(err, "Unable to read shard labels")
+		return nil, err
+	}
+
 	labelReq, err := labels.NewRequirement("declcd/shard", selection.Equals, []string{shard})
 	if err != nil {
 		log.Error(err, "Unable to set label requirements")
 		return nil, err
 	}
 
+	var projectNamespaces map[string]cache.Config
+	if len(opts.ProjectNamespaces) != 0 {
+		projectNamespaces = make(map[string]cache.Config, len(opts.ProjectNamespaces))
+		for _, projectNamespace := range opts.ProjectNamespaces {
+			projectNamespaces[projectNamespace] = cache.Config{}
+		}
+	}
+
+	buildInfo := buildinfo.New(opts.Version, opts.Commit)
+
 	mgr, err := ctrl.NewManager(cfg, ctrl.Options{
 		Scheme: scheme,
 		Metrics: server.Options{
 			BindAddress: opts.MetricsAddr,
+			// Falls back to a self-signed certificate when CertDir holds no key pair.
+			// Certificates in CertDir are watched and reloaded on rotation.
+			SecureServing: opts.MetricsSecure,
+			CertDir:       opts.MetricsCertDir,
 			ExtraHandlers: map[string]http.Handler{
 				"/debug/pprof/": http.DefaultServeMux,
+				buildinfo.Path:  buildInfo.Handler(),
 			},
 		},
-		HealthProbeBindAddress:  opts.ProbeAddr,
-		LeaderElection:          true,
+		HealthProbeBindAddress: opts.ProbeAddr,
+		// Replicas of a shard share a lease, so that only one of them reconciles.
+		LeaderElection:          opts.LeaderElection,
 		LeaderElectionID:        shard,
 		LeaderElectionNamespace: namespace,
+		// The process exits once the manager stopped, so a rolling update hands over the lease without waiting for its expiry.
+		LeaderElectionReleaseOnCancel: true,
+		LeaseDuration:                 durationOrDefault(opts.LeaseDuration),
+		RenewDeadline:                 durationOrDefault(opts.RenewDeadline),
+		RetryPeriod:                   durationOrDefault(opts.RetryPeriod),
 		Cache: cache.Options{
 			ByObject: map[client.Object]cache.ByObject{
 				&gitops.GitOpsProject{}: {
 					Label: labels.NewSelector().
 						Add(*labelReq),
+					Namespaces: projectNamespaces,
 				},
 			},
 		},
+		Client: client.Options{
+			Cache: &client.CacheOptions{
+				// Secrets referenced by projects and project locks are read rarely, so they are not worth a cluster wide informer.
+				DisableFor: []client.Object{&corev1.Secret{}, &coordinationv1.Lease{}},
+			},
+		},
 	})
 	if err != nil {
 		log.Error(err, "Unable to create manager")
@@ -317,6 +1362,35 @@ func Setup(cfg *rest.Config, options ...option) (manager.Manager, error) {
 	}
 
 	componentBuilder := component.NewBuilder()
+	componentBuilder.Registry = opts.ComponentRegistry
+	componentBuilder.Decrypter, err = component.NewSOPSDecrypter(opts.SOPSKeyDir)
+	if err != nil {
+		log.Error(err, "Unable to read SOPS keys")
+		return nil, err
+	}
+
+	inventoryKey, err := inventory.ReadEncryptionKey(opts.InventoryKeyPath)
+	if err != nil {
+		log.Error(err, "Unable to read inventory encryption key")
+		return nil, err
+	}
+
+	var inventoryBackend func(projectUID string) inventory.Backend
+	switch opts.InventoryStorage {
+	case "volume":
+	case "secret":
+		inventoryBackend = func(projectUID string) inventory.Backend {
+			return &inventory.SecretBackend{
+				Client:    mgr.GetClient(),
+				Namespace: namespace,
+				Name:      projectUID,
+			}
+		}
+	default:
+		err := fmt.Errorf("%w: %s", ErrUnknownInventoryStorage, opts.InventoryStorage)
+		log.Error(err, "Unable to set up inventory")
+		return nil, err
+	}
 
 	maxProcs := goRuntime.GOMAXPROCS(0)
 
@@ -330,6 +1404,45 @@ func Setup(cfg *rest.Config, options ...option) (manager.Manager, error) {
 		return nil, err
 	}
 
+	var auditPublisher *audit.Publisher
+	if opts.AuditRepository != "" {
+		auditPublisher, err = audit.NewPublisher(
+			opts.AuditRepository,
+			opts.PlainHTTP || helm.RegistryHosts(opts.PlainHTTPHosts).Contains(opts.AuditRepository),
+		)
+		if err != nil {
+			log.Error(err, "Unable to setup audit publisher")
+			return nil, err
+		}
+	}
+
+	var reporter *report.Reporter
+	if opts.ReportDir != "" || opts.ReportWebhookURL != "" {
+		format := report.Format(opts.ReportFormat)
+		if format != report.Markdown && format != report.HTML {
+			err := fmt.Errorf("%w: %s", report.ErrUnknownFormat, opts.ReportFormat)
+			log.Error(err, "Unable to setup reporter")
+			return nil, err
+		}
+		reporter = &report.Reporter{
+			Format: format,
+		}
+		if opts.ReportDir != "" {
+			reporter.Sinks = append(reporter.Sinks, &report.DirectorySink{Path: opts.ReportDir})
+		}
+		if opts.ReportWebhookURL != "" {
+			reporter.Sinks = append(reporter.Sinks, &report.WebhookSink{
+				HTTPClient: &http.Client{Timeout: httpTimeout},
+				URL:        opts.ReportWebhookURL,
+			})
+		}
+	}
+
+	if err := metrics.Registry.Register(buildInfo.Collector()); err != nil {
+		log.Error(err, "Unable to register Prometheus Collector")
+		return nil, err
+	}
+
 	reconciliationHisto := prometheus.NewHistogramVec(prometheus.HistogramOpts{
 		Namespace: "declcd",
 		Name:      "reconciliation_duration_seconds",
@@ -340,20 +1453,162 @@ func Setup(cfg *rest.Config, options ...option) (manager.Manager, error) {
 		return nil, err
 	}
 
+	skippedCounter := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "declcd",
+		Name:      "reconciliation_skipped_total",
+		Help:      "Number of GitOps Project reconciliations skipped because the revision did not change",
+	}, []string{"project", "url"})
+	if err := metrics.Registry.Register(skippedCounter); err != nil {
+		log.Error(err, "Unable to register Prometheus Collector")
+		return nil, err
+	}
+
+	unsupportedLanguageCounter := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "declcd",
+		Name:      "reconciliation_unsupported_language_version_total",
+		Help:      "Number of GitOps Project reconciliations failed because the project pins an unsupported CUE language version",
+	}, []string{"project", "url"})
+	if err := metrics.Registry.Register(unsupportedLanguageCounter); err != nil {
+		log.Error(err, "Unable to register Prometheus Collector")
+		return nil, err
+	}
+
+	gitFailureCounter := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "declcd",
+		Name:      "git_failures_total",
+		Help:      "Number of failed Git operations of a GitOps Project by failure class",
+	}, []string{"project", "url", "class"})
+	if err := metrics.Registry.Register(gitFailureCounter); err != nil {
+		log.Error(err, "Unable to register Prometheus Collector")
+		return nil, err
+	}
+
+	componentFailureCounter := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "declcd",
+		Name:      "component_apply_failures_total",
+		Help:      "Number of components, which could not be applied, by project and component type",
+	}, []string{"project", "type"})
+	if err := metrics.Registry.Register(componentFailureCounter); err != nil {
+		log.Error(err, "Unable to register Prometheus Collector")
+		return nil, err
+	}
+
+	inventoryGauge := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "declcd",
+		Name:      "inventory_items",
+		Help:      "Number of objects and Helm releases managed by a GitOps Project",
+	}, []string{"project"})
+	if err := metrics.Registry.Register(inventoryGauge); err != nil {
+		log.Error(err, "Unable to register Prometheus Collector")
+		return nil, err
+	}
+
+	prunedCounter := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "declcd",
+		Name:      "garbage_collected_objects_total",
+		Help:      "Number of objects and Helm releases deleted by garbage collection",
+	}, []string{"project", "kind"})
+	if err := metrics.Registry.Register(prunedCounter); err != nil {
+		log.Error(err, "Unable to register Prometheus Collector")
+		return nil, err
+	}
+
+	helmOperationHisto := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "declcd",
+		Name:      "helm_release_operation_duration_seconds",
+		Help:      "Duration of Helm release operations: render, install, upgrade and hooks",
+		Buckets:   prometheus.ExponentialBuckets(0.1, 2, 12),
+	}, []string{"release", "namespace", "operation"})
+	if err := metrics.Registry.Register(helmOperationHisto); err != nil {
+		log.Error(err, "Unable to register Prometheus Collector")
+		return nil, err
+	}
+
+	helmPullHisto := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "declcd",
+		Name:      "helm_chart_pull_duration_seconds",
+		Help:      "Duration of Helm chart pulls by repository, chart and result",
+		Buckets:   prometheus.ExponentialBuckets(0.1, 2, 12),
+	}, []string{"repository", "chart", "result"})
+	if err := metrics.Registry.Register(helmPullHisto); err != nil {
+		log.Error(err, "Unable to register Prometheus Collector")
+		return nil, err
+	}
+
+	var triggers chan event.GenericEvent
+	if opts.WebhookReceiverAddr != "" {
+		triggers = make(chan event.GenericEvent)
+		shutdownTimeout := 10 * time.Second
+		if err := mgr.Add(&manager.Server{
+			Name: "webhook-receiver",
+			Server: &http.Server{
+				Addr: opts.WebhookReceiverAddr,
+				Handler: &WebhookReceiver{
+					Log:      log,
+					Client:   mgr.GetClient(),
+					Triggers: triggers,
+				},
+				ReadHeaderTimeout: 10 * time.Second,
+			},
+			// Only the leader reconciles, so only the leader is able to process events.
+			OnlyServeWhenLeader: true,
+			ShutdownTimeout:     &shutdownTimeout,
+		}); err != nil {
+			log.Error(err, "Unable to set up webhook receiver")
+			return nil, err
+		}
+	}
+
+	var helmCRDLocks *helm.CRDLocks
+	if opts.SerializeSharedCRDs {
+		helmCRDLocks = &helm.CRDLocks{}
+	}
+
 	if err := (&GitOpsProjectController{
-		Log:                     log,
-		ReconciliationHistogram: reconciliationHisto,
-		Client:                  mgr.GetClient(),
+		Log:                        log,
+		ReconciliationHistogram:    reconciliationHisto,
+		SkippedCounter:             skippedCounter,
+		UnsupportedLanguageCounter: unsupportedLanguageCounter,
+		GitFailureCounter:          gitFailureCounter,
+		ComponentFailureCounter:    componentFailureCounter,
+		InventoryGauge:             inventoryGauge,
+		Notifier:                   notification.NewNotifier(&http.Client{Timeout: httpTimeout}),
+		Notifications:              NewNotificationRegistry(),
+		Client:                     mgr.GetClient(),
+		Reporter:                   reporter,
+		ShardLabels:                shardLabels,
+		Recorder:                   mgr.GetEventRecorderFor(controllerName),
+		Triggers:                   triggers,
+		MaxConcurrentProjects:      opts.MaxConcurrentProjects,
+		// Locks are held by pods, as replicas of the same controller share its name, e.g. during a rolling update.
+		Lock: &lock.ProjectLock{
+			Client:   mgr.GetClient(),
+			Identity: podName,
+			Duration: 5 * time.Minute,
+		},
 		Reconciler: project.Reconciler{
-			Log:                   log,
-			KubeConfig:            cfg,
-			ComponentBuilder:      componentBuilder,
-			RepositoryManager:     vcs.NewRepositoryManager(namespace, kubeDynamicClient, log),
-			ProjectManager:        projectManager,
-			FieldManager:          controllerName,
-			WorkerPoolSize:        maxProcs,
-			InsecureSkipTLSverify: opts.InsecureSkipTLSverify,
-			PlainHTTP:             opts.PlainHTTP,
+			Log:                        log,
+			KubeConfig:                 cfg,
+			ComponentBuilder:           componentBuilder,
+			RepositoryManager:          vcs.NewRepositoryManager(namespace, kubeDynamicClient, log),
+			ProjectManager:             projectManager,
+			FieldManager:               controllerName,
+			WorkerPoolSize:             maxProcs,
+			InsecureSkipTLSverify:      opts.InsecureSkipTLSverify,
+			InsecureSkipTLSverifyHosts: opts.InsecureSkipTLSverifyHosts,
+			PlainHTTP:                  opts.PlainHTTP,
+			PlainHTTPHosts:             opts.PlainHTTPHosts,
+			AuditPublisher:             auditPublisher,
+			SkipUnchangedRevision:      opts.SkipUnchangedRevisions,
+			PruneDryRun:                opts.PruneDryRun,
+			RegistryClientPool:         &helm.RegistryClientPool{},
+			HelmCRDLocks:               helmCRDLocks,
+			HelmOperationHistogram:     helmOperationHisto,
+			PrunedCounter:              prunedCounter,
+			HelmPullHistogram:          helmPullHisto,
+			InventoryEncryptionKey:     inventoryKey,
+			InventoryBackend:           inventoryBackend,
+			HelmMaxHistory:             opts.HelmMaxHistory,
 		},
 	}).SetupWithManager(mgr); err != nil {
 		log.Error(err, "Unable to create controller")
@@ -372,3 +1627,24 @@ func Setup(cfg *rest.Config, options ...option) (manager.Manager, error) {
 
 	return mgr, nil
 }
+
+// readPodName reads the name of the controller pod.
+// Controllers installed without the pod name in their podinfo fall back to the hostname, which is the pod name by default.
+func readPodName(path string) (string, error) {
+	podNameBytes, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return os.Hostname()
+		}
+		return "", err
+	}
+	return strings.TrimSpace(string(podNameBytes)), nil
+}
+
+// durationOrDefault returns nil for zero durations, which lets controller-runtime apply its default.
+func durationOrDefault(duration time.Duration) *time.Duration {
+	if duration == 0 {
+		return nil
+	}
+	return &duration
+}
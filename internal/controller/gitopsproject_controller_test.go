@@ -32,6 +32,8 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/metrics"
@@ -253,7 +255,26 @@ var _ = Describe("GitOpsProject controller", Ordered, func() {
 						g.Expect(updatedGitOpsProject.Status.Revision.CommitHash).ToNot(BeEmpty())
 						g.Expect(updatedGitOpsProject.Status.Revision.ReconcileTime.IsZero()).
 							To(BeFalse())
-						g.Expect(len(updatedGitOpsProject.Status.Conditions)).To(Equal(2))
+						g.Expect(len(updatedGitOpsProject.Status.Conditions)).To(Equal(3))
+						g.Expect(updatedGitOpsProject.Status.Conditions[1].Type).To(Equal("Healthy"))
+						g.Expect(updatedGitOpsProject.Status.ConsecutiveFailures).To(BeZero())
+						g.Expect(updatedGitOpsProject.Status.NextRetryAt).To(BeNil())
+						g.Expect(updatedGitOpsProject.Status.Phase).To(Equal("Idle"))
+						g.Expect(updatedGitOpsProject.Status.Ready).To(Equal("True"))
+						g.Expect(updatedGitOpsProject.Status.Components).ToNot(BeEmpty())
+						for _, componentStatus := range updatedGitOpsProject.Status.Components {
+							g.Expect(componentStatus.Type).ToNot(BeEmpty())
+							g.Expect(componentStatus.Message).To(BeEmpty())
+							g.Expect(componentStatus.Revision).
+								To(Equal(updatedGitOpsProject.Status.Revision.CommitHash))
+							g.Expect(componentStatus.LastAppliedTime).ToNot(BeNil())
+						}
+						stages := make([]string, 0, len(updatedGitOpsProject.Status.Stages))
+						for _, stage := range updatedGitOpsProject.Status.Stages {
+							g.Expect(stage.FinishedAt).ToNot(BeNil())
+							stages = append(stages, stage.Name)
+						}
+						g.Expect(stages).To(Equal([]string{"Cloning", "Building", "Pruning", "Applying"}))
 					}, duration, assertionInterval).Should(Succeed())
 				},
 			)
@@ -413,7 +434,7 @@ var _ = Describe("GitOpsProject controller", Ordered, func() {
 					g.Expect(updatedGitOpsProject.Status.Revision.CommitHash).ToNot(BeEmpty())
 					g.Expect(updatedGitOpsProject.Status.Revision.ReconcileTime.IsZero()).
 						To(BeFalse())
-					g.Expect(len(updatedGitOpsProject.Status.Conditions)).To(Equal(2))
+					g.Expect(len(updatedGitOpsProject.Status.Conditions)).To(Equal(3))
 				}, duration, assertionInterval).Should(Succeed())
 
 				Eventually(func(g Gomega) {
@@ -430,7 +451,7 @@ var _ = Describe("GitOpsProject controller", Ordered, func() {
 					g.Expect(updatedGitOpsProject.Status.Revision.CommitHash).ToNot(BeEmpty())
 					g.Expect(updatedGitOpsProject.Status.Revision.ReconcileTime.IsZero()).
 						To(BeFalse())
-					g.Expect(len(updatedGitOpsProject.Status.Conditions)).To(Equal(2))
+					g.Expect(len(updatedGitOpsProject.Status.Conditions)).To(Equal(3))
 				}, duration, assertionInterval).Should(Succeed())
 
 				Eventually(func() (string, error) {
@@ -460,3 +481,71 @@ var _ = Describe("GitOpsProject controller", Ordered, func() {
 		)
 	})
 })
+
+var _ = DescribeTable("Failure backoff",
+	func(failures int64, interval time.Duration, expected time.Duration) {
+		Expect(failureBackoff(failures, interval)).To(Equal(expected))
+	},
+	Entry("First failure", int64(1), time.Hour, 30*time.Second),
+	Entry("Second failure", int64(2), time.Hour, time.Minute),
+	Entry("Third failure", int64(3), time.Hour, 5*time.Minute),
+	Entry("Persistent failure", int64(10), time.Hour, 5*time.Minute),
+	Entry("Capped at interval", int64(3), 2*time.Minute, 2*time.Minute),
+)
+
+var _ = DescribeTable("Max concurrent projects",
+	func(maxConcurrentProjects int, expected int) {
+		controller := &GitOpsProjectController{MaxConcurrentProjects: maxConcurrentProjects}
+		Expect(controller.controllerOptions().MaxConcurrentReconciles).To(Equal(expected))
+	},
+	Entry("Unset", 0, 1),
+	Entry("Serial", 1, 1),
+	Entry("Parallel", 4, 4),
+)
+
+var _ = DescribeTable("Shard affinity",
+	func(affinity *v1.LabelSelector, expected bool) {
+		shardLabels := labels.Set{
+			"declcd/shard":         "primary",
+			"declcd/ssd-cache":     "true",
+			"declcd/control-plane": "project-controller-primary",
+		}
+		matches, err := matchesShardAffinity(affinity, shardLabels)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(matches).To(Equal(expected))
+	},
+	Entry("No affinity", nil, true),
+	Entry("Matching labels", &v1.LabelSelector{
+		MatchLabels: map[string]string{"declcd/ssd-cache": "true"},
+	}, true),
+	Entry("Missing label", &v1.LabelSelector{
+		MatchExpressions: []v1.LabelSelectorRequirement{
+			{Key: "declcd/gpu", Operator: v1.LabelSelectorOpExists},
+		},
+	}, false),
+	Entry("Anti-affinity", &v1.LabelSelector{
+		MatchExpressions: []v1.LabelSelectorRequirement{
+			{Key: "declcd/shard", Operator: v1.LabelSelectorOpNotIn, Values: []string{"primary"}},
+		},
+	}, false),
+)
+
+var _ = Describe("Shard labels", func() {
+	It("Should parse downward API labels", func() {
+		path := filepath.Join(GinkgoT().TempDir(), "labels")
+		err := os.WriteFile(path, []byte("declcd/shard=\"primary\"\ndeclcd/ssd-cache=\"true\"\n"), 0600)
+		Expect(err).NotTo(HaveOccurred())
+		shardLabels, err := readShardLabels(path, "primary")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(shardLabels).To(Equal(labels.Set{
+			"declcd/shard":     "primary",
+			"declcd/ssd-cache": "true",
+		}))
+	})
+
+	It("Should fall back to the shard name", func() {
+		shardLabels, err := readShardLabels(filepath.Join(GinkgoT().TempDir(), "labels"), "primary")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(shardLabels).To(Equal(labels.Set{"declcd/shard": "primary"}))
+	})
+})
@@ -0,0 +1,99 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+	gitops "github.com/kharf/declcd/api/v1beta1"
+	"github.com/kharf/declcd/pkg/lock"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("Project lock", func() {
+	It("Should keep the status of a project locked by another controller", func() {
+		ctx := context.Background()
+		finished := v1.Condition{
+			Type:               "Finished",
+			Reason:             "Success",
+			Message:            "Reconciled",
+			Status:             "True",
+			LastTransitionTime: v1.NewTime(time.Now().Add(-time.Minute).Truncate(time.Second)),
+		}
+		gProject := &gitops.GitOpsProject{
+			ObjectMeta: v1.ObjectMeta{
+				Name:      "shop",
+				Namespace: "declcd-system",
+			},
+			Spec: gitops.GitOpsProjectSpec{
+				URL:    "git@github.com:kharf/declcd.git",
+				Branch: "main",
+			},
+			Status: gitops.GitOpsProjectStatus{
+				Conditions: []v1.Condition{finished},
+				Revision: gitops.GitOpsProjectRevision{
+					CommitHash: "abc",
+				},
+			},
+		}
+		holder := "project-controller-primary-7d9f8-x2k4p"
+		durationSeconds := int32(300)
+		lease := &coordinationv1.Lease{
+			ObjectMeta: v1.ObjectMeta{
+				Name:      "declcd-project-shop",
+				Namespace: "declcd-system",
+			},
+			Spec: coordinationv1.LeaseSpec{
+				HolderIdentity:       &holder,
+				LeaseDurationSeconds: &durationSeconds,
+				RenewTime:            &v1.MicroTime{Time: time.Now()},
+			},
+		}
+		kubeClient := fake.NewClientBuilder().
+			WithScheme(scheme).
+			WithObjects(gProject, lease).
+			WithStatusSubresource(gProject).
+			Build()
+		controller := &GitOpsProjectController{
+			Client: kubeClient,
+			Log:    logr.Discard(),
+			Lock: &lock.ProjectLock{
+				Client:   kubeClient,
+				Identity: "project-controller-primary-7d9f8-q8m2z",
+				Duration: 5 * time.Minute,
+			},
+		}
+
+		_, err := controller.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(gProject)})
+		Expect(err).ToNot(HaveOccurred())
+
+		var current gitops.GitOpsProject
+		Expect(kubeClient.Get(ctx, client.ObjectKeyFromObject(gProject), &current)).To(Succeed())
+		Expect(current.Status.Revision.CommitHash).To(Equal("abc"))
+		Expect(current.Status.Conditions).To(HaveLen(2))
+		Expect(current.Status.Conditions[0].Type).To(Equal("Finished"))
+		Expect(current.Status.Conditions[0].Status).To(Equal(v1.ConditionTrue))
+		Expect(current.Status.Conditions[1].Type).To(Equal("Locked"))
+		Expect(current.Status.Conditions[1].Message).To(ContainSubstring(holder))
+	})
+})
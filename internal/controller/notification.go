@@ -0,0 +1,176 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/kharf/declcd/pkg/component"
+	"github.com/kharf/declcd/pkg/notification"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// NotificationRegistry holds the Notification components of all projects reconciled by a controller shard.
+// It is kept in memory and filled again by the first reconciliation of every project after a restart.
+type NotificationRegistry struct {
+	mu            sync.RWMutex
+	notifications map[types.NamespacedName][]*component.Notification
+}
+
+// NewNotificationRegistry constructs an empty [NotificationRegistry].
+func NewNotificationRegistry() *NotificationRegistry {
+	return &NotificationRegistry{
+		notifications: make(map[types.NamespacedName][]*component.Notification),
+	}
+}
+
+// Register replaces the notifications declared by a project.
+func (registry *NotificationRegistry) Register(
+	project types.NamespacedName,
+	notifications []*component.Notification,
+) {
+	if registry == nil {
+		return
+	}
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	if notifications == nil {
+		notifications = []*component.Notification{}
+	}
+	registry.notifications[project] = notifications
+}
+
+// Deregister drops the notifications declared by a deleted project.
+func (registry *NotificationRegistry) Deregister(project types.NamespacedName) {
+	if registry == nil {
+		return
+	}
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	delete(registry.notifications, project)
+}
+
+// Registered reports whether the notifications of a project are known,
+// which is not the case for projects not fully reconciled since the controller started.
+func (registry *NotificationRegistry) Registered(project types.NamespacedName) bool {
+	if registry == nil {
+		return true
+	}
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+	_, found := registry.notifications[project]
+	return found
+}
+
+// registeredNotification is a notification together with the project declaring it.
+type registeredNotification struct {
+	owner        types.NamespacedName
+	notification *component.Notification
+}
+
+// matching returns all notifications whose filter matches the event, sorted by their owner and id.
+func (registry *NotificationRegistry) matching(event notification.Event) []registeredNotification {
+	if registry == nil {
+		return nil
+	}
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+	matching := make([]registeredNotification, 0)
+	for owner, notifications := range registry.notifications {
+		for _, declared := range notifications {
+			if declared.Filter.Matches(owner.Name, event) {
+				matching = append(matching, registeredNotification{
+					owner:        owner,
+					notification: declared,
+				})
+			}
+		}
+	}
+	slices.SortFunc(matching, func(a, b registeredNotification) int {
+		if a.owner != b.owner {
+			return strings.Compare(a.owner.String(), b.owner.String())
+		}
+		return strings.Compare(a.notification.ID, b.notification.ID)
+	})
+	return matching
+}
+
+// dispatch delivers the event to the targets of all matching notifications.
+// Failing targets are logged and do not prevent the delivery to other targets.
+func (controller *GitOpsProjectController) dispatch(ctx context.Context, event notification.Event) {
+	for _, registered := range controller.Notifications.matching(event) {
+		log := controller.Log.WithValues(
+			"project", event.Project,
+			"notification", registered.notification.ID,
+			"owner", registered.owner.String(),
+		)
+		declared := registered.notification
+
+		if declared.Slack != nil {
+			secret, err := controller.readNotificationSecret(ctx, &declared.Slack.SecretRef)
+			if err != nil {
+				log.Error(err, "Unable to read Slack notification secret")
+			} else if err := controller.Notifier.NotifySlack(ctx, string(secret["url"]), event); err != nil {
+				log.Error(err, "Unable to send Slack notification")
+			}
+		}
+
+		if declared.Webhook != nil {
+			secret, err := controller.readNotificationSecret(ctx, declared.Webhook.SecretRef)
+			if err != nil {
+				log.Error(err, "Unable to read webhook notification secret")
+			} else if err := controller.Notifier.Notify(ctx, declared.Webhook.URL, secret["secret"], event); err != nil {
+				log.Error(err, "Unable to send webhook notification")
+			}
+		}
+
+		if declared.Email != nil {
+			secret, err := controller.readNotificationSecret(ctx, declared.Email.SecretRef)
+			if err != nil {
+				log.Error(err, "Unable to read email notification secret")
+			} else if err := controller.Notifier.Mail(
+				ctx,
+				*declared.Email,
+				string(secret["username"]),
+				string(secret["password"]),
+				event,
+			); err != nil {
+				log.Error(err, "Unable to send email notification")
+			}
+		}
+	}
+}
+
+// readNotificationSecret returns no data for targets without a secret.
+func (controller *GitOpsProjectController) readNotificationSecret(
+	ctx context.Context,
+	ref *notification.SecretReference,
+) (map[string][]byte, error) {
+	if ref == nil {
+		return nil, nil
+	}
+	var secret corev1.Secret
+	if err := controller.Client.Get(ctx, types.NamespacedName{
+		Name:      ref.Name,
+		Namespace: ref.Namespace,
+	}, &secret); err != nil {
+		return nil, err
+	}
+	return secret.Data, nil
+}
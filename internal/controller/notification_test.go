@@ -0,0 +1,54 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"github.com/kharf/declcd/pkg/component"
+	"github.com/kharf/declcd/pkg/notification"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+var _ = Describe("Notification registry", func() {
+	platform := types.NamespacedName{Name: "platform", Namespace: "declcd-system"}
+	shop := types.NamespacedName{Name: "shop", Namespace: "declcd-system"}
+	event := notification.Event{
+		Project:  "shop",
+		Reason:   "Failed",
+		Severity: notification.SeverityError,
+	}
+
+	It("Should match notifications of other projects by their filter", func() {
+		registry := NewNotificationRegistry()
+		Expect(registry.Registered(platform)).To(BeFalse())
+		registry.Register(platform, []*component.Notification{
+			{ID: "all_Notification", Filter: notification.Filter{Projects: []string{"*"}}},
+			{ID: "own_Notification"},
+		})
+		registry.Register(shop, nil)
+		Expect(registry.Registered(platform)).To(BeTrue())
+		Expect(registry.Registered(shop)).To(BeTrue())
+
+		matching := registry.matching(event)
+		Expect(matching).To(HaveLen(1))
+		Expect(matching[0].owner).To(Equal(platform))
+		Expect(matching[0].notification.ID).To(Equal("all_Notification"))
+
+		registry.Deregister(platform)
+		Expect(registry.Registered(platform)).To(BeFalse())
+		Expect(registry.matching(event)).To(BeEmpty())
+	})
+})
@@ -0,0 +1,188 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+	"text/template"
+
+	gitops "github.com/kharf/declcd/api/v1beta1"
+	"github.com/kharf/declcd/pkg/project"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// teardownFinalizer is set on preview projects, so that their objects are deleted with them.
+const teardownFinalizer = "gitops.declcd.io/teardown"
+
+var (
+	ErrInvalidPreviewNamespace = errors.New("Invalid preview namespace")
+
+	nonDNSLabelChars = regexp.MustCompile("[^a-z0-9-]+")
+)
+
+// syncPreviews creates a preview project for every branch matching the previews of a project
+// and deletes preview projects of deleted branches.
+func (controller *GitOpsProjectController) syncPreviews(ctx context.Context, gProject *gitops.GitOpsProject) error {
+	previews := gProject.Spec.Previews
+	namespaceTemplate, err := template.New("namespace").Parse(previews.NamespaceTemplate)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidPreviewNamespace, err)
+	}
+
+	branches, err := controller.Reconciler.RepositoryManager.ListBranches(ctx, gProject.Spec.URL, gProject.GetName())
+	if err != nil {
+		return err
+	}
+
+	desired := make(map[string]struct{})
+	for _, branch := range branches {
+		matches, err := path.Match(previews.Branches, branch)
+		if err != nil {
+			return err
+		}
+		if !matches || branch == gProject.Spec.Branch {
+			continue
+		}
+
+		preview, err := previewProject(gProject, branch, namespaceTemplate)
+		if err != nil {
+			return err
+		}
+		desired[preview.GetName()] = struct{}{}
+
+		existing := &gitops.GitOpsProject{
+			ObjectMeta: v1.ObjectMeta{
+				Name:      preview.GetName(),
+				Namespace: preview.GetNamespace(),
+			},
+		}
+		if _, err := controllerutil.CreateOrUpdate(ctx, controller.Client, existing, func() error {
+			existing.Labels = preview.Labels
+			existing.Annotations = preview.Annotations
+			existing.Spec = preview.Spec
+			controllerutil.AddFinalizer(existing, teardownFinalizer)
+			return controllerutil.SetControllerReference(gProject, existing, controller.Client.Scheme())
+		}); err != nil {
+			return err
+		}
+	}
+
+	var previewProjects gitops.GitOpsProjectList
+	if err := controller.Client.List(
+		ctx,
+		&previewProjects,
+		client.InNamespace(gProject.GetNamespace()),
+		client.MatchingLabels{project.PreviewOfLabel: gProject.GetName()},
+	); err != nil {
+		return err
+	}
+	for i := range previewProjects.Items {
+		previewProject := &previewProjects.Items[i]
+		if _, found := desired[previewProject.GetName()]; found {
+			continue
+		}
+		controller.Log.Info(
+			"Deleting preview of deleted branch",
+			"project", previewProject.GetName(),
+			"branch", previewProject.GetAnnotations()[project.PreviewBranchAnnotation],
+		)
+		if err := controller.Client.Delete(ctx, previewProject); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// previewProject derives the preview project of a branch.
+// Labels are inherited, so that the preview is reconciled by the same shard.
+func previewProject(
+	gProject *gitops.GitOpsProject,
+	branch string,
+	namespaceTemplate *template.Template,
+) (*gitops.GitOpsProject, error) {
+	branchLabel := dnsLabel(branch)
+
+	var namespace strings.Builder
+	if err := namespaceTemplate.Execute(&namespace, map[string]string{
+		"Project": gProject.GetName(),
+		"Branch":  branchLabel,
+	}); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidPreviewNamespace, err)
+	}
+	if errs := validation.IsDNS1123Label(namespace.String()); len(errs) != 0 {
+		return nil, fmt.Errorf("%w: %s: %s", ErrInvalidPreviewNamespace, namespace.String(), strings.Join(errs, ", "))
+	}
+
+	labels := make(map[string]string, len(gProject.GetLabels())+1)
+	for key, value := range gProject.GetLabels() {
+		labels[key] = value
+	}
+	labels[project.PreviewOfLabel] = gProject.GetName()
+
+	spec := *gProject.Spec.DeepCopy()
+	spec.Branch = branch
+	spec.Revision = ""
+	spec.Promotions = nil
+	spec.Previews = nil
+	spec.TargetNamespace = namespace.String()
+
+	return &gitops.GitOpsProject{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-%s", gProject.GetName(), branchLabel),
+			Namespace: gProject.GetNamespace(),
+			Labels:    labels,
+			Annotations: map[string]string{
+				project.PreviewBranchAnnotation: branch,
+			},
+		},
+		Spec: spec,
+	}, nil
+}
+
+// dnsLabel converts a branch name, e.g. "preview/JIRA-123_login", to a DNS label, e.g. "preview-jira-123-login".
+func dnsLabel(branch string) string {
+	label := nonDNSLabelChars.ReplaceAllString(strings.ToLower(branch), "-")
+	if len(label) > validation.DNS1123LabelMaxLength {
+		label = label[:validation.DNS1123LabelMaxLength]
+	}
+	return strings.Trim(label, "-")
+}
+
+// teardown deletes the objects of a deleted preview project and releases it afterwards.
+func (controller *GitOpsProjectController) teardown(ctx context.Context, gProject *gitops.GitOpsProject) error {
+	if !controllerutil.ContainsFinalizer(gProject, teardownFinalizer) {
+		return nil
+	}
+	reconciler := controller.Reconciler
+	reconciler.OnEvent = controller.eventRecorderFor(gProject)
+	if err := reconciler.Teardown(ctx, *gProject); err != nil {
+		return err
+	}
+	controllerutil.RemoveFinalizer(gProject, teardownFinalizer)
+	if err := controller.Client.Update(ctx, gProject); err != nil && !k8sErrors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
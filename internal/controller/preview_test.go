@@ -0,0 +1,77 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"text/template"
+
+	gitops "github.com/kharf/declcd/api/v1beta1"
+	"github.com/kharf/declcd/pkg/project"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = DescribeTable("Preview branch labels",
+	func(branch string, expected string) {
+		Expect(dnsLabel(branch)).To(Equal(expected))
+	},
+	Entry("Simple", "preview/login", "preview-login"),
+	Entry("Mixed case and underscores", "preview/JIRA-123_Login", "preview-jira-123-login"),
+	Entry("Trailing separators", "preview/login/", "preview-login"),
+)
+
+var _ = Describe("Preview project", func() {
+	gProject := &gitops.GitOpsProject{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      "shop",
+			Namespace: "declcd-system",
+			Labels:    map[string]string{"declcd/shard": "primary"},
+		},
+		Spec: gitops.GitOpsProjectSpec{
+			URL:                 "git@github.com:kharf/shop.git",
+			Branch:              "main",
+			PullIntervalSeconds: 30,
+			Revision:            "v1.0.0",
+			Previews: &gitops.GitOpsProjectPreviews{
+				Branches:          "preview/*",
+				NamespaceTemplate: "{{ .Project }}-{{ .Branch }}",
+			},
+		},
+	}
+
+	It("Should track the branch in a templated namespace", func() {
+		namespaceTemplate := template.Must(template.New("").Parse(gProject.Spec.Previews.NamespaceTemplate))
+		preview, err := previewProject(gProject, "preview/Login", namespaceTemplate)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(preview.GetName()).To(Equal("shop-preview-login"))
+		Expect(preview.GetLabels()).To(Equal(map[string]string{
+			"declcd/shard":         "primary",
+			project.PreviewOfLabel: "shop",
+		}))
+		Expect(preview.GetAnnotations()[project.PreviewBranchAnnotation]).To(Equal("preview/Login"))
+		Expect(preview.Spec.Branch).To(Equal("preview/Login"))
+		Expect(preview.Spec.TargetNamespace).To(Equal("shop-preview-login"))
+		Expect(preview.Spec.Revision).To(BeEmpty())
+		Expect(preview.Spec.Previews).To(BeNil())
+		Expect(gProject.Spec.Previews).NotTo(BeNil())
+	})
+
+	It("Should refuse invalid namespaces", func() {
+		namespaceTemplate := template.Must(template.New("").Parse("{{ .Project }}.{{ .Branch }}"))
+		_, err := previewProject(gProject, "preview/login", namespaceTemplate)
+		Expect(err).To(MatchError(ErrInvalidPreviewNamespace))
+	})
+})
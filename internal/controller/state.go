@@ -0,0 +1,82 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	gitops "github.com/kharf/declcd/api/v1beta1"
+	"github.com/kharf/declcd/pkg/project"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// refreshState creates or updates the ProjectState of a project from the result of its reconciliation.
+// The state is owned by the project and deleted with it.
+func (controller *GitOpsProjectController) refreshState(
+	ctx context.Context,
+	gProject *gitops.GitOpsProject,
+	result *project.ReconcileResult,
+	reconcileTime v1.Time,
+) error {
+	state := &gitops.ProjectState{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      gProject.GetName(),
+			Namespace: gProject.GetNamespace(),
+		},
+	}
+	_, err := controllerutil.CreateOrUpdate(ctx, controller.Client, state, func() error {
+		summarize(state, result, reconcileTime)
+		return controllerutil.SetControllerReference(gProject, state, controller.Client.Scheme())
+	})
+	return err
+}
+
+// summarize replaces the summary of a state with the result of a reconciliation.
+func summarize(state *gitops.ProjectState, result *project.ReconcileResult, reconcileTime v1.Time) {
+	state.Revision = result.CommitHash
+	state.ReconcileTime = reconcileTime
+
+	kinds := make([]gitops.ProjectStateKindCount, 0, len(result.InventoryKinds))
+	for kind, count := range result.InventoryKinds {
+		kinds = append(kinds, gitops.ProjectStateKindCount{
+			Kind:  kind,
+			Count: count,
+		})
+	}
+	slices.SortFunc(kinds, func(a, b gitops.ProjectStateKindCount) int {
+		return strings.Compare(a.Kind, b.Kind)
+	})
+	state.Inventory = gitops.ProjectStateInventory{
+		Size:  result.InventorySize,
+		Kinds: kinds,
+	}
+
+	state.FailingComponents = make([]gitops.ProjectStateFailingComponent, 0)
+	for _, componentResult := range result.Components {
+		if componentResult.Err == nil {
+			continue
+		}
+		state.FailingComponents = append(state.FailingComponents, gitops.ProjectStateFailingComponent{
+			ID:      componentResult.ID,
+			Type:    componentResult.Type,
+			Message: componentResult.Err.Error(),
+		})
+	}
+
+	state.PendingPrunes = slices.Clone(result.PendingPrunes)
+}
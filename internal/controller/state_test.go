@@ -0,0 +1,95 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"errors"
+
+	gitops "github.com/kharf/declcd/api/v1beta1"
+	"github.com/kharf/declcd/pkg/project"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("Project state", func() {
+	gProject := &gitops.GitOpsProject{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      "shop",
+			Namespace: "declcd-system",
+			UID:       "4c2b6a9e",
+		},
+	}
+
+	It("Should summarize the reconciliation", func() {
+		ctx := context.Background()
+		controller := &GitOpsProjectController{
+			Client: fake.NewClientBuilder().WithScheme(scheme).Build(),
+		}
+
+		reconcileTime := v1.Now()
+		Expect(controller.refreshState(ctx, gProject, &project.ReconcileResult{
+			CommitHash:    "abc",
+			InventorySize: 3,
+			InventoryKinds: map[string]int{
+				"HelmRelease": 1,
+				"Deployment":  2,
+			},
+			Components: []project.ComponentResult{
+				{ID: "api___Deployment", Type: "Manifest"},
+				{ID: "db_shop_HelmRelease", Type: "HelmRelease", Err: errors.New("timed out")},
+			},
+			PendingPrunes: []string{"old_shop_apps_Deployment"},
+		}, reconcileTime)).To(Succeed())
+
+		var state gitops.ProjectState
+		Expect(controller.Client.Get(ctx, client.ObjectKeyFromObject(gProject), &state)).To(Succeed())
+		Expect(state.Revision).To(Equal("abc"))
+		Expect(state.Inventory).To(Equal(gitops.ProjectStateInventory{
+			Size: 3,
+			Kinds: []gitops.ProjectStateKindCount{
+				{Kind: "Deployment", Count: 2},
+				{Kind: "HelmRelease", Count: 1},
+			},
+		}))
+		Expect(state.FailingComponents).To(Equal([]gitops.ProjectStateFailingComponent{
+			{ID: "db_shop_HelmRelease", Type: "HelmRelease", Message: "timed out"},
+		}))
+		Expect(state.PendingPrunes).To(Equal([]string{"old_shop_apps_Deployment"}))
+		Expect(state.GetOwnerReferences()).To(HaveLen(1))
+		Expect(state.GetOwnerReferences()[0].UID).To(Equal(gProject.GetUID()))
+
+		By("Replacing the summary on the next reconciliation")
+		Expect(controller.refreshState(ctx, gProject, &project.ReconcileResult{
+			CommitHash:    "def",
+			InventorySize: 1,
+			InventoryKinds: map[string]int{
+				"Deployment": 1,
+			},
+			Components: []project.ComponentResult{
+				{ID: "api___Deployment", Type: "Manifest"},
+			},
+		}, v1.Now())).To(Succeed())
+
+		Expect(controller.Client.Get(ctx, client.ObjectKeyFromObject(gProject), &state)).To(Succeed())
+		Expect(state.Revision).To(Equal("def"))
+		Expect(state.Inventory.Size).To(Equal(1))
+		Expect(state.FailingComponents).To(BeEmpty())
+		Expect(state.PendingPrunes).To(BeEmpty())
+	})
+})
@@ -0,0 +1,139 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"time"
+
+	gitops "github.com/kharf/declcd/api/v1beta1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+var (
+	ErrTemplateViolation = errors.New("Project violates its template")
+)
+
+// checkTemplate returns an [ErrTemplateViolation], if the project or the shard reconciling it is not allowed by the template.
+func checkTemplate(
+	template *gitops.ClusterGitOpsProjectTemplate,
+	gProject *gitops.GitOpsProject,
+	shardLabels labels.Set,
+) error {
+	if !matchesAny(template.Spec.AllowedNamespaces, gProject.GetNamespace()) {
+		return fmt.Errorf(
+			"%w %s: namespace %s is not allowed",
+			ErrTemplateViolation,
+			template.GetName(),
+			gProject.GetNamespace(),
+		)
+	}
+	if !matchesAny(template.Spec.AllowedURLs, gProject.Spec.URL) {
+		return fmt.Errorf(
+			"%w %s: url %s is not allowed",
+			ErrTemplateViolation,
+			template.GetName(),
+			gProject.Spec.URL,
+		)
+	}
+	matches, err := matchesShardAffinity(template.Spec.ShardAffinity, shardLabels)
+	if err != nil {
+		return err
+	}
+	if !matches {
+		return fmt.Errorf(
+			"%w %s: shard does not match the shard affinity",
+			ErrTemplateViolation,
+			template.GetName(),
+		)
+	}
+	return nil
+}
+
+// matchesAny reports whether the value matches one of the glob patterns.
+// Empty patterns match every value.
+func matchesAny(patterns []string, value string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, pattern := range patterns {
+		if matched, err := path.Match(pattern, value); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
+
+// pullInterval returns the pull interval of the project bounded by its template.
+func pullInterval(gProject *gitops.GitOpsProject, template *gitops.ClusterGitOpsProjectTemplate) time.Duration {
+	seconds := gProject.Spec.PullIntervalSeconds
+	if template != nil {
+		if minSeconds := template.Spec.MinPullIntervalSeconds; minSeconds != 0 && seconds < minSeconds {
+			seconds = minSeconds
+		}
+		if maxSeconds := template.Spec.MaxPullIntervalSeconds; maxSeconds != 0 && seconds > maxSeconds {
+			seconds = maxSeconds
+		}
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// readTemplate returns nil for projects not stamped from a template.
+func (controller *GitOpsProjectController) readTemplate(
+	ctx context.Context,
+	gProject *gitops.GitOpsProject,
+) (*gitops.ClusterGitOpsProjectTemplate, error) {
+	if gProject.Spec.TemplateName == "" {
+		return nil, nil
+	}
+	var template gitops.ClusterGitOpsProjectTemplate
+	if err := controller.Client.Get(ctx, types.NamespacedName{
+		Name: gProject.Spec.TemplateName,
+	}, &template); err != nil {
+		return nil, err
+	}
+	return &template, nil
+}
+
+// projectsForTemplate enqueues all projects stamped from a changed template, so that its guardrails are enforced immediately.
+func (controller *GitOpsProjectController) projectsForTemplate(
+	ctx context.Context,
+	template client.Object,
+) []reconcile.Request {
+	var projects gitops.GitOpsProjectList
+	if err := controller.Client.List(ctx, &projects); err != nil {
+		controller.Log.Error(err, "Unable to list GitOpsProjects of template", "template", template.GetName())
+		return nil
+	}
+	requests := make([]reconcile.Request, 0)
+	for _, gProject := range projects.Items {
+		if gProject.Spec.TemplateName != template.GetName() {
+			continue
+		}
+		requests = append(requests, reconcile.Request{
+			NamespacedName: types.NamespacedName{
+				Name:      gProject.GetName(),
+				Namespace: gProject.GetNamespace(),
+			},
+		})
+	}
+	return requests
+}
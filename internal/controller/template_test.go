@@ -0,0 +1,81 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"time"
+
+	gitops "github.com/kharf/declcd/api/v1beta1"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+var _ = Describe("Project template", func() {
+	template := &gitops.ClusterGitOpsProjectTemplate{
+		ObjectMeta: v1.ObjectMeta{
+			Name: "tenant",
+		},
+		Spec: gitops.ClusterGitOpsProjectTemplateSpec{
+			AllowedURLs:       []string{"git@github.com:my-org/*"},
+			AllowedNamespaces: []string{"team-*"},
+			ShardAffinity: &v1.LabelSelector{
+				MatchLabels: map[string]string{"tier": "tenant"},
+			},
+			MinPullIntervalSeconds: 60,
+			MaxPullIntervalSeconds: 600,
+		},
+	}
+	shardLabels := labels.Set{"declcd/shard": "tenant", "tier": "tenant"}
+
+	newProject := func(namespace string, url string, interval int) *gitops.GitOpsProject {
+		return &gitops.GitOpsProject{
+			ObjectMeta: v1.ObjectMeta{
+				Name:      "shop",
+				Namespace: namespace,
+			},
+			Spec: gitops.GitOpsProjectSpec{
+				URL:                 url,
+				PullIntervalSeconds: interval,
+				TemplateName:        "tenant",
+			},
+		}
+	}
+
+	It("Should allow projects within the guardrails", func() {
+		gProject := newProject("team-a", "git@github.com:my-org/shop.git", 30)
+		Expect(checkTemplate(template, gProject, shardLabels)).To(Succeed())
+	})
+
+	It("Should refuse projects violating the guardrails", func() {
+		gProject := newProject("kube-system", "git@github.com:my-org/shop.git", 30)
+		Expect(checkTemplate(template, gProject, shardLabels)).To(MatchError(ErrTemplateViolation))
+
+		gProject = newProject("team-a", "git@github.com:other-org/shop.git", 30)
+		Expect(checkTemplate(template, gProject, shardLabels)).To(MatchError(ErrTemplateViolation))
+
+		gProject = newProject("team-a", "git@github.com:my-org/shop.git", 30)
+		Expect(checkTemplate(template, gProject, labels.Set{"declcd/shard": "primary"})).
+			To(MatchError(ErrTemplateViolation))
+	})
+
+	It("Should bound the pull interval", func() {
+		Expect(pullInterval(newProject("team-a", "", 30), template)).To(Equal(time.Minute))
+		Expect(pullInterval(newProject("team-a", "", 3600), template)).To(Equal(10 * time.Minute))
+		Expect(pullInterval(newProject("team-a", "", 120), template)).To(Equal(2 * time.Minute))
+		Expect(pullInterval(newProject("team-a", "", 30), nil)).To(Equal(30 * time.Second))
+	})
+})
@@ -0,0 +1,306 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/go-logr/logr"
+	gitops "github.com/kharf/declcd/api/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+// maxWebhookBodyBytes caps the size of accepted push event payloads.
+const maxWebhookBodyBytes = 5 << 20
+
+var (
+	ErrUnsupportedWebhookEvent = errors.New("Unsupported webhook event")
+)
+
+// pushEvent is the provider independent part of a push event.
+type pushEvent struct {
+	// RepositoryURLs are all urls the pushed repository is known by, e.g. its https and ssh clone urls.
+	RepositoryURLs []string
+	// Branches are the pushed branches.
+	Branches []string
+}
+
+// WebhookReceiver accepts push events of GitHub, GitLab and Bitbucket
+// and immediately triggers the reconciliation of every GitOpsProject tracking the pushed branch.
+// Projects have to declare a webhook secret, which authenticates the events.
+type WebhookReceiver struct {
+	Log logr.Logger
+
+	// Client lists the projects of this shard and reads their webhook secrets.
+	Client client.Client
+
+	// Triggers receives the projects to reconcile.
+	Triggers chan<- event.GenericEvent
+}
+
+var _ http.Handler = (*WebhookReceiver)(nil)
+
+func (receiver *WebhookReceiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookBodyBytes))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	// GitHub sends a ping when a webhook is created.
+	if r.Header.Get("X-GitHub-Event") == "ping" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	push, err := parsePushEvent(r.Header, body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var projects gitops.GitOpsProjectList
+	if err := receiver.Client.List(r.Context(), &projects); err != nil {
+		receiver.Log.Error(err, "Unable to list GitOpsProjects")
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	matched := 0
+	triggered := 0
+	for i := range projects.Items {
+		gProject := &projects.Items[i]
+		if gProject.Spec.Webhook == nil || !push.matches(gProject) {
+			continue
+		}
+		matched++
+
+		log := receiver.Log.WithValues("project", gProject.GetName(), "namespace", gProject.GetNamespace())
+		var secret corev1.Secret
+		if err := receiver.Client.Get(r.Context(), types.NamespacedName{
+			Name:      gProject.Spec.Webhook.SecretName,
+			Namespace: gProject.GetNamespace(),
+		}, &secret); err != nil {
+			log.Error(err, "Unable to read webhook secret")
+			continue
+		}
+		if !verifyWebhook(r.Header, body, secret.Data["secret"]) {
+			log.Info("Refused webhook event with invalid signature")
+			continue
+		}
+
+		log.Info("Triggering reconciliation by webhook event")
+		select {
+		case receiver.Triggers <- event.GenericEvent{Object: gProject}:
+			triggered++
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	switch {
+	case matched == 0:
+		http.Error(w, "No GitOpsProject tracks the pushed branch", http.StatusNotFound)
+	case triggered == 0:
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+	default:
+		w.WriteHeader(http.StatusAccepted)
+	}
+}
+
+// verifyWebhook authenticates an event with the mechanism of its provider.
+// GitHub and Bitbucket sign the body with HMAC-SHA256, GitLab sends the secret as token.
+func verifyWebhook(header http.Header, body []byte, secret []byte) bool {
+	if len(secret) == 0 {
+		return false
+	}
+
+	if token := header.Get("X-Gitlab-Token"); token != "" {
+		return subtle.ConstantTimeCompare([]byte(token), secret) == 1
+	}
+
+	signature := header.Get("X-Hub-Signature-256")
+	if signature == "" {
+		// Bitbucket uses the header name without the algorithm suffix.
+		signature = header.Get("X-Hub-Signature")
+	}
+	digest, found := strings.CutPrefix(signature, "sha256=")
+	if !found {
+		return false
+	}
+	received, err := hex.DecodeString(digest)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return hmac.Equal(received, mac.Sum(nil))
+}
+
+func parsePushEvent(header http.Header, body []byte) (*pushEvent, error) {
+	switch {
+	case header.Get("X-GitHub-Event") != "":
+		if eventType := header.Get("X-GitHub-Event"); eventType != "push" {
+			return nil, fmt.Errorf("%w: %s", ErrUnsupportedWebhookEvent, eventType)
+		}
+		var payload struct {
+			Ref        string `json:"ref"`
+			Repository struct {
+				CloneURL string `json:"clone_url"`
+				SSHURL   string `json:"ssh_url"`
+				HTMLURL  string `json:"html_url"`
+			} `json:"repository"`
+		}
+		if err := json.Unmarshal(body, &payload); err != nil {
+			return nil, err
+		}
+		return &pushEvent{
+			RepositoryURLs: []string{
+				payload.Repository.CloneURL,
+				payload.Repository.SSHURL,
+				payload.Repository.HTMLURL,
+			},
+			Branches: branches(payload.Ref),
+		}, nil
+
+	case header.Get("X-Gitlab-Event") != "":
+		if eventType := header.Get("X-Gitlab-Event"); eventType != "Push Hook" {
+			return nil, fmt.Errorf("%w: %s", ErrUnsupportedWebhookEvent, eventType)
+		}
+		var payload struct {
+			Ref     string `json:"ref"`
+			Project struct {
+				GitHTTPURL string `json:"git_http_url"`
+				GitSSHURL  string `json:"git_ssh_url"`
+				WebURL     string `json:"web_url"`
+			} `json:"project"`
+		}
+		if err := json.Unmarshal(body, &payload); err != nil {
+			return nil, err
+		}
+		return &pushEvent{
+			RepositoryURLs: []string{
+				payload.Project.GitHTTPURL,
+				payload.Project.GitSSHURL,
+				payload.Project.WebURL,
+			},
+			Branches: branches(payload.Ref),
+		}, nil
+
+	case header.Get("X-Event-Key") != "":
+		if eventType := header.Get("X-Event-Key"); eventType != "repo:push" {
+			return nil, fmt.Errorf("%w: %s", ErrUnsupportedWebhookEvent, eventType)
+		}
+		var payload struct {
+			Push struct {
+				Changes []struct {
+					New *struct {
+						Type string `json:"type"`
+						Name string `json:"name"`
+					} `json:"new"`
+				} `json:"changes"`
+			} `json:"push"`
+			Repository struct {
+				Links struct {
+					HTML struct {
+						Href string `json:"href"`
+					} `json:"html"`
+				} `json:"links"`
+			} `json:"repository"`
+		}
+		if err := json.Unmarshal(body, &payload); err != nil {
+			return nil, err
+		}
+		push := &pushEvent{
+			RepositoryURLs: []string{payload.Repository.Links.HTML.Href},
+		}
+		for _, change := range payload.Push.Changes {
+			// Deleted branches have no new state.
+			if change.New != nil && change.New.Type == "branch" {
+				push.Branches = append(push.Branches, change.New.Name)
+			}
+		}
+		return push, nil
+	}
+
+	return nil, ErrUnsupportedWebhookEvent
+}
+
+// branches returns the branch of a pushed ref. Pushed tags yield no branches.
+func branches(ref string) []string {
+	branch, found := strings.CutPrefix(ref, "refs/heads/")
+	if !found {
+		return nil
+	}
+	return []string{branch}
+}
+
+func (push *pushEvent) matches(gProject *gitops.GitOpsProject) bool {
+	tracksBranch := false
+	for _, branch := range push.Branches {
+		if branch == gProject.Spec.Branch {
+			tracksBranch = true
+			break
+		}
+	}
+	if !tracksBranch {
+		return false
+	}
+
+	projectRepository := normalizeRepositoryURL(gProject.Spec.URL)
+	for _, repositoryURL := range push.RepositoryURLs {
+		if repositoryURL != "" && normalizeRepositoryURL(repositoryURL) == projectRepository {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeRepositoryURL reduces https, ssh and scp-like repository urls to host/path,
+// so that the clone url of a project matches every url of a pushed repository.
+func normalizeRepositoryURL(repositoryURL string) string {
+	normalized := strings.TrimSpace(repositoryURL)
+	if parsed, err := url.Parse(normalized); err == nil && parsed.Host != "" {
+		normalized = parsed.Hostname() + parsed.Path
+	} else if userHost, path, found := strings.Cut(normalized, ":"); found {
+		// scp-like syntax, e.g. git@github.com:owner/repo.git
+		_, host, _ := strings.Cut(userHost, "@")
+		if host == "" {
+			host = userHost
+		}
+		normalized = host + "/" + path
+	}
+	normalized = strings.TrimSuffix(normalized, "/")
+	normalized = strings.TrimSuffix(normalized, ".git")
+	return strings.ToLower(normalized)
+}
@@ -106,6 +106,14 @@ func (opt private) Apply(opts *options) {
 	opts.private = bool(opt)
 }
 
+type bearerToken string
+
+var _ Option = (*bearerToken)(nil)
+
+func (opt bearerToken) Apply(opts *options) {
+	opts.bearerToken = string(opt)
+}
+
 type provider cloud.ProviderID
 
 var _ Option = (*provider)(nil)
@@ -120,6 +128,7 @@ type options struct {
 	private         bool
 	project         projectOption
 	cloudProviderID cloud.ProviderID
+	bearerToken     string
 }
 
 type Option interface {
@@ -154,6 +163,11 @@ func WithProvider(providerID cloud.ProviderID) provider {
 	return provider(providerID)
 }
 
+// WithBearerToken makes a private index.yaml repository require the token instead of basic auth.
+func WithBearerToken(token string) bearerToken {
+	return bearerToken(token)
+}
+
 type Server interface {
 	// base URL of form http://ipaddr:port with no trailing slash
 	URL() string
@@ -287,7 +301,11 @@ func NewHelmEnvironment(opts ...Option) (*Environment, error) {
 				}
 
 				// declcd:abcd
-				if auth[0] != "Basic ZGVjbGNkOmFiY2Q=" {
+				expectedAuth := "Basic ZGVjbGNkOmFiY2Q="
+				if options.bearerToken != "" {
+					expectedAuth = "Bearer " + options.bearerToken
+				}
+				if auth[0] != expectedAuth {
 					w.WriteHeader(500)
 					return
 
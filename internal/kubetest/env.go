@@ -31,6 +31,8 @@ import (
 	"github.com/kharf/declcd/pkg/vcs"
 	_ "github.com/kharf/declcd/test/workingdir"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/kubectl/pkg/scheme"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/envtest"
@@ -206,6 +208,19 @@ func (client *FakeDynamicClient) Delete(ctx context.Context, obj *unstructured.U
 	return client.Err
 }
 
+func (client *FakeDynamicClient) List(
+	ctx context.Context,
+	gvk schema.GroupVersionKind,
+	namespace string,
+	selector labels.Selector,
+) ([]unstructured.Unstructured, error) {
+	return nil, client.Err
+}
+
+func (client *FakeDynamicClient) MigrateStorageVersion(ctx context.Context, crdName string) (int, error) {
+	return 0, client.Err
+}
+
 func (client *FakeDynamicClient) Get(
 	ctx context.Context,
 	obj *unstructured.Unstructured,
@@ -13,10 +13,36 @@ _crd: {
 			kind:     "GitOpsProject"
 			listKind: "GitOpsProjectList"
 			plural:   "gitopsprojects"
+			shortNames: ["gop"]
 			singular: "gitopsproject"
 		}
 		scope: "Namespaced"
 		versions: [{
+			additionalPrinterColumns: [{
+				jsonPath: ".status.revision.reconcileTime"
+				name:     "Last Sync"
+				type:     "date"
+			}, {
+				jsonPath: ".status.revision.commitHash"
+				name:     "Revision"
+				type:     "string"
+			}, {
+				jsonPath: ".status.ready"
+				name:     "Ready"
+				type:     "string"
+			}, {
+				jsonPath: ".spec.suspend"
+				name:     "Suspended"
+				type:     "boolean"
+			}, {
+				jsonPath: ".status.shard"
+				name:     "Shard"
+				type:     "string"
+			}, {
+				jsonPath: ".metadata.creationTimestamp"
+				name:     "Age"
+				type:     "date"
+			}]
 			name: "v1beta1"
 			schema: openAPIV3Schema: {
 				description: "GitOpsProject is the Schema for the gitopsprojects API"
@@ -44,17 +70,300 @@ _crd: {
 					spec: {
 						description: "GitOpsProjectSpec defines the desired state of GitOpsProject"
 						properties: {
+							artifactPath: {
+								description: """
+	Path to a component artifact inside the gitops repository, produced by 'declcd build --output'.
+	When set, components are read from the artifact instead of compiling CUE in-cluster.
+	"""
+								type: "string"
+							}
 							branch: {
-								description: "The branch of the gitops repository holding the declcd configuration."
+								description: """
+	The branch of the gitops repository holding the declcd configuration.
+	It is ignored for project bundles.
+	"""
 								minLength:   1
 								type:        "string"
 							}
+							fieldValidation: {
+								description: """
+	FieldValidation instructs the API server how to handle unknown or duplicate fields of applied manifests and hooks.
+	Strict rejects them and fails the component with the paths of the offending fields,
+	catching schema drift between the cluster and the schemas the project was validated against.
+	Defaults to the behavior of the API server.
+	"""
+								enum: [
+									"Ignore",
+									"Warn",
+									"Strict",
+								]
+								type: "string"
+							}
+							guardrails: {
+								description: "Guardrails refuse objects before they are applied, failing only the component declaring them."
+								properties: {
+									deniedKinds: {
+										description: "DeniedKinds are kinds the project must not apply, e.g. Nodes or PriorityClasses for tenant projects."
+										items: {
+											description: "GitOpsProjectDeniedKind matches objects by their kind."
+											properties: {
+												apiGroup: {
+													description: "APIGroup of matching objects. Empty matches every group."
+													type:        "string"
+												}
+												kind: {
+													description: "Kind of matching objects."
+													minLength:   1
+													type:        "string"
+												}
+											}
+											required: [
+												"kind",
+											]
+											type: "object"
+										}
+										type: "array"
+									}
+									maxObjectSizeBytes: {
+										description: """
+	MaxObjectSizeBytes is the maximum size of an encoded object, e.g. to catch ConfigMaps exceeding the etcd limits.
+	Zero disables the limit.
+	"""
+										format:  "int64"
+										minimum: 0
+										type:    "integer"
+									}
+								}
+								type: "object"
+							}
+							maxConcurrentApplies: {
+								description: """
+	MaxConcurrentApplies limits the components of this project applied in parallel,
+	e.g. to keep a huge project from saturating the API server for other projects of the shard.
+	Defaults to and never exceeds the worker pool size of the controller.
+	"""
+								minimum: 1
+								type:    "integer"
+							}
+							notification: {
+								description: "Notification posts status transitions of this project to an external system."
+								properties: {
+									secretName: {
+										description: "Name of a Secret in the project namespace with a 'secret' key used to sign requests via HMAC-SHA256."
+										type:        "string"
+									}
+									url: {
+										description: "The url receiving a POST request on every status transition."
+										minLength:   1
+										type:        "string"
+									}
+								}
+								required: [
+									"url",
+								]
+								type: "object"
+							}
+							previews: {
+								description: """
+	Previews spawn a preview project per branch matching a glob, e.g. for pull requests.
+	Preview projects are torn down, when their branch is deleted.
+	"""
+								properties: {
+									branches: {
+										description: "Branches is a glob matching the branches to preview, e.g. \"preview/*\"."
+										minLength:   1
+										type:        "string"
+									}
+									namespaceTemplate: {
+										description: """
+	NamespaceTemplate is a Go template of the target namespace of a preview,
+	e.g. "{{ .Project }}-{{ .Branch }}".
+	Branch is the branch name converted to a DNS label.
+	"""
+										minLength: 1
+										type:      "string"
+									}
+								}
+								required: [
+									"branches",
+									"namespaceTemplate",
+								]
+								type: "object"
+							}
+							promotions: {
+								description: "Promotions are the component versions promoted by operators via 'declcd promote'."
+								items: {
+									description: "GitOpsProjectPromotion releases a deferred version of a component."
+									properties: {
+										component: {
+											description: "Component is the id of the promoted component."
+											minLength:   1
+											type:        "string"
+										}
+										digest: {
+											description: "Digest identifies the promoted version of the component."
+											minLength:   1
+											type:        "string"
+										}
+									}
+									required: [
+										"component",
+										"digest",
+									]
+									type: "object"
+								}
+								type: "array"
+							}
 							pullIntervalSeconds: {
 								description: "This defines how often declcd will try to fetch changes from the gitops repository."
 								minimum:     5
 								type:        "integer"
 							}
+							requiredCheck: {
+								description: """
+	RequiredCheck requires a successful commit status or check run on the Git provider,
+	e.g. of a CI job validating the project, before a new commit is applied.
+	Commits without it are skipped and the previously applied commit stays in place.
+	"""
+								properties: {
+									baseURL: {
+										description: "BaseURL of the provider API for GitHub Enterprise or self-managed GitLab instances."
+										type:        "string"
+									}
+									name: {
+										description: "Name of the commit status or check run, e.g. \"declcd-verify\"."
+										minLength:   1
+										type:        "string"
+									}
+									provider: {
+										description: "Provider hosting the repository."
+										enum: [
+											"github",
+											"gitlab",
+										]
+										type: "string"
+									}
+									repository: {
+										description: "Repository is the owner/repo on GitHub or the project id or path on GitLab."
+										minLength:   1
+										type:        "string"
+									}
+									tokenSecretName: {
+										description: "Name of a Secret in the project namespace with a 'token' key, which is allowed to read commit statuses."
+										minLength:   1
+										type:        "string"
+									}
+								}
+								required: [
+									"name",
+									"provider",
+									"repository",
+									"tokenSecretName",
+								]
+								type: "object"
+							}
+							revision: {
+								description: """
+	Revision pins reconciliation to a commit SHA or tag regardless of the branch head,
+	e.g. to roll back an environment to the last known good commit.
+	For project bundles it is a tag or digest overriding the one of the url.
+	"""
+								type: "string"
+							}
 							serviceAccountName: type: "string"
+							shardAffinity: {
+								description: """
+	ShardAffinity selects the controller shards by their pod labels, which are allowed to reconcile this project.
+	A shard assigned via the 'declcd/shard' label, which does not match, refuses the project.
+	Anti-affinity is expressed with the NotIn and DoesNotExist operators.
+	"""
+								properties: {
+									matchExpressions: {
+										description: "matchExpressions is a list of label selector requirements. The requirements are ANDed."
+										items: {
+											description: """
+	A label selector requirement is a selector that contains values, a key, and an operator that
+	relates the key and values.
+	"""
+											properties: {
+												key: {
+													description: "key is the label key that the selector applies to."
+													type:        "string"
+												}
+												operator: {
+													description: """
+	operator represents a key's relationship to a set of values.
+	Valid operators are In, NotIn, Exists and DoesNotExist.
+	"""
+													type: "string"
+												}
+												values: {
+													description: """
+	values is an array of string values. If the operator is In or NotIn,
+	the values array must be non-empty. If the operator is Exists or DoesNotExist,
+	the values array must be empty. This array is replaced during a strategic
+	merge patch.
+	"""
+													items: type: "string"
+													type:                     "array"
+													"x-kubernetes-list-type": "atomic"
+												}
+											}
+											required: [
+												"key",
+												"operator",
+											]
+											type: "object"
+										}
+										type:                     "array"
+										"x-kubernetes-list-type": "atomic"
+									}
+									matchLabels: {
+										additionalProperties: type: "string"
+										description: """
+	matchLabels is a map of {key,value} pairs. A single {key,value} in the matchLabels
+	map is equivalent to an element of matchExpressions, whose key field is "key", the
+	operator is "In", and the values array contains only "value". The requirements are ANDed.
+	"""
+										type: "object"
+									}
+								}
+								type:                    "object"
+								"x-kubernetes-map-type": "atomic"
+							}
+							suppressions: {
+								description: """
+	Suppressions exclude fields from being applied and from drift detection,
+	because they are legitimately managed by other controllers.
+	"""
+								items: {
+									description: "GitOpsProjectSuppression excludes fields of matching objects."
+									properties: {
+										apiGroup: {
+											description: "APIGroup of matching objects. Empty matches every group."
+											type:        "string"
+										}
+										kind: {
+											description: "Kind of matching objects. Empty matches every kind."
+											type:        "string"
+										}
+										paths: {
+											description: """
+	Dot separated field paths, e.g. "spec.replicas".
+	A "*" segment matches every key of an object or every element of a list.
+	"""
+											items: type: "string"
+											minItems: 1
+											type:     "array"
+										}
+									}
+									required: [
+										"paths",
+									]
+									type: "object"
+								}
+								type: "array"
+							}
 							suspend: {
 								description: """
 	This flag tells the controller to suspend subsequent executions, it does
@@ -62,11 +371,48 @@ _crd: {
 	"""
 								type: "boolean"
 							}
+							targetNamespace: {
+								description: """
+	TargetNamespace moves all namespaced objects and Helm releases into this namespace.
+	Namespaces declared by the project are replaced by it and all other cluster scoped objects are not applied,
+	as they would be shared with other projects.
+	It is set on preview projects.
+	"""
+								type: "string"
+							}
+							templateName: {
+								description: """
+	TemplateName is the name of the ClusterGitOpsProjectTemplate this project is stamped from.
+	Projects violating the template are refused.
+	"""
+								type: "string"
+							}
 							url: {
-								description: "The url to the gitops repository."
+								description: """
+	The url to the gitops repository.
+	Urls prefixed with oci://, e.g. oci://ghcr.io/org/project-config:tag, reference a project bundle
+	published with 'declcd publish' instead, which is pulled and verified against its digest every interval.
+	"""
 								minLength:   1
 								type:        "string"
 							}
+							webhook: {
+								description: """
+	Webhook allows Git providers to trigger a reconciliation on push events
+	instead of waiting for the pull interval.
+	"""
+								properties: {
+									secretName: {
+										description: "Name of a Secret in the project namespace with a 'secret' key, which is configured as webhook secret at the Git provider."
+										minLength:   1
+										type:        "string"
+									}
+								}
+								required: [
+									"secretName",
+								]
+								type: "object"
+							}
 						}
 						required: [
 							"branch",
@@ -78,6 +424,81 @@ _crd: {
 					status: {
 						description: "GitOpsProjectStatus defines the observed state of GitOpsProject"
 						properties: {
+							buildErrors: {
+								description: "BuildErrors lists the first CUE errors of the last reconciliation, if it failed to build the project."
+								items: {
+									description: "GitOpsProjectBuildError is a CUE error of a failed build."
+									properties: {
+										column: type: "integer"
+										file: {
+											description: "File relative to the repository. Empty for errors without a position."
+											type:        "string"
+										}
+										line: type: "integer"
+										message: {
+											description: "Message is the truncated error message prefixed with the path of the erroneous value."
+											type:        "string"
+										}
+										source: {
+											description: "Source is the truncated line of the file the error was reported at."
+											type:        "string"
+										}
+									}
+									required: [
+										"message",
+									]
+									type: "object"
+								}
+								type: "array"
+							}
+							components: {
+								description: "Components reports the outcome of every component of the last reconciliation, sorted by their id."
+								items: {
+									description: "GitOpsProjectComponentStatus summarizes the outcome of reconciling a component."
+									properties: {
+										changedRevision: {
+											description: "ChangedRevision is the commit hash the declared content of the component last changed with."
+											type:        "string"
+										}
+										changedTime: {
+											description: "ChangedTime is the time the changed content of the component was first applied."
+											format:      "date-time"
+											type:        "string"
+										}
+										digest: {
+											description: "Digest identifies the declared content of the component, when it was last applied."
+											type:        "string"
+										}
+										id: {
+											description: "ID of the component."
+											type:        "string"
+										}
+										lastAppliedTime: {
+											description: "LastAppliedTime is the last time the component was applied. Nil, when it has never been applied."
+											format:      "date-time"
+											type:        "string"
+										}
+										message: {
+											description: "Message describes why the component could not be applied by the last reconciliation."
+											type:        "string"
+										}
+										revision: {
+											description: "Revision is the commit hash the component was last applied from."
+											type:        "string"
+										}
+										type: {
+											description: "Type of the component, e.g. Manifest or HelmRelease."
+											type:        "string"
+										}
+									}
+									required: [
+										"id",
+										"type",
+									]
+									type: "object"
+								}
+								type: "array"
+							}
 							conditions: {
 								items: {
 									description: """
@@ -172,9 +593,120 @@ _crd: {
 								}
 								type: "array"
 							}
+							consecutiveFailures: {
+								description: "ConsecutiveFailures is the number of failed reconciliations since the last successful one."
+								format:      "int64"
+								type:        "integer"
+							}
+							gitFailures: {
+								description: "GitFailures counts failed Git operations by their class."
+								items: {
+									description: "GitOpsProjectGitFailure records the failures of one class of Git operations."
+									properties: {
+										class: {
+											description: "Class of the failure, one of Auth, Network, RefNotFound, Corruption and Unknown."
+											type:        "string"
+										}
+										count: {
+											description: "Count is the number of failures of this class."
+											format:      "int64"
+											type:        "integer"
+										}
+										lastOccurredAt: {
+											description: "LastOccurredAt is the time of the last failure of this class."
+											format:      "date-time"
+											type:        "string"
+										}
+										message: {
+											description: "Message of the last failure of this class."
+											type:        "string"
+										}
+									}
+									required: [
+										"class",
+										"count",
+										"lastOccurredAt",
+									]
+									type: "object"
+								}
+								type: "array"
+							}
+							lastSkippedAt: {
+								description: "LastSkippedAt is the last time a reconciliation was skipped, because the revision did not change."
+								format:      "date-time"
+								type:        "string"
+							}
+							namespaces: {
+								items: {
+									description: "GitOpsProjectNamespaceStatus summarizes the outcome of applying all components targeting a namespace."
+									properties: {
+										message: {
+											description: "Message describes the first failure of the namespace."
+											type:        "string"
+										}
+										name: {
+											description: "The name of the namespace. Empty for cluster scoped components."
+											type:        "string"
+										}
+										succeeded: {
+											description: "Succeeded reports whether all components of the namespace were applied."
+											type:        "boolean"
+										}
+									}
+									required: [
+										"succeeded",
+									]
+									type: "object"
+								}
+								type: "array"
+							}
+							nextRetryAt: {
+								description: "NextRetryAt is the time the next reconciliation is scheduled after a failure."
+								format:      "date-time"
+								type:        "string"
+							}
+							pendingPromotions: {
+								description: "PendingPromotions lists the component versions, which have been committed, but are not applied yet."
+								items: {
+									description: "GitOpsProjectPendingPromotion is a deferred version of a component."
+									properties: {
+										component: {
+											description: "Component is the id of the deferred component."
+											type:        "string"
+										}
+										digest: {
+											description: "Digest identifies the deferred version of the component."
+											type:        "string"
+										}
+										promoteAfter: {
+											description: "PromoteAfter is the time the version is applied. Nil for versions, which have to be promoted manually."
+											format:      "date-time"
+											type:        "string"
+										}
+									}
+									required: [
+										"component",
+										"digest",
+									]
+									type: "object"
+								}
+								type: "array"
+							}
+							phase: {
+								description: "Phase is the stage the current reconciliation is in, or Idle between reconciliations."
+								type:        "string"
+							}
+							ready: {
+								description: "Ready reports whether the last reconciliation applied all components, either True or False."
+								type:        "string"
+							}
 							revision: {
 								properties: {
 									commitHash: type: "string"
+									pinned: {
+										description: "Pinned is the spec revision the commit was resolved from. Empty while following the branch head."
+										type:        "string"
+									}
 									reconcileTime: {
 										format: "date-time"
 										type:   "string"
@@ -182,6 +714,43 @@ _crd: {
 								}
 								type: "object"
 							}
+							shard: {
+								description: "Shard is the name of the controller shard, which reconciled the project last."
+								type:        "string"
+							}
+							skippedCount: {
+								description: "SkippedCount is the number of skipped reconciliations."
+								format:      "int64"
+								type:        "integer"
+							}
+							stages: {
+								description: "Stages lists the stages of the current or last reconciliation in the order they were entered."
+								items: {
+									description: "GitOpsProjectStage records when a stage of a reconciliation ran."
+									properties: {
+										finishedAt: {
+											description: "FinishedAt is the time the stage was left. Nil while the stage is running."
+											format:      "date-time"
+											type:        "string"
+										}
+										name: {
+											description: "Name of the stage, one of Cloning, Building, Pruning and Applying."
+											type:        "string"
+										}
+										startedAt: {
+											description: "StartedAt is the time the stage was entered."
+											format:      "date-time"
+											type:        "string"
+										}
+									}
+									required: [
+										"name",
+										"startedAt",
+									]
+									type: "object"
+								}
+								type: "array"
+							}
 						}
 						type: "object"
 					}
@@ -194,3 +763,295 @@ _crd: {
 		}]
 	}
 }
+
+_templateCrd: {
+	apiVersion: "apiextensions.k8s.io/v1"
+	kind:       "CustomResourceDefinition"
+	metadata: {
+		annotations: "controller-gen.kubebuilder.io/version": "v0.15.0"
+		name: "clustergitopsprojecttemplates.gitops.declcd.io"
+	}
+	spec: {
+		group: "gitops.declcd.io"
+		names: {
+			kind:     "ClusterGitOpsProjectTemplate"
+			listKind: "ClusterGitOpsProjectTemplateList"
+			plural:   "clustergitopsprojecttemplates"
+			shortNames: ["gopt"]
+			singular: "clustergitopsprojecttemplate"
+		}
+		scope: "Cluster"
+		versions: [{
+			additionalPrinterColumns: [{
+				jsonPath: ".metadata.creationTimestamp"
+				name:     "Age"
+				type:     "date"
+			}]
+			name: "v1beta1"
+			schema: openAPIV3Schema: {
+				description: """
+	ClusterGitOpsProjectTemplate is the Schema for the clustergitopsprojecttemplates API.
+	Admins define templates and tenants stamp GitOpsProjects from them by setting spec.templateName,
+	while the controller refuses projects violating the template.
+	"""
+				properties: {
+					apiVersion: {
+						description: """
+	APIVersion defines the versioned schema of this representation of an object.
+	Servers should convert recognized schemas to the latest internal value, and
+	may reject unrecognized values.
+	More info: https://git.k8s.io/community/contributors/devel/sig-architecture/api-conventions.md#resources
+	"""
+						type: "string"
+					}
+					kind: {
+						description: """
+	Kind is a string value representing the REST resource this object represents.
+	Servers may infer this from the endpoint the client submits requests to.
+	Cannot be updated.
+	In CamelCase.
+	More info: https://git.k8s.io/community/contributors/devel/sig-architecture/api-conventions.md#types-kinds
+	"""
+						type: "string"
+					}
+					metadata: type: "object"
+					spec: {
+						description: "ClusterGitOpsProjectTemplateSpec defines the guardrails of GitOpsProjects stamped from a template."
+						properties: {
+							allowedNamespaces: {
+								description: """
+	AllowedNamespaces are glob patterns matching the namespaces projects are allowed to be created in,
+	e.g. "team-*". Empty allows every namespace.
+	"""
+								items: type: "string"
+								type: "array"
+							}
+							allowedURLs: {
+								description: """
+	AllowedURLs are glob patterns matching the gitops repositories projects are allowed to reconcile,
+	e.g. "git@github.com:my-org/*". Empty allows every repository.
+	"""
+								items: type: "string"
+								type: "array"
+							}
+							maxPullIntervalSeconds: {
+								description: "MaxPullIntervalSeconds is the upper bound of the pull interval of stamped projects."
+								minimum:     5
+								type:        "integer"
+							}
+							minPullIntervalSeconds: {
+								description: "MinPullIntervalSeconds is the lower bound of the pull interval of stamped projects."
+								minimum:     5
+								type:        "integer"
+							}
+							shardAffinity: {
+								description: """
+	ShardAffinity selects the controller shards by their pod labels, which are allowed to reconcile stamped projects.
+	It is enforced in addition to the shard affinity of a project.
+	"""
+								properties: {
+									matchExpressions: {
+										description: "matchExpressions is a list of label selector requirements. The requirements are ANDed."
+										items: {
+											description: """
+	A label selector requirement is a selector that contains values, a key, and an operator that
+	relates the key and values.
+	"""
+											properties: {
+												key: {
+													description: "key is the label key that the selector applies to."
+													type:        "string"
+												}
+												operator: {
+													description: """
+	operator represents a key's relationship to a set of values.
+	Valid operators are In, NotIn, Exists and DoesNotExist.
+	"""
+													type: "string"
+												}
+												values: {
+													description: """
+	values is an array of string values. If the operator is In or NotIn,
+	the values array must be non-empty. If the operator is Exists or DoesNotExist,
+	the values array must be empty. This array is replaced during a strategic
+	merge patch.
+	"""
+													items: type: "string"
+													type:                     "array"
+													"x-kubernetes-list-type": "atomic"
+												}
+											}
+											required: [
+												"key",
+												"operator",
+											]
+											type: "object"
+										}
+										type:                     "array"
+										"x-kubernetes-list-type": "atomic"
+									}
+									matchLabels: {
+										additionalProperties: type: "string"
+										description: """
+	matchLabels is a map of {key,value} pairs. A single {key,value} in the matchLabels
+	map is equivalent to an element of matchExpressions, whose key field is "key", the
+	operator is "In", and the values array contains only "value". The requirements are ANDed.
+	"""
+										type: "object"
+									}
+								}
+								type:                    "object"
+								"x-kubernetes-map-type": "atomic"
+							}
+						}
+						type: "object"
+					}
+				}
+				type: "object"
+			}
+			served:  true
+			storage: true
+		}]
+	}
+}
+
+_projectStateCrd: {
+	apiVersion: "apiextensions.k8s.io/v1"
+	kind:       "CustomResourceDefinition"
+	metadata: {
+		annotations: "controller-gen.kubebuilder.io/version": "v0.15.0"
+		name: "projectstates.gitops.declcd.io"
+	}
+	spec: {
+		group: "gitops.declcd.io"
+		names: {
+			kind:     "ProjectState"
+			listKind: "ProjectStateList"
+			plural:   "projectstates"
+			shortNames: ["gops"]
+			singular: "projectstate"
+		}
+		scope: "Namespaced"
+		versions: [{
+			additionalPrinterColumns: [{
+				jsonPath: ".revision"
+				name:     "Revision"
+				type:     "string"
+			}, {
+				jsonPath: ".inventory.size"
+				name:     "Inventory"
+				type:     "integer"
+			}, {
+				jsonPath: ".reconcileTime"
+				name:     "Reconciled"
+				type:     "date"
+			}]
+			name: "v1beta1"
+			schema: openAPIV3Schema: {
+				description: """
+	ProjectState is the Schema for the projectstates API.
+	It is a read-only view of the delivery state of the GitOpsProject of the same name,
+	which the controller refreshes on every reconciliation.
+	Granting read access to project states does not require access to the inventory or logs of the controller.
+	"""
+				properties: {
+					apiVersion: {
+						description: """
+	APIVersion defines the versioned schema of this representation of an object.
+	Servers should convert recognized schemas to the latest internal value, and
+	may reject unrecognized values.
+	More info: https://git.k8s.io/community/contributors/devel/sig-architecture/api-conventions.md#resources
+	"""
+						type: "string"
+					}
+					failingComponents: {
+						description: "FailingComponents are the components, which failed to reconcile, sorted by id."
+						items: {
+							description: "ProjectStateFailingComponent is a component, which failed to reconcile."
+							properties: {
+								id: type: "string"
+								message: {
+									description: "Message describes why the component failed."
+									type:        "string"
+								}
+								type: {
+									description: "Type is the component type, e.g. Manifest, HelmRelease or the type of a custom component."
+									type:        "string"
+								}
+							}
+							required: [
+								"id",
+								"message",
+								"type",
+							]
+							type: "object"
+						}
+						type: "array"
+					}
+					inventory: {
+						description: "Inventory summarizes the objects and Helm releases managed by the project after the reconciliation."
+						properties: {
+							kinds: {
+								description: """
+	Kinds counts the inventory items by their kind, sorted by kind.
+	Helm releases are counted as kind HelmRelease.
+	"""
+								items: {
+									description: "ProjectStateKindCount is the number of inventory items of a kind."
+									properties: {
+										count: type: "integer"
+										kind: type:  "string"
+									}
+									required: [
+										"count",
+										"kind",
+									]
+									type: "object"
+								}
+								type: "array"
+							}
+							size: {
+								description: "Size is the number of objects and Helm releases in the inventory."
+								type:        "integer"
+							}
+						}
+						required: ["size"]
+						type: "object"
+					}
+					kind: {
+						description: """
+	Kind is a string value representing the REST resource this object represents.
+	Servers may infer this from the endpoint the client submits requests to.
+	Cannot be updated.
+	In CamelCase.
+	More info: https://git.k8s.io/community/contributors/devel/sig-architecture/api-conventions.md#types-kinds
+	"""
+						type: "string"
+					}
+					metadata: type: "object"
+					pendingPrunes: {
+						description: """
+	PendingPrunes are the ids of inventory items, which are no longer declared,
+	but have not been removed from the cluster yet, sorted by id.
+	"""
+						items: type: "string"
+						type: "array"
+					}
+					reconcileTime: {
+						description: "ReconcileTime is the time of the reconciliation."
+						format:      "date-time"
+						type:        "string"
+					}
+					revision: {
+						description: "Revision is the reconciled commit hash."
+						type:        "string"
+					}
+				}
+				required: ["inventory"]
+				type: "object"
+			}
+			served:  true
+			storage: true
+		}]
+	}
+}
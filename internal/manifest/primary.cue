@@ -7,6 +7,8 @@ import (
 _controlPlaneKey: "declcd/control-plane"
 _shardKey: "declcd/shard"
 
+{{- if not .SkipCRD}}
+
 // _crd is autogenerated into crd.cue
 crd: component.#Manifest & {
 	content: _crd & {
@@ -14,13 +16,28 @@ crd: component.#Manifest & {
 	}
 }
 
+templateCrd: component.#Manifest & {
+	content: _templateCrd & {
+		metadata: labels: _{{.Shard}}Labels
+	}
+}
+
+projectStateCrd: component.#Manifest & {
+	content: _projectStateCrd & {
+		metadata: labels: _{{.Shard}}Labels
+	}
+}
+{{- end}}
+
 ns: component.#Manifest & {
-	dependencies: [crd.id]
+	{{- if not .SkipCRD}}
+	dependencies: [crd.id, templateCrd.id, projectStateCrd.id]
+	{{- end}}
 	content: {
 		apiVersion: "v1"
 		kind:       "Namespace"
 		metadata: {
-			name:   "declcd-system"
+			name:   "{{.Namespace}}"
 			labels: _{{.Shard}}Labels
 		}
 	}
@@ -32,7 +49,7 @@ clusterRole: component.#Manifest & {
 		apiVersion: "rbac.authorization.k8s.io/v1"
 		kind:       "ClusterRole"
 		metadata: {
-			name:   "project-controller"
+			name:   "{{.ClusterPrefix}}project-controller"
 			labels: _{{.Shard}}Labels
 		}
 		rules: [
@@ -44,6 +61,15 @@ clusterRole: component.#Manifest & {
 					"watch",
 				]
 			},
+			{
+				apiGroups: ["gitops.declcd.io"]
+				resources: ["clustergitopsprojecttemplates"]
+				verbs: [
+					"get",
+					"list",
+					"watch",
+				]
+			},
 			{
 				apiGroups: ["gitops.declcd.io"]
 				resources: ["gitopsprojects/status"]
@@ -5,8 +5,8 @@ import (
 )
 
 {{.Name}}: component.#Manifest & {
+	// The namespace depends on the CRD, unless it is owned by another instance.
 	dependencies: [
-		crd.id,
 		ns.id,
 	]
 	content: {
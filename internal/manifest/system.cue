@@ -32,7 +32,7 @@ _{{.Shard}}Labels: {
 		apiVersion: "rbac.authorization.k8s.io/v1"
 		kind:       "ClusterRoleBinding"
 		metadata: {
-			name:   "{{.Name}}"
+			name:   "{{.ClusterPrefix}}{{.Name}}"
 			labels: _{{.Shard}}Labels
 		}
 		roleRef: {
@@ -137,11 +137,42 @@ _{{.Shard}}LeaderRoleName: "{{.Shard}}-leader-election"
 	}
 }
 
+{{- if .CertManagerClusterIssuer}}
+
+{{.Shard}}MetricsCertificate: component.#Manifest & {
+	dependencies: [ns.id]
+	content: {
+		apiVersion: "cert-manager.io/v1"
+		kind:       "Certificate"
+		metadata: {
+			name:      "{{.Name}}-metrics"
+			namespace: ns.content.metadata.name
+			labels:    _{{.Shard}}Labels
+		}
+		spec: {
+			secretName: "{{.Name}}-metrics-tls"
+			dnsNames: [
+				"{{.Name}}.\(ns.content.metadata.name).svc",
+				"{{.Name}}.\(ns.content.metadata.name).svc.cluster.local",
+			]
+			issuerRef: {
+				group: "cert-manager.io"
+				kind:  "ClusterIssuer"
+				name:  "{{.CertManagerClusterIssuer}}"
+			}
+		}
+	}
+}
+{{- end}}
+
 {{.Shard}}ProjectControllerDeployment: component.#Manifest & {
 	dependencies: [
 		ns.id,
 		{{.Shard}}PVC.id,
 		knownHostsCm.id,
+		{{- if .CertManagerClusterIssuer}}
+		{{.Shard}}MetricsCertificate.id,
+		{{- end}}
 	]
 	content: {
 		apiVersion: "apps/v1"
@@ -160,6 +191,15 @@ _{{.Shard}}LeaderRoleName: "{{.Shard}}-leader-election"
 				}
 				spec: {
 					serviceAccountName: "{{.Name}}"
+					{{- if .ImagePullSecrets}}
+					imagePullSecrets: [
+						{{- range .ImagePullSecrets}}
+						{
+							name: "{{.}}"
+						},
+						{{- end}}
+					]
+					{{- end}}
 					securityContext: {
 						runAsNonRoot:        true
 						fsGroup:             65532
@@ -182,10 +222,18 @@ _{{.Shard}}LeaderRoleName: "{{.Shard}}-leader-election"
 										path: "name"
 										fieldRef: fieldPath: "metadata.labels['\(_controlPlaneKey)']"
 									},
+									{
+										path: "pod"
+										fieldRef: fieldPath: "metadata.name"
+									},
 									{
 										path: "shard"
 										fieldRef: fieldPath: "metadata.labels['\(_shardKey)']"
 									},
+									{
+										path: "labels"
+										fieldRef: fieldPath: "metadata.labels"
+									},
 								]
 							}
 						},
@@ -197,16 +245,48 @@ _{{.Shard}}LeaderRoleName: "{{.Shard}}-leader-election"
 							name: "cache"
 							emptyDir: {}
 						},
+						{
+							name: "sops"
+							secret: {
+								secretName: "sops-keys"
+								optional:   true
+							}
+						},
+						{
+							name: "inventory-encryption"
+							secret: {
+								secretName: "inventory-encryption"
+								optional:   true
+							}
+						},
+						{{- if .CertManagerClusterIssuer}}
+						{
+							name: "metrics-tls"
+							secret: secretName: {{.Shard}}MetricsCertificate.content.spec.secretName
+						},
+						{{- end}}
 					]
 					containers: [
 						{
 							name:  "{{.Name}}"
-							image: "ghcr.io/kharf/declcd:{{ .Version }}"
+							image: "{{ .ImageRegistry }}/declcd:{{ .Version }}"
 							command: [
 								"/controller",
 							]
 							args: [
 								"--log-level=0",
+								{{- if .ClusterPrefix}}
+								"--project-namespace=\(ns.content.metadata.name)",
+								{{- end}}
+								{{- if .MetricsSecure}}
+								"--metrics-secure=true",
+								{{- end}}
+								{{- if .CertManagerClusterIssuer}}
+								"--metrics-cert-dir=/metrics-certs",
+								{{- end}}
+								{{- if .CUERegistry}}
+								"--cue-registry={{.CUERegistry}}",
+								{{- end}}
 							]
 							securityContext: {
 								allowPrivilegeEscalation: false
@@ -251,6 +331,23 @@ _{{.Shard}}LeaderRoleName: "{{.Shard}}-leader-election"
 									name:      "cache"
 									mountPath: "/.cache"
 								},
+								{
+									name:      "sops"
+									mountPath: "/sops"
+									readOnly:  true
+								},
+								{
+									name:      "inventory-encryption"
+									mountPath: "/inventory-encryption"
+									readOnly:  true
+								},
+								{{- if .CertManagerClusterIssuer}}
+								{
+									name:      "metrics-tls"
+									mountPath: "/metrics-certs"
+									readOnly:  true
+								},
+								{{- end}}
 							]
 						},
 					]
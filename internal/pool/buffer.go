@@ -0,0 +1,50 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package pool provides reusable buffers for encoding large objects.
+// Objects are encoded once into a pooled buffer, which is shared between applying and storing them in the inventory.
+// Decoded component contents stay materialized as maps, because the garbage collector,
+// the namespace isolation and the audit publisher read them after the build.
+package pool
+
+import (
+	"bytes"
+	"sync"
+)
+
+// maxBufferSize limits the capacity of pooled buffers,
+// so a single giant object does not pin its memory for the lifetime of the process.
+const maxBufferSize = 8 << 20
+
+var buffers = sync.Pool{
+	New: func() any {
+		return new(bytes.Buffer)
+	},
+}
+
+// GetBuffer returns an empty buffer from the pool.
+func GetBuffer() *bytes.Buffer {
+	buf := buffers.Get().(*bytes.Buffer)
+	buf.Reset()
+	return buf
+}
+
+// PutBuffer returns a buffer to the pool.
+// The buffer must not be used afterwards.
+func PutBuffer(buf *bytes.Buffer) {
+	if buf.Cap() > maxBufferSize {
+		return
+	}
+	buffers.Put(buf)
+}
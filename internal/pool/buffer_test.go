@@ -0,0 +1,46 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pool
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestGetBuffer(t *testing.T) {
+	buf := GetBuffer()
+	assert.Equal(t, buf.Len(), 0)
+	buf.WriteString(`{"kind":"ConfigMap"}`)
+	PutBuffer(buf)
+
+	for range 10 {
+		reused := GetBuffer()
+		assert.Equal(t, reused.Len(), 0)
+		PutBuffer(reused)
+	}
+}
+
+func TestPutBuffer(t *testing.T) {
+	oversized := GetBuffer()
+	oversized.Grow(maxBufferSize + 1)
+	PutBuffer(oversized)
+
+	for range 10 {
+		buf := GetBuffer()
+		assert.Assert(t, buf != oversized)
+		assert.Assert(t, buf.Cap() <= maxBufferSize)
+	}
+}
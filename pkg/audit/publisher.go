@@ -0,0 +1,261 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"cuelabs.dev/go/oci/ociregistry"
+	"cuelabs.dev/go/oci/ociregistry/ociauth"
+	"cuelabs.dev/go/oci/ociregistry/ociclient"
+	"github.com/kharf/declcd/pkg/inventory"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// ArtifactType identifies a Declcd audit record in an OCI registry.
+	ArtifactType = "application/vnd.declcd.audit.v1"
+	// ManifestsMediaType is the media type of the layer holding all rendered objects as a multi-document YAML stream.
+	ManifestsMediaType = "application/vnd.declcd.audit.manifests.v1+yaml"
+	// InventoryMediaType is the media type of the layer holding the inventory snapshot.
+	InventoryMediaType = "application/vnd.declcd.audit.inventory.v1+json"
+	// RedactedValue replaces the values of Secrets, which are never published.
+	RedactedValue = "<redacted>"
+)
+
+var (
+	ErrInvalidRepository = errors.New("Invalid audit repository")
+)
+
+// Revision is the fully rendered cluster state applied for a Git commit.
+type Revision struct {
+	// Project is the name of the reconciled GitOpsProject.
+	Project string
+
+	// Namespace of the reconciled GitOpsProject.
+	Namespace string
+
+	// The hash of the reconciled Git Commit.
+	CommitHash string
+
+	// Manifests are the applied Kubernetes objects, including the rendered objects of Helm releases.
+	Manifests []unstructured.Unstructured
+
+	// Inventory is the snapshot of the inventory after the reconciliation.
+	Inventory *inventory.Storage
+}
+
+// InventoryEntry is the audit representation of an inventory item.
+type InventoryEntry struct {
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	Namespace  string `json:"namespace"`
+	Kind       string `json:"kind"`
+	APIVersion string `json:"apiVersion,omitempty"`
+}
+
+// Publisher pushes the rendered cluster state of a reconciled revision as an OCI artifact,
+// giving an immutable record of exactly what was applied for each commit.
+type Publisher struct {
+	registry   ociregistry.Interface
+	repository string
+}
+
+// NewPublisher constructs a [Publisher] pushing to given repository reference, e.g. "registry.example.com/declcd/audit".
+// Credentials are read from the Docker config file.
+func NewPublisher(repository string, plainHTTP bool) (*Publisher, error) {
+	host, repoPath, found := strings.Cut(repository, "/")
+	if !found || host == "" || repoPath == "" {
+		return nil, fmt.Errorf("%w: expected <host>/<path>, got '%s'", ErrInvalidRepository, repository)
+	}
+
+	config, err := ociauth.Load(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	registry, err := ociclient.New(host, &ociclient.Options{
+		Transport: ociauth.NewStdTransport(ociauth.StdTransportParams{
+			Config:    config,
+			Transport: http.DefaultTransport,
+		}),
+		Insecure: plainHTTP,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return NewPublisherForRegistry(registry, repoPath), nil
+}
+
+// NewPublisherForRegistry constructs a [Publisher] pushing to the repository of an already connected registry.
+func NewPublisherForRegistry(registry ociregistry.Interface, repository string) *Publisher {
+	return &Publisher{
+		registry:   registry,
+		repository: repository,
+	}
+}
+
+// Publish pushes the revision as an OCI artifact to <repository>/<namespace>/<project> tagged with the commit hash.
+// The values of Secrets are redacted.
+func (p *Publisher) Publish(ctx context.Context, revision Revision) (*ociregistry.Descriptor, error) {
+	repository := fmt.Sprintf(
+		"%s/%s/%s",
+		p.repository,
+		strings.ToLower(revision.Namespace),
+		strings.ToLower(revision.Project),
+	)
+
+	manifests, err := encodeManifests(revision.Manifests)
+	if err != nil {
+		return nil, err
+	}
+	manifestsDesc, err := p.pushBlob(ctx, repository, ManifestsMediaType, manifests)
+	if err != nil {
+		return nil, err
+	}
+
+	inv, err := encodeInventory(revision.Inventory)
+	if err != nil {
+		return nil, err
+	}
+	inventoryDesc, err := p.pushBlob(ctx, repository, InventoryMediaType, inv)
+	if err != nil {
+		return nil, err
+	}
+
+	configDesc, err := p.pushBlob(ctx, repository, ocispec.DescriptorEmptyJSON.MediaType, ocispec.DescriptorEmptyJSON.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := ocispec.Manifest{
+		MediaType:    ocispec.MediaTypeImageManifest,
+		ArtifactType: ArtifactType,
+		Config:       *configDesc,
+		Layers: []ocispec.Descriptor{
+			*manifestsDesc,
+			*inventoryDesc,
+		},
+		Annotations: map[string]string{
+			ocispec.AnnotationRevision: revision.CommitHash,
+			ocispec.AnnotationTitle:    fmt.Sprintf("%s/%s", revision.Namespace, revision.Project),
+		},
+	}
+	manifest.SchemaVersion = 2
+
+	manifestContent, err := json.Marshal(manifest)
+	if err != nil {
+		return nil, err
+	}
+
+	desc, err := p.registry.PushManifest(
+		ctx,
+		repository,
+		revision.CommitHash,
+		manifestContent,
+		ocispec.MediaTypeImageManifest,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &desc, nil
+}
+
+func (p *Publisher) pushBlob(
+	ctx context.Context,
+	repository string,
+	mediaType string,
+	content []byte,
+) (*ociregistry.Descriptor, error) {
+	desc := ociregistry.Descriptor{
+		MediaType: mediaType,
+		Digest:    digest.FromBytes(content),
+		Size:      int64(len(content)),
+	}
+	desc, err := p.registry.PushBlob(ctx, repository, desc, bytes.NewReader(content))
+	if err != nil {
+		return nil, err
+	}
+	return &desc, nil
+}
+
+func encodeManifests(manifests []unstructured.Unstructured) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	for _, manifest := range manifests {
+		content, err := yaml.Marshal(redact(manifest).Object)
+		if err != nil {
+			return nil, err
+		}
+		buf.WriteString("---\n")
+		buf.Write(content)
+	}
+	return buf.Bytes(), nil
+}
+
+// redact returns a copy of Secrets with their values replaced by [RedactedValue].
+// The keys are kept, so that the record still shows which keys were applied.
+func redact(manifest unstructured.Unstructured) unstructured.Unstructured {
+	if manifest.GetKind() != "Secret" || manifest.GroupVersionKind().Group != "" {
+		return manifest
+	}
+	redacted := *manifest.DeepCopy()
+	for _, field := range []string{"data", "stringData"} {
+		values, ok := redacted.Object[field].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for key := range values {
+			values[key] = RedactedValue
+		}
+	}
+	return redacted
+}
+
+func encodeInventory(storage *inventory.Storage) ([]byte, error) {
+	entries := make([]InventoryEntry, 0)
+	if storage != nil {
+		for _, item := range storage.Items() {
+			entry := InventoryEntry{
+				ID:        item.GetID(),
+				Name:      item.GetName(),
+				Namespace: item.GetNamespace(),
+			}
+			switch item := item.(type) {
+			case *inventory.ManifestItem:
+				entry.Kind = item.TypeMeta.Kind
+				entry.APIVersion = item.TypeMeta.APIVersion
+			case *inventory.HelmReleaseItem:
+				entry.Kind = "HelmRelease"
+			}
+			entries = append(entries, entry)
+		}
+	}
+	slices.SortFunc(entries, func(a, b InventoryEntry) int {
+		return strings.Compare(a.ID, b.ID)
+	})
+	return json.Marshal(entries)
+}
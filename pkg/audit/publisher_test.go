@@ -0,0 +1,145 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"cuelabs.dev/go/oci/ociregistry/ocimem"
+	"github.com/kharf/declcd/pkg/audit"
+	"github.com/kharf/declcd/pkg/inventory"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"gotest.tools/v3/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestPublisher_Publish(t *testing.T) {
+	ctx := context.Background()
+	registry := ocimem.New()
+	publisher := audit.NewPublisherForRegistry(registry, "declcd/audit")
+
+	inventoryPath, err := os.MkdirTemp("", "")
+	assert.NilError(t, err)
+	defer os.RemoveAll(inventoryPath)
+	inventoryInstance := inventory.Instance{
+		Path: inventoryPath,
+	}
+	err = inventoryInstance.StoreItem(&inventory.HelmReleaseItem{
+		Name:      "test",
+		Namespace: "test",
+		ID:        "test_test_HelmRelease",
+	}, nil)
+	assert.NilError(t, err)
+	storage, err := inventoryInstance.Load()
+	assert.NilError(t, err)
+
+	ns := unstructured.Unstructured{}
+	ns.SetAPIVersion("v1")
+	ns.SetKind("Namespace")
+	ns.SetName("test")
+
+	secret := unstructured.Unstructured{}
+	secret.SetAPIVersion("v1")
+	secret.SetKind("Secret")
+	secret.SetName("credentials")
+	secret.SetNamespace("test")
+	secret.Object["data"] = map[string]interface{}{"password": "c2VjcmV0"}
+	secret.Object["stringData"] = map[string]interface{}{"user": "admin"}
+
+	desc, err := publisher.Publish(ctx, audit.Revision{
+		Project:    "Project",
+		Namespace:  "Tenant",
+		CommitHash: "abc",
+		Manifests:  []unstructured.Unstructured{ns, secret},
+		Inventory:  storage,
+	})
+	assert.NilError(t, err)
+
+	manifestReader, err := registry.GetTag(ctx, "declcd/audit/tenant/project", "abc")
+	assert.NilError(t, err)
+	defer manifestReader.Close()
+	assert.Equal(t, manifestReader.Descriptor().Digest, desc.Digest)
+
+	var manifest ocispec.Manifest
+	err = json.NewDecoder(manifestReader).Decode(&manifest)
+	assert.NilError(t, err)
+	assert.Equal(t, manifest.ArtifactType, audit.ArtifactType)
+	assert.Equal(t, manifest.Annotations[ocispec.AnnotationRevision], "abc")
+	assert.Equal(t, manifest.Annotations[ocispec.AnnotationTitle], "Tenant/Project")
+	assert.Assert(t, len(manifest.Layers) == 2)
+	assert.Equal(t, manifest.Layers[0].MediaType, audit.ManifestsMediaType)
+	assert.Equal(t, manifest.Layers[1].MediaType, audit.InventoryMediaType)
+
+	manifests := readBlob(t, registry, manifest.Layers[0])
+	assert.Equal(
+		t,
+		string(manifests),
+		"---\napiVersion: v1\nkind: Namespace\nmetadata:\n  name: test\n"+
+			"---\napiVersion: v1\ndata:\n  password: <redacted>\nkind: Secret\nmetadata:\n  name: credentials\n  namespace: test\nstringData:\n  user: <redacted>\n",
+	)
+	// Redaction never modifies the applied objects.
+	assert.Equal(t, secret.Object["data"].(map[string]interface{})["password"], "c2VjcmV0")
+
+	var entries []audit.InventoryEntry
+	err = json.NewDecoder(bytes.NewReader(readBlob(t, registry, manifest.Layers[1]))).Decode(&entries)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, entries, []audit.InventoryEntry{
+		{
+			ID:        "test_test_HelmRelease",
+			Name:      "test",
+			Namespace: "test",
+			Kind:      "HelmRelease",
+		},
+	})
+}
+
+func readBlob(t *testing.T, registry *ocimem.Registry, desc ocispec.Descriptor) []byte {
+	reader, err := registry.GetBlob(context.Background(), "declcd/audit/tenant/project", desc.Digest)
+	assert.NilError(t, err)
+	defer reader.Close()
+	content, err := io.ReadAll(reader)
+	assert.NilError(t, err)
+	return content
+}
+
+func TestPublisher_Publish_SameNameInOtherNamespace(t *testing.T) {
+	ctx := context.Background()
+	registry := ocimem.New()
+	publisher := audit.NewPublisherForRegistry(registry, "declcd/audit")
+
+	for _, namespace := range []string{"team-a", "team-b"} {
+		_, err := publisher.Publish(ctx, audit.Revision{
+			Project:    "shop",
+			Namespace:  namespace,
+			CommitHash: "abc",
+		})
+		assert.NilError(t, err)
+	}
+
+	for _, repository := range []string{"declcd/audit/team-a/shop", "declcd/audit/team-b/shop"} {
+		reader, err := registry.GetTag(ctx, repository, "abc")
+		assert.NilError(t, err)
+		var manifest ocispec.Manifest
+		assert.NilError(t, json.NewDecoder(reader).Decode(&manifest))
+		reader.Close()
+		assert.Assert(t, strings.HasSuffix(manifest.Annotations[ocispec.AnnotationTitle], "/shop"))
+	}
+}
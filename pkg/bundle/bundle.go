@@ -0,0 +1,341 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bundle
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"cuelabs.dev/go/oci/ociregistry"
+	"cuelabs.dev/go/oci/ociregistry/ociauth"
+	"cuelabs.dev/go/oci/ociregistry/ociclient"
+	"cuelabs.dev/go/oci/ociregistry/ociref"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+const (
+	// Scheme prefixes project urls referencing a bundle instead of a Git repository.
+	Scheme = "oci://"
+	// ArtifactType identifies a Declcd project bundle in an OCI registry.
+	ArtifactType = "application/vnd.declcd.project.v1"
+	// LayerMediaType is the media type of the layer holding the project directory as gzipped tar archive.
+	LayerMediaType = "application/vnd.declcd.project.layer.v1.tar+gzip"
+)
+
+var (
+	ErrInvalidReference = errors.New("Invalid bundle reference")
+	ErrInvalidBundle    = errors.New("Invalid bundle")
+	ErrDigestMismatch   = errors.New("Bundle digest mismatch")
+)
+
+// IsBundle reports whether a project url references a bundle.
+func IsBundle(url string) bool {
+	return strings.HasPrefix(url, Scheme)
+}
+
+// ParseReference parses a bundle url like "oci://ghcr.io/org/project-config:tag" or "oci://ghcr.io/org/project-config@sha256:...".
+func ParseReference(url string) (ociref.Reference, error) {
+	ref, err := ociref.Parse(strings.TrimPrefix(url, Scheme))
+	if err != nil {
+		return ociref.Reference{}, fmt.Errorf("%w: %s: %w", ErrInvalidReference, url, err)
+	}
+	if ref.Tag == "" && ref.Digest == "" {
+		return ociref.Reference{}, fmt.Errorf("%w: %s: missing tag or digest", ErrInvalidReference, url)
+	}
+	return ref, nil
+}
+
+// NewRegistry connects to the registry host of a bundle.
+// Credentials are read from the Docker config file.
+func NewRegistry(host string, plainHTTP bool, insecureSkipTLSverify bool) (ociregistry.Interface, error) {
+	config, err := ociauth.Load(nil)
+	if err != nil {
+		return nil, err
+	}
+	transport := http.DefaultTransport
+	if insecureSkipTLSverify {
+		insecureTransport := http.DefaultTransport.(*http.Transport).Clone()
+		insecureTransport.TLSClientConfig = &tls.Config{
+			InsecureSkipVerify: true,
+		}
+		transport = insecureTransport
+	}
+	return ociclient.New(host, &ociclient.Options{
+		Transport: ociauth.NewStdTransport(ociauth.StdTransportParams{
+			Config:    config,
+			Transport: transport,
+		}),
+		Insecure: plainHTTP,
+	})
+}
+
+// Publish pushes the project directory as bundle tagged with the reference tag.
+// Git metadata is not part of the bundle.
+func Publish(
+	ctx context.Context,
+	registry ociregistry.Interface,
+	ref ociref.Reference,
+	dir string,
+) (*ociregistry.Descriptor, error) {
+	if ref.Tag == "" {
+		return nil, fmt.Errorf("%w: %s: missing tag", ErrInvalidReference, ref)
+	}
+	archive, err := archiveDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	layerDesc, err := pushBlob(ctx, registry, ref.Repository, LayerMediaType, archive)
+	if err != nil {
+		return nil, err
+	}
+	configDesc, err := pushBlob(
+		ctx,
+		registry,
+		ref.Repository,
+		ocispec.DescriptorEmptyJSON.MediaType,
+		ocispec.DescriptorEmptyJSON.Data,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := ocispec.Manifest{
+		MediaType:    ocispec.MediaTypeImageManifest,
+		ArtifactType: ArtifactType,
+		Config:       *configDesc,
+		Layers:       []ocispec.Descriptor{*layerDesc},
+	}
+	manifest.SchemaVersion = 2
+	manifestContent, err := json.Marshal(manifest)
+	if err != nil {
+		return nil, err
+	}
+	desc, err := registry.PushManifest(ctx, ref.Repository, ref.Tag, manifestContent, ocispec.MediaTypeImageManifest)
+	if err != nil {
+		return nil, err
+	}
+	return &desc, nil
+}
+
+// Pull replaces the content of dir with the bundle and returns the digest of its manifest, which identifies the pulled revision.
+// References with a digest are preferred over their tag.
+// The manifest and the layer are verified against their digests before anything is extracted.
+func Pull(
+	ctx context.Context,
+	registry ociregistry.Interface,
+	ref ociref.Reference,
+	dir string,
+) (string, error) {
+	var manifestReader ociregistry.BlobReader
+	var err error
+	if ref.Digest != "" {
+		manifestReader, err = registry.GetManifest(ctx, ref.Repository, ref.Digest)
+	} else {
+		manifestReader, err = registry.GetTag(ctx, ref.Repository, ref.Tag)
+	}
+	if err != nil {
+		return "", err
+	}
+	manifestDesc := manifestReader.Descriptor()
+	manifestContent, err := readVerified(manifestReader, manifestDesc)
+	if err != nil {
+		return "", err
+	}
+	if ref.Digest != "" && manifestDesc.Digest != ref.Digest {
+		return "", fmt.Errorf("%w: expected manifest %s, got %s", ErrDigestMismatch, ref.Digest, manifestDesc.Digest)
+	}
+
+	var manifest ocispec.Manifest
+	if err := json.Unmarshal(manifestContent, &manifest); err != nil {
+		return "", fmt.Errorf("%w: %w", ErrInvalidBundle, err)
+	}
+	if manifest.ArtifactType != ArtifactType || len(manifest.Layers) != 1 ||
+		manifest.Layers[0].MediaType != LayerMediaType {
+		return "", fmt.Errorf("%w: %s is not a project bundle", ErrInvalidBundle, ref)
+	}
+
+	layerDesc := manifest.Layers[0]
+	layerReader, err := registry.GetBlob(ctx, ref.Repository, layerDesc.Digest)
+	if err != nil {
+		return "", err
+	}
+	archive, err := readVerified(layerReader, layerDesc)
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.RemoveAll(dir); err != nil {
+		return "", err
+	}
+	if err := extract(archive, dir); err != nil {
+		return "", err
+	}
+	return manifestDesc.Digest.String(), nil
+}
+
+func readVerified(reader ociregistry.BlobReader, desc ociregistry.Descriptor) ([]byte, error) {
+	defer reader.Close()
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(content)) != desc.Size && desc.Size != 0 {
+		return nil, fmt.Errorf("%w: expected %d bytes of %s, got %d", ErrDigestMismatch, desc.Size, desc.Digest, len(content))
+	}
+	if actual := desc.Digest.Algorithm().FromBytes(content); actual != desc.Digest {
+		return nil, fmt.Errorf("%w: expected %s, got %s", ErrDigestMismatch, desc.Digest, actual)
+	}
+	return content, nil
+}
+
+func pushBlob(
+	ctx context.Context,
+	registry ociregistry.Interface,
+	repository string,
+	mediaType string,
+	content []byte,
+) (*ociregistry.Descriptor, error) {
+	desc := ociregistry.Descriptor{
+		MediaType: mediaType,
+		Digest:    digest.FromBytes(content),
+		Size:      int64(len(content)),
+	}
+	desc, err := registry.PushBlob(ctx, repository, desc, bytes.NewReader(content))
+	if err != nil {
+		return nil, err
+	}
+	return &desc, nil
+}
+
+// archiveDir archives regular files and directories. Symbolic links are not followed.
+func archiveDir(dir string) ([]byte, error) {
+	var buf bytes.Buffer
+	gzipWriter := gzip.NewWriter(&buf)
+	tarWriter := tar.NewWriter(gzipWriter)
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() && entry.Name() == ".git" {
+			return filepath.SkipDir
+		}
+		if path == dir || !(entry.IsDir() || entry.Type().IsRegular()) {
+			return nil
+		}
+		relativePath, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(relativePath)
+		// Bundles of the same content have the same digest.
+		header.ModTime = time.Time{}
+		header.AccessTime, header.ChangeTime = time.Time{}, time.Time{}
+		header.Uname, header.Gname = "", ""
+		header.Uid, header.Gid = 0, 0
+		if err := tarWriter.WriteHeader(header); err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			return nil
+		}
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		_, err = io.Copy(tarWriter, file)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := tarWriter.Close(); err != nil {
+		return nil, err
+	}
+	if err := gzipWriter.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// extract refuses entries escaping dir.
+func extract(archive []byte, dir string) error {
+	gzipReader, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidBundle, err)
+	}
+	defer gzipReader.Close()
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	tarReader := tar.NewReader(gzipReader)
+	for {
+		header, err := tarReader.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidBundle, err)
+		}
+		name := filepath.FromSlash(header.Name)
+		if !filepath.IsLocal(name) {
+			return fmt.Errorf("%w: path %s escapes the bundle", ErrInvalidBundle, header.Name)
+		}
+		path := filepath.Join(dir, name)
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(path, 0700); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+				return err
+			}
+			file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(file, tarReader)
+			file.Close()
+			if err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("%w: unsupported entry %s", ErrInvalidBundle, header.Name)
+		}
+	}
+}
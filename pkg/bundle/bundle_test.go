@@ -0,0 +1,152 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bundle_test
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"cuelabs.dev/go/oci/ociregistry"
+	"cuelabs.dev/go/oci/ociregistry/ocimem"
+	"github.com/kharf/declcd/pkg/bundle"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"gotest.tools/v3/assert"
+)
+
+func TestPublishAndPull(t *testing.T) {
+	ctx := context.Background()
+	registry := ocimem.New()
+
+	projectDir := t.TempDir()
+	writeFile(t, filepath.Join(projectDir, "cue.mod", "module.cue"), `module: "example.com/project@v0"`)
+	writeFile(t, filepath.Join(projectDir, "apps", "app.cue"), "package apps\n")
+	writeFile(t, filepath.Join(projectDir, ".git", "HEAD"), "ref: refs/heads/main\n")
+
+	ref, err := bundle.ParseReference("oci://registry.local/org/project-config:v1")
+	assert.NilError(t, err)
+	desc, err := bundle.Publish(ctx, registry, ref, projectDir)
+	assert.NilError(t, err)
+
+	// Publishing unchanged content yields the same revision.
+	republished, err := bundle.Publish(ctx, registry, ref, projectDir)
+	assert.NilError(t, err)
+	assert.Equal(t, republished.Digest, desc.Digest)
+
+	targetDir := filepath.Join(t.TempDir(), "project")
+	writeFile(t, filepath.Join(targetDir, "stale.cue"), "package stale\n")
+	revision, err := bundle.Pull(ctx, registry, ref, targetDir)
+	assert.NilError(t, err)
+	assert.Equal(t, revision, desc.Digest.String())
+
+	content, err := os.ReadFile(filepath.Join(targetDir, "apps", "app.cue"))
+	assert.NilError(t, err)
+	assert.Equal(t, string(content), "package apps\n")
+	_, err = os.Stat(filepath.Join(targetDir, "stale.cue"))
+	assert.Assert(t, os.IsNotExist(err))
+	_, err = os.Stat(filepath.Join(targetDir, ".git"))
+	assert.Assert(t, os.IsNotExist(err))
+
+	pinned, err := bundle.ParseReference("oci://registry.local/org/project-config@" + desc.Digest.String())
+	assert.NilError(t, err)
+	revision, err = bundle.Pull(ctx, registry, pinned, targetDir)
+	assert.NilError(t, err)
+	assert.Equal(t, revision, desc.Digest.String())
+}
+
+func TestPull_NoBundle(t *testing.T) {
+	ctx := context.Background()
+	registry := ocimem.New()
+
+	ref, err := bundle.ParseReference("oci://registry.local/org/image:v1")
+	assert.NilError(t, err)
+	config := ocispec.DescriptorEmptyJSON
+	_, err = registry.PushBlob(ctx, ref.Repository, config, bytes.NewReader(config.Data))
+	assert.NilError(t, err)
+	manifest := []byte(`{"schemaVersion":2,"mediaType":"` + ocispec.MediaTypeImageManifest +
+		`","config":{"mediaType":"` + config.MediaType + `","digest":"` + config.Digest.String() +
+		`","size":2},"layers":[]}`)
+	_, err = registry.PushManifest(ctx, ref.Repository, ref.Tag, manifest, ocispec.MediaTypeImageManifest)
+	assert.NilError(t, err)
+
+	_, err = bundle.Pull(ctx, registry, ref, t.TempDir())
+	assert.ErrorIs(t, err, bundle.ErrInvalidBundle)
+}
+
+// tamperingRegistry serves a different blob for every requested layer.
+type tamperingRegistry struct {
+	ociregistry.Interface
+	tampered digest.Digest
+}
+
+func (registry tamperingRegistry) GetBlob(
+	ctx context.Context,
+	repository string,
+	_ ociregistry.Digest,
+) (ociregistry.BlobReader, error) {
+	return registry.Interface.GetBlob(ctx, repository, registry.tampered)
+}
+
+func TestPull_DigestMismatch(t *testing.T) {
+	ctx := context.Background()
+	registry := ocimem.New()
+
+	projectDir := t.TempDir()
+	writeFile(t, filepath.Join(projectDir, "apps", "app.cue"), "package apps\n")
+	ref, err := bundle.ParseReference("oci://registry.local/org/project-config:v1")
+	assert.NilError(t, err)
+	_, err = bundle.Publish(ctx, registry, ref, projectDir)
+	assert.NilError(t, err)
+
+	tampered := []byte("tampered")
+	tamperedDesc, err := registry.PushBlob(ctx, ref.Repository, ociregistry.Descriptor{
+		MediaType: bundle.LayerMediaType,
+		Digest:    digest.FromBytes(tampered),
+		Size:      int64(len(tampered)),
+	}, bytes.NewReader(tampered))
+	assert.NilError(t, err)
+
+	targetDir := filepath.Join(t.TempDir(), "project")
+	writeFile(t, filepath.Join(targetDir, "apps", "app.cue"), "package apps\n")
+	_, err = bundle.Pull(ctx, tamperingRegistry{Interface: registry, tampered: tamperedDesc.Digest}, ref, targetDir)
+	assert.ErrorIs(t, err, bundle.ErrDigestMismatch)
+	// The previous content is kept.
+	_, err = os.Stat(filepath.Join(targetDir, "apps", "app.cue"))
+	assert.NilError(t, err)
+
+	unknown, err := bundle.ParseReference(
+		"oci://registry.local/org/project-config@" + digest.FromString("unknown").String(),
+	)
+	assert.NilError(t, err)
+	_, err = bundle.Pull(ctx, registry, unknown, t.TempDir())
+	assert.Assert(t, err != nil)
+}
+
+func TestParseReference(t *testing.T) {
+	_, err := bundle.ParseReference("oci://registry.local/org/project-config")
+	assert.ErrorIs(t, err, bundle.ErrInvalidReference)
+	_, err = bundle.ParseReference("oci://")
+	assert.ErrorIs(t, err, bundle.ErrInvalidReference)
+	assert.Assert(t, bundle.IsBundle("oci://registry.local/org/project-config:v1"))
+	assert.Assert(t, !bundle.IsBundle("https://github.com/kharf/declcd.git"))
+}
+
+func writeFile(t *testing.T, path string, content string) {
+	assert.NilError(t, os.MkdirAll(filepath.Dir(path), 0700))
+	assert.NilError(t, os.WriteFile(path, []byte(content), 0600))
+}
@@ -0,0 +1,172 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloud
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	// GitHubAPIURL is the default endpoint of the GitHub REST API.
+	GitHubAPIURL = "https://api.github.com"
+)
+
+var (
+	ErrInvalidPrivateKey = errors.New("Invalid GitHub App private key")
+)
+
+// Installation access token of a GitHub App.
+type GitHubInstallationToken struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// GitHubAppProvider mints short-lived installation tokens from a GitHub App private key,
+// which are accepted by the GitHub Container Registry.
+type GitHubAppProvider struct {
+	HttpClient *http.Client
+
+	// APIURL of the GitHub REST API. Defaults to [GitHubAPIURL].
+	APIURL string
+
+	AppID          int64
+	InstallationID int64
+
+	// PEM encoded PKCS1 or PKCS8 RSA private key of the App.
+	PrivateKey []byte
+}
+
+var _ Provider = (*GitHubAppProvider)(nil)
+
+func (provider *GitHubAppProvider) FetchCredentials(ctx context.Context) (*Credentials, error) {
+	appToken, err := provider.signAppToken(time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	apiURL := provider.APIURL
+	if apiURL == "" {
+		apiURL = GitHubAPIURL
+	}
+	req, err := http.NewRequestWithContext(
+		ctx,
+		http.MethodPost,
+		fmt.Sprintf(
+			"%s/app/installations/%d/access_tokens",
+			strings.TrimSuffix(apiURL, "/"),
+			provider.InstallationID,
+		),
+		nil,
+	)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Add("Authorization", "Bearer "+appToken)
+	req.Header.Add("Accept", "application/vnd.github+json")
+
+	response, err := provider.HttpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusCreated {
+		return nil, fmt.Errorf(
+			"%w: got status code %d from github",
+			ErrUnexpectedResponse,
+			response.StatusCode,
+		)
+	}
+
+	var token GitHubInstallationToken
+	if err := json.NewDecoder(response.Body).Decode(&token); err != nil {
+		return nil, err
+	}
+
+	return &Credentials{
+		Username: "x-access-token",
+		Password: token.Token,
+	}, nil
+}
+
+// signAppToken creates the RS256 signed JWT authenticating as the App itself.
+// See: https://docs.github.com/en/apps/creating-github-apps/authenticating-with-a-github-app/generating-a-json-web-token-jwt-for-a-github-app
+func (provider *GitHubAppProvider) signAppToken(now time.Time) (string, error) {
+	key, err := parseRSAPrivateKey(provider.PrivateKey)
+	if err != nil {
+		return "", err
+	}
+
+	header, err := json.Marshal(map[string]string{
+		"alg": "RS256",
+		"typ": "JWT",
+	})
+	if err != nil {
+		return "", err
+	}
+	claims, err := json.Marshal(map[string]interface{}{
+		// Issued in the past to allow for clock drift.
+		"iat": now.Add(-60 * time.Second).Unix(),
+		"exp": now.Add(9 * time.Minute).Unix(),
+		"iss": fmt.Sprintf("%d", provider.AppID),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." +
+		base64.RawURLEncoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", err
+	}
+
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+func parseRSAPrivateKey(privateKey []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(privateKey)
+	if block == nil {
+		return nil, fmt.Errorf("%w: no PEM block found", ErrInvalidPrivateKey)
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidPrivateKey, err)
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("%w: not an RSA key", ErrInvalidPrivateKey)
+	}
+	return rsaKey, nil
+}
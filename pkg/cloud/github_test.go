@@ -0,0 +1,91 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloud_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kharf/declcd/pkg/cloud"
+	"gotest.tools/v3/assert"
+)
+
+func TestGitHubAppProvider_FetchCredentials(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NilError(t, err)
+	privateKey := pem.EncodeToMemory(&pem.Block{
+		Type:  "RSA PRIVATE KEY",
+		Bytes: x509.MarshalPKCS1PrivateKey(key),
+	})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, r.Method, http.MethodPost)
+		assert.Equal(t, r.URL.Path, "/app/installations/42/access_tokens")
+
+		appToken, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		assert.Assert(t, found)
+		parts := strings.Split(appToken, ".")
+		assert.Assert(t, len(parts) == 3)
+
+		signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+		assert.NilError(t, err)
+		digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+		err = rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, digest[:], signature)
+		assert.NilError(t, err)
+
+		rawClaims, err := base64.RawURLEncoding.DecodeString(parts[1])
+		assert.NilError(t, err)
+		var claims map[string]interface{}
+		assert.NilError(t, json.Unmarshal(rawClaims, &claims))
+		assert.Equal(t, claims["iss"], "7")
+
+		w.WriteHeader(http.StatusCreated)
+		_, err = w.Write([]byte(`{"token":"ghs_installation","expires_at":"2024-01-01T00:00:00Z"}`))
+		assert.NilError(t, err)
+	}))
+	defer server.Close()
+
+	provider := &cloud.GitHubAppProvider{
+		HttpClient:     server.Client(),
+		APIURL:         server.URL,
+		AppID:          7,
+		InstallationID: 42,
+		PrivateKey:     privateKey,
+	}
+	creds, err := provider.FetchCredentials(context.Background())
+	assert.NilError(t, err)
+	assert.Equal(t, creds.Username, "x-access-token")
+	assert.Equal(t, creds.Password, "ghs_installation")
+}
+
+func TestGitHubAppProvider_FetchCredentials_InvalidKey(t *testing.T) {
+	provider := &cloud.GitHubAppProvider{
+		HttpClient: http.DefaultClient,
+		PrivateKey: []byte("invalid"),
+	}
+	_, err := provider.FetchCredentials(context.Background())
+	assert.ErrorIs(t, err, cloud.ErrInvalidPrivateKey)
+}
@@ -0,0 +1,128 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package component
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/kharf/declcd/pkg/helm"
+)
+
+var (
+	ErrUnsupportedArtifactVersion  = errors.New("Unsupported artifact version")
+	ErrUnsupportedArtifactInstance = errors.New("Component type can not be stored in an artifact")
+	ErrDecryptedArtifactInstance   = errors.New("Decrypted component can not be stored in an artifact")
+)
+
+const (
+	// ArtifactVersion is the version of the serialized BuildResult format.
+	ArtifactVersion = "v1"
+)
+
+// BuildResult holds all compiled components of a project.
+// It can be serialized to an artifact, e.g. by CI, and consumed by the controller instead of compiling CUE in-cluster.
+// SOPS encrypted components have to be kept encrypted, see [Builder.KeepEncrypted].
+type BuildResult struct {
+	Instances []Instance
+}
+
+type artifact struct {
+	Version   string              `json:"version"`
+	Instances []artifactComponent `json:"instances"`
+}
+
+type artifactComponent struct {
+	Type      string          `json:"type"`
+	Component json.RawMessage `json:"component"`
+}
+
+var _ json.Marshaler = (*BuildResult)(nil)
+var _ json.Unmarshaler = (*BuildResult)(nil)
+
+func (result BuildResult) MarshalJSON() ([]byte, error) {
+	components := make([]artifactComponent, 0, len(result.Instances))
+	for _, instance := range result.Instances {
+		var componentType string
+		switch instance := instance.(type) {
+		case *Manifest:
+			if instance.Decrypted {
+				return nil, fmt.Errorf("%w: %s", ErrDecryptedArtifactInstance, instance.GetID())
+			}
+			componentType = "Manifest"
+		case *Hook:
+			if instance.Decrypted {
+				return nil, fmt.Errorf("%w: %s", ErrDecryptedArtifactInstance, instance.GetID())
+			}
+			componentType = "Hook"
+		case *HTTPHook:
+			componentType = "HTTPHook"
+		case *helm.ReleaseComponent:
+			componentType = "HelmRelease"
+		case *Notification:
+			componentType = "Notification"
+		default:
+			return nil, fmt.Errorf("%w: %s", ErrUnsupportedArtifactInstance, instance.GetID())
+		}
+		content, err := json.Marshal(instance)
+		if err != nil {
+			return nil, err
+		}
+		components = append(components, artifactComponent{
+			Type:      componentType,
+			Component: content,
+		})
+	}
+	return json.Marshal(artifact{
+		Version:   ArtifactVersion,
+		Instances: components,
+	})
+}
+
+func (result *BuildResult) UnmarshalJSON(data []byte) error {
+	var art artifact
+	if err := json.Unmarshal(data, &art); err != nil {
+		return err
+	}
+	if art.Version != ArtifactVersion {
+		return fmt.Errorf("%w: %s", ErrUnsupportedArtifactVersion, art.Version)
+	}
+
+	instances := make([]Instance, 0, len(art.Instances))
+	for _, component := range art.Instances {
+		var instance Instance
+		switch component.Type {
+		case "Manifest":
+			instance = &Manifest{}
+		case "Hook":
+			instance = &Hook{}
+		case "HTTPHook":
+			instance = &HTTPHook{}
+		case "HelmRelease":
+			instance = &helm.ReleaseComponent{}
+		case "Notification":
+			instance = &Notification{}
+		default:
+			return fmt.Errorf("%w: %s", ErrUnsupportedArtifactInstance, component.Type)
+		}
+		if err := json.Unmarshal(component.Component, instance); err != nil {
+			return err
+		}
+		instances = append(instances, instance)
+	}
+	result.Instances = instances
+	return nil
+}
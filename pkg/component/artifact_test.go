@@ -0,0 +1,103 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package component_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/kharf/declcd/pkg/component"
+	"github.com/kharf/declcd/pkg/helm"
+	"gotest.tools/v3/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestBuildResult_JSON(t *testing.T) {
+	result := component.BuildResult{
+		Instances: []component.Instance{
+			&component.Manifest{
+				ID:           "prometheus___Namespace",
+				Dependencies: []string{},
+				Content: unstructured.Unstructured{
+					Object: map[string]interface{}{
+						"apiVersion": "v1",
+						"kind":       "Namespace",
+						"metadata": map[string]interface{}{
+							"name": "prometheus",
+						},
+					},
+				},
+			},
+			&component.Hook{
+				ID:            "backup_prometheus_batch_Job",
+				Dependencies:  []string{"prometheus___Namespace"},
+				Phase:         component.PreApply,
+				FailurePolicy: component.Ignore,
+				Timeout:       10 * time.Minute,
+				Content: unstructured.Unstructured{
+					Object: map[string]interface{}{
+						"apiVersion": "batch/v1",
+						"kind":       "Job",
+						"metadata": map[string]interface{}{
+							"name":      "backup",
+							"namespace": "prometheus",
+						},
+					},
+				},
+			},
+			&component.HTTPHook{
+				ID:            "flush_HTTPHook",
+				Dependencies:  []string{"backup_prometheus_batch_Job"},
+				Phase:         component.PostApply,
+				FailurePolicy: component.Abort,
+				Timeout:       time.Minute,
+				Request: component.HTTPRequest{
+					URL:    "https://cache.example.com/flush",
+					Method: "POST",
+				},
+			},
+			&helm.ReleaseComponent{
+				ID:           "test_prometheus_HelmRelease",
+				Dependencies: []string{"prometheus___Namespace"},
+				Content: helm.ReleaseDeclaration{
+					Name:      "test",
+					Namespace: "prometheus",
+					Chart: helm.Chart{
+						Name:    "test",
+						RepoURL: "oci://declcd.io",
+						Version: "1.0.0",
+					},
+					Values: helm.Values{
+						"autoscaling": map[string]interface{}{
+							"enabled": true,
+						},
+					},
+				},
+			},
+		},
+	}
+
+	data, err := json.Marshal(result)
+	assert.NilError(t, err)
+
+	var decoded component.BuildResult
+	err = json.Unmarshal(data, &decoded)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, decoded, result)
+
+	err = json.Unmarshal([]byte(`{"version":"v0","instances":[]}`), &decoded)
+	assert.ErrorIs(t, err, component.ErrUnsupportedArtifactVersion)
+}
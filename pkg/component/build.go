@@ -17,18 +17,59 @@ package component
 import (
 	"errors"
 	"fmt"
+	"slices"
+	"time"
 
+	"cuelang.org/go/cue"
 	internalCue "github.com/kharf/declcd/internal/cue"
 	"github.com/kharf/declcd/pkg/helm"
+	"github.com/kharf/declcd/pkg/notification"
+	"github.com/kharf/declcd/pkg/promotion"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 )
 
 var (
-	ErrMissingField = errors.New("Missing content field")
+	ErrMissingField            = errors.New("Missing content field")
+	ErrUnknownPrunePolicy      = errors.New("Unknown prune policy")
+	ErrUnsupportedGenerateName = errors.New("Unsupported generateName")
+	ErrUnknownMigration        = errors.New("Unknown migration")
+	ErrMissingTarget           = errors.New("Missing notification target")
+)
+
+const (
+	// PruneAnnotation is set on manifests declaring a prune policy other than the default deletion.
+	// It is persisted with the manifest, so that the policy is still known after the component has been removed.
+	PruneAnnotation = "declcd/prune"
+
+	// PruneOrphan drops a removed manifest from the inventory and releases its field ownership
+	// without deleting the live object. Removed Helm releases stay installed.
+	// It is declared with the @prune(orphan) attribute.
+	PruneOrphan = "orphan"
+
+	// PruneDelete is the default policy, which deletes removed manifests and uninstalls removed releases.
+	PruneDelete = "delete"
+
+	// FieldManagerAnnotation is set on manifests declaring a field manager other than the one of the controller.
+	// It is persisted with the manifest, so that the ownership of orphaned manifests is released for the right manager.
+	FieldManagerAnnotation = "declcd/field-manager"
+
+	// MigrateStorage rewrites all custom resources of a CustomResourceDefinition after it has been applied,
+	// when objects are still stored in versions other than its storage version.
+	// It is declared with the @migrate(storage) attribute.
+	MigrateStorage = "storage"
 )
 
 // Builder compiles and decodes CUE kubernetes manifest definitions of a component to the corresponding Go struct.
 type Builder struct {
+	// Registry optionally holds handlers decoding custom component types.
+	Registry *Registry
+
+	// Decrypter optionally decrypts SOPS encrypted manifests and hooks.
+	Decrypter *SOPSDecrypter
+
+	// KeepEncrypted leaves SOPS encrypted manifests and hooks encrypted,
+	// e.g. for artifacts, which the controller decrypts when it loads them.
+	KeepEncrypted bool
 }
 
 // NewBuilder contructs a [Builder].
@@ -38,8 +79,9 @@ func NewBuilder() Builder {
 
 // BuildOptions defining which package is compiled and how it is done.
 type BuildOptions struct {
-	packagePath string
-	projectRoot string
+	packagePath       string
+	projectRoot       string
+	dependencySources *DependencySources
 }
 
 type buildOptions = func(opts *BuildOptions)
@@ -58,6 +100,13 @@ func WithProjectRoot(projectRootPath string) buildOptions {
 	}
 }
 
+// WithDependencySources records the positions of all declared dependencies in the given sources.
+func WithDependencySources(sources *DependencySources) buildOptions {
+	return func(opts *BuildOptions) {
+		opts.dependencySources = sources
+	}
+}
+
 const (
 	ProjectRootPath = "."
 )
@@ -72,6 +121,9 @@ func (b Builder) Build(opts ...buildOptions) ([]Instance, error) {
 	for _, opt := range opts {
 		opt(options)
 	}
+	if err := checkLanguageVersion(options.projectRoot); err != nil {
+		return nil, err
+	}
 	value, err := internalCue.BuildPackage(
 		options.packagePath,
 		options.projectRoot,
@@ -79,46 +131,426 @@ func (b Builder) Build(opts ...buildOptions) ([]Instance, error) {
 	if err != nil {
 		return nil, err
 	}
-	iter, err := value.Fields()
+	fieldValues, err := componentFields(*value)
 	if err != nil {
 		return nil, err
 	}
 	instances := make([]Instance, 0)
-	for iter.Next() {
-		componentValue := iter.Value()
-		var instance internalInstance
-		if err = componentValue.Decode(&instance); err != nil {
+	ids := make(map[string]struct{})
+	for _, fieldValue := range fieldValues {
+		prunePolicy, err := readPrunePolicy(fieldValue)
+		if err != nil {
+			return nil, err
+		}
+		versionRetention, err := readVersioning(fieldValue)
+		if err != nil {
 			return nil, err
 		}
-		switch instance.Type {
-		case "Manifest":
-			if err := validateManifest(instance); err != nil {
+		migration, err := readMigration(fieldValue)
+		if err != nil {
+			return nil, err
+		}
+		componentValues := []cue.Value{fieldValue}
+		if isMatrix(fieldValue) {
+			componentValues, err = expandMatrix(fieldValue)
+			if err != nil {
 				return nil, err
 			}
-			instances = append(instances, &Manifest{
-				ID:           instance.ID,
-				Dependencies: instance.Dependencies,
-				Content: unstructured.Unstructured{
-					Object: instance.Content,
-				},
-			})
-		case "HelmRelease":
-			instances = append(instances, &helm.ReleaseComponent{
-				ID:           instance.ID,
-				Dependencies: instance.Dependencies,
-				Content: helm.ReleaseDeclaration{
-					Name:      instance.Name,
-					Namespace: instance.Namespace,
-					Chart:     instance.Chart,
-					Values:    instance.Values,
-				},
-			})
 		}
+		for _, componentValue := range componentValues {
+			instance, err := b.decodeInstance(componentValue)
+			if err != nil {
+				return nil, err
+			}
+			if instance == nil {
+				continue
+			}
+			if release, ok := instance.(*helm.ReleaseComponent); ok {
+				if err := loadValuesFiles(options.projectRoot, componentValue, release); err != nil {
+					return nil, err
+				}
+			}
+			decoded := []Instance{instance}
+			switch expandable := instance.(type) {
+			case *Kustomization:
+				decoded, err = expandKustomization(options.projectRoot, expandable)
+				if err != nil {
+					return nil, err
+				}
+			case *ManifestSequence:
+				decoded = expandManifestSequence(expandable)
+			}
+			for _, instance := range decoded {
+				if err := b.Decrypt(instance); err != nil {
+					return nil, err
+				}
+				if prunePolicy == PruneOrphan {
+					switch instance := instance.(type) {
+					case *Manifest:
+						annotations := instance.Content.GetAnnotations()
+						if annotations == nil {
+							annotations = make(map[string]string, 1)
+						}
+						annotations[PruneAnnotation] = PruneOrphan
+						instance.Content.SetAnnotations(annotations)
+					case *helm.ReleaseComponent:
+						instance.Orphan = true
+					}
+				}
+				if manifest, ok := instance.(*Manifest); ok && manifest.FieldManager != "" {
+					annotations := manifest.Content.GetAnnotations()
+					if annotations == nil {
+						annotations = make(map[string]string, 1)
+					}
+					annotations[FieldManagerAnnotation] = manifest.FieldManager
+					manifest.Content.SetAnnotations(annotations)
+				}
+				if versionRetention != "" {
+					if err := markVersioned(instance, versionRetention); err != nil {
+						return nil, err
+					}
+				}
+				if migration != "" {
+					manifest, ok := instance.(*Manifest)
+					if !ok || manifest.Content.GetKind() != "CustomResourceDefinition" {
+						return nil, fmt.Errorf(
+							"%w: %s: only supported for CustomResourceDefinitions",
+							ErrUnknownMigration,
+							instance.GetID(),
+						)
+					}
+					manifest.Migration = migration
+				}
+				if _, found := ids[instance.GetID()]; found {
+					return nil, fmt.Errorf("%w: %s", ErrDuplicateComponentID, instance.GetID())
+				}
+				if options.dependencySources != nil {
+					if err := recordDependencies(options.dependencySources, instance.GetID(), componentValue); err != nil {
+						return nil, err
+					}
+				}
+				ids[instance.GetID()] = struct{}{}
+				instances = append(instances, instance)
+			}
+		}
+	}
+	if err := versionManifests(instances); err != nil {
+		return nil, err
 	}
 	return instances, nil
 }
 
-func validateManifest(instance internalInstance) error {
+// Decrypt decrypts the content of SOPS encrypted manifests and hooks in place and marks them as decrypted.
+// Content is left encrypted, when the builder keeps it encrypted.
+func (b Builder) Decrypt(instance Instance) error {
+	var content map[string]interface{}
+	var decrypted *bool
+	switch instance := instance.(type) {
+	case *Manifest:
+		content = instance.Content.Object
+		decrypted = &instance.Decrypted
+	case *Hook:
+		content = instance.Content.Object
+		decrypted = &instance.Decrypted
+	default:
+		return nil
+	}
+	if _, encrypted := content[SOPSMetadataField]; !encrypted || b.KeepEncrypted {
+		return nil
+	}
+	if b.Decrypter == nil {
+		return fmt.Errorf("%w: %s", ErrSOPSDecrypterMissing, instance.GetID())
+	}
+	if err := b.Decrypter.Decrypt(content); err != nil {
+		return fmt.Errorf("%s: %w", instance.GetID(), err)
+	}
+	*decrypted = true
+	return nil
+}
+
+func (b Builder) decodeInstance(componentValue cue.Value) (Instance, error) {
+	componentType, err := componentValue.LookupPath(cue.ParsePath("type")).String()
+	if err == nil {
+		if _, reserved := reservedComponentType[componentType]; !reserved {
+			return b.Registry.decode(componentType, componentValue)
+		}
+	}
+
+	var instance internalInstance
+	if err := componentValue.Decode(&instance); err != nil {
+		return nil, err
+	}
+	promotionPolicy, err := promotion.Parse(instance.PromoteAfter, instance.Promotion)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", instance.ID, err)
+	}
+	wait, err := decodeWait(instance.Wait)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", instance.ID, err)
+	}
+	switch instance.Type {
+	case "Manifest":
+		if err := validateManifest(instance, true); err != nil {
+			return nil, err
+		}
+		return &Manifest{
+			ID:           instance.ID,
+			Dependencies: instance.Dependencies,
+			Content: unstructured.Unstructured{
+				Object: instance.Content,
+			},
+			Wait:               wait,
+			Promotion:          promotionPolicy,
+			FieldManager:       instance.FieldManager,
+			ServiceAccountName: instance.ServiceAccountName,
+		}, nil
+	case "Hook":
+		if err := validateManifest(instance, false); err != nil {
+			return nil, err
+		}
+		timeout, err := time.ParseDuration(instance.Timeout)
+		if err != nil {
+			return nil, err
+		}
+		return &Hook{
+			ID:            instance.ID,
+			Dependencies:  instance.Dependencies,
+			Phase:         HookPhase(instance.Phase),
+			FailurePolicy: FailurePolicy(instance.FailurePolicy),
+			Timeout:       timeout,
+			Content: unstructured.Unstructured{
+				Object: instance.Content,
+			},
+			FieldManager:       instance.FieldManager,
+			ServiceAccountName: instance.ServiceAccountName,
+		}, nil
+	case "HTTPHook":
+		if instance.Request == nil {
+			return nil, missingFieldError("request")
+		}
+		timeout, err := time.ParseDuration(instance.Timeout)
+		if err != nil {
+			return nil, err
+		}
+		return &HTTPHook{
+			ID:            instance.ID,
+			Dependencies:  instance.Dependencies,
+			Phase:         HookPhase(instance.Phase),
+			FailurePolicy: FailurePolicy(instance.FailurePolicy),
+			Timeout:       timeout,
+			Request:       *instance.Request,
+		}, nil
+	case "Kustomization":
+		return &Kustomization{
+			ID:                 instance.ID,
+			Dependencies:       instance.Dependencies,
+			Path:               instance.Path,
+			Wait:               wait,
+			Promotion:          promotionPolicy,
+			FieldManager:       instance.FieldManager,
+			ServiceAccountName: instance.ServiceAccountName,
+		}, nil
+	case "ManifestSequence":
+		for _, content := range instance.Manifests {
+			if err := validateManifest(internalInstance{Content: content}, false); err != nil {
+				return nil, fmt.Errorf("%s: %w", instance.ID, err)
+			}
+		}
+		return &ManifestSequence{
+			ID:                 instance.ID,
+			Dependencies:       instance.Dependencies,
+			Wait:               wait,
+			Promotion:          promotionPolicy,
+			FieldManager:       instance.FieldManager,
+			ServiceAccountName: instance.ServiceAccountName,
+			contents:           instance.Manifests,
+		}, nil
+	case "HelmRelease":
+		return &helm.ReleaseComponent{
+			ID:           instance.ID,
+			Dependencies: instance.Dependencies,
+			Content: helm.ReleaseDeclaration{
+				Name:               instance.Name,
+				Namespace:          instance.Namespace,
+				Chart:              instance.Chart,
+				Values:             instance.Values,
+				Capabilities:       instance.Capabilities,
+				Wait:               wait,
+				NamespaceMetadata:  instance.NamespaceMetadata,
+				ValuesFrom:         instance.ValuesFrom,
+				Patches:            instance.Patches,
+				HookResources:      instance.HookResources,
+				FieldManager:       instance.FieldManager,
+				ServiceAccountName: instance.ServiceAccountName,
+			},
+			Promotion: promotionPolicy,
+		}, nil
+	case "Notification":
+		if instance.Slack == nil && instance.Webhook == nil && instance.Email == nil {
+			return nil, fmt.Errorf("%w: %s", ErrMissingTarget, instance.ID)
+		}
+		return &Notification{
+			ID:           instance.ID,
+			Dependencies: instance.Dependencies,
+			Filter: notification.Filter{
+				Severity: notification.Severity(instance.Severity),
+				Projects: instance.Projects,
+				Shards:   instance.Shards,
+			},
+			Slack:   instance.Slack,
+			Webhook: instance.Webhook,
+			Email:   instance.Email,
+		}, nil
+	}
+	return nil, nil
+}
+
+// decodeWait returns nil, when waiting is not declared or disabled.
+func decodeWait(wait *internalWait) (*helm.Wait, error) {
+	if wait == nil || !wait.Enabled {
+		return nil, nil
+	}
+	timeout, err := time.ParseDuration(wait.Timeout)
+	if err != nil {
+		return nil, err
+	}
+	return &helm.Wait{
+		Timeout: timeout,
+		Rules:   wait.Rules,
+	}, nil
+}
+
+// recordDependencies stores the position of every entry of the dependencies list of a component.
+func recordDependencies(sources *DependencySources, componentID string, componentValue cue.Value) error {
+	dependencies := componentValue.LookupPath(cue.ParsePath("dependencies"))
+	if !dependencies.Exists() {
+		return nil
+	}
+	iter, err := dependencies.List()
+	if err != nil {
+		return err
+	}
+	for iter.Next() {
+		dependency, err := iter.Value().String()
+		if err != nil {
+			return err
+		}
+		sources.record(componentID, dependency, iter.Value().Pos().String())
+	}
+	return nil
+}
+
+// readPrunePolicy returns the policy of the @prune attribute of a component field.
+func readPrunePolicy(componentValue cue.Value) (string, error) {
+	attr := componentValue.Attribute("prune")
+	if attr.Err() != nil {
+		return PruneDelete, nil
+	}
+	policy, err := attr.String(0)
+	if err != nil {
+		return "", err
+	}
+	switch policy {
+	case PruneOrphan, PruneDelete:
+		return policy, nil
+	}
+	return "", fmt.Errorf("%w: %s", ErrUnknownPrunePolicy, policy)
+}
+
+// readMigration returns the migration of the @migrate attribute of a component field.
+// It is empty for components without the attribute.
+func readMigration(componentValue cue.Value) (string, error) {
+	attr := componentValue.Attribute("migrate")
+	if attr.Err() != nil {
+		return "", nil
+	}
+	migration, err := attr.String(0)
+	if err != nil {
+		return "", err
+	}
+	if migration != MigrateStorage {
+		return "", fmt.Errorf("%w: %s", ErrUnknownMigration, migration)
+	}
+	return migration, nil
+}
+
+// componentFields returns the fields of a package declaring components.
+// The components of a Bundle replace the Bundle itself, as if they were declared in the package.
+func componentFields(value cue.Value) ([]cue.Value, error) {
+	iter, err := value.Fields()
+	if err != nil {
+		return nil, err
+	}
+	fields := make([]cue.Value, 0)
+	for iter.Next() {
+		if !isBundle(iter.Value()) {
+			fields = append(fields, iter.Value())
+			continue
+		}
+		bundleFields, err := componentFields(iter.Value().LookupPath(cue.ParsePath("components")))
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, bundleFields...)
+	}
+	return fields, nil
+}
+
+func isBundle(componentValue cue.Value) bool {
+	componentType, err := componentValue.LookupPath(cue.ParsePath("type")).String()
+	return err == nil && componentType == "Bundle"
+}
+
+func isMatrix(componentValue cue.Value) bool {
+	componentType, err := componentValue.LookupPath(cue.ParsePath("type")).String()
+	return err == nil && componentType == "Matrix"
+}
+
+// expandMatrix unifies the template of a Matrix with the parameters of every combination of its dimensions.
+// Combinations are ordered by dimension name and the order of the dimension values.
+func expandMatrix(matrixValue cue.Value) ([]cue.Value, error) {
+	var dimensions map[string][]string
+	if err := matrixValue.LookupPath(cue.ParsePath("dimensions")).Decode(&dimensions); err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(dimensions))
+	for name := range dimensions {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	combinations := []map[string]string{{}}
+	for _, name := range names {
+		next := make([]map[string]string, 0, len(combinations)*len(dimensions[name]))
+		for _, combination := range combinations {
+			for _, dimensionValue := range dimensions[name] {
+				parameters := make(map[string]string, len(combination)+1)
+				for key, value := range combination {
+					parameters[key] = value
+				}
+				parameters[name] = dimensionValue
+				next = append(next, parameters)
+			}
+		}
+		combinations = next
+	}
+
+	template := matrixValue.LookupPath(cue.ParsePath("template"))
+	values := make([]cue.Value, 0, len(combinations))
+	for _, parameters := range combinations {
+		value := template.FillPath(cue.ParsePath("parameters"), parameters)
+		if err := value.Err(); err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+// validateManifest checks the fields required to identify an object.
+// Objects named by the API server through metadata.generateName have to be namespaced,
+// because the inventory locates cluster-scoped objects by their name.
+func validateManifest(instance internalInstance, allowGenerateName bool) error {
 	_, found := instance.Content["apiVersion"]
 	if !found {
 		return missingFieldError("apiVersion")
@@ -135,14 +567,23 @@ func validateManifest(instance internalInstance) error {
 			"metadata",
 		)
 	}
-	_, found = metadata["name"]
-	if !found {
-		return missingFieldError("metadata.name")
+	_, named := metadata["name"]
+	if !named {
+		generateName, _ := metadata["generateName"].(string)
+		if generateName == "" {
+			return missingFieldError("metadata.name")
+		}
+		if !allowGenerateName {
+			return fmt.Errorf("%w: only supported for manifests", ErrUnsupportedGenerateName)
+		}
 	}
-	_, found = metadata["namespace"]
+	namespace, found := metadata["namespace"]
 	if !found {
 		return missingFieldError("metadata.namespace")
 	}
+	if !named && namespace == "" {
+		return fmt.Errorf("%w: only supported for namespaced objects", ErrUnsupportedGenerateName)
+	}
 	return nil
 }
 
@@ -20,10 +20,14 @@ import (
 	"os"
 	"path"
 	"testing"
+	"time"
 
 	"github.com/kharf/declcd/internal/dnstest"
 	"github.com/kharf/declcd/internal/ocitest"
+	"github.com/kharf/declcd/pkg/health"
 	"github.com/kharf/declcd/pkg/helm"
+	"github.com/kharf/declcd/pkg/notification"
+	"github.com/kharf/declcd/pkg/promotion"
 	_ "github.com/kharf/declcd/test/workingdir"
 	"gotest.tools/v3/assert"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
@@ -52,6 +56,7 @@ func TestBuilder_Build(t *testing.T) {
 	builder := NewBuilder()
 	cwd, err := os.Getwd()
 	assert.NilError(t, err)
+	promoteAfter := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
 	testCases := []struct {
 		name              string
 		projectRoot       string
@@ -160,6 +165,48 @@ func TestBuilder_Build(t *testing.T) {
 					},
 					Dependencies: []string{"prometheus___Namespace"},
 				},
+				&helm.ReleaseComponent{
+					ID: "test-capabilities_prometheus_HelmRelease",
+					Content: helm.ReleaseDeclaration{
+						Name:      "test-capabilities",
+						Namespace: "prometheus",
+						Chart: helm.Chart{
+							Name:    "test",
+							RepoURL: "oci://test",
+							Version: "test",
+						},
+						Values: helm.Values{
+							"autoscaling": map[string]interface{}{
+								"enabled": true,
+							},
+						},
+						Capabilities: &helm.Capabilities{
+							KubeVersion: "1.29.0",
+							APIVersions: []string{"monitoring.coreos.com/v1"},
+						},
+					},
+					Dependencies: []string{"prometheus___Namespace"},
+				},
+				&helm.ReleaseComponent{
+					ID: "test-wait_prometheus_HelmRelease",
+					Content: helm.ReleaseDeclaration{
+						Name:      "test-wait",
+						Namespace: "prometheus",
+						Chart: helm.Chart{
+							Name:    "test",
+							RepoURL: "oci://test",
+							Version: "test",
+						},
+						Values: helm.Values{},
+						Wait: &helm.Wait{
+							Timeout: 10 * time.Minute,
+							Rules: health.Rules{
+								"Prometheus": "Available",
+							},
+						},
+					},
+					Dependencies: []string{"prometheus___Namespace"},
+				},
 			},
 			expectedErr: "",
 		},
@@ -233,33 +280,1029 @@ func TestBuilder_Build(t *testing.T) {
 			expectedInstances: []Instance{},
 			expectedErr:       "release.chart.auth: 2 errors in empty disjunction: (and 2 more errors)",
 		},
-	}
-	for _, tc := range testCases {
-		t.Run(tc.name, func(t *testing.T) {
-			components, err := builder.Build(
-				WithProjectRoot(tc.projectRoot),
-				WithPackagePath(tc.packagePath),
-			)
-			if tc.expectedErr != "" {
-				assert.ErrorContains(t, err, tc.expectedErr)
-			} else {
-				assert.NilError(t, err)
-				assert.Assert(t, len(components) == len(tc.expectedInstances))
-				for i, expected := range tc.expectedInstances {
-					current := components[i]
-					switch expected := expected.(type) {
-					case *Manifest:
-						current, ok := current.(*Manifest)
-						assert.Assert(t, ok)
-						assert.Equal(t, current.ID, expected.ID)
-						assert.DeepEqual(t, current.Dependencies, expected.Dependencies)
-						assert.DeepEqual(t, current.Content, expected.Content)
-					case *helm.ReleaseComponent:
-						current, ok := current.(*helm.ReleaseComponent)
-						assert.Assert(t, ok)
-						assert.Equal(t, current.ID, expected.ID)
-						assert.DeepEqual(t, current.Content.Values, expected.Content.Values)
-						assert.DeepEqual(t, current.Dependencies, expected.Dependencies)
+		{
+			name:        "Hook",
+			projectRoot: path.Join(cwd, "test", "testdata", "build"),
+			packagePath: "./infra/hook",
+			expectedInstances: []Instance{
+				&Hook{
+					ID:            "backup_prometheus_batch_Job",
+					Phase:         PreApply,
+					FailurePolicy: Ignore,
+					Timeout:       10 * time.Minute,
+					Content: unstructured.Unstructured{
+						Object: map[string]interface{}{
+							"apiVersion": "batch/v1",
+							"kind":       "Job",
+							"metadata": map[string]interface{}{
+								"name":      "backup",
+								"namespace": "prometheus",
+							},
+							"spec": map[string]interface{}{
+								"template": map[string]interface{}{
+									"spec": map[string]interface{}{
+										"restartPolicy": "Never",
+										"containers": []interface{}{
+											map[string]interface{}{
+												"name":  "backup",
+												"image": "busybox",
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+					Dependencies: []string{},
+				},
+			},
+			expectedErr: "",
+		},
+		{
+			name:        "HTTPHook",
+			projectRoot: path.Join(cwd, "test", "testdata", "build"),
+			packagePath: "./infra/httphook",
+			expectedInstances: []Instance{
+				&HTTPHook{
+					ID:            "flush_HTTPHook",
+					Phase:         PostApply,
+					FailurePolicy: Abort,
+					Timeout:       5 * time.Minute,
+					Request: HTTPRequest{
+						URL:     "https://cache.example.com/flush",
+						Method:  "POST",
+						Headers: map[string]string{"Content-Type": "application/json"},
+						Body:    `{"cache":"all"}`,
+					},
+					Dependencies: []string{},
+				},
+			},
+			expectedErr: "",
+		},
+		{
+			name:        "Notification",
+			projectRoot: path.Join(cwd, "test", "testdata", "build"),
+			packagePath: "./infra/notification",
+			expectedInstances: []Instance{
+				&Notification{
+					ID:           "platform_Notification",
+					Dependencies: []string{},
+					Filter: notification.Filter{
+						Severity: notification.SeverityError,
+						Projects: []string{"*"},
+					},
+					Slack: &notification.Slack{
+						SecretRef: notification.SecretReference{
+							Name:      "slack",
+							Namespace: "declcd-system",
+						},
+					},
+					Email: &notification.Email{
+						Host: "smtp.example.com",
+						Port: 587,
+						From: "declcd@example.com",
+						To:   []string{"platform@example.com"},
+					},
+				},
+			},
+			expectedErr: "",
+		},
+		{
+			name:        "Matrix",
+			projectRoot: path.Join(cwd, "test", "testdata", "build"),
+			packagePath: "./infra/matrix",
+			expectedInstances: []Instance{
+				&Manifest{
+					ID: "config-eu-dev_prometheus__ConfigMap",
+					Content: unstructured.Unstructured{
+						Object: map[string]interface{}{
+							"apiVersion": "v1",
+							"kind":       "ConfigMap",
+							"metadata": map[string]interface{}{
+								"name":      "config-eu-dev",
+								"namespace": "prometheus",
+							},
+							"data": map[string]interface{}{
+								"cluster": "eu",
+								"env":     "dev",
+							},
+						},
+					},
+					Dependencies: []string{},
+				},
+				&Manifest{
+					ID: "config-eu-prod_prometheus__ConfigMap",
+					Content: unstructured.Unstructured{
+						Object: map[string]interface{}{
+							"apiVersion": "v1",
+							"kind":       "ConfigMap",
+							"metadata": map[string]interface{}{
+								"name":      "config-eu-prod",
+								"namespace": "prometheus",
+							},
+							"data": map[string]interface{}{
+								"cluster": "eu",
+								"env":     "prod",
+							},
+						},
+					},
+					Dependencies: []string{},
+				},
+				&Manifest{
+					ID: "config-us-dev_prometheus__ConfigMap",
+					Content: unstructured.Unstructured{
+						Object: map[string]interface{}{
+							"apiVersion": "v1",
+							"kind":       "ConfigMap",
+							"metadata": map[string]interface{}{
+								"name":      "config-us-dev",
+								"namespace": "prometheus",
+							},
+							"data": map[string]interface{}{
+								"cluster": "us",
+								"env":     "dev",
+							},
+						},
+					},
+					Dependencies: []string{},
+				},
+				&Manifest{
+					ID: "config-us-prod_prometheus__ConfigMap",
+					Content: unstructured.Unstructured{
+						Object: map[string]interface{}{
+							"apiVersion": "v1",
+							"kind":       "ConfigMap",
+							"metadata": map[string]interface{}{
+								"name":      "config-us-prod",
+								"namespace": "prometheus",
+							},
+							"data": map[string]interface{}{
+								"cluster": "us",
+								"env":     "prod",
+							},
+						},
+					},
+					Dependencies: []string{},
+				},
+			},
+			expectedErr: "",
+		},
+		{
+			name:        "Bundle",
+			projectRoot: path.Join(cwd, "test", "testdata", "build"),
+			packagePath: "./infra/bundle",
+			expectedInstances: []Instance{
+				&Manifest{
+					ID: "monitoring___Namespace",
+					Content: unstructured.Unstructured{
+						Object: map[string]interface{}{
+							"apiVersion": "v1",
+							"kind":       "Namespace",
+							"metadata": map[string]interface{}{
+								"name":      "monitoring",
+								"namespace": "",
+							},
+						},
+					},
+					Dependencies: []string{},
+				},
+				&Manifest{
+					ID: "scrape-config_monitoring__ConfigMap",
+					Content: unstructured.Unstructured{
+						Object: map[string]interface{}{
+							"apiVersion": "v1",
+							"kind":       "ConfigMap",
+							"metadata": map[string]interface{}{
+								"name":      "scrape-config",
+								"namespace": "monitoring",
+							},
+							"data": map[string]interface{}{
+								"interval": "30s",
+							},
+						},
+					},
+					Dependencies: []string{"monitoring___Namespace"},
+				},
+			},
+			expectedErr: "",
+		},
+		{
+			name:        "RBACPolicy",
+			projectRoot: path.Join(cwd, "test", "testdata", "build"),
+			packagePath: "./infra/rbac",
+			expectedInstances: []Instance{
+				&Manifest{
+					ID: "declcd-tenant__rbac.authorization.k8s.io_ClusterRole",
+					Content: unstructured.Unstructured{
+						Object: map[string]interface{}{
+							"apiVersion": "rbac.authorization.k8s.io/v1",
+							"kind":       "ClusterRole",
+							"metadata": map[string]interface{}{
+								"name":      "declcd-tenant",
+								"namespace": "",
+							},
+							"rules": []interface{}{
+								map[string]interface{}{
+									"apiGroups": []interface{}{""},
+									"resources": []interface{}{"namespaces"},
+									"verbs":     []interface{}{"create", "get"},
+								},
+							},
+						},
+					},
+					Dependencies: []string{},
+				},
+				&Manifest{
+					ID: "declcd-tenant_monitoring_rbac.authorization.k8s.io_Role",
+					Content: unstructured.Unstructured{
+						Object: map[string]interface{}{
+							"apiVersion": "rbac.authorization.k8s.io/v1",
+							"kind":       "Role",
+							"metadata": map[string]interface{}{
+								"name":      "declcd-tenant",
+								"namespace": "monitoring",
+							},
+							"rules": []interface{}{
+								map[string]interface{}{
+									"apiGroups": []interface{}{"apps"},
+									"resources": []interface{}{"deployments"},
+									"verbs":     []interface{}{"get"},
+								},
+							},
+						},
+					},
+					Dependencies: []string{},
+				},
+				&Manifest{
+					ID: "declcd-tenant__rbac.authorization.k8s.io_ClusterRoleBinding",
+					Content: unstructured.Unstructured{
+						Object: map[string]interface{}{
+							"apiVersion": "rbac.authorization.k8s.io/v1",
+							"kind":       "ClusterRoleBinding",
+							"metadata": map[string]interface{}{
+								"name":      "declcd-tenant",
+								"namespace": "",
+							},
+							"roleRef": map[string]interface{}{
+								"apiGroup": "rbac.authorization.k8s.io",
+								"kind":     "ClusterRole",
+								"name":     "declcd-tenant",
+							},
+							"subjects": []interface{}{
+								map[string]interface{}{
+									"kind":      "ServiceAccount",
+									"name":      "tenant",
+									"namespace": "declcd-system",
+								},
+							},
+						},
+					},
+					Dependencies: []string{},
+				},
+				&Manifest{
+					ID: "declcd-tenant_monitoring_rbac.authorization.k8s.io_RoleBinding",
+					Content: unstructured.Unstructured{
+						Object: map[string]interface{}{
+							"apiVersion": "rbac.authorization.k8s.io/v1",
+							"kind":       "RoleBinding",
+							"metadata": map[string]interface{}{
+								"name":      "declcd-tenant",
+								"namespace": "monitoring",
+							},
+							"roleRef": map[string]interface{}{
+								"apiGroup": "rbac.authorization.k8s.io",
+								"kind":     "Role",
+								"name":     "declcd-tenant",
+							},
+							"subjects": []interface{}{
+								map[string]interface{}{
+									"kind":      "ServiceAccount",
+									"name":      "tenant",
+									"namespace": "declcd-system",
+								},
+							},
+						},
+					},
+					Dependencies: []string{},
+				},
+			},
+			expectedErr: "",
+		},
+		{
+			name:              "MatrixDuplicateID",
+			projectRoot:       path.Join(cwd, "test", "testdata", "build"),
+			packagePath:       "./infra/matrixduplicateid",
+			expectedInstances: []Instance{},
+			expectedErr:       ErrDuplicateComponentID.Error(),
+		},
+		{
+			name:        "PruneOrphan",
+			projectRoot: path.Join(cwd, "test", "testdata", "build"),
+			packagePath: "./infra/pruneorphan",
+			expectedInstances: []Instance{
+				&Manifest{
+					ID: "config_prometheus__ConfigMap",
+					Content: unstructured.Unstructured{
+						Object: map[string]interface{}{
+							"apiVersion": "v1",
+							"kind":       "ConfigMap",
+							"metadata": map[string]interface{}{
+								"name":      "config",
+								"namespace": "prometheus",
+								"annotations": map[string]interface{}{
+									PruneAnnotation: PruneOrphan,
+								},
+							},
+						},
+					},
+					Dependencies: []string{},
+				},
+				&helm.ReleaseComponent{
+					ID: "test_prometheus_HelmRelease",
+					Content: helm.ReleaseDeclaration{
+						Name:      "test",
+						Namespace: "prometheus",
+						Chart: helm.Chart{
+							Name:    "test",
+							RepoURL: "oci://test",
+							Version: "test",
+						},
+						Values: helm.Values{},
+					},
+					Dependencies: []string{},
+					Orphan:       true,
+				},
+			},
+			expectedErr: "",
+		},
+		{
+			name:        "FieldManager",
+			projectRoot: path.Join(cwd, "test", "testdata", "build"),
+			packagePath: "./infra/fieldmanager",
+			expectedInstances: []Instance{
+				&Manifest{
+					ID: "config_tenant__ConfigMap",
+					Content: unstructured.Unstructured{
+						Object: map[string]interface{}{
+							"apiVersion": "v1",
+							"kind":       "ConfigMap",
+							"metadata": map[string]interface{}{
+								"name":      "config",
+								"namespace": "tenant",
+								"annotations": map[string]interface{}{
+									FieldManagerAnnotation: "tenant",
+								},
+							},
+						},
+					},
+					Dependencies: []string{},
+					FieldManager: "tenant",
+				},
+				&helm.ReleaseComponent{
+					ID: "test_tenant_HelmRelease",
+					Content: helm.ReleaseDeclaration{
+						Name:      "test",
+						Namespace: "tenant",
+						Chart: helm.Chart{
+							Name:    "test",
+							RepoURL: "oci://test",
+							Version: "test",
+						},
+						Values:       helm.Values{},
+						FieldManager: "tenant",
+					},
+					Dependencies: []string{},
+				},
+			},
+			expectedErr: "",
+		},
+		{
+			name:        "ServiceAccount",
+			projectRoot: path.Join(cwd, "test", "testdata", "build"),
+			packagePath: "./infra/serviceaccount",
+			expectedInstances: []Instance{
+				&Manifest{
+					ID: "config_apps__ConfigMap",
+					Content: unstructured.Unstructured{
+						Object: map[string]interface{}{
+							"apiVersion": "v1",
+							"kind":       "ConfigMap",
+							"metadata": map[string]interface{}{
+								"name":      "config",
+								"namespace": "apps",
+							},
+						},
+					},
+					Dependencies:       []string{},
+					ServiceAccountName: "apps",
+				},
+				&helm.ReleaseComponent{
+					ID: "test_apps_HelmRelease",
+					Content: helm.ReleaseDeclaration{
+						Name:      "test",
+						Namespace: "apps",
+						Chart: helm.Chart{
+							Name:    "test",
+							RepoURL: "oci://test",
+							Version: "test",
+						},
+						Values:             helm.Values{},
+						ServiceAccountName: "apps",
+					},
+					Dependencies: []string{},
+				},
+			},
+			expectedErr: "",
+		},
+		{
+			name:        "GenerateName",
+			projectRoot: path.Join(cwd, "test", "testdata", "build"),
+			packagePath: "./infra/generatename",
+			expectedInstances: []Instance{
+				&Manifest{
+					ID: "migration-_prometheus_batch_Job",
+					Content: unstructured.Unstructured{
+						Object: map[string]interface{}{
+							"apiVersion": "batch/v1",
+							"kind":       "Job",
+							"metadata": map[string]interface{}{
+								"generateName": "migration-",
+								"namespace":    "prometheus",
+							},
+							"spec": map[string]interface{}{
+								"template": map[string]interface{}{
+									"spec": map[string]interface{}{
+										"restartPolicy": "Never",
+										"containers": []interface{}{
+											map[string]interface{}{
+												"name":  "migration",
+												"image": "busybox",
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+					Dependencies: []string{},
+				},
+			},
+			expectedErr: "",
+		},
+		{
+			name:        "Versioned",
+			projectRoot: path.Join(cwd, "test", "testdata", "build"),
+			packagePath: "./infra/versioned",
+			expectedInstances: []Instance{
+				&Manifest{
+					ID: "config_test__ConfigMap",
+					Content: unstructured.Unstructured{
+						Object: map[string]interface{}{
+							"apiVersion": "v1",
+							"kind":       "ConfigMap",
+							"metadata": map[string]interface{}{
+								"name":      "config-d2137d7f01",
+								"namespace": "test",
+								"annotations": map[string]interface{}{
+									VersionedAnnotation: "30m",
+								},
+							},
+							"data": map[string]interface{}{
+								"LOG_LEVEL": "debug",
+							},
+						},
+					},
+					Dependencies: []string{},
+				},
+				&Manifest{
+					ID: "test_test_apps_Deployment",
+					Content: unstructured.Unstructured{
+						Object: map[string]interface{}{
+							"apiVersion": "apps/v1",
+							"kind":       "Deployment",
+							"metadata": map[string]interface{}{
+								"name":      "test",
+								"namespace": "test",
+							},
+							"spec": map[string]interface{}{
+								"template": map[string]interface{}{
+									"spec": map[string]interface{}{
+										"containers": []interface{}{
+											map[string]interface{}{
+												"name":  "test",
+												"image": "test",
+												"envFrom": []interface{}{
+													map[string]interface{}{
+														"configMapRef": map[string]interface{}{
+															"name": "config-d2137d7f01",
+														},
+													},
+												},
+											},
+										},
+										"volumes": []interface{}{
+											map[string]interface{}{
+												"name": "config",
+												"configMap": map[string]interface{}{
+													"name": "config-d2137d7f01",
+												},
+											},
+											map[string]interface{}{
+												"name": "other",
+												"configMap": map[string]interface{}{
+													"name": "other",
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+					Dependencies: []string{"config_test__ConfigMap"},
+				},
+			},
+			expectedErr: "",
+		},
+		{
+			name:        "MigrateStorage",
+			projectRoot: path.Join(cwd, "test", "testdata", "build"),
+			packagePath: "./infra/migrate",
+			expectedInstances: []Instance{
+				&Manifest{
+					ID: "tests.declcd.io__apiextensions.k8s.io_CustomResourceDefinition",
+					Content: unstructured.Unstructured{
+						Object: map[string]interface{}{
+							"apiVersion": "apiextensions.k8s.io/v1",
+							"kind":       "CustomResourceDefinition",
+							"metadata": map[string]interface{}{
+								"name":      "tests.declcd.io",
+								"namespace": "",
+							},
+							"spec": map[string]interface{}{
+								"group": "declcd.io",
+								"names": map[string]interface{}{
+									"kind":   "Test",
+									"plural": "tests",
+								},
+								"scope": "Namespaced",
+							},
+						},
+					},
+					Dependencies: []string{},
+					Migration:    MigrateStorage,
+				},
+			},
+			expectedErr: "",
+		},
+		{
+			name:        "NamespaceMetadata",
+			projectRoot: path.Join(cwd, "test", "testdata", "build"),
+			packagePath: "./infra/namespacemetadata",
+			expectedInstances: []Instance{
+				&helm.ReleaseComponent{
+					ID: "test_restricted_HelmRelease",
+					Content: helm.ReleaseDeclaration{
+						Name:      "test",
+						Namespace: "restricted",
+						Chart: helm.Chart{
+							Name:    "test",
+							RepoURL: "oci://test",
+							Version: "test",
+						},
+						Values: helm.Values{},
+						NamespaceMetadata: &helm.NamespaceMetadata{
+							Labels: map[string]string{
+								"pod-security.kubernetes.io/enforce": "baseline",
+							},
+							Annotations: map[string]string{
+								"owner": "declcd",
+							},
+						},
+					},
+					Dependencies: []string{},
+				},
+			},
+			expectedErr: "",
+		},
+		{
+			name:        "HookResources",
+			projectRoot: path.Join(cwd, "test", "testdata", "build"),
+			packagePath: "./infra/hookresources",
+			expectedInstances: []Instance{
+				&helm.ReleaseComponent{
+					ID: "test_test_HelmRelease",
+					Content: helm.ReleaseDeclaration{
+						Name:      "test",
+						Namespace: "test",
+						Chart: helm.Chart{
+							Name:    "test",
+							RepoURL: "oci://test",
+							Version: "test",
+						},
+						Values: helm.Values{},
+						HookResources: &helm.HookResources{
+							Kinds: []helm.HookResourceKind{
+								{
+									APIVersion: "v1",
+									Kind:       "Secret",
+								},
+							},
+						},
+					},
+					Dependencies: []string{},
+				},
+			},
+			expectedErr: "",
+		},
+		{
+			name:        "ValuesFrom",
+			projectRoot: path.Join(cwd, "test", "testdata", "build"),
+			packagePath: "./infra/valuesfrom",
+			expectedInstances: []Instance{
+				&helm.ReleaseComponent{
+					ID: "test_test_HelmRelease",
+					Content: helm.ReleaseDeclaration{
+						Name:      "test",
+						Namespace: "test",
+						Chart: helm.Chart{
+							Name:    "test",
+							RepoURL: "oci://test",
+							Version: "test",
+						},
+						Values: helm.Values{},
+						ValuesFrom: []helm.ValuesReference{
+							{
+								Kind: "Secret",
+								Name: "credentials",
+							},
+							{
+								Kind:      "ConfigMap",
+								Name:      "defaults",
+								Namespace: "shared",
+								Key:       "test.yaml",
+							},
+							{
+								Kind:     "Secret",
+								Name:     "overrides",
+								Optional: true,
+							},
+						},
+					},
+					Dependencies: []string{},
+				},
+			},
+			expectedErr: "",
+		},
+		{
+			name:        "Promotion",
+			projectRoot: path.Join(cwd, "test", "testdata", "build"),
+			packagePath: "./infra/promotion",
+			expectedInstances: []Instance{
+				&Manifest{
+					ID: "config_prod__ConfigMap",
+					Content: unstructured.Unstructured{
+						Object: map[string]interface{}{
+							"apiVersion": "v1",
+							"kind":       "ConfigMap",
+							"metadata": map[string]interface{}{
+								"name":      "config",
+								"namespace": "prod",
+							},
+						},
+					},
+					Dependencies: []string{},
+					Promotion: promotion.Policy{
+						After: &promoteAfter,
+					},
+				},
+				&helm.ReleaseComponent{
+					ID: "test_prod_HelmRelease",
+					Content: helm.ReleaseDeclaration{
+						Name:      "test",
+						Namespace: "prod",
+						Chart: helm.Chart{
+							Name:    "test",
+							RepoURL: "oci://test",
+							Version: "test",
+						},
+						Values: helm.Values{},
+					},
+					Dependencies: []string{},
+					Promotion: promotion.Policy{
+						Manual: true,
+					},
+				},
+			},
+			expectedErr: "",
+		},
+		{
+			name:        "Kustomization",
+			projectRoot: path.Join(cwd, "test", "testdata", "build"),
+			packagePath: "./infra/kustomization",
+			expectedInstances: []Instance{
+				&Manifest{
+					ID: "prod___Namespace",
+					Content: unstructured.Unstructured{
+						Object: map[string]interface{}{
+							"apiVersion": "v1",
+							"kind":       "Namespace",
+							"metadata": map[string]interface{}{
+								"name": "prod",
+							},
+						},
+					},
+					Dependencies: []string{},
+				},
+				&Manifest{
+					ID: "config_prod__ConfigMap",
+					Content: unstructured.Unstructured{
+						Object: map[string]interface{}{
+							"apiVersion": "v1",
+							"kind":       "ConfigMap",
+							"metadata": map[string]interface{}{
+								"name":      "config",
+								"namespace": "prod",
+							},
+							"data": map[string]interface{}{
+								"env": "prod",
+							},
+						},
+					},
+					Dependencies: []string{"prod___Namespace"},
+				},
+				&Kustomization{
+					ID:           "overlay_Kustomization",
+					Path:         "infra/kustomization/overlay",
+					Dependencies: []string{"prod___Namespace", "config_prod__ConfigMap"},
+					Objects:      []string{"prod___Namespace", "config_prod__ConfigMap"},
+				},
+			},
+			expectedErr: "",
+		},
+		{
+			name:        "Wait",
+			projectRoot: path.Join(cwd, "test", "testdata", "build"),
+			packagePath: "./infra/wait",
+			expectedInstances: []Instance{
+				&Manifest{
+					ID: "tests.declcd.io__apiextensions.k8s.io_CustomResourceDefinition",
+					Content: unstructured.Unstructured{
+						Object: map[string]interface{}{
+							"apiVersion": "apiextensions.k8s.io/v1",
+							"kind":       "CustomResourceDefinition",
+							"metadata": map[string]interface{}{
+								"name":      "tests.declcd.io",
+								"namespace": "",
+							},
+						},
+					},
+					Dependencies: []string{},
+					Wait: &helm.Wait{
+						Timeout: 5 * time.Minute,
+						Rules:   health.Rules{},
+					},
+				},
+				&Manifest{
+					ID: "prod___Namespace",
+					Content: unstructured.Unstructured{
+						Object: map[string]interface{}{
+							"apiVersion": "v1",
+							"kind":       "Namespace",
+							"metadata": map[string]interface{}{
+								"name": "prod",
+							},
+						},
+					},
+					Dependencies: []string{"tests.declcd.io__apiextensions.k8s.io_CustomResourceDefinition"},
+				},
+				&Manifest{
+					ID: "config_prod__ConfigMap",
+					Content: unstructured.Unstructured{
+						Object: map[string]interface{}{
+							"apiVersion": "v1",
+							"kind":       "ConfigMap",
+							"metadata": map[string]interface{}{
+								"name":      "config",
+								"namespace": "prod",
+							},
+							"data": map[string]interface{}{
+								"env": "prod",
+							},
+						},
+					},
+					Dependencies: []string{"tests.declcd.io__apiextensions.k8s.io_CustomResourceDefinition", "prod___Namespace"},
+				},
+				&Kustomization{
+					ID:   "overlay_Kustomization",
+					Path: "infra/kustomization/overlay",
+					Dependencies: []string{
+						"tests.declcd.io__apiextensions.k8s.io_CustomResourceDefinition",
+						"prod___Namespace",
+						"config_prod__ConfigMap",
+					},
+					Objects: []string{"prod___Namespace", "config_prod__ConfigMap"},
+					Wait: &helm.Wait{
+						Timeout: 1 * time.Minute,
+						Rules:   health.Rules{"ConfigMap": "Ready"},
+					},
+				},
+			},
+			expectedErr: "",
+		},
+		{
+			name:        "ManifestSequence",
+			projectRoot: path.Join(cwd, "test", "testdata", "build"),
+			packagePath: "./infra/sequence",
+			expectedInstances: []Instance{
+				&Manifest{
+					ID: "prod___Namespace",
+					Content: unstructured.Unstructured{
+						Object: map[string]interface{}{
+							"apiVersion": "v1",
+							"kind":       "Namespace",
+							"metadata": map[string]interface{}{
+								"name":      "prod",
+								"namespace": "",
+							},
+						},
+					},
+					Dependencies: []string{},
+				},
+				&Manifest{
+					ID: "credentials_prod__Secret",
+					Content: unstructured.Unstructured{
+						Object: map[string]interface{}{
+							"apiVersion": "v1",
+							"kind":       "Secret",
+							"metadata": map[string]interface{}{
+								"name":      "credentials",
+								"namespace": "prod",
+							},
+						},
+					},
+					Dependencies: []string{"prod___Namespace"},
+					Wait: &helm.Wait{
+						Timeout: 1 * time.Minute,
+						Rules:   health.Rules{},
+					},
+				},
+				&Manifest{
+					ID: "app_prod_apps_Deployment",
+					Content: unstructured.Unstructured{
+						Object: map[string]interface{}{
+							"apiVersion": "apps/v1",
+							"kind":       "Deployment",
+							"metadata": map[string]interface{}{
+								"name":      "app",
+								"namespace": "prod",
+							},
+						},
+					},
+					Dependencies: []string{"credentials_prod__Secret"},
+					Wait: &helm.Wait{
+						Timeout: 1 * time.Minute,
+						Rules:   health.Rules{},
+					},
+				},
+				&ManifestSequence{
+					ID: "app_ManifestSequence",
+					Dependencies: []string{
+						"prod___Namespace",
+						"credentials_prod__Secret",
+						"app_prod_apps_Deployment",
+					},
+					Objects: []string{"credentials_prod__Secret", "app_prod_apps_Deployment"},
+					Wait: &helm.Wait{
+						Timeout: 1 * time.Minute,
+						Rules:   health.Rules{},
+					},
+				},
+			},
+			expectedErr: "",
+		},
+		{
+			name:              "KustomizationOutsideProject",
+			projectRoot:       path.Join(cwd, "test", "testdata", "build"),
+			packagePath:       "./infra/kustomizationoutsideproject",
+			expectedInstances: []Instance{},
+			expectedErr:       ErrKustomizationPath.Error(),
+		},
+		{
+			name:        "ValuesFiles",
+			projectRoot: path.Join(cwd, "test", "testdata", "build"),
+			packagePath: "./infra/valuesfiles",
+			expectedInstances: []Instance{
+				&helm.ReleaseComponent{
+					ID: "test_prometheus_HelmRelease",
+					Content: helm.ReleaseDeclaration{
+						Name:      "test",
+						Namespace: "prometheus",
+						Chart: helm.Chart{
+							Name:    "test",
+							RepoURL: "oci://test",
+							Version: "test",
+						},
+						Values: helm.Values{
+							"autoscaling": map[string]interface{}{
+								"enabled": true,
+							},
+							"image": map[string]interface{}{
+								"repository": "nginx",
+								"tag":        "1.27",
+							},
+							"service": map[string]interface{}{
+								"type": "ClusterIP",
+							},
+						},
+					},
+					Dependencies: []string{},
+				},
+			},
+			expectedErr: "",
+		},
+		{
+			name:              "ValuesSchemaViolation",
+			projectRoot:       path.Join(cwd, "test", "testdata", "build"),
+			packagePath:       "./infra/valuesschemaviolation",
+			expectedInstances: []Instance{},
+			expectedErr:       "service.type",
+		},
+		{
+			name:              "UnsupportedLanguageVersion",
+			projectRoot:       path.Join(cwd, "test", "testdata", "unsupportedlanguage"),
+			packagePath:       "./infra",
+			expectedInstances: []Instance{},
+			expectedErr:       ErrUnsupportedLanguageVersion.Error(),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			components, err := builder.Build(
+				WithProjectRoot(tc.projectRoot),
+				WithPackagePath(tc.packagePath),
+			)
+			if tc.expectedErr != "" {
+				assert.ErrorContains(t, err, tc.expectedErr)
+			} else {
+				assert.NilError(t, err)
+				assert.Assert(t, len(components) == len(tc.expectedInstances))
+				for i, expected := range tc.expectedInstances {
+					current := components[i]
+					switch expected := expected.(type) {
+					case *Manifest:
+						current, ok := current.(*Manifest)
+						assert.Assert(t, ok)
+						assert.Equal(t, current.ID, expected.ID)
+						assert.DeepEqual(t, current.Dependencies, expected.Dependencies)
+						assert.DeepEqual(t, current.Content, expected.Content)
+						assert.DeepEqual(t, current.Wait, expected.Wait)
+						assert.DeepEqual(t, current.Promotion, expected.Promotion)
+						assert.Equal(t, current.FieldManager, expected.FieldManager)
+						assert.Equal(t, current.ServiceAccountName, expected.ServiceAccountName)
+					case *Hook:
+						current, ok := current.(*Hook)
+						assert.Assert(t, ok)
+						assert.Equal(t, current.ID, expected.ID)
+						assert.Equal(t, current.Phase, expected.Phase)
+						assert.Equal(t, current.FailurePolicy, expected.FailurePolicy)
+						assert.Equal(t, current.Timeout, expected.Timeout)
+						assert.DeepEqual(t, current.Dependencies, expected.Dependencies)
+						assert.DeepEqual(t, current.Content, expected.Content)
+					case *helm.ReleaseComponent:
+						current, ok := current.(*helm.ReleaseComponent)
+						assert.Assert(t, ok)
+						assert.Equal(t, current.ID, expected.ID)
+						assert.DeepEqual(t, current.Content.Values, expected.Content.Values)
+						assert.DeepEqual(t, current.Content.Capabilities, expected.Content.Capabilities)
+						assert.DeepEqual(t, current.Content.Wait, expected.Content.Wait)
+						assert.DeepEqual(t, current.Content.NamespaceMetadata, expected.Content.NamespaceMetadata)
+						assert.DeepEqual(t, current.Content.ValuesFrom, expected.Content.ValuesFrom)
+						assert.DeepEqual(t, current.Content.HookResources, expected.Content.HookResources)
+						assert.DeepEqual(t, current.Dependencies, expected.Dependencies)
+						assert.DeepEqual(t, current.Promotion, expected.Promotion)
+						assert.Equal(t, current.Orphan, expected.Orphan)
+						assert.Equal(t, current.Content.FieldManager, expected.Content.FieldManager)
+						assert.Equal(t, current.Content.ServiceAccountName, expected.Content.ServiceAccountName)
+					case *Kustomization:
+						current, ok := current.(*Kustomization)
+						assert.Assert(t, ok)
+						assert.Equal(t, current.ID, expected.ID)
+						assert.Equal(t, current.Path, expected.Path)
+						assert.DeepEqual(t, current.Objects, expected.Objects)
+						assert.DeepEqual(t, current.Dependencies, expected.Dependencies)
+						assert.DeepEqual(t, current.Wait, expected.Wait)
+					case *ManifestSequence:
+						current, ok := current.(*ManifestSequence)
+						assert.Assert(t, ok)
+						assert.Equal(t, current.ID, expected.ID)
+						assert.DeepEqual(t, current.Objects, expected.Objects)
+						assert.DeepEqual(t, current.Dependencies, expected.Dependencies)
+						assert.DeepEqual(t, current.Wait, expected.Wait)
+					case *Notification:
+						current, ok := current.(*Notification)
+						assert.Assert(t, ok)
+						assert.DeepEqual(t, current, expected)
 					}
 
 				}
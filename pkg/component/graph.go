@@ -17,6 +17,9 @@ package component
 import (
 	"errors"
 	"fmt"
+	"slices"
+	"strings"
+	"sync"
 )
 
 var (
@@ -67,6 +70,64 @@ func (graph *DependencyGraph) Get(componentID string) Instance {
 	return node
 }
 
+// DependencySources records where the dependencies of components are declared,
+// so that unknown component ids can be reported with their position in the project.
+// It is safe for concurrent use by multiple builds.
+type DependencySources struct {
+	mu        sync.Mutex
+	positions map[string]map[string]string
+}
+
+// NewDependencySources constructs empty [DependencySources].
+func NewDependencySources() *DependencySources {
+	return &DependencySources{
+		positions: make(map[string]map[string]string),
+	}
+}
+
+func (sources *DependencySources) record(componentID string, dependency string, position string) {
+	sources.mu.Lock()
+	defer sources.mu.Unlock()
+	dependencies, found := sources.positions[componentID]
+	if !found {
+		dependencies = make(map[string]string)
+		sources.positions[componentID] = dependencies
+	}
+	dependencies[dependency] = position
+}
+
+func (sources *DependencySources) position(componentID string, dependency string) string {
+	if sources == nil {
+		return ""
+	}
+	sources.mu.Lock()
+	defer sources.mu.Unlock()
+	return sources.positions[componentID][dependency]
+}
+
+// Validate checks that every dependency references a component of the graph.
+// All unknown ids are reported at once, prefixed with their position, if sources are given.
+func (graph *DependencyGraph) Validate(sources *DependencySources) error {
+	unknown := make([]string, 0)
+	for id, node := range graph.set {
+		for _, dependency := range node.GetDependencies() {
+			if _, found := graph.set[dependency]; found {
+				continue
+			}
+			description := fmt.Sprintf("%s depends on %s", id, dependency)
+			if position := sources.position(id, dependency); position != "" {
+				description = fmt.Sprintf("%s: %s", position, description)
+			}
+			unknown = append(unknown, description)
+		}
+	}
+	if len(unknown) == 0 {
+		return nil
+	}
+	slices.Sort(unknown)
+	return fmt.Errorf("%w: %s", ErrUnknownComponentID, strings.Join(unknown, "; "))
+}
+
 // TopologicalSort performs a topological sort on the component dependency graph and returns the sorted order.
 // It returns an error if a cycle is detected.
 func (dag *DependencyGraph) TopologicalSort() ([]Instance, error) {
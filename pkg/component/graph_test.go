@@ -33,9 +33,9 @@ func TestDependencyGraph_Insert(t *testing.T) {
 			name: "NoConflict",
 			nodes: []component.Instance{
 				&component.Manifest{
-					"prometheus___Namespace",
-					[]string{},
-					unstructured.Unstructured{
+					ID:           "prometheus___Namespace",
+					Dependencies: []string{},
+					Content: unstructured.Unstructured{
 						Object: map[string]interface{}{
 							"kind":       "Namespace",
 							"apiVersion": "v1",
@@ -46,9 +46,9 @@ func TestDependencyGraph_Insert(t *testing.T) {
 					},
 				},
 				&component.Manifest{
-					"linkerd___Namespace",
-					[]string{"certmanager"},
-					unstructured.Unstructured{
+					ID:           "linkerd___Namespace",
+					Dependencies: []string{"certmanager"},
+					Content: unstructured.Unstructured{
 						Object: map[string]interface{}{
 							"kind":       "Namespace",
 							"apiVersion": "v1",
@@ -65,9 +65,9 @@ func TestDependencyGraph_Insert(t *testing.T) {
 			name: "Conflict",
 			nodes: []component.Instance{
 				&component.Manifest{
-					"prometheus___Namespace",
-					[]string{},
-					unstructured.Unstructured{
+					ID:           "prometheus___Namespace",
+					Dependencies: []string{},
+					Content: unstructured.Unstructured{
 						Object: map[string]interface{}{
 							"kind":       "Namespace",
 							"apiVersion": "v1",
@@ -78,9 +78,9 @@ func TestDependencyGraph_Insert(t *testing.T) {
 					},
 				},
 				&component.Manifest{
-					"prometheus___Namespace",
-					[]string{"certmanager"},
-					unstructured.Unstructured{
+					ID:           "prometheus___Namespace",
+					Dependencies: []string{"certmanager"},
+					Content: unstructured.Unstructured{
 						Object: map[string]interface{}{
 							"kind":       "Namespace",
 							"apiVersion": "v1",
@@ -91,9 +91,9 @@ func TestDependencyGraph_Insert(t *testing.T) {
 					},
 				},
 				&component.Manifest{
-					"shouldntmatter___Namespace",
-					[]string{},
-					unstructured.Unstructured{
+					ID:           "shouldntmatter___Namespace",
+					Dependencies: []string{},
+					Content: unstructured.Unstructured{
 						Object: map[string]interface{}{
 							"kind":       "Namespace",
 							"apiVersion": "v1",
@@ -165,9 +165,9 @@ func TestDependencyGraph_Delete(t *testing.T) {
 	graph := component.NewDependencyGraph()
 	err := graph.Insert(
 		&component.Manifest{
-			"prometheus___Namespace",
-			[]string{},
-			unstructured.Unstructured{
+			ID:           "prometheus___Namespace",
+			Dependencies: []string{},
+			Content: unstructured.Unstructured{
 				Object: map[string]interface{}{
 					"kind":       "Namespace",
 					"apiVersion": "v1",
@@ -178,9 +178,9 @@ func TestDependencyGraph_Delete(t *testing.T) {
 			},
 		},
 		&component.Manifest{
-			"linkerd___Namespace",
-			[]string{"certmanager"},
-			unstructured.Unstructured{
+			ID:           "linkerd___Namespace",
+			Dependencies: []string{"certmanager"},
+			Content: unstructured.Unstructured{
 				Object: map[string]interface{}{
 					"kind":       "Namespace",
 					"apiVersion": "v1",
@@ -209,9 +209,9 @@ func TestDependencyGraph_TopologicalSort(t *testing.T) {
 			name: "Positive",
 			nodes: []component.Instance{
 				&component.Manifest{
-					"prometheus___Namespace",
-					[]string{},
-					unstructured.Unstructured{
+					ID:           "prometheus___Namespace",
+					Dependencies: []string{},
+					Content: unstructured.Unstructured{
 						Object: map[string]interface{}{
 							"kind":       "Namespace",
 							"apiVersion": "v1",
@@ -222,9 +222,9 @@ func TestDependencyGraph_TopologicalSort(t *testing.T) {
 					},
 				},
 				&component.Manifest{
-					"linkerd___Namespace",
-					[]string{"certmanager___Namespace"},
-					unstructured.Unstructured{
+					ID:           "linkerd___Namespace",
+					Dependencies: []string{"certmanager___Namespace"},
+					Content: unstructured.Unstructured{
 						Object: map[string]interface{}{
 							"kind":       "Namespace",
 							"apiVersion": "v1",
@@ -235,9 +235,9 @@ func TestDependencyGraph_TopologicalSort(t *testing.T) {
 					},
 				},
 				&component.Manifest{
-					"certmanager___Namespace",
-					[]string{},
-					unstructured.Unstructured{
+					ID:           "certmanager___Namespace",
+					Dependencies: []string{},
+					Content: unstructured.Unstructured{
 						Object: map[string]interface{}{
 							"kind":       "Namespace",
 							"apiVersion": "v1",
@@ -248,9 +248,9 @@ func TestDependencyGraph_TopologicalSort(t *testing.T) {
 					},
 				},
 				&component.Manifest{
-					"emissaryingress___Namespace",
-					[]string{"certmanager___Namespace"},
-					unstructured.Unstructured{
+					ID:           "emissaryingress___Namespace",
+					Dependencies: []string{"certmanager___Namespace"},
+					Content: unstructured.Unstructured{
 						Object: map[string]interface{}{
 							"kind":       "Namespace",
 							"apiVersion": "v1",
@@ -261,9 +261,9 @@ func TestDependencyGraph_TopologicalSort(t *testing.T) {
 					},
 				},
 				&component.Manifest{
-					"keda___Namespace",
-					[]string{"prometheus___Namespace"},
-					unstructured.Unstructured{
+					ID:           "keda___Namespace",
+					Dependencies: []string{"prometheus___Namespace"},
+					Content: unstructured.Unstructured{
 						Object: map[string]interface{}{
 							"kind":       "Namespace",
 							"apiVersion": "v1",
@@ -279,9 +279,9 @@ func TestDependencyGraph_TopologicalSort(t *testing.T) {
 			name: "UnknownDependencyID",
 			nodes: []component.Instance{
 				&component.Manifest{
-					"prometheus___Namespace",
-					[]string{},
-					unstructured.Unstructured{
+					ID:           "prometheus___Namespace",
+					Dependencies: []string{},
+					Content: unstructured.Unstructured{
 						Object: map[string]interface{}{
 							"kind":       "Namespace",
 							"apiVersion": "v1",
@@ -292,9 +292,9 @@ func TestDependencyGraph_TopologicalSort(t *testing.T) {
 					},
 				},
 				&component.Manifest{
-					"linkerd___Namespace",
-					[]string{"certmanager"},
-					unstructured.Unstructured{
+					ID:           "linkerd___Namespace",
+					Dependencies: []string{"certmanager"},
+					Content: unstructured.Unstructured{
 						Object: map[string]interface{}{
 							"kind":       "Namespace",
 							"apiVersion": "v1",
@@ -305,9 +305,9 @@ func TestDependencyGraph_TopologicalSort(t *testing.T) {
 					},
 				},
 				&component.Manifest{
-					"certmanager___Namespace",
-					[]string{},
-					unstructured.Unstructured{
+					ID:           "certmanager___Namespace",
+					Dependencies: []string{},
+					Content: unstructured.Unstructured{
 						Object: map[string]interface{}{
 							"kind":       "Namespace",
 							"apiVersion": "v1",
@@ -324,9 +324,9 @@ func TestDependencyGraph_TopologicalSort(t *testing.T) {
 			name: "Cycle",
 			nodes: []component.Instance{
 				&component.Manifest{
-					"prometheus___Namespace",
-					[]string{},
-					unstructured.Unstructured{
+					ID:           "prometheus___Namespace",
+					Dependencies: []string{},
+					Content: unstructured.Unstructured{
 						Object: map[string]interface{}{
 							"kind":       "Namespace",
 							"apiVersion": "v1",
@@ -337,9 +337,9 @@ func TestDependencyGraph_TopologicalSort(t *testing.T) {
 					},
 				},
 				&component.Manifest{
-					"linkerd___Namespace",
-					[]string{"certmanager___Namespace"},
-					unstructured.Unstructured{
+					ID:           "linkerd___Namespace",
+					Dependencies: []string{"certmanager___Namespace"},
+					Content: unstructured.Unstructured{
 						Object: map[string]interface{}{
 							"kind":       "Namespace",
 							"apiVersion": "v1",
@@ -350,9 +350,9 @@ func TestDependencyGraph_TopologicalSort(t *testing.T) {
 					},
 				},
 				&component.Manifest{
-					"certmanager___Namespace",
-					[]string{"linkerd___Namespace"},
-					unstructured.Unstructured{
+					ID:           "certmanager___Namespace",
+					Dependencies: []string{"linkerd___Namespace"},
+					Content: unstructured.Unstructured{
 						Object: map[string]interface{}{
 							"kind":       "Namespace",
 							"apiVersion": "v1",
@@ -363,9 +363,9 @@ func TestDependencyGraph_TopologicalSort(t *testing.T) {
 					},
 				},
 				&component.Manifest{
-					"emissaryingress___Namespace",
-					[]string{"certmanager___Namespace"},
-					unstructured.Unstructured{
+					ID:           "emissaryingress___Namespace",
+					Dependencies: []string{"certmanager___Namespace"},
+					Content: unstructured.Unstructured{
 						Object: map[string]interface{}{
 							"kind":       "Namespace",
 							"apiVersion": "v1",
@@ -376,9 +376,9 @@ func TestDependencyGraph_TopologicalSort(t *testing.T) {
 					},
 				},
 				&component.Manifest{
-					"keda___Namespace",
-					[]string{"prometheus___Namespace"},
-					unstructured.Unstructured{
+					ID:           "keda___Namespace",
+					Dependencies: []string{"prometheus___Namespace"},
+					Content: unstructured.Unstructured{
 						Object: map[string]interface{}{
 							"kind":       "Namespace",
 							"apiVersion": "v1",
@@ -395,9 +395,9 @@ func TestDependencyGraph_TopologicalSort(t *testing.T) {
 			name: "DistantCycle",
 			nodes: []component.Instance{
 				&component.Manifest{
-					"prometheus___Namespace",
-					[]string{"keda___Namespace"},
-					unstructured.Unstructured{
+					ID:           "prometheus___Namespace",
+					Dependencies: []string{"keda___Namespace"},
+					Content: unstructured.Unstructured{
 						Object: map[string]interface{}{
 							"kind":       "Namespace",
 							"apiVersion": "v1",
@@ -408,9 +408,9 @@ func TestDependencyGraph_TopologicalSort(t *testing.T) {
 					},
 				},
 				&component.Manifest{
-					"linkerd___Namespace",
-					[]string{"certmanager___Namespace"},
-					unstructured.Unstructured{
+					ID:           "linkerd___Namespace",
+					Dependencies: []string{"certmanager___Namespace"},
+					Content: unstructured.Unstructured{
 						Object: map[string]interface{}{
 							"kind":       "Namespace",
 							"apiVersion": "v1",
@@ -421,9 +421,9 @@ func TestDependencyGraph_TopologicalSort(t *testing.T) {
 					},
 				},
 				&component.Manifest{
-					"certmanager___Namespace",
-					[]string{},
-					unstructured.Unstructured{
+					ID:           "certmanager___Namespace",
+					Dependencies: []string{},
+					Content: unstructured.Unstructured{
 						Object: map[string]interface{}{
 							"kind":       "Namespace",
 							"apiVersion": "v1",
@@ -434,9 +434,9 @@ func TestDependencyGraph_TopologicalSort(t *testing.T) {
 					},
 				},
 				&component.Manifest{
-					"emissaryingress___Namespace",
-					[]string{"certmanager___Namespace"},
-					unstructured.Unstructured{
+					ID:           "emissaryingress___Namespace",
+					Dependencies: []string{"certmanager___Namespace"},
+					Content: unstructured.Unstructured{
 						Object: map[string]interface{}{
 							"kind":       "Namespace",
 							"apiVersion": "v1",
@@ -447,9 +447,9 @@ func TestDependencyGraph_TopologicalSort(t *testing.T) {
 					},
 				},
 				&component.Manifest{
-					"keda___Namespace",
-					[]string{"prometheus___Namespace"},
-					unstructured.Unstructured{
+					ID:           "keda___Namespace",
+					Dependencies: []string{"prometheus___Namespace"},
+					Content: unstructured.Unstructured{
 						Object: map[string]interface{}{
 							"kind":       "Namespace",
 							"apiVersion": "v1",
@@ -15,7 +15,11 @@
 package component
 
 import (
+	"time"
+
 	"github.com/kharf/declcd/pkg/helm"
+	"github.com/kharf/declcd/pkg/notification"
+	"github.com/kharf/declcd/pkg/promotion"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 )
 
@@ -30,14 +34,42 @@ type Instance interface {
 // internalInstance represents a Declcd component with its id, dependencies and content.
 // It is the Go equivalent of the Component CUE definition the user interacts with.
 type internalInstance struct {
-	ID           string                 `json:"id"`
-	Type         string                 `json:"type"`
-	Dependencies []string               `json:"dependencies"`
-	Content      map[string]interface{} `json:"content"`
-	Name         string                 `json:"name"`
-	Namespace    string                 `json:"namespace"`
-	Chart        helm.Chart             `json:"chart"`
-	Values       map[string]interface{} `json:"values"`
+	ID                 string                   `json:"id"`
+	Type               string                   `json:"type"`
+	Dependencies       []string                 `json:"dependencies"`
+	Content            map[string]interface{}   `json:"content"`
+	Manifests          []map[string]interface{} `json:"manifests"`
+	Name               string                   `json:"name"`
+	Namespace          string                   `json:"namespace"`
+	Chart              helm.Chart               `json:"chart"`
+	Values             map[string]interface{}   `json:"values"`
+	Capabilities       *helm.Capabilities       `json:"capabilities"`
+	Wait               *internalWait            `json:"wait"`
+	NamespaceMetadata  *helm.NamespaceMetadata  `json:"namespaceMetadata"`
+	ValuesFrom         []helm.ValuesReference   `json:"valuesFrom"`
+	Patches            helm.Patches             `json:"patches"`
+	HookResources      *helm.HookResources      `json:"hookResources"`
+	Phase              string                   `json:"phase"`
+	FailurePolicy      string                   `json:"failurePolicy"`
+	Timeout            string                   `json:"timeout"`
+	Path               string                   `json:"path"`
+	PromoteAfter       string                   `json:"promoteAfter"`
+	Promotion          string                   `json:"promotion"`
+	FieldManager       string                   `json:"fieldManager"`
+	ServiceAccountName string                   `json:"serviceAccountName"`
+	Severity           string                   `json:"severity"`
+	Projects           []string                 `json:"projects"`
+	Shards             []string                 `json:"shards"`
+	Slack              *notification.Slack      `json:"slack"`
+	Webhook            *notification.Webhook    `json:"webhook"`
+	Email              *notification.Email      `json:"email"`
+	Request            *HTTPRequest             `json:"request"`
+}
+
+type internalWait struct {
+	Enabled bool              `json:"enabled"`
+	Timeout string            `json:"timeout"`
+	Rules   map[string]string `json:"rules"`
 }
 
 // Manifest represents a Declcd component with its id, dependencies and content.
@@ -47,6 +79,26 @@ type Manifest struct {
 	ID           string
 	Dependencies []string
 	Content      unstructured.Unstructured
+
+	// Wait optionally blocks dependent components until the object is ready.
+	Wait *helm.Wait
+
+	// Promotion optionally defers applying new versions of the manifest.
+	Promotion promotion.Policy
+
+	// FieldManager optionally overrides the field manager of the controller the manifest is applied with.
+	FieldManager string
+
+	// ServiceAccountName optionally names the service account in the project namespace the manifest is applied as.
+	ServiceAccountName string
+
+	// Migration optionally migrates the custom resources of a CustomResourceDefinition after it has been applied.
+	// It is declared with the @migrate attribute, e.g. [MigrateStorage].
+	Migration string
+
+	// Decrypted reports whether the content was decrypted from SOPS.
+	// Decrypted content is never exported to artifacts or published.
+	Decrypted bool `json:"-"`
 }
 
 var _ Instance = (*Manifest)(nil)
@@ -60,3 +112,104 @@ func (m *Manifest) GetDependencies() []string {
 }
 
 var _ Instance = (*helm.ReleaseComponent)(nil)
+
+// HookPhase defines when a Hook is run in relation to all other components.
+type HookPhase string
+
+const (
+	// PreApply hooks run before all other components are applied.
+	PreApply HookPhase = "PreApply"
+	// PostApply hooks run after all other components are applied.
+	PostApply HookPhase = "PostApply"
+)
+
+// FailurePolicy controls how a failing Hook affects the reconciliation.
+type FailurePolicy string
+
+const (
+	// Abort stops the reconciliation when a hook fails.
+	Abort FailurePolicy = "Abort"
+	// Ignore logs a failing hook and continues the reconciliation.
+	Ignore FailurePolicy = "Ignore"
+)
+
+// Hook is a Kubernetes object applied before or after all other components on every reconciliation.
+// Jobs are recreated on every run and awaited until they complete.
+type Hook struct {
+	ID            string
+	Dependencies  []string
+	Phase         HookPhase
+	FailurePolicy FailurePolicy
+	Timeout       time.Duration
+	Content       unstructured.Unstructured
+
+	// FieldManager optionally overrides the field manager of the controller the hook is applied with.
+	FieldManager string
+
+	// ServiceAccountName optionally names the service account in the project namespace the hook is applied as.
+	ServiceAccountName string
+
+	// Decrypted reports whether the content was decrypted from SOPS.
+	// Decrypted content is never exported to artifacts or published.
+	Decrypted bool `json:"-"`
+}
+
+var _ Instance = (*Hook)(nil)
+
+func (h *Hook) GetID() string {
+	return h.ID
+}
+
+func (h *Hook) GetDependencies() []string {
+	return h.Dependencies
+}
+
+// HTTPHook is a request sent before or after all other components on every reconciliation, e.g. to flush a cache.
+// Responses with a status outside of 2xx fail the hook.
+type HTTPHook struct {
+	ID            string
+	Dependencies  []string
+	Phase         HookPhase
+	FailurePolicy FailurePolicy
+	Timeout       time.Duration
+	Request       HTTPRequest
+}
+
+// HTTPRequest is sent by an [HTTPHook].
+type HTTPRequest struct {
+	URL     string            `json:"url"`
+	Method  string            `json:"method"`
+	Headers map[string]string `json:"headers"`
+	Body    string            `json:"body"`
+}
+
+var _ Instance = (*HTTPHook)(nil)
+
+func (h *HTTPHook) GetID() string {
+	return h.ID
+}
+
+func (h *HTTPHook) GetDependencies() []string {
+	return h.Dependencies
+}
+
+// Notification posts the outcome of reconciliations to Slack, a generic webhook or an SMTP server.
+// It is not applied to the cluster, but handed to the controller with the result of the reconciliation.
+type Notification struct {
+	ID           string
+	Dependencies []string
+	Filter       notification.Filter
+	Slack        *notification.Slack
+	Webhook      *notification.Webhook
+	Email        *notification.Email
+}
+
+var _ Instance = (*Notification)(nil)
+
+func (n *Notification) GetID() string {
+	return n.ID
+}
+
+func (n *Notification) GetDependencies() []string {
+	return n.Dependencies
+}
@@ -0,0 +1,142 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package component
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+
+	"github.com/kharf/declcd/pkg/helm"
+	"github.com/kharf/declcd/pkg/promotion"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/kustomize/api/krusty"
+	"sigs.k8s.io/kustomize/kyaml/filesys"
+)
+
+var (
+	ErrKustomizationPath = errors.New("Kustomization path must be relative to the project root")
+)
+
+// Kustomization is a kustomize directory of the project, which is built into Manifests.
+// It does not apply anything itself, but depends on all of its Manifests,
+// so that components depending on a Kustomization wait until all of its objects are applied.
+type Kustomization struct {
+	ID           string
+	Dependencies []string
+	Path         string
+
+	// Objects are the ids of the Manifests built from the kustomize directory.
+	Objects []string
+
+	// Wait optionally blocks dependent components until all objects of the Kustomization are ready.
+	Wait *helm.Wait
+
+	// Promotion optionally defers applying new versions of all objects of the Kustomization.
+	Promotion promotion.Policy
+
+	// FieldManager optionally overrides the field manager of the controller all objects are applied with.
+	FieldManager string
+
+	// ServiceAccountName optionally names the service account in the project namespace all objects are applied as.
+	ServiceAccountName string
+
+	manifests []*Manifest
+}
+
+var _ Instance = (*Kustomization)(nil)
+
+func (k *Kustomization) GetID() string {
+	return k.ID
+}
+
+func (k *Kustomization) GetDependencies() []string {
+	return k.Dependencies
+}
+
+// expandKustomization builds the kustomize directory and returns a Manifest for every resulting object,
+// followed by the Kustomization itself.
+// Manifests inherit the dependencies of the Kustomization and are identified like declared Manifests,
+// so that they are stored in the inventory and collected like them.
+func expandKustomization(projectRoot string, kustomization *Kustomization) ([]Instance, error) {
+	if !filepath.IsLocal(kustomization.Path) {
+		return nil, fmt.Errorf("%w: %s: %s", ErrKustomizationPath, kustomization.ID, kustomization.Path)
+	}
+
+	kustomizer := krusty.MakeKustomizer(krusty.MakeDefaultOptions())
+	resMap, err := kustomizer.Run(filesys.MakeFsOnDisk(), filepath.Join(projectRoot, kustomization.Path))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", kustomization.ID, err)
+	}
+
+	resources := resMap.Resources()
+	manifests := make([]*Manifest, 0, len(resources))
+	// Namespaces and CustomResourceDefinitions of the Kustomization are applied before the objects they contain.
+	namespaces := make(map[string]string)
+	crds := make(map[string]string)
+	for _, resource := range resources {
+		content, err := resource.Map()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", kustomization.ID, err)
+		}
+		manifest := &Manifest{
+			Content: unstructured.Unstructured{
+				Object: content,
+			},
+			FieldManager:       kustomization.FieldManager,
+			ServiceAccountName: kustomization.ServiceAccountName,
+		}
+		manifest.ID = manifestID(&manifest.Content)
+		switch manifest.Content.GroupVersionKind().GroupKind().String() {
+		case "Namespace":
+			namespaces[manifest.Content.GetName()] = manifest.ID
+		case "CustomResourceDefinition.apiextensions.k8s.io":
+			group, _, _ := unstructured.NestedString(content, "spec", "group")
+			crds[group] = manifest.ID
+		}
+		manifests = append(manifests, manifest)
+	}
+
+	instances := make([]Instance, 0, len(manifests)+1)
+	manifestIDs := make([]string, 0, len(manifests))
+	for _, manifest := range manifests {
+		manifest.Dependencies = slices.Clone(kustomization.Dependencies)
+		if id, found := namespaces[manifest.Content.GetNamespace()]; found {
+			manifest.Dependencies = append(manifest.Dependencies, id)
+		}
+		if id, found := crds[manifest.Content.GroupVersionKind().Group]; found {
+			manifest.Dependencies = append(manifest.Dependencies, id)
+		}
+		instances = append(instances, manifest)
+		manifestIDs = append(manifestIDs, manifest.ID)
+	}
+
+	kustomization.Objects = manifestIDs
+	kustomization.manifests = manifests
+	kustomization.Dependencies = append(slices.Clone(kustomization.Dependencies), manifestIDs...)
+	return append(instances, kustomization), nil
+}
+
+// manifestID constructs the id of a Manifest the way the #Manifest schema does.
+func manifestID(content *unstructured.Unstructured) string {
+	return fmt.Sprintf(
+		"%s_%s_%s_%s",
+		content.GetName(),
+		content.GetNamespace(),
+		content.GroupVersionKind().Group,
+		content.GetKind(),
+	)
+}
@@ -0,0 +1,92 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package component
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/cuecontext"
+	"golang.org/x/mod/semver"
+)
+
+var (
+	ErrUnsupportedLanguageVersion = errors.New("Unsupported CUE language version")
+)
+
+const (
+	// MinLanguageVersion is the earliest CUE language version a project can pin in its module.cue.
+	MinLanguageVersion = "v0.8.0"
+
+	// MaxLanguageVersion is the CUE language version of the evaluator embedded in declcd.
+	MaxLanguageVersion = "v0.9.2"
+)
+
+// LanguageVersion reads the CUE language version pinned in the module.cue of a project.
+// It is empty for projects without a module.cue or without a pinned version.
+func LanguageVersion(projectRoot string) (string, error) {
+	content, err := os.ReadFile(filepath.Join(projectRoot, "cue.mod", "module.cue"))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return "", nil
+		}
+		return "", err
+	}
+
+	moduleValue := cuecontext.New().CompileBytes(content, cue.Filename("module.cue"))
+	if err := moduleValue.Err(); err != nil {
+		return "", err
+	}
+
+	versionValue := moduleValue.LookupPath(cue.ParsePath("language.version"))
+	if !versionValue.Exists() {
+		return "", nil
+	}
+	return versionValue.String()
+}
+
+// checkLanguageVersion fails with an actionable error when a project pins a CUE language version,
+// which the embedded evaluator cannot build, instead of letting the evaluation fail on unknown language features.
+func checkLanguageVersion(projectRoot string) error {
+	version, err := LanguageVersion(projectRoot)
+	if err != nil {
+		return err
+	}
+	if version == "" {
+		return nil
+	}
+	if !semver.IsValid(version) {
+		return fmt.Errorf(
+			"%w: %q in cue.mod/module.cue is not a semantic version like %s",
+			ErrUnsupportedLanguageVersion,
+			version,
+			MaxLanguageVersion,
+		)
+	}
+	if semver.Compare(version, MinLanguageVersion) < 0 || semver.Compare(version, MaxLanguageVersion) > 0 {
+		return fmt.Errorf(
+			"%w: project pins %s in cue.mod/module.cue, but declcd supports %s to %s; pin a version in this range or upgrade declcd",
+			ErrUnsupportedLanguageVersion,
+			version,
+			MinLanguageVersion,
+			MaxLanguageVersion,
+		)
+	}
+	return nil
+}
@@ -3,16 +3,46 @@ package component
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"strings"
+	"time"
 
 	"github.com/go-logr/logr"
+	"github.com/kharf/declcd/internal/pool"
+	"github.com/kharf/declcd/pkg/health"
 	"github.com/kharf/declcd/pkg/helm"
 	"github.com/kharf/declcd/pkg/inventory"
 	"github.com/kharf/declcd/pkg/kube"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/rest"
 )
 
+var (
+	// ErrImpersonationUnsupported occurs when a component declares a service account, but the reconciler is unable to impersonate it.
+	ErrImpersonationUnsupported = errors.New("Service account impersonation not supported")
+)
+
+const (
+	// ManagedByLabel is the recommended Kubernetes label naming the tool managing an object.
+	// Manifests applied for a project are labeled with ManagedByValue, unless they declare the label themselves.
+	ManagedByLabel = "app.kubernetes.io/managed-by"
+	ManagedByValue = "declcd"
+
+	// ProjectLabel names the project, whose inventory tracks an applied manifest.
+	ProjectLabel = "declcd/project"
+)
+
+// Impersonator returns a client and a config impersonating a service account declared by components.
+type Impersonator func(serviceAccountName string) (kube.Client[unstructured.Unstructured], *rest.Config, error)
+
 // Reconciler reads Components with their desired state
 // and applies them on a Kubernetes cluster.
 // It stores objects in the inventory.
@@ -35,12 +65,41 @@ type Reconciler struct {
 
 	// Managers identify distinct workflows that are modifying the object (especially useful on conflicts!),
 	FieldManager string
+
+	// Registry optionally holds handlers applying custom component types.
+	Registry *Registry
+
+	// Suppressions exclude fields of manifests from being applied.
+	Suppressions kube.SuppressionRules
+
+	// Guardrails refuse manifests and hooks before they are applied.
+	Guardrails kube.Guardrails
+
+	// FieldValidation optionally instructs the API server to reject manifests and hooks with unknown or duplicate fields.
+	FieldValidation kube.FieldValidation
+
+	// Impersonator optionally creates the clients components declaring a service account are reconciled with.
+	// Such components are refused without it.
+	Impersonator Impersonator
+
+	// HTTPClient optionally sends the requests of HTTP hooks.
+	// Defaults to [http.DefaultClient].
+	HTTPClient *http.Client
+
+	// Project optionally names the project applying the manifests.
+	// Manifests are only labeled with [ManagedByLabel] and [ProjectLabel], when it is set.
+	Project string
 }
 
 func (reconciler *Reconciler) Reconcile(
 	ctx context.Context,
 	instance Instance,
 ) error {
+	reconciler, err := reconciler.impersonate(instance)
+	if err != nil {
+		return err
+	}
+
 	switch componentInstance := instance.(type) {
 	case *Manifest:
 		reconciler.Log.Info(
@@ -53,29 +112,92 @@ func (reconciler *Reconciler) Reconcile(
 			componentInstance.Content.GetKind(),
 		)
 
-		if err := reconciler.DynamicClient.Apply(ctx, &componentInstance.Content, reconciler.FieldManager, kube.Force(true)); err != nil {
+		reconciler.Suppressions.Strip(&componentInstance.Content)
+		reconciler.label(componentInstance)
+
+		if _, versioned := componentInstance.Content.GetAnnotations()[VersionedAnnotation]; versioned {
+			if err := reconciler.retainVersions(ctx, componentInstance, time.Now()); err != nil {
+				return err
+			}
+		}
+
+		// Encode once and share the bytes between apply and inventory to avoid holding multiple copies of large objects.
+		buf := pool.GetBuffer()
+		defer pool.PutBuffer(buf)
+		if err := json.NewEncoder(buf).Encode(componentInstance.Content.Object); err != nil {
+			return err
+		}
+
+		if err := reconciler.Guardrails.Check(&componentInstance.Content, buf.Len()); err != nil {
 			return err
 		}
 
-		invManifest := &inventory.ManifestItem{
-			ID: componentInstance.ID,
-			TypeMeta: v1.TypeMeta{
-				Kind:       componentInstance.Content.GetKind(),
-				APIVersion: componentInstance.Content.GetAPIVersion(),
-			},
-			Name:      componentInstance.Content.GetName(),
-			Namespace: componentInstance.Content.GetNamespace(),
+		stored := buf.Bytes()
+		if componentInstance.Content.GetName() == "" && componentInstance.Content.GetGenerateName() != "" {
+			stored, err = reconciler.applyGenerated(ctx, componentInstance, buf.Bytes())
+			if err != nil {
+				return err
+			}
+		} else {
+			if err := reconciler.DynamicClient.Apply(
+				ctx,
+				&componentInstance.Content,
+				reconciler.fieldManager(componentInstance.FieldManager),
+				kube.Force(true),
+				kube.Encoded(buf.Bytes()),
+				reconciler.FieldValidation,
+			); err != nil {
+				return err
+			}
+
+			if componentInstance.Migration == MigrateStorage {
+				if err := reconciler.migrateStorageVersion(ctx, componentInstance); err != nil {
+					return err
+				}
+			}
 		}
 
-		buf := &bytes.Buffer{}
-		if err := json.NewEncoder(buf).Encode(componentInstance.Content.Object); err != nil {
+		if err := reconciler.storeManifest(componentInstance.ID, &componentInstance.Content, stored); err != nil {
+			return err
+		}
+
+		if componentInstance.Wait != nil {
+			if err := reconciler.waitUntilReady(
+				ctx,
+				[]unstructured.Unstructured{componentInstance.Content},
+				*componentInstance.Wait,
+			); err != nil {
+				return fmt.Errorf("%s: %w", componentInstance.ID, err)
+			}
+		}
+
+	case *Hook:
+		if err := reconciler.reconcileHook(ctx, componentInstance); err != nil {
 			return err
 		}
 
-		if err := reconciler.InventoryInstance.StoreItem(invManifest, buf); err != nil {
+	case *HTTPHook:
+		if err := reconciler.reconcileHTTPHook(ctx, componentInstance); err != nil {
 			return err
 		}
 
+	case *Kustomization:
+		// Objects of a Kustomization are applied as its Manifests, which it depends on.
+		reconciler.Log.Info("Applied kustomization", "path", componentInstance.Path)
+		if componentInstance.Wait != nil {
+			objs := make([]unstructured.Unstructured, 0, len(componentInstance.manifests))
+			for _, manifest := range componentInstance.manifests {
+				objs = append(objs, manifest.Content)
+			}
+			if err := reconciler.waitUntilReady(ctx, objs, *componentInstance.Wait); err != nil {
+				return fmt.Errorf("%s: %w", componentInstance.ID, err)
+			}
+		}
+
+	case *ManifestSequence:
+		// Objects of a sequence are applied as its Manifests, which already waited for their predecessors.
+		reconciler.Log.Info("Applied manifest sequence", "objects", len(componentInstance.manifests))
+
 	case *helm.ReleaseComponent:
 		if _, err := reconciler.ChartReconciler.Reconcile(
 			ctx,
@@ -83,6 +205,363 @@ func (reconciler *Reconciler) Reconcile(
 		); err != nil {
 			return err
 		}
+
+	case CustomInstance:
+		if err := reconciler.Registry.reconcile(ctx, componentInstance); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// impersonate returns a copy of the reconciler acting as the service account the component declares,
+// or the reconciler itself.
+func (reconciler *Reconciler) impersonate(instance Instance) (*Reconciler, error) {
+	var serviceAccountName string
+	switch componentInstance := instance.(type) {
+	case *Manifest:
+		serviceAccountName = componentInstance.ServiceAccountName
+	case *Hook:
+		serviceAccountName = componentInstance.ServiceAccountName
+	case *Kustomization:
+		serviceAccountName = componentInstance.ServiceAccountName
+	case *ManifestSequence:
+		serviceAccountName = componentInstance.ServiceAccountName
+	case *helm.ReleaseComponent:
+		serviceAccountName = componentInstance.Content.ServiceAccountName
+	}
+	if serviceAccountName == "" {
+		return reconciler, nil
+	}
+	if reconciler.Impersonator == nil {
+		return nil, fmt.Errorf("%w: %s: %s", ErrImpersonationUnsupported, instance.GetID(), serviceAccountName)
+	}
+
+	client, cfg, err := reconciler.Impersonator(serviceAccountName)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", instance.GetID(), err)
+	}
+	impersonated := *reconciler
+	impersonated.DynamicClient = client
+	impersonated.ChartReconciler.Client = client
+	impersonated.ChartReconciler.KubeConfig = cfg
+	return &impersonated, nil
+}
+
+// fieldManager returns the field manager declared by a component or the one of the controller.
+func (reconciler *Reconciler) fieldManager(declared string) string {
+	if declared != "" {
+		return declared
+	}
+	return reconciler.FieldManager
+}
+
+// waitUntilReady blocks until all objects are ready or the timeout of the wait has passed,
+// so that dependent components are applied only afterwards.
+func (reconciler *Reconciler) waitUntilReady(
+	ctx context.Context,
+	objs []unstructured.Unstructured,
+	wait helm.Wait,
+) error {
+	timeoutCtx, cancel := context.WithTimeout(ctx, wait.Timeout)
+	defer cancel()
+	return health.WaitUntilReady(timeoutCtx, reconciler.DynamicClient, objs, wait.Rules)
+}
+
+// label marks a manifest as managed by the project, so that it is reported as unmanaged once it is missing from the inventory.
+// Generated and versioned manifests are applied with other names than they are tracked with and stay unlabeled.
+func (reconciler *Reconciler) label(manifest *Manifest) {
+	if reconciler.Project == "" || manifest.Content.GetName() == "" {
+		return
+	}
+	if _, versioned := manifest.Content.GetAnnotations()[VersionedAnnotation]; versioned {
+		return
+	}
+	labels := manifest.Content.GetLabels()
+	if labels == nil {
+		labels = make(map[string]string, 2)
+	}
+	if _, declared := labels[ManagedByLabel]; !declared {
+		labels[ManagedByLabel] = ManagedByValue
+	}
+	labels[ProjectLabel] = reconciler.Project
+	manifest.Content.SetLabels(labels)
+}
+
+func (reconciler *Reconciler) storeManifest(id string, content *unstructured.Unstructured, encoded []byte) error {
+	invManifest := &inventory.ManifestItem{
+		ID: id,
+		TypeMeta: v1.TypeMeta{
+			Kind:       content.GetKind(),
+			APIVersion: content.GetAPIVersion(),
+		},
+		Name:      content.GetName(),
+		Namespace: content.GetNamespace(),
+	}
+
+	return reconciler.InventoryInstance.StoreItem(invManifest, bytes.NewReader(encoded))
+}
+
+// migrateStorageVersion rewrites the custom resources of an applied CustomResourceDefinition,
+// so that deprecated versions can be removed from it by later upgrades.
+// Interrupted migrations are resumed by the next reconciliation.
+func (reconciler *Reconciler) migrateStorageVersion(ctx context.Context, manifest *Manifest) error {
+	migrated, err := reconciler.DynamicClient.MigrateStorageVersion(ctx, manifest.Content.GetName())
+	if err != nil {
+		return err
+	}
+	if migrated > 0 {
+		reconciler.Log.Info(
+			"Migrated storage version",
+			"name",
+			manifest.Content.GetName(),
+			"objects",
+			migrated,
+		)
+	}
+	return nil
+}
+
+// readStored returns the manifest applied by the previous reconciliation or nil, if it has never been applied.
+func (reconciler *Reconciler) readStored(manifest *Manifest) (*unstructured.Unstructured, error) {
+	reader, err := reconciler.InventoryInstance.GetItem(&inventory.ManifestItem{
+		ID:        manifest.ID,
+		Namespace: manifest.Content.GetNamespace(),
+	})
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer reader.Close()
+
+	var previous unstructured.Unstructured
+	if err := json.NewDecoder(reader).Decode(&previous.Object); err != nil {
+		return nil, err
+	}
+	return &previous, nil
+}
+
+// generatedHashAnnotation is set on the inventory copy of an object created with metadata.generateName.
+// It holds the hash of the declared manifest, so that the object is only replaced when its declaration changes.
+const generatedHashAnnotation = "declcd/generated-hash"
+
+// applyGenerated creates the object of a manifest with metadata.generateName,
+// unless the object generated by a previous reconciliation still exists and its declaration is unchanged.
+// A changed declaration replaces the previous object, so that only one generated object of a manifest exists at a time.
+// Objects of manifests, which are no longer declared, are deleted by the garbage collection.
+// It returns the encoded object with the name generated by the API server, which is stored in the inventory.
+func (reconciler *Reconciler) applyGenerated(ctx context.Context, manifest *Manifest, declared []byte) ([]byte, error) {
+	sum := sha256.Sum256(declared)
+	hash := hex.EncodeToString(sum[:])
+
+	previous, err := reconciler.readStored(manifest)
+	if err != nil {
+		return nil, err
+	}
+	if previous != nil && previous.GetName() != "" {
+		if previous.GetAnnotations()[generatedHashAnnotation] == hash {
+			_, err := reconciler.DynamicClient.Get(ctx, previous)
+			if err == nil {
+				manifest.Content.SetName(previous.GetName())
+				return json.Marshal(previous.Object)
+			}
+			if !k8sErrors.IsNotFound(err) {
+				return nil, err
+			}
+		}
+
+		reconciler.Log.Info(
+			"Deleting previously generated object",
+			"namespace",
+			previous.GetNamespace(),
+			"name",
+			previous.GetName(),
+			"kind",
+			previous.GetKind(),
+		)
+		if err := reconciler.DynamicClient.Delete(ctx, previous); err != nil && !k8sErrors.IsNotFound(err) {
+			return nil, err
+		}
+	}
+
+	if err := reconciler.DynamicClient.Apply(
+		ctx,
+		&manifest.Content,
+		reconciler.fieldManager(manifest.FieldManager),
+		kube.Force(true),
+		kube.Encoded(declared),
+		reconciler.FieldValidation,
+	); err != nil {
+		return nil, err
+	}
+
+	// Track the object with the name the API server generated.
+	tracked := manifest.Content.DeepCopy()
+	annotations := tracked.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string, 1)
+	}
+	annotations[generatedHashAnnotation] = hash
+	tracked.SetAnnotations(annotations)
+	return json.Marshal(tracked.Object)
+}
+
+var (
+	ErrHookFailed = errors.New("Hook failed")
+)
+
+// reconcileHook recreates the hook object and waits for Jobs to complete.
+func (reconciler *Reconciler) reconcileHook(
+	ctx context.Context,
+	hook *Hook,
+) error {
+	log := reconciler.Log.WithValues(
+		"namespace",
+		hook.Content.GetNamespace(),
+		"name",
+		hook.Content.GetName(),
+		"kind",
+		hook.Content.GetKind(),
+		"phase",
+		hook.Phase,
+	)
+
+	buf := pool.GetBuffer()
+	defer pool.PutBuffer(buf)
+	if err := json.NewEncoder(buf).Encode(hook.Content.Object); err != nil {
+		return err
+	}
+
+	if err := reconciler.Guardrails.Check(&hook.Content, buf.Len()); err != nil {
+		return err
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, hook.Timeout)
+	defer cancel()
+
+	if err := reconciler.DynamicClient.Delete(timeoutCtx, &hook.Content); err != nil && !k8sErrors.IsNotFound(err) {
+		return err
+	}
+	if err := reconciler.waitUntil(timeoutCtx, &hook.Content, isDeleted); err != nil {
+		return err
+	}
+
+	log.Info("Running hook")
+
+	if err := reconciler.DynamicClient.Apply(
+		timeoutCtx,
+		&hook.Content,
+		reconciler.fieldManager(hook.FieldManager),
+		kube.Force(true),
+		kube.Encoded(buf.Bytes()),
+		reconciler.FieldValidation,
+	); err != nil {
+		return err
+	}
+
+	if hook.Content.GetKind() == "Job" && hook.Content.GroupVersionKind().Group == "batch" {
+		if err := reconciler.waitUntil(timeoutCtx, &hook.Content, isJobFinished); err != nil {
+			return err
+		}
+	}
+
+	return reconciler.storeManifest(hook.ID, &hook.Content, buf.Bytes())
+}
+
+// reconcileHTTPHook sends the request of the hook and fails it for responses with a status outside of 2xx.
+func (reconciler *Reconciler) reconcileHTTPHook(
+	ctx context.Context,
+	hook *HTTPHook,
+) error {
+	reconciler.Log.Info("Running hook", "id", hook.ID, "phase", hook.Phase)
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, hook.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(
+		timeoutCtx,
+		hook.Request.Method,
+		hook.Request.URL,
+		strings.NewReader(hook.Request.Body),
+	)
+	if err != nil {
+		return err
+	}
+	for key, value := range hook.Request.Headers {
+		req.Header.Set(key, value)
+	}
+
+	httpClient := reconciler.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %s: %w", ErrHookFailed, hook.ID, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%w: %s: %s", ErrHookFailed, hook.ID, resp.Status)
 	}
 	return nil
 }
+
+type waitCondition = func(obj *unstructured.Unstructured, err error) (bool, error)
+
+func isDeleted(obj *unstructured.Unstructured, err error) (bool, error) {
+	if err != nil {
+		if k8sErrors.IsNotFound(err) {
+			return true, nil
+		}
+		return false, err
+	}
+	return obj == nil, nil
+}
+
+func isJobFinished(obj *unstructured.Unstructured, err error) (bool, error) {
+	if err != nil {
+		return false, err
+	}
+	conditions, _, err := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	if err != nil {
+		return false, err
+	}
+	for _, c := range conditions {
+		cond, ok := c.(map[string]interface{})
+		if !ok || cond["status"] != "True" {
+			continue
+		}
+		switch cond["type"] {
+		case "Complete":
+			return true, nil
+		case "Failed":
+			return false, fmt.Errorf("%w: job %s: %v", ErrHookFailed, obj.GetName(), cond["message"])
+		}
+	}
+	return false, nil
+}
+
+func (reconciler *Reconciler) waitUntil(
+	ctx context.Context,
+	obj *unstructured.Unstructured,
+	condition waitCondition,
+) error {
+	for {
+		done, err := condition(reconciler.DynamicClient.Get(ctx, obj))
+		if err != nil {
+			return err
+		}
+		if done {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w: %w", ErrHookFailed, ctx.Err())
+		case <-time.After(1 * time.Second):
+		}
+	}
+}
@@ -0,0 +1,436 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package component
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/kharf/declcd/pkg/inventory"
+	"github.com/kharf/declcd/pkg/kube"
+	"gotest.tools/v3/assert"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// generatingClient names created objects like the API server does for metadata.generateName.
+type generatingClient struct {
+	kube.Client[unstructured.Unstructured]
+	objects map[string]struct{}
+	created int
+}
+
+func (client *generatingClient) Apply(
+	ctx context.Context,
+	obj *unstructured.Unstructured,
+	fieldManager string,
+	opts ...kube.ApplyOption,
+) error {
+	client.created++
+	obj.SetName(fmt.Sprintf("%s%d", obj.GetGenerateName(), client.created))
+	client.objects[obj.GetName()] = struct{}{}
+	return nil
+}
+
+func (client *generatingClient) Get(
+	ctx context.Context,
+	obj *unstructured.Unstructured,
+) (*unstructured.Unstructured, error) {
+	if _, found := client.objects[obj.GetName()]; !found {
+		return nil, k8sErrors.NewNotFound(schema.GroupResource{Resource: obj.GetKind()}, obj.GetName())
+	}
+	return obj, nil
+}
+
+func (client *generatingClient) Delete(ctx context.Context, obj *unstructured.Unstructured) error {
+	delete(client.objects, obj.GetName())
+	return nil
+}
+
+func generatedJob(image string) *Manifest {
+	return &Manifest{
+		ID: "migration-_shop_batch_Job",
+		Content: unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "batch/v1",
+			"kind":       "Job",
+			"metadata": map[string]interface{}{
+				"generateName": "migration-",
+				"namespace":    "shop",
+			},
+			"spec": map[string]interface{}{
+				"image": image,
+			},
+		}},
+	}
+}
+
+func TestReconciler_Reconcile_GenerateName(t *testing.T) {
+	client := &generatingClient{
+		objects: make(map[string]struct{}),
+	}
+	reconciler := Reconciler{
+		Log:           logr.Discard(),
+		DynamicClient: client,
+		InventoryInstance: &inventory.Instance{
+			Path: t.TempDir(),
+		},
+	}
+	ctx := context.Background()
+	trackedName := func() string {
+		storage, err := reconciler.InventoryInstance.Load()
+		assert.NilError(t, err)
+		item, found := storage.Items()["migration-_shop_batch_Job"]
+		assert.Assert(t, found)
+		return item.GetName()
+	}
+
+	assert.NilError(t, reconciler.Reconcile(ctx, generatedJob("migrate:1")))
+	assert.Equal(t, client.created, 1)
+	assert.Equal(t, trackedName(), "migration-1")
+
+	// An unchanged declaration keeps the generated object.
+	assert.NilError(t, reconciler.Reconcile(ctx, generatedJob("migrate:1")))
+	assert.Equal(t, client.created, 1)
+	assert.Equal(t, trackedName(), "migration-1")
+
+	// A changed declaration replaces the generated object.
+	assert.NilError(t, reconciler.Reconcile(ctx, generatedJob("migrate:2")))
+	assert.Equal(t, client.created, 2)
+	assert.Equal(t, trackedName(), "migration-2")
+	assert.DeepEqual(t, client.objects, map[string]struct{}{"migration-2": {}})
+
+	// A deleted object is generated again.
+	delete(client.objects, "migration-2")
+	assert.NilError(t, reconciler.Reconcile(ctx, generatedJob("migrate:2")))
+	assert.Equal(t, client.created, 3)
+	assert.Equal(t, trackedName(), "migration-3")
+}
+
+// encodingClient records the encoding an object has been applied with.
+type encodingClient struct {
+	kube.Client[unstructured.Unstructured]
+	encoded []byte
+}
+
+func (client *encodingClient) Apply(
+	ctx context.Context,
+	obj *unstructured.Unstructured,
+	fieldManager string,
+	opts ...kube.ApplyOption,
+) error {
+	for _, opt := range opts {
+		if encoded, ok := opt.(kube.Encoded); ok {
+			client.encoded = encoded
+		}
+	}
+	return nil
+}
+
+func TestReconciler_Reconcile_Inventory(t *testing.T) {
+	client := &encodingClient{}
+	reconciler := Reconciler{
+		Log:           logr.Discard(),
+		DynamicClient: client,
+		InventoryInstance: &inventory.Instance{
+			Path: t.TempDir(),
+		},
+	}
+	manifest := &Manifest{
+		ID: "dashboards_monitoring__ConfigMap",
+		Content: unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata": map[string]interface{}{
+				"name":      "dashboards",
+				"namespace": "monitoring",
+			},
+			"data": map[string]interface{}{
+				"dashboard.json": strings.Repeat(`{"panels":[]}`, 1024),
+			},
+		}},
+	}
+
+	assert.NilError(t, reconciler.Reconcile(context.Background(), manifest))
+	assert.Assert(t, client.encoded != nil)
+
+	storage, err := reconciler.InventoryInstance.Load()
+	assert.NilError(t, err)
+	item, found := storage.Items()[manifest.ID]
+	assert.Assert(t, found)
+	assert.Equal(t, item.GetName(), "dashboards")
+	assert.Equal(t, item.GetNamespace(), "monitoring")
+
+	reader, err := reconciler.InventoryInstance.GetItem(item)
+	assert.NilError(t, err)
+	defer reader.Close()
+	stored, err := io.ReadAll(reader)
+	assert.NilError(t, err)
+	// The inventory stores exactly what has been applied.
+	assert.Equal(t, string(stored), string(client.encoded))
+
+	var object map[string]interface{}
+	assert.NilError(t, json.Unmarshal(stored, &object))
+	assert.DeepEqual(t, object, manifest.Content.Object)
+}
+
+func TestReconciler_label(t *testing.T) {
+	manifest := func(name string, labels map[string]string, annotations map[string]string) *Manifest {
+		content := unstructured.Unstructured{}
+		content.SetName(name)
+		content.SetLabels(labels)
+		content.SetAnnotations(annotations)
+		return &Manifest{Content: content}
+	}
+
+	testCases := []struct {
+		name     string
+		project  string
+		manifest *Manifest
+		expected map[string]string
+	}{
+		{
+			name:     "Labeled",
+			project:  "shop",
+			manifest: manifest("api", map[string]string{"app": "api"}, nil),
+			expected: map[string]string{
+				"app":          "api",
+				ManagedByLabel: ManagedByValue,
+				ProjectLabel:   "shop",
+			},
+		},
+		{
+			name:     "DeclaredManagedBy",
+			project:  "shop",
+			manifest: manifest("api", map[string]string{ManagedByLabel: "kustomize"}, nil),
+			expected: map[string]string{
+				ManagedByLabel: "kustomize",
+				ProjectLabel:   "shop",
+			},
+		},
+		{
+			name:     "NoProject",
+			manifest: manifest("api", nil, nil),
+		},
+		{
+			name:     "Generated",
+			project:  "shop",
+			manifest: manifest("", nil, nil),
+		},
+		{
+			name:     "Versioned",
+			project:  "shop",
+			manifest: manifest("config-1", nil, map[string]string{VersionedAnnotation: "1h"}),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			reconciler := Reconciler{Project: tc.project}
+			reconciler.label(tc.manifest)
+			assert.DeepEqual(t, tc.manifest.Content.GetLabels(), tc.expected)
+		})
+	}
+}
+
+// jobClient runs applied Jobs until they reach the given condition. Jobs without a condition never finish.
+type jobClient struct {
+	kube.Client[unstructured.Unstructured]
+	condition string
+	objects   map[string]*unstructured.Unstructured
+	deleted   int
+}
+
+func (client *jobClient) Apply(
+	ctx context.Context,
+	obj *unstructured.Unstructured,
+	fieldManager string,
+	opts ...kube.ApplyOption,
+) error {
+	applied := obj.DeepCopy()
+	if client.condition != "" {
+		applied.Object["status"] = map[string]interface{}{
+			"conditions": []interface{}{
+				map[string]interface{}{
+					"type":    client.condition,
+					"status":  "True",
+					"message": "BackoffLimitExceeded",
+				},
+			},
+		}
+	}
+	client.objects[obj.GetName()] = applied
+	return nil
+}
+
+func (client *jobClient) Get(
+	ctx context.Context,
+	obj *unstructured.Unstructured,
+) (*unstructured.Unstructured, error) {
+	applied, found := client.objects[obj.GetName()]
+	if !found {
+		return nil, k8sErrors.NewNotFound(schema.GroupResource{Resource: obj.GetKind()}, obj.GetName())
+	}
+	return applied, nil
+}
+
+func (client *jobClient) Delete(ctx context.Context, obj *unstructured.Unstructured) error {
+	if _, found := client.objects[obj.GetName()]; found {
+		client.deleted++
+	}
+	delete(client.objects, obj.GetName())
+	return nil
+}
+
+func TestReconciler_Reconcile_Hook(t *testing.T) {
+	job := unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "batch/v1",
+		"kind":       "Job",
+		"metadata": map[string]interface{}{
+			"name":      "backup",
+			"namespace": "shop",
+		},
+	}}
+	configMap := unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata": map[string]interface{}{
+			"name":      "maintenance",
+			"namespace": "shop",
+		},
+	}}
+
+	testCases := []struct {
+		name      string
+		id        string
+		content   unstructured.Unstructured
+		condition string
+		err       error
+	}{
+		{
+			name:      "Complete",
+			id:        "backup_shop_batch_Job",
+			content:   job,
+			condition: "Complete",
+		},
+		{
+			name:      "Failed",
+			id:        "backup_shop_batch_Job",
+			content:   job,
+			condition: "Failed",
+			err:       ErrHookFailed,
+		},
+		{
+			name:    "Timeout",
+			id:      "backup_shop_batch_Job",
+			content: job,
+			err:     context.DeadlineExceeded,
+		},
+		{
+			name:    "NotAwaited",
+			id:      "maintenance_shop__ConfigMap",
+			content: configMap,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := &jobClient{
+				condition: tc.condition,
+				objects:   make(map[string]*unstructured.Unstructured),
+			}
+			reconciler := Reconciler{
+				Log:           logr.Discard(),
+				DynamicClient: client,
+				InventoryInstance: &inventory.Instance{
+					Path: t.TempDir(),
+				},
+			}
+			hook := &Hook{
+				ID:      tc.id,
+				Phase:   PreApply,
+				Timeout: 100 * time.Millisecond,
+				Content: *tc.content.DeepCopy(),
+			}
+			ctx := context.Background()
+
+			err := reconciler.Reconcile(ctx, hook)
+			if tc.err != nil {
+				assert.ErrorIs(t, err, ErrHookFailed)
+				assert.ErrorIs(t, err, tc.err)
+				return
+			}
+			assert.NilError(t, err)
+			storage, err := reconciler.InventoryInstance.Load()
+			assert.NilError(t, err)
+			_, found := storage.Items()[tc.id]
+			assert.Assert(t, found)
+
+			// Hooks are recreated on every run.
+			assert.NilError(t, reconciler.Reconcile(ctx, hook))
+			assert.Equal(t, client.deleted, 1)
+		})
+	}
+}
+
+func TestReconciler_Reconcile_HTTPHook(t *testing.T) {
+	var received *http.Request
+	var receivedBody string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received = r
+		receivedBody = string(body)
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	reconciler := Reconciler{
+		Log:        logr.Discard(),
+		HTTPClient: server.Client(),
+	}
+	ctx := context.Background()
+
+	hook := &HTTPHook{
+		ID:      "flush_HTTPHook",
+		Phase:   PostApply,
+		Timeout: time.Second,
+		Request: HTTPRequest{
+			URL:     server.URL + "/flush",
+			Method:  http.MethodPost,
+			Headers: map[string]string{"Authorization": "Bearer token"},
+			Body:    `{"cache":"all"}`,
+		},
+	}
+	assert.NilError(t, reconciler.Reconcile(ctx, hook))
+	assert.Equal(t, received.Method, http.MethodPost)
+	assert.Equal(t, received.URL.Path, "/flush")
+	assert.Equal(t, received.Header.Get("Authorization"), "Bearer token")
+	assert.Equal(t, receivedBody, `{"cache":"all"}`)
+
+	hook.Request.URL = server.URL + "/fail"
+	err := reconciler.Reconcile(ctx, hook)
+	assert.ErrorIs(t, err, ErrHookFailed)
+	assert.ErrorContains(t, err, "503")
+}
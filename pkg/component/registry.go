@@ -0,0 +1,127 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package component
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"cuelang.org/go/cue"
+)
+
+var (
+	ErrReservedType       = errors.New("Component type is reserved")
+	ErrTypeRegistered     = errors.New("Component type already registered")
+	ErrUnknownType        = errors.New("Unknown component type")
+	ErrTypeMismatch       = errors.New("Component type mismatch")
+	reservedComponentType = map[string]struct{}{
+		"Manifest":         {},
+		"Hook":             {},
+		"HTTPHook":         {},
+		"HelmRelease":      {},
+		"Matrix":           {},
+		"Kustomization":    {},
+		"ManifestSequence": {},
+		"Notification":     {},
+	}
+)
+
+// CustomInstance is a component of a type registered in a [Registry], e.g. TerraformRun or DatabaseMigration.
+type CustomInstance interface {
+	Instance
+	// GetType returns the component type, which is the value of the type field in CUE.
+	GetType() string
+}
+
+// TypeHandler extends Declcd with a custom component type.
+//
+// Custom instances are not recorded in the inventory and are therefore never pruned:
+// when a custom component is removed from a project, everything its handler applied stays in place.
+// Handlers owning external state have to clean it up themselves, e.g. by reconciling an explicit absent state.
+type TypeHandler interface {
+	// Decode translates the CUE value of a component into an instance.
+	// The returned instance has to report the same type the handler is registered for.
+	Decode(value cue.Value) (CustomInstance, error)
+
+	// Reconcile applies an instance previously decoded by this handler.
+	// It is only called for declared instances, never for removed ones.
+	Reconcile(ctx context.Context, instance CustomInstance) error
+}
+
+// Registry holds the handlers of custom component types.
+// It is safe for concurrent use.
+type Registry struct {
+	mu       sync.RWMutex
+	handlers map[string]TypeHandler
+}
+
+// NewRegistry contructs an empty [Registry].
+func NewRegistry() *Registry {
+	return &Registry{
+		handlers: make(map[string]TypeHandler),
+	}
+}
+
+// Register adds a handler for given component type.
+// Built-in types cannot be overridden and every type can only be registered once.
+func (registry *Registry) Register(componentType string, handler TypeHandler) error {
+	if _, found := reservedComponentType[componentType]; found {
+		return fmt.Errorf("%w: %s", ErrReservedType, componentType)
+	}
+
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	if _, found := registry.handlers[componentType]; found {
+		return fmt.Errorf("%w: %s", ErrTypeRegistered, componentType)
+	}
+	registry.handlers[componentType] = handler
+	return nil
+}
+
+// Lookup returns the handler of given component type.
+func (registry *Registry) Lookup(componentType string) (TypeHandler, bool) {
+	if registry == nil {
+		return nil, false
+	}
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+	handler, found := registry.handlers[componentType]
+	return handler, found
+}
+
+func (registry *Registry) decode(componentType string, value cue.Value) (Instance, error) {
+	handler, found := registry.Lookup(componentType)
+	if !found {
+		return nil, nil
+	}
+	instance, err := handler.Decode(value)
+	if err != nil {
+		return nil, err
+	}
+	if instance.GetType() != componentType {
+		return nil, fmt.Errorf("%w: expected %s, got %s", ErrTypeMismatch, componentType, instance.GetType())
+	}
+	return instance, nil
+}
+
+func (registry *Registry) reconcile(ctx context.Context, instance CustomInstance) error {
+	handler, found := registry.Lookup(instance.GetType())
+	if !found {
+		return fmt.Errorf("%w: %s", ErrUnknownType, instance.GetType())
+	}
+	return handler.Reconcile(ctx, instance)
+}
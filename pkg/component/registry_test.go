@@ -0,0 +1,98 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package component
+
+import (
+	"context"
+	"testing"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/cuecontext"
+	"github.com/go-logr/logr"
+	"gotest.tools/v3/assert"
+)
+
+type migration struct {
+	ID           string   `json:"id"`
+	Dependencies []string `json:"dependencies"`
+	Database     string   `json:"database"`
+}
+
+func (m *migration) GetID() string {
+	return m.ID
+}
+
+func (m *migration) GetDependencies() []string {
+	return m.Dependencies
+}
+
+func (m *migration) GetType() string {
+	return "DatabaseMigration"
+}
+
+type migrationHandler struct {
+	reconciled []string
+}
+
+func (h *migrationHandler) Decode(value cue.Value) (CustomInstance, error) {
+	var m migration
+	if err := value.Decode(&m); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+func (h *migrationHandler) Reconcile(ctx context.Context, instance CustomInstance) error {
+	h.reconciled = append(h.reconciled, instance.(*migration).Database)
+	return nil
+}
+
+func TestRegistry(t *testing.T) {
+	registry := NewRegistry()
+	handler := &migrationHandler{}
+	err := registry.Register("DatabaseMigration", handler)
+	assert.NilError(t, err)
+
+	err = registry.Register("DatabaseMigration", handler)
+	assert.ErrorIs(t, err, ErrTypeRegistered)
+
+	err = registry.Register("Manifest", handler)
+	assert.ErrorIs(t, err, ErrReservedType)
+
+	value := cuecontext.New().CompileString(`
+type:     "DatabaseMigration"
+id:       "users_DatabaseMigration"
+dependencies: []
+database: "users"
+`)
+	assert.NilError(t, value.Err())
+
+	builder := Builder{Registry: registry}
+	instance, err := builder.decodeInstance(value)
+	assert.NilError(t, err)
+	assert.Equal(t, instance.GetID(), "users_DatabaseMigration")
+
+	reconciler := Reconciler{
+		Log:      logr.Discard(),
+		Registry: registry,
+	}
+	err = reconciler.Reconcile(context.Background(), instance)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, handler.reconciled, []string{"users"})
+
+	instance, err = NewBuilder().decodeInstance(value)
+	assert.NilError(t, err)
+	assert.Assert(t, instance == nil)
+}
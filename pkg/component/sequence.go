@@ -0,0 +1,90 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package component
+
+import (
+	"slices"
+
+	"github.com/kharf/declcd/pkg/helm"
+	"github.com/kharf/declcd/pkg/promotion"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// ManifestSequence is an ordered list of objects, which is built into Manifests applied one after another.
+// Like a Kustomization, it does not apply anything itself, but depends on all of its Manifests.
+type ManifestSequence struct {
+	ID           string
+	Dependencies []string
+
+	// Objects are the ids of the Manifests in the order they are applied.
+	Objects []string
+
+	// Wait optionally blocks every Manifest until its predecessor is ready.
+	// Without it, the Manifests are applied in order without waiting for readiness.
+	Wait *helm.Wait
+
+	// Promotion optionally defers applying new versions of all objects of the sequence.
+	Promotion promotion.Policy
+
+	// FieldManager optionally overrides the field manager of the controller all objects are applied with.
+	FieldManager string
+
+	// ServiceAccountName optionally names the service account in the project namespace all objects are applied as.
+	ServiceAccountName string
+
+	contents  []map[string]interface{}
+	manifests []*Manifest
+}
+
+var _ Instance = (*ManifestSequence)(nil)
+
+func (s *ManifestSequence) GetID() string {
+	return s.ID
+}
+
+func (s *ManifestSequence) GetDependencies() []string {
+	return s.Dependencies
+}
+
+// expandManifestSequence returns a Manifest for every object, which depends on its predecessor,
+// followed by the sequence itself.
+// The first Manifest inherits the dependencies of the sequence.
+func expandManifestSequence(sequence *ManifestSequence) []Instance {
+	instances := make([]Instance, 0, len(sequence.contents)+1)
+	manifests := make([]*Manifest, 0, len(sequence.contents))
+	manifestIDs := make([]string, 0, len(sequence.contents))
+	dependencies := slices.Clone(sequence.Dependencies)
+	for _, content := range sequence.contents {
+		manifest := &Manifest{
+			Content: unstructured.Unstructured{
+				Object: content,
+			},
+			Dependencies:       dependencies,
+			Wait:               sequence.Wait,
+			FieldManager:       sequence.FieldManager,
+			ServiceAccountName: sequence.ServiceAccountName,
+		}
+		manifest.ID = manifestID(&manifest.Content)
+		dependencies = []string{manifest.ID}
+		instances = append(instances, manifest)
+		manifests = append(manifests, manifest)
+		manifestIDs = append(manifestIDs, manifest.ID)
+	}
+
+	sequence.Objects = manifestIDs
+	sequence.manifests = manifests
+	sequence.Dependencies = append(slices.Clone(sequence.Dependencies), manifestIDs...)
+	return append(instances, sequence)
+}
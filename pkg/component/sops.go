@@ -0,0 +1,218 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package component
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+)
+
+var (
+	ErrSOPSDecrypterMissing = errors.New("SOPS encrypted component, but no SOPS keys configured")
+	ErrSOPSDataKey          = errors.New("Unable to decrypt SOPS data key")
+	ErrSOPSValue            = errors.New("Unable to decrypt SOPS value")
+)
+
+// SOPSMetadataField is the field of a manifest content holding the metadata written by SOPS.
+// It is present, when a manifest was encrypted with 'sops --encrypt' and imported into CUE.
+const SOPSMetadataField = "sops"
+
+var sopsValuePattern = regexp.MustCompile(`^ENC\[AES256_GCM,data:(.+),iv:(.+),tag:(.+),type:(.+)\]$`)
+
+// SOPSDecrypter decrypts the values of SOPS encrypted manifests with the data key,
+// which is encrypted for one of its PGP keys.
+// The message authentication code of the document is not verified,
+// as every value is authenticated together with its path by AES-GCM.
+type SOPSDecrypter struct {
+	keyRing openpgp.EntityList
+}
+
+// NewSOPSDecrypter reads all armored or binary PGP private keys in the given directory,
+// e.g. a mounted Secret. A missing directory results in a decrypter without keys.
+func NewSOPSDecrypter(keyDir string) (*SOPSDecrypter, error) {
+	entries, err := os.ReadDir(keyDir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return &SOPSDecrypter{}, nil
+		}
+		return nil, err
+	}
+
+	var keyRing openpgp.EntityList
+	for _, entry := range entries {
+		// Secret volumes link their keys to hidden directories.
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(keyDir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		keys, err := openpgp.ReadArmoredKeyRing(bytes.NewReader(content))
+		if err != nil {
+			keys, err = openpgp.ReadKeyRing(bytes.NewReader(content))
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", entry.Name(), err)
+			}
+		}
+		keyRing = append(keyRing, keys...)
+	}
+
+	return &SOPSDecrypter{keyRing: keyRing}, nil
+}
+
+// Decrypt replaces all encrypted values of the content in place and removes the SOPS metadata.
+// Content without SOPS metadata is left untouched.
+func (decrypter *SOPSDecrypter) Decrypt(content map[string]interface{}) error {
+	metadata, ok := content[SOPSMetadataField].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	dataKey, err := decrypter.dataKey(metadata)
+	if err != nil {
+		return err
+	}
+
+	delete(content, SOPSMetadataField)
+	for key, value := range content {
+		decrypted, err := decryptValue(value, []string{key}, dataKey)
+		if err != nil {
+			return err
+		}
+		content[key] = decrypted
+	}
+
+	return nil
+}
+
+func (decrypter *SOPSDecrypter) dataKey(metadata map[string]interface{}) ([]byte, error) {
+	pgpKeys, _ := metadata["pgp"].([]interface{})
+	for _, pgpKey := range pgpKeys {
+		pgpKey, ok := pgpKey.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		enc, ok := pgpKey["enc"].(string)
+		if !ok {
+			continue
+		}
+		block, err := armor.Decode(strings.NewReader(enc))
+		if err != nil {
+			continue
+		}
+		message, err := openpgp.ReadMessage(block.Body, decrypter.keyRing, nil, nil)
+		if err != nil {
+			continue
+		}
+		dataKey, err := io.ReadAll(message.UnverifiedBody)
+		if err != nil {
+			continue
+		}
+		return dataKey, nil
+	}
+	return nil, fmt.Errorf("%w: none of the %d PGP keys is available", ErrSOPSDataKey, len(pgpKeys))
+}
+
+// decryptValue walks the value like SOPS, which authenticates every value with the path of its keys.
+// List items share the path of the list.
+func decryptValue(value interface{}, path []string, dataKey []byte) (interface{}, error) {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		for key, nested := range value {
+			decrypted, err := decryptValue(nested, append(path[:len(path):len(path)], key), dataKey)
+			if err != nil {
+				return nil, err
+			}
+			value[key] = decrypted
+		}
+		return value, nil
+	case []interface{}:
+		for i, nested := range value {
+			decrypted, err := decryptValue(nested, path, dataKey)
+			if err != nil {
+				return nil, err
+			}
+			value[i] = decrypted
+		}
+		return value, nil
+	case string:
+		if !sopsValuePattern.MatchString(value) {
+			return value, nil
+		}
+		decrypted, err := decryptSOPSValue(value, strings.Join(path, ":")+":", dataKey)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %w", ErrSOPSValue, strings.Join(path, "."), err)
+		}
+		return decrypted, nil
+	default:
+		return value, nil
+	}
+}
+
+func decryptSOPSValue(value string, additionalData string, dataKey []byte) (interface{}, error) {
+	matches := sopsValuePattern.FindStringSubmatch(value)
+	data, err := base64.StdEncoding.DecodeString(matches[1])
+	if err != nil {
+		return nil, err
+	}
+	iv, err := base64.StdEncoding.DecodeString(matches[2])
+	if err != nil {
+		return nil, err
+	}
+	tag, err := base64.StdEncoding.DecodeString(matches[3])
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCMWithNonceSize(block, len(iv))
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := gcm.Open(nil, iv, append(data, tag...), []byte(additionalData))
+	if err != nil {
+		return nil, err
+	}
+
+	switch valueType := matches[4]; valueType {
+	case "str", "bytes":
+		return string(plaintext), nil
+	case "int":
+		return strconv.ParseInt(string(plaintext), 10, 64)
+	case "float":
+		return strconv.ParseFloat(string(plaintext), 64)
+	case "bool":
+		return strconv.ParseBool(string(plaintext))
+	default:
+		return nil, fmt.Errorf("unknown type %s", valueType)
+	}
+}
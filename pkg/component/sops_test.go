@@ -0,0 +1,210 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package component_test
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/kharf/declcd/pkg/component"
+	"gotest.tools/v3/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// newSOPSKey creates a PGP key and a SOPS data key encrypted with it.
+func newSOPSKey(t *testing.T) (*component.SOPSDecrypter, []byte, map[string]interface{}) {
+	entity, err := openpgp.NewEntity("declcd", "", "declcd@example.com", nil)
+	assert.NilError(t, err)
+
+	keyDir := t.TempDir()
+	var privateKey bytes.Buffer
+	armorWriter, err := armor.Encode(&privateKey, openpgp.PrivateKeyType, nil)
+	assert.NilError(t, err)
+	assert.NilError(t, entity.SerializePrivate(armorWriter, nil))
+	assert.NilError(t, armorWriter.Close())
+	assert.NilError(t, os.WriteFile(filepath.Join(keyDir, "key.asc"), privateKey.Bytes(), 0600))
+
+	dataKey := make([]byte, 32)
+	_, err = rand.Read(dataKey)
+	assert.NilError(t, err)
+	var encryptedDataKey bytes.Buffer
+	armorWriter, err = armor.Encode(&encryptedDataKey, "PGP MESSAGE", nil)
+	assert.NilError(t, err)
+	plaintextWriter, err := openpgp.Encrypt(armorWriter, []*openpgp.Entity{entity}, nil, nil, nil)
+	assert.NilError(t, err)
+	_, err = plaintextWriter.Write(dataKey)
+	assert.NilError(t, err)
+	assert.NilError(t, plaintextWriter.Close())
+	assert.NilError(t, armorWriter.Close())
+
+	metadata := map[string]interface{}{
+		"pgp": []interface{}{
+			map[string]interface{}{
+				"fp":  fmt.Sprintf("%X", entity.PrimaryKey.Fingerprint),
+				"enc": encryptedDataKey.String(),
+			},
+		},
+	}
+
+	decrypter, err := component.NewSOPSDecrypter(keyDir)
+	assert.NilError(t, err)
+	return decrypter, dataKey, metadata
+}
+
+func TestSOPSDecrypter_Decrypt(t *testing.T) {
+	decrypter, dataKey, metadata := newSOPSKey(t)
+
+	testCases := []struct {
+		name     string
+		content  map[string]interface{}
+		expected map[string]interface{}
+		err      error
+	}{
+		{
+			name: "Plain",
+			content: map[string]interface{}{
+				"kind": "Secret",
+			},
+			expected: map[string]interface{}{
+				"kind": "Secret",
+			},
+		},
+		{
+			name: "Encrypted",
+			content: map[string]interface{}{
+				"kind": "Secret",
+				"stringData": map[string]interface{}{
+					"password": encryptValue(t, dataKey, "secret", "str", "stringData:password:"),
+				},
+				"spec": map[string]interface{}{
+					"ports": []interface{}{
+						encryptValue(t, dataKey, "8080", "int", "spec:ports:"),
+					},
+				},
+				"sops": metadata,
+			},
+			expected: map[string]interface{}{
+				"kind": "Secret",
+				"stringData": map[string]interface{}{
+					"password": "secret",
+				},
+				"spec": map[string]interface{}{
+					"ports": []interface{}{
+						int64(8080),
+					},
+				},
+			},
+		},
+		{
+			name: "WrongPath",
+			content: map[string]interface{}{
+				"data": map[string]interface{}{
+					"password": encryptValue(t, dataKey, "secret", "str", "stringData:password:"),
+				},
+				"sops": metadata,
+			},
+			err: component.ErrSOPSValue,
+		},
+		{
+			name: "UnknownKey",
+			content: map[string]interface{}{
+				"sops": map[string]interface{}{
+					"pgp": []interface{}{},
+				},
+			},
+			err: component.ErrSOPSDataKey,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := decrypter.Decrypt(tc.content)
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+				return
+			}
+			assert.NilError(t, err)
+			assert.DeepEqual(t, tc.content, tc.expected)
+		})
+	}
+}
+
+func TestBuilder_Decrypt(t *testing.T) {
+	decrypter, dataKey, metadata := newSOPSKey(t)
+
+	newSecret := func() *component.Manifest {
+		return &component.Manifest{
+			ID: "db_shop__Secret",
+			Content: unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"apiVersion": "v1",
+					"kind":       "Secret",
+					"stringData": map[string]interface{}{
+						"password": encryptValue(t, dataKey, "secret", "str", "stringData:password:"),
+					},
+					"sops": metadata,
+				},
+			},
+		}
+	}
+
+	secret := newSecret()
+	encrypted := secret.Content.DeepCopy()
+	builder := component.Builder{Decrypter: decrypter, KeepEncrypted: true}
+	assert.NilError(t, builder.Decrypt(secret))
+	assert.Assert(t, !secret.Decrypted)
+	assert.DeepEqual(t, secret.Content.Object, encrypted.Object)
+	_, err := json.Marshal(component.BuildResult{Instances: []component.Instance{secret}})
+	assert.NilError(t, err)
+
+	secret = newSecret()
+	builder = component.Builder{Decrypter: decrypter}
+	assert.NilError(t, builder.Decrypt(secret))
+	assert.Assert(t, secret.Decrypted)
+	password, _, err := unstructured.NestedString(secret.Content.Object, "stringData", "password")
+	assert.NilError(t, err)
+	assert.Equal(t, password, "secret")
+	_, err = json.Marshal(component.BuildResult{Instances: []component.Instance{secret}})
+	assert.ErrorIs(t, err, component.ErrDecryptedArtifactInstance)
+}
+
+// encryptValue encrypts a value the way 'sops --encrypt' does.
+func encryptValue(t *testing.T, dataKey []byte, value string, valueType string, additionalData string) string {
+	block, err := aes.NewCipher(dataKey)
+	assert.NilError(t, err)
+	iv := make([]byte, 32)
+	_, err = rand.Read(iv)
+	assert.NilError(t, err)
+	gcm, err := cipher.NewGCMWithNonceSize(block, len(iv))
+	assert.NilError(t, err)
+	sealed := gcm.Seal(nil, iv, []byte(value), []byte(additionalData))
+	data, tag := sealed[:len(sealed)-gcm.Overhead()], sealed[len(sealed)-gcm.Overhead():]
+	return fmt.Sprintf(
+		"ENC[AES256_GCM,data:%s,iv:%s,tag:%s,type:%s]",
+		base64.StdEncoding.EncodeToString(data),
+		base64.StdEncoding.EncodeToString(iv),
+		base64.StdEncoding.EncodeToString(tag),
+		valueType,
+	)
+}
@@ -0,0 +1,103 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package component
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/encoding/yaml"
+	"github.com/kharf/declcd/pkg/helm"
+)
+
+var (
+	ErrValuesFilePath        = errors.New("Values file must be relative to the project root")
+	ErrUnsupportedValuesFile = errors.New("Unsupported values file")
+)
+
+// loadValuesFiles unifies the declared values of a HelmRelease with the contents of its valuesFiles
+// and validates the result against its valuesSchema.
+// Files are read relative to the project root. CUE files have to be self-contained, as imports are not resolved.
+func loadValuesFiles(projectRoot string, componentValue cue.Value, release *helm.ReleaseComponent) error {
+	var valuesFiles []string
+	if filesValue := componentValue.LookupPath(cue.ParsePath("valuesFiles")); filesValue.Exists() {
+		if err := filesValue.Decode(&valuesFiles); err != nil {
+			return fmt.Errorf("%s: %w", release.ID, err)
+		}
+	}
+	var valuesSchema string
+	if schemaValue := componentValue.LookupPath(cue.ParsePath("valuesSchema")); schemaValue.Exists() {
+		if err := schemaValue.Decode(&valuesSchema); err != nil {
+			return fmt.Errorf("%s: %w", release.ID, err)
+		}
+	}
+	if len(valuesFiles) == 0 && valuesSchema == "" {
+		return nil
+	}
+
+	values := componentValue.LookupPath(cue.ParsePath("values"))
+	for _, valuesFile := range valuesFiles {
+		fileValue, err := compileValuesFile(componentValue.Context(), projectRoot, valuesFile)
+		if err != nil {
+			return fmt.Errorf("%s: %w", release.ID, err)
+		}
+		values = values.Unify(fileValue)
+	}
+	if valuesSchema != "" {
+		schema, err := compileValuesFile(componentValue.Context(), projectRoot, valuesSchema)
+		if err != nil {
+			return fmt.Errorf("%s: %w", release.ID, err)
+		}
+		values = schema.Unify(values)
+	}
+	if err := values.Validate(cue.Concrete(true)); err != nil {
+		return fmt.Errorf("%s: %w", release.ID, err)
+	}
+
+	var decoded helm.Values
+	if err := values.Decode(&decoded); err != nil {
+		return fmt.Errorf("%s: %w", release.ID, err)
+	}
+	release.Content.Values = decoded
+	return nil
+}
+
+func compileValuesFile(ctx *cue.Context, projectRoot string, valuesFile string) (cue.Value, error) {
+	if !filepath.IsLocal(valuesFile) {
+		return cue.Value{}, fmt.Errorf("%w: %s", ErrValuesFilePath, valuesFile)
+	}
+	content, err := os.ReadFile(filepath.Join(projectRoot, valuesFile))
+	if err != nil {
+		return cue.Value{}, err
+	}
+
+	var value cue.Value
+	switch filepath.Ext(valuesFile) {
+	case ".cue":
+		value = ctx.CompileBytes(content, cue.Filename(valuesFile))
+	case ".yaml", ".yml", ".json":
+		file, err := yaml.Extract(valuesFile, content)
+		if err != nil {
+			return cue.Value{}, err
+		}
+		value = ctx.BuildFile(file)
+	default:
+		return cue.Value{}, fmt.Errorf("%w: %s", ErrUnsupportedValuesFile, valuesFile)
+	}
+	return value, value.Err()
+}
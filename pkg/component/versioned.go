@@ -0,0 +1,337 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package component
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"cuelang.org/go/cue"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+var (
+	ErrUnsupportedVersioning = errors.New("Unsupported versioning")
+)
+
+const (
+	// VersionedAnnotation is set on ConfigMaps and Secrets declared with the @versioned attribute.
+	// It holds the retention of superseded versions and is persisted with the manifest.
+	VersionedAnnotation = "declcd/versioned"
+
+	// VersionsAnnotation lists the superseded versions of a versioned manifest, which are still retained.
+	VersionsAnnotation = "declcd/versions"
+
+	// DefaultVersionRetention is how long superseded versions are kept,
+	// so that workloads which have not been rolled out yet can still mount them.
+	DefaultVersionRetention = time.Hour
+)
+
+// SupersededVersion is a previous version of a versioned ConfigMap or Secret.
+type SupersededVersion struct {
+	Name         string    `json:"name"`
+	SupersededAt time.Time `json:"supersededAt"`
+}
+
+// SupersededVersions reads the retained versions of a versioned manifest.
+func SupersededVersions(obj *unstructured.Unstructured) ([]SupersededVersion, error) {
+	content, found := obj.GetAnnotations()[VersionsAnnotation]
+	if !found {
+		return nil, nil
+	}
+	var versions []SupersededVersion
+	if err := json.Unmarshal([]byte(content), &versions); err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
+
+// readVersioning returns the retention of the @versioned attribute of a component field.
+// It is empty for components without the attribute.
+func readVersioning(componentValue cue.Value) (string, error) {
+	attr := componentValue.Attribute("versioned")
+	if attr.Err() != nil {
+		return "", nil
+	}
+	retention, found, err := attr.Lookup(0, "retention")
+	if err != nil {
+		return "", err
+	}
+	if !found {
+		return DefaultVersionRetention.String(), nil
+	}
+	if _, err := time.ParseDuration(retention); err != nil {
+		return "", fmt.Errorf("%w: retention: %w", ErrUnsupportedVersioning, err)
+	}
+	return retention, nil
+}
+
+// markVersioned annotates a ConfigMap or Secret to be versioned by its content.
+func markVersioned(instance Instance, retention string) error {
+	manifest, ok := instance.(*Manifest)
+	if !ok {
+		return fmt.Errorf("%w: %s is not a manifest", ErrUnsupportedVersioning, instance.GetID())
+	}
+	if _, ok := versionedKind(&manifest.Content); !ok {
+		return fmt.Errorf("%w: %s is neither a ConfigMap nor a Secret", ErrUnsupportedVersioning, instance.GetID())
+	}
+	if manifest.Content.GetName() == "" {
+		return fmt.Errorf("%w: %s uses generateName", ErrUnsupportedVersioning, instance.GetID())
+	}
+	annotations := manifest.Content.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string, 1)
+	}
+	annotations[VersionedAnnotation] = retention
+	manifest.Content.SetAnnotations(annotations)
+	return nil
+}
+
+func versionedKind(obj *unstructured.Unstructured) (string, bool) {
+	gvk := obj.GroupVersionKind()
+	if gvk.Group != "" || (gvk.Kind != "ConfigMap" && gvk.Kind != "Secret") {
+		return "", false
+	}
+	return gvk.Kind, true
+}
+
+// versionManifests suffixes the names of versioned ConfigMaps and Secrets with a hash of their content
+// and updates the references of all workloads of the same package and namespace.
+// Component IDs keep the declared name, so that a version replaces its predecessor in the inventory.
+func versionManifests(instances []Instance) error {
+	// namespace -> kind -> declared name -> versioned name
+	versions := make(map[string]map[string]map[string]string)
+	for _, instance := range instances {
+		manifest, ok := instance.(*Manifest)
+		if !ok {
+			continue
+		}
+		if _, found := manifest.Content.GetAnnotations()[VersionedAnnotation]; !found {
+			continue
+		}
+		kind, _ := versionedKind(&manifest.Content)
+		hash, err := contentHash(&manifest.Content)
+		if err != nil {
+			return err
+		}
+		name := manifest.Content.GetName()
+		versionedName := fmt.Sprintf("%s-%s", name, hash)
+		manifest.Content.SetName(versionedName)
+
+		namespace := manifest.Content.GetNamespace()
+		if versions[namespace] == nil {
+			versions[namespace] = map[string]map[string]string{
+				"ConfigMap": {},
+				"Secret":    {},
+			}
+		}
+		versions[namespace][kind][name] = versionedName
+	}
+	if len(versions) == 0 {
+		return nil
+	}
+
+	for _, instance := range instances {
+		var content *unstructured.Unstructured
+		switch instance := instance.(type) {
+		case *Manifest:
+			content = &instance.Content
+		case *Hook:
+			content = &instance.Content
+		default:
+			continue
+		}
+		namespaceVersions, found := versions[content.GetNamespace()]
+		if !found {
+			continue
+		}
+		if podSpec := findPodSpec(content); podSpec != nil {
+			rewritePodSpec(podSpec, namespaceVersions["ConfigMap"], namespaceVersions["Secret"])
+		}
+	}
+	return nil
+}
+
+func contentHash(obj *unstructured.Unstructured) (string, error) {
+	data := make(map[string]interface{}, 3)
+	for _, field := range []string{"data", "binaryData", "stringData"} {
+		if value, found := obj.Object[field]; found {
+			data[field] = value
+		}
+	}
+	content, err := json.Marshal(data)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])[:10], nil
+}
+
+var podSpecPaths = map[string][]string{
+	"Pod":         {"spec"},
+	"Deployment":  {"spec", "template", "spec"},
+	"StatefulSet": {"spec", "template", "spec"},
+	"DaemonSet":   {"spec", "template", "spec"},
+	"ReplicaSet":  {"spec", "template", "spec"},
+	"Job":         {"spec", "template", "spec"},
+	"CronJob":     {"spec", "jobTemplate", "spec", "template", "spec"},
+}
+
+// findPodSpec returns the pod spec of a workload without copying it.
+func findPodSpec(obj *unstructured.Unstructured) map[string]interface{} {
+	path, found := podSpecPaths[obj.GetKind()]
+	if !found {
+		return nil
+	}
+	current := obj.Object
+	for _, field := range path {
+		next, ok := current[field].(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		current = next
+	}
+	return current
+}
+
+func rewritePodSpec(podSpec map[string]interface{}, configMaps map[string]string, secrets map[string]string) {
+	for _, volume := range objects(podSpec["volumes"]) {
+		rename(volume, configMaps, "configMap", "name")
+		rename(volume, secrets, "secret", "secretName")
+		projected, _ := volume["projected"].(map[string]interface{})
+		for _, source := range objects(projected["sources"]) {
+			rename(source, configMaps, "configMap", "name")
+			rename(source, secrets, "secret", "name")
+		}
+	}
+	for _, containersField := range []string{"containers", "initContainers", "ephemeralContainers"} {
+		for _, container := range objects(podSpec[containersField]) {
+			for _, envFrom := range objects(container["envFrom"]) {
+				rename(envFrom, configMaps, "configMapRef", "name")
+				rename(envFrom, secrets, "secretRef", "name")
+			}
+			for _, env := range objects(container["env"]) {
+				rename(env, configMaps, "valueFrom", "configMapKeyRef", "name")
+				rename(env, secrets, "valueFrom", "secretKeyRef", "name")
+			}
+		}
+	}
+	for _, pullSecret := range objects(podSpec["imagePullSecrets"]) {
+		rename(pullSecret, secrets, "name")
+	}
+}
+
+func objects(field interface{}) []map[string]interface{} {
+	list, _ := field.([]interface{})
+	objs := make([]map[string]interface{}, 0, len(list))
+	for _, element := range list {
+		if obj, ok := element.(map[string]interface{}); ok {
+			objs = append(objs, obj)
+		}
+	}
+	return objs
+}
+
+// rename replaces the name at the given path, if it references a versioned object.
+func rename(obj map[string]interface{}, names map[string]string, path ...string) {
+	for _, field := range path[:len(path)-1] {
+		next, ok := obj[field].(map[string]interface{})
+		if !ok {
+			return
+		}
+		obj = next
+	}
+	key := path[len(path)-1]
+	name, _ := obj[key].(string)
+	if versionedName, found := names[name]; found {
+		obj[key] = versionedName
+	}
+}
+
+// retainVersions records the version replaced by the manifest
+// and deletes superseded versions, whose retention has expired.
+// Retained versions are annotated on the manifest, so that they are remembered with it in the inventory.
+func (reconciler *Reconciler) retainVersions(ctx context.Context, manifest *Manifest, now time.Time) error {
+	annotations := manifest.Content.GetAnnotations()
+	retention, err := time.ParseDuration(annotations[VersionedAnnotation])
+	if err != nil {
+		return fmt.Errorf("%w: retention: %w", ErrUnsupportedVersioning, err)
+	}
+
+	previous, err := reconciler.readStored(manifest)
+	if err != nil {
+		return err
+	}
+	var versions []SupersededVersion
+	if previous != nil {
+		versions, err = SupersededVersions(previous)
+		if err != nil {
+			return err
+		}
+		if previous.GetName() != "" && previous.GetName() != manifest.Content.GetName() {
+			versions = append(versions, SupersededVersion{
+				Name:         previous.GetName(),
+				SupersededAt: now,
+			})
+		}
+	}
+
+	retained := make([]SupersededVersion, 0, len(versions))
+	for _, version := range versions {
+		if version.Name == manifest.Content.GetName() {
+			// the content was reverted to a superseded version.
+			continue
+		}
+		if now.Sub(version.SupersededAt) < retention {
+			retained = append(retained, version)
+			continue
+		}
+		reconciler.Log.Info(
+			"Deleting superseded version",
+			"namespace",
+			manifest.Content.GetNamespace(),
+			"name",
+			version.Name,
+			"kind",
+			manifest.Content.GetKind(),
+		)
+		obj := &unstructured.Unstructured{}
+		obj.SetAPIVersion(manifest.Content.GetAPIVersion())
+		obj.SetKind(manifest.Content.GetKind())
+		obj.SetNamespace(manifest.Content.GetNamespace())
+		obj.SetName(version.Name)
+		if err := reconciler.DynamicClient.Delete(ctx, obj); err != nil && !k8sErrors.IsNotFound(err) {
+			return err
+		}
+	}
+
+	if len(retained) == 0 {
+		delete(annotations, VersionsAnnotation)
+	} else {
+		content, err := json.Marshal(retained)
+		if err != nil {
+			return err
+		}
+		annotations[VersionsAnnotation] = string(content)
+	}
+	manifest.Content.SetAnnotations(annotations)
+	return nil
+}
@@ -0,0 +1,273 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package declcdtest reconciles Declcd projects against ephemeral envtest control planes,
+// so that users can write integration tests for their own components the way Declcd tests itself.
+//
+// The control plane binaries are located through the KUBEBUILDER_ASSETS environment variable,
+// see [envtest.Environment].
+package declcdtest
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-logr/logr"
+	gitops "github.com/kharf/declcd/api/v1beta1"
+	"github.com/kharf/declcd/pkg/component"
+	"github.com/kharf/declcd/pkg/kube"
+	"github.com/kharf/declcd/pkg/project"
+	"github.com/kharf/declcd/pkg/vcs"
+	"github.com/otiai10/copy"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/uuid"
+	"k8s.io/client-go/rest"
+	"k8s.io/kubectl/pkg/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/envtest"
+)
+
+// Environment is an ephemeral Kubernetes control plane, which reconciles one Declcd project.
+// It is stopped automatically, when the test finishes.
+type Environment struct {
+	Ctx context.Context
+
+	// KubeConfig connects to the control plane.
+	KubeConfig *rest.Config
+
+	// Client reads and writes typed objects to assert the outcome of reconciliations.
+	Client client.Client
+
+	// DynamicClient reads and writes unstructured objects to assert the outcome of reconciliations.
+	DynamicClient *kube.DynamicClient
+
+	reconciler    project.Reconciler
+	gitopsProject gitops.GitOpsProject
+	repository    *git.Repository
+}
+
+type options struct {
+	crdPaths         []string
+	log              logr.Logger
+	componentBuilder component.Builder
+	fieldManager     string
+}
+
+// Option configures an [Environment].
+type Option interface {
+	apply(*options)
+}
+
+type crdPaths []string
+
+var _ Option = (*crdPaths)(nil)
+
+func (opt crdPaths) apply(opts *options) {
+	opts.crdPaths = append(opts.crdPaths, opt...)
+}
+
+// WithCRDPaths installs the CustomResourceDefinitions of given files or directories before the project is reconciled,
+// e.g. CRDs the components depend on, but which are managed outside of the project.
+func WithCRDPaths(paths ...string) crdPaths {
+	return paths
+}
+
+type logger logr.Logger
+
+var _ Option = (*logger)(nil)
+
+func (opt logger) apply(opts *options) {
+	opts.log = logr.Logger(opt)
+}
+
+// WithLogger logs reconciliations. Nothing is logged by default.
+func WithLogger(log logr.Logger) logger {
+	return logger(log)
+}
+
+type componentBuilder component.Builder
+
+var _ Option = (*componentBuilder)(nil)
+
+func (opt componentBuilder) apply(opts *options) {
+	opts.componentBuilder = component.Builder(opt)
+}
+
+// WithComponentBuilder builds the project with given builder,
+// e.g. to reconcile custom component types of a [component.Registry] or SOPS encrypted manifests.
+func WithComponentBuilder(builder component.Builder) componentBuilder {
+	return componentBuilder(builder)
+}
+
+type fieldManager string
+
+var _ Option = (*fieldManager)(nil)
+
+func (opt fieldManager) apply(opts *options) {
+	opts.fieldManager = string(opt)
+}
+
+// WithFieldManager applies objects with given field manager instead of the one of the primary controller shard.
+func WithFieldManager(name string) fieldManager {
+	return fieldManager(name)
+}
+
+// Start runs a control plane and prepares the reconciliation of a project.
+// It fails the test, if the control plane cannot be started.
+func Start(t testing.TB, opts ...Option) *Environment {
+	t.Helper()
+	options := &options{
+		log:              logr.Discard(),
+		componentBuilder: component.NewBuilder(),
+		fieldManager:     project.ControllerName("primary"),
+	}
+	for _, opt := range opts {
+		opt.apply(options)
+	}
+
+	controlPlane := &envtest.Environment{
+		CRDDirectoryPaths:     options.crdPaths,
+		ErrorIfCRDPathMissing: true,
+	}
+	cfg, err := controlPlane.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(func() {
+		cancel()
+		if err := controlPlane.Stop(); err != nil {
+			t.Log(err)
+		}
+	})
+
+	if err := gitops.AddToScheme(scheme.Scheme); err != nil {
+		t.Fatal(err)
+	}
+	kubeClient, err := client.New(cfg, client.Options{Scheme: scheme.Scheme})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dynamicClient, err := kube.NewDynamicClient(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	repositoryDir := t.TempDir()
+	repository, err := git.PlainInit(repositoryDir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	suspend := false
+	gitopsProject := gitops.GitOpsProject{
+		TypeMeta: v1.TypeMeta{
+			APIVersion: gitops.GroupVersion.String(),
+			Kind:       "GitOpsProject",
+		},
+		ObjectMeta: v1.ObjectMeta{
+			Name:      "test",
+			Namespace: "default",
+			UID:       uuid.NewUUID(),
+		},
+		Spec: gitops.GitOpsProjectSpec{
+			URL:                 repositoryDir,
+			PullIntervalSeconds: 5,
+			Suspend:             &suspend,
+		},
+	}
+	// The reconciler clones the project into the temporary directory of the system.
+	t.Cleanup(func() {
+		_ = os.RemoveAll(filepath.Join(os.TempDir(), "declcd", string(gitopsProject.GetUID())))
+	})
+
+	workerPoolSize := runtime.GOMAXPROCS(0)
+	return &Environment{
+		Ctx:           ctx,
+		KubeConfig:    cfg,
+		Client:        kubeClient,
+		DynamicClient: dynamicClient,
+		reconciler: project.Reconciler{
+			Log:        options.log,
+			KubeConfig: cfg,
+			ProjectManager: project.NewManager(
+				options.componentBuilder,
+				options.log,
+				workerPoolSize,
+			),
+			RepositoryManager: vcs.NewRepositoryManager("default", dynamicClient, options.log),
+			ComponentBuilder:  options.componentBuilder,
+			FieldManager:      options.fieldManager,
+			WorkerPoolSize:    workerPoolSize,
+			InventoryRoot:     t.TempDir(),
+		},
+		gitopsProject: gitopsProject,
+		repository:    repository,
+	}
+}
+
+// Reconcile commits the current state of the Declcd project at given path
+// and reconciles it like the controller reconciles a new commit.
+// Repeated calls reconcile the same project, so that updates and the pruning of removed components can be tested.
+// It fails the test, if the project cannot be committed,
+// and returns the error of the reconciliation otherwise.
+func (env *Environment) Reconcile(t testing.TB, projectPath string) (*project.ReconcileResult, error) {
+	t.Helper()
+	worktree, err := env.repository.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := os.ReadDir(worktree.Filesystem.Root())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		if entry.Name() == git.GitDirName {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(worktree.Filesystem.Root(), entry.Name())); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := copy.Copy(projectPath, worktree.Filesystem.Root(), copy.Options{
+		Skip: func(_ os.FileInfo, src string, _ string) (bool, error) {
+			return filepath.Base(src) == git.GitDirName, nil
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := worktree.AddWithOptions(&git.AddOptions{All: true}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := worktree.Commit("declcdtest", &git.CommitOptions{
+		AllowEmptyCommits: true,
+		Author: &object.Signature{
+			Name:  "declcdtest",
+			Email: "declcdtest@declcd.io",
+			When:  time.Now(),
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	return env.reconciler.Reconcile(env.Ctx, env.gitopsProject)
+}
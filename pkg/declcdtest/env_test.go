@@ -0,0 +1,51 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package declcdtest_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kharf/declcd/pkg/declcdtest"
+	"github.com/otiai10/copy"
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestEnvironment_Reconcile(t *testing.T) {
+	projectPath := t.TempDir()
+	assert.NilError(t, copy.Copy(filepath.Join("..", "..", "test", "testdata", "declcdtest"), projectPath))
+
+	env := declcdtest.Start(t)
+
+	result, err := env.Reconcile(t, projectPath)
+	assert.NilError(t, err)
+	assert.Assert(t, len(result.Failed()) == 0)
+
+	var configMap corev1.ConfigMap
+	err = env.Client.Get(env.Ctx, types.NamespacedName{Name: "config", Namespace: "default"}, &configMap)
+	assert.NilError(t, err)
+	assert.Equal(t, configMap.Data["env"], "test")
+
+	assert.NilError(t, os.Remove(filepath.Join(projectPath, "apps", "component.cue")))
+	_, err = env.Reconcile(t, projectPath)
+	assert.NilError(t, err)
+
+	err = env.Client.Get(env.Ctx, types.NamespacedName{Name: "config", Namespace: "default"}, &configMap)
+	assert.Assert(t, k8sErrors.IsNotFound(err))
+}
@@ -16,14 +16,22 @@ package garbage
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
 
 	"github.com/go-logr/logr"
 	"github.com/kharf/declcd/pkg/component"
 	"github.com/kharf/declcd/pkg/helm"
 	"github.com/kharf/declcd/pkg/inventory"
 	"github.com/kharf/declcd/pkg/kube"
+	"github.com/prometheus/client_golang/prometheus"
 	"golang.org/x/sync/errgroup"
 	"helm.sh/helm/v3/pkg/action"
+	corev1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/client-go/rest"
 )
@@ -42,7 +50,20 @@ type Collector struct {
 	// The object does not include the storage itself, it only holds a reference to the storage.
 	InventoryInstance *inventory.Instance
 
+	// FieldManager is the identity declcd applies manifests with.
+	// Orphaned manifests are released from it.
+	FieldManager string
+
 	WorkerPoolSize int
+
+	// Project is the name of the GitOpsProject the inventory belongs to.
+	Project string
+
+	// PrunedCounter optionally counts the deleted objects and uninstalled releases by project and kind.
+	PrunedCounter *prometheus.CounterVec
+
+	// OnEvent is optionally called for deleted and orphaned objects and releases, e.g. to emit Kubernetes Events.
+	OnEvent func(eventType string, reason string, message string)
 }
 
 // Collect inspects the inventory for dangling manifests or helm releases,
@@ -68,20 +89,63 @@ func (c *Collector) Collect(
 	return eg.Wait()
 }
 
+// Removal is an inventory item, which a collection would remove.
+type Removal struct {
+	Item inventory.Item
+
+	// Orphan reports whether the object or release stays in the cluster and is only released.
+	Orphan bool
+}
+
+// Dangling returns the inventory items, which are undefined in the declcd gitops repository,
+// without removing them from the Kubernetes cluster or the inventory.
+func (c *Collector) Dangling(dag *component.DependencyGraph) ([]Removal, error) {
+	storage, err := c.InventoryInstance.Load()
+	if err != nil {
+		return nil, err
+	}
+	removals := make([]Removal, 0)
+	for _, invComponent := range storage.Items() {
+		if !isDangling(dag, invComponent) {
+			continue
+		}
+		var orphan bool
+		switch item := invComponent.(type) {
+		case *inventory.HelmReleaseItem:
+			orphan, err = c.isOrphanRelease(item)
+		case *inventory.ManifestItem:
+			var stored *unstructured.Unstructured
+			stored, err = c.readStored(item)
+			orphan = stored != nil && stored.GetAnnotations()[component.PruneAnnotation] == component.PruneOrphan
+		}
+		if err != nil {
+			return nil, err
+		}
+		removals = append(removals, Removal{
+			Item:   invComponent,
+			Orphan: orphan,
+		})
+	}
+	return removals, nil
+}
+
+func isDangling(dag *component.DependencyGraph, inventoryItem inventory.Item) bool {
+	instance := dag.Get(inventoryItem.GetID())
+	if instance == nil {
+		return true
+	}
+	return inventoryItem.GetID() != instance.GetID()
+}
+
 func (c *Collector) collect(
 	ctx context.Context,
 	dag *component.DependencyGraph,
 	inventoryItem inventory.Item,
 ) error {
-	collect := true
-	instance := dag.Get(inventoryItem.GetID())
-	if instance != nil {
-		collect = inventoryItem.GetID() != instance.GetID()
-	}
-	if collect {
+	if isDangling(dag, inventoryItem) {
 		switch item := inventoryItem.(type) {
 		case *inventory.HelmReleaseItem:
-			if err := c.collectHelmRelease(item); err != nil {
+			if err := c.collectHelmRelease(ctx, item); err != nil {
 				return err
 			}
 		case *inventory.ManifestItem:
@@ -94,8 +158,31 @@ func (c *Collector) collect(
 }
 
 func (c *Collector) collectHelmRelease(
+	ctx context.Context,
 	invHr *inventory.HelmReleaseItem,
 ) error {
+	stored, err := c.readRelease(invHr)
+	if err != nil {
+		return err
+	}
+	if stored != nil && stored.Orphan {
+		c.Log.Info(
+			"Orphaning unreferenced helm release",
+			"namespace",
+			invHr.GetNamespace(),
+			"name",
+			invHr.GetName(),
+		)
+		if err := c.InventoryInstance.DeleteItem(invHr); err != nil {
+			return err
+		}
+		c.event(
+			"Orphaned",
+			fmt.Sprintf("Kept Helm release %s/%s installed, which is no longer declared", invHr.GetNamespace(), invHr.GetName()),
+		)
+		return nil
+	}
+
 	c.Log.Info(
 		"Collecting unreferenced helm release",
 		"namespace",
@@ -103,8 +190,22 @@ func (c *Collector) collectHelmRelease(
 		"name",
 		invHr.GetName(),
 	)
+	// Helm does not delete hook objects, so the tracked ones are deleted with the release.
+	// They are deleted first, so that an interrupted collection is retried with the release still installed.
+	if stored != nil {
+		for _, hookResource := range stored.HookResources {
+			unstr := &unstructured.Unstructured{}
+			unstr.SetAPIVersion(hookResource.APIVersion)
+			unstr.SetKind(hookResource.Kind)
+			unstr.SetName(hookResource.Name)
+			unstr.SetNamespace(hookResource.Namespace)
+			if err := c.Client.Delete(ctx, unstr); err != nil && !k8sErrors.IsNotFound(err) {
+				return err
+			}
+		}
+	}
 	// fieldManager is irrelevant for deleting.
-	helmCfg, err := helm.Init(invHr.GetNamespace(), c.KubeConfig, c.Client, "")
+	helmCfg, err := helm.Init(invHr.GetNamespace(), c.KubeConfig, c.Client, "", nil)
 	if err != nil {
 		return err
 	}
@@ -117,9 +218,63 @@ func (c *Collector) collectHelmRelease(
 	if err := c.InventoryInstance.DeleteItem(invHr); err != nil {
 		return err
 	}
+	c.countPruned("HelmRelease")
+	c.event(
+		"Pruned",
+		fmt.Sprintf("Uninstalled Helm release %s/%s, which is no longer declared", invHr.GetNamespace(), invHr.GetName()),
+	)
 	return nil
 }
 
+// isOrphanRelease reports whether the stored release has to stay installed.
+// Releases without stored content are uninstalled.
+func (c *Collector) isOrphanRelease(invHr *inventory.HelmReleaseItem) (bool, error) {
+	stored, err := c.readRelease(invHr)
+	if err != nil {
+		return false, err
+	}
+	return stored != nil && stored.Orphan, nil
+}
+
+// readRelease reads the stored release or returns nil, if its content is not stored.
+func (c *Collector) readRelease(invHr *inventory.HelmReleaseItem) (*helm.Release, error) {
+	reader, err := c.InventoryInstance.GetItem(invHr)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer reader.Close()
+
+	var stored helm.Release
+	if err := json.NewDecoder(reader).Decode(&stored); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &stored, nil
+}
+
+// readStored reads the stored manifest or returns nil, if its content is not stored.
+func (c *Collector) readStored(invManifest *inventory.ManifestItem) (*unstructured.Unstructured, error) {
+	reader, err := c.InventoryInstance.GetItem(invManifest)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer reader.Close()
+
+	var stored unstructured.Unstructured
+	if err := json.NewDecoder(reader).Decode(&stored.Object); err != nil {
+		return nil, err
+	}
+	return &stored, nil
+}
+
 func (c *Collector) collectManifest(
 	ctx context.Context,
 	invManifest *inventory.ManifestItem,
@@ -138,11 +293,87 @@ func (c *Collector) collectManifest(
 	unstr.SetNamespace(invManifest.GetNamespace())
 	unstr.SetKind(invManifest.TypeMeta.Kind)
 	unstr.SetAPIVersion(invManifest.TypeMeta.APIVersion)
-	if err := c.Client.Delete(ctx, unstr); err != nil {
+
+	stored, err := c.readStored(invManifest)
+	if err != nil {
 		return err
 	}
+	if stored != nil && stored.GetAnnotations()[component.PruneAnnotation] == component.PruneOrphan {
+		c.Log.Info(
+			"Orphaning unreferenced manifest",
+			"namespace",
+			invManifest.GetNamespace(),
+			"name",
+			invManifest.GetName(),
+			"kind",
+			invManifest.TypeMeta.Kind,
+		)
+		fieldManager := c.FieldManager
+		if declared := stored.GetAnnotations()[component.FieldManagerAnnotation]; declared != "" {
+			fieldManager = declared
+		}
+		if err := c.Client.ReleaseOwnership(ctx, unstr, fieldManager); err != nil &&
+			!k8sErrors.IsNotFound(err) {
+			return err
+		}
+		c.event("Orphaned", fmt.Sprintf("Released %s, which is no longer declared", describe(invManifest)))
+	} else {
+		if err := c.Client.Delete(ctx, unstr); err != nil {
+			return err
+		}
+		c.countPruned(invManifest.TypeMeta.Kind)
+		c.event("Pruned", fmt.Sprintf("Deleted %s, which is no longer declared", describe(invManifest)))
+		if stored != nil {
+			if err := c.deleteSupersededVersions(ctx, stored); err != nil {
+				return err
+			}
+		}
+	}
 	if err := c.InventoryInstance.DeleteItem(invManifest); err != nil {
 		return err
 	}
 	return nil
 }
+
+func (c *Collector) event(reason string, message string) {
+	if c.OnEvent != nil {
+		c.OnEvent(corev1.EventTypeNormal, reason, message)
+	}
+}
+
+// describe returns the kind and namespaced name of a manifest, e.g. "Deployment shop/api".
+func describe(invManifest *inventory.ManifestItem) string {
+	if invManifest.GetNamespace() == "" {
+		return fmt.Sprintf("%s %s", invManifest.TypeMeta.Kind, invManifest.GetName())
+	}
+	return fmt.Sprintf("%s %s/%s", invManifest.TypeMeta.Kind, invManifest.GetNamespace(), invManifest.GetName())
+}
+
+func (c *Collector) countPruned(kind string) {
+	if c.PrunedCounter == nil {
+		return
+	}
+	c.PrunedCounter.With(prometheus.Labels{
+		"project": c.Project,
+		"kind":    kind,
+	}).Inc()
+}
+
+// deleteSupersededVersions removes the retained versions of a versioned ConfigMap or Secret together with the manifest.
+func (c *Collector) deleteSupersededVersions(ctx context.Context, stored *unstructured.Unstructured) error {
+	versions, err := component.SupersededVersions(stored)
+	if err != nil {
+		return err
+	}
+	for _, version := range versions {
+		unstr := &unstructured.Unstructured{}
+		unstr.SetName(version.Name)
+		unstr.SetNamespace(stored.GetNamespace())
+		unstr.SetKind(stored.GetKind())
+		unstr.SetAPIVersion(stored.GetAPIVersion())
+		if err := c.Client.Delete(ctx, unstr); err != nil && !k8sErrors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
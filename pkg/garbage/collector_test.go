@@ -296,6 +296,70 @@ func TestCollector_Collect(t *testing.T) {
 				})
 			},
 		},
+		{
+			name: "Orphaned-DepB",
+			runCase: func(context testCaseContext) {
+				dag := component.NewDependencyGraph()
+				ctx := context.ctx
+				env := context.env
+				inventoryInstance := context.inventoryInstance
+
+				prepareManifests(ctx, t, []*inventory.ManifestItem{nsB}, env, inventoryInstance, dag)
+
+				obj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(toObject(depB))
+				assert.NilError(t, err)
+				unstr := &unstructured.Unstructured{Object: obj}
+				unstr.SetAnnotations(map[string]string{
+					component.PruneAnnotation: component.PruneOrphan,
+				})
+				err = env.DynamicTestKubeClient.Apply(ctx, unstr, "test")
+				assert.NilError(t, err)
+				buf := &bytes.Buffer{}
+				err = json.NewEncoder(buf).Encode(unstr.Object)
+				assert.NilError(t, err)
+				err = inventoryInstance.StoreItem(depB, buf)
+				assert.NilError(t, err)
+
+				err = context.collector.Collect(ctx, &dag)
+				assert.NilError(t, err)
+
+				storage, err := inventoryInstance.Load()
+				assert.NilError(t, err)
+				assert.Assert(t, !storage.HasItem(depB))
+
+				foundObj, err := env.DynamicTestKubeClient.Get(ctx, unstr)
+				assert.NilError(t, err)
+				for _, entry := range foundObj.GetManagedFields() {
+					assert.Assert(t, entry.Manager != "test")
+				}
+			},
+		},
+		{
+			name: "Orphaned-HR",
+			runCase: func(context testCaseContext) {
+				dag := component.NewDependencyGraph()
+				ctx := context.ctx
+				inventoryInstance := context.inventoryInstance
+
+				buf := &bytes.Buffer{}
+				err := json.NewEncoder(buf).Encode(helm.Release{
+					Name:      hr.Name,
+					Namespace: hr.Namespace,
+					Orphan:    true,
+				})
+				assert.NilError(t, err)
+				err = inventoryInstance.StoreItem(hr, buf)
+				assert.NilError(t, err)
+
+				// Uninstalling would fail, as the release is not installed.
+				err = context.collector.Collect(ctx, &dag)
+				assert.NilError(t, err)
+
+				storage, err := inventoryInstance.Load()
+				assert.NilError(t, err)
+				assert.Assert(t, !storage.HasItem(hr))
+			},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -314,6 +378,7 @@ func TestCollector_Collect(t *testing.T) {
 				Client:            env.DynamicTestKubeClient,
 				KubeConfig:        env.ControlPlane.Config,
 				InventoryInstance: inventoryInstance,
+				FieldManager:      "test",
 				WorkerPoolSize:    goRuntime.GOMAXPROCS(0),
 			}
 
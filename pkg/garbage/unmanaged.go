@@ -0,0 +1,109 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package garbage
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/kharf/declcd/pkg/component"
+	"github.com/kharf/declcd/pkg/inventory"
+	"github.com/kharf/declcd/pkg/kube"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+)
+
+// UnmanagedObject is a cluster object labeled as managed by a project, which is not in the inventory of the project.
+// It is neither applied nor pruned, e.g. because it was left behind by a lost inventory.
+type UnmanagedObject struct {
+	APIVersion string
+	Kind       string
+	Name       string
+	Namespace  string
+}
+
+// ListableKinds returns the preferred version of every kind the cluster serves and allows to list.
+// Groups failing discovery, e.g. because of an unavailable aggregated API, are skipped.
+func ListableKinds(discoveryClient discovery.ServerResourcesInterface) ([]schema.GroupVersionKind, error) {
+	resourceLists, err := discoveryClient.ServerPreferredResources()
+	if err != nil && !discovery.IsGroupDiscoveryFailedError(err) {
+		return nil, err
+	}
+	resourceLists = discovery.FilteredBy(discovery.SupportsAllVerbs{Verbs: []string{"list"}}, resourceLists)
+
+	kinds := make([]schema.GroupVersionKind, 0)
+	for _, resourceList := range resourceLists {
+		groupVersion, err := schema.ParseGroupVersion(resourceList.GroupVersion)
+		if err != nil {
+			return nil, err
+		}
+		for _, resource := range resourceList.APIResources {
+			// Subresources like pods/log are not listable on their own.
+			if strings.Contains(resource.Name, "/") {
+				continue
+			}
+			kinds = append(kinds, groupVersion.WithKind(resource.Kind))
+		}
+	}
+	return kinds, nil
+}
+
+// Unmanaged lists the objects of the given kinds labeled as managed by the project with [component.ManagedByLabel] and [component.ProjectLabel],
+// which are absent from its inventory, sorted by kind, namespace and name.
+// Objects of other projects are tracked by their inventories and never listed.
+// Objects of Helm releases are not in the inventory, but are labeled as managed by Helm by convention.
+func Unmanaged(
+	ctx context.Context,
+	client kube.Client[unstructured.Unstructured],
+	kinds []schema.GroupVersionKind,
+	project string,
+	storage inventory.Storage,
+) ([]UnmanagedObject, error) {
+	selector := labels.SelectorFromSet(labels.Set{
+		component.ManagedByLabel: component.ManagedByValue,
+		component.ProjectLabel:   project,
+	})
+	unmanaged := make([]UnmanagedObject, 0)
+	for _, gvk := range kinds {
+		objects, err := client.List(ctx, gvk, "", selector)
+		if err != nil {
+			return nil, err
+		}
+		for _, object := range objects {
+			// Manifest ids are built like the #Manifest schema builds them.
+			id := fmt.Sprintf("%s_%s_%s_%s", object.GetName(), object.GetNamespace(), gvk.Group, gvk.Kind)
+			if _, found := storage.Items()[id]; found {
+				continue
+			}
+			unmanaged = append(unmanaged, UnmanagedObject{
+				APIVersion: gvk.GroupVersion().String(),
+				Kind:       gvk.Kind,
+				Name:       object.GetName(),
+				Namespace:  object.GetNamespace(),
+			})
+		}
+	}
+	slices.SortFunc(unmanaged, func(a, b UnmanagedObject) int {
+		return strings.Compare(
+			a.Kind+"/"+a.Namespace+"/"+a.Name,
+			b.Kind+"/"+b.Namespace+"/"+b.Name,
+		)
+	})
+	return unmanaged, nil
+}
@@ -0,0 +1,155 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package garbage_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/kharf/declcd/pkg/component"
+	"github.com/kharf/declcd/pkg/garbage"
+	"github.com/kharf/declcd/pkg/inventory"
+	"github.com/kharf/declcd/pkg/kube"
+	"gotest.tools/v3/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+)
+
+// listClient serves the listed objects of every kind.
+type listClient struct {
+	kube.Client[unstructured.Unstructured]
+	objects map[schema.GroupVersionKind][]unstructured.Unstructured
+}
+
+func (client listClient) List(
+	ctx context.Context,
+	gvk schema.GroupVersionKind,
+	namespace string,
+	selector labels.Selector,
+) ([]unstructured.Unstructured, error) {
+	matching := make([]unstructured.Unstructured, 0)
+	for _, object := range client.objects[gvk] {
+		if selector.Matches(labels.Set(object.GetLabels())) {
+			matching = append(matching, object)
+		}
+	}
+	return matching, nil
+}
+
+// preferredResources serves the preferred resources of a cluster.
+type preferredResources struct {
+	discovery.ServerResourcesInterface
+	resources []*metav1.APIResourceList
+}
+
+func (client preferredResources) ServerPreferredResources() ([]*metav1.APIResourceList, error) {
+	return client.resources, nil
+}
+
+func object(name string, namespace string, managedBy string, project string) unstructured.Unstructured {
+	obj := unstructured.Unstructured{}
+	obj.SetName(name)
+	obj.SetNamespace(namespace)
+	if managedBy != "" {
+		obj.SetLabels(map[string]string{
+			component.ManagedByLabel: managedBy,
+			component.ProjectLabel:   project,
+		})
+	}
+	return obj
+}
+
+func TestUnmanaged(t *testing.T) {
+	instance := inventory.Instance{
+		Path: t.TempDir(),
+	}
+	assert.NilError(t, instance.StoreItem(&inventory.ManifestItem{
+		TypeMeta:  metav1.TypeMeta{Kind: "Deployment", APIVersion: "apps/v1"},
+		Name:      "api",
+		Namespace: "shop",
+		ID:        "api_shop_apps_Deployment",
+	}, strings.NewReader(`{"apiVersion":"apps/v1","kind":"Deployment","metadata":{"name":"api","namespace":"shop"}}`)))
+	assert.NilError(t, instance.StoreItem(&inventory.ManifestItem{
+		TypeMeta: metav1.TypeMeta{Kind: "Namespace", APIVersion: "v1"},
+		Name:     "shop",
+		ID:       "shop___Namespace",
+	}, strings.NewReader(`{"apiVersion":"v1","kind":"Namespace","metadata":{"name":"shop"}}`)))
+	storage, err := instance.Load()
+	assert.NilError(t, err)
+
+	deployment := schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}
+	namespace := schema.GroupVersionKind{Version: "v1", Kind: "Namespace"}
+	client := listClient{
+		objects: map[schema.GroupVersionKind][]unstructured.Unstructured{
+			deployment: {
+				object("api", "shop", component.ManagedByValue, "shop"),
+				object("worker", "shop", component.ManagedByValue, "shop"),
+				object("db", "shop", "Helm", "shop"),
+				object("web", "shop", "", ""),
+				object("billing", "shop", component.ManagedByValue, "billing"),
+			},
+			namespace: {
+				object("shop", "", component.ManagedByValue, "shop"),
+				object("legacy", "", component.ManagedByValue, "shop"),
+				object("billing", "", component.ManagedByValue, "billing"),
+			},
+		},
+	}
+
+	unmanaged, err := garbage.Unmanaged(
+		context.Background(),
+		client,
+		[]schema.GroupVersionKind{deployment, namespace},
+		"shop",
+		*storage,
+	)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, unmanaged, []garbage.UnmanagedObject{
+		{APIVersion: "apps/v1", Kind: "Deployment", Name: "worker", Namespace: "shop"},
+		{APIVersion: "v1", Kind: "Namespace", Name: "legacy"},
+	})
+}
+
+func TestListableKinds(t *testing.T) {
+	discoveryClient := preferredResources{
+		resources: []*metav1.APIResourceList{
+			{
+				GroupVersion: "v1",
+				APIResources: []metav1.APIResource{
+					{Name: "pods", Kind: "Pod", Namespaced: true, Verbs: []string{"get", "list", "delete"}},
+					{Name: "pods/log", Kind: "Pod", Namespaced: true, Verbs: []string{"get", "list"}},
+					{Name: "bindings", Kind: "Binding", Namespaced: true, Verbs: []string{"create"}},
+				},
+			},
+			{
+				GroupVersion: "apps/v1",
+				APIResources: []metav1.APIResource{
+					{Name: "deployments", Kind: "Deployment", Namespaced: true, Verbs: []string{"list"}},
+				},
+			},
+		},
+	}
+
+	kinds, err := garbage.ListableKinds(discoveryClient)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, kinds, []schema.GroupVersionKind{
+		{Version: "v1", Kind: "Pod"},
+		{Group: "apps", Version: "v1", Kind: "Deployment"},
+	})
+}
@@ -0,0 +1,132 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package health
+
+import (
+	"context"
+	"errors"
+
+	"github.com/kharf/declcd/pkg/kube"
+	"golang.org/x/sync/errgroup"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// Status is the aggregated readiness of applied objects.
+type Status string
+
+const (
+	// Healthy objects are ready to serve.
+	Healthy Status = "Healthy"
+	// Progressing objects are not ready yet, but may become ready without intervention.
+	Progressing Status = "Progressing"
+	// Degraded objects failed or disappeared from the cluster.
+	Degraded Status = "Degraded"
+)
+
+// ObjectHealth is the readiness of a single object, which is not healthy.
+type ObjectHealth struct {
+	Kind      string
+	Namespace string
+	Name      string
+	Status    Status
+
+	// Message explains why the object is not healthy.
+	Message string
+}
+
+// Report is the outcome of assessing the readiness of applied objects.
+type Report struct {
+	// Status is the worst status of all objects.
+	Status Status
+
+	// Unhealthy lists every object, which is not healthy, in the order of the assessed objects.
+	Unhealthy []ObjectHealth
+}
+
+// Assess reads the current cluster state of all objects once and reports their aggregated readiness.
+// In contrast to [WaitUntilReady], it does not wait for objects to become ready.
+// Objects are read by up to concurrency goroutines.
+func Assess(
+	ctx context.Context,
+	client kube.Client[unstructured.Unstructured],
+	objs []unstructured.Unstructured,
+	rules Rules,
+	concurrency int,
+) Report {
+	results := make([]ObjectHealth, len(objs))
+	eg := errgroup.Group{}
+	eg.SetLimit(max(concurrency, 1))
+	for i := range objs {
+		eg.Go(func() error {
+			results[i] = assessObject(ctx, client, &objs[i], rules)
+			return nil
+		})
+	}
+	_ = eg.Wait()
+
+	report := Report{
+		Status:    Healthy,
+		Unhealthy: make([]ObjectHealth, 0),
+	}
+	for _, objHealth := range results {
+		if objHealth.Status == Healthy {
+			continue
+		}
+		report.Unhealthy = append(report.Unhealthy, objHealth)
+		if objHealth.Status == Degraded || report.Status == Healthy {
+			report.Status = objHealth.Status
+		}
+	}
+	return report
+}
+
+func assessObject(
+	ctx context.Context,
+	client kube.Client[unstructured.Unstructured],
+	obj *unstructured.Unstructured,
+	rules Rules,
+) ObjectHealth {
+	objHealth := ObjectHealth{
+		Kind:      obj.GetKind(),
+		Namespace: obj.GetNamespace(),
+		Name:      obj.GetName(),
+		Status:    Healthy,
+	}
+
+	current, err := client.Get(ctx, obj)
+	if err != nil {
+		objHealth.Status = Progressing
+		if k8sErrors.IsNotFound(err) {
+			objHealth.Status = Degraded
+		}
+		objHealth.Message = err.Error()
+		return objHealth
+	}
+
+	ready, err := IsReady(current, rules)
+	switch {
+	case errors.Is(err, ErrFailed):
+		objHealth.Status = Degraded
+		objHealth.Message = err.Error()
+	case err != nil:
+		objHealth.Status = Progressing
+		objHealth.Message = err.Error()
+	case !ready:
+		objHealth.Status = Progressing
+		objHealth.Message = "Not ready"
+	}
+	return objHealth
+}
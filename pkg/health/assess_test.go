@@ -0,0 +1,177 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package health_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kharf/declcd/pkg/health"
+	"github.com/kharf/declcd/pkg/kube"
+	"gotest.tools/v3/assert"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// clusterClient serves the cluster state of objects by name.
+type clusterClient map[string]*unstructured.Unstructured
+
+var _ kube.Client[unstructured.Unstructured] = (clusterClient)(nil)
+
+func (client clusterClient) Apply(
+	ctx context.Context,
+	obj *unstructured.Unstructured,
+	fieldManager string,
+	opts ...kube.ApplyOption,
+) error {
+	return nil
+}
+
+func (client clusterClient) Update(
+	ctx context.Context,
+	obj *unstructured.Unstructured,
+	fieldManager string,
+	opts ...kube.ApplyOption,
+) error {
+	return nil
+}
+
+func (client clusterClient) Get(
+	ctx context.Context,
+	obj *unstructured.Unstructured,
+) (*unstructured.Unstructured, error) {
+	current, found := client[obj.GetName()]
+	if !found {
+		return nil, k8sErrors.NewNotFound(schema.GroupResource{Resource: obj.GetKind()}, obj.GetName())
+	}
+	return current, nil
+}
+
+func (client clusterClient) Delete(ctx context.Context, obj *unstructured.Unstructured) error {
+	return nil
+}
+
+func (client clusterClient) List(
+	ctx context.Context,
+	gvk schema.GroupVersionKind,
+	namespace string,
+	selector labels.Selector,
+) ([]unstructured.Unstructured, error) {
+	return nil, nil
+}
+
+func (client clusterClient) MigrateStorageVersion(ctx context.Context, crdName string) (int, error) {
+	return 0, nil
+}
+
+func (client clusterClient) RESTMapper() meta.RESTMapper {
+	return nil
+}
+
+func deployment(name string, availableReplicas int64) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata":   map[string]interface{}{"name": name, "namespace": "test"},
+		"spec":       map[string]interface{}{"replicas": int64(1)},
+		"status": map[string]interface{}{
+			"updatedReplicas":   int64(1),
+			"availableReplicas": availableReplicas,
+		},
+	}}
+}
+
+func failedJob(name string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "batch/v1",
+		"kind":       "Job",
+		"metadata":   map[string]interface{}{"name": name, "namespace": "test"},
+		"status": map[string]interface{}{
+			"conditions": []interface{}{
+				map[string]interface{}{"type": "Failed", "status": "True"},
+			},
+		},
+	}}
+}
+
+func TestAssess(t *testing.T) {
+	testCases := []struct {
+		name              string
+		cluster           clusterClient
+		objs              []*unstructured.Unstructured
+		expectedStatus    health.Status
+		expectedUnhealthy []string
+	}{
+		{
+			name: "Healthy",
+			cluster: clusterClient{
+				"a": deployment("a", 1),
+				"b": deployment("b", 1),
+			},
+			objs:              []*unstructured.Unstructured{deployment("a", 0), deployment("b", 0)},
+			expectedStatus:    health.Healthy,
+			expectedUnhealthy: []string{},
+		},
+		{
+			name: "Progressing",
+			cluster: clusterClient{
+				"a": deployment("a", 1),
+				"b": deployment("b", 0),
+			},
+			objs:              []*unstructured.Unstructured{deployment("a", 0), deployment("b", 0)},
+			expectedStatus:    health.Progressing,
+			expectedUnhealthy: []string{"b"},
+		},
+		{
+			name: "Degraded",
+			cluster: clusterClient{
+				"a": deployment("a", 0),
+				"b": failedJob("b"),
+			},
+			objs:              []*unstructured.Unstructured{deployment("a", 0), failedJob("b")},
+			expectedStatus:    health.Degraded,
+			expectedUnhealthy: []string{"a", "b"},
+		},
+		{
+			name: "Missing",
+			cluster: clusterClient{
+				"a": deployment("a", 1),
+			},
+			objs:              []*unstructured.Unstructured{deployment("a", 0), deployment("b", 0)},
+			expectedStatus:    health.Degraded,
+			expectedUnhealthy: []string{"b"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			objs := make([]unstructured.Unstructured, 0, len(tc.objs))
+			for _, obj := range tc.objs {
+				objs = append(objs, *obj)
+			}
+			report := health.Assess(context.Background(), tc.cluster, objs, nil, 2)
+			assert.Equal(t, report.Status, tc.expectedStatus)
+			unhealthy := make([]string, 0, len(report.Unhealthy))
+			for _, objHealth := range report.Unhealthy {
+				assert.Assert(t, objHealth.Message != "")
+				unhealthy = append(unhealthy, objHealth.Name)
+			}
+			assert.DeepEqual(t, unhealthy, tc.expectedUnhealthy)
+		})
+	}
+}
@@ -0,0 +1,170 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/kharf/declcd/pkg/kube"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+var (
+	ErrFailed   = errors.New("Object failed")
+	ErrNotReady = errors.New("Object not ready")
+)
+
+// Rules map an object kind to the status condition type, which has to be True for objects of that kind to be ready.
+// Rules take precedence over the built-in checks and make custom resources awaitable.
+type Rules map[string]string
+
+// IsReady reports whether an object is ready to serve.
+// Deployments, StatefulSets, DaemonSets, Jobs, Pods, PersistentVolumeClaims and CustomResourceDefinitions are checked by their status.
+// Objects of all other kinds follow the kstatus conventions of their status conditions:
+// they fail with a True Stalled condition, report their Ready condition and are not ready with a True Reconciling condition.
+// Objects without any of these conditions are ready as soon as they exist, unless a rule is defined for their kind.
+func IsReady(obj *unstructured.Unstructured, rules Rules) (bool, error) {
+	if conditionType, found := rules[obj.GetKind()]; found {
+		return hasCondition(obj, conditionType), nil
+	}
+
+	if !isObserved(obj) {
+		return false, nil
+	}
+
+	switch obj.GroupVersionKind().GroupKind().String() {
+	case "Deployment.apps":
+		replicas := desiredReplicas(obj)
+		return nestedInt(obj, "status", "updatedReplicas") >= replicas &&
+			nestedInt(obj, "status", "availableReplicas") >= replicas, nil
+	case "StatefulSet.apps":
+		replicas := desiredReplicas(obj)
+		return nestedInt(obj, "status", "updatedReplicas") >= replicas &&
+			nestedInt(obj, "status", "readyReplicas") >= replicas, nil
+	case "DaemonSet.apps":
+		desired := nestedInt(obj, "status", "desiredNumberScheduled")
+		return nestedInt(obj, "status", "updatedNumberScheduled") >= desired &&
+			nestedInt(obj, "status", "numberReady") >= desired, nil
+	case "Job.batch":
+		if hasCondition(obj, "Failed") {
+			return false, fmt.Errorf("%w: job %s", ErrFailed, obj.GetName())
+		}
+		return hasCondition(obj, "Complete"), nil
+	case "Pod":
+		phase, _, _ := unstructured.NestedString(obj.Object, "status", "phase")
+		if phase == "Failed" {
+			return false, fmt.Errorf("%w: pod %s", ErrFailed, obj.GetName())
+		}
+		return phase == "Succeeded" || hasCondition(obj, "Ready"), nil
+	case "PersistentVolumeClaim":
+		phase, _, _ := unstructured.NestedString(obj.Object, "status", "phase")
+		return phase == "Bound", nil
+	case "CustomResourceDefinition.apiextensions.k8s.io":
+		return hasCondition(obj, "Established"), nil
+	}
+
+	if hasCondition(obj, "Stalled") {
+		return false, fmt.Errorf("%w: %s %s", ErrFailed, obj.GetKind(), obj.GetName())
+	}
+	if status, found := conditionStatus(obj, "Ready"); found {
+		return status == "True", nil
+	}
+	return !hasCondition(obj, "Reconciling"), nil
+}
+
+// WaitUntilReady polls the cluster state of all objects until they are ready or the context is done.
+func WaitUntilReady(
+	ctx context.Context,
+	client kube.Client[unstructured.Unstructured],
+	objs []unstructured.Unstructured,
+	rules Rules,
+) error {
+	for i := range objs {
+		obj := &objs[i]
+		for {
+			current, err := client.Get(ctx, obj)
+			if err != nil {
+				return err
+			}
+			ready, err := IsReady(current, rules)
+			if err != nil {
+				return err
+			}
+			if ready {
+				break
+			}
+
+			select {
+			case <-ctx.Done():
+				return fmt.Errorf(
+					"%w: %s %s/%s: %w",
+					ErrNotReady,
+					obj.GetKind(),
+					obj.GetNamespace(),
+					obj.GetName(),
+					ctx.Err(),
+				)
+			case <-time.After(1 * time.Second):
+			}
+		}
+	}
+	return nil
+}
+
+// isObserved reports whether the controller of an object has seen its latest generation.
+func isObserved(obj *unstructured.Unstructured) bool {
+	observedGeneration, found, _ := unstructured.NestedInt64(obj.Object, "status", "observedGeneration")
+	if !found {
+		return true
+	}
+	return observedGeneration >= obj.GetGeneration()
+}
+
+func desiredReplicas(obj *unstructured.Unstructured) int64 {
+	replicas, found, _ := unstructured.NestedInt64(obj.Object, "spec", "replicas")
+	if !found {
+		return 1
+	}
+	return replicas
+}
+
+func nestedInt(obj *unstructured.Unstructured, fields ...string) int64 {
+	value, _, _ := unstructured.NestedInt64(obj.Object, fields...)
+	return value
+}
+
+func hasCondition(obj *unstructured.Unstructured, conditionType string) bool {
+	status, _ := conditionStatus(obj, conditionType)
+	return status == "True"
+}
+
+// conditionStatus returns the status of the condition of given type and whether the object reports it at all.
+func conditionStatus(obj *unstructured.Unstructured, conditionType string) (string, bool) {
+	conditions, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	for _, c := range conditions {
+		cond, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if cond["type"] == conditionType {
+			status, _ := cond["status"].(string)
+			return status, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,202 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package health_test
+
+import (
+	"testing"
+
+	"github.com/kharf/declcd/pkg/health"
+	"gotest.tools/v3/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestIsReady(t *testing.T) {
+	testCases := []struct {
+		name          string
+		obj           map[string]interface{}
+		rules         health.Rules
+		expectedReady bool
+		expectedErr   string
+	}{
+		{
+			name: "DeploymentAvailable",
+			obj: map[string]interface{}{
+				"apiVersion": "apps/v1",
+				"kind":       "Deployment",
+				"metadata":   map[string]interface{}{"name": "test", "generation": int64(2)},
+				"spec":       map[string]interface{}{"replicas": int64(2)},
+				"status": map[string]interface{}{
+					"observedGeneration": int64(2),
+					"updatedReplicas":    int64(2),
+					"availableReplicas":  int64(2),
+				},
+			},
+			expectedReady: true,
+		},
+		{
+			name: "DeploymentProgressing",
+			obj: map[string]interface{}{
+				"apiVersion": "apps/v1",
+				"kind":       "Deployment",
+				"metadata":   map[string]interface{}{"name": "test", "generation": int64(2)},
+				"spec":       map[string]interface{}{"replicas": int64(2)},
+				"status": map[string]interface{}{
+					"observedGeneration": int64(2),
+					"updatedReplicas":    int64(2),
+					"availableReplicas":  int64(1),
+				},
+			},
+			expectedReady: false,
+		},
+		{
+			name: "DeploymentNotObserved",
+			obj: map[string]interface{}{
+				"apiVersion": "apps/v1",
+				"kind":       "Deployment",
+				"metadata":   map[string]interface{}{"name": "test", "generation": int64(3)},
+				"status": map[string]interface{}{
+					"observedGeneration": int64(2),
+					"updatedReplicas":    int64(1),
+					"availableReplicas":  int64(1),
+				},
+			},
+			expectedReady: false,
+		},
+		{
+			name: "JobFailed",
+			obj: map[string]interface{}{
+				"apiVersion": "batch/v1",
+				"kind":       "Job",
+				"metadata":   map[string]interface{}{"name": "test"},
+				"status": map[string]interface{}{
+					"conditions": []interface{}{
+						map[string]interface{}{"type": "Failed", "status": "True"},
+					},
+				},
+			},
+			expectedReady: false,
+			expectedErr:   health.ErrFailed.Error(),
+		},
+		{
+			name: "ConfigMap",
+			obj: map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "ConfigMap",
+				"metadata":   map[string]interface{}{"name": "test"},
+			},
+			expectedReady: true,
+		},
+		{
+			name: "CustomResourceReady",
+			obj: map[string]interface{}{
+				"apiVersion": "cert-manager.io/v1",
+				"kind":       "Certificate",
+				"metadata":   map[string]interface{}{"name": "test"},
+				"status": map[string]interface{}{
+					"conditions": []interface{}{
+						map[string]interface{}{"type": "Ready", "status": "True"},
+					},
+				},
+			},
+			expectedReady: true,
+		},
+		{
+			name: "CustomResourceNotReady",
+			obj: map[string]interface{}{
+				"apiVersion": "cert-manager.io/v1",
+				"kind":       "Certificate",
+				"metadata":   map[string]interface{}{"name": "test"},
+				"status": map[string]interface{}{
+					"conditions": []interface{}{
+						map[string]interface{}{"type": "Ready", "status": "False"},
+					},
+				},
+			},
+			expectedReady: false,
+		},
+		{
+			name: "CustomResourceReconciling",
+			obj: map[string]interface{}{
+				"apiVersion": "example.com/v1",
+				"kind":       "Database",
+				"metadata":   map[string]interface{}{"name": "test"},
+				"status": map[string]interface{}{
+					"conditions": []interface{}{
+						map[string]interface{}{"type": "Reconciling", "status": "True"},
+					},
+				},
+			},
+			expectedReady: false,
+		},
+		{
+			name: "CustomResourceStalled",
+			obj: map[string]interface{}{
+				"apiVersion": "example.com/v1",
+				"kind":       "Database",
+				"metadata":   map[string]interface{}{"name": "test"},
+				"status": map[string]interface{}{
+					"conditions": []interface{}{
+						map[string]interface{}{"type": "Stalled", "status": "True"},
+						map[string]interface{}{"type": "Ready", "status": "False"},
+					},
+				},
+			},
+			expectedReady: false,
+			expectedErr:   health.ErrFailed.Error(),
+		},
+		{
+			name: "CustomRuleNotReady",
+			obj: map[string]interface{}{
+				"apiVersion": "monitoring.coreos.com/v1",
+				"kind":       "Prometheus",
+				"metadata":   map[string]interface{}{"name": "test"},
+				"status": map[string]interface{}{
+					"conditions": []interface{}{
+						map[string]interface{}{"type": "Available", "status": "False"},
+					},
+				},
+			},
+			rules:         health.Rules{"Prometheus": "Available"},
+			expectedReady: false,
+		},
+		{
+			name: "CustomRuleReady",
+			obj: map[string]interface{}{
+				"apiVersion": "monitoring.coreos.com/v1",
+				"kind":       "Prometheus",
+				"metadata":   map[string]interface{}{"name": "test"},
+				"status": map[string]interface{}{
+					"conditions": []interface{}{
+						map[string]interface{}{"type": "Available", "status": "True"},
+					},
+				},
+			},
+			rules:         health.Rules{"Prometheus": "Available"},
+			expectedReady: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ready, err := health.IsReady(&unstructured.Unstructured{Object: tc.obj}, tc.rules)
+			if tc.expectedErr != "" {
+				assert.ErrorContains(t, err, tc.expectedErr)
+			} else {
+				assert.NilError(t, err)
+			}
+			assert.Equal(t, ready, tc.expectedReady)
+		})
+	}
+}
@@ -28,24 +28,31 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/go-logr/logr"
 	"github.com/google/go-cmp/cmp"
+	"github.com/kharf/declcd/internal/pool"
 	"github.com/kharf/declcd/pkg/cloud"
+	"github.com/kharf/declcd/pkg/health"
 	"github.com/kharf/declcd/pkg/inventory"
 	"github.com/kharf/declcd/pkg/kube"
+	"github.com/prometheus/client_golang/prometheus"
 	"gopkg.in/yaml.v3"
 	"helm.sh/helm/v3/pkg/action"
 	"helm.sh/helm/v3/pkg/chart"
 	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
 	"helm.sh/helm/v3/pkg/cli"
 	helmKube "helm.sh/helm/v3/pkg/kube"
 	"helm.sh/helm/v3/pkg/registry"
 	"helm.sh/helm/v3/pkg/release"
 	"helm.sh/helm/v3/pkg/storage/driver"
+	corev1 "k8s.io/api/core/v1"
 	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/rest"
 )
 
@@ -64,10 +71,25 @@ type WorkloadIdentity struct {
 	Provider string `json:"provider"`
 }
 
+// GitHubApp authenticates with an installation token minted from a GitHub App private key,
+// for example against the GitHub Container Registry.
+// The referenced secret has to contain the PEM encoded key under "privateKey".
+type GitHubApp struct {
+	AppID          int64     `json:"appID"`
+	InstallationID int64     `json:"installationID"`
+	SecretRef      SecretRef `json:"secretRef"`
+
+	// APIURL of the GitHub REST API. Defaults to https://api.github.com.
+	APIURL string `json:"apiURL"`
+}
+
 // Auth contains methods for repository/registry authentication.
 type Auth struct {
 	SecretRef        *SecretRef        `json:"secretRef"`
 	WorkloadIdentity *WorkloadIdentity `json:"workloadIdentity"`
+	GitHubApp        *GitHubApp        `json:"githubApp"`
+	BearerToken      *BearerToken      `json:"bearerToken"`
+	TLS              *ClientTLS        `json:"tls"`
 }
 
 // A Helm package that contains information
@@ -82,6 +104,9 @@ type Chart struct {
 
 	// Authentication information for private repositories.
 	Auth *Auth `json:"auth,omitempty"`
+
+	// Verify refuses to install the chart, unless it is signed by a trusted key.
+	Verify *Verify `json:"verify,omitempty"`
 }
 
 // ChartReconciler reads Helm Packages with their desired state
@@ -103,8 +128,55 @@ type ChartReconciler struct {
 	// certificate chain and host name.
 	InsecureSkipTLSverify bool
 
+	// InsecureSkipTLSverifyHosts are the hosts, whose certificates are not verified,
+	// while all other hosts are verified unless InsecureSkipTLSverify is set.
+	InsecureSkipTLSverifyHosts RegistryHosts
+
 	// Force http for Helm registries.
 	PlainHTTP bool
+
+	// PlainHTTPHosts are the registry hosts reached via http,
+	// while all other registries are reached via https unless PlainHTTP is set.
+	PlainHTTPHosts RegistryHosts
+
+	// Suppressions exclude fields of release objects from being applied and from conflict detection.
+	Suppressions kube.SuppressionRules
+
+	// Guardrails refuse rendered release objects before they are installed or upgraded.
+	Guardrails kube.Guardrails
+
+	// Registries optionally shares authenticated OCI registry clients between chart pulls.
+	Registries *RegistryClientPool
+
+	// CRDLocks optionally serializes installs and upgrades of releases, which bundle the same CRDs.
+	CRDLocks *CRDLocks
+
+	// PullConcurrency limits the number of charts pulled at the same time by [ChartReconciler.Prefetch].
+	// Defaults to [DefaultPullConcurrency].
+	PullConcurrency int
+
+	// PullRetryBudget is the number of retries shared by all chart pulls of [ChartReconciler.Prefetch].
+	// Defaults to the number of charts to pull.
+	PullRetryBudget int
+
+	// OperationHistogram optionally observes the duration of release operations by release, namespace and [Operation].
+	OperationHistogram *prometheus.HistogramVec
+
+	// PullHistogram optionally observes the duration of chart pulls by repository, chart and result.
+	PullHistogram *prometheus.HistogramVec
+
+	// MaxHistory limits the stored versions per release.
+	// Superseded and failed versions beyond it are deleted on every reconciliation.
+	// Defaults to [DefaultMaxHistory].
+	MaxHistory int
+
+	// SlowRenderThreshold is the rendering duration, above which a chart is logged as slow.
+	// Defaults to [DefaultSlowRenderThreshold].
+	SlowRenderThreshold time.Duration
+
+	// OnEvent is optionally called for upgraded releases, e.g. to emit Kubernetes Events.
+	// The event type is either Normal or Warning.
+	OnEvent func(eventType string, reason string, message string)
 }
 
 type logKey struct{}
@@ -144,12 +216,37 @@ func (c *ChartReconciler) Reconcile(
 
 	// Need to init on every reconcile in order to override the fallback namespace, which is taken from the kube config
 	// when templates have no metadata.namespace defined.
-	helmCfg, err := Init(component.Content.Namespace, c.KubeConfig, c.Client, c.FieldManager)
+	helmCfg, err := Init(
+		component.Content.Namespace,
+		c.KubeConfig,
+		c.Client,
+		c.fieldManager(component.Content),
+		c.Suppressions,
+	)
 	if err != nil {
 		return nil, err
 	}
+	if component.Content.Capabilities != nil {
+		capabilities, err := overrideCapabilities(helmCfg, *component.Content.Capabilities)
+		if err != nil {
+			return nil, err
+		}
+		helmCfg.Capabilities = capabilities
+	}
 	ctx = context.WithValue(ctx, configKey{}, helmCfg)
 
+	resolved, err := c.resolveValues(ctx, component.Content)
+	if err != nil {
+		return nil, err
+	}
+	ctx = context.WithValue(ctx, valuesKey{}, resolved)
+
+	if component.Content.NamespaceMetadata != nil {
+		if err := c.applyNamespaceMetadata(ctx, component.Content); err != nil {
+			return nil, err
+		}
+	}
+
 	installedRelease, err := c.installOrUpgrade(
 		ctx,
 		component,
@@ -159,21 +256,83 @@ func (c *ChartReconciler) Reconcile(
 		return nil, err
 	}
 
+	// The release is reconciled already, so an unsuccessful compaction is retried on the next run.
+	if err := c.compactHistory(ctx, installedRelease.Name); err != nil {
+		logger.Error(err, "Unable to delete superseded release versions")
+	}
+
+	if component.Content.HookResources != nil {
+		installed, err := helmCfg.Releases.Get(installedRelease.Name, installedRelease.Version)
+		if err != nil {
+			return nil, err
+		}
+		installedRelease.HookResources, err = c.trackHookResources(ctx, installed, *component.Content.HookResources)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	installedRelease.Orphan = component.Orphan
 	invRelease := &inventory.HelmReleaseItem{
 		Name:      installedRelease.Name,
 		Namespace: installedRelease.Namespace,
 		ID:        component.ID,
 	}
-	buf := &bytes.Buffer{}
+	buf := pool.GetBuffer()
+	defer pool.PutBuffer(buf)
 	if err := json.NewEncoder(buf).Encode(installedRelease); err != nil {
 		return nil, err
 	}
 	if err := inventoryInstance.StoreItem(invRelease, buf); err != nil {
 		return nil, err
 	}
+
+	if desiredRelease.Wait != nil {
+		if err := c.waitUntilReady(ctx, helmCfg, installedRelease.Name, *desiredRelease.Wait); err != nil {
+			return nil, err
+		}
+	}
+
 	return installedRelease, nil
 }
 
+// applyNamespaceMetadata creates the release namespace if needed and applies the declared labels and annotations.
+// Every release owns its metadata with a dedicated field manager, so that it neither removes fields of a
+// Namespace declared as a manifest nor those of other releases in the same namespace.
+func (c *ChartReconciler) applyNamespaceMetadata(ctx context.Context, release ReleaseDeclaration) error {
+	namespace := &unstructured.Unstructured{}
+	namespace.SetAPIVersion("v1")
+	namespace.SetKind("Namespace")
+	namespace.SetName(release.Namespace)
+	namespace.SetLabels(release.NamespaceMetadata.Labels)
+	namespace.SetAnnotations(release.NamespaceMetadata.Annotations)
+	return c.Client.Apply(
+		ctx,
+		namespace,
+		fmt.Sprintf("%s-%s", c.fieldManager(release), release.Name),
+		kube.Force(true),
+	)
+}
+
+func (c *ChartReconciler) waitUntilReady(
+	ctx context.Context,
+	helmCfg *action.Configuration,
+	releaseName string,
+	wait Wait,
+) error {
+	logger := c.Log.WithValues("releasename", releaseName)
+	manifests, err := RenderedManifests(helmCfg, releaseName)
+	if err != nil {
+		return err
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, wait.Timeout)
+	defer cancel()
+
+	logger.Info("Waiting for release objects to become ready")
+	return health.WaitUntilReady(timeoutCtx, c.Client, manifests, wait.Rules)
+}
+
 // Init setups a Helm config with a Kubernetes client capable of doing SSA
 // and overrides any default namespace with given namespace.
 func Init(
@@ -181,6 +340,7 @@ func Init(
 	kubeConfig *rest.Config,
 	client kube.Client[unstructured.Unstructured],
 	fieldManager string,
+	suppressions kube.SuppressionRules,
 ) (*action.Configuration, error) {
 	helmCfg := &action.Configuration{}
 	voidLog := func(string, ...interface{}) {}
@@ -199,10 +359,53 @@ func Init(
 		Client:        helmKubeClient,
 		DynamicClient: client,
 		FieldManager:  fieldManager,
+		Suppressions:  suppressions,
 	}
 	return helmCfg, nil
 }
 
+// overrideCapabilities discovers the capabilities of the cluster and overrides them with the declared ones.
+func overrideCapabilities(
+	helmConfig *action.Configuration,
+	declared Capabilities,
+) (*chartutil.Capabilities, error) {
+	discoveryClient, err := helmConfig.RESTClientGetter.ToDiscoveryClient()
+	if err != nil {
+		return nil, err
+	}
+
+	apiVersions, err := action.GetVersionSet(discoveryClient)
+	if err != nil && !discovery.IsGroupDiscoveryFailedError(err) {
+		return nil, err
+	}
+	apiVersions = append(apiVersions, declared.APIVersions...)
+
+	var kubeVersion chartutil.KubeVersion
+	if declared.KubeVersion != "" {
+		parsed, err := chartutil.ParseKubeVersion(declared.KubeVersion)
+		if err != nil {
+			return nil, err
+		}
+		kubeVersion = *parsed
+	} else {
+		serverVersion, err := discoveryClient.ServerVersion()
+		if err != nil {
+			return nil, err
+		}
+		kubeVersion = chartutil.KubeVersion{
+			Version: serverVersion.GitVersion,
+			Major:   serverVersion.Major,
+			Minor:   serverVersion.Minor,
+		}
+	}
+
+	return &chartutil.Capabilities{
+		APIVersions: apiVersions,
+		KubeVersion: kubeVersion,
+		HelmVersion: chartutil.DefaultCapabilities.HelmVersion,
+	}, nil
+}
+
 func (c *ChartReconciler) installOrUpgrade(
 	ctx context.Context,
 	component *ReleaseComponent,
@@ -215,11 +418,24 @@ func (c *ChartReconciler) installOrUpgrade(
 	log.Info("Loading chart")
 
 	helmConfig := ctx.Value(configKey{}).(*action.Configuration)
+	resolved := ctx.Value(valuesKey{}).(*resolvedValues)
 	chrt, err := c.load(ctx, desiredRelease.Chart)
 	if err != nil {
 		return nil, err
 	}
 
+	if c.CRDLocks != nil {
+		crds, err := BundledCRDs(chrt)
+		if err != nil {
+			return nil, err
+		}
+		if len(crds) != 0 {
+			log.V(1).Info("Waiting for releases bundling the same CRDs", "crds", crds)
+			unlock := c.CRDLocks.Lock(crds)
+			defer unlock()
+		}
+	}
+
 	histClient := action.NewHistory(helmConfig)
 	histClient.Max = 2
 	releases, err := histClient.Run(desiredRelease.Name)
@@ -238,6 +454,14 @@ func (c *ChartReconciler) installOrUpgrade(
 		}
 	}
 
+	releases, reinstall, err := remediate(ctx, releases)
+	if err != nil {
+		return nil, err
+	}
+	if reinstall {
+		return c.install(ctx, desiredRelease, chrt)
+	}
+
 	drift, err := c.diff(
 		ctx,
 		component,
@@ -249,45 +473,105 @@ func (c *ChartReconciler) installOrUpgrade(
 		return nil, err
 	}
 
+	if drift.driftType == driftTypeDeleted && drift.affectedManifest != nil {
+		orphaned, err := c.isOrphaned(ctx, releases[len(releases)-1])
+		if err != nil {
+			return nil, err
+		}
+		if orphaned {
+			log.Info("Purging orphaned release, because all of its objects were deleted")
+			if err := purge(ctx, desiredRelease.Name); err != nil {
+				return nil, err
+			}
+			return c.install(ctx, desiredRelease, chrt)
+		}
+	}
+
 	if drift.driftType == driftTypeNone {
 		log.Info("No changes")
 		latestInternalRelease := releases[len(releases)-1]
 		return &Release{
-			Name:      latestInternalRelease.Name,
-			Namespace: latestInternalRelease.Namespace,
-			Chart:     desiredRelease.Chart,
-			Values:    desiredRelease.Values,
-			Version:   latestInternalRelease.Version,
+			Name:             latestInternalRelease.Name,
+			Namespace:        latestInternalRelease.Namespace,
+			Chart:            desiredRelease.Chart,
+			Values:           desiredRelease.Values,
+			Capabilities:     desiredRelease.Capabilities,
+			ValuesFrom:       desiredRelease.ValuesFrom,
+			ValuesFromDigest: resolved.digest,
+			Patches:          desiredRelease.Patches,
+			FieldManager:     desiredRelease.FieldManager,
+			Version:          latestInternalRelease.Version,
 		}, nil
 	}
 
 	logDrift(ctx, drift.driftType, drift.affectedManifest, err)
 
 	upgrade := action.NewUpgrade(helmConfig)
-	upgrade.PlainHTTP = c.PlainHTTP
+	upgrade.PlainHTTP = c.plainHTTP(desiredRelease.Chart.RepoURL)
 	upgrade.Wait = false
 	upgrade.Namespace = desiredRelease.Namespace
-	upgrade.MaxHistory = 5
+	upgrade.PostRenderer = c.postRenderer(desiredRelease.Patches)
+	upgrade.MaxHistory = c.maxHistory()
 	if drift.driftType == driftTypeConflict {
 		upgrade.Force = true
 	}
 
+	if err := c.preflight(ctx, desiredRelease, chrt); err != nil {
+		return nil, err
+	}
+
 	log.Info("Upgrading release")
 
-	release, err := upgrade.Run(desiredRelease.Name, chrt, desiredRelease.Values)
+	start := time.Now()
+	release, err := upgrade.Run(desiredRelease.Name, chrt, resolved.values)
 	if err != nil {
 		return nil, err
 	}
+	c.observe(OperationUpgrade, desiredRelease, time.Since(start))
+	c.observeHooks(desiredRelease, release)
+
+	if drift.driftType == driftTypeConflict {
+		c.event(
+			corev1.EventTypeWarning,
+			"ConflictOverridden",
+			fmt.Sprintf(
+				"Forced upgrade of Helm release %s/%s to revision %d over conflicting changes of %s %s: %v",
+				release.Namespace,
+				release.Name,
+				release.Version,
+				drift.affectedManifest.GetKind(),
+				drift.affectedManifest.GetName(),
+				drift.cause,
+			),
+		)
+	} else {
+		c.event(
+			corev1.EventTypeNormal,
+			"Upgraded",
+			fmt.Sprintf("Upgraded Helm release %s/%s to revision %d", release.Namespace, release.Name, release.Version),
+		)
+	}
 
 	return &Release{
-		Name:      release.Name,
-		Namespace: release.Namespace,
-		Chart:     desiredRelease.Chart,
-		Values:    desiredRelease.Values,
-		Version:   release.Version,
+		Name:             release.Name,
+		Namespace:        release.Namespace,
+		Chart:            desiredRelease.Chart,
+		Values:           desiredRelease.Values,
+		Capabilities:     desiredRelease.Capabilities,
+		ValuesFrom:       desiredRelease.ValuesFrom,
+		ValuesFromDigest: resolved.digest,
+		Patches:          desiredRelease.Patches,
+		FieldManager:     desiredRelease.FieldManager,
+		Version:          release.Version,
 	}, nil
 }
 
+func (c *ChartReconciler) event(eventType string, reason string, message string) {
+	if c.OnEvent != nil {
+		c.OnEvent(eventType, reason, message)
+	}
+}
+
 type drift struct {
 	driftType        driftType
 	affectedManifest *unstructured.Unstructured
@@ -313,13 +597,17 @@ func (c *ChartReconciler) diff(
 	releaseDeclaration := component.Content
 
 	helmConfig := ctx.Value(configKey{}).(*action.Configuration)
+	resolved := ctx.Value(valuesKey{}).(*resolvedValues)
 	upgrade := action.NewUpgrade(helmConfig)
-	upgrade.PlainHTTP = c.PlainHTTP
+	upgrade.PlainHTTP = c.plainHTTP(releaseDeclaration.Chart.RepoURL)
 	upgrade.Wait = false
 	upgrade.Namespace = releaseDeclaration.Namespace
+	upgrade.PostRenderer = c.postRenderer(releaseDeclaration.Patches)
 	upgrade.DryRun = true
 
-	release, err := upgrade.Run(releaseDeclaration.Name, loadedChart, releaseDeclaration.Values)
+	start := time.Now()
+	release, err := upgrade.Run(releaseDeclaration.Name, loadedChart, resolved.values)
+	c.observeRender(ctx, releaseDeclaration, time.Since(start))
 	if err != nil {
 		release := releases[len(releases)-1]
 		if !release.Info.Status.IsPending() {
@@ -376,7 +664,8 @@ func (c *ChartReconciler) diff(
 			}, nil
 		}
 
-		if err := c.Client.Apply(ctx, newManifest, c.FieldManager, kube.DryRun(true)); err != nil {
+		c.Suppressions.Strip(newManifest)
+		if err := c.Client.Apply(ctx, newManifest, c.fieldManager(releaseDeclaration), kube.DryRun(true)); err != nil {
 			switch k8sErrors.ReasonForError(err) {
 			case v1.StatusReasonUnknown:
 				return nil, err
@@ -413,12 +702,23 @@ func (c *ChartReconciler) diff(
 		return nil, err
 	}
 
+	// Wait, NamespaceMetadata and ServiceAccountName are not part of the stored release and do not require an upgrade.
+	releaseDeclaration.Wait = nil
+	releaseDeclaration.NamespaceMetadata = nil
+	releaseDeclaration.ServiceAccountName = ""
+	// HookResources only select the tracked hook objects, which are stored instead of the selector.
+	releaseDeclaration.HookResources = nil
+	// Referenced values are compared by digest, as their content is not stored.
 	if isEqual := cmp.Equal(releaseDeclaration, ReleaseDeclaration{
-		Name:      storedRelease.Name,
-		Namespace: storedRelease.Namespace,
-		Chart:     storedRelease.Chart,
-		Values:    storedRelease.Values,
-	}); isEqual {
+		Name:         storedRelease.Name,
+		Namespace:    storedRelease.Namespace,
+		Chart:        storedRelease.Chart,
+		Values:       storedRelease.Values,
+		Capabilities: storedRelease.Capabilities,
+		ValuesFrom:   storedRelease.ValuesFrom,
+		Patches:      storedRelease.Patches,
+		FieldManager: storedRelease.FieldManager,
+	}); isEqual && storedRelease.ValuesFromDigest == resolved.digest {
 		return &drift{
 			driftType: driftTypeNone,
 		}, nil
@@ -469,27 +769,41 @@ func (c *ChartReconciler) install(
 	log := ctx.Value(logKey{}).(*logr.Logger)
 
 	helmConfig := ctx.Value(configKey{}).(*action.Configuration)
+	resolved := ctx.Value(valuesKey{}).(*resolvedValues)
 	install := action.NewInstall(helmConfig)
-	install.PlainHTTP = c.PlainHTTP
+	install.PlainHTTP = c.plainHTTP(desiredRelease.Chart.RepoURL)
 	install.Wait = false
 	install.ReleaseName = desiredRelease.Name
 	install.CreateNamespace = true
 	install.Namespace = desiredRelease.Namespace
+	install.PostRenderer = c.postRenderer(desiredRelease.Patches)
+
+	if err := c.preflight(ctx, desiredRelease, loadedChart); err != nil {
+		return nil, err
+	}
 
 	log.Info("Installing chart")
 
-	release, err := install.Run(loadedChart, desiredRelease.Values)
+	start := time.Now()
+	release, err := install.Run(loadedChart, resolved.values)
 	if err != nil {
 		log.Error(err, "Installing chart failed")
 		return nil, err
 	}
+	c.observe(OperationInstall, desiredRelease, time.Since(start))
+	c.observeHooks(desiredRelease, release)
 
 	return &Release{
-		Name:      release.Name,
-		Namespace: release.Namespace,
-		Chart:     desiredRelease.Chart,
-		Values:    desiredRelease.Values,
-		Version:   release.Version,
+		Name:             release.Name,
+		Namespace:        release.Namespace,
+		Chart:            desiredRelease.Chart,
+		Values:           desiredRelease.Values,
+		Capabilities:     desiredRelease.Capabilities,
+		ValuesFrom:       desiredRelease.ValuesFrom,
+		ValuesFromDigest: resolved.digest,
+		Patches:          desiredRelease.Patches,
+		FieldManager:     desiredRelease.FieldManager,
+		Version:          release.Version,
 	}, nil
 }
 
@@ -511,6 +825,106 @@ func reset(
 	return nil
 }
 
+// remediate brings a release stuck in a state, which an upgrade can not recover from, into a consistent state.
+// It returns the remaining releases and reports whether the release has to be installed from scratch.
+func remediate(
+	ctx context.Context,
+	releases []*release.Release,
+) ([]*release.Release, bool, error) {
+	log := ctx.Value(logKey{}).(*logr.Logger)
+	helmConfig := ctx.Value(configKey{}).(*action.Configuration)
+
+	latest := releases[len(releases)-1]
+	switch latest.Info.Status {
+	case release.StatusUninstalling:
+		// An interrupted uninstallation leaves objects behind, which are removed before installing again.
+		log.Info("Finishing interrupted uninstallation")
+		uninstall := action.NewUninstall(helmConfig)
+		uninstall.Wait = false
+		if _, err := uninstall.Run(latest.Name); err != nil {
+			return nil, false, err
+		}
+		return nil, true, nil
+	case release.StatusUninstalled:
+		// Uninstallations keeping the history block new installations.
+		if err := purge(ctx, latest.Name); err != nil {
+			return nil, false, err
+		}
+		return nil, true, nil
+	case release.StatusPendingRollback:
+		if err := reset(ctx, latest); err != nil {
+			return nil, false, err
+		}
+		releases = releases[:len(releases)-1]
+		return releases, len(releases) == 0, nil
+	}
+
+	return releases, false, nil
+}
+
+// purge removes all stored versions of a release without touching its objects.
+func purge(
+	ctx context.Context,
+	releaseName string,
+) error {
+	log := ctx.Value(logKey{}).(*logr.Logger)
+
+	log.Info("Purging release history")
+
+	helmConfig := ctx.Value(configKey{}).(*action.Configuration)
+	history, err := helmConfig.Releases.History(releaseName)
+	if err != nil {
+		return err
+	}
+	for _, rel := range history {
+		if _, err := helmConfig.Releases.Delete(rel.Name, rel.Version); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// isOrphaned reports whether none of the objects of a release exist anymore, because they were deleted manually.
+func (c *ChartReconciler) isOrphaned(
+	ctx context.Context,
+	rel *release.Release,
+) (bool, error) {
+	decoder := yaml.NewDecoder(bytes.NewBufferString(rel.Manifest))
+	found := false
+	for {
+		var unstr map[string]interface{}
+		if err := decoder.Decode(&unstr); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return false, err
+		}
+		if len(unstr) == 0 {
+			continue
+		}
+
+		manifest := &unstructured.Unstructured{Object: unstr}
+		if manifest.GetNamespace() == "" {
+			manifest.SetNamespace(rel.Namespace)
+		}
+
+		obj, err := c.Client.Get(ctx, manifest)
+		if err != nil {
+			if k8sErrors.IsNotFound(err) {
+				continue
+			}
+			return false, err
+		}
+		if obj != nil {
+			found = true
+			break
+		}
+	}
+
+	return !found && rel.Manifest != "", nil
+}
+
 func (c *ChartReconciler) load(
 	ctx context.Context,
 	chartRequest Chart,
@@ -542,75 +956,109 @@ func (c *ChartReconciler) pull(
 	ctx context.Context,
 	chartRequest Chart,
 	chartDestPath string,
+) error {
+	start := time.Now()
+	err := c.pullChart(ctx, chartRequest, chartDestPath)
+	c.observePull(chartRequest, time.Since(start), err)
+	return err
+}
+
+func (c *ChartReconciler) pullChart(
+	ctx context.Context,
+	chartRequest Chart,
+	chartDestPath string,
 ) error {
 	helmConfig := ctx.Value(configKey{}).(*action.Configuration)
 	pull := action.NewPullWithOpts(action.WithConfig(helmConfig))
 	pull.DestDir = chartDestPath
 
-	httpClient := http.DefaultClient
-	httpClient.Transport = &http.Transport{
-		TLSClientConfig: &tls.Config{
-			InsecureSkipVerify: c.InsecureSkipTLSverify,
+	insecureSkipTLSverify := c.insecureSkipTLSverify(chartRequest.RepoURL)
+	plainHTTP := c.plainHTTP(chartRequest.RepoURL)
+	// A dedicated client instead of mutating http.DefaultClient, as charts may be pulled concurrently.
+	httpClient := &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{
+				InsecureSkipVerify: insecureSkipTLSverify,
+			},
 		},
 	}
-	pull.PlainHTTP = c.PlainHTTP
-	pull.InsecureSkipTLSverify = c.InsecureSkipTLSverify
+	pull.PlainHTTP = plainHTTP
+	pull.InsecureSkipTLSverify = insecureSkipTLSverify
 
 	var chartRef string
 	if registry.IsOCI(chartRequest.RepoURL) {
+		if usesIndexAuth(chartRequest.Auth) {
+			return fmt.Errorf(
+				"%w: bearerToken and tls are only supported by index.yaml repositories: %s",
+				ErrUnsupportedAuth,
+				chartRequest.RepoURL,
+			)
+		}
 		opts := []registry.ClientOption{
 			registry.ClientOptDebug(false),
 			registry.ClientOptEnableCache(true),
 			registry.ClientOptWriter(os.Stderr),
 			registry.ClientOptHTTPClient(httpClient),
 		}
-		if c.PlainHTTP {
+		if plainHTTP {
 			opts = append(opts, registry.ClientOptPlainHTTP())
 		}
-		registryClient, err := registry.NewClient(opts...)
+		host, _ := strings.CutPrefix(chartRequest.RepoURL, "oci://")
+		registryClient, err := c.Registries.get(
+			registryKey(host, chartRequest.Auth),
+			func() (*registry.Client, error) {
+				return registry.NewClient(opts...)
+			},
+			func(registryClient *registry.Client) error {
+				if chartRequest.Auth == nil {
+					return nil
+				}
+
+				creds, err := c.registryCredentials(ctx, chartRequest, host, httpClient)
+				if err != nil {
+					return err
+				}
+
+				return registryClient.Login(
+					host,
+					registry.LoginOptBasicAuth(creds.Username, creds.Password),
+				)
+			},
+		)
 		if err != nil {
 			return err
 		}
 		pull.SetRegistryClient(registryClient)
 
+		chartRef = fmt.Sprintf("%s/%s", chartRequest.RepoURL, chartRequest.Name)
+	} else {
+		// Helm's pull action only supports basic auth and client certificates read from files.
+		if usesIndexAuth(chartRequest.Auth) {
+			return c.pullFromIndex(ctx, chartRequest, chartDestPath)
+		}
 		if chartRequest.Auth != nil {
-			host, _ := strings.CutPrefix(chartRequest.RepoURL, "oci://")
-
 			var creds *cloud.Credentials
-			if chartRequest.Auth.WorkloadIdentity != nil {
-				provider := cloud.GetProvider(
-					cloud.ProviderID(chartRequest.Auth.WorkloadIdentity.Provider),
-					host,
-					httpClient,
-				)
-				creds, err = provider.FetchCredentials(ctx)
-				if err != nil {
-					return err
-				}
+			var err error
+			if chartRequest.Auth.GitHubApp != nil {
+				creds, err = c.fetchGitHubAppCredentials(ctx, chartRequest, httpClient)
 			} else {
 				creds, err = c.readCredentialsFromSecret(ctx, chartRequest)
-				if err != nil {
-					return err
-				}
 			}
-
-			if err := registryClient.Login(
-				host,
-				registry.LoginOptBasicAuth(creds.Username, creds.Password),
-			); err != nil {
+			if err != nil {
 				return err
 			}
+			pull.Username = creds.Username
+			pull.Password = creds.Password
 		}
 
-		chartRef = fmt.Sprintf("%s/%s", chartRequest.RepoURL, chartRequest.Name)
-	} else {
-		if chartRequest.Auth != nil {
-			creds, err := c.readCredentialsFromSecret(ctx, chartRequest)
+		if chartRequest.Verify != nil {
+			keyring, err := c.provenanceKeyring(ctx, *chartRequest.Verify)
 			if err != nil {
 				return err
 			}
-			pull.Username = creds.Username
-			pull.Password = creds.Password
+			defer os.Remove(keyring)
+			pull.Verify = true
+			pull.Keyring = keyring
 		}
 
 		pull.RepoURL = chartRequest.RepoURL
@@ -624,13 +1072,75 @@ func (c *ChartReconciler) pull(
 		return err
 	}
 
+	archive := newArchivePath(chartRequest).fullPath
 	_, err = pull.Run(chartRef)
 	if err != nil {
+		if chartRequest.Verify != nil {
+			// Helm keeps archives failing verification, which must never be loaded from the cache.
+			_ = os.Remove(archive)
+			return fmt.Errorf("%w: %w", ErrChartVerification, err)
+		}
 		return err
 	}
+
+	if chartRequest.Verify != nil && registry.IsOCI(chartRequest.RepoURL) {
+		host, _ := strings.CutPrefix(chartRequest.RepoURL, "oci://")
+		host, _, _ = strings.Cut(host, "/")
+		var creds *cloud.Credentials
+		if chartRequest.Auth != nil {
+			creds, err = c.registryCredentials(ctx, chartRequest, host, httpClient)
+			if err != nil {
+				_ = os.Remove(archive)
+				return err
+			}
+		}
+		if err := c.verifyOCIChart(ctx, chartRequest, archive, creds, httpClient); err != nil {
+			_ = os.Remove(archive)
+			return err
+		}
+	}
 	return nil
 }
 
+// registryCredentials fetches the credentials of the chart registry.
+func (c *ChartReconciler) registryCredentials(
+	ctx context.Context,
+	chartRequest Chart,
+	host string,
+	httpClient *http.Client,
+) (*cloud.Credentials, error) {
+	if chartRequest.Auth.WorkloadIdentity != nil {
+		provider := cloud.GetProvider(
+			cloud.ProviderID(chartRequest.Auth.WorkloadIdentity.Provider),
+			host,
+			httpClient,
+		)
+		return provider.FetchCredentials(ctx)
+	}
+	if chartRequest.Auth.GitHubApp != nil {
+		return c.fetchGitHubAppCredentials(ctx, chartRequest, httpClient)
+	}
+	return c.readCredentialsFromSecret(ctx, chartRequest)
+}
+
+// fieldManager returns the field manager declared by the release or the one of the controller.
+func (c *ChartReconciler) fieldManager(release ReleaseDeclaration) string {
+	if release.FieldManager != "" {
+		return release.FieldManager
+	}
+	return c.FieldManager
+}
+
+// insecureSkipTLSverify reports whether the certificate of the host of given repository is not verified.
+func (c *ChartReconciler) insecureSkipTLSverify(repoURL string) bool {
+	return c.InsecureSkipTLSverify || c.InsecureSkipTLSverifyHosts.Contains(repoURL)
+}
+
+// plainHTTP reports whether the registry of given repository is reached via http.
+func (c *ChartReconciler) plainHTTP(repoURL string) bool {
+	return c.PlainHTTP || c.PlainHTTPHosts.Contains(repoURL)
+}
+
 func (c *ChartReconciler) readCredentialsFromSecret(
 	ctx context.Context,
 	chartRequest Chart,
@@ -675,6 +1185,48 @@ func (c *ChartReconciler) readCredentialsFromSecret(
 	}, nil
 }
 
+func (c *ChartReconciler) fetchGitHubAppCredentials(
+	ctx context.Context,
+	chartRequest Chart,
+	httpClient *http.Client,
+) (*cloud.Credentials, error) {
+	githubApp := chartRequest.Auth.GitHubApp
+
+	secretReq := &unstructured.Unstructured{}
+	secretReq.SetKind("Secret")
+	secretReq.SetAPIVersion("v1")
+	secretReq.SetName(githubApp.SecretRef.Name)
+	secretReq.SetNamespace(githubApp.SecretRef.Namespace)
+	secret, err := c.Client.Get(ctx, secretReq)
+	if err != nil {
+		return nil, err
+	}
+
+	var privateKey string
+	data, found := secret.Object["data"].(map[string]interface{})
+	if found {
+		privateKey, err = getSecretValue(data, "privateKey", false)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		stringData, _ := secret.Object["stringData"].(map[string]string)
+		privateKey = stringData["privateKey"]
+		if privateKey == "" {
+			return nil, fmt.Errorf("%w: privateKey is empty", ErrAuthSecretValueNotFound)
+		}
+	}
+
+	provider := &cloud.GitHubAppProvider{
+		HttpClient:     httpClient,
+		APIURL:         githubApp.APIURL,
+		AppID:          githubApp.AppID,
+		InstallationID: githubApp.InstallationID,
+		PrivateKey:     []byte(privateKey),
+	}
+	return provider.FetchCredentials(ctx)
+}
+
 func getSecretValue(data map[string]interface{}, key string, isOptional bool) (string, error) {
 	value := data[key]
 	if value == nil {
@@ -690,6 +1242,42 @@ func getSecretValue(data map[string]interface{}, key string, isOptional bool) (s
 	return string(bytes), nil
 }
 
+// RenderedManifests returns the objects of the latest revision of an installed release.
+func RenderedManifests(helmConfig *action.Configuration, releaseName string) ([]unstructured.Unstructured, error) {
+	release, err := action.NewGet(helmConfig).Run(releaseName)
+	if err != nil {
+		return nil, err
+	}
+	return decodeManifests(release)
+}
+
+// decodeManifests decodes the rendered manifest of a release,
+// defaulting the namespace of all objects to the release namespace.
+func decodeManifests(rel *release.Release) ([]unstructured.Unstructured, error) {
+	manifests := make([]unstructured.Unstructured, 0)
+	decoder := yaml.NewDecoder(bytes.NewBufferString(rel.Manifest))
+	for {
+		var unstr map[string]interface{}
+		if err := decoder.Decode(&unstr); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, err
+		}
+		if len(unstr) == 0 {
+			continue
+		}
+
+		manifest := unstructured.Unstructured{Object: unstr}
+		if manifest.GetNamespace() == "" {
+			manifest.SetNamespace(rel.Namespace)
+		}
+		manifests = append(manifests, manifest)
+	}
+
+	return manifests, nil
+}
+
 // Remove removes the locally stored Helm Chart from the file system, but does not uninstall the Chart/Release.
 func Remove(chart Chart) error {
 	return os.RemoveAll(newArchivePath(chart).fullPath)
@@ -703,6 +1291,10 @@ type archivePath struct {
 func newArchivePath(chart Chart) archivePath {
 	chartIdentifier := fmt.Sprintf("%s-%s", chart.Name, chart.Version)
 	chartDestPath := filepath.Join(os.TempDir(), chart.Name)
+	if chart.Verify != nil {
+		// A cached unverified archive must never be loaded for a chart requiring verification.
+		chartDestPath = filepath.Join(os.TempDir(), "verified", chart.Name)
+	}
 	fullPath := filepath.Join(chartDestPath, fmt.Sprintf("%s.tgz", chartIdentifier))
 	return archivePath{
 		dir:      chartDestPath,
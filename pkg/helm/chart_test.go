@@ -19,12 +19,17 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"testing"
 	"text/template"
 
+	"github.com/go-logr/logr"
 	_ "github.com/kharf/declcd/test/workingdir"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"helm.sh/helm/v3/pkg/action"
 	"helm.sh/helm/v3/pkg/release"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/types"
@@ -107,6 +112,13 @@ func TestChartReconciler_Reconcile(t *testing.T) {
 	privateHelmEnvironment := newHelmEnvironment(false, true, "")
 	defer privateHelmEnvironment.Close()
 
+	bearerHelmEnvironment, err := helmtest.NewHelmEnvironment(
+		helmtest.WithPrivate(true),
+		helmtest.WithBearerToken("abcd"),
+	)
+	assertError(err)
+	defer bearerHelmEnvironment.Close()
+
 	publicOciHelmEnvironment := newHelmEnvironment(true, false, "")
 	defer publicOciHelmEnvironment.Close()
 
@@ -179,6 +191,49 @@ func TestChartReconciler_Reconcile(t *testing.T) {
 				assert.Equal(t, hpa.Namespace, context.releaseDeclaration.Namespace)
 			},
 		},
+		{
+			name: "Metrics",
+			setup: func() testCaseContext {
+				release := createReleaseDeclaration(
+					"default",
+					publicHelmEnvironment.ChartServer.URL(),
+					"1.0.0",
+					nil,
+					Values{},
+				)
+
+				return testCaseContext{
+					releaseDeclaration: release,
+					chartServer:        publicHelmEnvironment.ChartServer,
+					assertFunc:         defaultAssertionFunc(release),
+				}
+			},
+			postRun: func(context testCaseContext) {
+				histogram := context.chartReconciler.OperationHistogram
+				install, err := histogram.GetMetricWithLabelValues(
+					context.releaseDeclaration.Name,
+					context.releaseDeclaration.Namespace,
+					string(helm.OperationInstall),
+				)
+				assert.NilError(t, err)
+				assert.Equal(t, testutil.CollectAndCount(install.(prometheus.Histogram)), 1)
+
+				_, err = context.chartReconciler.Reconcile(
+					context.environment.Ctx,
+					&helm.ReleaseComponent{
+						ID: fmt.Sprintf(
+							"%s_%s_%s",
+							context.releaseDeclaration.Name,
+							context.releaseDeclaration.Namespace,
+							"HelmRelease",
+						),
+						Content: context.releaseDeclaration,
+					},
+				)
+				assert.NilError(t, err)
+				assert.Equal(t, testutil.CollectAndCount(histogram), 2)
+			},
+		},
 		{
 			name: "HTTP-Auth-Secret-Not-Found",
 			setup: func() testCaseContext {
@@ -256,6 +311,63 @@ func TestChartReconciler_Reconcile(t *testing.T) {
 			postRun: func(context testCaseContext) {
 			},
 		},
+		{
+			name: "HTTP-Bearer-Auth",
+			setup: func() testCaseContext {
+				release := createReleaseDeclaration(
+					"default",
+					bearerHelmEnvironment.ChartServer.URL(),
+					"1.0.0",
+					&Auth{
+						BearerToken: &BearerToken{
+							SecretRef: SecretRef{
+								Name:      "token",
+								Namespace: "default",
+							},
+						},
+					},
+					Values{},
+				)
+
+				return testCaseContext{
+					releaseDeclaration: release,
+					createAuthSecret:   true,
+					chartServer:        publicHelmEnvironment.ChartServer,
+					assertFunc:         defaultAssertionFunc(release),
+				}
+			},
+			postRun: func(context testCaseContext) {
+			},
+		},
+		{
+			name: "OCI-Bearer-Auth-Unsupported",
+			setup: func() testCaseContext {
+				release := createReleaseDeclaration(
+					"default",
+					publicOciHelmEnvironment.ChartServer.URL(),
+					"1.0.0",
+					&Auth{
+						BearerToken: &BearerToken{
+							SecretRef: SecretRef{
+								Name:      "token",
+								Namespace: "default",
+							},
+						},
+					},
+					Values{},
+				)
+
+				return testCaseContext{
+					releaseDeclaration: release,
+					chartServer:        publicOciHelmEnvironment.ChartServer,
+					assertFunc: func(t *testing.T, env *kubetest.Environment, reconcileErr error, actualRelease *helm.Release, liveName, namespace string) {
+						assert.ErrorContains(t, reconcileErr, ErrUnsupportedAuth.Error())
+					},
+				}
+			},
+			postRun: func(context testCaseContext) {
+			},
+		},
 		{
 			name: "OCI",
 			setup: func() testCaseContext {
@@ -446,6 +558,220 @@ func TestChartReconciler_Reconcile(t *testing.T) {
 			postRun: func(context testCaseContext) {
 			},
 		},
+		{
+			name: "Namespace-Metadata",
+			setup: func() testCaseContext {
+				release := createReleaseDeclaration(
+					"restricted",
+					publicHelmEnvironment.ChartServer.URL(),
+					"1.0.0",
+					nil,
+					Values{},
+				)
+				release.NamespaceMetadata = &NamespaceMetadata{
+					Labels: map[string]string{
+						"pod-security.kubernetes.io/enforce": "baseline",
+					},
+					Annotations: map[string]string{
+						"owner": "declcd",
+					},
+				}
+
+				return testCaseContext{
+					releaseDeclaration: release,
+					chartServer:        publicHelmEnvironment.ChartServer,
+					assertFunc: func(t *testing.T, env *kubetest.Environment, reconcileErr error, actualRelease *helm.Release, liveName, namespace string) {
+						defaultAssertionFunc(release)(t, env, reconcileErr, actualRelease, liveName, namespace)
+						var ns corev1.Namespace
+						err := env.TestKubeClient.Get(
+							context.Background(),
+							types.NamespacedName{Name: namespace},
+							&ns,
+						)
+						assert.NilError(t, err)
+						assert.Equal(t, ns.Labels["pod-security.kubernetes.io/enforce"], "baseline")
+						assert.Equal(t, ns.Annotations["owner"], "declcd")
+					},
+				}
+			},
+			postRun: func(context testCaseContext) {
+			},
+		},
+		{
+			name: "ValuesFrom",
+			setup: func() testCaseContext {
+				env := projecttest.StartProjectEnv(t)
+				err := env.TestKubeClient.Create(env.Ctx, &corev1.ConfigMap{
+					ObjectMeta: v1.ObjectMeta{
+						Name:      "values",
+						Namespace: "default",
+					},
+					Data: map[string]string{
+						DefaultValuesKey: "autoscaling:\n  enabled: false\n",
+					},
+				})
+				assert.NilError(t, err)
+
+				release := createReleaseDeclaration(
+					"default",
+					publicHelmEnvironment.ChartServer.URL(),
+					"1.0.0",
+					nil,
+					Values{},
+				)
+				release.ValuesFrom = []ValuesReference{
+					{
+						Kind: "ConfigMap",
+						Name: "values",
+					},
+					{
+						Kind:     "Secret",
+						Name:     "overrides",
+						Optional: true,
+					},
+				}
+
+				return testCaseContext{
+					environment:        &env,
+					releaseDeclaration: release,
+					chartServer:        publicHelmEnvironment.ChartServer,
+					assertFunc: func(t *testing.T, env *kubetest.Environment, reconcileErr error, actualRelease *helm.Release, liveName, namespace string) {
+						defaultAssertionFunc(release)(t, env, reconcileErr, actualRelease, liveName, namespace)
+						assert.Assert(t, actualRelease.ValuesFromDigest != "")
+					},
+				}
+			},
+			postRun: func(context testCaseContext) {
+				defer context.environment.Stop()
+				ctx := context.environment.Ctx
+				component := &helm.ReleaseComponent{
+					ID: fmt.Sprintf(
+						"%s_%s_%s",
+						context.releaseDeclaration.Name,
+						context.releaseDeclaration.Namespace,
+						"HelmRelease",
+					),
+					Content: context.releaseDeclaration,
+				}
+
+				unchangedRelease, err := context.chartReconciler.Reconcile(ctx, component)
+				assert.NilError(t, err)
+				assert.Equal(t, unchangedRelease.Version, 1)
+
+				err = context.environment.TestKubeClient.Update(ctx, &corev1.ConfigMap{
+					ObjectMeta: v1.ObjectMeta{
+						Name:      "values",
+						Namespace: "default",
+					},
+					Data: map[string]string{
+						DefaultValuesKey: "autoscaling:\n  enabled: true\n",
+					},
+				})
+				assert.NilError(t, err)
+
+				actualRelease, err := context.chartReconciler.Reconcile(ctx, component)
+				assert.NilError(t, err)
+				assert.Equal(t, actualRelease.Version, 2)
+				assert.Assert(t, actualRelease.ValuesFromDigest != unchangedRelease.ValuesFromDigest)
+			},
+		},
+		{
+			name: "Patches",
+			setup: func() testCaseContext {
+				env := projecttest.StartProjectEnv(t)
+				release := createReleaseDeclaration(
+					"default",
+					publicHelmEnvironment.ChartServer.URL(),
+					"1.0.0",
+					nil,
+					Values{},
+				)
+				release.Patches = Patches{
+					{
+						Target: PatchTarget{Kind: "Service"},
+						Merge: map[string]interface{}{
+							"metadata": map[string]interface{}{
+								"labels": map[string]interface{}{
+									"patched": "merge",
+								},
+							},
+						},
+					},
+					{
+						Target: PatchTarget{APIVersion: "apps/v1", Kind: "Deployment"},
+						StrategicMerge: map[string]interface{}{
+							"spec": map[string]interface{}{
+								"template": map[string]interface{}{
+									"spec": map[string]interface{}{
+										"containers": []interface{}{
+											map[string]interface{}{
+												"name":            "test",
+												"imagePullPolicy": "Always",
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+					{
+						Target: PatchTarget{Kind: "Deployment"},
+						JSONPatch: []JSONPatchOperation{
+							{
+								Op:   "remove",
+								Path: "/spec/template/spec/containers/0/livenessProbe",
+							},
+						},
+					},
+				}
+
+				return testCaseContext{
+					environment:        &env,
+					releaseDeclaration: release,
+					chartServer:        publicHelmEnvironment.ChartServer,
+					assertFunc: func(t *testing.T, env *kubetest.Environment, reconcileErr error, actualRelease *helm.Release, liveName, namespace string) {
+						defaultAssertionFunc(release)(t, env, reconcileErr, actualRelease, liveName, namespace)
+						ctx := context.Background()
+						var svc corev1.Service
+						err := env.TestKubeClient.Get(
+							ctx,
+							types.NamespacedName{Name: liveName, Namespace: namespace},
+							&svc,
+						)
+						assert.NilError(t, err)
+						assert.Equal(t, svc.Labels["patched"], "merge")
+						var deployment appsv1.Deployment
+						err = env.TestKubeClient.Get(
+							ctx,
+							types.NamespacedName{Name: liveName, Namespace: namespace},
+							&deployment,
+						)
+						assert.NilError(t, err)
+						assert.Assert(t, len(deployment.Spec.Template.Spec.Containers) == 1)
+						container := deployment.Spec.Template.Spec.Containers[0]
+						assert.Assert(t, container.Image != "")
+						assert.Equal(t, container.ImagePullPolicy, corev1.PullAlways)
+						assert.Assert(t, container.LivenessProbe == nil)
+						assert.DeepEqual(t, actualRelease.Patches, release.Patches)
+					},
+				}
+			},
+			postRun: func(context testCaseContext) {
+				defer context.environment.Stop()
+				component := &helm.ReleaseComponent{
+					ID: fmt.Sprintf(
+						"%s_%s_%s",
+						context.releaseDeclaration.Name,
+						context.releaseDeclaration.Namespace,
+						"HelmRelease",
+					),
+					Content: context.releaseDeclaration,
+				}
+				unchangedRelease, err := context.chartReconciler.Reconcile(context.environment.Ctx, component)
+				assert.NilError(t, err)
+				assert.Equal(t, unchangedRelease.Version, 1)
+			},
+		},
 		{
 			name: "Cached",
 			setup: func() testCaseContext {
@@ -641,6 +967,94 @@ func TestChartReconciler_Reconcile(t *testing.T) {
 				assert.Equal(t, actualRelease.Version, 1)
 			},
 		},
+		{
+			name: "No-Upgrade-HookResources",
+			setup: func() testCaseContext {
+				release := createReleaseDeclaration(
+					"default",
+					publicHelmEnvironment.ChartServer.URL(),
+					"1.0.0",
+					nil,
+					Values{},
+				)
+				release.HookResources = &helm.HookResources{
+					Kinds: []helm.HookResourceKind{
+						{APIVersion: "v1", Kind: "Secret"},
+					},
+				}
+
+				return testCaseContext{
+					releaseDeclaration: release,
+					chartServer:        publicHelmEnvironment.ChartServer,
+					assertFunc:         defaultAssertionFunc(release),
+				}
+			},
+			postRun: func(context testCaseContext) {
+				actualRelease, err := context.chartReconciler.Reconcile(
+					context.environment.Ctx,
+					&helm.ReleaseComponent{
+						ID: fmt.Sprintf(
+							"%s_%s_%s",
+							context.releaseDeclaration.Name,
+							context.releaseDeclaration.Namespace,
+							"HelmRelease",
+						),
+						Content: context.releaseDeclaration,
+					},
+				)
+				assert.NilError(t, err)
+
+				assertChartv1(
+					t,
+					context.environment.Environment,
+					actualRelease.Name,
+					actualRelease.Namespace,
+				)
+				assert.Equal(t, actualRelease.Version, 1)
+			},
+		},
+		{
+			name: "No-Upgrade-ServiceAccount",
+			setup: func() testCaseContext {
+				release := createReleaseDeclaration(
+					"default",
+					publicHelmEnvironment.ChartServer.URL(),
+					"1.0.0",
+					nil,
+					Values{},
+				)
+				release.ServiceAccountName = "tenant"
+
+				return testCaseContext{
+					releaseDeclaration: release,
+					chartServer:        publicHelmEnvironment.ChartServer,
+					assertFunc:         defaultAssertionFunc(release),
+				}
+			},
+			postRun: func(context testCaseContext) {
+				actualRelease, err := context.chartReconciler.Reconcile(
+					context.environment.Ctx,
+					&helm.ReleaseComponent{
+						ID: fmt.Sprintf(
+							"%s_%s_%s",
+							context.releaseDeclaration.Name,
+							context.releaseDeclaration.Namespace,
+							"HelmRelease",
+						),
+						Content: context.releaseDeclaration,
+					},
+				)
+				assert.NilError(t, err)
+
+				assertChartv1(
+					t,
+					context.environment.Environment,
+					actualRelease.Name,
+					actualRelease.Namespace,
+				)
+				assert.Equal(t, actualRelease.Version, 1)
+			},
+		},
 		{
 			name: "Conflict",
 			setup: func() testCaseContext {
@@ -758,6 +1172,112 @@ func TestChartReconciler_Reconcile(t *testing.T) {
 				assert.Equal(t, actualRelease.Version, 2)
 			},
 		},
+		{
+			name: "Pending-Rollback-Recovery",
+			setup: func() testCaseContext {
+				release := createReleaseDeclaration(
+					"default",
+					publicHelmEnvironment.ChartServer.URL(),
+					"1.0.0",
+					nil,
+					Values{},
+				)
+
+				return testCaseContext{
+					releaseDeclaration: release,
+					chartServer:        publicHelmEnvironment.ChartServer,
+					assertFunc:         defaultAssertionFunc(release),
+				}
+			},
+			postRun: func(context testCaseContext) {
+				helmConfig, err := helmtest.ConfigureHelm(context.chartReconciler.KubeConfig)
+				assert.NilError(t, err)
+
+				helmGet := action.NewGet(helmConfig)
+				rel, err := helmGet.Run("test")
+				assert.NilError(t, err)
+
+				rel.Info.Status = release.StatusPendingRollback
+				rel.Version = 2
+
+				err = helmConfig.Releases.Create(rel)
+				assert.NilError(t, err)
+
+				actualRelease, err := context.chartReconciler.Reconcile(
+					context.environment.Ctx,
+					&helm.ReleaseComponent{
+						ID: fmt.Sprintf(
+							"%s_%s_%s",
+							context.releaseDeclaration.Name,
+							context.releaseDeclaration.Namespace,
+							"HelmRelease",
+						),
+						Content: context.releaseDeclaration,
+					},
+				)
+				assert.NilError(t, err)
+
+				assertChartv1(
+					t,
+					context.environment.Environment,
+					actualRelease.Name,
+					actualRelease.Namespace,
+				)
+				assert.Equal(t, actualRelease.Version, 1)
+			},
+		},
+		{
+			name: "Uninstalling-Recovery",
+			setup: func() testCaseContext {
+				release := createReleaseDeclaration(
+					"default",
+					publicHelmEnvironment.ChartServer.URL(),
+					"1.0.0",
+					nil,
+					Values{},
+				)
+
+				return testCaseContext{
+					releaseDeclaration: release,
+					chartServer:        publicHelmEnvironment.ChartServer,
+					assertFunc:         defaultAssertionFunc(release),
+				}
+			},
+			postRun: func(context testCaseContext) {
+				helmConfig, err := helmtest.ConfigureHelm(context.chartReconciler.KubeConfig)
+				assert.NilError(t, err)
+
+				helmGet := action.NewGet(helmConfig)
+				rel, err := helmGet.Run("test")
+				assert.NilError(t, err)
+
+				rel.Info.Status = release.StatusUninstalling
+				err = helmConfig.Releases.Update(rel)
+				assert.NilError(t, err)
+
+				actualRelease, err := context.chartReconciler.Reconcile(
+					context.environment.Ctx,
+					&helm.ReleaseComponent{
+						ID: fmt.Sprintf(
+							"%s_%s_%s",
+							context.releaseDeclaration.Name,
+							context.releaseDeclaration.Namespace,
+							"HelmRelease",
+						),
+						Content: context.releaseDeclaration,
+					},
+				)
+				assert.NilError(t, err)
+
+				assertChartv1(
+					t,
+					context.environment.Environment,
+					actualRelease.Name,
+					actualRelease.Namespace,
+				)
+				assert.Equal(t, actualRelease.Version, 1)
+			},
+		},
 		{
 			name: "Pending-Install-Recovery",
 			setup: func() testCaseContext {
@@ -836,6 +1356,14 @@ func TestChartReconciler_Reconcile(t *testing.T) {
 					context.environment,
 				)
 			}
+			if auth != nil && auth.BearerToken != nil && context.createAuthSecret {
+				applyRepoTokenSecret(
+					t,
+					auth.BearerToken.SecretRef.Name,
+					auth.BearerToken.SecretRef.Namespace,
+					context.environment,
+				)
+			}
 
 			err := Remove(context.releaseDeclaration.Chart)
 			defer Remove(context.releaseDeclaration.Chart)
@@ -860,6 +1388,9 @@ func TestChartReconciler_Reconcile(t *testing.T) {
 				FieldManager:          "controller",
 				InventoryInstance:     inventoryInstance,
 				InsecureSkipTLSverify: true,
+				OperationHistogram: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+					Name: "helm_release_operation_duration_seconds",
+				}, []string{"release", "namespace", "operation"}),
 			}
 			context.chartReconciler = chartReconciler
 
@@ -918,6 +1449,33 @@ func applyRepoAuthSecret(
 	assert.NilError(t, err)
 }
 
+func applyRepoTokenSecret(
+	t *testing.T,
+	name string,
+	namespace string,
+	env *projecttest.Environment,
+) {
+	unstr := unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Secret",
+			"metadata": map[string]interface{}{
+				"name":      name,
+				"namespace": namespace,
+			},
+			"data": map[string][]byte{
+				"token": []byte("abcd"),
+			},
+		},
+	}
+	err := env.DynamicTestKubeClient.Apply(
+		env.Ctx,
+		&unstr,
+		"charttest",
+	)
+	assert.NilError(t, err)
+}
+
 func createReleaseDeclaration(
 	namespace string,
 	url string,
@@ -1005,3 +1563,108 @@ func assertChartv2(t *testing.T, env *kubetest.Environment, liveName string, nam
 	)
 	assert.Error(t, err, "serviceaccounts \"test\" not found")
 }
+
+func TestChartReconciler_Prefetch(t *testing.T) {
+	helmEnvironment := newHelmEnvironment(false, false, "")
+	defer helmEnvironment.Close()
+
+	charts := []Chart{
+		{
+			Name:    "test",
+			RepoURL: helmEnvironment.ChartServer.URL(),
+			Version: "1.0.0",
+		},
+		{
+			Name:    "test",
+			RepoURL: helmEnvironment.ChartServer.URL(),
+			Version: "2.0.0",
+		},
+		{
+			Name:    "test",
+			RepoURL: helmEnvironment.ChartServer.URL(),
+			Version: "1.0.0",
+		},
+	}
+	for _, chart := range charts {
+		err := Remove(chart)
+		assert.NilError(t, err)
+		defer Remove(chart)
+	}
+
+	chartReconciler := helm.ChartReconciler{
+		Log:                   logr.Discard(),
+		InsecureSkipTLSverify: true,
+		Registries:            &helm.RegistryClientPool{},
+		PullConcurrency:       2,
+	}
+	chartReconciler.Prefetch(context.Background(), charts)
+
+	for _, version := range []string{"1.0.0", "2.0.0"} {
+		_, err := os.Stat(filepath.Join(os.TempDir(), "test", fmt.Sprintf("test-%s.tgz", version)))
+		assert.NilError(t, err)
+	}
+}
+
+func TestChartReconciler_Render(t *testing.T) {
+	testRoot, err := os.MkdirTemp("", "declcd-cue-registry*")
+	assertError(err)
+
+	dnsServer, err := dnstest.NewDNSServer()
+	assertError(err)
+	defer dnsServer.Close()
+
+	cueModuleRegistry, err := ocitest.StartCUERegistry(testRoot)
+	assertError(err)
+	defer cueModuleRegistry.Close()
+
+	helmEnvironment := newHelmEnvironment(false, false, "")
+	defer helmEnvironment.Close()
+
+	env := projecttest.StartProjectEnv(t)
+	defer env.Stop()
+
+	release := createReleaseDeclaration(
+		"default",
+		helmEnvironment.ChartServer.URL(),
+		"1.0.0",
+		nil,
+		Values{
+			"autoscaling": map[string]interface{}{
+				"enabled": true,
+			},
+		},
+	)
+	err = Remove(release.Chart)
+	assert.NilError(t, err)
+	defer Remove(release.Chart)
+
+	chartReconciler := helm.ChartReconciler{
+		Log:                   env.Log,
+		KubeConfig:            env.ControlPlane.Config,
+		Client:                env.DynamicTestKubeClient,
+		FieldManager:          "controller",
+		InsecureSkipTLSverify: true,
+	}
+
+	manifests, err := chartReconciler.Render(env.Ctx, &helm.ReleaseComponent{
+		ID:      "test___default_HelmRelease",
+		Content: release,
+	})
+	assert.NilError(t, err)
+
+	kinds := make([]string, 0, len(manifests))
+	for _, manifest := range manifests {
+		assert.Equal(t, manifest.GetNamespace(), "default")
+		kinds = append(kinds, manifest.GetKind())
+	}
+	slices.Sort(kinds)
+	assert.DeepEqual(t, kinds, []string{"Deployment", "HorizontalPodAutoscaler", "Service", "ServiceAccount"})
+
+	var deployment appsv1.Deployment
+	err = env.TestKubeClient.Get(
+		env.Ctx,
+		types.NamespacedName{Name: release.Name, Namespace: release.Namespace},
+		&deployment,
+	)
+	assert.Assert(t, k8sErrors.IsNotFound(err))
+}
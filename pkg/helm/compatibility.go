@@ -0,0 +1,143 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var (
+	ErrIncompatibleChart = errors.New("Incompatible chart")
+)
+
+// CheckCompatibility verifies the kubeVersion constraint of a chart and the API versions of its rendered objects
+// against the capabilities of the target cluster.
+// Objects of custom resources defined by the chart itself are not verified, because their definitions are installed with the chart.
+func CheckCompatibility(
+	chrt *chart.Chart,
+	objects []unstructured.Unstructured,
+	capabilities *chartutil.Capabilities,
+) error {
+	kubeVersion := capabilities.KubeVersion.Version
+	if constraint := chrt.Metadata.KubeVersion; constraint != "" &&
+		!chartutil.IsCompatibleRange(constraint, kubeVersion) {
+		return fmt.Errorf(
+			"%w: chart %s %s requires Kubernetes %s, cluster is %s",
+			ErrIncompatibleChart,
+			chrt.Name(),
+			chrt.Metadata.Version,
+			constraint,
+			kubeVersion,
+		)
+	}
+
+	definedKinds := make(map[schema.GroupKind]struct{})
+	for _, object := range objects {
+		if object.GetKind() != "CustomResourceDefinition" {
+			continue
+		}
+		group, _, _ := unstructured.NestedString(object.Object, "spec", "group")
+		kind, _, _ := unstructured.NestedString(object.Object, "spec", "names", "kind")
+		definedKinds[schema.GroupKind{Group: group, Kind: kind}] = struct{}{}
+	}
+
+	unserved := make([]string, 0)
+	for _, object := range objects {
+		gvk := object.GroupVersionKind()
+		if gvk.Empty() {
+			continue
+		}
+		if _, defined := definedKinds[gvk.GroupKind()]; defined {
+			continue
+		}
+		apiVersion := gvk.GroupVersion().String()
+		if capabilities.APIVersions.Has(apiVersion+"/"+gvk.Kind) || capabilities.APIVersions.Has(apiVersion) {
+			continue
+		}
+		resource := fmt.Sprintf("%s %s", apiVersion, gvk.Kind)
+		if !slices.Contains(unserved, resource) {
+			unserved = append(unserved, resource)
+		}
+	}
+	if len(unserved) != 0 {
+		slices.Sort(unserved)
+		return fmt.Errorf(
+			"%w: chart %s %s renders API versions, which Kubernetes %s does not serve: %s",
+			ErrIncompatibleChart,
+			chrt.Name(),
+			chrt.Metadata.Version,
+			kubeVersion,
+			strings.Join(unserved, ", "),
+		)
+	}
+	return nil
+}
+
+// preflight renders the chart without contacting the cluster and checks its compatibility,
+// so that an incompatible chart fails before any of its objects are applied.
+func (c *ChartReconciler) preflight(
+	ctx context.Context,
+	desiredRelease ReleaseDeclaration,
+	chrt *chart.Chart,
+) error {
+	helmConfig := ctx.Value(configKey{}).(*action.Configuration)
+	resolved := ctx.Value(valuesKey{}).(*resolvedValues)
+
+	capabilities := helmConfig.Capabilities
+	if capabilities == nil {
+		discovered, err := overrideCapabilities(helmConfig, Capabilities{})
+		if err != nil {
+			return err
+		}
+		capabilities = discovered
+	}
+
+	// Checked before rendering, because rendering fails on its own with a less descriptive error.
+	if err := CheckCompatibility(chrt, nil, capabilities); err != nil {
+		return err
+	}
+
+	// A client only installation replaces the clients and capabilities of its configuration.
+	renderConfig := *helmConfig
+	install := action.NewInstall(&renderConfig)
+	install.DryRun = true
+	install.ClientOnly = true
+	install.IncludeCRDs = true
+	install.ReleaseName = desiredRelease.Name
+	install.Namespace = desiredRelease.Namespace
+	install.PostRenderer = c.postRenderer(desiredRelease.Patches)
+	install.KubeVersion = &capabilities.KubeVersion
+	install.APIVersions = capabilities.APIVersions
+
+	rendered, err := install.Run(chrt, resolved.values)
+	if err != nil {
+		return err
+	}
+	objects, err := decodeManifests(rendered)
+	if err != nil {
+		return err
+	}
+	return CheckCompatibility(chrt, objects, capabilities)
+}
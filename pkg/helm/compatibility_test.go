@@ -0,0 +1,113 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helm_test
+
+import (
+	"testing"
+
+	"github.com/kharf/declcd/pkg/helm"
+	"gotest.tools/v3/assert"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestCheckCompatibility(t *testing.T) {
+	capabilities := &chartutil.Capabilities{
+		KubeVersion: chartutil.KubeVersion{
+			Version: "v1.25.0",
+			Major:   "1",
+			Minor:   "25",
+		},
+		APIVersions: chartutil.VersionSet{
+			"v1",
+			"v1/ConfigMap",
+			"apps/v1",
+			"apps/v1/Deployment",
+			"apiextensions.k8s.io/v1",
+			"apiextensions.k8s.io/v1/CustomResourceDefinition",
+		},
+	}
+	object := func(apiVersion string, kind string) unstructured.Unstructured {
+		obj := unstructured.Unstructured{Object: map[string]interface{}{}}
+		obj.SetAPIVersion(apiVersion)
+		obj.SetKind(kind)
+		obj.SetName("test")
+		return obj
+	}
+	crd := object("apiextensions.k8s.io/v1", "CustomResourceDefinition")
+	crd.Object["spec"] = map[string]interface{}{
+		"group": "declcd.io",
+		"names": map[string]interface{}{
+			"kind": "Test",
+		},
+	}
+
+	testCases := []struct {
+		name        string
+		kubeVersion string
+		objects     []unstructured.Unstructured
+		expectedErr string
+	}{
+		{
+			name:        "Compatible",
+			kubeVersion: ">=1.24.0-0",
+			objects: []unstructured.Unstructured{
+				object("v1", "ConfigMap"),
+				object("apps/v1", "Deployment"),
+			},
+		},
+		{
+			name:        "KubeVersion",
+			kubeVersion: ">=1.27.0-0",
+			expectedErr: "Incompatible chart: chart test 1.0.0 requires Kubernetes >=1.27.0-0, cluster is v1.25.0",
+		},
+		{
+			name: "UnservedAPIVersion",
+			objects: []unstructured.Unstructured{
+				object("policy/v1beta1", "PodSecurityPolicy"),
+				object("policy/v1beta1", "PodSecurityPolicy"),
+				object("batch/v1beta1", "CronJob"),
+			},
+			expectedErr: "Incompatible chart: chart test 1.0.0 renders API versions, which Kubernetes v1.25.0 does not serve: batch/v1beta1 CronJob, policy/v1beta1 PodSecurityPolicy",
+		},
+		{
+			name: "DefinedByChart",
+			objects: []unstructured.Unstructured{
+				crd,
+				object("declcd.io/v1", "Test"),
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			chrt := &chart.Chart{
+				Metadata: &chart.Metadata{
+					Name:        "test",
+					Version:     "1.0.0",
+					KubeVersion: tc.kubeVersion,
+				},
+			}
+			err := helm.CheckCompatibility(chrt, tc.objects, capabilities)
+			if tc.expectedErr == "" {
+				assert.NilError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, helm.ErrIncompatibleChart)
+			assert.Error(t, err, tc.expectedErr)
+		})
+	}
+}
@@ -0,0 +1,93 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helm
+
+import (
+	"bytes"
+	"io"
+	"slices"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+	"helm.sh/helm/v3/pkg/chart"
+)
+
+// CRDLocks serializes installs and upgrades of releases, which bundle the same CustomResourceDefinitions,
+// so that concurrent writes to a shared CRD neither race nor fail with spurious conflicts.
+// Releases without shared CRDs are not blocked.
+// It is safe for concurrent use and meant to live across reconciliations.
+type CRDLocks struct {
+	mu    sync.Mutex
+	locks map[string]*sync.Mutex
+}
+
+// Lock blocks until it holds the locks of all given CRDs and returns a function releasing them.
+// Locks are acquired in a stable order, so that releases sharing multiple CRDs do not deadlock.
+func (l *CRDLocks) Lock(crds []string) func() {
+	names := slices.Clone(crds)
+	slices.Sort(names)
+	names = slices.Compact(names)
+
+	l.mu.Lock()
+	if l.locks == nil {
+		l.locks = make(map[string]*sync.Mutex, len(names))
+	}
+	held := make([]*sync.Mutex, 0, len(names))
+	for _, name := range names {
+		lock, found := l.locks[name]
+		if !found {
+			lock = &sync.Mutex{}
+			l.locks[name] = lock
+		}
+		held = append(held, lock)
+	}
+	l.mu.Unlock()
+
+	for _, lock := range held {
+		lock.Lock()
+	}
+	return func() {
+		for i := len(held) - 1; i >= 0; i-- {
+			held[i].Unlock()
+		}
+	}
+}
+
+// BundledCRDs returns the names of the CustomResourceDefinitions in the crds directories of a chart and its subcharts.
+// CRDs rendered from templates are not detected.
+func BundledCRDs(chrt *chart.Chart) ([]string, error) {
+	names := make([]string, 0)
+	for _, crd := range chrt.CRDObjects() {
+		decoder := yaml.NewDecoder(bytes.NewReader(crd.File.Data))
+		for {
+			var object struct {
+				Kind     string `yaml:"kind"`
+				Metadata struct {
+					Name string `yaml:"name"`
+				} `yaml:"metadata"`
+			}
+			if err := decoder.Decode(&object); err != nil {
+				if err == io.EOF {
+					break
+				}
+				return nil, err
+			}
+			if object.Kind == "CustomResourceDefinition" && object.Metadata.Name != "" {
+				names = append(names, object.Metadata.Name)
+			}
+		}
+	}
+	return names, nil
+}
@@ -0,0 +1,124 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helm_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kharf/declcd/pkg/helm"
+	"gotest.tools/v3/assert"
+	"helm.sh/helm/v3/pkg/chart"
+)
+
+func TestBundledCRDs(t *testing.T) {
+	dependency := &chart.Chart{
+		Metadata: &chart.Metadata{Name: "operator"},
+		Files: []*chart.File{
+			{
+				Name: "crds/monitors.yaml",
+				Data: []byte(`apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: servicemonitors.monitoring.coreos.com
+---
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: podmonitors.monitoring.coreos.com
+`),
+			},
+		},
+	}
+	chrt := &chart.Chart{
+		Metadata: &chart.Metadata{Name: "stack"},
+		Files: []*chart.File{
+			{
+				Name: "crds/rules.yaml",
+				Data: []byte(`apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: prometheusrules.monitoring.coreos.com
+`),
+			},
+			{
+				Name: "README.md",
+				Data: []byte("# stack"),
+			},
+		},
+	}
+	chrt.AddDependency(dependency)
+
+	crds, err := helm.BundledCRDs(chrt)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, crds, []string{
+		"prometheusrules.monitoring.coreos.com",
+		"servicemonitors.monitoring.coreos.com",
+		"podmonitors.monitoring.coreos.com",
+	})
+
+	crds, err = helm.BundledCRDs(&chart.Chart{Metadata: &chart.Metadata{Name: "plain"}})
+	assert.NilError(t, err)
+	assert.Equal(t, len(crds), 0)
+}
+
+func TestCRDLocks_Lock(t *testing.T) {
+	locks := &helm.CRDLocks{}
+
+	var mu sync.Mutex
+	running := 0
+	maxRunning := 0
+	var wg sync.WaitGroup
+	// Every release shares a CRD with the others, though in a different order and with duplicates.
+	releases := [][]string{
+		{"a.example.com", "b.example.com"},
+		{"b.example.com", "a.example.com"},
+		{"a.example.com", "a.example.com"},
+		{"a.example.com"},
+	}
+	for _, crds := range releases {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			unlock := locks.Lock(crds)
+			defer unlock()
+			mu.Lock()
+			running++
+			maxRunning = max(maxRunning, running)
+			mu.Unlock()
+			time.Sleep(10 * time.Millisecond)
+			mu.Lock()
+			running--
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, maxRunning, 1)
+
+	// Releases without shared CRDs are not blocked.
+	unlock := locks.Lock([]string{"a.example.com"})
+	done := make(chan struct{})
+	go func() {
+		locks.Lock([]string{"c.example.com"})()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("release without shared CRDs was blocked")
+	}
+	unlock()
+}
@@ -0,0 +1,79 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helm
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/releaseutil"
+)
+
+// DefaultMaxHistory is the number of stored versions kept per release.
+const DefaultMaxHistory = 5
+
+// maxHistory returns the configured history limit or [DefaultMaxHistory].
+func (c *ChartReconciler) maxHistory() int {
+	if c.MaxHistory > 0 {
+		return c.MaxHistory
+	}
+	return DefaultMaxHistory
+}
+
+// Superseded returns the versions of a release history, which are removed to keep at most maxHistory versions.
+// Only superseded, failed and dangling pending versions are removed, beginning with the oldest.
+// The latest version and the deployed version are always kept, so the history may still exceed the limit.
+func Superseded(history []*release.Release, maxHistory int) []*release.Release {
+	if len(history) <= maxHistory {
+		return nil
+	}
+	sorted := make([]*release.Release, len(history))
+	copy(sorted, history)
+	releaseutil.SortByRevision(sorted)
+
+	excess := len(sorted) - maxHistory
+	superseded := make([]*release.Release, 0, excess)
+	for _, rel := range sorted[:len(sorted)-1] {
+		if len(superseded) == excess {
+			break
+		}
+		status := rel.Info.Status
+		if status == release.StatusSuperseded || status == release.StatusFailed || status.IsPending() {
+			superseded = append(superseded, rel)
+		}
+	}
+	return superseded
+}
+
+// compactHistory deletes versions of a release beyond the history limit,
+// which accumulate e.g. when recovering from pending states creates additional versions on every reconciliation.
+func (c *ChartReconciler) compactHistory(ctx context.Context, releaseName string) error {
+	log := ctx.Value(logKey{}).(*logr.Logger)
+	helmConfig := ctx.Value(configKey{}).(*action.Configuration)
+
+	history, err := helmConfig.Releases.History(releaseName)
+	if err != nil {
+		return err
+	}
+	for _, rel := range Superseded(history, c.maxHistory()) {
+		log.V(1).Info("Deleting superseded release version", "version", rel.Version, "status", rel.Info.Status)
+		if _, err := helmConfig.Releases.Delete(rel.Name, rel.Version); err != nil {
+			return err
+		}
+	}
+	return nil
+}
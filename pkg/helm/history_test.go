@@ -0,0 +1,81 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helm_test
+
+import (
+	"testing"
+
+	"github.com/kharf/declcd/pkg/helm"
+	"gotest.tools/v3/assert"
+	"helm.sh/helm/v3/pkg/release"
+)
+
+func TestSuperseded(t *testing.T) {
+	testCases := []struct {
+		name       string
+		statuses   []release.Status
+		maxHistory int
+		expected   []int
+	}{
+		{
+			name:       "WithinLimit",
+			statuses:   []release.Status{release.StatusSuperseded, release.StatusDeployed},
+			maxHistory: 5,
+			expected:   nil,
+		},
+		{
+			name: "Flapping",
+			statuses: []release.Status{
+				release.StatusSuperseded,
+				release.StatusFailed,
+				release.StatusPendingUpgrade,
+				release.StatusFailed,
+				release.StatusSuperseded,
+				release.StatusDeployed,
+			},
+			maxHistory: 3,
+			expected:   []int{1, 2, 3},
+		},
+		{
+			name: "KeepDeployedAndLatest",
+			statuses: []release.Status{
+				release.StatusDeployed,
+				release.StatusFailed,
+				release.StatusFailed,
+			},
+			maxHistory: 1,
+			expected:   []int{2},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Storage drivers do not return versions in order.
+			history := make([]*release.Release, 0, len(tc.statuses))
+			for i := len(tc.statuses) - 1; i >= 0; i-- {
+				history = append(history, &release.Release{
+					Name:    "test",
+					Version: i + 1,
+					Info:    &release.Info{Status: tc.statuses[i]},
+				})
+			}
+			var versions []int
+			for _, rel := range helm.Superseded(history, tc.maxHistory) {
+				versions = append(versions, rel.Version)
+			}
+			assert.DeepEqual(t, versions, tc.expected)
+		})
+	}
+}
@@ -0,0 +1,134 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helm
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+	"helm.sh/helm/v3/pkg/release"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// HookReleaseLabel marks objects created by hooks at runtime with the name of their release,
+// so that they are tracked with the release, when their kind is declared in [HookResources].
+const HookReleaseLabel = "declcd/hook-release"
+
+// HookResources selects the objects created by Helm hooks, which are deleted with their release.
+// Helm neither tracks hook objects, like pre-install Jobs, nor the objects they create,
+// so both stay in the cluster after the release is uninstalled.
+// The hook objects of the installed revision are always tracked.
+type HookResources struct {
+	// Kinds of objects, which hooks create at runtime, like Secrets generated by a pre-install Job.
+	// Objects of these kinds in the release namespace are tracked,
+	// when they are labeled with [HookReleaseLabel] and the name of the release.
+	Kinds []HookResourceKind `json:"kinds,omitempty"`
+}
+
+// HookResourceKind is the kind of objects created by hooks at runtime.
+type HookResourceKind struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+}
+
+// HookResource is a tracked object created by a hook.
+type HookResource struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Name       string `json:"name"`
+	Namespace  string `json:"namespace,omitempty"`
+}
+
+// trackHookResources returns the hook objects of the installed revision of a release
+// and the objects labeled as created by its hooks, sorted by kind, namespace and name.
+func (c *ChartReconciler) trackHookResources(
+	ctx context.Context,
+	installed *release.Release,
+	selected HookResources,
+) ([]HookResource, error) {
+	tracked, err := HookObjects(installed)
+	if err != nil {
+		return nil, err
+	}
+
+	selector := labels.SelectorFromSet(labels.Set{HookReleaseLabel: installed.Name})
+	for _, kind := range selected.Kinds {
+		gvk := schema.FromAPIVersionAndKind(kind.APIVersion, kind.Kind)
+		objects, err := c.Client.List(ctx, gvk, installed.Namespace, selector)
+		if err != nil {
+			return nil, err
+		}
+		for _, object := range objects {
+			tracked = append(tracked, HookResource{
+				APIVersion: kind.APIVersion,
+				Kind:       kind.Kind,
+				Name:       object.GetName(),
+				Namespace:  object.GetNamespace(),
+			})
+		}
+	}
+
+	slices.SortFunc(tracked, func(a, b HookResource) int {
+		return strings.Compare(
+			a.Kind+"/"+a.Namespace+"/"+a.Name,
+			b.Kind+"/"+b.Namespace+"/"+b.Name,
+		)
+	})
+	return slices.Compact(tracked), nil
+}
+
+// HookObjects decodes the objects of all hooks of a release.
+// Objects without a namespace are created in the release namespace.
+func HookObjects(rel *release.Release) ([]HookResource, error) {
+	objects := make([]HookResource, 0, len(rel.Hooks))
+	for _, hook := range rel.Hooks {
+		decoder := yaml.NewDecoder(bytes.NewBufferString(hook.Manifest))
+		for {
+			var object struct {
+				APIVersion string `yaml:"apiVersion"`
+				Kind       string `yaml:"kind"`
+				Metadata   struct {
+					Name      string `yaml:"name"`
+					Namespace string `yaml:"namespace"`
+				} `yaml:"metadata"`
+			}
+			if err := decoder.Decode(&object); err != nil {
+				if err == io.EOF {
+					break
+				}
+				return nil, err
+			}
+			if object.Kind == "" || object.Metadata.Name == "" {
+				continue
+			}
+			namespace := object.Metadata.Namespace
+			if namespace == "" {
+				namespace = rel.Namespace
+			}
+			objects = append(objects, HookResource{
+				APIVersion: object.APIVersion,
+				Kind:       object.Kind,
+				Name:       object.Metadata.Name,
+				Namespace:  namespace,
+			})
+		}
+	}
+	return objects, nil
+}
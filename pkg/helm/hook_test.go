@@ -0,0 +1,71 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helm_test
+
+import (
+	"testing"
+
+	"github.com/kharf/declcd/pkg/helm"
+	"gotest.tools/v3/assert"
+	"helm.sh/helm/v3/pkg/release"
+)
+
+func TestHookObjects(t *testing.T) {
+	rel := &release.Release{
+		Name:      "db",
+		Namespace: "shop",
+		Hooks: []*release.Hook{
+			{
+				Name: "db-migrate",
+				Kind: "Job",
+				Manifest: `apiVersion: batch/v1
+kind: Job
+metadata:
+  name: db-migrate
+  annotations:
+    helm.sh/hook: pre-install
+`,
+			},
+			{
+				Name: "db-credentials",
+				Kind: "Secret",
+				Manifest: `# Source: db/templates/credentials.yaml
+apiVersion: v1
+kind: Secret
+metadata:
+  name: db-credentials
+  namespace: shared
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: db-hook
+`,
+			},
+		},
+	}
+
+	objects, err := helm.HookObjects(rel)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, objects, []helm.HookResource{
+		{APIVersion: "batch/v1", Kind: "Job", Name: "db-migrate", Namespace: "shop"},
+		{APIVersion: "v1", Kind: "Secret", Name: "db-credentials", Namespace: "shared"},
+		{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "ClusterRole", Name: "db-hook", Namespace: "shop"},
+	})
+
+	objects, err = helm.HookObjects(&release.Release{Name: "plain", Namespace: "shop"})
+	assert.NilError(t, err)
+	assert.Equal(t, len(objects), 0)
+}
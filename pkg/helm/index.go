@@ -0,0 +1,258 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helm
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"helm.sh/helm/v3/pkg/repo"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+)
+
+var (
+	ErrUnsupportedAuth  = errors.New("Unsupported auth")
+	ErrInvalidCABundle  = errors.New("Invalid CA bundle")
+	ErrRepositoryStatus = errors.New("Unexpected repository response")
+	ErrChartURLNotFound = errors.New("Chart URL not found")
+)
+
+// BearerToken authenticates against index.yaml repositories with the token stored under "token" in the referenced secret.
+type BearerToken struct {
+	SecretRef SecretRef `json:"secretRef"`
+}
+
+// ClientTLS authenticates against index.yaml repositories with a client certificate.
+// The referenced secret has to contain the PEM encoded certificate under "tls.crt" and its key under "tls.key",
+// like Secrets of type kubernetes.io/tls.
+// A PEM encoded CA bundle under "ca.crt" optionally verifies the repository.
+type ClientTLS struct {
+	SecretRef SecretRef `json:"secretRef"`
+}
+
+// usesIndexAuth reports whether the chart authenticates with a method, which Helm's pull action does not support.
+func usesIndexAuth(auth *Auth) bool {
+	return auth != nil && (auth.BearerToken != nil || auth.TLS != nil)
+}
+
+// pullFromIndex downloads a chart of an index.yaml repository with a bearer token or a client certificate.
+func (c *ChartReconciler) pullFromIndex(
+	ctx context.Context,
+	chartRequest Chart,
+	chartDestPath string,
+) error {
+	repoURL, err := url.Parse(chartRequest.RepoURL)
+	if err != nil {
+		return err
+	}
+
+	httpClient, err := c.indexHTTPClient(ctx, chartRequest.Auth, repoURL.Host)
+	if err != nil {
+		return err
+	}
+
+	indexURL := strings.TrimSuffix(chartRequest.RepoURL, "/") + "/index.yaml"
+	indexBytes, err := download(ctx, httpClient, indexURL)
+	if err != nil {
+		return err
+	}
+	var index repo.IndexFile
+	if err := yaml.Unmarshal(indexBytes, &index); err != nil {
+		return err
+	}
+	index.SortEntries()
+
+	chartVersion, err := index.Get(chartRequest.Name, chartRequest.Version)
+	if err != nil {
+		return err
+	}
+	if len(chartVersion.URLs) == 0 {
+		return fmt.Errorf("%w: %s-%s", ErrChartURLNotFound, chartRequest.Name, chartRequest.Version)
+	}
+	chartURL, err := repo.ResolveReferenceURL(chartRequest.RepoURL, chartVersion.URLs[0])
+	if err != nil {
+		return err
+	}
+	archive, err := download(ctx, httpClient, chartURL)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(chartDestPath, 0700); err != nil {
+		return err
+	}
+	// Charts are loaded by their archive path, so a partially written archive must never be visible.
+	tmpDir, err := os.MkdirTemp(chartDestPath, "download-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
+	// Provenance files refer to the archive by its file name in the repository.
+	tmp := filepath.Join(tmpDir, path.Base(chartURL))
+	if err := os.WriteFile(tmp, archive, 0600); err != nil {
+		return err
+	}
+
+	if chartRequest.Verify != nil {
+		provenance, err := download(ctx, httpClient, chartURL+".prov")
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrChartVerification, err)
+		}
+		provenanceFile := tmp + ".prov"
+		if err := os.WriteFile(provenanceFile, provenance, 0600); err != nil {
+			return err
+		}
+		if err := c.verifyProvenance(ctx, *chartRequest.Verify, tmp, provenanceFile); err != nil {
+			return err
+		}
+	}
+	return os.Rename(tmp, newArchivePath(chartRequest).fullPath)
+}
+
+// indexHTTPClient configures a client with the client certificate and the bearer token of the auth.
+// The token is only sent to the repository host, like Helm only passes credentials to it.
+func (c *ChartReconciler) indexHTTPClient(
+	ctx context.Context,
+	auth *Auth,
+	host string,
+) (*http.Client, error) {
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: c.InsecureSkipTLSverify || c.InsecureSkipTLSverifyHosts.Contains(host),
+	}
+	if auth.TLS != nil {
+		data, err := c.readSecretData(ctx, auth.TLS.SecretRef)
+		if err != nil {
+			return nil, err
+		}
+		certificate, err := getSecretValue(data, "tls.crt", false)
+		if err != nil {
+			return nil, err
+		}
+		key, err := getSecretValue(data, "tls.key", false)
+		if err != nil {
+			return nil, err
+		}
+		keyPair, err := tls.X509KeyPair([]byte(certificate), []byte(key))
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.Certificates = []tls.Certificate{keyPair}
+
+		caBundle, err := getSecretValue(data, "ca.crt", true)
+		if err != nil {
+			return nil, err
+		}
+		if caBundle != "" {
+			rootCAs := x509.NewCertPool()
+			if !rootCAs.AppendCertsFromPEM([]byte(caBundle)) {
+				return nil, fmt.Errorf(
+					"%w: %s/%s",
+					ErrInvalidCABundle,
+					auth.TLS.SecretRef.Namespace,
+					auth.TLS.SecretRef.Name,
+				)
+			}
+			tlsConfig.RootCAs = rootCAs
+		}
+	}
+
+	var transport http.RoundTripper = &http.Transport{
+		Proxy:           http.ProxyFromEnvironment,
+		TLSClientConfig: tlsConfig,
+	}
+	if auth.BearerToken != nil {
+		data, err := c.readSecretData(ctx, auth.BearerToken.SecretRef)
+		if err != nil {
+			return nil, err
+		}
+		token, err := getSecretValue(data, "token", false)
+		if err != nil {
+			return nil, err
+		}
+		transport = &bearerTransport{
+			base:  transport,
+			host:  host,
+			token: token,
+		}
+	}
+	return &http.Client{Transport: transport}, nil
+}
+
+type bearerTransport struct {
+	base  http.RoundTripper
+	host  string
+	token string
+}
+
+var _ http.RoundTripper = (*bearerTransport)(nil)
+
+func (transport *bearerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Host != transport.host {
+		return transport.base.RoundTrip(req)
+	}
+	// RoundTrippers must not modify the request.
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+transport.token)
+	return transport.base.RoundTrip(req)
+}
+
+func (c *ChartReconciler) readSecretData(ctx context.Context, secretRef SecretRef) (map[string]interface{}, error) {
+	secretReq := &unstructured.Unstructured{}
+	secretReq.SetKind("Secret")
+	secretReq.SetAPIVersion("v1")
+	secretReq.SetName(secretRef.Name)
+	secretReq.SetNamespace(secretRef.Namespace)
+	secret, err := c.Client.Get(ctx, secretReq)
+	if err != nil {
+		return nil, err
+	}
+	data, found := secret.Object["data"].(map[string]interface{})
+	if !found {
+		return nil, fmt.Errorf(
+			"%w: %s/%s has no data",
+			ErrAuthSecretValueNotFound,
+			secretRef.Namespace,
+			secretRef.Name,
+		)
+	}
+	return data, nil
+}
+
+func download(ctx context.Context, httpClient *http.Client, target string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: %s: %s", ErrRepositoryStatus, target, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
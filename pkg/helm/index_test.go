@@ -0,0 +1,405 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helm
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/kharf/declcd/pkg/kube"
+	"gotest.tools/v3/assert"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// secretClient serves Secrets from memory with their data base64 encoded like the API server.
+type secretClient struct {
+	kube.Client[unstructured.Unstructured]
+	secrets map[string]map[string]string
+}
+
+func (client *secretClient) Get(
+	ctx context.Context,
+	obj *unstructured.Unstructured,
+) (*unstructured.Unstructured, error) {
+	secretData, found := client.secrets[obj.GetNamespace()+"/"+obj.GetName()]
+	if !found {
+		return nil, k8sErrors.NewNotFound(schema.GroupResource{Resource: "secrets"}, obj.GetName())
+	}
+	secret := obj.DeepCopy()
+	if secretData == nil {
+		return secret, nil
+	}
+	data := make(map[string]interface{}, len(secretData))
+	for key, value := range secretData {
+		data[key] = base64.StdEncoding.EncodeToString([]byte(value))
+	}
+	secret.Object["data"] = data
+	return secret, nil
+}
+
+func indexYAML(chartName string, urls ...string) string {
+	index := fmt.Sprintf("apiVersion: v1\nentries:\n  %s:\n  - name: %s\n    version: 1.0.0\n", chartName, chartName)
+	if len(urls) == 0 {
+		return index
+	}
+	index += "    urls:\n"
+	for _, url := range urls {
+		index += fmt.Sprintf("    - %s\n", url)
+	}
+	return index
+}
+
+func bearerRef() *Auth {
+	return &Auth{BearerToken: &BearerToken{SecretRef: SecretRef{Name: "token", Namespace: "default"}}}
+}
+
+func TestChartReconciler_PullFromIndex(t *testing.T) {
+	archive := "chart archive"
+	testCases := []struct {
+		name        string
+		chartName   string
+		version     string
+		index       func(serverURL string) string
+		secrets     map[string]map[string]string
+		expectedErr string
+	}{
+		{
+			name:      "RelativeURL",
+			chartName: "index-relative",
+			version:   "1.0.0",
+			index: func(serverURL string) string {
+				return indexYAML("index-relative", "charts/index-relative-1.0.0.tgz")
+			},
+			secrets: map[string]map[string]string{"default/token": {"token": "abcd"}},
+		},
+		{
+			name:      "AbsoluteURL",
+			chartName: "index-absolute",
+			version:   "1.0.0",
+			index: func(serverURL string) string {
+				return indexYAML("index-absolute", serverURL+"/charts/index-absolute-1.0.0.tgz")
+			},
+			secrets: map[string]map[string]string{"default/token": {"token": "abcd"}},
+		},
+		{
+			name:      "WrongToken",
+			chartName: "index-wrongtoken",
+			version:   "1.0.0",
+			index: func(serverURL string) string {
+				return indexYAML("index-wrongtoken", "charts/index-wrongtoken-1.0.0.tgz")
+			},
+			secrets:     map[string]map[string]string{"default/token": {"token": "wrong"}},
+			expectedErr: "/index.yaml: 401 Unauthorized",
+		},
+		{
+			name:      "MissingToken",
+			chartName: "index-missingtoken",
+			version:   "1.0.0",
+			index: func(serverURL string) string {
+				return indexYAML("index-missingtoken", "charts/index-missingtoken-1.0.0.tgz")
+			},
+			secrets:     map[string]map[string]string{"default/token": {"password": "abcd"}},
+			expectedErr: ErrAuthSecretValueNotFound.Error() + ": token is empty",
+		},
+		{
+			name:      "SecretWithoutData",
+			chartName: "index-nodata",
+			version:   "1.0.0",
+			index: func(serverURL string) string {
+				return indexYAML("index-nodata", "charts/index-nodata-1.0.0.tgz")
+			},
+			secrets:     map[string]map[string]string{"default/token": nil},
+			expectedErr: ErrAuthSecretValueNotFound.Error() + ": default/token has no data",
+		},
+		{
+			name:      "SecretNotFound",
+			chartName: "index-nosecret",
+			version:   "1.0.0",
+			index: func(serverURL string) string {
+				return indexYAML("index-nosecret", "charts/index-nosecret-1.0.0.tgz")
+			},
+			expectedErr: `secrets "token" not found`,
+		},
+		{
+			name:      "VersionNotFound",
+			chartName: "index-noversion",
+			version:   "2.0.0",
+			index: func(serverURL string) string {
+				return indexYAML("index-noversion", "charts/index-noversion-1.0.0.tgz")
+			},
+			secrets:     map[string]map[string]string{"default/token": {"token": "abcd"}},
+			expectedErr: "no chart version found for index-noversion-2.0.0",
+		},
+		{
+			name:      "ChartURLNotFound",
+			chartName: "index-nourl",
+			version:   "1.0.0",
+			index: func(serverURL string) string {
+				return indexYAML("index-nourl")
+			},
+			secrets:     map[string]map[string]string{"default/token": {"token": "abcd"}},
+			expectedErr: ErrChartURLNotFound.Error() + ": index-nourl-1.0.0",
+		},
+		{
+			name:      "ArchiveNotFound",
+			chartName: "index-noarchive",
+			version:   "1.0.0",
+			index: func(serverURL string) string {
+				return indexYAML("index-noarchive", "charts/unknown-1.0.0.tgz")
+			},
+			secrets:     map[string]map[string]string{"default/token": {"token": "abcd"}},
+			expectedErr: "/charts/unknown-1.0.0.tgz: 404 Not Found",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var index string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("Authorization") != "Bearer abcd" {
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				switch r.URL.Path {
+				case "/index.yaml":
+					w.Write([]byte(index))
+				case fmt.Sprintf("/charts/%s-1.0.0.tgz", tc.chartName):
+					w.Write([]byte(archive))
+				default:
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}))
+			defer server.Close()
+			index = tc.index(server.URL)
+
+			chartRequest := Chart{
+				Name:    tc.chartName,
+				RepoURL: server.URL,
+				Version: tc.version,
+				Auth:    bearerRef(),
+			}
+			assert.NilError(t, Remove(chartRequest))
+			defer Remove(chartRequest)
+
+			reconciler := ChartReconciler{
+				Client: &secretClient{secrets: tc.secrets},
+			}
+			archivePath := newArchivePath(chartRequest)
+			err := reconciler.pullFromIndex(context.Background(), chartRequest, archivePath.dir)
+			if tc.expectedErr != "" {
+				assert.ErrorContains(t, err, tc.expectedErr)
+				_, err := os.Stat(archivePath.fullPath)
+				assert.Assert(t, os.IsNotExist(err))
+				return
+			}
+			assert.NilError(t, err)
+			content, err := os.ReadFile(archivePath.fullPath)
+			assert.NilError(t, err)
+			assert.Equal(t, string(content), archive)
+		})
+	}
+}
+
+func TestBearerTransport_RoundTrip(t *testing.T) {
+	authorization := make(chan string, 1)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization <- r.Header.Get("Authorization")
+	})
+	repository := httptest.NewServer(handler)
+	defer repository.Close()
+	other := httptest.NewServer(handler)
+	defer other.Close()
+
+	repositoryURL, err := url.Parse(repository.URL)
+	assert.NilError(t, err)
+	transport := &bearerTransport{
+		base:  http.DefaultTransport,
+		host:  repositoryURL.Host,
+		token: "abcd",
+	}
+	client := &http.Client{Transport: transport}
+
+	testCases := []struct {
+		name                  string
+		target                string
+		expectedAuthorization string
+	}{
+		{
+			name:                  "RepositoryHost",
+			target:                repository.URL + "/index.yaml",
+			expectedAuthorization: "Bearer abcd",
+		},
+		{
+			name:                  "OtherHost",
+			target:                other.URL + "/charts/test-1.0.0.tgz",
+			expectedAuthorization: "",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, tc.target, nil)
+			assert.NilError(t, err)
+			resp, err := client.Do(req)
+			assert.NilError(t, err)
+			resp.Body.Close()
+			assert.Equal(t, <-authorization, tc.expectedAuthorization)
+			// RoundTrippers must not modify the request.
+			assert.Equal(t, req.Header.Get("Authorization"), "")
+		})
+	}
+}
+
+// clientCertificate creates a self-signed client certificate with its key, both PEM encoded.
+func clientCertificate(t *testing.T) (*x509.Certificate, string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NilError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "declcd"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.NilError(t, err)
+	certificate, err := x509.ParseCertificate(der)
+	assert.NilError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	assert.NilError(t, err)
+	return certificate,
+		string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+		string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}))
+}
+
+func TestChartReconciler_IndexHTTPClient_TLS(t *testing.T) {
+	certificate, certificatePEM, keyPEM := clientCertificate(t)
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(certificate)
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	server.TLS = &tls.Config{
+		ClientAuth: tls.RequireAndVerifyClientCert,
+		ClientCAs:  clientCAs,
+	}
+	server.StartTLS()
+	defer server.Close()
+	serverURL, err := url.Parse(server.URL)
+	assert.NilError(t, err)
+	serverCA := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}))
+
+	testCases := []struct {
+		name                       string
+		secret                     map[string]string
+		insecureSkipTLSverifyHosts RegistryHosts
+		expectedClientErr          string
+		expectedErr                string
+	}{
+		{
+			name: "CABundle",
+			secret: map[string]string{
+				"tls.crt": certificatePEM,
+				"tls.key": keyPEM,
+				"ca.crt":  serverCA,
+			},
+		},
+		{
+			name: "InsecureSkipTLSverifyHost",
+			secret: map[string]string{
+				"tls.crt": certificatePEM,
+				"tls.key": keyPEM,
+			},
+			insecureSkipTLSverifyHosts: RegistryHosts{serverURL.Host},
+		},
+		{
+			name: "UnknownAuthority",
+			secret: map[string]string{
+				"tls.crt": certificatePEM,
+				"tls.key": keyPEM,
+			},
+			expectedErr: "certificate signed by unknown authority",
+		},
+		{
+			name: "InvalidCABundle",
+			secret: map[string]string{
+				"tls.crt": certificatePEM,
+				"tls.key": keyPEM,
+				"ca.crt":  "invalid",
+			},
+			expectedClientErr: ErrInvalidCABundle.Error() + ": default/tls",
+		},
+		{
+			name: "MissingKey",
+			secret: map[string]string{
+				"tls.crt": certificatePEM,
+			},
+			expectedClientErr: ErrAuthSecretValueNotFound.Error() + ": tls.key is empty",
+		},
+		{
+			name: "MismatchingKey",
+			secret: map[string]string{
+				"tls.crt": certificatePEM,
+				"tls.key": func() string {
+					_, _, otherKeyPEM := clientCertificate(t)
+					return otherKeyPEM
+				}(),
+			},
+			expectedClientErr: "private key does not match public key",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			reconciler := ChartReconciler{
+				Client:                     &secretClient{secrets: map[string]map[string]string{"default/tls": tc.secret}},
+				InsecureSkipTLSverifyHosts: tc.insecureSkipTLSverifyHosts,
+			}
+			auth := &Auth{TLS: &ClientTLS{SecretRef: SecretRef{Name: "tls", Namespace: "default"}}}
+			httpClient, err := reconciler.indexHTTPClient(context.Background(), auth, serverURL.Host)
+			if tc.expectedClientErr != "" {
+				assert.ErrorContains(t, err, tc.expectedClientErr)
+				return
+			}
+			assert.NilError(t, err)
+
+			content, err := download(context.Background(), httpClient, server.URL+"/index.yaml")
+			if tc.expectedErr != "" {
+				assert.ErrorContains(t, err, tc.expectedErr)
+				return
+			}
+			assert.NilError(t, err)
+			assert.Equal(t, string(content), "ok")
+		})
+	}
+}
@@ -0,0 +1,98 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helm
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+	"helm.sh/helm/v3/pkg/release"
+)
+
+// Operation is a step of a release reconciliation, which is observed by the [ChartReconciler.OperationHistogram].
+type Operation string
+
+const (
+	// OperationRender renders the chart templates of an installed release to detect drift.
+	OperationRender Operation = "render"
+	// OperationInstall installs a release including its hooks.
+	OperationInstall Operation = "install"
+	// OperationUpgrade upgrades a release including its hooks.
+	OperationUpgrade Operation = "upgrade"
+	// OperationHooks is the accumulated execution time of all hooks run by an installation or upgrade.
+	OperationHooks Operation = "hooks"
+)
+
+// DefaultSlowRenderThreshold is the rendering duration, above which a chart is reported as slow.
+const DefaultSlowRenderThreshold = 10 * time.Second
+
+func (c *ChartReconciler) observe(operation Operation, desiredRelease ReleaseDeclaration, duration time.Duration) {
+	if c.OperationHistogram == nil {
+		return
+	}
+	c.OperationHistogram.With(prometheus.Labels{
+		"release":   desiredRelease.Name,
+		"namespace": desiredRelease.Namespace,
+		"operation": string(operation),
+	}).Observe(duration.Seconds())
+}
+
+// observeRender records the rendering duration and warns about charts exceeding the slow render threshold,
+// as they delay every reconciliation of the project.
+func (c *ChartReconciler) observeRender(ctx context.Context, desiredRelease ReleaseDeclaration, duration time.Duration) {
+	c.observe(OperationRender, desiredRelease, duration)
+	threshold := c.SlowRenderThreshold
+	if threshold == 0 {
+		threshold = DefaultSlowRenderThreshold
+	}
+	if duration > threshold {
+		log := ctx.Value(logKey{}).(*logr.Logger)
+		log.Info("Slow chart rendering", "duration", duration.String(), "threshold", threshold.String())
+	}
+}
+
+// observePull records the duration of a chart pull, including failed attempts.
+func (c *ChartReconciler) observePull(chartRequest Chart, duration time.Duration, err error) {
+	if c.PullHistogram == nil {
+		return
+	}
+	result := "success"
+	if err != nil {
+		result = "failure"
+	}
+	c.PullHistogram.With(prometheus.Labels{
+		"repository": chartRequest.RepoURL,
+		"chart":      chartRequest.Name,
+		"result":     result,
+	}).Observe(duration.Seconds())
+}
+
+// observeHooks records the accumulated execution time of all hooks, which ran for the given release revision.
+func (c *ChartReconciler) observeHooks(desiredRelease ReleaseDeclaration, installed *release.Release) {
+	var total time.Duration
+	ran := false
+	for _, hook := range installed.Hooks {
+		if hook.LastRun.StartedAt.IsZero() || hook.LastRun.CompletedAt.IsZero() {
+			continue
+		}
+		ran = true
+		total += hook.LastRun.CompletedAt.Sub(hook.LastRun.StartedAt)
+	}
+	if ran {
+		c.observe(OperationHooks, desiredRelease, total)
+	}
+}
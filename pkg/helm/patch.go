@@ -0,0 +1,226 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helm
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	jsonpatch "github.com/evanphx/json-patch/v5"
+	"github.com/kharf/declcd/pkg/kube"
+	"gopkg.in/yaml.v3"
+	"helm.sh/helm/v3/pkg/postrender"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+var (
+	ErrInvalidPatch = errors.New("Invalid patch")
+)
+
+// Patches modify the rendered objects of a release before they are applied.
+// They are applied in order.
+type Patches []Patch
+
+// Patch modifies every rendered object matching its target with exactly one of Merge, StrategicMerge and JSONPatch.
+type Patch struct {
+	Target PatchTarget `json:"target"`
+
+	// Merge is an unstructured overlay merged into matching objects with JSON merge patch semantics (RFC 7386).
+	// Lists are replaced as a whole and null removes a field.
+	Merge map[string]interface{} `json:"merge,omitempty"`
+
+	// StrategicMerge is merged into matching objects with Kubernetes strategic merge patch semantics,
+	// which merges lists by their key, e.g. containers by name. '$patch: delete' removes a list entry.
+	// Kinds without strategic merge metadata, like custom resources, are patched with JSON merge patch semantics.
+	StrategicMerge map[string]interface{} `json:"strategicMerge,omitempty"`
+
+	// JSONPatch operations (RFC 6902) applied to matching objects, e.g. to remove a field or a list entry by index.
+	JSONPatch []JSONPatchOperation `json:"jsonPatch,omitempty"`
+}
+
+// PatchTarget selects rendered objects. Empty fields match every object.
+type PatchTarget struct {
+	APIVersion string `json:"apiVersion,omitempty"`
+	Kind       string `json:"kind,omitempty"`
+	Name       string `json:"name,omitempty"`
+}
+
+// JSONPatchOperation is a single operation of a JSON patch (RFC 6902).
+type JSONPatchOperation struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	From  string      `json:"from,omitempty"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+func (target PatchTarget) matches(obj *unstructured.Unstructured) bool {
+	return (target.APIVersion == "" || target.APIVersion == obj.GetAPIVersion()) &&
+		(target.Kind == "" || target.Kind == obj.GetKind()) &&
+		(target.Name == "" || target.Name == obj.GetName())
+}
+
+// postRenderer returns nil without patches and guardrails, so that Helm skips post-rendering.
+func (c *ChartReconciler) postRenderer(patches Patches) postrender.PostRenderer {
+	if len(patches) == 0 && c.Guardrails.MaxObjectSize == 0 && len(c.Guardrails.DeniedKinds) == 0 {
+		return nil
+	}
+	return patchRenderer{patches: patches, guardrails: c.Guardrails}
+}
+
+// patchRenderer applies patches to the manifests rendered by Helm
+// and refuses patched objects violating the guardrails.
+type patchRenderer struct {
+	patches    Patches
+	guardrails kube.Guardrails
+}
+
+var _ postrender.PostRenderer = (*patchRenderer)(nil)
+
+func (renderer patchRenderer) Run(renderedManifests *bytes.Buffer) (*bytes.Buffer, error) {
+	rendered := renderedManifests.Bytes()
+	decoder := yaml.NewDecoder(bytes.NewReader(rendered))
+	modifiedManifests := &bytes.Buffer{}
+	encoder := yaml.NewEncoder(modifiedManifests)
+	encoder.SetIndent(2)
+	for {
+		var content map[string]interface{}
+		if err := decoder.Decode(&content); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, err
+		}
+		if content == nil {
+			continue
+		}
+		obj := &unstructured.Unstructured{Object: content}
+		for i, patch := range renderer.patches {
+			if !patch.Target.matches(obj) {
+				continue
+			}
+			if err := patch.apply(obj); err != nil {
+				return nil, fmt.Errorf(
+					"patch %d: %s %s: %w",
+					i,
+					obj.GetKind(),
+					obj.GetName(),
+					err,
+				)
+			}
+		}
+		encoded, err := json.Marshal(obj.Object)
+		if err != nil {
+			return nil, err
+		}
+		if err := renderer.guardrails.Check(obj, len(encoded)); err != nil {
+			return nil, err
+		}
+		if err := encoder.Encode(obj.Object); err != nil {
+			return nil, err
+		}
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	// Without patches the rendered manifests stay untouched, so that enabling guardrails does not change the release.
+	if len(renderer.patches) == 0 {
+		return bytes.NewBuffer(rendered), nil
+	}
+	return modifiedManifests, nil
+}
+
+func (patch Patch) apply(obj *unstructured.Unstructured) error {
+	declared := 0
+	if patch.Merge != nil {
+		declared++
+	}
+	if patch.StrategicMerge != nil {
+		declared++
+	}
+	if patch.JSONPatch != nil {
+		declared++
+	}
+	if declared != 1 {
+		return fmt.Errorf("%w: exactly one of merge, strategicMerge and jsonPatch has to be declared", ErrInvalidPatch)
+	}
+
+	original, err := json.Marshal(obj.Object)
+	if err != nil {
+		return err
+	}
+
+	var patched []byte
+	switch {
+	case patch.Merge != nil:
+		mergePatch, err := json.Marshal(patch.Merge)
+		if err != nil {
+			return err
+		}
+		patched, err = jsonpatch.MergePatch(original, mergePatch)
+		if err != nil {
+			return err
+		}
+
+	case patch.StrategicMerge != nil:
+		mergePatch, err := json.Marshal(patch.StrategicMerge)
+		if err != nil {
+			return err
+		}
+		patched, err = strategicMerge(obj, original, mergePatch)
+		if err != nil {
+			return err
+		}
+
+	case patch.JSONPatch != nil:
+		operations, err := json.Marshal(patch.JSONPatch)
+		if err != nil {
+			return err
+		}
+		jsonPatch, err := jsonpatch.DecodePatch(operations)
+		if err != nil {
+			return fmt.Errorf("%w: %s", ErrInvalidPatch, err)
+		}
+		patched, err = jsonPatch.Apply(original)
+		if err != nil {
+			return err
+		}
+	}
+
+	content := make(map[string]interface{})
+	if err := json.Unmarshal(patched, &content); err != nil {
+		return err
+	}
+	obj.Object = content
+	return nil
+}
+
+// strategicMerge looks up the patch metadata of built-in kinds, like kubectl does,
+// and falls back to a JSON merge patch for all other kinds.
+func strategicMerge(obj *unstructured.Unstructured, original []byte, mergePatch []byte) ([]byte, error) {
+	typed, err := scheme.Scheme.New(obj.GroupVersionKind())
+	if err != nil {
+		if runtime.IsNotRegisteredError(err) {
+			return jsonpatch.MergePatch(original, mergePatch)
+		}
+		return nil, err
+	}
+	return strategicpatch.StrategicMergePatch(original, mergePatch, typed)
+}
@@ -0,0 +1,106 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helm
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/kharf/declcd/pkg/kube"
+	"gotest.tools/v3/assert"
+)
+
+const renderedManifests = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+data:
+  key: value
+---
+apiVersion: scheduling.k8s.io/v1
+kind: PriorityClass
+metadata:
+  name: critical
+value: 1000000
+`
+
+func TestPatchRenderer_Run(t *testing.T) {
+	testCases := []struct {
+		name       string
+		patches    Patches
+		guardrails kube.Guardrails
+		expected   string
+		err        error
+	}{
+		{
+			name:     "Unchanged",
+			expected: renderedManifests,
+		},
+		{
+			name: "DeniedKind",
+			guardrails: kube.Guardrails{
+				DeniedKinds: []kube.DeniedKind{
+					{APIGroup: "scheduling.k8s.io", Kind: "PriorityClass"},
+				},
+			},
+			err: kube.ErrKindDenied,
+		},
+		{
+			name: "TooLarge",
+			guardrails: kube.Guardrails{
+				MaxObjectSize: 100,
+			},
+			patches: Patches{
+				{
+					Target: PatchTarget{Kind: "ConfigMap"},
+					Merge: map[string]interface{}{
+						"data": map[string]interface{}{
+							"key": strings.Repeat("a", 100),
+						},
+					},
+				},
+			},
+			err: kube.ErrObjectTooLarge,
+		},
+		{
+			name: "WithinLimit",
+			guardrails: kube.Guardrails{
+				MaxObjectSize: 1024,
+			},
+			expected: renderedManifests,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			renderer := patchRenderer{patches: tc.patches, guardrails: tc.guardrails}
+			modified, err := renderer.Run(bytes.NewBufferString(renderedManifests))
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+				return
+			}
+			assert.NilError(t, err)
+			assert.Equal(t, modified.String(), tc.expected)
+		})
+	}
+}
+
+func TestChartReconciler_postRenderer(t *testing.T) {
+	chartReconciler := ChartReconciler{}
+	assert.Assert(t, chartReconciler.postRenderer(nil) == nil)
+
+	chartReconciler.Guardrails = kube.Guardrails{MaxObjectSize: 1024}
+	assert.Assert(t, chartReconciler.postRenderer(nil) != nil)
+}
@@ -0,0 +1,174 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helm
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"sigs.k8s.io/yaml"
+)
+
+// ValueSource describes where an effective value of a release is set.
+type ValueSource string
+
+const (
+	// ChartDefaults are the values shipped with the chart in its values.yaml.
+	ChartDefaults ValueSource = "ChartDefaults"
+	// ValuesFrom are the values read from a Secret or ConfigMap referenced by the release declaration.
+	ValuesFrom ValueSource = "ValuesFrom"
+	// DeclarationValues are the values of the release declaration.
+	DeclarationValues ValueSource = "DeclarationValues"
+)
+
+// ValueOrigin is the provenance of a single effective value of a release.
+type ValueOrigin struct {
+	// Path is the dot separated key of the value.
+	Path   string
+	Value  interface{}
+	Source ValueSource
+	// Reference names the object and the key a [ValuesFrom] value is read from,
+	// with the namespace and the key defaulted.
+	Reference *ValuesReference
+	// Overrides indicates whether the value replaces a chart default or a value of a previous valuesFrom reference.
+	Overrides bool
+}
+
+// ValuesLayer are values merged on top of the chart defaults and all previous layers.
+type ValuesLayer struct {
+	Source ValueSource
+	// Reference names the object and the key of a [ValuesFrom] layer.
+	Reference *ValuesReference
+	Values    Values
+}
+
+// Provenance reports for every leaf of the effective values, whether it is set by the chart defaults or the declaration.
+// See [LayeredProvenance].
+func Provenance(defaults map[string]interface{}, declared Values) []ValueOrigin {
+	return LayeredProvenance(defaults, ValuesLayer{Source: DeclarationValues, Values: declared})
+}
+
+// LayeredProvenance reports for every leaf of the effective values, which layer sets it.
+// Layers are merged in order on top of the chart defaults like Helm does: maps are merged into the values below,
+// all other values replace them, and nulls remove them.
+// The result is sorted by path.
+func LayeredProvenance(defaults map[string]interface{}, layers ...ValuesLayer) []ValueOrigin {
+	tree := overlay("", nil, defaults, ValuesLayer{Source: ChartDefaults})
+	for _, layer := range layers {
+		tree = overlay("", tree, layer.Values, layer)
+	}
+	origins := make([]ValueOrigin, 0)
+	collectOrigins(tree, &origins)
+	return origins
+}
+
+// overlay merges the values of a layer into a tree, whose inner nodes are maps and whose leaves are origins.
+func overlay(
+	prefix string,
+	tree map[string]interface{},
+	values map[string]interface{},
+	layer ValuesLayer,
+) map[string]interface{} {
+	merged := make(map[string]interface{}, len(tree)+len(values))
+	for key, node := range tree {
+		merged[key] = node
+	}
+	for key, value := range values {
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		}
+		if value == nil {
+			delete(merged, key)
+			continue
+		}
+
+		node, isSet := merged[key]
+		subtree, isSubtree := node.(map[string]interface{})
+		if origin, isLeaf := node.(ValueOrigin); isLeaf {
+			// empty maps are leaves, which are merged like any other map.
+			if emptyMap, isMap := origin.Value.(map[string]interface{}); isMap && len(emptyMap) == 0 {
+				isSubtree = true
+			}
+		}
+		valueMap, isMap := value.(map[string]interface{})
+		if isMap && (!isSet || isSubtree) && len(valueMap)+len(subtree) != 0 {
+			merged[key] = overlay(path, subtree, valueMap, layer)
+			continue
+		}
+		merged[key] = ValueOrigin{
+			Path:      path,
+			Value:     value,
+			Source:    layer.Source,
+			Reference: layer.Reference,
+			Overrides: isSet,
+		}
+	}
+	return merged
+}
+
+func collectOrigins(tree map[string]interface{}, origins *[]ValueOrigin) {
+	keys := make([]string, 0, len(tree))
+	for key := range tree {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	for _, key := range keys {
+		switch node := tree[key].(type) {
+		case map[string]interface{}:
+			collectOrigins(node, origins)
+		case ValueOrigin:
+			*origins = append(*origins, node)
+		}
+	}
+}
+
+// ValuesProvenance loads the chart of the declared release and reports the provenance of its effective values,
+// including the values read from its valuesFrom references.
+// See [LayeredProvenance].
+func (c *ChartReconciler) ValuesProvenance(
+	ctx context.Context,
+	release ReleaseDeclaration,
+) ([]ValueOrigin, error) {
+	ctx = context.WithValue(ctx, logKey{}, &c.Log)
+	chrt, err := c.load(ctx, release.Chart)
+	if err != nil {
+		return nil, err
+	}
+
+	layers := make([]ValuesLayer, 0, len(release.ValuesFrom)+1)
+	for _, ref := range release.ValuesFrom {
+		content, err := c.readValuesReference(ctx, ref, release.Namespace)
+		if err != nil {
+			return nil, err
+		}
+		if content == nil {
+			continue
+		}
+		var referenced Values
+		if err := yaml.Unmarshal(content, &referenced); err != nil {
+			return nil, fmt.Errorf("%s %s: %w", ref.Kind, ref.Name, err)
+		}
+		reference := ref.defaulted(release.Namespace)
+		layers = append(layers, ValuesLayer{
+			Source:    ValuesFrom,
+			Reference: &reference,
+			Values:    referenced,
+		})
+	}
+	layers = append(layers, ValuesLayer{Source: DeclarationValues, Values: release.Values})
+	return LayeredProvenance(chrt.Values, layers...), nil
+}
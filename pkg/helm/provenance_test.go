@@ -0,0 +1,155 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helm_test
+
+import (
+	"testing"
+
+	"github.com/kharf/declcd/pkg/helm"
+	"gotest.tools/v3/assert"
+)
+
+func TestProvenance(t *testing.T) {
+	defaults := map[string]interface{}{
+		"replicaCount": float64(1),
+		"image": map[string]interface{}{
+			"repository": "nginx",
+			"tag":        "1.0.0",
+		},
+		"service": map[string]interface{}{
+			"type": "ClusterIP",
+		},
+		"ingress": map[string]interface{}{
+			"enabled": false,
+		},
+	}
+	declared := helm.Values{
+		"image": map[string]interface{}{
+			"tag": "2.0.0",
+		},
+		"ingress":   nil,
+		"autoscale": true,
+		"service":   "none",
+	}
+
+	origins := helm.Provenance(defaults, declared)
+	assert.DeepEqual(t, origins, []helm.ValueOrigin{
+		{
+			Path:   "autoscale",
+			Value:  true,
+			Source: helm.DeclarationValues,
+		},
+		{
+			Path:   "image.repository",
+			Value:  "nginx",
+			Source: helm.ChartDefaults,
+		},
+		{
+			Path:      "image.tag",
+			Value:     "2.0.0",
+			Source:    helm.DeclarationValues,
+			Overrides: true,
+		},
+		{
+			Path:   "replicaCount",
+			Value:  float64(1),
+			Source: helm.ChartDefaults,
+		},
+		{
+			Path:      "service",
+			Value:     "none",
+			Source:    helm.DeclarationValues,
+			Overrides: true,
+		},
+	})
+}
+
+func TestLayeredProvenance(t *testing.T) {
+	defaults := map[string]interface{}{
+		"replicaCount": float64(1),
+		"database": map[string]interface{}{
+			"host":     "localhost",
+			"password": "",
+		},
+		"extraEnv": map[string]interface{}{},
+	}
+	credentials := helm.ValuesReference{
+		Kind:      "Secret",
+		Name:      "credentials",
+		Namespace: "shop",
+		Key:       "values.yaml",
+	}
+	overrides := helm.ValuesReference{
+		Kind:      "ConfigMap",
+		Name:      "overrides",
+		Namespace: "shop",
+		Key:       "prod.yaml",
+	}
+
+	origins := helm.LayeredProvenance(
+		defaults,
+		helm.ValuesLayer{
+			Source:    helm.ValuesFrom,
+			Reference: &credentials,
+			Values: helm.Values{
+				"database": map[string]interface{}{
+					"password": "secret",
+				},
+				"replicaCount": float64(2),
+			},
+		},
+		helm.ValuesLayer{
+			Source:    helm.ValuesFrom,
+			Reference: &overrides,
+			Values: helm.Values{
+				"extraEnv": map[string]interface{}{
+					"LOG_LEVEL": "debug",
+				},
+			},
+		},
+		helm.ValuesLayer{
+			Source: helm.DeclarationValues,
+			Values: helm.Values{
+				"replicaCount": float64(3),
+			},
+		},
+	)
+	assert.DeepEqual(t, origins, []helm.ValueOrigin{
+		{
+			Path:   "database.host",
+			Value:  "localhost",
+			Source: helm.ChartDefaults,
+		},
+		{
+			Path:      "database.password",
+			Value:     "secret",
+			Source:    helm.ValuesFrom,
+			Reference: &credentials,
+			Overrides: true,
+		},
+		{
+			Path:      "extraEnv.LOG_LEVEL",
+			Value:     "debug",
+			Source:    helm.ValuesFrom,
+			Reference: &overrides,
+		},
+		{
+			Path:      "replicaCount",
+			Value:     float64(3),
+			Source:    helm.DeclarationValues,
+			Overrides: true,
+		},
+	})
+}
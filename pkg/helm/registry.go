@@ -0,0 +1,229 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/registry"
+)
+
+const (
+	// DefaultRegistryLoginTTL is how long a registry login is reused.
+	// It is shorter than the lifetime of the short-lived tokens issued by cloud providers and GitHub.
+	DefaultRegistryLoginTTL = 10 * time.Minute
+
+	// DefaultPullConcurrency is the number of charts pulled at the same time by [ChartReconciler.Prefetch].
+	DefaultPullConcurrency = 4
+)
+
+// RegistryHosts lists registry and repository hosts, optionally with their port, e.g. "registry.internal:5000".
+type RegistryHosts []string
+
+// Contains reports whether the host of given repository url or registry reference is listed.
+// Hosts listed without a port match every port.
+func (hosts RegistryHosts) Contains(ref string) bool {
+	if len(hosts) == 0 {
+		return false
+	}
+	if _, rest, found := strings.Cut(ref, "://"); found {
+		ref = rest
+	}
+	host, _, _ := strings.Cut(ref, "/")
+	hostname := host
+	if name, _, err := net.SplitHostPort(host); err == nil {
+		hostname = name
+	}
+	for _, listed := range hosts {
+		if listed == host || listed == hostname {
+			return true
+		}
+	}
+	return false
+}
+
+// RegistryClientPool shares authenticated OCI registry clients between chart pulls,
+// so that releases resolving charts from the same registry with the same credentials
+// neither fetch credentials nor log in for every pull.
+// It is safe for concurrent use and meant to live across reconciliations.
+type RegistryClientPool struct {
+	// TTL defines how long a login is reused, before credentials are fetched again.
+	// Defaults to [DefaultRegistryLoginTTL].
+	TTL time.Duration
+
+	mu      sync.Mutex
+	clients map[string]*pooledRegistryClient
+}
+
+type pooledRegistryClient struct {
+	mu         sync.Mutex
+	client     *registry.Client
+	loggedInAt time.Time
+}
+
+// get returns the registry client of given key and logs it in, if its login is older than the TTL.
+// Concurrent callers of the same key wait for a single login.
+// A nil pool creates and logs in a new client on every call.
+func (pool *RegistryClientPool) get(
+	key string,
+	newClient func() (*registry.Client, error),
+	login func(client *registry.Client) error,
+) (*registry.Client, error) {
+	if pool == nil {
+		client, err := newClient()
+		if err != nil {
+			return nil, err
+		}
+		if err := login(client); err != nil {
+			return nil, err
+		}
+		return client, nil
+	}
+
+	pool.mu.Lock()
+	if pool.clients == nil {
+		pool.clients = make(map[string]*pooledRegistryClient)
+	}
+	pooled, found := pool.clients[key]
+	if !found {
+		pooled = &pooledRegistryClient{}
+		pool.clients[key] = pooled
+	}
+	pool.mu.Unlock()
+
+	pooled.mu.Lock()
+	defer pooled.mu.Unlock()
+	if pooled.client == nil {
+		client, err := newClient()
+		if err != nil {
+			return nil, err
+		}
+		pooled.client = client
+	}
+
+	ttl := pool.TTL
+	if ttl == 0 {
+		ttl = DefaultRegistryLoginTTL
+	}
+	if time.Since(pooled.loggedInAt) >= ttl {
+		if err := login(pooled.client); err != nil {
+			return nil, err
+		}
+		pooled.loggedInAt = time.Now()
+	}
+	return pooled.client, nil
+}
+
+// registryKey identifies a registry together with the source of its credentials.
+func registryKey(host string, auth *Auth) string {
+	switch {
+	case auth == nil:
+		return host
+	case auth.WorkloadIdentity != nil:
+		return fmt.Sprintf("%s|workloadIdentity=%s", host, auth.WorkloadIdentity.Provider)
+	case auth.GitHubApp != nil:
+		return fmt.Sprintf(
+			"%s|githubApp=%d/%d",
+			host,
+			auth.GitHubApp.AppID,
+			auth.GitHubApp.InstallationID,
+		)
+	case auth.SecretRef != nil:
+		return fmt.Sprintf("%s|secretRef=%s/%s", host, auth.SecretRef.Namespace, auth.SecretRef.Name)
+	}
+	return host
+}
+
+// Prefetch pulls all given charts, which are not yet available locally, in parallel,
+// so that releases load them from disk when they are reconciled.
+// At most PullConcurrency charts are pulled at the same time.
+// Failed pulls are retried until the retry budget, shared by all pulls, is used up.
+// Failures are only logged, because the reconciliation of a release pulls its chart again and reports the error.
+func (c *ChartReconciler) Prefetch(ctx context.Context, charts []Chart) {
+	missing := make(map[string]Chart, len(charts))
+	for _, chartRequest := range charts {
+		archivePath := newArchivePath(chartRequest)
+		if _, found := missing[archivePath.fullPath]; found {
+			continue
+		}
+		if _, err := loader.Load(archivePath.fullPath); err != nil {
+			pathErr := &fs.PathError{}
+			if errors.As(err, &pathErr) {
+				missing[archivePath.fullPath] = chartRequest
+			}
+		}
+	}
+	if len(missing) == 0 {
+		return
+	}
+
+	concurrency := c.PullConcurrency
+	if concurrency <= 0 {
+		concurrency = DefaultPullConcurrency
+	}
+	budget := c.PullRetryBudget
+	if budget <= 0 {
+		budget = len(missing)
+	}
+	retries := atomic.Int64{}
+	retries.Store(int64(budget))
+
+	eg := errgroup.Group{}
+	eg.SetLimit(concurrency)
+	for _, chartRequest := range missing {
+		eg.Go(func() error {
+			log := c.Log.WithValues(
+				"name",
+				chartRequest.Name,
+				"url",
+				chartRequest.RepoURL,
+				"version",
+				chartRequest.Version,
+			)
+			// Every pull gets its own configuration, because pulls set their registry client on it.
+			pullCtx := context.WithValue(ctx, configKey{}, &action.Configuration{})
+			backoff := time.Second
+			for {
+				err := c.pull(pullCtx, chartRequest, newArchivePath(chartRequest).dir)
+				if err == nil {
+					return nil
+				}
+				if retries.Add(-1) < 0 || ctx.Err() != nil {
+					log.Error(err, "Unable to prefetch chart")
+					return nil
+				}
+				log.V(1).Info("Retrying chart pull", "err", err)
+				select {
+				case <-ctx.Done():
+					return nil
+				case <-time.After(backoff):
+				}
+				backoff *= 2
+			}
+		})
+	}
+	_ = eg.Wait()
+}
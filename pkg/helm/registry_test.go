@@ -0,0 +1,44 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helm_test
+
+import (
+	"testing"
+
+	"github.com/kharf/declcd/pkg/helm"
+	"gotest.tools/v3/assert"
+)
+
+func TestRegistryHosts_Contains(t *testing.T) {
+	hosts := helm.RegistryHosts{"legacy.internal:5000", "charts.internal"}
+	testCases := []struct {
+		ref      string
+		expected bool
+	}{
+		{ref: "oci://legacy.internal:5000/charts", expected: true},
+		{ref: "legacy.internal:5000/declcd/audit", expected: true},
+		{ref: "oci://legacy.internal:5001/charts", expected: false},
+		{ref: "http://charts.internal/stable", expected: true},
+		{ref: "https://charts.internal:8443", expected: true},
+		{ref: "oci://registry.example.com/charts.internal", expected: false},
+		{ref: "https://charts.example.com", expected: false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.ref, func(t *testing.T) {
+			assert.Equal(t, hosts.Contains(tc.ref), tc.expected)
+		})
+	}
+	assert.Assert(t, !helm.RegistryHosts(nil).Contains("oci://legacy.internal:5000"))
+}
@@ -14,6 +14,13 @@
 
 package helm
 
+import (
+	"time"
+
+	"github.com/kharf/declcd/pkg/health"
+	"github.com/kharf/declcd/pkg/promotion"
+)
+
 // ReleaseComponent represents a Declcd component with its id, dependencies and content.
 // It is the Go equivalent of the CUE definition the user interacts with.
 // See [ReleaseDeclaration] for more.
@@ -21,6 +28,12 @@ type ReleaseComponent struct {
 	ID           string
 	Dependencies []string
 	Content      ReleaseDeclaration
+
+	// Promotion optionally defers applying new versions of the release.
+	Promotion promotion.Policy
+
+	// Orphan keeps the release installed, when the component is removed from the project.
+	Orphan bool
 }
 
 func (hr *ReleaseComponent) GetID() string {
@@ -41,6 +54,67 @@ type ReleaseDeclaration struct {
 	Namespace string `json:"namespace"`
 	Chart     Chart  `json:"chart"`
 	Values    Values `json:"values"`
+	// Capabilities optionally override the Kubernetes version and API versions
+	// a chart is rendered against.
+	Capabilities *Capabilities `json:"capabilities,omitempty"`
+	// Wait optionally blocks until all objects of the release are ready,
+	// so dependent components only start when the release is actually serving.
+	Wait *Wait `json:"wait,omitempty"`
+	// FieldManager optionally overrides the field manager of the controller the release objects are applied with.
+	FieldManager string `json:"fieldManager,omitempty"`
+	// ServiceAccountName optionally restricts the release to the permissions of a service account in the project namespace,
+	// which is impersonated instead of the one of the project.
+	ServiceAccountName string `json:"serviceAccountName,omitempty"`
+	// NamespaceMetadata optionally declares labels and annotations of the release namespace,
+	// which are applied before the chart is installed or upgraded.
+	NamespaceMetadata *NamespaceMetadata `json:"namespaceMetadata,omitempty"`
+	// ValuesFrom reads values from Secrets or ConfigMaps in the cluster.
+	// They are merged in order and overridden by Values.
+	ValuesFrom []ValuesReference `json:"valuesFrom,omitempty"`
+	// Patches modify the rendered objects before they are applied.
+	Patches Patches `json:"patches,omitempty"`
+	// HookResources optionally tracks the objects created by Helm hooks with the release in the inventory,
+	// so that they are deleted when the release is uninstalled instead of dangling.
+	HookResources *HookResources `json:"hookResources,omitempty"`
+}
+
+// ValuesReference reads YAML encoded values from a key of a Secret or ConfigMap.
+// Changes of the referenced content upgrade the release, so that rotated credentials propagate automatically.
+type ValuesReference struct {
+	// Kind of the referenced object, either Secret or ConfigMap.
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+	// Namespace of the referenced object. Defaults to the release namespace.
+	Namespace string `json:"namespace,omitempty"`
+	// Key holding the values. Defaults to values.yaml.
+	Key string `json:"key,omitempty"`
+	// Optional references are skipped, when the object or the key does not exist.
+	Optional bool `json:"optional,omitempty"`
+}
+
+// NamespaceMetadata is required on the release namespace before a chart is installed,
+// like PodSecurity admission or sidecar injection labels.
+type NamespaceMetadata struct {
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// Wait configures waiting for the readiness of all release objects after an installation or upgrade.
+type Wait struct {
+	// Timeout defines how long to wait for all objects to become ready.
+	Timeout time.Duration `json:"timeout"`
+	// Rules customize the readiness check per object kind, e.g. for custom resources.
+	Rules health.Rules `json:"rules,omitempty"`
+}
+
+// Capabilities override the discovered capabilities of a Kubernetes cluster.
+// This helps rendering charts with strict version checks on clusters reporting unusual versions,
+// like pre-release suffixes.
+type Capabilities struct {
+	// KubeVersion replaces the discovered Kubernetes version, e.g. "1.30.0".
+	KubeVersion string `json:"kubeVersion,omitempty"`
+	// APIVersions are added to the discovered API versions, e.g. "monitoring.coreos.com/v1".
+	APIVersions []string `json:"apiVersions,omitempty"`
 }
 
 // Values provide a way to override Helm Chart template defaults with custom information.
@@ -54,6 +128,22 @@ type Release struct {
 	Namespace string `json:"namespace"`
 	Chart     Chart  `json:"chart"`
 	Values    Values `json:"values"`
+	// Capabilities the release was rendered against, if overridden.
+	Capabilities *Capabilities `json:"capabilities,omitempty"`
+	// ValuesFrom the release was rendered with.
+	ValuesFrom []ValuesReference `json:"valuesFrom,omitempty"`
+	// ValuesFromDigest identifies the content read through ValuesFrom, without storing the content itself.
+	ValuesFromDigest string `json:"valuesFromDigest,omitempty"`
+	// Patches the rendered objects were modified with.
+	Patches Patches `json:"patches,omitempty"`
+	// FieldManager the release objects were applied with, if overridden.
+	FieldManager string `json:"fieldManager,omitempty"`
 	// Version is an int which represents the revision of the release.
 	Version int `json:"-"`
+	// Orphan is persisted with the release, so that the garbage collector keeps it installed
+	// after its component has been removed.
+	Orphan bool `json:"orphan,omitempty"`
+	// HookResources are the tracked objects created by hooks of the installed revision,
+	// which the garbage collector deletes after uninstalling the release.
+	HookResources []HookResource `json:"hookResources,omitempty"`
 }
@@ -0,0 +1,93 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helm
+
+import (
+	"context"
+
+	"helm.sh/helm/v3/pkg/action"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// Render templates the declared release against the cluster without installing or upgrading it
+// and returns the objects a reconciliation would apply.
+// Hooks are not part of the result.
+func (c *ChartReconciler) Render(
+	ctx context.Context,
+	component *ReleaseComponent,
+) ([]unstructured.Unstructured, error) {
+	desiredRelease := component.Content
+	if desiredRelease.Name == "" {
+		desiredRelease.Name = desiredRelease.Chart.Name
+	}
+	if desiredRelease.Namespace == "" {
+		desiredRelease.Namespace = "default"
+	}
+
+	logger := c.Log.WithValues(
+		"name",
+		desiredRelease.Chart.Name,
+		"releasename",
+		desiredRelease.Name,
+		"namespace",
+		desiredRelease.Namespace,
+	)
+	ctx = context.WithValue(ctx, logKey{}, &logger)
+
+	helmCfg, err := Init(
+		desiredRelease.Namespace,
+		c.KubeConfig,
+		c.Client,
+		c.fieldManager(desiredRelease),
+		c.Suppressions,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if desiredRelease.Capabilities != nil {
+		capabilities, err := overrideCapabilities(helmCfg, *desiredRelease.Capabilities)
+		if err != nil {
+			return nil, err
+		}
+		helmCfg.Capabilities = capabilities
+	}
+	ctx = context.WithValue(ctx, configKey{}, helmCfg)
+
+	resolved, err := c.resolveValues(ctx, desiredRelease)
+	if err != nil {
+		return nil, err
+	}
+
+	chrt, err := c.load(ctx, desiredRelease.Chart)
+	if err != nil {
+		return nil, err
+	}
+
+	install := action.NewInstall(helmCfg)
+	install.PlainHTTP = c.plainHTTP(desiredRelease.Chart.RepoURL)
+	install.ReleaseName = desiredRelease.Name
+	install.Namespace = desiredRelease.Namespace
+	install.PostRenderer = c.postRenderer(desiredRelease.Patches)
+	install.DryRunOption = "server"
+	// Objects of an already installed release must not be reported as conflicts.
+	install.IsUpgrade = true
+
+	rendered, err := install.Run(chrt, resolved.values)
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeManifests(rendered)
+}
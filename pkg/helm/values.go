@@ -0,0 +1,160 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helm
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+)
+
+var (
+	ErrUnsupportedValuesKind = errors.New("Unsupported valuesFrom kind")
+	ErrValuesKeyNotFound     = errors.New("Values key not found")
+)
+
+const (
+	// DefaultValuesKey is the key of a Secret or ConfigMap read by a [ValuesReference] without a key.
+	DefaultValuesKey = "values.yaml"
+)
+
+type valuesKey struct{}
+
+// resolvedValues are the effective values of a release.
+type resolvedValues struct {
+	values Values
+
+	// digest identifies the content of all valuesFrom references.
+	// It is empty for releases without valuesFrom.
+	digest string
+}
+
+// resolveValues reads all valuesFrom references in order and merges the declared values on top.
+// Changes to the referenced content change the digest, which triggers an upgrade,
+// even if the declaration itself is unchanged.
+func (c *ChartReconciler) resolveValues(
+	ctx context.Context,
+	release ReleaseDeclaration,
+) (*resolvedValues, error) {
+	if len(release.ValuesFrom) == 0 {
+		return &resolvedValues{values: release.Values}, nil
+	}
+
+	hash := sha256.New()
+	values := Values{}
+	for _, ref := range release.ValuesFrom {
+		content, err := c.readValuesReference(ctx, ref, release.Namespace)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(hash, "%s/%s/%s/%s\n", ref.Kind, ref.Namespace, ref.Name, ref.Key)
+		if content == nil {
+			// Creating a missing optional reference later changes the digest as well.
+			hash.Write([]byte("absent\n"))
+			continue
+		}
+		hash.Write(content)
+
+		var referenced Values
+		if err := yaml.Unmarshal(content, &referenced); err != nil {
+			return nil, fmt.Errorf("%s %s: %w", ref.Kind, ref.Name, err)
+		}
+		values = mergeValues(values, referenced)
+	}
+
+	return &resolvedValues{
+		values: mergeValues(values, release.Values),
+		digest: hex.EncodeToString(hash.Sum(nil)),
+	}, nil
+}
+
+// readValuesReference returns nil content for optional references to a missing object or key.
+func (c *ChartReconciler) readValuesReference(
+	ctx context.Context,
+	ref ValuesReference,
+	releaseNamespace string,
+) ([]byte, error) {
+	ref = ref.defaulted(releaseNamespace)
+	namespace := ref.Namespace
+	key := ref.Key
+
+	switch ref.Kind {
+	case "Secret", "ConfigMap":
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedValuesKind, ref.Kind)
+	}
+
+	req := &unstructured.Unstructured{}
+	req.SetKind(ref.Kind)
+	req.SetAPIVersion("v1")
+	req.SetName(ref.Name)
+	req.SetNamespace(namespace)
+	obj, err := c.Client.Get(ctx, req)
+	if err != nil {
+		if ref.Optional && k8sErrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	data, _ := obj.Object["data"].(map[string]interface{})
+	value, found := data[key].(string)
+	if !found {
+		if ref.Optional {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("%w: %s in %s %s/%s", ErrValuesKeyNotFound, key, ref.Kind, namespace, ref.Name)
+	}
+	if ref.Kind == "ConfigMap" {
+		return []byte(value), nil
+	}
+	return base64.StdEncoding.DecodeString(value)
+}
+
+// defaulted returns the reference with the namespace defaulted to the release namespace and the key to [DefaultValuesKey].
+func (ref ValuesReference) defaulted(releaseNamespace string) ValuesReference {
+	if ref.Namespace == "" {
+		ref.Namespace = releaseNamespace
+	}
+	if ref.Key == "" {
+		ref.Key = DefaultValuesKey
+	}
+	return ref
+}
+
+// mergeValues returns a copy of base, deeply overridden by override.
+func mergeValues(base Values, override Values) Values {
+	merged := make(Values, len(base)+len(override))
+	for key, value := range base {
+		merged[key] = value
+	}
+	for key, value := range override {
+		overrideTable, isTable := value.(map[string]interface{})
+		baseTable, baseIsTable := merged[key].(map[string]interface{})
+		if isTable && baseIsTable {
+			merged[key] = map[string]interface{}(mergeValues(baseTable, overrideTable))
+			continue
+		}
+		merged[key] = value
+	}
+	return merged
+}
@@ -0,0 +1,341 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helm
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"cuelabs.dev/go/oci/ociregistry"
+	"cuelabs.dev/go/oci/ociregistry/ociauth"
+	"cuelabs.dev/go/oci/ociregistry/ociclient"
+	"github.com/kharf/declcd/pkg/cloud"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"helm.sh/helm/v3/pkg/provenance"
+	"helm.sh/helm/v3/pkg/registry"
+)
+
+const (
+	// CosignPublicKey is the key of the PEM encoded cosign public key in the Secret referenced by [Verify].
+	CosignPublicKey = "cosign.pub"
+	// ProvenanceKeyring is the key of the OpenPGP public keyring in the Secret referenced by [Verify].
+	ProvenanceKeyring = "pubring.gpg"
+
+	cosignSignatureAnnotation = "dev.cosignproject.cosign/signature"
+)
+
+var (
+	// ErrChartVerification occurs when a chart is not signed by the key of its [Verify] declaration.
+	// Such charts are never installed.
+	ErrChartVerification = errors.New("Chart verification failed")
+)
+
+// Verify refuses to install a chart, which is not signed by a trusted key.
+// Charts from OCI registries are verified against their cosign signatures with the public key stored under "cosign.pub".
+// Charts from index.yaml repositories are verified against their provenance file with the OpenPGP keyring stored under "pubring.gpg".
+// Keyless cosign signatures are not supported.
+type Verify struct {
+	SecretRef SecretRef `json:"secretRef"`
+}
+
+// verifyOCIChart checks that the pulled archive is the chart layer of the manifest tagged with the chart version
+// and that the manifest carries a cosign signature of the trusted key.
+func (c *ChartReconciler) verifyOCIChart(
+	ctx context.Context,
+	chartRequest Chart,
+	archive string,
+	creds *cloud.Credentials,
+	httpClient *http.Client,
+) error {
+	publicKey, err := c.cosignPublicKey(ctx, *chartRequest.Verify)
+	if err != nil {
+		return err
+	}
+
+	host, repositoryPath, _ := strings.Cut(strings.TrimPrefix(chartRequest.RepoURL, "oci://"), "/")
+	repository := strings.TrimPrefix(repositoryPath+"/"+chartRequest.Name, "/")
+	client, err := ociclient.New(host, &ociclient.Options{
+		Transport: ociauth.NewStdTransport(ociauth.StdTransportParams{
+			Config:    staticCredentials{creds: creds},
+			Transport: httpClient.Transport,
+		}),
+		Insecure: c.plainHTTP(chartRequest.RepoURL),
+	})
+	if err != nil {
+		return err
+	}
+
+	// OCI tags do not allow "+", so Helm replaces it with "_".
+	manifestDesc, manifest, err := getManifest(ctx, client, repository, strings.ReplaceAll(chartRequest.Version, "+", "_"))
+	if err != nil {
+		return err
+	}
+	if err := verifyChartLayer(manifest, archive); err != nil {
+		return err
+	}
+
+	signatureTag := fmt.Sprintf("%s-%s.sig", manifestDesc.Digest.Algorithm(), manifestDesc.Digest.Encoded())
+	_, signatures, err := getManifest(ctx, client, repository, signatureTag)
+	if err != nil {
+		if errors.Is(err, ociregistry.ErrManifestUnknown) || errors.Is(err, ociregistry.ErrNameUnknown) {
+			return fmt.Errorf("%w: %s: no cosign signature", ErrChartVerification, chartRequest.Name)
+		}
+		return err
+	}
+
+	for _, layer := range signatures.Layers {
+		signature, found := layer.Annotations[cosignSignatureAnnotation]
+		if !found {
+			continue
+		}
+		payload, err := getBlob(ctx, client, repository, layer)
+		if err != nil {
+			return err
+		}
+		if VerifyCosignSignature(publicKey, payload, signature, manifestDesc.Digest) == nil {
+			return nil
+		}
+	}
+	return fmt.Errorf(
+		"%w: %s: no cosign signature of the trusted key for %s",
+		ErrChartVerification,
+		chartRequest.Name,
+		manifestDesc.Digest,
+	)
+}
+
+// verifyProvenance checks the provenance file of an archive from an index.yaml repository.
+func (c *ChartReconciler) verifyProvenance(ctx context.Context, verify Verify, archive string, provenanceFile string) error {
+	keyring, err := c.provenanceKeyring(ctx, verify)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(keyring)
+	signatory, err := provenance.NewFromKeyring(keyring, "")
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrChartVerification, err)
+	}
+	if _, err := signatory.Verify(archive, provenanceFile); err != nil {
+		return fmt.Errorf("%w: %w", ErrChartVerification, err)
+	}
+	return nil
+}
+
+// provenanceKeyring writes the trusted keyring to a temporary file, as Helm reads keyrings from files only.
+// The caller removes it.
+func (c *ChartReconciler) provenanceKeyring(ctx context.Context, verify Verify) (string, error) {
+	data, err := c.readSecretData(ctx, verify.SecretRef)
+	if err != nil {
+		return "", err
+	}
+	keyring, err := getSecretValue(data, ProvenanceKeyring, false)
+	if err != nil {
+		return "", err
+	}
+	file, err := os.CreateTemp("", "keyring-*.gpg")
+	if err != nil {
+		return "", err
+	}
+	if _, err := file.WriteString(keyring); err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return "", err
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(file.Name())
+		return "", err
+	}
+	return file.Name(), nil
+}
+
+func (c *ChartReconciler) cosignPublicKey(ctx context.Context, verify Verify) (crypto.PublicKey, error) {
+	data, err := c.readSecretData(ctx, verify.SecretRef)
+	if err != nil {
+		return nil, err
+	}
+	key, err := getSecretValue(data, CosignPublicKey, false)
+	if err != nil {
+		return nil, err
+	}
+	return ParseCosignPublicKey([]byte(key))
+}
+
+// ParseCosignPublicKey parses a PEM encoded ECDSA, RSA or Ed25519 public key as generated by 'cosign generate-key-pair'.
+func ParseCosignPublicKey(content []byte) (crypto.PublicKey, error) {
+	block, _ := pem.Decode(content)
+	if block == nil {
+		return nil, fmt.Errorf("%w: public key is not PEM encoded", ErrChartVerification)
+	}
+	publicKey, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrChartVerification, err)
+	}
+	return publicKey, nil
+}
+
+// cosignPayload is the simple signing payload cosign signs for an OCI artifact.
+type cosignPayload struct {
+	Critical struct {
+		Image struct {
+			DockerManifestDigest string `json:"docker-manifest-digest"`
+		} `json:"image"`
+	} `json:"critical"`
+}
+
+// VerifyCosignSignature checks the base64 encoded signature of a cosign payload
+// and that the payload signs the manifest with the given digest.
+func VerifyCosignSignature(
+	publicKey crypto.PublicKey,
+	payload []byte,
+	encodedSignature string,
+	manifestDigest digest.Digest,
+) error {
+	signature, err := base64.StdEncoding.DecodeString(encodedSignature)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrChartVerification, err)
+	}
+	hash := sha256.Sum256(payload)
+	valid := false
+	switch key := publicKey.(type) {
+	case *ecdsa.PublicKey:
+		valid = ecdsa.VerifyASN1(key, hash[:], signature)
+	case *rsa.PublicKey:
+		valid = rsa.VerifyPKCS1v15(key, crypto.SHA256, hash[:], signature) == nil
+	case ed25519.PublicKey:
+		valid = ed25519.Verify(key, payload, signature)
+	default:
+		return fmt.Errorf("%w: unsupported public key %T", ErrChartVerification, publicKey)
+	}
+	if !valid {
+		return fmt.Errorf("%w: invalid signature", ErrChartVerification)
+	}
+
+	// The signature is only valid for the artifact named in its payload.
+	var signed cosignPayload
+	if err := json.Unmarshal(payload, &signed); err != nil {
+		return fmt.Errorf("%w: %w", ErrChartVerification, err)
+	}
+	if signed.Critical.Image.DockerManifestDigest != manifestDigest.String() {
+		return fmt.Errorf(
+			"%w: signature is for %s, not %s",
+			ErrChartVerification,
+			signed.Critical.Image.DockerManifestDigest,
+			manifestDigest,
+		)
+	}
+	return nil
+}
+
+func verifyChartLayer(manifest *ocispec.Manifest, archive string) error {
+	content, err := os.ReadFile(archive)
+	if err != nil {
+		return err
+	}
+	archiveDigest := digest.FromBytes(content)
+	for _, layer := range manifest.Layers {
+		if layer.MediaType == registry.ChartLayerMediaType {
+			if layer.Digest != archiveDigest {
+				return fmt.Errorf(
+					"%w: pulled chart %s does not match the signed manifest layer %s",
+					ErrChartVerification,
+					archiveDigest,
+					layer.Digest,
+				)
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: manifest has no chart layer", ErrChartVerification)
+}
+
+func getManifest(
+	ctx context.Context,
+	client ociregistry.Interface,
+	repository string,
+	tag string,
+) (*ociregistry.Descriptor, *ocispec.Manifest, error) {
+	reader, err := client.GetTag(ctx, repository, tag)
+	if err != nil {
+		return nil, nil, err
+	}
+	desc := reader.Descriptor()
+	content, err := readBlob(reader, desc)
+	if err != nil {
+		return nil, nil, err
+	}
+	var manifest ocispec.Manifest
+	if err := json.Unmarshal(content, &manifest); err != nil {
+		return nil, nil, err
+	}
+	return &desc, &manifest, nil
+}
+
+func getBlob(
+	ctx context.Context,
+	client ociregistry.Interface,
+	repository string,
+	desc ociregistry.Descriptor,
+) ([]byte, error) {
+	reader, err := client.GetBlob(ctx, repository, desc.Digest)
+	if err != nil {
+		return nil, err
+	}
+	return readBlob(reader, desc)
+}
+
+// readBlob reads the content and ensures it matches its digest.
+func readBlob(reader ociregistry.BlobReader, desc ociregistry.Descriptor) ([]byte, error) {
+	defer reader.Close()
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	if actual := desc.Digest.Algorithm().FromBytes(content); actual != desc.Digest {
+		return nil, fmt.Errorf("%w: expected %s, got %s", ErrChartVerification, desc.Digest, actual)
+	}
+	return content, nil
+}
+
+// staticCredentials authenticates OCI clients with the credentials the chart is pulled with.
+type staticCredentials struct {
+	creds *cloud.Credentials
+}
+
+var _ ociauth.Config = staticCredentials{}
+
+func (config staticCredentials) EntryForRegistry(host string) (ociauth.ConfigEntry, error) {
+	if config.creds == nil {
+		return ociauth.ConfigEntry{}, nil
+	}
+	return ociauth.ConfigEntry{
+		Username: config.creds.Username,
+		Password: config.creds.Password,
+	}, nil
+}
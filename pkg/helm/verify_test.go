@@ -0,0 +1,112 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helm_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"testing"
+
+	"github.com/kharf/declcd/pkg/helm"
+	"github.com/opencontainers/go-digest"
+	"gotest.tools/v3/assert"
+)
+
+func TestVerifyCosignSignature(t *testing.T) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NilError(t, err)
+	publicKeyDER, err := x509.MarshalPKIXPublicKey(&privateKey.PublicKey)
+	assert.NilError(t, err)
+	publicKey, err := helm.ParseCosignPublicKey(
+		pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicKeyDER}),
+	)
+	assert.NilError(t, err)
+
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NilError(t, err)
+
+	manifestDigest := digest.FromString("manifest")
+	payload := []byte(fmt.Sprintf(
+		`{"critical":{"identity":{"docker-reference":"registry.local/charts/test"},"image":{"docker-manifest-digest":%q},"type":"cosign container image signature"},"optional":null}`,
+		manifestDigest,
+	))
+	sign := func(key *ecdsa.PrivateKey, payload []byte) string {
+		hash := sha256.Sum256(payload)
+		signature, err := ecdsa.SignASN1(rand.Reader, key, hash[:])
+		assert.NilError(t, err)
+		return base64.StdEncoding.EncodeToString(signature)
+	}
+
+	testCases := []struct {
+		name           string
+		payload        []byte
+		signature      string
+		manifestDigest digest.Digest
+		valid          bool
+	}{
+		{
+			name:           "Valid",
+			payload:        payload,
+			signature:      sign(privateKey, payload),
+			manifestDigest: manifestDigest,
+			valid:          true,
+		},
+		{
+			name:           "UntrustedKey",
+			payload:        payload,
+			signature:      sign(otherKey, payload),
+			manifestDigest: manifestDigest,
+		},
+		{
+			name:           "OtherManifest",
+			payload:        payload,
+			signature:      sign(privateKey, payload),
+			manifestDigest: digest.FromString("other"),
+		},
+		{
+			name:           "TamperedPayload",
+			payload:        append([]byte(" "), payload...),
+			signature:      sign(privateKey, payload),
+			manifestDigest: manifestDigest,
+		},
+		{
+			name:           "NoBase64",
+			payload:        payload,
+			signature:      "%",
+			manifestDigest: manifestDigest,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := helm.VerifyCosignSignature(publicKey, tc.payload, tc.signature, tc.manifestDigest)
+			if tc.valid {
+				assert.NilError(t, err)
+			} else {
+				assert.ErrorIs(t, err, helm.ErrChartVerification)
+			}
+		})
+	}
+}
+
+func TestParseCosignPublicKey(t *testing.T) {
+	_, err := helm.ParseCosignPublicKey([]byte("no pem"))
+	assert.ErrorIs(t, err, helm.ErrChartVerification)
+}
@@ -0,0 +1,265 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inventory
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// InventoryLabel is the label of Secrets stored by a [SecretBackend], which holds the name of their inventory.
+	InventoryLabel = "declcd/inventory"
+	// ItemAnnotation is the annotation of Secrets stored by a [SecretBackend], which holds the id of their item.
+	ItemAnnotation = "declcd/inventory-item"
+
+	secretContentKey = "content"
+	secretTimeout    = 30 * time.Second
+)
+
+// Backend persists the content of inventory items.
+// Content is already encrypted, when the [Instance] encrypts items.
+type Backend interface {
+	// Keys returns the ids of all stored items.
+	Keys() ([]string, error)
+
+	// Read opens the stored content of an item.
+	// If the item is not stored, the error satisfies errors.Is(err, fs.ErrNotExist).
+	Read(item Item) (io.ReadCloser, error)
+
+	// Write stores an item and replaces its content. A nil content stores the item without content.
+	Write(item Item, content io.Reader) error
+
+	// Delete removes an item.
+	Delete(item Item) error
+
+	// Clear removes all items and the inventory itself.
+	Clear() error
+}
+
+// FileBackend stores every item as a file in a directory per namespace, e.g. on a volume of the controller.
+type FileBackend struct {
+	Path string
+}
+
+var _ Backend = (*FileBackend)(nil)
+
+func (backend *FileBackend) Keys() ([]string, error) {
+	if err := os.MkdirAll(backend.Path, 0700); err != nil {
+		return nil, err
+	}
+	keys := make([]string, 0)
+	err := filepath.WalkDir(backend.Path, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			keys = append(keys, d.Name())
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// Read opens the item file. If the file cannot be opened, the error will be of type *PathError.
+func (backend *FileBackend) Read(item Item) (io.ReadCloser, error) {
+	return os.Open(filepath.Join(backend.Path, itemNs(item), item.GetID()))
+}
+
+func (backend *FileBackend) Write(item Item, content io.Reader) error {
+	dir := filepath.Join(backend.Path, itemNs(item))
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	file, err := os.Create(filepath.Join(dir, item.GetID()))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	if content == nil {
+		return nil
+	}
+	// Copy without splitting into lines, which would allocate a string per line for large objects.
+	if _, err := io.Copy(file, content); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (backend *FileBackend) Delete(item Item) error {
+	dir := filepath.Join(backend.Path, itemNs(item))
+	dirFile, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer dirFile.Close()
+	_, err = dirFile.Readdirnames(1)
+	if err == io.EOF {
+		if err := os.Remove(dir); err != nil {
+			return err
+		}
+	}
+	if err != nil {
+		return err
+	}
+	return os.Remove(filepath.Join(dir, item.GetID()))
+}
+
+func (backend *FileBackend) Clear() error {
+	return os.RemoveAll(backend.Path)
+}
+
+// SecretBackend stores every item in a Secret, so that the controller runs without a volume
+// and inventories survive rescheduling the controller onto other nodes or shards.
+// Every item is stored in its own Secret, which keeps items far below the size limit of Secrets
+// and lets components of a project be stored concurrently without conflicts.
+type SecretBackend struct {
+	Client client.Client
+
+	// Namespace of the Secrets, e.g. the namespace of the controller.
+	Namespace string
+
+	// Name identifies the inventory among all inventories in the namespace, e.g. the UID of its project.
+	Name string
+}
+
+var _ Backend = (*SecretBackend)(nil)
+
+func (backend *SecretBackend) Keys() ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), secretTimeout)
+	defer cancel()
+	// Only metadata is listed, as the item ids are part of it.
+	secrets := &v1.PartialObjectMetadataList{}
+	secrets.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("SecretList"))
+	if err := backend.Client.List(
+		ctx,
+		secrets,
+		client.InNamespace(backend.Namespace),
+		client.MatchingLabels{InventoryLabel: backend.Name},
+	); err != nil {
+		return nil, err
+	}
+	keys := make([]string, 0, len(secrets.Items))
+	for _, secret := range secrets.Items {
+		if key := secret.GetAnnotations()[ItemAnnotation]; key != "" {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+func (backend *SecretBackend) Read(item Item) (io.ReadCloser, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), secretTimeout)
+	defer cancel()
+	var secret corev1.Secret
+	if err := backend.Client.Get(ctx, client.ObjectKey{
+		Name:      backend.secretName(item),
+		Namespace: backend.Namespace,
+	}, &secret); err != nil {
+		if k8sErrors.IsNotFound(err) {
+			return nil, fmt.Errorf("%w: %s", fs.ErrNotExist, item.GetID())
+		}
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(secret.Data[secretContentKey])), nil
+}
+
+func (backend *SecretBackend) Write(item Item, content io.Reader) error {
+	data := map[string][]byte{}
+	if content != nil {
+		stored, err := io.ReadAll(content)
+		if err != nil {
+			return err
+		}
+		data[secretContentKey] = stored
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), secretTimeout)
+	defer cancel()
+	secret := &corev1.Secret{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      backend.secretName(item),
+			Namespace: backend.Namespace,
+			Labels: map[string]string{
+				InventoryLabel: backend.Name,
+			},
+			Annotations: map[string]string{
+				ItemAnnotation: item.GetID(),
+			},
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: data,
+	}
+	err := backend.Client.Create(ctx, secret)
+	if !k8sErrors.IsAlreadyExists(err) {
+		return err
+	}
+
+	var existing corev1.Secret
+	if err := backend.Client.Get(ctx, client.ObjectKeyFromObject(secret), &existing); err != nil {
+		return err
+	}
+	existing.Data = data
+	return backend.Client.Update(ctx, &existing)
+}
+
+func (backend *SecretBackend) Delete(item Item) error {
+	ctx, cancel := context.WithTimeout(context.Background(), secretTimeout)
+	defer cancel()
+	err := backend.Client.Delete(ctx, &corev1.Secret{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      backend.secretName(item),
+			Namespace: backend.Namespace,
+		},
+	})
+	if k8sErrors.IsNotFound(err) {
+		return fmt.Errorf("%w: %s", fs.ErrNotExist, item.GetID())
+	}
+	return err
+}
+
+func (backend *SecretBackend) Clear() error {
+	ctx, cancel := context.WithTimeout(context.Background(), secretTimeout)
+	defer cancel()
+	return backend.Client.DeleteAllOf(
+		ctx,
+		&corev1.Secret{},
+		client.InNamespace(backend.Namespace),
+		client.MatchingLabels{InventoryLabel: backend.Name},
+	)
+}
+
+// secretName derives a valid and unique object name from the inventory name and the item id,
+// which may contain characters not allowed in names, like underscores.
+func (backend *SecretBackend) secretName(item Item) string {
+	hash := sha256.Sum256([]byte(backend.Name + "/" + item.GetID()))
+	return "declcd-inventory-" + hex.EncodeToString(hash[:16])
+}
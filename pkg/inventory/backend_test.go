@@ -0,0 +1,133 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inventory_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/fs"
+	"strings"
+	"testing"
+
+	"github.com/kharf/declcd/pkg/inventory"
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestSecretBackend(t *testing.T) {
+	kubeClient := fake.NewClientBuilder().Build()
+	key := bytes.Repeat([]byte{1}, 32)
+	instance := inventory.Instance{
+		Backend: &inventory.SecretBackend{
+			Client:    kubeClient,
+			Namespace: "declcd-system",
+			Name:      "project-a",
+		},
+		EncryptionKey: key,
+	}
+	other := inventory.Instance{
+		Backend: &inventory.SecretBackend{
+			Client:    kubeClient,
+			Namespace: "declcd-system",
+			Name:      "project-b",
+		},
+	}
+
+	secret := &inventory.ManifestItem{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Secret",
+			APIVersion: "v1",
+		},
+		Name:      "secret",
+		Namespace: "test",
+		ID:        "secret_test__Secret",
+	}
+	release := &inventory.HelmReleaseItem{
+		Name:      "test",
+		Namespace: "test",
+		ID:        "test_test_HelmRelease",
+	}
+	content := `{"apiVersion":"v1","kind":"Secret","metadata":{"name":"secret","namespace":"test"},"stringData":{"password":"confidential"}}`
+	assert.NilError(t, instance.StoreItem(secret, strings.NewReader(content)))
+	assert.NilError(t, instance.StoreItem(release, nil))
+	assert.NilError(t, other.StoreItem(release, nil))
+
+	// Updates replace the content.
+	content = `{"apiVersion":"v1","kind":"Secret","metadata":{"name":"secret","namespace":"test"},"stringData":{"password":"rotated"}}`
+	assert.NilError(t, instance.StoreItem(secret, strings.NewReader(content)))
+
+	var secrets corev1.SecretList
+	err := kubeClient.List(
+		context.Background(),
+		&secrets,
+		client.InNamespace("declcd-system"),
+		client.MatchingLabels{inventory.InventoryLabel: "project-a"},
+	)
+	assert.NilError(t, err)
+	assert.Equal(t, len(secrets.Items), 2)
+	for _, stored := range secrets.Items {
+		assert.Assert(t, !bytes.Contains(stored.Data["content"], []byte("rotated")))
+	}
+
+	storage, err := instance.Load()
+	assert.NilError(t, err)
+	assert.Equal(t, len(storage.Items()), 2)
+	assert.Assert(t, storage.HasItem(secret))
+	assert.Assert(t, storage.HasItem(release))
+	assert.Equal(t, storage.Items()[secret.GetID()].(*inventory.ManifestItem).TypeMeta.Kind, "Secret")
+
+	reader, err := instance.GetItem(secret)
+	assert.NilError(t, err)
+	read, err := io.ReadAll(reader)
+	assert.NilError(t, err)
+	assert.NilError(t, reader.Close())
+	assert.Equal(t, string(read), content)
+
+	assert.NilError(t, instance.DeleteItem(secret))
+	_, err = instance.GetItem(secret)
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+	assert.ErrorIs(t, instance.DeleteItem(secret), fs.ErrNotExist)
+
+	assert.NilError(t, instance.Clear())
+	storage, err = instance.Load()
+	assert.NilError(t, err)
+	assert.Equal(t, len(storage.Items()), 0)
+
+	// Inventories of other projects are untouched.
+	storage, err = other.Load()
+	assert.NilError(t, err)
+	assert.Assert(t, storage.HasItem(release))
+}
+
+func TestFileBackend_Clear(t *testing.T) {
+	instance := inventory.Instance{
+		Path: t.TempDir(),
+	}
+	release := &inventory.HelmReleaseItem{
+		Name:      "test",
+		Namespace: "test",
+		ID:        "test_test_HelmRelease",
+	}
+	assert.NilError(t, instance.StoreItem(release, nil))
+
+	assert.NilError(t, instance.Clear())
+	storage, err := instance.Load()
+	assert.NilError(t, err)
+	assert.Equal(t, len(storage.Items()), 0)
+}
@@ -0,0 +1,120 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inventory
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"io"
+	"os"
+)
+
+var (
+	ErrInvalidEncryptionKey = errors.New("Inventory encryption key must be 32 base64 encoded bytes")
+	ErrEncryptionKeyMissing = errors.New("Inventory item is encrypted, but no encryption key is configured")
+)
+
+// encryptedHeader prefixes encrypted items,
+// so that items stored before encryption was enabled remain readable and are encrypted on their next update.
+var encryptedHeader = []byte("declcd-aes256gcm\n")
+
+// ReadEncryptionKey reads a base64 encoded AES-256 key, e.g. from a mounted Secret.
+// A missing file disables encryption and results in a nil key.
+func ReadEncryptionKey(path string) ([]byte, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return ParseEncryptionKey(content)
+}
+
+// ParseEncryptionKey decodes a base64 encoded AES-256 key, e.g. read from the data of a Secret.
+func ParseEncryptionKey(content []byte) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(string(bytes.TrimSpace(content)))
+	if err != nil || len(key) != 32 {
+		return nil, ErrInvalidEncryptionKey
+	}
+	return key, nil
+}
+
+func (instance Instance) seal(plaintext []byte) ([]byte, error) {
+	gcm, err := newGCM(instance.EncryptionKey)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	sealed := make([]byte, 0, len(encryptedHeader)+len(nonce)+len(plaintext)+gcm.Overhead())
+	sealed = append(sealed, encryptedHeader...)
+	sealed = append(sealed, nonce...)
+	return gcm.Seal(sealed, nonce, plaintext, nil), nil
+}
+
+// open returns a reader of the decrypted item content and takes over closing the stored content.
+// Plaintext items are streamed as they are.
+func (instance Instance) open(stored io.ReadCloser) (io.ReadCloser, error) {
+	reader := bufio.NewReader(stored)
+	header, err := reader.Peek(len(encryptedHeader))
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		stored.Close()
+		return nil, err
+	}
+	if !bytes.Equal(header, encryptedHeader) {
+		return struct {
+			io.Reader
+			io.Closer
+		}{reader, stored}, nil
+	}
+	defer stored.Close()
+
+	if instance.EncryptionKey == nil {
+		return nil, ErrEncryptionKeyMissing
+	}
+	sealed, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := newGCM(instance.EncryptionKey)
+	if err != nil {
+		return nil, err
+	}
+	sealed = sealed[len(encryptedHeader):]
+	if len(sealed) < gcm.NonceSize() {
+		return nil, io.ErrUnexpectedEOF
+	}
+	plaintext, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], nil)
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(plaintext)), nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
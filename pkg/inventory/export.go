@@ -0,0 +1,129 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inventory
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+var (
+	ErrInvalidItemContent = errors.New("Inventory item content is no valid JSON")
+)
+
+// Export is the portable representation of an inventory,
+// e.g. to move a project to another cluster or to restore a lost inventory volume.
+// The content of items is exported decrypted, so that it can be imported with another encryption key.
+type Export struct {
+	Items []ExportedItem `json:"items"`
+}
+
+// ExportedItem is an inventory item with its stored content.
+type ExportedItem struct {
+	// ID identifies the item in the inventory and encodes its name, namespace and kind.
+	ID string `json:"id"`
+
+	// Content is the stored object or Helm release. Empty, when the item is stored without content.
+	Content json.RawMessage `json:"content,omitempty"`
+}
+
+// Export reads all items of the inventory with their content, sorted by id.
+func (instance *Instance) Export() (*Export, error) {
+	storage, err := instance.Load()
+	if err != nil {
+		return nil, err
+	}
+	exported := make([]ExportedItem, 0, len(storage.Items()))
+	for _, item := range storage.Items() {
+		content, err := instance.readContent(item)
+		if err != nil {
+			return nil, err
+		}
+		exported = append(exported, ExportedItem{
+			ID:      item.GetID(),
+			Content: content,
+		})
+	}
+	slices.SortFunc(exported, func(a, b ExportedItem) int {
+		return strings.Compare(a.ID, b.ID)
+	})
+	return &Export{
+		Items: exported,
+	}, nil
+}
+
+func (instance *Instance) readContent(item Item) (json.RawMessage, error) {
+	reader, err := instance.GetItem(item)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	if len(content) == 0 {
+		return nil, nil
+	}
+	if !json.Valid(content) {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidItemContent, item.GetID())
+	}
+	return content, nil
+}
+
+// Import stores all exported items in the inventory and encrypts them, if the instance encrypts items.
+// Stored items with the same id are replaced and all other stored items are kept.
+func (instance *Instance) Import(export *Export) error {
+	for _, exported := range export.Items {
+		item, err := keyItem(exported.ID)
+		if err != nil {
+			return err
+		}
+		var content io.Reader
+		if len(exported.Content) != 0 {
+			content = bytes.NewReader(exported.Content)
+		}
+		if err := instance.StoreItem(item, content); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// keyItem returns the item identified by a key without reading its content.
+// The name of manifests is the one encoded in the key, which locates the item in the inventory.
+func keyItem(key string) (Item, error) {
+	identifier := strings.Split(key, "_")
+	switch {
+	case len(identifier) == 3 && identifier[2] == "HelmRelease":
+		return &HelmReleaseItem{
+			Name:      identifier[0],
+			Namespace: identifier[1],
+			ID:        key,
+		}, nil
+	case len(identifier) == 4:
+		return &ManifestItem{
+			Name:      identifier[0],
+			Namespace: identifier[1],
+			ID:        key,
+		}, nil
+	}
+	return nil, fmt.Errorf("%w: key '%s' identifies neither a manifest nor a HelmRelease", ErrWrongInventoryKey, key)
+}
@@ -0,0 +1,109 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inventory_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kharf/declcd/pkg/inventory"
+	"gotest.tools/v3/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+func TestInstance_ExportImport(t *testing.T) {
+	source := inventory.Instance{
+		Path:          t.TempDir(),
+		EncryptionKey: bytes.Repeat([]byte{1}, 32),
+	}
+	target := inventory.Instance{
+		Path:          t.TempDir(),
+		EncryptionKey: bytes.Repeat([]byte{2}, 32),
+	}
+
+	secret := &inventory.ManifestItem{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Secret",
+			APIVersion: "v1",
+		},
+		Name:      "secret",
+		Namespace: "test",
+		ID:        "secret_test__Secret",
+	}
+	secretContent := `{"apiVersion":"v1","kind":"Secret","metadata":{"name":"secret","namespace":"test"},"stringData":{"password":"confidential"}}`
+	namespace := &inventory.ManifestItem{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Namespace",
+			APIVersion: "v1",
+		},
+		Name: "test",
+		ID:   "test___Namespace",
+	}
+	namespaceContent := `{"apiVersion":"v1","kind":"Namespace","metadata":{"name":"test"}}`
+	release := &inventory.HelmReleaseItem{
+		Name:      "test",
+		Namespace: "test",
+		ID:        "test_test_HelmRelease",
+	}
+	assert.NilError(t, source.StoreItem(secret, strings.NewReader(secretContent)))
+	assert.NilError(t, source.StoreItem(namespace, strings.NewReader(namespaceContent)))
+	assert.NilError(t, source.StoreItem(release, nil))
+
+	exported, err := source.Export()
+	assert.NilError(t, err)
+	assert.DeepEqual(t, exported, &inventory.Export{
+		Items: []inventory.ExportedItem{
+			{ID: "secret_test__Secret", Content: json.RawMessage(secretContent)},
+			{ID: "test___Namespace", Content: json.RawMessage(namespaceContent)},
+			{ID: "test_test_HelmRelease"},
+		},
+	})
+
+	// Exports are written as YAML by the CLI.
+	data, err := yaml.Marshal(exported)
+	assert.NilError(t, err)
+	var read inventory.Export
+	assert.NilError(t, yaml.Unmarshal(data, &read))
+	assert.NilError(t, target.Import(&read))
+
+	storage, err := target.Load()
+	assert.NilError(t, err)
+	assert.Equal(t, len(storage.Items()), 3)
+	assert.Assert(t, storage.HasItem(secret))
+	assert.Assert(t, storage.HasItem(namespace))
+	assert.Assert(t, storage.HasItem(release))
+
+	// Imported items are encrypted with the key of the target.
+	stored, err := os.ReadFile(filepath.Join(target.Path, "test", secret.GetID()))
+	assert.NilError(t, err)
+	assert.Assert(t, !bytes.Contains(stored, []byte("confidential")))
+	reader, err := target.GetItem(secret)
+	assert.NilError(t, err)
+	content, err := io.ReadAll(reader)
+	assert.NilError(t, err)
+	assert.NilError(t, reader.Close())
+	assert.Equal(t, string(content), secretContent)
+
+	err = target.Import(&inventory.Export{
+		Items: []inventory.ExportedItem{{ID: "unknown"}},
+	})
+	assert.ErrorIs(t, err, inventory.ErrWrongInventoryKey)
+}
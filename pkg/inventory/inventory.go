@@ -15,14 +15,11 @@
 package inventory
 
 import (
-	"bufio"
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
-	"io/fs"
-	"os"
-	"path/filepath"
 	"strings"
 
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -113,145 +110,163 @@ func (inv Storage) HasItem(item Item) bool {
 	return false
 }
 
+// CountByKind counts the stored items by the kind of their object.
+// Helm releases are counted as kind HelmRelease.
+func (inv Storage) CountByKind() map[string]int {
+	counts := make(map[string]int)
+	for _, item := range inv.items {
+		switch item := item.(type) {
+		case *HelmReleaseItem:
+			counts["HelmRelease"]++
+		case *ManifestItem:
+			counts[item.TypeMeta.Kind]++
+		}
+	}
+	return counts
+}
+
 // Instance is a representation of an inventory.
 // It can store, delete and read items.
 // The object does not include the storage itself, it only holds a reference to the storage.
 type Instance struct {
+	// Path is the directory holding the items, unless a Backend is set.
 	Path string
+
+	// Backend optionally stores the items somewhere else than in Path,
+	// e.g. in Secrets with [SecretBackend], so that the controller does not need a volume.
+	Backend Backend
+
+	// EncryptionKey optionally encrypts the content of stored items with AES-256-GCM.
+	// See [ReadEncryptionKey].
+	EncryptionKey []byte
+}
+
+func (instance Instance) backend() Backend {
+	if instance.Backend != nil {
+		return instance.Backend
+	}
+	return &FileBackend{Path: instance.Path}
 }
 
 // Load returns all the stored components in this inventory.
 func (instance *Instance) Load() (*Storage, error) {
-	if err := os.MkdirAll(instance.Path, 0700); err != nil {
+	backend := instance.backend()
+	keys, err := backend.Keys()
+	if err != nil {
 		return nil, err
 	}
-	items := make(map[string]Item)
-	err := filepath.WalkDir(instance.Path, func(path string, d fs.DirEntry, err error) error {
-		if err != nil {
-			return err
+	items := make(map[string]Item, len(keys))
+	for _, key := range keys {
+		identifier := strings.Split(key, "_")
+		if len(identifier) < 3 {
+			return nil, fmt.Errorf("%w: key '%s' does not contain 4 identifiers", ErrWrongInventoryKey, key)
 		}
-		if !d.IsDir() {
-			key := d.Name()
-			identifier := strings.Split(key, "_")
-			name := identifier[0]
-			namespace := identifier[1]
-			if len(identifier) == 3 {
-				kind := identifier[2]
-				if kind != "HelmRelease" {
-					return fmt.Errorf(
-						"%w: key with only 3 identifiers is expected to be a HelmRelease",
-						ErrWrongInventoryKey,
-					)
-				}
-				items[key] = &HelmReleaseItem{
-					Name:      name,
-					Namespace: namespace,
-					ID:        key,
-				}
-			} else {
-				if len(identifier) != 4 {
-					return fmt.Errorf("%w: key '%s' does not contain 4 identifiers", ErrWrongInventoryKey, key)
-				}
-				file, err := os.Open(path)
-				if err != nil {
-					return err
-				}
-				defer file.Close()
-				unstr := map[string]interface{}{}
-				if err := json.NewDecoder(file).Decode(&unstr); err != nil {
-					return err
-				}
-				kind, found := unstr["kind"].(string)
-				if !found {
-					return fmt.Errorf("%w: %s not found in inventory item %s", ErrManifestFieldNotFound, "kind", key)
-				}
-				apiVersion, found := unstr["apiVersion"].(string)
-				if !found {
-					return fmt.Errorf("%w: %s not found in inventory item %s", ErrManifestFieldNotFound, "apiVersion", key)
-				}
-				items[key] = &ManifestItem{
-					TypeMeta: v1.TypeMeta{
-						Kind:       kind,
-						APIVersion: apiVersion,
-					},
-					Name:      name,
-					Namespace: namespace,
-					ID:        key,
-				}
+		name := identifier[0]
+		namespace := identifier[1]
+		if len(identifier) == 3 {
+			kind := identifier[2]
+			if kind != "HelmRelease" {
+				return nil, fmt.Errorf(
+					"%w: key with only 3 identifiers is expected to be a HelmRelease",
+					ErrWrongInventoryKey,
+				)
+			}
+			items[key] = &HelmReleaseItem{
+				Name:      name,
+				Namespace: namespace,
+				ID:        key,
 			}
+			continue
 		}
-		return nil
-	})
-	if err != nil {
-		return nil, err
+		if len(identifier) != 4 {
+			return nil, fmt.Errorf("%w: key '%s' does not contain 4 identifiers", ErrWrongInventoryKey, key)
+		}
+		item, err := instance.loadManifest(backend, key, name, namespace)
+		if err != nil {
+			return nil, err
+		}
+		items[key] = item
 	}
 	return &Storage{
 		items: items,
 	}, nil
 }
 
-// GetItem opens the item file for reading.
-// If there is an error, it will be of type *PathError.
+func (instance *Instance) loadManifest(backend Backend, key string, name string, namespace string) (*ManifestItem, error) {
+	stored, err := backend.Read(&ManifestItem{Name: name, Namespace: namespace, ID: key})
+	if err != nil {
+		return nil, err
+	}
+	content, err := instance.open(stored)
+	if err != nil {
+		return nil, err
+	}
+	defer content.Close()
+	unstr := map[string]interface{}{}
+	if err := json.NewDecoder(content).Decode(&unstr); err != nil {
+		return nil, err
+	}
+	kind, found := unstr["kind"].(string)
+	if !found {
+		return nil, fmt.Errorf("%w: %s not found in inventory item %s", ErrManifestFieldNotFound, "kind", key)
+	}
+	apiVersion, found := unstr["apiVersion"].(string)
+	if !found {
+		return nil, fmt.Errorf("%w: %s not found in inventory item %s", ErrManifestFieldNotFound, "apiVersion", key)
+	}
+	// Objects created with metadata.generateName are identified by the prefix,
+	// but live under the name generated by the API server.
+	if metadata, ok := unstr["metadata"].(map[string]interface{}); ok {
+		if generated, ok := metadata["name"].(string); ok && generated != "" {
+			name = generated
+		}
+	}
+	return &ManifestItem{
+		TypeMeta: v1.TypeMeta{
+			Kind:       kind,
+			APIVersion: apiVersion,
+		},
+		Name:      name,
+		Namespace: namespace,
+		ID:        key,
+	}, nil
+}
+
+// GetItem opens the stored item for reading and decrypts it, if it is encrypted.
+// If the item is not stored, the error satisfies errors.Is(err, fs.ErrNotExist).
 func (instance Instance) GetItem(item Item) (io.ReadCloser, error) {
-	itemFile, err := os.Open(filepath.Join(instance.Path, itemNs(item), item.GetID()))
+	stored, err := instance.backend().Read(item)
 	if err != nil {
 		return nil, err
 	}
-	return itemFile, nil
+	return instance.open(stored)
 }
 
 // StoreItem persists given item with optional content in the inventory.
 func (instance Instance) StoreItem(item Item, contentReader io.Reader) error {
-	dir := filepath.Join(instance.Path, itemNs(item))
-	if err := os.MkdirAll(dir, 0700); err != nil {
-		return err
-	}
-	file, err := os.Create(filepath.Join(dir, item.GetID()))
-	if err != nil {
-		return err
-	}
-	defer file.Close()
-	if contentReader != nil {
-		bufferedReadWriter := bufio.NewReadWriter(
-			bufio.NewReader(contentReader),
-			bufio.NewWriter(file),
-		)
-		for {
-			line, err := bufferedReadWriter.ReadString('\n')
-			if err == io.EOF {
-				break
-			}
-			_, err = bufferedReadWriter.WriteString(line)
-			if err != nil {
-				return err
-			}
+	if contentReader != nil && instance.EncryptionKey != nil {
+		plaintext, err := io.ReadAll(contentReader)
+		if err != nil {
+			return err
 		}
-		if err = bufferedReadWriter.Flush(); err != nil {
+		sealed, err := instance.seal(plaintext)
+		if err != nil {
 			return err
 		}
+		contentReader = bytes.NewReader(sealed)
 	}
-	return nil
+	return instance.backend().Write(item, contentReader)
 }
 
 // DeleteItem removes the item from the inventory.
 // Declcd will not be tracking its current state anymore.
 func (instance Instance) DeleteItem(item Item) error {
-	dir := filepath.Join(instance.Path, itemNs(item))
-	dirFile, err := os.Open(dir)
-	if err != nil {
-		return err
-	}
-	defer dirFile.Close()
-	_, err = dirFile.Readdirnames(1)
-	if err == io.EOF {
-		if err := os.Remove(dir); err != nil {
-			return err
-		}
-	}
-	if err != nil {
-		return err
-	}
-	return os.Remove(filepath.Join(dir, item.GetID()))
+	return instance.backend().Delete(item)
+}
+
+// Clear removes all items and the inventory itself, e.g. when its project is deleted.
+func (instance Instance) Clear() error {
+	return instance.backend().Clear()
 }
 
 func itemNs(item Item) string {
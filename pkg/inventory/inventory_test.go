@@ -16,8 +16,12 @@ package inventory_test
 
 import (
 	"bytes"
+	"encoding/base64"
 	"encoding/json"
+	"io"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -35,6 +39,7 @@ func TestManager_Load(t *testing.T) {
 	testCases := []struct {
 		name  string
 		items []inventory.Item
+		kinds map[string]int
 	}{
 		{
 			name: "Mixed",
@@ -54,6 +59,27 @@ func TestManager_Load(t *testing.T) {
 					ID:        "test_test_HelmRelease",
 				},
 			},
+			kinds: map[string]int{
+				"Namespace":   1,
+				"HelmRelease": 1,
+			},
+		},
+		{
+			name: "GeneratedName",
+			items: []inventory.Item{
+				&inventory.ManifestItem{
+					TypeMeta: metav1.TypeMeta{
+						Kind:       "Job",
+						APIVersion: "batch/v1",
+					},
+					Name:      "migration-x7k2p",
+					Namespace: "test",
+					ID:        "migration-_test_batch_Job",
+				},
+			},
+			kinds: map[string]int{
+				"Job": 1,
+			},
 		},
 	}
 	for _, tc := range testCases {
@@ -89,7 +115,91 @@ func TestManager_Load(t *testing.T) {
 			assert.NilError(t, err)
 			for _, item := range tc.items {
 				assert.Assert(t, storage.HasItem(item))
+				assert.Equal(t, storage.Items()[item.GetID()].GetName(), item.GetName())
 			}
+			assert.DeepEqual(t, storage.CountByKind(), tc.kinds)
 		})
 	}
 }
+
+func TestInstance_Encryption(t *testing.T) {
+	path := t.TempDir()
+	keyPath := filepath.Join(t.TempDir(), "key")
+	err := os.WriteFile(keyPath, []byte(base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{1}, 32))+"\n"), 0600)
+	assert.NilError(t, err)
+	key, err := inventory.ReadEncryptionKey(keyPath)
+	assert.NilError(t, err)
+
+	encrypted := inventory.Instance{
+		Path:          path,
+		EncryptionKey: key,
+	}
+	plain := inventory.Instance{
+		Path: path,
+	}
+
+	secret := &inventory.ManifestItem{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Secret",
+			APIVersion: "v1",
+		},
+		Name:      "secret",
+		Namespace: "test",
+		ID:        "secret_test__Secret",
+	}
+	content := `{"apiVersion":"v1","kind":"Secret","metadata":{"name":"secret","namespace":"test"},"stringData":{"password":"confidential"}}`
+	err = encrypted.StoreItem(secret, strings.NewReader(content))
+	assert.NilError(t, err)
+
+	stored, err := os.ReadFile(filepath.Join(path, "test", secret.GetID()))
+	assert.NilError(t, err)
+	assert.Assert(t, !bytes.Contains(stored, []byte("confidential")))
+
+	reader, err := encrypted.GetItem(secret)
+	assert.NilError(t, err)
+	read, err := io.ReadAll(reader)
+	assert.NilError(t, err)
+	assert.NilError(t, reader.Close())
+	assert.Equal(t, string(read), content)
+
+	storage, err := encrypted.Load()
+	assert.NilError(t, err)
+	assert.Assert(t, storage.HasItem(secret))
+
+	_, err = plain.GetItem(secret)
+	assert.ErrorIs(t, err, inventory.ErrEncryptionKeyMissing)
+
+	// Items stored before encryption was enabled stay readable.
+	configMap := &inventory.ManifestItem{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "ConfigMap",
+			APIVersion: "v1",
+		},
+		Name:      "config",
+		Namespace: "test",
+		ID:        "config_test__ConfigMap",
+	}
+	content = `{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"config","namespace":"test"}}`
+	err = plain.StoreItem(configMap, strings.NewReader(content))
+	assert.NilError(t, err)
+	reader, err = encrypted.GetItem(configMap)
+	assert.NilError(t, err)
+	read, err = io.ReadAll(reader)
+	assert.NilError(t, err)
+	assert.NilError(t, reader.Close())
+	assert.Equal(t, string(read), content)
+}
+
+func TestReadEncryptionKey(t *testing.T) {
+	dir := t.TempDir()
+
+	key, err := inventory.ReadEncryptionKey(filepath.Join(dir, "missing"))
+	assert.NilError(t, err)
+	assert.Assert(t, key == nil)
+
+	invalidPath := filepath.Join(dir, "invalid")
+	err = os.WriteFile(invalidPath, []byte(base64.StdEncoding.EncodeToString([]byte("short"))), 0600)
+	assert.NilError(t, err)
+	_, err = inventory.ReadEncryptionKey(invalidPath)
+	assert.ErrorIs(t, err, inventory.ErrInvalidEncryptionKey)
+}
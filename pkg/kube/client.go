@@ -16,10 +16,12 @@ package kube
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"slices"
 	"time"
 
+	"github.com/kharf/declcd/internal/pool"
 	"helm.sh/helm/v3/pkg/action"
 	helmKube "helm.sh/helm/v3/pkg/kube"
 	"k8s.io/cli-runtime/pkg/resource"
@@ -28,7 +30,9 @@ import (
 	"k8s.io/apimachinery/pkg/api/meta"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/discovery/cached/memory"
 	"k8s.io/client-go/dynamic"
@@ -64,8 +68,10 @@ func (c *InMemoryRESTClientGetter) ToRawKubeConfigLoader() clientcmd.ClientConfi
 }
 
 type applyOptions struct {
-	dryRun bool
-	force  bool
+	dryRun          bool
+	force           bool
+	encoded         []byte
+	fieldValidation string
 }
 
 // ApplyOption is a specific configuration used for applying changes to an object.
@@ -87,6 +93,23 @@ func (f Force) Apply(opts *applyOptions) {
 	opts.force = bool(f)
 }
 
+// Encoded provides the JSON encoding of the object, which is sent as is instead of encoding the object again.
+// Callers share it to avoid holding multiple copies of large objects.
+type Encoded []byte
+
+func (e Encoded) Apply(opts *applyOptions) {
+	opts.encoded = []byte(e)
+}
+
+// FieldValidation instructs the API server how to handle unknown or duplicate fields: Ignore, Warn or Strict.
+// Strict rejects the object with the paths of all offending fields.
+// Empty leaves the decision to the API server.
+type FieldValidation string
+
+func (fv FieldValidation) Apply(opts *applyOptions) {
+	opts.fieldValidation = string(fv)
+}
+
 // Client connects to a Kubernetes cluster
 // to create, read, update and delete manifests/objects.
 type Client[T any] interface {
@@ -94,11 +117,19 @@ type Client[T any] interface {
 	// and takes the ownership of this object.
 	// The object is created when it does not exist.
 	// It errors on conflicts if force is set to false.
+	// Objects without a name but with metadata.generateName are created instead,
+	// because Server-Side Apply requires a name, and obj is updated with the generated name.
+	// On a dry run, obj is updated with the object the API server would have persisted.
 	Apply(ctx context.Context, obj *T, fieldManager string, opts ...ApplyOption) error
 	// Get retrieves the unstructured object from a Kubernetes cluster.
 	Get(ctx context.Context, obj *T) (*T, error)
 	// Delete removes the object from the Kubernetes cluster.
 	Delete(ctx context.Context, obj *T) error
+	// List retrieves all objects of a kind matching the label selector.
+	// An empty namespace lists the objects of all namespaces.
+	List(ctx context.Context, gvk schema.GroupVersionKind, namespace string, selector labels.Selector) ([]T, error)
+	// MigrateStorageVersion rewrites all custom resources of a CustomResourceDefinition still stored in previous versions.
+	MigrateStorageVersion(ctx context.Context, crdName string) (int, error)
 	// Returns the [meta.RESTMapper] associated with this client.
 	RESTMapper() meta.RESTMapper
 }
@@ -163,18 +194,39 @@ func (client *DynamicClient) Apply(
 		return err
 	}
 
-	createOptions := v1.ApplyOptions{
-		FieldManager: fieldManager,
-		Force:        applyOptions.force,
+	data := applyOptions.encoded
+	if data == nil {
+		buf := pool.GetBuffer()
+		defer pool.PutBuffer(buf)
+		if err := json.NewEncoder(buf).Encode(obj.Object); err != nil {
+			return err
+		}
+		data = buf.Bytes()
 	}
 
-	if applyOptions.dryRun {
-		createOptions.DryRun = []string{"All"}
-	}
+	if obj.GetName() == "" && obj.GetGenerateName() != "" {
+		if err := client.create(ctx, obj, fieldManager, applyOptions, resourceInterface); err != nil {
+			return err
+		}
+	} else {
+		patchOptions := v1.PatchOptions{
+			FieldManager:    fieldManager,
+			Force:           &applyOptions.force,
+			FieldValidation: applyOptions.fieldValidation,
+		}
 
-	_, err = resourceInterface.Apply(ctx, obj.GetName(), obj, createOptions)
-	if err != nil {
-		return err
+		if applyOptions.dryRun {
+			patchOptions.DryRun = []string{"All"}
+		}
+
+		patched, err := resourceInterface.Patch(ctx, obj.GetName(), types.ApplyPatchType, data, patchOptions)
+		if err != nil {
+			return err
+		}
+
+		if applyOptions.dryRun {
+			obj.Object = patched.Object
+		}
 	}
 
 	if !applyOptions.dryRun {
@@ -206,6 +258,36 @@ func (client *DynamicClient) Apply(
 	return nil
 }
 
+// create lets the API server generate the name of the object and sets it on obj.
+func (client *DynamicClient) create(
+	ctx context.Context,
+	obj *unstructured.Unstructured,
+	fieldManager string,
+	applyOptions *applyOptions,
+	resourceInterface dynamic.ResourceInterface,
+) error {
+	createOptions := v1.CreateOptions{
+		FieldManager:    fieldManager,
+		FieldValidation: applyOptions.fieldValidation,
+	}
+
+	if applyOptions.dryRun {
+		createOptions.DryRun = []string{"All"}
+	}
+
+	created, err := resourceInterface.Create(ctx, obj, createOptions)
+	if err != nil {
+		return err
+	}
+
+	if applyOptions.dryRun {
+		obj.Object = created.Object
+		return nil
+	}
+	obj.SetName(created.GetName())
+	return nil
+}
+
 func (client *DynamicClient) wait(
 	ctx context.Context,
 	name string,
@@ -294,17 +376,67 @@ func (client *DynamicClient) Delete(ctx context.Context, obj *unstructured.Unstr
 	if err != nil {
 		return err
 	}
+	// Jobs orphan their pods by default, so dependents are explicitly removed in the background.
+	propagationPolicy := v1.DeletePropagationBackground
 	if err := resourceInterface.Delete(ctx, obj.GetName(), v1.DeleteOptions{
 		TypeMeta: v1.TypeMeta{
 			Kind:       obj.GetKind(),
 			APIVersion: obj.GetAPIVersion(),
 		},
+		PropagationPolicy: &propagationPolicy,
 	}); err != nil {
 		return err
 	}
 	return nil
 }
 
+// ReleaseOwnership removes the managed fields of the field manager from the live object without deleting it,
+// so that another manager can take the fields over without conflicts.
+// Following fields have to be set on obj:
+// - GVK, Namespace, Name
+func (client *DynamicClient) ReleaseOwnership(
+	ctx context.Context,
+	obj *unstructured.Unstructured,
+	fieldManager string,
+) error {
+	resourceInterface, err := client.resourceInterface(obj.GroupVersionKind(), obj.GetNamespace())
+	if err != nil {
+		return err
+	}
+
+	live, err := resourceInterface.Get(ctx, obj.GetName(), v1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	liveManagedFields := live.GetManagedFields()
+	managedFields := make([]v1.ManagedFieldsEntry, 0, len(liveManagedFields))
+	for _, entry := range liveManagedFields {
+		if entry.Manager != fieldManager {
+			managedFields = append(managedFields, entry)
+		}
+	}
+	if len(managedFields) == len(liveManagedFields) {
+		return nil
+	}
+	if len(managedFields) == 0 {
+		// An empty list is ignored by the api server, a single empty entry resets the managed fields.
+		managedFields = append(managedFields, v1.ManagedFieldsEntry{})
+	}
+
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"managedFields": managedFields,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = resourceInterface.Patch(ctx, obj.GetName(), types.MergePatchType, patch, v1.PatchOptions{})
+	return err
+}
+
 var (
 	ErrManifestNoMetadata = errors.New("Helm chart manifest has no metadata")
 )
@@ -347,6 +479,27 @@ func (client *DynamicClient) Get(
 	return foundObj, nil
 }
 
+// List retrieves all objects of a kind matching the label selector.
+// An empty namespace lists the objects of all namespaces.
+func (client *DynamicClient) List(
+	ctx context.Context,
+	gvk schema.GroupVersionKind,
+	namespace string,
+	selector labels.Selector,
+) ([]unstructured.Unstructured, error) {
+	resourceInterface, err := client.resourceInterface(gvk, namespace)
+	if err != nil {
+		return nil, err
+	}
+	list, err := resourceInterface.List(ctx, v1.ListOptions{
+		LabelSelector: selector.String(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
+
 func (client *DynamicClient) RESTMapper() meta.RESTMapper {
 	return client.restMapper
 }
@@ -373,6 +526,8 @@ type HelmClient struct {
 	*helmKube.Client
 	DynamicClient Client[unstructured.Unstructured]
 	FieldManager  string
+	// Suppressions exclude fields of release objects from being applied.
+	Suppressions SuppressionRules
 }
 
 var _ helmKube.Interface = (*HelmClient)(nil)
@@ -383,10 +538,12 @@ var ErrObjectNotUnstructured = errors.New("Helm object is not of type unstructur
 func (c *HelmClient) Create(resources helmKube.ResourceList) (*helmKube.Result, error) {
 	ctx := context.Background()
 	for _, info := range resources {
-		if _, ok := info.Object.(*unstructured.Unstructured); !ok {
+		obj, ok := info.Object.(*unstructured.Unstructured)
+		if !ok {
 			return nil, ErrObjectNotUnstructured
 		}
-		if err := c.DynamicClient.Apply(ctx, info.Object.(*unstructured.Unstructured), c.FieldManager); err != nil {
+		c.Suppressions.Strip(obj)
+		if err := c.DynamicClient.Apply(ctx, obj, c.FieldManager); err != nil {
 			return nil, err
 		}
 	}
@@ -404,12 +561,14 @@ func (c *HelmClient) Update(
 	ctx := context.Background()
 	res := &helmKube.Result{}
 	err := target.Visit(func(info *resource.Info, err error) error {
-		if _, ok := info.Object.(*unstructured.Unstructured); !ok {
+		obj, ok := info.Object.(*unstructured.Unstructured)
+		if !ok {
 			return ErrObjectNotUnstructured
 		}
 		// Append the created resource to the results, even if something fails
 		res.Created = append(res.Created, info)
-		if err := c.DynamicClient.Apply(ctx, info.Object.(*unstructured.Unstructured), c.FieldManager, Force(true)); err != nil {
+		c.Suppressions.Strip(obj)
+		if err := c.DynamicClient.Apply(ctx, obj, c.FieldManager, Force(true)); err != nil {
 			return err
 		}
 		return nil
@@ -0,0 +1,95 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kube_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kharf/declcd/pkg/kube"
+	"gotest.tools/v3/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/rest"
+)
+
+// newPatchServer serves the discovery of ConfigMaps and records the body of every apply patch.
+func newPatchServer(t *testing.T, patches *[][]byte) *httptest.Server {
+	discovery := map[string]string{
+		"/api":  `{"kind":"APIVersions","versions":["v1"]}`,
+		"/apis": `{"kind":"APIGroupList","apiVersion":"v1","groups":[]}`,
+		"/api/v1": `{"kind":"APIResourceList","groupVersion":"v1","resources":[` +
+			`{"name":"configmaps","singularName":"configmap","namespaced":true,"kind":"ConfigMap","verbs":["get","patch"]}]}`,
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodPatch {
+			body, err := io.ReadAll(r.Body)
+			if err != nil || r.Header.Get("Content-Type") != string(types.ApplyPatchType) {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			*patches = append(*patches, body)
+			_, _ = w.Write(body)
+			return
+		}
+		response, found := discovery[r.URL.Path]
+		if !found {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(response))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestDynamicClient_Apply_Encoded(t *testing.T) {
+	var patches [][]byte
+	server := newPatchServer(t, &patches)
+	client, err := kube.NewDynamicClient(&rest.Config{Host: server.URL})
+	assert.NilError(t, err)
+
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata": map[string]interface{}{
+			"name":      "dashboards",
+			"namespace": "monitoring",
+		},
+		"data": map[string]interface{}{
+			"dashboard.json": "{}",
+		},
+	}}
+	ctx := context.Background()
+
+	// Without an encoding, the client encodes the object itself.
+	assert.NilError(t, client.Apply(ctx, obj, "declcd"))
+	assert.Equal(t, len(patches), 1)
+	var applied map[string]interface{}
+	assert.NilError(t, json.Unmarshal(patches[0], &applied))
+	assert.DeepEqual(t, applied, obj.Object)
+
+	// A provided encoding is sent as is.
+	encoded := []byte(`{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"dashboards","namespace":"monitoring"},"data":{"dashboard.json":"{\"panels\":[]}"}}`)
+	assert.NilError(t, client.Apply(ctx, obj, "declcd", kube.Encoded(encoded)))
+	assert.Equal(t, len(patches), 2)
+	assert.Equal(t, string(patches[1]), string(encoded))
+}
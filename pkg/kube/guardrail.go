@@ -0,0 +1,79 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kube
+
+import (
+	"errors"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+var (
+	ErrObjectTooLarge = errors.New("Object exceeds maximum size")
+	ErrKindDenied     = errors.New("Object kind denied")
+)
+
+// DeniedKind matches objects, which must not be applied.
+type DeniedKind struct {
+	// APIGroup of matching objects. Empty matches every group.
+	APIGroup string
+
+	Kind string
+}
+
+func (denied DeniedKind) matches(obj *unstructured.Unstructured) bool {
+	gvk := obj.GroupVersionKind()
+	if denied.APIGroup != "" && denied.APIGroup != gvk.Group {
+		return false
+	}
+	return denied.Kind == gvk.Kind
+}
+
+// Guardrails refuse objects before they are applied,
+// like ConfigMaps above the etcd request limit or cluster wide kinds tenants must not manage.
+type Guardrails struct {
+	// MaxObjectSize is the maximum size of an encoded object in bytes. Zero disables the limit.
+	MaxObjectSize int
+
+	DeniedKinds []DeniedKind
+}
+
+// Check returns an error, if the object with the given encoded size violates a guardrail.
+func (guardrails Guardrails) Check(obj *unstructured.Unstructured, size int) error {
+	for _, denied := range guardrails.DeniedKinds {
+		if denied.matches(obj) {
+			return fmt.Errorf(
+				"%w: %s %s/%s",
+				ErrKindDenied,
+				obj.GroupVersionKind().GroupKind(),
+				obj.GetNamespace(),
+				obj.GetName(),
+			)
+		}
+	}
+	if guardrails.MaxObjectSize > 0 && size > guardrails.MaxObjectSize {
+		return fmt.Errorf(
+			"%w: %s %s/%s has %d bytes, limit is %d bytes",
+			ErrObjectTooLarge,
+			obj.GetKind(),
+			obj.GetNamespace(),
+			obj.GetName(),
+			size,
+			guardrails.MaxObjectSize,
+		)
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kube_test
+
+import (
+	"testing"
+
+	"github.com/kharf/declcd/pkg/kube"
+	"gotest.tools/v3/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestGuardrails_Check(t *testing.T) {
+	guardrails := kube.Guardrails{
+		MaxObjectSize: 1024,
+		DeniedKinds: []kube.DeniedKind{
+			{Kind: "Node"},
+			{APIGroup: "scheduling.k8s.io", Kind: "PriorityClass"},
+		},
+	}
+
+	testCases := []struct {
+		name     string
+		object   map[string]interface{}
+		size     int
+		expected error
+	}{
+		{
+			name: "Allowed",
+			object: map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "ConfigMap",
+			},
+			size:     1024,
+			expected: nil,
+		},
+		{
+			name: "TooLarge",
+			object: map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "ConfigMap",
+			},
+			size:     1025,
+			expected: kube.ErrObjectTooLarge,
+		},
+		{
+			name: "DeniedKind",
+			object: map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "Node",
+			},
+			size:     1,
+			expected: kube.ErrKindDenied,
+		},
+		{
+			name: "DeniedGroupKind",
+			object: map[string]interface{}{
+				"apiVersion": "scheduling.k8s.io/v1",
+				"kind":       "PriorityClass",
+			},
+			size:     1,
+			expected: kube.ErrKindDenied,
+		},
+		{
+			name: "OtherGroup",
+			object: map[string]interface{}{
+				"apiVersion": "example.com/v1",
+				"kind":       "PriorityClass",
+			},
+			size:     1,
+			expected: nil,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := guardrails.Check(&unstructured.Unstructured{Object: tc.object}, tc.size)
+			if tc.expected == nil {
+				assert.NilError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, tc.expected)
+		})
+	}
+}
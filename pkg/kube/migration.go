@@ -0,0 +1,164 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kube
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+var (
+	ErrNoStorageVersion = errors.New("CustomResourceDefinition has no storage version")
+)
+
+const (
+	// StorageMigrationAnnotation holds the list continuation of an unfinished storage version migration,
+	// so that an interrupted migration resumes where it stopped.
+	StorageMigrationAnnotation = "declcd/storage-migration"
+
+	storageMigrationPageSize = 500
+)
+
+var crdResource = schema.GroupVersionResource{
+	Group:    apiextensionsv1.SchemeGroupVersion.Group,
+	Version:  apiextensionsv1.SchemeGroupVersion.Version,
+	Resource: "customresourcedefinitions",
+}
+
+// MigrateStorageVersion rewrites all custom resources of a CustomResourceDefinition,
+// which still has objects stored in versions other than its storage version,
+// and drops the migrated versions from its status.storedVersions afterwards.
+// Rewriting an unchanged object lets the API server persist it in the current storage version.
+// The progress is tracked on the CustomResourceDefinition with the [StorageMigrationAnnotation].
+// An expired or malformed continuation restarts the migration from the first page.
+// It returns the number of rewritten objects.
+func (client *DynamicClient) MigrateStorageVersion(ctx context.Context, crdName string) (int, error) {
+	crdInterface := client.dynamicClient.Resource(crdResource)
+	unstr, err := crdInterface.Get(ctx, crdName, v1.GetOptions{})
+	if err != nil {
+		return 0, err
+	}
+	var crd apiextensionsv1.CustomResourceDefinition
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(unstr.Object, &crd); err != nil {
+		return 0, err
+	}
+
+	storageVersion := ""
+	for _, version := range crd.Spec.Versions {
+		if version.Storage {
+			storageVersion = version.Name
+		}
+	}
+	if storageVersion == "" {
+		return 0, fmt.Errorf("%w: %s", ErrNoStorageVersion, crdName)
+	}
+	if len(crd.Status.StoredVersions) == 1 && crd.Status.StoredVersions[0] == storageVersion {
+		return 0, nil
+	}
+
+	resourceInterface := client.dynamicClient.Resource(schema.GroupVersionResource{
+		Group:    crd.Spec.Group,
+		Version:  storageVersion,
+		Resource: crd.Spec.Names.Plural,
+	})
+
+	migrated := 0
+	continuation := crd.Annotations[StorageMigrationAnnotation]
+	for {
+		list, err := resourceInterface.List(ctx, v1.ListOptions{
+			Limit:    storageMigrationPageSize,
+			Continue: continuation,
+		})
+		if err != nil {
+			if continuation != "" && isInvalidContinuation(err) {
+				// The stored continuation is unusable, so the migration starts over from the first page
+				// instead of failing on every reconciliation.
+				continuation = ""
+				if err := client.trackStorageMigration(ctx, crdName, continuation); err != nil {
+					return migrated, err
+				}
+				continue
+			}
+			return migrated, err
+		}
+
+		for _, item := range list.Items {
+			if _, err := resourceInterface.Namespace(item.GetNamespace()).Update(ctx, &item, v1.UpdateOptions{}); err != nil {
+				// conflicting and deleted objects have been written by someone else in the meantime.
+				if k8sErrors.IsConflict(err) || k8sErrors.IsNotFound(err) {
+					continue
+				}
+				return migrated, err
+			}
+			migrated++
+		}
+
+		continuation = list.GetContinue()
+		if err := client.trackStorageMigration(ctx, crdName, continuation); err != nil {
+			return migrated, err
+		}
+		if continuation == "" {
+			break
+		}
+	}
+
+	patch, err := json.Marshal(map[string]interface{}{
+		"status": map[string]interface{}{
+			"storedVersions": []string{storageVersion},
+		},
+	})
+	if err != nil {
+		return migrated, err
+	}
+	if _, err := crdInterface.Patch(ctx, crdName, types.MergePatchType, patch, v1.PatchOptions{}, "status"); err != nil {
+		return migrated, err
+	}
+	return migrated, nil
+}
+
+// isInvalidContinuation reports whether a list failed because of its continuation,
+// which has expired with 410 Gone after an etcd compaction or is malformed.
+func isInvalidContinuation(err error) bool {
+	return k8sErrors.IsResourceExpired(err) || k8sErrors.IsGone(err) || k8sErrors.IsBadRequest(err)
+}
+
+// trackStorageMigration stores the list continuation of a running migration or removes it, when the migration is done.
+func (client *DynamicClient) trackStorageMigration(ctx context.Context, crdName string, continuation string) error {
+	var annotation interface{}
+	if continuation != "" {
+		annotation = continuation
+	}
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]interface{}{
+				StorageMigrationAnnotation: annotation,
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = client.dynamicClient.Resource(crdResource).Patch(ctx, crdName, types.MergePatchType, patch, v1.PatchOptions{})
+	return err
+}
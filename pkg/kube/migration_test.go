@@ -0,0 +1,148 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kube_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/kharf/declcd/internal/kubetest"
+	"github.com/kharf/declcd/pkg/kube"
+	"gotest.tools/v3/assert"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const widgetCRDName = "widgets.migration.declcd.io"
+
+func widgetCRD(storageVersion string) *unstructured.Unstructured {
+	versions := make([]interface{}, 0, 2)
+	for _, version := range []string{"v1alpha1", "v1"} {
+		versions = append(versions, map[string]interface{}{
+			"name":    version,
+			"served":  true,
+			"storage": version == storageVersion,
+			"schema": map[string]interface{}{
+				"openAPIV3Schema": map[string]interface{}{
+					"type":                                 "object",
+					"x-kubernetes-preserve-unknown-fields": true,
+				},
+			},
+		})
+	}
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apiextensions.k8s.io/v1",
+		"kind":       "CustomResourceDefinition",
+		"metadata": map[string]interface{}{
+			"name": widgetCRDName,
+		},
+		"spec": map[string]interface{}{
+			"group": "migration.declcd.io",
+			"names": map[string]interface{}{
+				"kind":     "Widget",
+				"listKind": "WidgetList",
+				"plural":   "widgets",
+				"singular": "widget",
+			},
+			"scope":    "Namespaced",
+			"versions": versions,
+		},
+	}}
+}
+
+func TestDynamicClient_MigrateStorageVersion(t *testing.T) {
+	env := kubetest.StartKubetestEnv(t, logr.Discard())
+	defer env.Stop()
+	ctx := env.Ctx
+	dynamicClient := env.DynamicTestKubeClient
+	testClient := env.TestKubeClient
+
+	assert.NilError(t, dynamicClient.Apply(ctx, widgetCRD("v1alpha1"), "test", kube.Force(true)))
+	widgets := 5
+	for i := 0; i < widgets; i++ {
+		widget := &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "migration.declcd.io/v1alpha1",
+			"kind":       "Widget",
+			"metadata": map[string]interface{}{
+				"name":      fmt.Sprintf("widget-%d", i),
+				"namespace": "test",
+			},
+		}}
+		assert.NilError(t, dynamicClient.Apply(ctx, widget, "test", kube.Force(true)))
+	}
+	assert.NilError(t, dynamicClient.Apply(ctx, widgetCRD("v1"), "test", kube.Force(true)))
+
+	readCRD := func() *unstructured.Unstructured {
+		crd := &unstructured.Unstructured{}
+		crd.SetAPIVersion("apiextensions.k8s.io/v1")
+		crd.SetKind("CustomResourceDefinition")
+		assert.NilError(t, testClient.Get(ctx, types.NamespacedName{Name: widgetCRDName}, crd))
+		return crd
+	}
+	storedVersions := func() []string {
+		versions, _, err := unstructured.NestedStringSlice(readCRD().Object, "status", "storedVersions")
+		assert.NilError(t, err)
+		return versions
+	}
+	track := func(continuation string) {
+		patch := fmt.Sprintf(`{"metadata":{"annotations":{%q:%q}}}`, kube.StorageMigrationAnnotation, continuation)
+		assert.NilError(t, testClient.Patch(ctx, readCRD(), client.RawPatch(types.MergePatchType, []byte(patch))))
+	}
+	storeVersions := func() {
+		patch := `{"status":{"storedVersions":["v1alpha1","v1"]}}`
+		assert.NilError(t, testClient.Status().Patch(ctx, readCRD(), client.RawPatch(types.MergePatchType, []byte(patch))))
+	}
+	assert.DeepEqual(t, storedVersions(), []string{"v1alpha1", "v1"})
+
+	// An interrupted migration resumes with the page after the tracked continuation.
+	firstPage := &unstructured.UnstructuredList{}
+	firstPage.SetAPIVersion("migration.declcd.io/v1")
+	firstPage.SetKind("WidgetList")
+	assert.NilError(t, testClient.List(ctx, firstPage, client.Limit(2)))
+	assert.Assert(t, firstPage.GetContinue() != "")
+	track(firstPage.GetContinue())
+
+	migrated, err := dynamicClient.MigrateStorageVersion(ctx, widgetCRDName)
+	assert.NilError(t, err)
+	assert.Equal(t, migrated, widgets-2)
+	assert.DeepEqual(t, storedVersions(), []string{"v1"})
+	_, tracked := readCRD().GetAnnotations()[kube.StorageMigrationAnnotation]
+	assert.Assert(t, !tracked)
+
+	// Migrated CustomResourceDefinitions are left untouched.
+	migrated, err = dynamicClient.MigrateStorageVersion(ctx, widgetCRDName)
+	assert.NilError(t, err)
+	assert.Equal(t, migrated, 0)
+
+	// An unusable continuation restarts the migration from the first page.
+	storeVersions()
+	track("invalid")
+	migrated, err = dynamicClient.MigrateStorageVersion(ctx, widgetCRDName)
+	assert.NilError(t, err)
+	assert.Equal(t, migrated, widgets)
+	assert.DeepEqual(t, storedVersions(), []string{"v1"})
+	_, tracked = readCRD().GetAnnotations()[kube.StorageMigrationAnnotation]
+	assert.Assert(t, !tracked)
+}
+
+func TestDynamicClient_MigrateStorageVersion_NotFound(t *testing.T) {
+	env := kubetest.StartKubetestEnv(t, logr.Discard())
+	defer env.Stop()
+	_, err := env.DynamicTestKubeClient.MigrateStorageVersion(env.Ctx, "unknown.declcd.io")
+	assert.Assert(t, k8sErrors.IsNotFound(err))
+}
@@ -0,0 +1,99 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kube
+
+import (
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// SuppressionRule excludes fields of matching objects from being applied,
+// so that they stay owned by the controllers legitimately managing them,
+// like replicas scaled by a HorizontalPodAutoscaler or a caBundle injected by cert-manager.
+type SuppressionRule struct {
+	// APIGroup of matching objects. Empty matches every group.
+	APIGroup string
+
+	// Kind of matching objects. Empty matches every kind.
+	Kind string
+
+	// Paths are dot separated field paths, e.g. "spec.replicas".
+	// A "*" segment matches every key of an object or every element of a list,
+	// e.g. "webhooks.*.clientConfig.caBundle".
+	Paths []string
+}
+
+func (rule SuppressionRule) matches(obj *unstructured.Unstructured) bool {
+	gvk := obj.GroupVersionKind()
+	if rule.APIGroup != "" && rule.APIGroup != gvk.Group {
+		return false
+	}
+	if rule.Kind != "" && rule.Kind != gvk.Kind {
+		return false
+	}
+	return true
+}
+
+// SuppressionRules are evaluated against every object declcd applies.
+type SuppressionRules []SuppressionRule
+
+// Strip removes all suppressed fields from the object.
+// Fields are only removed from the desired state; Server-Side Apply then leaves them to their other managers.
+func (rules SuppressionRules) Strip(obj *unstructured.Unstructured) {
+	for _, rule := range rules {
+		if !rule.matches(obj) {
+			continue
+		}
+		for _, path := range rule.Paths {
+			removePath(obj.Object, strings.Split(path, "."))
+		}
+	}
+}
+
+func removePath(field interface{}, segments []string) {
+	if len(segments) == 0 {
+		return
+	}
+	segment, rest := segments[0], segments[1:]
+	switch field := field.(type) {
+	case map[string]interface{}:
+		if segment == "*" {
+			for key, child := range field {
+				if len(rest) == 0 {
+					delete(field, key)
+				} else {
+					removePath(child, rest)
+				}
+			}
+			return
+		}
+		if len(rest) == 0 {
+			delete(field, segment)
+			return
+		}
+		if child, found := field[segment]; found {
+			removePath(child, rest)
+		}
+	case []interface{}:
+		// list elements themselves are never removed, as this would change the meaning of the list.
+		if segment != "*" || len(rest) == 0 {
+			return
+		}
+		for _, child := range field {
+			removePath(child, rest)
+		}
+	}
+}
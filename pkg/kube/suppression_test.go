@@ -0,0 +1,125 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kube_test
+
+import (
+	"testing"
+
+	"github.com/kharf/declcd/pkg/kube"
+	"gotest.tools/v3/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestSuppressionRules_Strip(t *testing.T) {
+	testCases := []struct {
+		name     string
+		rules    kube.SuppressionRules
+		object   map[string]interface{}
+		expected map[string]interface{}
+	}{
+		{
+			name: "Field",
+			rules: kube.SuppressionRules{
+				{
+					APIGroup: "apps",
+					Kind:     "Deployment",
+					Paths:    []string{"spec.replicas"},
+				},
+			},
+			object: map[string]interface{}{
+				"apiVersion": "apps/v1",
+				"kind":       "Deployment",
+				"spec": map[string]interface{}{
+					"replicas": 3,
+					"paused":   false,
+				},
+			},
+			expected: map[string]interface{}{
+				"apiVersion": "apps/v1",
+				"kind":       "Deployment",
+				"spec": map[string]interface{}{
+					"paused": false,
+				},
+			},
+		},
+		{
+			name: "Wildcard",
+			rules: kube.SuppressionRules{
+				{
+					Kind:  "ValidatingWebhookConfiguration",
+					Paths: []string{"webhooks.*.clientConfig.caBundle"},
+				},
+			},
+			object: map[string]interface{}{
+				"apiVersion": "admissionregistration.k8s.io/v1",
+				"kind":       "ValidatingWebhookConfiguration",
+				"webhooks": []interface{}{
+					map[string]interface{}{
+						"name": "a",
+						"clientConfig": map[string]interface{}{
+							"caBundle": "abc",
+						},
+					},
+					map[string]interface{}{
+						"name": "b",
+					},
+				},
+			},
+			expected: map[string]interface{}{
+				"apiVersion": "admissionregistration.k8s.io/v1",
+				"kind":       "ValidatingWebhookConfiguration",
+				"webhooks": []interface{}{
+					map[string]interface{}{
+						"name":         "a",
+						"clientConfig": map[string]interface{}{},
+					},
+					map[string]interface{}{
+						"name": "b",
+					},
+				},
+			},
+		},
+		{
+			name: "NoMatch",
+			rules: kube.SuppressionRules{
+				{
+					APIGroup: "apps",
+					Paths:    []string{"spec.replicas"},
+				},
+			},
+			object: map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "ReplicationController",
+				"spec": map[string]interface{}{
+					"replicas": 3,
+				},
+			},
+			expected: map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "ReplicationController",
+				"spec": map[string]interface{}{
+					"replicas": 3,
+				},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			obj := &unstructured.Unstructured{Object: tc.object}
+			tc.rules.Strip(obj)
+			assert.DeepEqual(t, obj.Object, tc.expected)
+		})
+	}
+}
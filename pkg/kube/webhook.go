@@ -0,0 +1,45 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kube
+
+import (
+	"regexp"
+
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// webhookCallPattern matches the API server message of an admission webhook, which could not be called through its Service,
+// e.g. failed calling webhook "webhook.cert-manager.io": failed to call webhook: Post "https://cert-manager-webhook.cert-manager.svc:443/validate?timeout=10s": dial tcp 10.96.0.1:443: connect: connection refused
+var webhookCallPattern = regexp.MustCompile(
+	`failed calling webhook "[^"]*": failed to call webhook: \w+ "https://([a-z0-9]([-a-z0-9]*[a-z0-9])?)\.([a-z0-9]([-a-z0-9]*[a-z0-9])?)\.svc[.:/"]`,
+)
+
+// UnavailableWebhookService returns the Service of the admission webhook, which rejected a request,
+// because it could not be reached, e.g. when its Pods are not ready yet.
+// Webhooks denying a request or configured with a url instead of a Service are not reported.
+func UnavailableWebhookService(err error) (types.NamespacedName, bool) {
+	if err == nil || !k8sErrors.IsInternalError(err) {
+		return types.NamespacedName{}, false
+	}
+	matches := webhookCallPattern.FindStringSubmatch(err.Error())
+	if matches == nil {
+		return types.NamespacedName{}, false
+	}
+	return types.NamespacedName{
+		Name:      matches[1],
+		Namespace: matches[3],
+	}, true
+}
@@ -0,0 +1,81 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kube_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/kharf/declcd/pkg/kube"
+	"gotest.tools/v3/assert"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestUnavailableWebhookService(t *testing.T) {
+	testCases := []struct {
+		name     string
+		err      error
+		expected types.NamespacedName
+		found    bool
+	}{
+		{
+			name: "ConnectionRefused",
+			err: k8sErrors.NewInternalError(errors.New(
+				`failed calling webhook "webhook.cert-manager.io": failed to call webhook: Post "https://cert-manager-webhook.cert-manager.svc:443/validate?timeout=10s": dial tcp 10.96.0.1:443: connect: connection refused`,
+			)),
+			expected: types.NamespacedName{Name: "cert-manager-webhook", Namespace: "cert-manager"},
+			found:    true,
+		},
+		{
+			name: "NoEndpoints",
+			err: fmt.Errorf("apply: %w", k8sErrors.NewInternalError(errors.New(
+				`failed calling webhook "validate.nginx.ingress.kubernetes.io": failed to call webhook: Post "https://ingress-nginx-controller-admission.ingress-nginx.svc/networking/v1/ingresses?timeout=10s": no endpoints available for service "ingress-nginx-controller-admission"`,
+			))),
+			expected: types.NamespacedName{Name: "ingress-nginx-controller-admission", Namespace: "ingress-nginx"},
+			found:    true,
+		},
+		{
+			name: "Denied",
+			err: k8sErrors.NewForbidden(
+				schema.GroupResource{Group: "cert-manager.io", Resource: "certificates"},
+				"test",
+				errors.New(`admission webhook "webhook.cert-manager.io" denied the request`),
+			),
+			found: false,
+		},
+		{
+			name: "URLWebhook",
+			err: k8sErrors.NewInternalError(errors.New(
+				`failed calling webhook "webhook.example.com": failed to call webhook: Post "https://example.com/validate": dial tcp: lookup example.com: no such host`,
+			)),
+			found: false,
+		},
+		{
+			name:  "Nil",
+			err:   nil,
+			found: false,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			service, found := kube.UnavailableWebhookService(tc.err)
+			assert.Equal(t, found, tc.found)
+			assert.Equal(t, service, tc.expected)
+		})
+	}
+}
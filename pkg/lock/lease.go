@@ -0,0 +1,162 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var (
+	// ErrLocked occurs when another controller holds the lock of a project.
+	ErrLocked = errors.New("Project is locked by another controller")
+)
+
+// ProjectLock is a lease based lock in the cluster, which ensures that a project is reconciled by a single controller at a time,
+// even when multiple shards select the same project.
+type ProjectLock struct {
+	Client client.Client
+
+	// Identity of the controller holding the lock.
+	Identity string
+
+	// Duration after which a lock, which has not been renewed, can be taken over.
+	// The lock is renewed in the background while it is held.
+	Duration time.Duration
+}
+
+// Unlock releases a held lock.
+type Unlock func()
+
+// Acquire takes the lock of the project with the given namespace and name.
+// It returns ErrLocked if another controller holds an unexpired lock.
+func (lock *ProjectLock) Acquire(ctx context.Context, namespace string, name string) (Unlock, error) {
+	key := types.NamespacedName{
+		Name:      fmt.Sprintf("declcd-project-%s", name),
+		Namespace: namespace,
+	}
+
+	now := metav1.NewMicroTime(time.Now())
+	durationSeconds := int32(lock.Duration.Seconds())
+
+	var lease coordinationv1.Lease
+	err := lock.Client.Get(ctx, key, &lease)
+	switch {
+	case k8sErrors.IsNotFound(err):
+		lease = coordinationv1.Lease{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      key.Name,
+				Namespace: key.Namespace,
+			},
+			Spec: coordinationv1.LeaseSpec{
+				HolderIdentity:       &lock.Identity,
+				LeaseDurationSeconds: &durationSeconds,
+				AcquireTime:          &now,
+				RenewTime:            &now,
+			},
+		}
+		if err := lock.Client.Create(ctx, &lease); err != nil {
+			if k8sErrors.IsAlreadyExists(err) {
+				return nil, ErrLocked
+			}
+			return nil, err
+		}
+	case err != nil:
+		return nil, err
+	default:
+		if holder := lease.Spec.HolderIdentity; holder != nil && *holder != "" && *holder != lock.Identity &&
+			!isExpired(&lease, now.Time) {
+			return nil, fmt.Errorf("%w: held by %s", ErrLocked, *holder)
+		}
+		lease.Spec.HolderIdentity = &lock.Identity
+		lease.Spec.LeaseDurationSeconds = &durationSeconds
+		lease.Spec.AcquireTime = &now
+		lease.Spec.RenewTime = &now
+		if err := lock.Client.Update(ctx, &lease); err != nil {
+			if k8sErrors.IsConflict(err) {
+				return nil, ErrLocked
+			}
+			return nil, err
+		}
+	}
+
+	renewCtx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		lock.renew(renewCtx, key)
+	}()
+
+	return func() {
+		cancel()
+		<-done
+		lock.release(ctx, key)
+	}, nil
+}
+
+func isExpired(lease *coordinationv1.Lease, now time.Time) bool {
+	if lease.Spec.RenewTime == nil || lease.Spec.LeaseDurationSeconds == nil {
+		return true
+	}
+	expiry := lease.Spec.RenewTime.Add(time.Duration(*lease.Spec.LeaseDurationSeconds) * time.Second)
+	return now.After(expiry)
+}
+
+// renew extends the lease periodically until the context is canceled.
+// Failed renewals are retried on the next tick, the lease only expires if renewing fails for its whole duration.
+func (lock *ProjectLock) renew(ctx context.Context, key types.NamespacedName) {
+	ticker := time.NewTicker(lock.Duration / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			var lease coordinationv1.Lease
+			if err := lock.Client.Get(ctx, key, &lease); err != nil {
+				continue
+			}
+			if lease.Spec.HolderIdentity == nil || *lease.Spec.HolderIdentity != lock.Identity {
+				return
+			}
+			now := metav1.NewMicroTime(time.Now())
+			lease.Spec.RenewTime = &now
+			_ = lock.Client.Update(ctx, &lease)
+		}
+	}
+}
+
+// release frees the lease, so that other controllers don't have to wait for its expiry.
+func (lock *ProjectLock) release(ctx context.Context, key types.NamespacedName) {
+	var lease coordinationv1.Lease
+	if err := lock.Client.Get(ctx, key, &lease); err != nil {
+		return
+	}
+	if lease.Spec.HolderIdentity == nil || *lease.Spec.HolderIdentity != lock.Identity {
+		return
+	}
+	lease.Spec.HolderIdentity = nil
+	lease.Spec.RenewTime = nil
+	lease.Spec.AcquireTime = nil
+	_ = lock.Client.Update(ctx, &lease)
+}
@@ -0,0 +1,95 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lock_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kharf/declcd/pkg/lock"
+	"gotest.tools/v3/assert"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestProjectLock(t *testing.T) {
+	ctx := context.Background()
+	client := fake.NewClientBuilder().Build()
+
+	primary := &lock.ProjectLock{
+		Client:   client,
+		Identity: "project-controller-primary",
+		Duration: time.Minute,
+	}
+	secondary := &lock.ProjectLock{
+		Client:   client,
+		Identity: "project-controller-secondary",
+		Duration: time.Minute,
+	}
+
+	unlock, err := primary.Acquire(ctx, "declcd-system", "test")
+	assert.NilError(t, err)
+
+	_, err = secondary.Acquire(ctx, "declcd-system", "test")
+	assert.ErrorIs(t, err, lock.ErrLocked)
+
+	unlockOther, err := secondary.Acquire(ctx, "declcd-system", "other")
+	assert.NilError(t, err)
+	unlockOther()
+
+	unlock()
+
+	var lease coordinationv1.Lease
+	err = client.Get(ctx, types.NamespacedName{Name: "declcd-project-test", Namespace: "declcd-system"}, &lease)
+	assert.NilError(t, err)
+	assert.Assert(t, lease.Spec.HolderIdentity == nil)
+
+	unlock, err = secondary.Acquire(ctx, "declcd-system", "test")
+	assert.NilError(t, err)
+	unlock()
+}
+
+func TestProjectLock_Expired(t *testing.T) {
+	ctx := context.Background()
+	client := fake.NewClientBuilder().Build()
+
+	holder := "crashed-controller"
+	durationSeconds := int32(60)
+	renewTime := metav1.NewMicroTime(time.Now().Add(-2 * time.Minute))
+	err := client.Create(ctx, &coordinationv1.Lease{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "declcd-project-test",
+			Namespace: "declcd-system",
+		},
+		Spec: coordinationv1.LeaseSpec{
+			HolderIdentity:       &holder,
+			LeaseDurationSeconds: &durationSeconds,
+			RenewTime:            &renewTime,
+		},
+	})
+	assert.NilError(t, err)
+
+	primary := &lock.ProjectLock{
+		Client:   client,
+		Identity: "project-controller-primary",
+		Duration: time.Minute,
+	}
+	unlock, err := primary.Acquire(ctx, "declcd-system", "test")
+	assert.NilError(t, err)
+	unlock()
+}
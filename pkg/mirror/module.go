@@ -0,0 +1,181 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mirror
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"cuelang.org/go/mod/modconfig"
+	"cuelang.org/go/mod/modfile"
+	"cuelang.org/go/mod/modregistry"
+	"cuelang.org/go/mod/module"
+)
+
+var (
+	ErrModuleNotMirrored = errors.New("Module not mirrored")
+)
+
+// NewModuleClient connects to the registries of a CUE_REGISTRY value, e.g. "ghcr.io/kharf" or "registry.internal/cue+insecure".
+// Invalid values are rejected like the cue command does.
+// Credentials are read from the CUE login configuration.
+func NewModuleClient(cueRegistry string) (*modregistry.Client, error) {
+	resolver, err := modconfig.NewResolver(&modconfig.Config{
+		Env:        append(os.Environ(), "CUE_REGISTRY="+cueRegistry),
+		ClientType: "declcd",
+	})
+	if err != nil {
+		return nil, err
+	}
+	return modregistry.NewClientWithResolver(resolver), nil
+}
+
+// MirroredModule is a module version present in the target registry after mirroring.
+type MirroredModule struct {
+	Version module.Version
+
+	// Existing reports whether the module was already present in the target registry and not copied again.
+	Existing bool
+}
+
+// ModuleMirror copies CUE modules together with all of their dependencies from one registry to another,
+// e.g. the declcd schema into an internal registry of an air-gapped environment.
+type ModuleMirror struct {
+	From *modregistry.Client
+	To   *modregistry.Client
+}
+
+// Mirror copies the given module versions and their transitive dependencies as declared in their module files.
+// Modules already present in the target registry are not copied again, but their dependencies are still walked.
+func (mirror ModuleMirror) Mirror(ctx context.Context, versions []module.Version) ([]MirroredModule, error) {
+	mirrored := make([]MirroredModule, 0, len(versions))
+	visited := make(map[module.Version]struct{}, len(versions))
+	queue := append([]module.Version{}, versions...)
+	for len(queue) > 0 {
+		version := queue[0]
+		queue = queue[1:]
+		if _, found := visited[version]; found {
+			continue
+		}
+		visited[version] = struct{}{}
+
+		source, err := mirror.From.GetModule(ctx, version)
+		if err != nil {
+			return mirrored, err
+		}
+		dependencies, err := moduleDependencies(ctx, source)
+		if err != nil {
+			return mirrored, err
+		}
+		queue = append(queue, dependencies...)
+
+		existing, err := mirror.exists(ctx, version)
+		if err != nil {
+			return mirrored, err
+		}
+		if !existing {
+			if err := mirror.copy(ctx, source); err != nil {
+				return mirrored, fmt.Errorf("module %s: %w", version, err)
+			}
+		}
+		mirrored = append(mirrored, MirroredModule{
+			Version:  version,
+			Existing: existing,
+		})
+	}
+	return mirrored, nil
+}
+
+// AllVersions returns every version of the given module path, e.g. "github.com/kharf/declcd/schema@v0",
+// published in the source registry.
+func (mirror ModuleMirror) AllVersions(ctx context.Context, modulePath string) ([]module.Version, error) {
+	tags, err := mirror.From.ModuleVersions(ctx, modulePath)
+	if err != nil {
+		return nil, err
+	}
+	versions := make([]module.Version, 0, len(tags))
+	for _, tag := range tags {
+		version, err := module.NewVersion(modulePath, tag)
+		if err != nil {
+			return nil, err
+		}
+		versions = append(versions, version)
+	}
+	module.Sort(versions)
+	return versions, nil
+}
+
+// Verify resolves every module version through the target registry,
+// proving that a CUE_REGISTRY pointing to it serves all of them.
+func Verify(ctx context.Context, client *modregistry.Client, versions []module.Version) error {
+	var errs []error
+	for _, version := range versions {
+		if _, err := client.GetModule(ctx, version); err != nil {
+			errs = append(errs, fmt.Errorf("%w: %s: %w", ErrModuleNotMirrored, version, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (mirror ModuleMirror) exists(ctx context.Context, version module.Version) (bool, error) {
+	_, err := mirror.To.GetModule(ctx, version)
+	if err == nil {
+		return true, nil
+	}
+	if errors.Is(err, modregistry.ErrNotFound) {
+		return false, nil
+	}
+	return false, err
+}
+
+func (mirror ModuleMirror) copy(ctx context.Context, source *modregistry.Module) error {
+	zipReader, err := source.GetZip(ctx)
+	if err != nil {
+		return err
+	}
+	defer zipReader.Close()
+	content, err := io.ReadAll(zipReader)
+	if err != nil {
+		return err
+	}
+	metadata, err := source.Metadata()
+	if err != nil || (metadata != nil && metadata.VCSCommitTime.IsZero()) {
+		// Modules published without VCS information carry incomplete metadata, which cannot be pushed again.
+		metadata = nil
+	}
+	return mirror.To.PutModuleWithMetadata(
+		ctx,
+		source.Version(),
+		bytes.NewReader(content),
+		int64(len(content)),
+		metadata,
+	)
+}
+
+func moduleDependencies(ctx context.Context, source *modregistry.Module) ([]module.Version, error) {
+	content, err := source.ModuleFile(ctx)
+	if err != nil {
+		return nil, err
+	}
+	moduleFile, err := modfile.Parse(content, source.Version().String()+"/cue.mod/module.cue")
+	if err != nil {
+		return nil, err
+	}
+	return moduleFile.DepVersions(), nil
+}
@@ -0,0 +1,119 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mirror_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"cuelabs.dev/go/oci/ociregistry/ocimem"
+	"cuelabs.dev/go/oci/ociregistry/ociserver"
+	"cuelang.org/go/mod/modregistry"
+	"cuelang.org/go/mod/module"
+	"github.com/kharf/declcd/pkg/mirror"
+	"gotest.tools/v3/assert"
+)
+
+func TestModuleMirror_Mirror(t *testing.T) {
+	ctx := context.Background()
+
+	source := httptest.NewServer(ociserver.New(ocimem.New(), nil))
+	defer source.Close()
+	target := httptest.NewServer(ociserver.New(ocimem.New(), nil))
+	defer target.Close()
+
+	// Hosts on localhost are reached via http.
+	from, err := mirror.NewModuleClient(strings.TrimPrefix(source.URL, "http://") + "/kharf")
+	assert.NilError(t, err)
+	to, err := mirror.NewModuleClient(strings.TrimPrefix(target.URL, "http://") + "/mirror")
+	assert.NilError(t, err)
+
+	k8s := module.MustNewVersion("github.com/kharf/cuepkgs/modules/k8s@v0", "v0.0.5")
+	schema := module.MustNewVersion("github.com/kharf/declcd/schema@v0", "v0.9.1")
+	oldSchema := module.MustNewVersion("github.com/kharf/declcd/schema@v0", "v0.9.0")
+	putModule(t, from, k8s)
+	putModule(t, from, schema, k8s)
+	putModule(t, from, oldSchema)
+
+	moduleMirror := mirror.ModuleMirror{
+		From: from,
+		To:   to,
+	}
+
+	err = mirror.Verify(ctx, to, []module.Version{schema})
+	assert.ErrorIs(t, err, mirror.ErrModuleNotMirrored)
+
+	mirrored, err := moduleMirror.Mirror(ctx, []module.Version{schema})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, describe(mirrored), []string{
+		"github.com/kharf/declcd/schema@v0.9.1 copied",
+		"github.com/kharf/cuepkgs/modules/k8s@v0.0.5 copied",
+	})
+	assert.NilError(t, mirror.Verify(ctx, to, []module.Version{schema, k8s}))
+
+	mirrored, err = moduleMirror.Mirror(ctx, []module.Version{schema})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, describe(mirrored), []string{
+		"github.com/kharf/declcd/schema@v0.9.1 existing",
+		"github.com/kharf/cuepkgs/modules/k8s@v0.0.5 existing",
+	})
+
+	versions, err := moduleMirror.AllVersions(ctx, "github.com/kharf/declcd/schema@v0")
+	assert.NilError(t, err)
+	assert.Equal(t, len(versions), 2)
+	assert.Assert(t, versions[0].Equal(oldSchema))
+	assert.Assert(t, versions[1].Equal(schema))
+}
+
+func describe(mirrored []mirror.MirroredModule) []string {
+	descriptions := make([]string, 0, len(mirrored))
+	for _, mirroredModule := range mirrored {
+		state := "copied"
+		if mirroredModule.Existing {
+			state = "existing"
+		}
+		descriptions = append(descriptions, fmt.Sprintf("%s %s", mirroredModule.Version, state))
+	}
+	return descriptions
+}
+
+func putModule(t *testing.T, client *modregistry.Client, version module.Version, dependencies ...module.Version) {
+	var moduleFile strings.Builder
+	fmt.Fprintf(&moduleFile, "module: %q\nlanguage: version: \"v0.9.0\"\n", version.Path())
+	for _, dependency := range dependencies {
+		fmt.Fprintf(&moduleFile, "deps: %q: v: %q\n", dependency.Path(), dependency.Version())
+	}
+
+	var buf bytes.Buffer
+	zipWriter := zip.NewWriter(&buf)
+	for name, content := range map[string]string{
+		"cue.mod/module.cue": moduleFile.String(),
+		"schema.cue":         "package schema\n",
+	} {
+		writer, err := zipWriter.Create(name)
+		assert.NilError(t, err)
+		_, err = writer.Write([]byte(content))
+		assert.NilError(t, err)
+	}
+	assert.NilError(t, zipWriter.Close())
+
+	err := client.PutModule(context.Background(), version, bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	assert.NilError(t, err)
+}
@@ -0,0 +1,147 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notification
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const (
+	// SignatureHeader carries the hex encoded HMAC-SHA256 of the request body, prefixed with "sha256=".
+	SignatureHeader = "X-Declcd-Signature"
+)
+
+var (
+	ErrUnexpectedStatusCode = errors.New("Unexpected status code")
+)
+
+// Event describes a status transition of a GitOpsProject.
+type Event struct {
+	Project   string    `json:"project"`
+	Namespace string    `json:"namespace"`
+	URL       string    `json:"url"`
+	Revision  string    `json:"revision"`
+	Reason    string    `json:"reason"`
+	Message   string    `json:"message"`
+	Succeeded bool      `json:"succeeded"`
+	Severity  Severity  `json:"severity"`
+	Shard     string    `json:"shard,omitempty"`
+	Time      time.Time `json:"time"`
+}
+
+// Notifier posts status transitions of GitOpsProjects to external systems,
+// like deployment tracking or change management tools.
+type Notifier struct {
+	HTTPClient *http.Client
+
+	// Attempts is the maximum number of requests sent per event.
+	Attempts int
+
+	// Backoff is the delay before the first retry. It doubles with every further retry.
+	Backoff time.Duration
+
+	// Timeout optionally bounds the delivery of an event including all retries,
+	// so that an unresponsive receiver does not block the reconciliation reporting the event.
+	Timeout time.Duration
+}
+
+// NewNotifier constructs a [Notifier] trying to deliver an event three times within 30 seconds.
+// The client should bound single requests with a timeout.
+func NewNotifier(httpClient *http.Client) *Notifier {
+	return &Notifier{
+		HTTPClient: httpClient,
+		Attempts:   3,
+		Backoff:    1 * time.Second,
+		Timeout:    30 * time.Second,
+	}
+}
+
+// Notify sends the event as JSON via POST to the url.
+// When a secret is given, the body is signed with HMAC-SHA256 and the signature is sent in the [SignatureHeader].
+// Failed deliveries and server errors are retried.
+func (notifier *Notifier) Notify(ctx context.Context, url string, secret []byte, event Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	var signature string
+	if len(secret) != 0 {
+		mac := hmac.New(sha256.New, secret)
+		mac.Write(body)
+		signature = "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	}
+	return notifier.post(ctx, url, signature, body)
+}
+
+// post retries failed deliveries and server errors with an exponential backoff.
+func (notifier *Notifier) post(ctx context.Context, url string, signature string, body []byte) error {
+	if notifier.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, notifier.Timeout)
+		defer cancel()
+	}
+	backoff := notifier.Backoff
+	for attempt := 1; ; attempt++ {
+		retryable, err := notifier.send(ctx, url, signature, body)
+		if err == nil {
+			return nil
+		}
+		if !retryable || attempt >= notifier.Attempts {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+func (notifier *Notifier) send(ctx context.Context, url string, signature string, body []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if signature != "" {
+		req.Header.Set(SignatureHeader, signature)
+	}
+
+	resp, err := notifier.HTTPClient.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+
+	err = fmt.Errorf("%w: %d", ErrUnexpectedStatusCode, resp.StatusCode)
+	retryable := resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests
+	return retryable, err
+}
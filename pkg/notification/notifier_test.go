@@ -0,0 +1,106 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notification_test
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kharf/declcd/pkg/notification"
+	"gotest.tools/v3/assert"
+)
+
+func TestNotifier_Notify(t *testing.T) {
+	secret := []byte("secret")
+	requests := 0
+	var received notification.Event
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		assert.NilError(t, err)
+		mac := hmac.New(sha256.New, secret)
+		mac.Write(body)
+		assert.Equal(t, r.Header.Get(notification.SignatureHeader), "sha256="+hex.EncodeToString(mac.Sum(nil)))
+		assert.NilError(t, json.Unmarshal(body, &received))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	notifier := notification.NewNotifier(server.Client())
+	notifier.Backoff = 10 * time.Millisecond
+	event := notification.Event{
+		Project:   "test",
+		Namespace: "declcd-system",
+		Revision:  "abc",
+		Reason:    "Success",
+		Succeeded: true,
+	}
+	err := notifier.Notify(context.Background(), server.URL, secret, event)
+	assert.NilError(t, err)
+	assert.Equal(t, requests, 2)
+	assert.Equal(t, received.Revision, "abc")
+	assert.Equal(t, received.Reason, "Success")
+}
+
+func TestNotifier_Notify_ClientError(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	notifier := notification.NewNotifier(server.Client())
+	err := notifier.Notify(context.Background(), server.URL, nil, notification.Event{})
+	assert.ErrorIs(t, err, notification.ErrUnexpectedStatusCode)
+	assert.Equal(t, requests, 1)
+}
+
+func TestNotifier_Notify_Timeout(t *testing.T) {
+	var requests atomic.Int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	notifier := notification.NewNotifier(server.Client())
+	notifier.Backoff = 10 * time.Millisecond
+	notifier.Timeout = 100 * time.Millisecond
+	start := time.Now()
+	err := notifier.Notify(context.Background(), server.URL, nil, notification.Event{})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Assert(t, time.Since(start) < 5*time.Second)
+	assert.Equal(t, requests.Load(), int32(1))
+}
@@ -0,0 +1,202 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notification
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/smtp"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Severity classifies events, so that targets can ignore successful reconciliations.
+type Severity string
+
+const (
+	// SeverityInfo is the severity of successful reconciliations.
+	SeverityInfo Severity = "Info"
+	// SeverityError is the severity of failed and partially failed reconciliations.
+	SeverityError Severity = "Error"
+)
+
+func (severity Severity) rank() int {
+	if severity == SeverityError {
+		return 1
+	}
+	return 0
+}
+
+// Filter selects the events delivered to a target.
+type Filter struct {
+	// Severity is the minimum severity of delivered events.
+	Severity Severity
+
+	// Projects are glob patterns matching the names of the projects, whose events are delivered.
+	// When empty, only events of the project declaring the target are delivered.
+	Projects []string
+
+	// Shards are the names of the controller shards, whose events are delivered.
+	// When empty, events of all shards are delivered.
+	Shards []string
+}
+
+// Matches reports whether the event is delivered to a target declared by the given project.
+func (filter Filter) Matches(owner string, event Event) bool {
+	if event.Severity.rank() < filter.Severity.rank() {
+		return false
+	}
+	if len(filter.Shards) != 0 && !slices.Contains(filter.Shards, event.Shard) {
+		return false
+	}
+	if len(filter.Projects) == 0 {
+		return event.Project == owner
+	}
+	for _, pattern := range filter.Projects {
+		if matched, err := path.Match(pattern, event.Project); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
+
+// SecretReference points to a Secret holding the credentials of a target.
+type SecretReference struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+}
+
+// Slack posts events to a Slack incoming webhook.
+// The referenced Secret has to contain the webhook url under "url".
+type Slack struct {
+	SecretRef SecretReference `json:"secretRef"`
+}
+
+// Webhook posts events as JSON to a generic endpoint, see [Notifier.Notify].
+// The referenced Secret optionally contains the signing secret under "secret".
+type Webhook struct {
+	URL       string           `json:"url"`
+	SecretRef *SecretReference `json:"secretRef"`
+}
+
+// Email sends events as plain text emails via SMTP.
+// The referenced Secret optionally contains the credentials under "username" and "password".
+type Email struct {
+	Host      string           `json:"host"`
+	Port      int              `json:"port"`
+	From      string           `json:"from"`
+	To        []string         `json:"to"`
+	SecretRef *SecretReference `json:"secretRef"`
+}
+
+// Summary is a single line describing the event for humans.
+func (event Event) Summary() string {
+	revision := event.Revision
+	if len(revision) > 7 {
+		revision = revision[:7]
+	}
+	return fmt.Sprintf(
+		"%s/%s: %s at %s: %s",
+		event.Namespace,
+		event.Project,
+		event.Reason,
+		revision,
+		event.Message,
+	)
+}
+
+// NotifySlack posts the summary of the event to a Slack incoming webhook.
+// Failed deliveries and server errors are retried.
+func (notifier *Notifier) NotifySlack(ctx context.Context, url string, event Event) error {
+	body, err := json.Marshal(map[string]string{
+		"text": event.Summary(),
+	})
+	if err != nil {
+		return err
+	}
+	return notifier.post(ctx, url, "", body)
+}
+
+// Mail sends the event as plain text email.
+// The connection is upgraded with STARTTLS, when the server supports it.
+// Credentials are only sent, when a username is given.
+func (notifier *Notifier) Mail(ctx context.Context, email Email, username string, password string, event Event) error {
+	addr := net.JoinHostPort(email.Host, strconv.Itoa(email.Port))
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			conn.Close()
+			return err
+		}
+	}
+
+	smtpClient, err := smtp.NewClient(conn, email.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer smtpClient.Close()
+
+	if ok, _ := smtpClient.Extension("STARTTLS"); ok {
+		if err := smtpClient.StartTLS(&tls.Config{ServerName: email.Host}); err != nil {
+			return err
+		}
+	}
+	if username != "" {
+		if err := smtpClient.Auth(smtp.PlainAuth("", username, password, email.Host)); err != nil {
+			return err
+		}
+	}
+	if err := smtpClient.Mail(email.From); err != nil {
+		return err
+	}
+	for _, to := range email.To {
+		if err := smtpClient.Rcpt(to); err != nil {
+			return err
+		}
+	}
+
+	writer, err := smtpClient.Data()
+	if err != nil {
+		return err
+	}
+	var message strings.Builder
+	fmt.Fprintf(&message, "From: %s\r\n", email.From)
+	fmt.Fprintf(&message, "To: %s\r\n", strings.Join(email.To, ", "))
+	fmt.Fprintf(&message, "Subject: [declcd] %s/%s: %s\r\n", event.Namespace, event.Project, event.Reason)
+	message.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	message.WriteString("\r\n")
+	fmt.Fprintf(&message, "%s\r\n", event.Summary())
+	if event.URL != "" {
+		fmt.Fprintf(&message, "\r\nRepository: %s\r\n", event.URL)
+	}
+	if _, err := writer.Write([]byte(message.String())); err != nil {
+		writer.Close()
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+	return smtpClient.Quit()
+}
@@ -0,0 +1,119 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notification_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kharf/declcd/pkg/notification"
+	"gotest.tools/v3/assert"
+)
+
+func TestFilter_Matches(t *testing.T) {
+	failed := notification.Event{
+		Project:  "shop",
+		Reason:   "Failed",
+		Severity: notification.SeverityError,
+		Shard:    "primary",
+	}
+	succeeded := notification.Event{
+		Project:  "shop",
+		Reason:   "Success",
+		Severity: notification.SeverityInfo,
+		Shard:    "primary",
+	}
+
+	testCases := []struct {
+		name     string
+		filter   notification.Filter
+		owner    string
+		event    notification.Event
+		expected bool
+	}{
+		{
+			name:     "Owner",
+			filter:   notification.Filter{},
+			owner:    "shop",
+			event:    succeeded,
+			expected: true,
+		},
+		{
+			name:     "OtherProject",
+			filter:   notification.Filter{},
+			owner:    "platform",
+			event:    succeeded,
+			expected: false,
+		},
+		{
+			name:     "ProjectPattern",
+			filter:   notification.Filter{Projects: []string{"sh*"}},
+			owner:    "platform",
+			event:    succeeded,
+			expected: true,
+		},
+		{
+			name:     "ErrorSeverity",
+			filter:   notification.Filter{Severity: notification.SeverityError},
+			owner:    "shop",
+			event:    succeeded,
+			expected: false,
+		},
+		{
+			name:     "ErrorSeverityFailed",
+			filter:   notification.Filter{Severity: notification.SeverityError},
+			owner:    "shop",
+			event:    failed,
+			expected: true,
+		},
+		{
+			name:     "Shard",
+			filter:   notification.Filter{Projects: []string{"*"}, Shards: []string{"secondary"}},
+			owner:    "platform",
+			event:    failed,
+			expected: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.filter.Matches(tc.owner, tc.event), tc.expected)
+		})
+	}
+}
+
+func TestNotifier_NotifySlack(t *testing.T) {
+	var received map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, r.Header.Get(notification.SignatureHeader), "")
+		assert.NilError(t, json.NewDecoder(r.Body).Decode(&received))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	notifier := notification.NewNotifier(server.Client())
+	err := notifier.NotifySlack(context.Background(), server.URL, notification.Event{
+		Project:   "shop",
+		Namespace: "declcd-system",
+		Revision:  "0123456789abcdef",
+		Reason:    "Failed",
+		Message:   "conflict",
+	})
+	assert.NilError(t, err)
+	assert.Equal(t, received["text"], "declcd-system/shop: Failed at 0123456: conflict")
+}
@@ -0,0 +1,71 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package project
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/kharf/declcd/pkg/component"
+	"github.com/kharf/declcd/pkg/helm"
+	"gotest.tools/v3/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestAppliedInstances(t *testing.T) {
+	namespace := &component.Manifest{ID: "shop___Namespace"}
+	failedRelease := &helm.ReleaseComponent{ID: "db_shop_HelmRelease"}
+	skippedManifest := &component.Manifest{ID: "api_shop_apps_Deployment"}
+	release := &helm.ReleaseComponent{ID: "cache_shop_HelmRelease"}
+	hook := &component.Hook{ID: "migrate_shop_batch_Job"}
+
+	instances := appliedInstances(
+		[]component.Instance{hook, namespace, failedRelease, skippedManifest, release},
+		[]ComponentResult{
+			{ID: "shop___Namespace"},
+			{ID: "db_shop_HelmRelease", Err: errors.New("chart not found")},
+			{ID: "api_shop_apps_Deployment", Err: ErrNamespaceFailed},
+			{ID: "cache_shop_HelmRelease"},
+		},
+	)
+	assert.DeepEqual(t, instances, []component.Instance{hook, namespace, release})
+}
+
+func TestIdentity(t *testing.T) {
+	secret := unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Secret",
+			"metadata": map[string]interface{}{
+				"name":      "db",
+				"namespace": "shop",
+				"annotations": map[string]interface{}{
+					"password": "secret",
+				},
+			},
+			"stringData": map[string]interface{}{
+				"password": "secret",
+			},
+		},
+	}
+	assert.DeepEqual(t, identity(secret).Object, map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Secret",
+		"metadata": map[string]interface{}{
+			"name":      "db",
+			"namespace": "shop",
+		},
+	})
+}
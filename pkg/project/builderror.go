@@ -0,0 +1,127 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package project
+
+import (
+	"bufio"
+	"cmp"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	cueErrors "cuelang.org/go/cue/errors"
+)
+
+// maxSourceLength caps the source line reported with a build issue.
+const maxSourceLength = 120
+
+// BuildError is returned by the [Reconciler] when the project could not be built.
+// It reports the CUE errors with positions relative to the repository.
+type BuildError struct {
+	// Issues are sorted by file, line and column.
+	Issues []BuildIssue
+	Err    error
+}
+
+var _ error = (*BuildError)(nil)
+
+func (e *BuildError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *BuildError) Unwrap() error {
+	return e.Err
+}
+
+// BuildIssue is a single error reported by CUE.
+type BuildIssue struct {
+	// File is relative to the repository. Empty for errors without a position.
+	File   string
+	Line   int
+	Column int
+
+	// Message is the error message prefixed with the path of the erroneous value.
+	Message string
+
+	// Source is the trimmed line of the file the error was reported at, e.g. the conflicting expression.
+	Source string
+}
+
+// String formats the issue as file:line:column: message.
+func (issue BuildIssue) String() string {
+	if issue.File == "" {
+		return issue.Message
+	}
+	return fmt.Sprintf("%s:%d:%d: %s", issue.File, issue.Line, issue.Column, issue.Message)
+}
+
+// buildError collects the CUE errors of err with their positions.
+// Files outside of the repository, e.g. imported modules, keep their absolute path.
+func buildError(err error, repositoryDir string) *BuildError {
+	issues := make([]BuildIssue, 0)
+	for _, cueErr := range cueErrors.Errors(err) {
+		format, args := cueErr.Msg()
+		issue := BuildIssue{
+			Message: fmt.Sprintf(format, args...),
+		}
+		if path := cueErr.Path(); len(path) != 0 {
+			issue.Message = fmt.Sprintf("%s: %s", strings.Join(path, "."), issue.Message)
+		}
+		if pos := cueErr.Position(); pos.IsValid() {
+			issue.File = pos.Filename()
+			if rel, err := filepath.Rel(repositoryDir, issue.File); err == nil && filepath.IsLocal(rel) {
+				issue.File = rel
+			}
+			issue.Line = pos.Line()
+			issue.Column = pos.Column()
+			issue.Source = sourceLine(pos.Filename(), pos.Line())
+		}
+		issues = append(issues, issue)
+	}
+	slices.SortStableFunc(issues, func(a, b BuildIssue) int {
+		return cmp.Or(
+			strings.Compare(a.File, b.File),
+			cmp.Compare(a.Line, b.Line),
+			cmp.Compare(a.Column, b.Column),
+		)
+	})
+	return &BuildError{
+		Issues: issues,
+		Err:    err,
+	}
+}
+
+// sourceLine returns the trimmed line of the file or an empty string, if it cannot be read.
+func sourceLine(file string, line int) string {
+	f, err := os.Open(file)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for current := 1; scanner.Scan(); current++ {
+		if current != line {
+			continue
+		}
+		source := []rune(strings.TrimSpace(scanner.Text()))
+		if len(source) > maxSourceLength {
+			source = source[:maxSourceLength]
+		}
+		return string(source)
+	}
+	return ""
+}
@@ -0,0 +1,58 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package project
+
+import (
+	"testing"
+
+	gitops "github.com/kharf/declcd/api/v1beta1"
+	"gotest.tools/v3/assert"
+)
+
+func TestReconciler_applyConcurrency(t *testing.T) {
+	testCases := []struct {
+		name                 string
+		maxConcurrentApplies int
+		expected             int
+	}{
+		{
+			name:                 "Unset",
+			maxConcurrentApplies: 0,
+			expected:             8,
+		},
+		{
+			name:                 "Lowered",
+			maxConcurrentApplies: 2,
+			expected:             2,
+		},
+		{
+			name:                 "CappedAtWorkerPoolSize",
+			maxConcurrentApplies: 32,
+			expected:             8,
+		},
+	}
+
+	reconciler := &Reconciler{WorkerPoolSize: 8}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			gProject := gitops.GitOpsProject{
+				Spec: gitops.GitOpsProjectSpec{
+					MaxConcurrentApplies: tc.maxConcurrentApplies,
+				},
+			}
+			assert.Equal(t, reconciler.applyConcurrency(gProject), tc.expected)
+		})
+	}
+}
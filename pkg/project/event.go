@@ -0,0 +1,72 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package project
+
+import (
+	"fmt"
+	"strings"
+
+	gitops "github.com/kharf/declcd/api/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// maxEventComponents limits the component ids listed by a single event, as events are meant to be read by humans.
+const maxEventComponents = 10
+
+// recordApplied emits a single event listing the components, whose declared content changed since they were last applied.
+// Unchanged components are reapplied on every reconciliation to correct drift and are not worth an event.
+func (reconciler *Reconciler) recordApplied(
+	previous []gitops.GitOpsProjectComponentStatus,
+	results []ComponentResult,
+	commitHash string,
+) {
+	if reconciler.OnEvent == nil {
+		return
+	}
+
+	previousDigests := make(map[string]string, len(previous))
+	for _, componentStatus := range previous {
+		previousDigests[componentStatus.ID] = componentStatus.Digest
+	}
+
+	changed := make([]string, 0)
+	for _, result := range results {
+		if result.Err != nil || result.Digest == "" {
+			continue
+		}
+		if digest, found := previousDigests[result.ID]; found && digest == result.Digest {
+			continue
+		}
+		changed = append(changed, result.ID)
+	}
+	if len(changed) == 0 {
+		return
+	}
+
+	listed := changed
+	if len(listed) > maxEventComponents {
+		listed = listed[:maxEventComponents]
+	}
+	message := fmt.Sprintf(
+		"Applied %d changed components of revision %s: %s",
+		len(changed),
+		commitHash,
+		strings.Join(listed, ", "),
+	)
+	if len(changed) > len(listed) {
+		message = fmt.Sprintf("%s and %d more", message, len(changed)-len(listed))
+	}
+	reconciler.OnEvent(corev1.EventTypeNormal, "Applied", message)
+}
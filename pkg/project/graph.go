@@ -0,0 +1,167 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package project
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/kharf/declcd/pkg/component"
+)
+
+var (
+	ErrUnsupportedGraphFormat = errors.New("Unsupported graph format")
+)
+
+// GraphFormat is the output format of a rendered component dependency graph.
+type GraphFormat string
+
+const (
+	// GraphDOT renders the graph in the Graphviz DOT language.
+	GraphDOT GraphFormat = "dot"
+	// GraphMermaid renders the graph as a Mermaid flowchart.
+	GraphMermaid GraphFormat = "mermaid"
+	// GraphJSON renders the graph as a list of nodes with their dependencies.
+	GraphJSON GraphFormat = "json"
+)
+
+// GraphNode is a component of a rendered dependency graph.
+type GraphNode struct {
+	ID           string   `json:"id"`
+	Type         string   `json:"type"`
+	Dependencies []string `json:"dependencies"`
+}
+
+// WriteGraph renders the dependency graph of a project.
+// Edges point from a component to the components it depends on.
+// Nodes are sorted by the order they are applied in and their ids, so that the output is stable.
+func WriteGraph(out io.Writer, dag *component.DependencyGraph, format GraphFormat) error {
+	instances, err := dag.TopologicalSort()
+	if err != nil {
+		return err
+	}
+	nodes := graphNodes(instances)
+
+	switch format {
+	case GraphDOT:
+		return writeDOT(out, nodes)
+	case GraphMermaid:
+		return writeMermaid(out, nodes)
+	case GraphJSON:
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(nodes)
+	default:
+		return fmt.Errorf("%w: %s", ErrUnsupportedGraphFormat, format)
+	}
+}
+
+// graphNodes orders the nodes by their depth in the graph and ids within the same depth.
+func graphNodes(instances []component.Instance) []GraphNode {
+	depths := make(map[string]int, len(instances))
+	for _, instance := range instances {
+		depth := 0
+		for _, dependency := range instance.GetDependencies() {
+			depth = max(depth, depths[dependency]+1)
+		}
+		depths[instance.GetID()] = depth
+	}
+
+	nodes := make([]GraphNode, 0, len(instances))
+	for _, instance := range instances {
+		dependencies := slices.Clone(instance.GetDependencies())
+		if dependencies == nil {
+			dependencies = []string{}
+		}
+		slices.Sort(dependencies)
+		nodes = append(nodes, GraphNode{
+			ID:           instance.GetID(),
+			Type:         componentType(instance),
+			Dependencies: dependencies,
+		})
+	}
+	slices.SortFunc(nodes, func(a, b GraphNode) int {
+		if depths[a.ID] != depths[b.ID] {
+			return depths[a.ID] - depths[b.ID]
+		}
+		return strings.Compare(a.ID, b.ID)
+	})
+	return nodes
+}
+
+var dotEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+
+func writeDOT(out io.Writer, nodes []GraphNode) error {
+	var builder strings.Builder
+	builder.WriteString("digraph components {\n")
+	builder.WriteString("  rankdir=LR;\n")
+	builder.WriteString("  node [shape=box];\n")
+	for _, node := range nodes {
+		fmt.Fprintf(
+			&builder,
+			"  \"%s\" [label=\"%s\\n%s\"];\n",
+			dotEscaper.Replace(node.ID),
+			dotEscaper.Replace(node.ID),
+			dotEscaper.Replace(node.Type),
+		)
+	}
+	for _, node := range nodes {
+		for _, dependency := range node.Dependencies {
+			fmt.Fprintf(
+				&builder,
+				"  \"%s\" -> \"%s\";\n",
+				dotEscaper.Replace(node.ID),
+				dotEscaper.Replace(dependency),
+			)
+		}
+	}
+	builder.WriteString("}\n")
+	_, err := io.WriteString(out, builder.String())
+	return err
+}
+
+var mermaidEscaper = strings.NewReplacer(`"`, "#quot;", "<", "#lt;", ">", "#gt;")
+
+// writeMermaid refers to nodes by their position,
+// because component ids contain characters Mermaid does not allow in node ids.
+func writeMermaid(out io.Writer, nodes []GraphNode) error {
+	positions := make(map[string]int, len(nodes))
+	for i, node := range nodes {
+		positions[node.ID] = i
+	}
+
+	var builder strings.Builder
+	builder.WriteString("flowchart LR\n")
+	for i, node := range nodes {
+		fmt.Fprintf(
+			&builder,
+			"  n%d[\"%s<br/>%s\"]\n",
+			i,
+			mermaidEscaper.Replace(node.ID),
+			mermaidEscaper.Replace(node.Type),
+		)
+	}
+	for i, node := range nodes {
+		for _, dependency := range node.Dependencies {
+			fmt.Fprintf(&builder, "  n%d --> n%d\n", i, positions[dependency])
+		}
+	}
+	_, err := io.WriteString(out, builder.String())
+	return err
+}
@@ -0,0 +1,98 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package project_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/kharf/declcd/pkg/component"
+	"github.com/kharf/declcd/pkg/helm"
+	"github.com/kharf/declcd/pkg/project"
+	"gotest.tools/v3/assert"
+)
+
+func TestWriteGraph(t *testing.T) {
+	dag := component.NewDependencyGraph()
+	err := dag.Insert(
+		&helm.ReleaseComponent{
+			ID:           "linkerd_linkerd_HelmRelease",
+			Dependencies: []string{"linkerd___Namespace"},
+		},
+		&component.Manifest{
+			ID: "linkerd___Namespace",
+		},
+	)
+	assert.NilError(t, err)
+
+	testCases := []struct {
+		name     string
+		format   project.GraphFormat
+		expected string
+	}{
+		{
+			name:   "DOT",
+			format: project.GraphDOT,
+			expected: `digraph components {
+  rankdir=LR;
+  node [shape=box];
+  "linkerd___Namespace" [label="linkerd___Namespace\nManifest"];
+  "linkerd_linkerd_HelmRelease" [label="linkerd_linkerd_HelmRelease\nHelmRelease"];
+  "linkerd_linkerd_HelmRelease" -> "linkerd___Namespace";
+}
+`,
+		},
+		{
+			name:   "Mermaid",
+			format: project.GraphMermaid,
+			expected: `flowchart LR
+  n0["linkerd___Namespace<br/>Manifest"]
+  n1["linkerd_linkerd_HelmRelease<br/>HelmRelease"]
+  n1 --> n0
+`,
+		},
+		{
+			name:   "JSON",
+			format: project.GraphJSON,
+			expected: `[
+  {
+    "id": "linkerd___Namespace",
+    "type": "Manifest",
+    "dependencies": []
+  },
+  {
+    "id": "linkerd_linkerd_HelmRelease",
+    "type": "HelmRelease",
+    "dependencies": [
+      "linkerd___Namespace"
+    ]
+  }
+]
+`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var out bytes.Buffer
+			err := project.WriteGraph(&out, &dag, tc.format)
+			assert.NilError(t, err)
+			assert.Equal(t, out.String(), tc.expected)
+		})
+	}
+
+	err = project.WriteGraph(&bytes.Buffer{}, &dag, "svg")
+	assert.ErrorIs(t, err, project.ErrUnsupportedGraphFormat)
+}
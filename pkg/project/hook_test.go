@@ -0,0 +1,163 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package project
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/kharf/declcd/pkg/component"
+	"gotest.tools/v3/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestPartitionHooks(t *testing.T) {
+	namespace := &component.Manifest{ID: "shop___Namespace"}
+	backup := &component.Hook{ID: "backup_shop_batch_Job", Phase: component.PreApply}
+	warmup := &component.HTTPHook{ID: "warmup_HTTPHook", Phase: component.PreApply}
+	deployment := &component.Manifest{ID: "api_shop_apps_Deployment"}
+	flush := &component.HTTPHook{ID: "flush_HTTPHook", Phase: component.PostApply}
+	smoke := &component.Hook{ID: "smoke_shop_batch_Job", Phase: component.PostApply}
+
+	preApplyHooks, instances, postApplyHooks := partitionHooks(
+		[]component.Instance{namespace, backup, warmup, deployment, flush, smoke},
+	)
+	assert.DeepEqual(t, preApplyHooks, []component.Instance{backup, warmup})
+	assert.DeepEqual(t, instances, []component.Instance{namespace, deployment})
+	assert.DeepEqual(t, postApplyHooks, []component.Instance{flush, smoke})
+}
+
+func TestReconciler_reconcileHooks(t *testing.T) {
+	var called []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = append(called, r.URL.Path)
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	httpHook := func(name string, failurePolicy component.FailurePolicy, dependencies ...string) *component.HTTPHook {
+		return &component.HTTPHook{
+			ID:            name + "_HTTPHook",
+			Dependencies:  dependencies,
+			Phase:         component.PostApply,
+			FailurePolicy: failurePolicy,
+			Timeout:       time.Second,
+			Request: component.HTTPRequest{
+				URL:    server.URL + "/" + name,
+				Method: http.MethodPost,
+			},
+		}
+	}
+	// a Job hook in a failed namespace is skipped before it is applied.
+	job := &component.Hook{
+		ID:            "smoke_billing_batch_Job",
+		Phase:         component.PostApply,
+		FailurePolicy: component.Abort,
+		Content: unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "batch/v1",
+			"kind":       "Job",
+			"metadata": map[string]interface{}{
+				"name":      "smoke",
+				"namespace": "billing",
+			},
+		}},
+	}
+
+	testCases := []struct {
+		name             string
+		hooks            []component.Instance
+		namespaceResults []NamespaceResult
+		componentResults []ComponentResult
+		expectedCalls    []string
+		err              error
+	}{
+		{
+			name: "Ordered",
+			hooks: []component.Instance{
+				httpHook("first", component.Abort),
+				httpHook("second", component.Abort),
+				httpHook("third", component.Abort),
+			},
+			expectedCalls: []string{"/first", "/second", "/third"},
+		},
+		{
+			name: "Abort",
+			hooks: []component.Instance{
+				httpHook("fail", component.Abort),
+				httpHook("second", component.Abort),
+			},
+			expectedCalls: []string{"/fail"},
+			err:           component.ErrHookFailed,
+		},
+		{
+			name: "Ignore",
+			hooks: []component.Instance{
+				httpHook("fail", component.Ignore),
+				httpHook("dependent", component.Abort, "fail_HTTPHook"),
+				httpHook("second", component.Abort),
+			},
+			expectedCalls: []string{"/fail", "/second"},
+		},
+		{
+			name: "FailedNamespacesAndDependencies",
+			hooks: []component.Instance{
+				job,
+				httpHook("dependent", component.Abort, "api_shop_apps_Deployment"),
+				httpHook("independent", component.Abort),
+			},
+			namespaceResults: []NamespaceResult{
+				{Namespace: "billing", Err: errors.New("apply failed")},
+				{Namespace: "shop", Err: ErrDependencyFailed},
+			},
+			componentResults: []ComponentResult{
+				{ID: "api_shop_apps_Deployment", Err: ErrDependencyFailed},
+			},
+			expectedCalls: []string{"/independent"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			called = nil
+			reconciler := &Reconciler{}
+			componentReconciler := component.Reconciler{
+				Log:        logr.Discard(),
+				HTTPClient: server.Client(),
+			}
+			err := reconciler.reconcileHooks(
+				context.Background(),
+				logr.Discard(),
+				componentReconciler,
+				tc.hooks,
+				tc.namespaceResults,
+				tc.componentResults,
+			)
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+			} else {
+				assert.NilError(t, err)
+			}
+			assert.DeepEqual(t, called, tc.expectedCalls)
+		})
+	}
+}
@@ -20,23 +20,133 @@ import (
 	"html/template"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"cuelang.org/go/mod/modfile"
 	"github.com/kharf/declcd/internal/manifest"
+	"github.com/kharf/declcd/pkg/component"
 )
 
 const (
 	ControllerNamespace = "declcd-system"
 	controllerName      = "project-controller"
+
+	// DefaultImageRegistry is the registry the controller image is pulled from.
+	DefaultImageRegistry = "ghcr.io/kharf"
+
+	// DefaultCUERegistry is the CUE registry configuration the controller resolves CUE modules with.
+	DefaultCUERegistry = "ghcr.io/kharf"
 )
 
+type initOptions struct {
+	metricsSecure            bool
+	certManagerClusterIssuer string
+	imageRegistry            string
+	imagePullSecrets         []string
+	namespace                string
+	skipCRD                  bool
+	cueRegistry              string
+}
+
+// InitOption is a specific configuration used for initializing a Declcd project.
+type InitOption interface {
+	apply(opts *initOptions)
+}
+
+// MetricsSecure configures the controller to serve its metric endpoint via https
+// with a self-signed certificate.
+type MetricsSecure bool
+
+func (opt MetricsSecure) apply(opts *initOptions) {
+	opts.metricsSecure = bool(opt)
+}
+
+// CertManagerClusterIssuer names the cert-manager ClusterIssuer used to issue the serving certificate
+// of the controller metric endpoint.
+// When set, a cert-manager Certificate is generated and mounted into the controller, which serves its metrics via https.
+type CertManagerClusterIssuer string
+
+func (opt CertManagerClusterIssuer) apply(opts *initOptions) {
+	opts.certManagerClusterIssuer = string(opt)
+}
+
+// ImageRegistry points the controller image to a mirror registry, e.g. in air-gapped clusters.
+// The image is referenced as <registry>/declcd:<version>.
+type ImageRegistry string
+
+func (opt ImageRegistry) apply(opts *initOptions) {
+	if opt != "" {
+		opts.imageRegistry = strings.TrimSuffix(string(opt), "/")
+	}
+}
+
+// ImagePullSecrets names the secrets in the controller namespace used to pull the controller image.
+type ImagePullSecrets []string
+
+func (opt ImagePullSecrets) apply(opts *initOptions) {
+	opts.imagePullSecrets = append(opts.imagePullSecrets, opt...)
+}
+
+// Namespace installs the controllers into another namespace than [ControllerNamespace],
+// e.g. to run multiple independent declcd instances on one cluster.
+// Controllers outside of [ControllerNamespace] only reconcile GitOpsProjects in their own namespace
+// and their cluster scoped objects are prefixed with the namespace, so that instances do not interfere.
+// It has to be the same for the primary and all secondary shards of an instance.
+type Namespace string
+
+func (opt Namespace) apply(opts *initOptions) {
+	if opt != "" {
+		opts.namespace = string(opt)
+	}
+}
+
+// SkipCRD leaves the GitOpsProject CRD to another declcd instance on the cluster, which owns and upgrades it.
+// Instances sharing a CRD can be upgraded one after another, as long as the CRD stays compatible.
+type SkipCRD bool
+
+func (opt SkipCRD) apply(opts *initOptions) {
+	opts.skipCRD = bool(opt)
+}
+
+// CUERegistry points the controller to a CUE registry mirror, e.g. in air-gapped clusters.
+// It is a CUE_REGISTRY value like "registry.internal/cue".
+type CUERegistry string
+
+func (opt CUERegistry) apply(opts *initOptions) {
+	// Only deviations from the default of the controller are rendered, which keeps generated projects unchanged.
+	if opt != DefaultCUERegistry {
+		opts.cueRegistry = string(opt)
+	}
+}
+
+// clusterPrefix returns the prefix of cluster scoped objects of the instance in the namespace.
+// Instances in the default namespace keep unprefixed names for compatibility.
+func clusterPrefix(namespace string) string {
+	if namespace == ControllerNamespace {
+		return ""
+	}
+	return namespace + "-"
+}
+
 func Init(
 	module string,
 	shard string,
 	isSecondary bool,
 	path string,
 	version string,
+	opts ...InitOption,
 ) error {
+	initOpts := &initOptions{
+		imageRegistry: DefaultImageRegistry,
+		namespace:     ControllerNamespace,
+	}
+	for _, opt := range opts {
+		opt.apply(initOpts)
+	}
+	if initOpts.certManagerClusterIssuer != "" {
+		initOpts.metricsSecure = true
+	}
+
 	moduleDir := filepath.Join(path, "cue.mod")
 	_, err := os.Stat(moduleDir)
 	if err != nil && !os.IsNotExist(err) {
@@ -47,7 +157,7 @@ func Init(
 		moduleFile := modfile.File{
 			Module: module,
 			Language: &modfile.Language{
-				Version: "v0.9.2",
+				Version: component.MaxLanguageVersion,
 			},
 			Deps: map[string]*modfile.Dep{
 				"github.com/kharf/declcd/schema@v0": {
@@ -94,9 +204,12 @@ func Init(
 		}
 
 		var buf bytes.Buffer
-		if err := tmpl.Execute(&buf, map[string]string{
-			"Name":  getControllerName(shard),
-			"Shard": shard,
+		if err := tmpl.Execute(&buf, map[string]interface{}{
+			"Name":          ControllerName(shard),
+			"Shard":         shard,
+			"Namespace":     initOpts.namespace,
+			"ClusterPrefix": clusterPrefix(initOpts.namespace),
+			"SkipCRD":       initOpts.skipCRD,
 		}); err != nil {
 			return err
 		}
@@ -105,8 +218,10 @@ func Init(
 			return err
 		}
 
-		if err := os.WriteFile(filepath.Join(declcdDir, "crd.cue"), []byte(manifest.CRD), 0666); err != nil {
-			return err
+		if !initOpts.skipCRD {
+			if err := os.WriteFile(filepath.Join(declcdDir, "crd.cue"), []byte(manifest.CRD), 0666); err != nil {
+				return err
+			}
 		}
 	}
 
@@ -116,10 +231,16 @@ func Init(
 	}
 
 	var buf bytes.Buffer
-	if err := tmpl.Execute(&buf, map[string]string{
-		"Name":    getControllerName(shard),
-		"Shard":   shard,
-		"Version": version,
+	if err := tmpl.Execute(&buf, map[string]interface{}{
+		"Name":                     ControllerName(shard),
+		"Shard":                    shard,
+		"Version":                  version,
+		"MetricsSecure":            initOpts.metricsSecure,
+		"CertManagerClusterIssuer": initOpts.certManagerClusterIssuer,
+		"ImageRegistry":            initOpts.imageRegistry,
+		"ImagePullSecrets":         initOpts.imagePullSecrets,
+		"ClusterPrefix":            clusterPrefix(initOpts.namespace),
+		"CUERegistry":              initOpts.cueRegistry,
 	}); err != nil {
 		return err
 	}
@@ -131,6 +252,7 @@ func Init(
 	return nil
 }
 
-func getControllerName(shard string) string {
+// ControllerName returns the name of the controller of a shard, which is also its field manager.
+func ControllerName(shard string) string {
 	return fmt.Sprintf("%s-%s", controllerName, shard)
 }
@@ -89,6 +89,139 @@ func TestInit(t *testing.T) {
 				assertModule(t, path, "github.com/kharf/declcd/init@v0", expectedFiles)
 			},
 		},
+		{
+			name: "CertManager",
+			run: func() string {
+				path, err := os.MkdirTemp("", "")
+				assert.NilError(t, err)
+				err = project.Init(
+					"github.com/kharf/declcd/init@v0",
+					"primary",
+					false,
+					path,
+					"0.1.0",
+					project.CertManagerClusterIssuer("issuer"),
+				)
+				assert.NilError(t, err)
+				return path
+			},
+			expectedFiles: []string{
+				"declcd/primary.cue",
+				"declcd/primary_system.cue",
+				"declcd/crd.cue",
+			},
+			assert: func(path string, expectedFiles []string) {
+				assertModule(t, path, "github.com/kharf/declcd/init@v0", expectedFiles)
+				content, err := os.ReadFile(filepath.Join(path, "declcd/primary_system.cue"))
+				assert.NilError(t, err)
+				system := string(content)
+				assert.Assert(t, strings.Contains(system, `kind:       "Certificate"`))
+				assert.Assert(t, strings.Contains(system, `name:  "issuer"`))
+				assert.Assert(t, strings.Contains(system, `"--metrics-secure=true"`))
+				assert.Assert(t, strings.Contains(system, `"--metrics-cert-dir=/metrics-certs"`))
+			},
+		},
+		{
+			name: "ImageRegistry",
+			run: func() string {
+				path, err := os.MkdirTemp("", "")
+				assert.NilError(t, err)
+				err = project.Init(
+					"github.com/kharf/declcd/init@v0",
+					"primary",
+					false,
+					path,
+					"0.1.0",
+					project.ImageRegistry("registry.local:5000/mirror/"),
+					project.ImagePullSecrets{"mirror-credentials"},
+				)
+				assert.NilError(t, err)
+				return path
+			},
+			expectedFiles: []string{
+				"declcd/primary.cue",
+				"declcd/primary_system.cue",
+				"declcd/crd.cue",
+			},
+			assert: func(path string, expectedFiles []string) {
+				assertModule(t, path, "github.com/kharf/declcd/init@v0", expectedFiles)
+				content, err := os.ReadFile(filepath.Join(path, "declcd/primary_system.cue"))
+				assert.NilError(t, err)
+				system := string(content)
+				assert.Assert(t, strings.Contains(system, `image: "registry.local:5000/mirror/declcd:0.1.0"`))
+				assert.Assert(t, strings.Contains(system, `name: "mirror-credentials"`))
+				assert.Assert(t, !strings.Contains(system, "--cue-registry"))
+			},
+		},
+		{
+			name: "CUERegistry",
+			run: func() string {
+				path, err := os.MkdirTemp("", "")
+				assert.NilError(t, err)
+				err = project.Init(
+					"github.com/kharf/declcd/init@v0",
+					"primary",
+					false,
+					path,
+					"0.1.0",
+					project.CUERegistry("registry.local:5000/cue"),
+				)
+				assert.NilError(t, err)
+				return path
+			},
+			expectedFiles: []string{
+				"declcd/primary.cue",
+				"declcd/primary_system.cue",
+				"declcd/crd.cue",
+			},
+			assert: func(path string, expectedFiles []string) {
+				assertModule(t, path, "github.com/kharf/declcd/init@v0", expectedFiles)
+				content, err := os.ReadFile(filepath.Join(path, "declcd/primary_system.cue"))
+				assert.NilError(t, err)
+				system := string(content)
+				assert.Assert(t, strings.Contains(system, `"--cue-registry=registry.local:5000/cue"`))
+			},
+		},
+		{
+			name: "Namespace",
+			run: func() string {
+				path, err := os.MkdirTemp("", "")
+				assert.NilError(t, err)
+				err = project.Init(
+					"github.com/kharf/declcd/init@v0",
+					"primary",
+					false,
+					path,
+					"0.1.0",
+					project.Namespace("team-a"),
+					project.SkipCRD(true),
+				)
+				assert.NilError(t, err)
+				return path
+			},
+			expectedFiles: []string{
+				"declcd/primary.cue",
+				"declcd/primary_system.cue",
+			},
+			assert: func(path string, expectedFiles []string) {
+				assertModule(t, path, "github.com/kharf/declcd/init@v0", expectedFiles)
+				_, err := os.Stat(filepath.Join(path, "declcd/crd.cue"))
+				assert.Assert(t, os.IsNotExist(err))
+
+				content, err := os.ReadFile(filepath.Join(path, "declcd/primary.cue"))
+				assert.NilError(t, err)
+				primary := string(content)
+				assert.Assert(t, !strings.Contains(primary, "crd.id"))
+				assert.Assert(t, strings.Contains(primary, `name:   "team-a"`))
+				assert.Assert(t, strings.Contains(primary, `name:   "team-a-project-controller"`))
+
+				content, err = os.ReadFile(filepath.Join(path, "declcd/primary_system.cue"))
+				assert.NilError(t, err)
+				system := string(content)
+				assert.Assert(t, strings.Contains(system, `name:   "team-a-project-controller-primary"`))
+				assert.Assert(t, strings.Contains(system, `"--project-namespace=\(ns.content.metadata.name)"`))
+			},
+		},
 		{
 			name: "Exists",
 			run: func() string {
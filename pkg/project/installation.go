@@ -20,6 +20,7 @@ import (
 	"errors"
 	"fmt"
 	"html/template"
+	"io"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -31,6 +32,7 @@ import (
 	"github.com/kharf/declcd/pkg/vcs"
 	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
 )
 
 var (
@@ -44,6 +46,48 @@ type InstallOptions struct {
 	Token    string
 	Interval int
 	Shard    string
+
+	// Namespace of the declcd instance, which has been passed to [Init]. Defaults to [ControllerNamespace].
+	Namespace string
+
+	// SSHKey is an existing private key authenticating the controller at the Git server, e.g. of a machine user.
+	// When set, it is stored instead of registering a generated deploy key via Token.
+	SSHKey []byte
+	// SSHKeyPassphrase decrypts a passphrase protected SSHKey.
+	SSHKeyPassphrase []byte
+	// SSHKnownHosts are the known_hosts entries the host key of the Git server is verified against.
+	SSHKnownHosts []byte
+
+	// DryRun renders all objects the installation would apply to Output as YAML documents instead of touching the cluster.
+	// The deploy key is generated locally and not registered at the Git provider.
+	DryRun bool
+	Output io.Writer
+
+	// Progress is called for every state transition of an installed object, e.g. to render a progress indicator.
+	// It is not called for dry runs.
+	Progress func(InstallProgress)
+}
+
+// InstallState is the state of an object during the installation.
+type InstallState string
+
+const (
+	InstallApplied InstallState = "Applied"
+	// InstallWaiting means the object waits for its namespace or custom resource definition to become available.
+	InstallWaiting InstallState = "Waiting"
+	InstallFailed  InstallState = "Failed"
+)
+
+// InstallProgress reports the state of the Index-th of Total objects installed.
+type InstallProgress struct {
+	Index     int
+	Total     int
+	Kind      string
+	Namespace string
+	Name      string
+	State     InstallState
+	// Err is set for failed objects.
+	Err error
 }
 
 type InstallAction struct {
@@ -66,6 +110,11 @@ func NewInstallAction(
 }
 
 func (act InstallAction) Install(ctx context.Context, opts InstallOptions) error {
+	namespace := opts.Namespace
+	if namespace == "" {
+		namespace = ControllerNamespace
+	}
+
 	var projectBuf bytes.Buffer
 	projectTmpl, err := template.New("").Parse(manifest.Project)
 	if err != nil {
@@ -74,7 +123,7 @@ func (act InstallAction) Install(ctx context.Context, opts InstallOptions) error
 
 	if err := projectTmpl.Execute(&projectBuf, map[string]interface{}{
 		"Name":                opts.Name,
-		"Namespace":           ControllerNamespace,
+		"Namespace":           namespace,
 		"Branch":              opts.Branch,
 		"PullIntervalSeconds": opts.Interval,
 		"Shard":               opts.Shard,
@@ -106,29 +155,71 @@ func (act InstallAction) Install(ctx context.Context, opts InstallOptions) error
 		return err
 	}
 
-	controllerName := getControllerName(opts.Shard)
+	objects := make([]*unstructured.Unstructured, 0, len(instances))
 	for _, instance := range instances {
 		manifest, ok := instance.(*component.Manifest)
 		if !ok {
 			return ErrHelmInstallationUnsupported
 		}
-
 		if opts.Shard == manifest.Content.GetLabels()["declcd/shard"] {
-			timeoutCtx, cancel := context.WithTimeout(ctx, 60*time.Second)
-			defer cancel()
-
-			if err := act.installObject(
-				timeoutCtx,
-				&manifest.Content,
-				controllerName,
-			); err != nil {
+			objects = append(objects, &manifest.Content)
+		}
+	}
+
+	controllerName := ControllerName(opts.Shard)
+	progress := installProgress{
+		callback: opts.Progress,
+		// The objects are followed by the secret holding the Git credentials.
+		total: len(objects) + 1,
+	}
+	for _, object := range objects {
+		if opts.DryRun {
+			if err := writeObject(opts.Output, object); err != nil {
 				return err
 			}
+			continue
+		}
+
+		timeoutCtx, cancel := context.WithTimeout(ctx, 60*time.Second)
+		defer cancel()
+
+		if err := act.installObject(
+			timeoutCtx,
+			object,
+			controllerName,
+			progress.next(),
+		); err != nil {
+			return err
+		}
+	}
+
+	if len(opts.SSHKey) != 0 {
+		secret, err := vcs.SSHKeySecret(
+			namespace,
+			opts.Name,
+			opts.SSHKey,
+			opts.SSHKeyPassphrase,
+			opts.SSHKnownHosts,
+		)
+		if err != nil {
+			return err
+		}
+		if opts.DryRun {
+			return writeObject(opts.Output, secret)
+		}
+		return act.installObject(ctx, secret, controllerName, progress.next())
+	}
+
+	if opts.DryRun {
+		secret, err := vcs.GenerateDeployKeySecret(namespace, opts.Name)
+		if err != nil {
+			return err
 		}
+		return writeObject(opts.Output, secret)
 	}
 
 	repoConfigurator, err := vcs.NewRepositoryConfigurator(
-		ControllerNamespace,
+		namespace,
 		act.kubeClient,
 		act.httpClient,
 		opts.Url,
@@ -138,32 +229,98 @@ func (act InstallAction) Install(ctx context.Context, opts InstallOptions) error
 		return err
 	}
 
+	deployKey := progress.next()
+	secret := &unstructured.Unstructured{}
+	secret.SetKind("Secret")
+	secret.SetNamespace(namespace)
+	secret.SetName(opts.Name)
 	if err := repoConfigurator.CreateDeployKeySecretIfNotExists(ctx, controllerName, opts.Name); err != nil {
+		deployKey.report(secret, InstallFailed, err)
 		return err
 	}
+	deployKey.report(secret, InstallApplied, nil)
 
 	return nil
 }
 
+// installProgress numbers the installed objects.
+type installProgress struct {
+	callback func(InstallProgress)
+	total    int
+	index    int
+}
+
+func (progress *installProgress) next() objectProgress {
+	progress.index++
+	return objectProgress{
+		callback: progress.callback,
+		index:    progress.index,
+		total:    progress.total,
+	}
+}
+
+type objectProgress struct {
+	callback func(InstallProgress)
+	index    int
+	total    int
+}
+
+func (progress objectProgress) report(unstr *unstructured.Unstructured, state InstallState, err error) {
+	if progress.callback == nil {
+		return
+	}
+	progress.callback(InstallProgress{
+		Index:     progress.index,
+		Total:     progress.total,
+		Kind:      unstr.GetKind(),
+		Namespace: unstr.GetNamespace(),
+		Name:      unstr.GetName(),
+		State:     state,
+		Err:       err,
+	})
+}
+
+// writeObject writes an object as YAML document.
+func writeObject(w io.Writer, unstr *unstructured.Unstructured) error {
+	content, err := yaml.Marshal(unstr.Object)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "---\n%s", content); err != nil {
+		return err
+	}
+	return nil
+}
+
 func (act InstallAction) installObject(
 	ctx context.Context,
 	unstr *unstructured.Unstructured,
 	fieldManager string,
+	progress objectProgress,
 ) error {
-	select {
-	case <-ctx.Done():
-		return ctx.Err()
-	default:
-	}
+	waiting := false
+	for {
+		select {
+		case <-ctx.Done():
+			progress.report(unstr, InstallFailed, ctx.Err())
+			return ctx.Err()
+		default:
+		}
 
-	if err := act.kubeClient.Apply(ctx, unstr, fieldManager); err != nil {
-		fmt.Println("installing ", unstr.GetName(), " in ", unstr.GetNamespace())
-		if k8sErrors.IsNotFound(err) {
-			time.Sleep(1 * time.Second)
-			return act.installObject(ctx, unstr, fieldManager)
+		err := act.kubeClient.Apply(ctx, unstr, fieldManager)
+		if err == nil {
+			progress.report(unstr, InstallApplied, nil)
+			return nil
 		}
-		return err
+		// The namespace or the custom resource definition of the object is not available yet.
+		if !k8sErrors.IsNotFound(err) {
+			progress.report(unstr, InstallFailed, err)
+			return err
+		}
+		if !waiting {
+			progress.report(unstr, InstallWaiting, nil)
+			waiting = true
+		}
+		time.Sleep(1 * time.Second)
 	}
-
-	return nil
 }
@@ -22,6 +22,7 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
@@ -38,6 +39,7 @@ import (
 	"gotest.tools/v3/assert"
 	appsv1 "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/types"
 )
 
@@ -181,6 +183,48 @@ func TestInstallAction_Install(t *testing.T) {
 				assert.Assert(t, cmp.Equal(vcsKeyAfter.Data, vcsKeyBefore.Data))
 			},
 		},
+		{
+			name: "DryRun",
+			project: testProject{
+				name:        "dryrun",
+				shard:       "dryrun",
+				isSecondary: false,
+			},
+			assertion: func(env projecttest.Environment, testProject testProject) {
+				defaultAssertion(t, env, testProject)
+			},
+			post: func(env projecttest.Environment, action project.InstallAction, testProject testProject) {
+				ctx := context.Background()
+				var output bytes.Buffer
+				err := action.Install(
+					ctx,
+					project.InstallOptions{
+						Branch:   branch,
+						Interval: intervalInSeconds,
+						Name:     "dryrun-preview",
+						Shard:    testProject.shard,
+						Url:      url,
+						DryRun:   true,
+						Output:   &output,
+					},
+				)
+				assert.NilError(t, err)
+
+				rendered := output.String()
+				assert.Assert(t, strings.Contains(rendered, "kind: CustomResourceDefinition"))
+				assert.Assert(t, strings.Contains(rendered, "kind: Deployment"))
+				assert.Assert(t, strings.Contains(rendered, "name: dryrun-preview"))
+				assert.Assert(t, strings.Contains(rendered, "name: "+vcs.SecretName("dryrun-preview")))
+
+				var gitOpsProject gitops.GitOpsProject
+				err = env.TestKubeClient.Get(
+					ctx,
+					types.NamespacedName{Name: "dryrun-preview", Namespace: project.ControllerNamespace},
+					&gitOpsProject,
+				)
+				assert.Assert(t, k8sErrors.IsNotFound(err))
+			},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -211,6 +255,7 @@ func TestInstallAction_Install(t *testing.T) {
 
 			action := project.NewInstallAction(kubeClient, client, testProject.TargetPath)
 
+			var applied []project.InstallProgress
 			err = action.Install(
 				ctx,
 				project.InstallOptions{
@@ -220,9 +265,20 @@ func TestInstallAction_Install(t *testing.T) {
 					Interval: intervalInSeconds,
 					Url:      url,
 					Token:    "aaaa",
+					Progress: func(progress project.InstallProgress) {
+						assert.Assert(t, progress.State != project.InstallFailed)
+						if progress.State == project.InstallApplied {
+							applied = append(applied, progress)
+						}
+					},
 				},
 			)
 			assert.NilError(t, err)
+			assert.Assert(t, len(applied) > 1)
+			last := applied[len(applied)-1]
+			assert.Equal(t, last.Index, last.Total)
+			assert.Equal(t, len(applied), last.Total)
+			assert.Equal(t, last.Kind, "Secret")
 
 			tc.assertion(env, tc.project)
 
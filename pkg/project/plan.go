@@ -0,0 +1,363 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package project
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	gitops "github.com/kharf/declcd/api/v1beta1"
+	"github.com/kharf/declcd/pkg/component"
+	"github.com/kharf/declcd/pkg/garbage"
+	"github.com/kharf/declcd/pkg/helm"
+	"github.com/kharf/declcd/pkg/inventory"
+	"github.com/kharf/declcd/pkg/kube"
+	"github.com/pmezard/go-difflib/difflib"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+)
+
+// ChangeAction is what a reconciliation would do with an object.
+type ChangeAction string
+
+const (
+	// ChangeCreate creates an object, which does not exist yet.
+	ChangeCreate ChangeAction = "Create"
+	// ChangeUpdate modifies an existing object.
+	ChangeUpdate ChangeAction = "Update"
+	// ChangeDelete deletes an object or uninstalls a Helm release, which is no longer declared.
+	ChangeDelete ChangeAction = "Delete"
+	// ChangeOrphan releases an object or Helm release, which is no longer declared, but stays in the cluster.
+	ChangeOrphan ChangeAction = "Orphan"
+)
+
+// Change is an object or Helm release, which a reconciliation would create, update, delete or orphan.
+type Change struct {
+	// Component is the id of the component declaring the object.
+	Component string
+
+	Action ChangeAction
+
+	APIVersion string
+
+	// Kind is HelmRelease for the removal of a whole release.
+	Kind string
+
+	Namespace string
+	Name      string
+
+	// Diff is a unified diff between the live object and the object the API server would persist.
+	// Empty for Helm releases and orphaned objects.
+	Diff string
+}
+
+// Plan is the structured result of a dry-run reconciliation.
+type Plan struct {
+	// CommitHash is the planned Git commit. Empty when planning a local project.
+	CommitHash string
+
+	// Changes are ordered like a reconciliation would apply them, followed by all removals.
+	Changes []Change
+}
+
+// Planner computes the changes a reconciliation would apply without applying them.
+// Desired objects are compared against the result of a server-side dry-run apply.
+type Planner struct {
+	Client          *kube.DynamicClient
+	ChartReconciler *helm.ChartReconciler
+
+	// FieldManager is used for the dry-run unless a component declares its own.
+	FieldManager string
+}
+
+// Plan compares the topologically sorted instances with the cluster and adds the given removals,
+// which are computed by [garbage.Collector.Dangling].
+// Hooks are recreated on every reconciliation and not part of the plan.
+// Objects, which cannot be planned, are reported in the returned error, while the plan contains all other changes.
+func (planner Planner) Plan(
+	ctx context.Context,
+	instances []component.Instance,
+	removals []garbage.Removal,
+) (*Plan, error) {
+	var errs []error
+	changes := make([]Change, 0)
+	for _, instance := range instances {
+		var objects []unstructured.Unstructured
+		fieldManager := planner.FieldManager
+		switch instance := instance.(type) {
+		case *component.Manifest:
+			objects = []unstructured.Unstructured{instance.Content}
+			if instance.FieldManager != "" {
+				fieldManager = instance.FieldManager
+			}
+		case *helm.ReleaseComponent:
+			rendered, err := planner.ChartReconciler.Render(ctx, instance)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", instance.GetID(), err))
+				continue
+			}
+			objects = rendered
+			if instance.Content.FieldManager != "" {
+				fieldManager = instance.Content.FieldManager
+			}
+		default:
+			continue
+		}
+
+		for i := range objects {
+			object := &objects[i]
+			change, err := planner.planObject(ctx, object, fieldManager)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %s %s/%s: %w",
+					instance.GetID(),
+					object.GetKind(),
+					object.GetNamespace(),
+					object.GetName(),
+					err,
+				))
+				continue
+			}
+			if change == nil {
+				continue
+			}
+			change.Component = instance.GetID()
+			changes = append(changes, *change)
+		}
+	}
+
+	for _, removal := range removals {
+		change, err := planner.planRemoval(ctx, removal)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", removal.Item.GetID(), err))
+			continue
+		}
+		changes = append(changes, *change)
+	}
+
+	return &Plan{
+		Changes: changes,
+	}, errors.Join(errs...)
+}
+
+// planObject returns nil, if the apply would not change anything.
+func (planner Planner) planObject(
+	ctx context.Context,
+	desired *unstructured.Unstructured,
+	fieldManager string,
+) (*Change, error) {
+	var live *unstructured.Unstructured
+	if desired.GetName() != "" {
+		obj, err := planner.Client.Get(ctx, desired)
+		if err != nil && !k8sErrors.IsNotFound(err) {
+			return nil, err
+		}
+		live = obj
+	}
+
+	dryRun := desired.DeepCopy()
+	if err := planner.Client.Apply(
+		ctx,
+		dryRun,
+		fieldManager,
+		kube.Force(true),
+		kube.DryRun(true),
+	); err != nil {
+		return nil, err
+	}
+
+	diff, err := diffObjects(live, dryRun)
+	if err != nil {
+		return nil, err
+	}
+	if diff == "" {
+		return nil, nil
+	}
+	action := ChangeUpdate
+	if live == nil {
+		action = ChangeCreate
+	}
+	return &Change{
+		Action:     action,
+		APIVersion: dryRun.GetAPIVersion(),
+		Kind:       dryRun.GetKind(),
+		Namespace:  dryRun.GetNamespace(),
+		Name:       dryRun.GetName(),
+		Diff:       diff,
+	}, nil
+}
+
+func (planner Planner) planRemoval(ctx context.Context, removal garbage.Removal) (*Change, error) {
+	action := ChangeDelete
+	if removal.Orphan {
+		action = ChangeOrphan
+	}
+	change := &Change{
+		Component: removal.Item.GetID(),
+		Action:    action,
+		Namespace: removal.Item.GetNamespace(),
+		Name:      removal.Item.GetName(),
+	}
+
+	manifest, ok := removal.Item.(*inventory.ManifestItem)
+	if !ok {
+		change.Kind = "HelmRelease"
+		return change, nil
+	}
+	change.APIVersion = manifest.TypeMeta.APIVersion
+	change.Kind = manifest.TypeMeta.Kind
+	if removal.Orphan {
+		return change, nil
+	}
+
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion(manifest.TypeMeta.APIVersion)
+	obj.SetKind(manifest.TypeMeta.Kind)
+	obj.SetNamespace(manifest.GetNamespace())
+	obj.SetName(manifest.GetName())
+	live, err := planner.Client.Get(ctx, obj)
+	if err != nil && !k8sErrors.IsNotFound(err) {
+		return nil, err
+	}
+	diff, err := diffObjects(live, nil)
+	if err != nil {
+		return nil, err
+	}
+	change.Diff = diff
+	return change, nil
+}
+
+// diffObjects returns a unified diff between the live and the desired object,
+// or an empty string if they do not differ.
+func diffObjects(live *unstructured.Unstructured, desired *unstructured.Unstructured) (string, error) {
+	from, err := diffableYAML(live)
+	if err != nil {
+		return "", err
+	}
+	to, err := diffableYAML(desired)
+	if err != nil {
+		return "", err
+	}
+
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(from),
+		B:        difflib.SplitLines(to),
+		FromFile: "live",
+		ToFile:   "desired",
+		Context:  3,
+	})
+}
+
+// diffableYAML encodes the object without the metadata maintained by the API server.
+func diffableYAML(obj *unstructured.Unstructured) (string, error) {
+	if obj == nil {
+		return "", nil
+	}
+	obj = obj.DeepCopy()
+	for _, field := range []string{"managedFields", "resourceVersion", "uid", "generation", "creationTimestamp"} {
+		unstructured.RemoveNestedField(obj.Object, "metadata", field)
+	}
+	content, err := yaml.Marshal(obj.Object)
+	if err != nil {
+		return "", err
+	}
+	return string(content), nil
+}
+
+// Plan loads the GitOps Git repository of the project like [Reconciler.Reconcile],
+// but only reports the changes a reconciliation would apply, including the removal of components no longer declared.
+// Suspension, required checks and unchanged revisions are not taken into account.
+func (reconciler *Reconciler) Plan(
+	ctx context.Context,
+	gProject gitops.GitOpsProject,
+) (*Plan, error) {
+	log := reconciler.Log.WithValues(
+		"project",
+		gProject.GetName(),
+		"repository",
+		gProject.Spec.URL,
+	)
+
+	cfg := reconciler.restConfig(gProject)
+	kubeDynamicClient, err := kube.NewDynamicClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	projectUID := string(gProject.GetUID())
+	// Planning must not move the working tree of a running reconciliation.
+	repositoryDir := filepath.Join(os.TempDir(), "declcd", "plan", projectUID)
+	commitHash, err := reconciler.checkout(ctx, log, gProject, repositoryDir)
+	if err != nil {
+		return nil, err
+	}
+
+	dependencyGraph, err := reconciler.load(gProject, repositoryDir)
+	if err != nil {
+		return nil, err
+	}
+	componentInstances, err := dependencyGraph.TopologicalSort()
+	if err != nil {
+		return nil, err
+	}
+	if gProject.Spec.TargetNamespace != "" {
+		componentInstances = retarget(componentInstances, gProject.Spec.TargetNamespace)
+	}
+	componentInstances, _, err = deferPromotions(componentInstances, gProject.Spec.Promotions, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	inventoryInstance := reconciler.inventoryInstance(projectUID)
+	garbageCollector := garbage.Collector{
+		Log:               log,
+		Client:            kubeDynamicClient,
+		KubeConfig:        cfg,
+		InventoryInstance: inventoryInstance,
+		FieldManager:      reconciler.FieldManager,
+		Project:           gProject.GetName(),
+	}
+	removals, err := garbageCollector.Dangling(dependencyGraph)
+	if err != nil {
+		return nil, err
+	}
+
+	planner := Planner{
+		Client:       kubeDynamicClient,
+		FieldManager: reconciler.FieldManager,
+		ChartReconciler: &helm.ChartReconciler{
+			KubeConfig:                 cfg,
+			Client:                     kubeDynamicClient,
+			FieldManager:               reconciler.FieldManager,
+			InventoryInstance:          inventoryInstance,
+			InsecureSkipTLSverify:      reconciler.InsecureSkipTLSverify,
+			InsecureSkipTLSverifyHosts: reconciler.InsecureSkipTLSverifyHosts,
+			PlainHTTP:                  reconciler.PlainHTTP,
+			PlainHTTPHosts:             reconciler.PlainHTTPHosts,
+			Guardrails:                 guardrails(gProject.Spec.Guardrails),
+			Registries:                 reconciler.RegistryClientPool,
+			Log:                        log,
+		},
+	}
+	plan, err := planner.Plan(ctx, componentInstances, removals)
+	if plan != nil {
+		plan.CommitHash = commitHash
+	}
+	return plan, err
+}
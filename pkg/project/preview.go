@@ -0,0 +1,126 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package project
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	gitops "github.com/kharf/declcd/api/v1beta1"
+	"github.com/kharf/declcd/pkg/component"
+	"github.com/kharf/declcd/pkg/garbage"
+	"github.com/kharf/declcd/pkg/helm"
+	"github.com/kharf/declcd/pkg/kube"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const (
+	// PreviewOfLabel is set on preview projects to the name of the project they preview.
+	// Preview projects authenticate with the repository auth Secret of that project.
+	PreviewOfLabel = "declcd/preview-of"
+	// PreviewBranchAnnotation is set on preview projects to the previewed branch.
+	PreviewBranchAnnotation = "declcd/preview-branch"
+)
+
+// authProjectName returns the name of the project, whose repository auth Secret is used.
+func authProjectName(gProject gitops.GitOpsProject) string {
+	if previewOf := gProject.GetLabels()[PreviewOfLabel]; previewOf != "" {
+		return previewOf
+	}
+	return gProject.GetName()
+}
+
+// retarget moves all namespaced objects and Helm releases into the target namespace.
+// Declared Namespaces are replaced by the target namespace, which is declared only once.
+// All other cluster scoped objects are dropped, because they would be shared with other projects.
+func retarget(instances []component.Instance, targetNamespace string) []component.Instance {
+	retargeted := make([]component.Instance, 0, len(instances))
+	namespaceDeclared := false
+	for _, instance := range instances {
+		var content *unstructured.Unstructured
+		switch instance := instance.(type) {
+		case *component.Manifest:
+			content = &instance.Content
+		case *component.Hook:
+			content = &instance.Content
+		case *helm.ReleaseComponent:
+			instance.Content.Namespace = targetNamespace
+			retargeted = append(retargeted, instance)
+			continue
+		default:
+			retargeted = append(retargeted, instance)
+			continue
+		}
+
+		switch {
+		case content.GetAPIVersion() == "v1" && content.GetKind() == "Namespace":
+			if namespaceDeclared {
+				continue
+			}
+			namespaceDeclared = true
+			content.SetName(targetNamespace)
+		case content.GetNamespace() == "":
+			continue
+		default:
+			content.SetNamespace(targetNamespace)
+		}
+		retargeted = append(retargeted, instance)
+	}
+	return retargeted
+}
+
+// Teardown deletes all objects and Helm releases in the inventory of a project,
+// e.g. of a preview project, whose branch has been deleted.
+// The inventory and the local clone of the repository are removed afterwards.
+func (reconciler *Reconciler) Teardown(ctx context.Context, gProject gitops.GitOpsProject) error {
+	log := reconciler.Log.WithValues("project", gProject.GetName())
+
+	cfg := reconciler.restConfig(gProject)
+
+	kubeDynamicClient, err := kube.NewDynamicClient(cfg)
+	if err != nil {
+		return err
+	}
+
+	projectUID := string(gProject.GetUID())
+	inventoryInstance := reconciler.inventoryInstance(projectUID)
+	garbageCollector := garbage.Collector{
+		Log:               log,
+		Client:            kubeDynamicClient,
+		KubeConfig:        cfg,
+		InventoryInstance: inventoryInstance,
+		FieldManager:      reconciler.FieldManager,
+		WorkerPoolSize:    reconciler.WorkerPoolSize,
+		Project:           gProject.GetName(),
+		PrunedCounter:     reconciler.PrunedCounter,
+		OnEvent:           reconciler.OnEvent,
+	}
+
+	// Everything in the inventory is collected, as nothing is declared.
+	emptyGraph := component.NewDependencyGraph()
+	if err := garbageCollector.Collect(ctx, &emptyGraph); err != nil {
+		return err
+	}
+
+	log.Info("Tore down project")
+	if err := inventoryInstance.Clear(); err != nil {
+		return err
+	}
+	if err := os.RemoveAll(filepath.Join(os.TempDir(), "declcd", "plan", projectUID)); err != nil {
+		return err
+	}
+	return os.RemoveAll(filepath.Join(os.TempDir(), "declcd", projectUID))
+}
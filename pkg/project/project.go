@@ -15,6 +15,7 @@
 package project
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io/fs"
@@ -59,6 +60,7 @@ func (manager *Manager) Load(
 	if _, err := os.Stat(projectPath); errors.Is(err, fs.ErrNotExist) {
 		return nil, err
 	}
+	dependencySources := component.NewDependencySources()
 	resultChan := make(chan instanceResult)
 	go func() {
 		defer close(resultChan)
@@ -98,6 +100,7 @@ func (manager *Manager) Load(
 						instances, err := manager.componentBuilder.Build(
 							component.WithProjectRoot(projectPath),
 							component.WithPackagePath(relativePath),
+							component.WithDependencySources(dependencySources),
 						)
 						if err != nil {
 							return err
@@ -131,5 +134,38 @@ func (manager *Manager) Load(
 			return nil, fmt.Errorf("%w: %w", ErrLoadProject, err)
 		}
 	}
+	if err := dag.Validate(dependencySources); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrLoadProject, err)
+	}
+	return &dag, nil
+}
+
+// LoadArtifact reads the components of a project from an artifact produced by a former build
+// and returns them as a directed acyclic dependency graph.
+// SOPS encrypted components of the artifact are decrypted.
+// No CUE is compiled.
+func (manager *Manager) LoadArtifact(
+	artifactPath string,
+) (*component.DependencyGraph, error) {
+	content, err := os.ReadFile(artifactPath)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrLoadProject, err)
+	}
+	var result component.BuildResult
+	if err := json.Unmarshal(content, &result); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrLoadProject, err)
+	}
+	for _, instance := range result.Instances {
+		if err := manager.componentBuilder.Decrypt(instance); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrLoadProject, err)
+		}
+	}
+	dag := component.NewDependencyGraph()
+	if err := dag.Insert(result.Instances...); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrLoadProject, err)
+	}
+	if err := dag.Validate(nil); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrLoadProject, err)
+	}
 	return &dag, nil
 }
@@ -15,6 +15,7 @@
 package project_test
 
 import (
+	"encoding/json"
 	"io"
 	"os"
 	"path/filepath"
@@ -105,6 +106,49 @@ func TestManager_Load(t *testing.T) {
 	assert.Assert(t, prometheusRelease != nil)
 	subcomponent := dag.Get("mysubcomponent_prometheus_apps_Deployment")
 	assert.Assert(t, subcomponent != nil)
+
+	instances, err := dag.TopologicalSort()
+	assert.NilError(t, err)
+	artifact, err := json.Marshal(component.BuildResult{Instances: instances})
+	assert.NilError(t, err)
+	artifactPath := filepath.Join(t.TempDir(), "components.json")
+	err = os.WriteFile(artifactPath, artifact, 0666)
+	assert.NilError(t, err)
+
+	artifactDag, err := pm.LoadArtifact(artifactPath)
+	assert.NilError(t, err)
+	for _, instance := range instances {
+		loaded := artifactDag.Get(instance.GetID())
+		assert.Assert(t, loaded != nil)
+		assert.DeepEqual(t, loaded.GetDependencies(), instance.GetDependencies())
+	}
+
+	unknownDir := filepath.Join(root, "infra", "unknown")
+	err = os.MkdirAll(unknownDir, 0700)
+	assert.NilError(t, err)
+	err = os.WriteFile(filepath.Join(unknownDir, "component.cue"), []byte(`package unknown
+
+import (
+	"github.com/kharf/declcd/schema/component"
+)
+
+ns: component.#Manifest & {
+	dependencies: [
+		"certmanager___Namespace",
+	]
+	content: {
+		apiVersion: "v1"
+		kind:       "Namespace"
+		metadata: name: "unknown"
+	}
+}
+`), 0600)
+	assert.NilError(t, err)
+
+	_, err = pm.Load(root)
+	assert.ErrorIs(t, err, component.ErrUnknownComponentID)
+	assert.ErrorContains(t, err, "infra/unknown/component.cue:9:")
+	assert.ErrorContains(t, err, "unknown___Namespace depends on certmanager___Namespace")
 }
 
 var dagResult *component.DependencyGraph
@@ -0,0 +1,115 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package project
+
+import (
+	"time"
+
+	gitops "github.com/kharf/declcd/api/v1beta1"
+	"github.com/kharf/declcd/pkg/component"
+	"github.com/kharf/declcd/pkg/helm"
+	"github.com/kharf/declcd/pkg/promotion"
+)
+
+// PendingPromotion is a committed version of a component, which is not applied yet.
+type PendingPromotion struct {
+	Component string
+
+	// Digest identifies the version, which has to be promoted.
+	Digest string
+
+	// PromoteAfter is the time the version is applied. Nil for versions, which have to be promoted manually.
+	PromoteAfter *time.Time
+}
+
+// deferPromotions removes all component versions from the topologically sorted instances, which wait for their promotion.
+// Removed components are still part of the dependency graph, so that the previously applied version is not collected.
+// The objects of a Kustomization or a ManifestSequence are deferred and promoted together with it.
+func deferPromotions(
+	instances []component.Instance,
+	promotions []gitops.GitOpsProjectPromotion,
+	now time.Time,
+) ([]component.Instance, []PendingPromotion, error) {
+	promoted := make(map[string]string, len(promotions))
+	for _, projectPromotion := range promotions {
+		promoted[projectPromotion.Component] = projectPromotion.Digest
+	}
+	byID := make(map[string]component.Instance, len(instances))
+	for _, instance := range instances {
+		byID[instance.GetID()] = instance
+	}
+
+	deferred := make(map[string]struct{})
+	pending := make([]PendingPromotion, 0)
+	for _, instance := range instances {
+		policy := promotionPolicy(instance)
+		if policy == (promotion.Policy{}) {
+			continue
+		}
+		version := []component.Instance{instance}
+		var objectIDs []string
+		switch instance := instance.(type) {
+		case *component.Kustomization:
+			objectIDs = instance.Objects
+		case *component.ManifestSequence:
+			objectIDs = instance.Objects
+		}
+		for _, objectID := range objectIDs {
+			if object, found := byID[objectID]; found {
+				version = append(version, object)
+			}
+		}
+		digest, err := promotion.Digest(version)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !policy.Pending(now, promoted[instance.GetID()] == digest) {
+			continue
+		}
+		pendingPromotion := PendingPromotion{
+			Component: instance.GetID(),
+			Digest:    digest,
+		}
+		if !policy.Manual {
+			pendingPromotion.PromoteAfter = policy.After
+		}
+		pending = append(pending, pendingPromotion)
+		for _, deferredInstance := range version {
+			deferred[deferredInstance.GetID()] = struct{}{}
+		}
+	}
+
+	applicable := make([]component.Instance, 0, len(instances)-len(deferred))
+	for _, instance := range instances {
+		if _, found := deferred[instance.GetID()]; !found {
+			applicable = append(applicable, instance)
+		}
+	}
+	return applicable, pending, nil
+}
+
+func promotionPolicy(instance component.Instance) promotion.Policy {
+	switch instance := instance.(type) {
+	case *component.Manifest:
+		return instance.Promotion
+	case *component.Kustomization:
+		return instance.Promotion
+	case *component.ManifestSequence:
+		return instance.Promotion
+	case *helm.ReleaseComponent:
+		return instance.Promotion
+	}
+	return promotion.Policy{}
+}
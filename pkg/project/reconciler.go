@@ -16,22 +16,44 @@ package project
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/go-logr/logr"
 	gitops "github.com/kharf/declcd/api/v1beta1"
+	"github.com/kharf/declcd/pkg/audit"
+	"github.com/kharf/declcd/pkg/bundle"
 	"github.com/kharf/declcd/pkg/component"
 	"github.com/kharf/declcd/pkg/garbage"
+	"github.com/kharf/declcd/pkg/health"
 	"github.com/kharf/declcd/pkg/helm"
 	"github.com/kharf/declcd/pkg/inventory"
 	"github.com/kharf/declcd/pkg/kube"
+	"github.com/kharf/declcd/pkg/promotion"
 	"github.com/kharf/declcd/pkg/vcs"
+	"github.com/opencontainers/go-digest"
+	"github.com/prometheus/client_golang/prometheus"
 	"golang.org/x/sync/errgroup"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/rest"
 )
 
+var (
+	ErrDependencyFailed = errors.New("Dependency failed")
+	ErrNamespaceFailed  = errors.New("Namespace failed")
+	// ErrInvalidArtifactPath occurs when the artifact path points outside of the gitops repository.
+	ErrInvalidArtifactPath = errors.New("Invalid artifact path")
+	// ErrRequiredCheckUnsupported occurs when a project requires a check, but the reconciler is unable to read checks.
+	ErrRequiredCheckUnsupported = errors.New("Required checks are not supported")
+)
+
 // Reconciler clones, pulls and loads a GitOps Git repository containing the desired cluster state,
 // translates cue definitions to either Kubernetes unstructurd objects or Helm Releases and applies/installs them on a Kubernetes cluster.
 // Every run stores objects in the inventory and collects dangling objects.
@@ -59,8 +81,106 @@ type Reconciler struct {
 	// certificate chains and host names.
 	InsecureSkipTLSverify bool
 
+	// InsecureSkipTLSverifyHosts are the Helm registry and repository hosts, whose certificates are not verified.
+	InsecureSkipTLSverifyHosts []string
+
 	// Force http for Helm registries.
 	PlainHTTP bool
+
+	// PlainHTTPHosts are the Helm registry hosts reached via http.
+	PlainHTTPHosts []string
+
+	// RegistryClientPool optionally shares authenticated Helm registry clients across reconciliations.
+	RegistryClientPool *helm.RegistryClientPool
+
+	// HelmCRDLocks optionally serializes installs and upgrades of Helm releases, which bundle the same CRDs,
+	// instead of letting them write the shared CRDs concurrently.
+	HelmCRDLocks *helm.CRDLocks
+
+	// AuditPublisher optionally pushes the rendered cluster state of every reconciled revision as an OCI artifact.
+	AuditPublisher *audit.Publisher
+
+	// SkipUnchangedRevision skips applying components, when the pulled commit equals the last reconciled commit.
+	// Drift is then only corrected on new commits.
+	SkipUnchangedRevision bool
+
+	// PruneDryRun only reports the inventory items, which are no longer declared, as pending prunes instead of removing them,
+	// and additionally reports the cluster objects labeled as managed by declcd, which are absent from the inventory.
+	PruneDryRun bool
+
+	// OnStage is optionally called whenever the reconciliation enters a new stage.
+	OnStage func(stage Stage)
+
+	// OnEvent is optionally called for significant changes to the cluster, e.g. to emit Kubernetes Events on the project:
+	// applied components, pruned objects and upgraded Helm releases.
+	// The event type is either Normal or Warning.
+	OnEvent func(eventType string, reason string, message string)
+
+	// HelmOperationHistogram optionally observes the duration of Helm release operations.
+	HelmOperationHistogram *prometheus.HistogramVec
+
+	// PrunedCounter optionally counts the objects and Helm releases deleted by garbage collection.
+	PrunedCounter *prometheus.CounterVec
+
+	// HelmPullHistogram optionally observes the duration of Helm chart pulls.
+	HelmPullHistogram *prometheus.HistogramVec
+
+	// HelmMaxHistory limits the stored versions per Helm release. Defaults to [helm.DefaultMaxHistory].
+	HelmMaxHistory int
+
+	// InventoryEncryptionKey optionally encrypts the inventory at rest.
+	// It does not cover the repository cache, which go-git and the CUE builder need as plain files.
+	InventoryEncryptionKey []byte
+
+	// InventoryRoot optionally overrides the directory holding the inventories of all projects,
+	// which is the /inventory volume by default.
+	InventoryRoot string
+
+	// InventoryBackend optionally stores the inventory of a project somewhere else than in InventoryRoot,
+	// e.g. in Secrets with [inventory.SecretBackend].
+	InventoryBackend func(projectUID string) inventory.Backend
+
+	// ReadRequiredCheck reads the state of the required check of a project for a commit from the Git provider.
+	// It is required for projects declaring a required check.
+	ReadRequiredCheck func(ctx context.Context, gProject gitops.GitOpsProject, commit string) (vcs.CheckState, error)
+}
+
+func (reconciler *Reconciler) inventoryInstance(projectUID string) *inventory.Instance {
+	inventoryRoot := reconciler.InventoryRoot
+	if inventoryRoot == "" {
+		// /inventory is mounted as volume.
+		inventoryRoot = "/inventory"
+	}
+	instance := &inventory.Instance{
+		Path:          filepath.Join(inventoryRoot, projectUID),
+		EncryptionKey: reconciler.InventoryEncryptionKey,
+	}
+	if reconciler.InventoryBackend != nil {
+		instance.Backend = reconciler.InventoryBackend(projectUID)
+	}
+	return instance
+}
+
+// Stage is a step of the reconciliation pipeline.
+type Stage string
+
+const (
+	// StageCloning clones or pulls the gitops repository.
+	StageCloning Stage = "Cloning"
+	// StageBuilding compiles the project and resolves the component dependency graph.
+	StageBuilding Stage = "Building"
+	// StagePruning collects components, which have been removed from the gitops repository.
+	StagePruning Stage = "Pruning"
+	// StageApplying pulls charts and applies hooks and components.
+	StageApplying Stage = "Applying"
+	// StageIdle is no stage of the pipeline, but the state between reconciliations.
+	StageIdle Stage = "Idle"
+)
+
+func (reconciler *Reconciler) enterStage(stage Stage) {
+	if reconciler.OnStage != nil {
+		reconciler.OnStage(stage)
+	}
 }
 
 // ReconcileResult reports the outcome and metadata of a reconciliation.
@@ -68,8 +188,87 @@ type ReconcileResult struct {
 	// Reports whether the GitOpsProject was flagged as suspended.
 	Suspended bool
 
+	// Reports whether applying was skipped, because the commit did not change since the last reconciliation.
+	Skipped bool
+
+	// Reports whether applying was skipped, because the required check of the commit did not succeed.
+	Unverified bool
+
+	// CheckState is the state of the required check of an unverified commit.
+	CheckState vcs.CheckState
+
 	// The hash of the reconciled Git Commit.
 	CommitHash string
+
+	// Namespaces reports the outcome of every namespace targeted by the components, sorted by name.
+	// Cluster scoped components are reported under the empty namespace.
+	Namespaces []NamespaceResult
+
+	// Components reports the outcome of every component except hooks, sorted by id.
+	// Components deferred by their promotion policy are not reported.
+	Components []ComponentResult
+
+	// PendingPromotions are the component versions, which have been deferred by their promotion policy.
+	PendingPromotions []PendingPromotion
+
+	// Health reports the readiness of all applied objects right after applying them.
+	Health health.Report
+
+	// InventorySize is the number of objects and Helm releases managed by the project after the reconciliation.
+	InventorySize int
+
+	// InventoryKinds counts the objects and Helm releases managed by the project after the reconciliation by their kind.
+	// Helm releases are counted as kind HelmRelease.
+	InventoryKinds map[string]int
+
+	// PendingPrunes are the ids of inventory items, which are no longer declared,
+	// but are still managed by the project after the reconciliation, sorted by id.
+	PendingPrunes []string
+
+	// UnmanagedObjects are the cluster objects labeled as managed by declcd, which are absent from the inventory.
+	// They are only reported on a prune dry-run.
+	UnmanagedObjects []garbage.UnmanagedObject
+
+	// Notifications are the Notification components declared by the project.
+	// They are not applied, but registered by the controller.
+	Notifications []*component.Notification
+}
+
+// NamespaceResult reports the outcome of applying all components targeting a namespace.
+type NamespaceResult struct {
+	Namespace string
+
+	// Err is the first error encountered while applying the components of the namespace.
+	// Nil, when all components were applied.
+	Err error
+}
+
+// ComponentResult reports the outcome of reconciling a component.
+type ComponentResult struct {
+	ID string
+
+	// Type is the component type, e.g. Manifest, HelmRelease or the type of a custom component.
+	Type string
+
+	// AppliedAt is the time the component was applied. Zero, when it was not applied.
+	AppliedAt time.Time
+
+	// Digest identifies the declared content of an applied component.
+	Digest string
+
+	// Err is the reason the component was not applied.
+	Err error
+}
+
+// Failed returns the namespaces, which could not be applied completely.
+func (result *ReconcileResult) Failed() []NamespaceResult {
+	failed := make([]NamespaceResult, 0)
+	for _, namespace := range result.Namespaces {
+		if namespace.Err != nil {
+			failed = append(failed, namespace)
+		}
+	}
+	return failed
 }
 
 // Reconcile clones, pulls and loads a GitOps Git repository containing the desired cluster state,
@@ -84,16 +283,7 @@ func (reconciler *Reconciler) Reconcile(
 	}
 	log := reconciler.Log
 
-	cfg := reconciler.KubeConfig
-	if gProject.Spec.ServiceAccountName != "" {
-		cfg.Impersonate = rest.ImpersonationConfig{
-			UserName: fmt.Sprintf(
-				"system:serviceaccount:%s:%s",
-				gProject.Namespace,
-				gProject.Spec.ServiceAccountName,
-			),
-		}
-	}
+	cfg := reconciler.restConfig(gProject)
 
 	log = log.WithValues(
 		"project",
@@ -114,21 +304,40 @@ func (reconciler *Reconciler) Reconcile(
 	}
 
 	projectUID := string(gProject.GetUID())
+	// The repository cache is kept in plaintext in the temporary directory of the container, never on the inventory volume.
 	repositoryDir := filepath.Join(os.TempDir(), "declcd", projectUID)
 
-	inventoryInstance := &inventory.Instance{
-		// /inventory is mounted as volume.
-		Path: filepath.Join("/inventory", projectUID),
+	inventoryInstance := reconciler.inventoryInstance(projectUID)
+
+	suppressions := make(kube.SuppressionRules, 0, len(gProject.Spec.Suppressions))
+	for _, suppression := range gProject.Spec.Suppressions {
+		suppressions = append(suppressions, kube.SuppressionRule{
+			APIGroup: suppression.APIGroup,
+			Kind:     suppression.Kind,
+			Paths:    suppression.Paths,
+		})
 	}
 
+	projectGuardrails := guardrails(gProject.Spec.Guardrails)
 	chartReconciler := helm.ChartReconciler{
-		KubeConfig:            cfg,
-		Client:                kubeDynamicClient,
-		FieldManager:          reconciler.FieldManager,
-		InventoryInstance:     inventoryInstance,
-		InsecureSkipTLSverify: reconciler.InsecureSkipTLSverify,
-		PlainHTTP:             reconciler.PlainHTTP,
-		Log:                   log,
+		KubeConfig:                 cfg,
+		Client:                     kubeDynamicClient,
+		FieldManager:               reconciler.FieldManager,
+		InventoryInstance:          inventoryInstance,
+		InsecureSkipTLSverify:      reconciler.InsecureSkipTLSverify,
+		InsecureSkipTLSverifyHosts: reconciler.InsecureSkipTLSverifyHosts,
+		PlainHTTP:                  reconciler.PlainHTTP,
+		PlainHTTPHosts:             reconciler.PlainHTTPHosts,
+		Suppressions:               suppressions,
+		Guardrails:                 projectGuardrails,
+		Registries:                 reconciler.RegistryClientPool,
+		CRDLocks:                   reconciler.HelmCRDLocks,
+		PullConcurrency:            reconciler.WorkerPoolSize,
+		OperationHistogram:         reconciler.HelmOperationHistogram,
+		PullHistogram:              reconciler.HelmPullHistogram,
+		MaxHistory:                 reconciler.HelmMaxHistory,
+		OnEvent:                    reconciler.OnEvent,
+		Log:                        log,
 	}
 
 	garbageCollector := garbage.Collector{
@@ -136,33 +345,62 @@ func (reconciler *Reconciler) Reconcile(
 		Client:            kubeDynamicClient,
 		KubeConfig:        cfg,
 		InventoryInstance: inventoryInstance,
+		FieldManager:      reconciler.FieldManager,
 		WorkerPoolSize:    reconciler.WorkerPoolSize,
+		Project:           gProject.GetName(),
+		PrunedCounter:     reconciler.PrunedCounter,
+		OnEvent:           reconciler.OnEvent,
 	}
 
-	repository, err := reconciler.RepositoryManager.Load(
-		ctx,
-		gProject.Spec.URL,
-		repositoryDir,
-		gProject.Name,
-	)
+	reconciler.enterStage(StageCloning)
+	commitHash, err := reconciler.checkout(ctx, log, gProject, repositoryDir)
 	if err != nil {
-		log.Error(
-			err,
-			"Unable to load gitops project repository",
-		)
 		return nil, err
 	}
 
-	commitHash, err := repository.Pull()
-	if err != nil {
-		log.Error(
-			err,
-			"Unable to pull gitops project repository",
-		)
-		return nil, err
+	// The applied commit has already been verified.
+	if gProject.Spec.RequiredCheck != nil && commitHash != gProject.Status.Revision.CommitHash {
+		if reconciler.ReadRequiredCheck == nil {
+			return nil, ErrRequiredCheckUnsupported
+		}
+		state, err := reconciler.ReadRequiredCheck(ctx, gProject, commitHash)
+		if err != nil {
+			log.Error(
+				err,
+				"Unable to read required check",
+				"check",
+				gProject.Spec.RequiredCheck.Name,
+			)
+			return nil, err
+		}
+		if state != vcs.CheckSucceeded {
+			log.Info(
+				"Skipping unverified revision",
+				"commit", commitHash,
+				"check", gProject.Spec.RequiredCheck.Name,
+				"state", state,
+			)
+			return &ReconcileResult{
+				Unverified: true,
+				CommitHash: commitHash,
+				CheckState: state,
+			}, nil
+		}
+	}
+
+	// Pending promotions may be due without a new commit.
+	if reconciler.SkipUnchangedRevision &&
+		commitHash == gProject.Status.Revision.CommitHash &&
+		len(gProject.Status.PendingPromotions) == 0 {
+		log.V(1).Info("Skipping unchanged revision", "commit", commitHash)
+		return &ReconcileResult{
+			Skipped:    true,
+			CommitHash: commitHash,
+		}, nil
 	}
 
-	dependencyGraph, err := reconciler.ProjectManager.Load(repositoryDir)
+	reconciler.enterStage(StageBuilding)
+	dependencyGraph, err := reconciler.load(gProject, repositoryDir)
 	if err != nil {
 		log.Error(
 			err,
@@ -179,10 +417,38 @@ func (reconciler *Reconciler) Reconcile(
 		)
 		return nil, err
 	}
+	if gProject.Spec.TargetNamespace != "" {
+		componentInstances = retarget(componentInstances, gProject.Spec.TargetNamespace)
+	}
+	notifications, componentInstances := partitionNotifications(componentInstances)
+
+	reconciler.enterStage(StagePruning)
+	if reconciler.PruneDryRun {
+		log.V(1).Info("Skipping garbage collection on a prune dry-run")
+	} else if err := garbageCollector.Collect(ctx, dependencyGraph); err != nil {
+		return nil, err
+	}
 
-	if err := garbageCollector.Collect(ctx, dependencyGraph); err != nil {
+	componentInstances, pendingPromotions, err := deferPromotions(
+		componentInstances,
+		gProject.Spec.Promotions,
+		time.Now(),
+	)
+	if err != nil {
 		return nil, err
 	}
+	for _, pendingPromotion := range pendingPromotions {
+		log.Info("Deferring component until its promotion", "component", pendingPromotion.Component)
+	}
+
+	reconciler.enterStage(StageApplying)
+	charts := make([]helm.Chart, 0)
+	for _, instance := range componentInstances {
+		if release, ok := instance.(*helm.ReleaseComponent); ok {
+			charts = append(charts, release.Content.Chart)
+		}
+	}
+	chartReconciler.Prefetch(ctx, charts)
 
 	componentReconciler := component.Reconciler{
 		Log:               log,
@@ -190,49 +456,783 @@ func (reconciler *Reconciler) Reconcile(
 		ChartReconciler:   chartReconciler,
 		InventoryInstance: inventoryInstance,
 		FieldManager:      reconciler.FieldManager,
+		Registry:          reconciler.ComponentBuilder.Registry,
+		Suppressions:      suppressions,
+		Guardrails:        projectGuardrails,
+		FieldValidation:   kube.FieldValidation(gProject.Spec.FieldValidation),
+		Impersonator:      reconciler.impersonator(gProject),
+		Project:           gProject.GetName(),
 	}
 
-	if err := reconciler.reconcileComponents(ctx, componentReconciler, componentInstances); err != nil {
+	preApplyHooks, mainInstances, postApplyHooks := partitionHooks(componentInstances)
+
+	if err := reconciler.reconcileHooks(ctx, log, componentReconciler, preApplyHooks, nil, nil); err != nil {
 		log.Error(
 			err,
-			"Unable to reconcile components",
+			"Unable to run pre-apply hooks",
 		)
 		return nil, err
 	}
 
+	namespaceResults, componentResults := reconciler.reconcileComponents(
+		ctx,
+		componentReconciler,
+		mainInstances,
+		reconciler.applyConcurrency(gProject),
+		installedObjects(chartReconciler),
+	)
+	reconciler.recordApplied(gProject.Status.Components, componentResults, commitHash)
+	for _, namespaceResult := range namespaceResults {
+		if namespaceResult.Err != nil {
+			log.Error(
+				namespaceResult.Err,
+				"Unable to reconcile components",
+				"namespace",
+				namespaceResult.Namespace,
+			)
+		}
+	}
+
+	if err := reconciler.reconcileHooks(
+		ctx,
+		log,
+		componentReconciler,
+		postApplyHooks,
+		namespaceResults,
+		componentResults,
+	); err != nil {
+		log.Error(
+			err,
+			"Unable to run post-apply hooks",
+		)
+		return nil, err
+	}
+
+	healthReport := reconciler.assessHealth(ctx, log, mainInstances, chartReconciler)
+
+	if reconciler.AuditPublisher != nil {
+		// The audit record is best-effort, so the reconciliation does not fail because of it.
+		if err := reconciler.publishAudit(
+			ctx,
+			gProject,
+			commitHash,
+			appliedInstances(componentInstances, componentResults),
+			chartReconciler,
+		); err != nil {
+			log.Error(
+				err,
+				"Unable to publish audit artifact",
+			)
+		}
+	}
+
+	inventorySize := 0
+	var inventoryKinds map[string]int
+	if storage, err := inventoryInstance.Load(); err != nil {
+		// The size is only informational, so the reconciliation does not fail because of it.
+		log.Error(err, "Unable to read inventory")
+	} else {
+		inventorySize = len(storage.Items())
+		inventoryKinds = storage.CountByKind()
+	}
+
+	var pendingPrunes []string
+	if removals, err := garbageCollector.Dangling(dependencyGraph); err != nil {
+		log.Error(err, "Unable to read pending prunes")
+	} else {
+		pendingPrunes = make([]string, 0, len(removals))
+		for _, removal := range removals {
+			pendingPrunes = append(pendingPrunes, removal.Item.GetID())
+		}
+		slices.Sort(pendingPrunes)
+	}
+
+	var unmanagedObjects []garbage.UnmanagedObject
+	if reconciler.PruneDryRun {
+		for _, pendingPrune := range pendingPrunes {
+			log.Info("Pending prune", "id", pendingPrune)
+		}
+		unmanagedObjects, err = reconciler.unmanaged(ctx, cfg, kubeDynamicClient, gProject.GetName(), inventoryInstance)
+		if err != nil {
+			// The report is only informational, so the reconciliation does not fail because of it.
+			log.Error(err, "Unable to report unmanaged objects")
+		}
+		for _, unmanaged := range unmanagedObjects {
+			log.Info(
+				"Unmanaged object",
+				"kind", unmanaged.Kind,
+				"namespace", unmanaged.Namespace,
+				"name", unmanaged.Name,
+			)
+		}
+	}
+
 	return &ReconcileResult{
-		Suspended:  false,
-		CommitHash: commitHash,
+		Suspended:         false,
+		CommitHash:        commitHash,
+		Namespaces:        namespaceResults,
+		Components:        componentResults,
+		PendingPromotions: pendingPromotions,
+		Health:            healthReport,
+		InventorySize:     inventorySize,
+		InventoryKinds:    inventoryKinds,
+		PendingPrunes:     pendingPrunes,
+		UnmanagedObjects:  unmanagedObjects,
+		Notifications:     notifications,
 	}, nil
 }
 
+// unmanaged lists the cluster objects labeled as managed by the project, which are absent from the inventory of the project.
+func (reconciler *Reconciler) unmanaged(
+	ctx context.Context,
+	cfg *rest.Config,
+	kubeDynamicClient *kube.DynamicClient,
+	project string,
+	inventoryInstance *inventory.Instance,
+) ([]garbage.UnmanagedObject, error) {
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	kinds, err := garbage.ListableKinds(discoveryClient)
+	if err != nil {
+		return nil, err
+	}
+	storage, err := inventoryInstance.Load()
+	if err != nil {
+		return nil, err
+	}
+	return garbage.Unmanaged(ctx, kubeDynamicClient, kinds, project, *storage)
+}
+
+// restConfig copies the config of the reconciler and impersonates the service account of the project, if declared.
+func (reconciler *Reconciler) restConfig(gProject gitops.GitOpsProject) *rest.Config {
+	if gProject.Spec.ServiceAccountName != "" {
+		return reconciler.serviceAccountConfig(gProject.Namespace, gProject.Spec.ServiceAccountName)
+	}
+	return rest.CopyConfig(reconciler.KubeConfig)
+}
+
+func (reconciler *Reconciler) serviceAccountConfig(namespace string, serviceAccountName string) *rest.Config {
+	cfg := rest.CopyConfig(reconciler.KubeConfig)
+	cfg.Impersonate = rest.ImpersonationConfig{
+		UserName: fmt.Sprintf(
+			"system:serviceaccount:%s:%s",
+			namespace,
+			serviceAccountName,
+		),
+	}
+	return cfg
+}
+
+// impersonator creates the clients of components declaring a service account of the project namespace.
+// Clients are shared by all components of a reconciliation declaring the same service account.
+func (reconciler *Reconciler) impersonator(gProject gitops.GitOpsProject) component.Impersonator {
+	type impersonated struct {
+		client kube.Client[unstructured.Unstructured]
+		cfg    *rest.Config
+	}
+	var mu sync.Mutex
+	clients := make(map[string]impersonated)
+	return func(serviceAccountName string) (kube.Client[unstructured.Unstructured], *rest.Config, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if existing, found := clients[serviceAccountName]; found {
+			return existing.client, existing.cfg, nil
+		}
+		cfg := reconciler.serviceAccountConfig(gProject.Namespace, serviceAccountName)
+		client, err := kube.NewDynamicClient(cfg)
+		if err != nil {
+			return nil, nil, err
+		}
+		clients[serviceAccountName] = impersonated{client: client, cfg: cfg}
+		return client, cfg, nil
+	}
+}
+
+// checkout clones or pulls the repository of the project into the directory
+// and checks out the pinned revision or the latest commit.
+func (reconciler *Reconciler) checkout(
+	ctx context.Context,
+	log logr.Logger,
+	gProject gitops.GitOpsProject,
+	repositoryDir string,
+) (string, error) {
+	if bundle.IsBundle(gProject.Spec.URL) {
+		return reconciler.pullBundle(ctx, log, gProject, repositoryDir)
+	}
+
+	repository, err := reconciler.RepositoryManager.Load(
+		ctx,
+		gProject.Spec.URL,
+		repositoryDir,
+		authProjectName(gProject),
+	)
+	if err != nil {
+		log.Error(
+			err,
+			"Unable to load gitops project repository",
+		)
+		return "", err
+	}
+
+	if gProject.Spec.Revision != "" {
+		commitHash, err := repository.Checkout(gProject.Spec.Revision)
+		if err != nil {
+			log.Error(
+				err,
+				"Unable to checkout pinned revision of gitops project repository",
+				"revision",
+				gProject.Spec.Revision,
+			)
+			return "", err
+		}
+		return commitHash, nil
+	}
+
+	commitHash, err := repository.Pull()
+	if err != nil {
+		log.Error(
+			err,
+			"Unable to pull gitops project repository",
+		)
+		return "", err
+	}
+	return commitHash, nil
+}
+
+// pullBundle replaces the directory with the project bundle the url references
+// and returns the digest of the bundle as revision.
+// A pinned revision overrides the tag or digest of the url.
+func (reconciler *Reconciler) pullBundle(
+	ctx context.Context,
+	log logr.Logger,
+	gProject gitops.GitOpsProject,
+	repositoryDir string,
+) (string, error) {
+	ref, err := bundle.ParseReference(gProject.Spec.URL)
+	if err != nil {
+		return "", err
+	}
+	if revision := gProject.Spec.Revision; revision != "" {
+		if strings.Contains(revision, ":") {
+			ref.Tag, ref.Digest = "", digest.Digest(revision)
+		} else {
+			ref.Tag, ref.Digest = revision, ""
+		}
+	}
+
+	registry, err := bundle.NewRegistry(
+		ref.Host,
+		reconciler.PlainHTTP || helm.RegistryHosts(reconciler.PlainHTTPHosts).Contains(ref.Host),
+		reconciler.InsecureSkipTLSverify ||
+			helm.RegistryHosts(reconciler.InsecureSkipTLSverifyHosts).Contains(ref.Host),
+	)
+	if err != nil {
+		return "", err
+	}
+
+	revision, err := bundle.Pull(ctx, registry, ref, repositoryDir)
+	if err != nil {
+		log.Error(
+			err,
+			"Unable to pull gitops project bundle",
+			"reference",
+			ref.String(),
+		)
+		return "", err
+	}
+	return revision, nil
+}
+
+// load builds the project or its prebuilt artifact from the checked out repository.
+// Failed builds are reported as [BuildError].
+func (reconciler *Reconciler) load(
+	gProject gitops.GitOpsProject,
+	repositoryDir string,
+) (*component.DependencyGraph, error) {
+	var dependencyGraph *component.DependencyGraph
+	var err error
+	if gProject.Spec.ArtifactPath != "" {
+		if !filepath.IsLocal(gProject.Spec.ArtifactPath) {
+			err = fmt.Errorf("%w: %s", ErrInvalidArtifactPath, gProject.Spec.ArtifactPath)
+		} else {
+			dependencyGraph, err = reconciler.ProjectManager.LoadArtifact(
+				filepath.Join(repositoryDir, gProject.Spec.ArtifactPath),
+			)
+		}
+	} else {
+		dependencyGraph, err = reconciler.ProjectManager.Load(repositoryDir)
+	}
+	if err != nil {
+		return nil, buildError(err, repositoryDir)
+	}
+	return dependencyGraph, nil
+}
+
+func guardrails(projectGuardrails *gitops.GitOpsProjectGuardrails) kube.Guardrails {
+	if projectGuardrails == nil {
+		return kube.Guardrails{}
+	}
+	deniedKinds := make([]kube.DeniedKind, 0, len(projectGuardrails.DeniedKinds))
+	for _, deniedKind := range projectGuardrails.DeniedKinds {
+		deniedKinds = append(deniedKinds, kube.DeniedKind{
+			APIGroup: deniedKind.APIGroup,
+			Kind:     deniedKind.Kind,
+		})
+	}
+	return kube.Guardrails{
+		MaxObjectSize: int(projectGuardrails.MaxObjectSizeBytes),
+		DeniedKinds:   deniedKinds,
+	}
+}
+
+// partitionNotifications splits notifications from all other instances, while keeping their order.
+func partitionNotifications(
+	componentInstances []component.Instance,
+) ([]*component.Notification, []component.Instance) {
+	notifications := make([]*component.Notification, 0)
+	instances := make([]component.Instance, 0, len(componentInstances))
+	for _, instance := range componentInstances {
+		if notification, ok := instance.(*component.Notification); ok {
+			notifications = append(notifications, notification)
+			continue
+		}
+		instances = append(instances, instance)
+	}
+	return notifications, instances
+}
+
+// partitionHooks splits topologically sorted instances into pre-apply hooks, regular components and post-apply hooks,
+// while keeping their order.
+func partitionHooks(
+	componentInstances []component.Instance,
+) ([]component.Instance, []component.Instance, []component.Instance) {
+	preApplyHooks := make([]component.Instance, 0)
+	postApplyHooks := make([]component.Instance, 0)
+	instances := make([]component.Instance, 0, len(componentInstances))
+	for _, instance := range componentInstances {
+		phase, _, ok := hookPolicy(instance)
+		if !ok {
+			instances = append(instances, instance)
+			continue
+		}
+		switch phase {
+		case component.PreApply:
+			preApplyHooks = append(preApplyHooks, instance)
+		case component.PostApply:
+			postApplyHooks = append(postApplyHooks, instance)
+		}
+	}
+	return preApplyHooks, instances, postApplyHooks
+}
+
+// hookPolicy returns the phase and the failure policy of Job and HTTP hooks.
+func hookPolicy(instance component.Instance) (component.HookPhase, component.FailurePolicy, bool) {
+	switch hook := instance.(type) {
+	case *component.Hook:
+		return hook.Phase, hook.FailurePolicy, true
+	case *component.HTTPHook:
+		return hook.Phase, hook.FailurePolicy, true
+	}
+	return "", "", false
+}
+
+// reconcileHooks runs the hooks in order and stops at the first failed hook with the [component.Abort] policy.
+// Hooks are skipped, when their namespace or one of their dependencies failed in the given results,
+// so that e.g. post-apply hooks do not act on a failed rollout.
+// Skipped and ignored hooks fail their dependent hooks the same way.
+func (reconciler *Reconciler) reconcileHooks(
+	ctx context.Context,
+	log logr.Logger,
+	componentReconciler component.Reconciler,
+	hooks []component.Instance,
+	namespaceResults []NamespaceResult,
+	componentResults []ComponentResult,
+) error {
+	failedNamespaces := make(map[string]struct{})
+	for _, result := range namespaceResults {
+		if result.Err != nil {
+			failedNamespaces[result.Namespace] = struct{}{}
+		}
+	}
+	failedComponents := make(map[string]struct{})
+	for _, result := range componentResults {
+		if result.Err != nil {
+			failedComponents[result.ID] = struct{}{}
+		}
+	}
+
+	for _, hook := range hooks {
+		if err := hookBlocked(hook, failedNamespaces, failedComponents); err != nil {
+			log.Info("Skipping hook", "hook", hook.GetID(), "reason", err.Error())
+			failedComponents[hook.GetID()] = struct{}{}
+			continue
+		}
+		if err := componentReconciler.Reconcile(ctx, hook); err != nil {
+			if _, failurePolicy, _ := hookPolicy(hook); failurePolicy == component.Ignore {
+				log.Error(err, "Ignoring failed hook", "hook", hook.GetID())
+				failedComponents[hook.GetID()] = struct{}{}
+				continue
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// hookBlocked returns an error, if the namespace or a dependency of the hook failed.
+func hookBlocked(
+	hook component.Instance,
+	failedNamespaces map[string]struct{},
+	failedComponents map[string]struct{},
+) error {
+	if namespace, namespaced := componentNamespace(hook); namespaced {
+		if _, failed := failedNamespaces[namespace]; failed {
+			return fmt.Errorf("%w: %s", ErrNamespaceFailed, namespace)
+		}
+	}
+	for _, dependency := range hook.GetDependencies() {
+		if _, failed := failedComponents[dependency]; failed {
+			return fmt.Errorf("%w: %s", ErrDependencyFailed, dependency)
+		}
+	}
+	return nil
+}
+
+// namespaceTransactions isolates failures of components by their target namespace.
+// Once a component of a namespace fails, all remaining components of that namespace
+// and all components depending on a failed component are skipped.
+type namespaceTransactions struct {
+	mu               sync.Mutex
+	namespaces       map[string]error
+	failedComponents map[string]struct{}
+	components       map[string]ComponentResult
+}
+
+func (transactions *namespaceTransactions) begin(instance component.Instance) error {
+	transactions.mu.Lock()
+	defer transactions.mu.Unlock()
+	namespace, namespaced := componentNamespace(instance)
+	if namespaced {
+		if _, found := transactions.namespaces[namespace]; !found {
+			transactions.namespaces[namespace] = nil
+		}
+		if err := transactions.namespaces[namespace]; err != nil {
+			err := fmt.Errorf("%w: %s", ErrNamespaceFailed, namespace)
+			transactions.failedComponents[instance.GetID()] = struct{}{}
+			transactions.record(instance, err)
+			return err
+		}
+	}
+	for _, dependency := range instance.GetDependencies() {
+		if _, failed := transactions.failedComponents[dependency]; failed {
+			err := fmt.Errorf("%w: %s", ErrDependencyFailed, dependency)
+			transactions.failedComponents[instance.GetID()] = struct{}{}
+			if namespaced {
+				transactions.namespaces[namespace] = err
+			}
+			transactions.record(instance, err)
+			return err
+		}
+	}
+	return nil
+}
+
+func (transactions *namespaceTransactions) commit(instance component.Instance) {
+	transactions.mu.Lock()
+	defer transactions.mu.Unlock()
+	transactions.record(instance, nil)
+}
+
+func (transactions *namespaceTransactions) fail(instance component.Instance, err error) {
+	transactions.mu.Lock()
+	defer transactions.mu.Unlock()
+	namespace, namespaced := componentNamespace(instance)
+	transactions.failedComponents[instance.GetID()] = struct{}{}
+	if namespaced && transactions.namespaces[namespace] == nil {
+		transactions.namespaces[namespace] = err
+	}
+	transactions.record(instance, err)
+}
+
+// record has to be called while holding the lock.
+func (transactions *namespaceTransactions) record(instance component.Instance, err error) {
+	result := ComponentResult{
+		ID:   instance.GetID(),
+		Type: componentType(instance),
+		Err:  err,
+	}
+	if err == nil {
+		result.AppliedAt = time.Now()
+		// The digest only tracks changes, so components are not failed because of it.
+		result.Digest, _ = promotion.Digest(instance)
+	}
+	transactions.components[instance.GetID()] = result
+}
+
+func (transactions *namespaceTransactions) componentResults() []ComponentResult {
+	results := make([]ComponentResult, 0, len(transactions.components))
+	for _, result := range transactions.components {
+		results = append(results, result)
+	}
+	slices.SortFunc(results, func(a, b ComponentResult) int {
+		return strings.Compare(a.ID, b.ID)
+	})
+	return results
+}
+
+func (transactions *namespaceTransactions) results() []NamespaceResult {
+	results := make([]NamespaceResult, 0, len(transactions.namespaces))
+	for namespace, err := range transactions.namespaces {
+		results = append(results, NamespaceResult{
+			Namespace: namespace,
+			Err:       err,
+		})
+	}
+	slices.SortFunc(results, func(a, b NamespaceResult) int {
+		return strings.Compare(a.Namespace, b.Namespace)
+	})
+	return results
+}
+
+func componentType(instance component.Instance) string {
+	switch instance := instance.(type) {
+	case *component.Manifest:
+		return "Manifest"
+	case *component.Hook:
+		return "Hook"
+	case *component.HTTPHook:
+		return "HTTPHook"
+	case *component.Kustomization:
+		return "Kustomization"
+	case *component.ManifestSequence:
+		return "ManifestSequence"
+	case *component.Notification:
+		return "Notification"
+	case *helm.ReleaseComponent:
+		return "HelmRelease"
+	case component.CustomInstance:
+		return instance.GetType()
+	}
+	return ""
+}
+
+// componentNamespace returns the namespace isolating failures of a component.
+// Cluster scoped objects share the empty namespace.
+// Kustomizations and manifest sequences are not namespaced, because their objects are applied as Manifests,
+// which are isolated by their own namespaces, and they only wait for them.
+// The objects of custom components are unknown, so they are not namespaced either.
+// Failures of components, which are not namespaced, only skip their dependents.
+func componentNamespace(instance component.Instance) (string, bool) {
+	switch instance := instance.(type) {
+	case *component.Manifest:
+		return instance.Content.GetNamespace(), true
+	case *component.Hook:
+		return instance.Content.GetNamespace(), true
+	case *helm.ReleaseComponent:
+		return instance.Content.Namespace, true
+	}
+	return "", false
+}
+
+// applyConcurrency returns the number of components of the project applied in parallel.
+// A project can lower it below the worker pool size, but never raise it.
+func (reconciler *Reconciler) applyConcurrency(gProject gitops.GitOpsProject) int {
+	if gProject.Spec.MaxConcurrentApplies > 0 && gProject.Spec.MaxConcurrentApplies < reconciler.WorkerPoolSize {
+		return gProject.Spec.MaxConcurrentApplies
+	}
+	return reconciler.WorkerPoolSize
+}
+
 func (reconciler *Reconciler) reconcileComponents(
 	ctx context.Context,
 	componentReconciler component.Reconciler,
 	componentInstances []component.Instance,
-) error {
+	concurrency int,
+	releaseObjects releaseObjects,
+) ([]NamespaceResult, []ComponentResult) {
+	transactions := &namespaceTransactions{
+		namespaces:       make(map[string]error),
+		failedComponents: make(map[string]struct{}),
+		components:       make(map[string]ComponentResult),
+	}
+	deferrals := &webhookDeferrals{
+		components: make(map[string]struct{}),
+	}
+	reconcile := func(instance component.Instance) {
+		if deferrals.dependsOnDeferred(instance) {
+			deferrals.add(instance, nil)
+			return
+		}
+		if err := transactions.begin(instance); err != nil {
+			return
+		}
+		if err := componentReconciler.Reconcile(
+			ctx,
+			instance,
+		); err != nil {
+			if workloads := webhookWorkloads(componentInstances, err, releaseObjects); len(workloads) != 0 {
+				deferrals.add(instance, workloads)
+				return
+			}
+			transactions.fail(instance, err)
+			return
+		}
+		transactions.commit(instance)
+	}
+
 	eg := errgroup.Group{}
-	eg.SetLimit(reconciler.WorkerPoolSize)
+	eg.SetLimit(concurrency)
 	for _, instance := range componentInstances {
 		// TODO: implement SCC decomposition for better concurrency/parallelism
 		if len(instance.GetDependencies()) == 0 {
 			eg.Go(func() error {
-				return componentReconciler.Reconcile(
-					ctx,
-					instance,
-				)
+				reconcile(instance)
+				return nil
 			})
 		} else {
-			if err := eg.Wait(); err != nil {
+			_ = eg.Wait()
+			reconcile(instance)
+		}
+	}
+	_ = eg.Wait()
+
+	if len(deferrals.instances) != 0 {
+		waitCtx, cancel := context.WithTimeout(ctx, webhookReadinessTimeout)
+		err := health.WaitUntilReady(waitCtx, componentReconciler.DynamicClient, deferrals.workloads, nil)
+		cancel()
+		for _, instance := range deferrals.instances {
+			if err != nil {
+				transactions.fail(instance, err)
+				continue
+			}
+			if err := transactions.begin(instance); err != nil {
+				continue
+			}
+			if err := componentReconciler.Reconcile(ctx, instance); err != nil {
+				transactions.fail(instance, err)
+				continue
+			}
+			transactions.commit(instance)
+		}
+	}
+
+	return transactions.results(), transactions.componentResults()
+}
+
+// assessHealth reports the readiness of the objects of all applied Manifests and Helm releases.
+// Releases, which cannot be rendered, are left out of the report.
+func (reconciler *Reconciler) assessHealth(
+	ctx context.Context,
+	log logr.Logger,
+	componentInstances []component.Instance,
+	chartReconciler helm.ChartReconciler,
+) health.Report {
+	objs := make([]unstructured.Unstructured, 0, len(componentInstances))
+	for _, instance := range componentInstances {
+		switch componentInstance := instance.(type) {
+		case *component.Manifest:
+			objs = append(objs, componentInstance.Content)
+		case *helm.ReleaseComponent:
+			releaseManifests, err := installedObjects(chartReconciler)(componentInstance)
+			if err != nil {
+				log.Error(err, "Unable to assess health of release", "release", componentInstance.Content.Name)
+				continue
+			}
+			objs = append(objs, releaseManifests...)
+		}
+	}
+
+	return health.Assess(ctx, chartReconciler.Client, objs, nil, reconciler.WorkerPoolSize)
+}
+
+// appliedInstances returns the hooks and the components, which were applied by the reconciliation.
+// Failed and skipped components are left out, so that they are not recorded as applied.
+func appliedInstances(componentInstances []component.Instance, componentResults []ComponentResult) []component.Instance {
+	applied := make(map[string]struct{}, len(componentResults))
+	for _, result := range componentResults {
+		if result.Err == nil {
+			applied[result.ID] = struct{}{}
+		}
+	}
+	instances := make([]component.Instance, 0, len(componentInstances))
+	for _, instance := range componentInstances {
+		if _, isHook := instance.(*component.Hook); !isHook {
+			if _, found := applied[instance.GetID()]; !found {
+				continue
+			}
+		}
+		instances = append(instances, instance)
+	}
+	return instances
+}
+
+// identity returns only the type and the name of an object,
+// so that the SOPS decrypted content of a component is never published.
+func identity(obj unstructured.Unstructured) unstructured.Unstructured {
+	identity := unstructured.Unstructured{Object: make(map[string]interface{}, 3)}
+	identity.SetAPIVersion(obj.GetAPIVersion())
+	identity.SetKind(obj.GetKind())
+	identity.SetName(obj.GetName())
+	if obj.GetNamespace() != "" {
+		identity.SetNamespace(obj.GetNamespace())
+	}
+	return identity
+}
+
+func (reconciler *Reconciler) publishAudit(
+	ctx context.Context,
+	gProject gitops.GitOpsProject,
+	commitHash string,
+	componentInstances []component.Instance,
+	chartReconciler helm.ChartReconciler,
+) error {
+	manifests := make([]unstructured.Unstructured, 0, len(componentInstances))
+	for _, instance := range componentInstances {
+		switch componentInstance := instance.(type) {
+		case *component.Manifest:
+			if componentInstance.Decrypted {
+				manifests = append(manifests, identity(componentInstance.Content))
+				continue
+			}
+			manifests = append(manifests, componentInstance.Content)
+		case *component.Hook:
+			if componentInstance.Decrypted {
+				manifests = append(manifests, identity(componentInstance.Content))
+				continue
+			}
+			manifests = append(manifests, componentInstance.Content)
+		case *helm.ReleaseComponent:
+			helmCfg, err := helm.Init(
+				componentInstance.Content.Namespace,
+				chartReconciler.KubeConfig,
+				chartReconciler.Client,
+				chartReconciler.FieldManager,
+				chartReconciler.Suppressions,
+			)
+			if err != nil {
 				return err
 			}
-			if err := componentReconciler.Reconcile(
-				ctx,
-				instance,
-			); err != nil {
+			releaseManifests, err := helm.RenderedManifests(helmCfg, componentInstance.Content.Name)
+			if err != nil {
 				return err
 			}
+			manifests = append(manifests, releaseManifests...)
 		}
 	}
-	return eg.Wait()
+
+	storage, err := chartReconciler.InventoryInstance.Load()
+	if err != nil {
+		return err
+	}
+
+	_, err = reconciler.AuditPublisher.Publish(ctx, audit.Revision{
+		Project:    gProject.GetName(),
+		Namespace:  gProject.GetNamespace(),
+		CommitHash: commitHash,
+		Manifests:  manifests,
+		Inventory:  storage,
+	})
+	return err
 }
@@ -16,6 +16,7 @@ package project_test
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -34,6 +35,7 @@ import (
 	"github.com/kharf/declcd/pkg/component"
 	"github.com/kharf/declcd/pkg/inventory"
 	"github.com/kharf/declcd/pkg/project"
+	"github.com/kharf/declcd/pkg/vcs"
 	_ "github.com/kharf/declcd/test/workingdir"
 	"gotest.tools/v3/assert"
 	appsv1 "k8s.io/api/apps/v1"
@@ -106,6 +108,14 @@ func TestReconciler_Reconcile(t *testing.T) {
 				result, err := reconciler.Reconcile(env.Ctx, gProject)
 				assert.NilError(t, err)
 				assert.Equal(t, result.Suspended, false)
+				assert.Assert(t, len(result.Namespaces) != 0)
+				assert.Assert(t, len(result.Failed()) == 0)
+				assert.Assert(t, len(result.Components) != 0)
+				for _, componentResult := range result.Components {
+					assert.NilError(t, componentResult.Err)
+					assert.Assert(t, componentResult.Type != "")
+					assert.Assert(t, !componentResult.AppliedAt.IsZero())
+				}
 
 				ctx := context.Background()
 				ns := "prometheus"
@@ -206,6 +216,60 @@ func TestReconciler_Reconcile(t *testing.T) {
 				assert.Error(t, err, "deployments.apps \"mysubcomponent\" not found")
 			},
 		},
+		{
+			name: "Plan",
+			prepare: func() *projecttest.Environment {
+				return nil
+			},
+			run: func(t *testing.T, tcContext testCaseContext) {
+				reconciler := tcContext.reconciler
+				env := tcContext.environment
+				gProject := tcContext.gitopsProject
+
+				result, err := reconciler.Reconcile(env.Ctx, gProject)
+				assert.NilError(t, err)
+
+				plan, err := reconciler.Plan(env.Ctx, gProject)
+				assert.NilError(t, err)
+				assert.Equal(t, plan.CommitHash, result.CommitHash)
+				for _, change := range plan.Changes {
+					assert.Assert(t, change.Action != project.ChangeDelete)
+				}
+
+				testProject := env.Projects[0]
+				err = os.RemoveAll(
+					filepath.Join(testProject.TargetPath, "infra", "prometheus", "subcomponent"),
+				)
+				assert.NilError(t, err)
+				_, err = testProject.GitRepository.CommitFile(
+					"infra/prometheus/",
+					"undeploy subcomponent",
+				)
+				assert.NilError(t, err)
+
+				plan, err = reconciler.Plan(env.Ctx, gProject)
+				assert.NilError(t, err)
+				var deleted *project.Change
+				for i, change := range plan.Changes {
+					if change.Action == project.ChangeDelete {
+						deleted = &plan.Changes[i]
+					}
+				}
+				assert.Assert(t, deleted != nil)
+				assert.Equal(t, deleted.Kind, "Deployment")
+				assert.Equal(t, deleted.Name, "mysubcomponent")
+				assert.Assert(t, deleted.Diff != "")
+
+				// Planning does not collect the removed component.
+				var mysubcomponent appsv1.Deployment
+				err = env.TestKubeClient.Get(
+					context.Background(),
+					types.NamespacedName{Name: "mysubcomponent", Namespace: "prometheus"},
+					&mysubcomponent,
+				)
+				assert.NilError(t, err)
+			},
+		},
 		{
 			name: "Impersonation",
 			prepare: func() *projecttest.Environment {
@@ -438,6 +502,140 @@ func TestReconciler_Reconcile(t *testing.T) {
 				assert.Error(t, err, "deployments.apps \"mysubcomponent\" not found")
 			},
 		},
+		{
+			name: "MaxConcurrentApplies",
+			prepare: func() *projecttest.Environment {
+				return nil
+			},
+			run: func(t *testing.T, tcContext testCaseContext) {
+				reconciler := tcContext.reconciler
+				env := tcContext.environment
+				gProject := tcContext.gitopsProject
+
+				gProject.Spec.MaxConcurrentApplies = 1
+				result, err := reconciler.Reconcile(env.Ctx, gProject)
+				assert.NilError(t, err)
+				assert.Assert(t, len(result.Failed()) == 0)
+				assert.Assert(t, len(result.Components) != 0)
+
+				var deployment appsv1.Deployment
+				err = env.TestKubeClient.Get(
+					context.Background(),
+					types.NamespacedName{Name: "mysubcomponent", Namespace: "prometheus"},
+					&deployment,
+				)
+				assert.NilError(t, err)
+			},
+		},
+		{
+			name: "BuildError",
+			prepare: func() *projecttest.Environment {
+				return nil
+			},
+			run: func(t *testing.T, tcContext testCaseContext) {
+				reconciler := tcContext.reconciler
+				env := tcContext.environment
+				gProject := tcContext.gitopsProject
+
+				testProject := env.Projects[0]
+				err := os.WriteFile(
+					filepath.Join(testProject.TargetPath, "infra", "prometheus", "broken.cue"),
+					[]byte("package prometheus\n\nbroken: {\n\treplicas: int & \"two\"\n}\n"),
+					0600,
+				)
+				assert.NilError(t, err)
+				_, err = testProject.GitRepository.CommitFile(
+					"infra/prometheus/broken.cue",
+					"break build",
+				)
+				assert.NilError(t, err)
+
+				_, err = reconciler.Reconcile(env.Ctx, gProject)
+				var buildErr *project.BuildError
+				assert.Assert(t, errors.As(err, &buildErr))
+				assert.Assert(t, len(buildErr.Issues) != 0)
+				issue := buildErr.Issues[0]
+				assert.Equal(t, issue.File, filepath.Join("infra", "prometheus", "broken.cue"))
+				assert.Equal(t, issue.Line, 4)
+				assert.Assert(t, issue.Column > 0)
+				assert.Assert(t, strings.Contains(issue.Message, "broken.replicas"))
+				assert.Equal(t, issue.Source, "replicas: int & \"two\"")
+			},
+		},
+		{
+			name: "SkipUnchangedRevision",
+			prepare: func() *projecttest.Environment {
+				return nil
+			},
+			run: func(t *testing.T, tcContext testCaseContext) {
+				reconciler := tcContext.reconciler
+				env := tcContext.environment
+				gProject := tcContext.gitopsProject
+
+				reconciler.SkipUnchangedRevision = true
+				result, err := reconciler.Reconcile(env.Ctx, gProject)
+				assert.NilError(t, err)
+				assert.Equal(t, result.Skipped, false)
+
+				gProject.Status.Revision.CommitHash = result.CommitHash
+				result, err = reconciler.Reconcile(env.Ctx, gProject)
+				assert.NilError(t, err)
+				assert.Equal(t, result.Skipped, true)
+				assert.Assert(t, len(result.Namespaces) == 0)
+			},
+		},
+		{
+			name: "RequiredCheck",
+			prepare: func() *projecttest.Environment {
+				return nil
+			},
+			run: func(t *testing.T, tcContext testCaseContext) {
+				reconciler := tcContext.reconciler
+				env := tcContext.environment
+				gProject := tcContext.gitopsProject
+
+				gProject.Spec.RequiredCheck = &gitops.GitOpsProjectRequiredCheck{
+					Name:            "declcd-verify",
+					Provider:        vcs.GitHub,
+					Repository:      "kharf/declcd",
+					TokenSecretName: "token",
+				}
+				checkState := vcs.CheckPending
+				var checkedCommit string
+				reconciler.ReadRequiredCheck = func(ctx context.Context, gProject gitops.GitOpsProject, commit string) (vcs.CheckState, error) {
+					checkedCommit = commit
+					return checkState, nil
+				}
+
+				result, err := reconciler.Reconcile(env.Ctx, gProject)
+				assert.NilError(t, err)
+				assert.Equal(t, result.Unverified, true)
+				assert.Equal(t, result.CheckState, vcs.CheckPending)
+				assert.Equal(t, checkedCommit, result.CommitHash)
+				assert.Assert(t, len(result.Namespaces) == 0)
+
+				var deployment appsv1.Deployment
+				err = env.TestKubeClient.Get(
+					env.Ctx,
+					types.NamespacedName{Name: "mysubcomponent", Namespace: "prometheus"},
+					&deployment,
+				)
+				assert.Error(t, err, "deployments.apps \"mysubcomponent\" not found")
+
+				checkState = vcs.CheckSucceeded
+				result, err = reconciler.Reconcile(env.Ctx, gProject)
+				assert.NilError(t, err)
+				assert.Equal(t, result.Unverified, false)
+				assert.Assert(t, len(result.Namespaces) != 0)
+
+				// The applied commit is not verified again.
+				checkedCommit = ""
+				gProject.Status.Revision.CommitHash = result.CommitHash
+				_, err = reconciler.Reconcile(env.Ctx, gProject)
+				assert.NilError(t, err)
+				assert.Equal(t, checkedCommit, "")
+			},
+		},
 	}
 
 	for _, tc := range testCases {
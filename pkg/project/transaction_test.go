@@ -0,0 +1,131 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package project
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/kharf/declcd/pkg/component"
+	"github.com/kharf/declcd/pkg/inventory"
+	"github.com/kharf/declcd/pkg/kube"
+	"gotest.tools/v3/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+var errApplyFailed = errors.New("apply failed")
+
+// failingClient refuses to apply the objects in the failing namespace and records all applied objects.
+type failingClient struct {
+	kube.Client[unstructured.Unstructured]
+	failingNamespace string
+
+	mu      sync.Mutex
+	applied []string
+}
+
+func (client *failingClient) Apply(
+	ctx context.Context,
+	obj *unstructured.Unstructured,
+	fieldManager string,
+	opts ...kube.ApplyOption,
+) error {
+	if obj.GetNamespace() == client.failingNamespace {
+		return errApplyFailed
+	}
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	client.applied = append(client.applied, obj.GetNamespace()+"/"+obj.GetName())
+	return nil
+}
+
+func configMap(name string, namespace string, dependencies ...string) *component.Manifest {
+	content := unstructured.Unstructured{}
+	content.SetAPIVersion("v1")
+	content.SetKind("ConfigMap")
+	content.SetName(name)
+	content.SetNamespace(namespace)
+	return &component.Manifest{
+		ID:           name + "_" + namespace + "__ConfigMap",
+		Dependencies: dependencies,
+		Content:      content,
+	}
+}
+
+func TestReconciler_reconcileComponents(t *testing.T) {
+	client := &failingClient{failingNamespace: "billing"}
+	componentReconciler := component.Reconciler{
+		Log:           logr.Discard(),
+		DynamicClient: client,
+		InventoryInstance: &inventory.Instance{
+			Path: t.TempDir(),
+		},
+	}
+
+	billing := configMap("config", "billing")
+	shop := configMap("config", "shop")
+	// depends on a succeeding component, but is skipped because its namespace failed.
+	billingDependent := configMap("invoices", "billing", shop.ID)
+	shopDependent := configMap("orders", "shop", shop.ID)
+	// fails its own namespace, because it depends on a failed component of another namespace.
+	crossNamespaceDependent := configMap("reports", "shop", billing.ID)
+	kustomization := &component.Kustomization{
+		ID:           "billing_Kustomization",
+		Dependencies: []string{billing.ID},
+		Objects:      []string{billing.ID},
+	}
+	skipped := configMap("audit", "shop")
+
+	reconciler := &Reconciler{}
+	namespaceResults, componentResults := reconciler.reconcileComponents(
+		context.Background(),
+		componentReconciler,
+		[]component.Instance{
+			billing,
+			shop,
+			billingDependent,
+			shopDependent,
+			crossNamespaceDependent,
+			kustomization,
+			skipped,
+		},
+		2,
+		nil,
+	)
+
+	assert.Equal(t, len(namespaceResults), 2)
+	assert.Equal(t, namespaceResults[0].Namespace, "billing")
+	assert.ErrorIs(t, namespaceResults[0].Err, errApplyFailed)
+	assert.Equal(t, namespaceResults[1].Namespace, "shop")
+	assert.ErrorIs(t, namespaceResults[1].Err, ErrDependencyFailed)
+
+	errs := make(map[string]error, len(componentResults))
+	for _, result := range componentResults {
+		errs[result.ID] = result.Err
+	}
+	assert.Equal(t, len(errs), 7)
+	assert.ErrorIs(t, errs[billing.ID], errApplyFailed)
+	assert.NilError(t, errs[shop.ID])
+	assert.ErrorIs(t, errs[billingDependent.ID], ErrNamespaceFailed)
+	assert.NilError(t, errs[shopDependent.ID])
+	assert.ErrorIs(t, errs[crossNamespaceDependent.ID], ErrDependencyFailed)
+	assert.ErrorIs(t, errs[kustomization.ID], ErrDependencyFailed)
+	assert.ErrorIs(t, errs[skipped.ID], ErrNamespaceFailed)
+
+	assert.DeepEqual(t, client.applied, []string{"shop/config", "shop/orders"})
+}
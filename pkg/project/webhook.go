@@ -0,0 +1,151 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package project
+
+import (
+	"sync"
+	"time"
+
+	"github.com/kharf/declcd/pkg/component"
+	"github.com/kharf/declcd/pkg/helm"
+	"github.com/kharf/declcd/pkg/kube"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// webhookReadinessTimeout bounds how long deferred components wait for the workloads of their webhooks.
+const webhookReadinessTimeout = 2 * time.Minute
+
+// webhookDeferrals collects components, which were rejected by an unreachable admission webhook served by the same project,
+// together with their dependents, to retry them once the webhook workloads are ready within the same reconciliation.
+type webhookDeferrals struct {
+	mu         sync.Mutex
+	instances  []component.Instance
+	components map[string]struct{}
+	workloads  []unstructured.Unstructured
+}
+
+func (deferrals *webhookDeferrals) add(instance component.Instance, workloads []unstructured.Unstructured) {
+	deferrals.mu.Lock()
+	defer deferrals.mu.Unlock()
+	deferrals.instances = append(deferrals.instances, instance)
+	deferrals.components[instance.GetID()] = struct{}{}
+	deferrals.workloads = append(deferrals.workloads, workloads...)
+}
+
+func (deferrals *webhookDeferrals) dependsOnDeferred(instance component.Instance) bool {
+	deferrals.mu.Lock()
+	defer deferrals.mu.Unlock()
+	for _, dependency := range instance.GetDependencies() {
+		if _, deferred := deferrals.components[dependency]; deferred {
+			return true
+		}
+	}
+	return false
+}
+
+// releaseObjects returns the objects of an installed Helm release.
+type releaseObjects func(release *helm.ReleaseComponent) ([]unstructured.Unstructured, error)
+
+// installedObjects reads the objects of installed Helm releases from their release storage.
+func installedObjects(chartReconciler helm.ChartReconciler) releaseObjects {
+	return func(release *helm.ReleaseComponent) ([]unstructured.Unstructured, error) {
+		helmCfg, err := helm.Init(
+			release.Content.Namespace,
+			chartReconciler.KubeConfig,
+			chartReconciler.Client,
+			chartReconciler.FieldManager,
+			chartReconciler.Suppressions,
+		)
+		if err != nil {
+			return nil, err
+		}
+		return helm.RenderedManifests(helmCfg, release.Content.Name)
+	}
+}
+
+// webhookWorkloads returns the workloads of the project serving the admission webhook,
+// which made the component fail, because it was unreachable.
+// Workloads are the Deployments, StatefulSets and DaemonSets selected by the Service of the webhook.
+// They are declared as Manifests or installed by Helm releases in the namespace of the Service, like most webhooks are.
+// It is empty if the error was not caused by an unreachable webhook or the webhook is not served by the project.
+func webhookWorkloads(
+	componentInstances []component.Instance,
+	err error,
+	releaseObjects releaseObjects,
+) []unstructured.Unstructured {
+	service, ok := kube.UnavailableWebhookService(err)
+	if !ok {
+		return nil
+	}
+
+	objects := make([]unstructured.Unstructured, 0)
+	for _, instance := range componentInstances {
+		switch instance := instance.(type) {
+		case *component.Manifest:
+			objects = append(objects, instance.Content)
+		case *helm.ReleaseComponent:
+			if instance.Content.Namespace != service.Namespace {
+				continue
+			}
+			// Releases, which are not installed yet, cannot serve the webhook.
+			installed, err := releaseObjects(instance)
+			if err != nil {
+				continue
+			}
+			objects = append(objects, installed...)
+		}
+	}
+
+	var selector map[string]interface{}
+	for i := range objects {
+		content := &objects[i]
+		if content.GetKind() != "Service" || content.GetAPIVersion() != "v1" ||
+			content.GetName() != service.Name || content.GetNamespace() != service.Namespace {
+			continue
+		}
+		selector, _, _ = unstructured.NestedMap(content.Object, "spec", "selector")
+		break
+	}
+	if len(selector) == 0 {
+		return nil
+	}
+
+	var workloads []unstructured.Unstructured
+	for i := range objects {
+		content := &objects[i]
+		if content.GetNamespace() != service.Namespace {
+			continue
+		}
+		switch content.GroupVersionKind().GroupKind().String() {
+		case "Deployment.apps", "StatefulSet.apps", "DaemonSet.apps":
+		default:
+			continue
+		}
+		labels, _, _ := unstructured.NestedStringMap(content.Object, "spec", "template", "metadata", "labels")
+		if selects(selector, labels) {
+			workloads = append(workloads, *content)
+		}
+	}
+	return workloads
+}
+
+func selects(selector map[string]interface{}, labels map[string]string) bool {
+	for key, value := range selector {
+		if labels[key] != value {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,243 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package project
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/kharf/declcd/pkg/component"
+	"github.com/kharf/declcd/pkg/helm"
+	"github.com/kharf/declcd/pkg/inventory"
+	"github.com/kharf/declcd/pkg/kube"
+	"gotest.tools/v3/assert"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+var errWebhookUnavailable = k8sErrors.NewInternalError(errors.New(
+	`failed calling webhook "webhook.cert-manager.io": failed to call webhook: Post "https://cert-manager-webhook.cert-manager.svc:443/validate?timeout=10s": dial tcp 10.96.0.1:443: connect: connection refused`,
+))
+
+func webhookService() unstructured.Unstructured {
+	return unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Service",
+		"metadata": map[string]interface{}{
+			"name":      "cert-manager-webhook",
+			"namespace": "cert-manager",
+		},
+		"spec": map[string]interface{}{
+			"selector": map[string]interface{}{
+				"app": "webhook",
+			},
+		},
+	}}
+}
+
+func webhookDeployment(app string) unstructured.Unstructured {
+	return unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata": map[string]interface{}{
+			"name":      app,
+			"namespace": "cert-manager",
+		},
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{
+				"metadata": map[string]interface{}{
+					"labels": map[string]interface{}{
+						"app": app,
+					},
+				},
+			},
+		},
+	}}
+}
+
+func TestWebhookWorkloads(t *testing.T) {
+	release := &helm.ReleaseComponent{
+		ID: "cert-manager_cert-manager_HelmRelease",
+		Content: helm.ReleaseDeclaration{
+			Name:      "cert-manager",
+			Namespace: "cert-manager",
+		},
+	}
+	installed := func(release *helm.ReleaseComponent) ([]unstructured.Unstructured, error) {
+		return []unstructured.Unstructured{
+			webhookService(),
+			webhookDeployment("webhook"),
+			webhookDeployment("controller"),
+		}, nil
+	}
+	notInstalled := func(release *helm.ReleaseComponent) ([]unstructured.Unstructured, error) {
+		return nil, errors.New("release: not found")
+	}
+
+	testCases := []struct {
+		name           string
+		instances      []component.Instance
+		err            error
+		releaseObjects releaseObjects
+		expected       []unstructured.Unstructured
+	}{
+		{
+			name: "Manifests",
+			instances: []component.Instance{
+				&component.Manifest{Content: webhookService()},
+				&component.Manifest{Content: webhookDeployment("webhook")},
+				&component.Manifest{Content: webhookDeployment("controller")},
+			},
+			err:            errWebhookUnavailable,
+			releaseObjects: notInstalled,
+			expected:       []unstructured.Unstructured{webhookDeployment("webhook")},
+		},
+		{
+			name:           "HelmRelease",
+			instances:      []component.Instance{release},
+			err:            errWebhookUnavailable,
+			releaseObjects: installed,
+			expected:       []unstructured.Unstructured{webhookDeployment("webhook")},
+		},
+		{
+			name:           "HelmReleaseNotInstalled",
+			instances:      []component.Instance{release},
+			err:            errWebhookUnavailable,
+			releaseObjects: notInstalled,
+		},
+		{
+			name:           "OtherError",
+			instances:      []component.Instance{release},
+			err:            errors.New("forbidden"),
+			releaseObjects: installed,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			workloads := webhookWorkloads(tc.instances, tc.err, tc.releaseObjects)
+			assert.DeepEqual(t, workloads, tc.expected)
+		})
+	}
+}
+
+// webhookClient rejects objects of the webhook group until the webhook Deployment was observed ready.
+type webhookClient struct {
+	kube.Client[unstructured.Unstructured]
+
+	mu       sync.Mutex
+	ready    bool
+	rejected int
+	applied  []string
+}
+
+func (client *webhookClient) Apply(
+	ctx context.Context,
+	obj *unstructured.Unstructured,
+	fieldManager string,
+	opts ...kube.ApplyOption,
+) error {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	if obj.GroupVersionKind().Group == "cert-manager.io" && !client.ready {
+		client.rejected++
+		return errWebhookUnavailable
+	}
+	client.applied = append(client.applied, obj.GetName())
+	return nil
+}
+
+func (client *webhookClient) Get(
+	ctx context.Context,
+	obj *unstructured.Unstructured,
+) (*unstructured.Unstructured, error) {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	client.ready = true
+	ready := obj.DeepCopy()
+	ready.SetGeneration(1)
+	ready.Object["status"] = map[string]interface{}{
+		"observedGeneration": int64(1),
+		"updatedReplicas":    int64(1),
+		"availableReplicas":  int64(1),
+	}
+	return ready, nil
+}
+
+func TestReconciler_reconcileComponents_WebhookDeferral(t *testing.T) {
+	client := &webhookClient{}
+	componentReconciler := component.Reconciler{
+		Log:           logr.Discard(),
+		DynamicClient: client,
+		InventoryInstance: &inventory.Instance{
+			Path: t.TempDir(),
+		},
+	}
+
+	issuer := &component.Manifest{
+		ID: "ca_shop_cert-manager.io_Issuer",
+		Content: unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "cert-manager.io/v1",
+			"kind":       "Issuer",
+			"metadata": map[string]interface{}{
+				"name":      "ca",
+				"namespace": "shop",
+			},
+		}},
+	}
+	// depends on the rejected issuer and is deferred with it.
+	config := &component.Manifest{
+		ID:           "tls_shop__ConfigMap",
+		Dependencies: []string{issuer.ID},
+		Content: unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata": map[string]interface{}{
+				"name":      "tls",
+				"namespace": "shop",
+			},
+		}},
+	}
+	service := &component.Manifest{
+		ID:      "cert-manager-webhook_cert-manager__Service",
+		Content: webhookService(),
+	}
+	deployment := &component.Manifest{
+		ID:      "webhook_cert-manager_apps_Deployment",
+		Content: webhookDeployment("webhook"),
+	}
+
+	reconciler := &Reconciler{}
+	namespaceResults, componentResults := reconciler.reconcileComponents(
+		context.Background(),
+		componentReconciler,
+		[]component.Instance{service, deployment, issuer, config},
+		1,
+		nil,
+	)
+
+	// the issuer and its dependent are replayed in order, once the webhook Deployment is ready.
+	assert.Equal(t, client.rejected, 1)
+	assert.DeepEqual(t, client.applied, []string{"cert-manager-webhook", "webhook", "ca", "tls"})
+	for _, result := range namespaceResults {
+		assert.NilError(t, result.Err)
+	}
+	assert.Equal(t, len(componentResults), 4)
+	for _, result := range componentResults {
+		assert.NilError(t, result.Err)
+	}
+}
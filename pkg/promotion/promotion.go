@@ -0,0 +1,87 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package promotion
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	ErrUnknownPromotion = errors.New("Unknown promotion")
+)
+
+const (
+	// Manual defers applying a component until its version has been promoted with 'declcd promote'.
+	Manual = "manual"
+)
+
+// Policy defers applying new versions of a component, which have already been committed to Git.
+// Until a version is promoted, the previously applied version stays in the cluster.
+type Policy struct {
+	// After promotes a version once the time has passed.
+	After *time.Time `json:",omitempty"`
+
+	// Manual requires every version to be promoted by an operator.
+	Manual bool `json:",omitempty"`
+}
+
+// Parse reads a policy from the promoteAfter and promotion fields of a component.
+// Both fields are optional.
+func Parse(promoteAfter string, promotion string) (Policy, error) {
+	var policy Policy
+	if promoteAfter != "" {
+		after, err := time.Parse(time.RFC3339, promoteAfter)
+		if err != nil {
+			return Policy{}, err
+		}
+		policy.After = &after
+	}
+	switch promotion {
+	case "":
+	case Manual:
+		policy.Manual = true
+	default:
+		return Policy{}, fmt.Errorf("%w: %s", ErrUnknownPromotion, promotion)
+	}
+	return policy, nil
+}
+
+// Pending reports whether a version has to wait for its promotion.
+// A version promoted by an operator is never pending.
+func (policy Policy) Pending(now time.Time, promoted bool) bool {
+	if promoted {
+		return false
+	}
+	if policy.Manual {
+		return true
+	}
+	return policy.After != nil && now.Before(*policy.After)
+}
+
+// Digest identifies the version of a component by its content.
+// Operators promote a digest, so that later versions of the same component are deferred again.
+func Digest(component any) (string, error) {
+	content, err := json.Marshal(component)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(content)
+	return "sha256:" + hex.EncodeToString(sum[:]), nil
+}
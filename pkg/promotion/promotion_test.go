@@ -0,0 +1,100 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package promotion_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kharf/declcd/pkg/promotion"
+	"gotest.tools/v3/assert"
+)
+
+func TestPolicy_Pending(t *testing.T) {
+	now := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		name         string
+		promoteAfter string
+		promotion    string
+		promoted     bool
+		expected     bool
+		err          error
+	}{
+		{
+			name:     "None",
+			expected: false,
+		},
+		{
+			name:         "Future",
+			promoteAfter: "2024-07-02T00:00:00Z",
+			expected:     true,
+		},
+		{
+			name:         "FuturePromoted",
+			promoteAfter: "2024-07-02T00:00:00Z",
+			promoted:     true,
+			expected:     false,
+		},
+		{
+			name:         "Passed",
+			promoteAfter: "2024-06-30T00:00:00Z",
+			expected:     false,
+		},
+		{
+			name:      "Manual",
+			promotion: promotion.Manual,
+			expected:  true,
+		},
+		{
+			name:         "ManualPassed",
+			promoteAfter: "2024-06-30T00:00:00Z",
+			promotion:    promotion.Manual,
+			expected:     true,
+		},
+		{
+			name:      "ManualPromoted",
+			promotion: promotion.Manual,
+			promoted:  true,
+			expected:  false,
+		},
+		{
+			name:      "Unknown",
+			promotion: "automatic",
+			err:       promotion.ErrUnknownPromotion,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			policy, err := promotion.Parse(tc.promoteAfter, tc.promotion)
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+				return
+			}
+			assert.NilError(t, err)
+			assert.Equal(t, policy.Pending(now, tc.promoted), tc.expected)
+		})
+	}
+}
+
+func TestDigest(t *testing.T) {
+	digest, err := promotion.Digest(map[string]string{"version": "1"})
+	assert.NilError(t, err)
+	sameDigest, err := promotion.Digest(map[string]string{"version": "1"})
+	assert.NilError(t, err)
+	otherDigest, err := promotion.Digest(map[string]string{"version": "2"})
+	assert.NilError(t, err)
+	assert.Equal(t, digest, sameDigest)
+	assert.Assert(t, digest != otherDigest)
+}
@@ -0,0 +1,226 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rbac
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"cuelang.org/go/cue/format"
+	"github.com/kharf/declcd/pkg/component"
+	"github.com/kharf/declcd/pkg/helm"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var (
+	// Verbs needed to apply, read and collect objects.
+	manifestVerbs = []string{"get", "create", "patch", "update", "delete"}
+	// Verbs needed by Helm to manage release secrets.
+	releaseStorageVerbs = []string{"get", "list", "create", "patch", "update", "delete"}
+)
+
+// Policy contains the minimal RBAC rules an impersonated ServiceAccount needs
+// to reconcile the components of a project.
+type Policy struct {
+	// ClusterRules grant access to cluster scoped objects.
+	ClusterRules []rbacv1.PolicyRule
+	// NamespaceRules grant access to objects per namespace.
+	NamespaceRules map[string][]rbacv1.PolicyRule
+}
+
+type ruleSet map[string]map[string]map[string]struct{}
+
+func (set ruleSet) add(group string, resource string, verbs ...string) {
+	resources, found := set[group]
+	if !found {
+		resources = make(map[string]map[string]struct{})
+		set[group] = resources
+	}
+	resourceVerbs, found := resources[resource]
+	if !found {
+		resourceVerbs = make(map[string]struct{})
+		resources[resource] = resourceVerbs
+	}
+	for _, verb := range verbs {
+		resourceVerbs[verb] = struct{}{}
+	}
+}
+
+func (set ruleSet) rules() []rbacv1.PolicyRule {
+	rules := make([]rbacv1.PolicyRule, 0, len(set))
+	for group, resources := range set {
+		for resource, verbs := range resources {
+			rules = append(rules, rbacv1.PolicyRule{
+				APIGroups: []string{group},
+				Resources: []string{resource},
+				Verbs:     sortedKeys(verbs),
+			})
+		}
+	}
+	slices.SortFunc(rules, func(a, b rbacv1.PolicyRule) int {
+		if c := strings.Compare(a.APIGroups[0], b.APIGroups[0]); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Resources[0], b.Resources[0])
+	})
+	return rules
+}
+
+func sortedKeys(set map[string]struct{}) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// Generate derives the minimal RBAC rules needed to reconcile given components.
+// The resource and scope of objects are resolved with the given mapper,
+// or with the CustomResourceDefinitions declared by the components, if the cluster does not know them yet.
+// Namespaced objects without a namespace are applied to the default namespace.
+// The content of Helm Charts is unknown before rendering,
+// therefore HelmReleases are granted full access to their release namespace, but no rights on cluster scoped objects.
+// Charts containing cluster scoped objects, like CustomResourceDefinitions or ClusterRoles, need additional rules.
+func Generate(instances []component.Instance, mapper meta.RESTMapper) (Policy, error) {
+	mapper = meta.MultiRESTMapper{declaredMapper(instances), mapper}
+	clusterRules := ruleSet{}
+	namespaceRules := make(map[string]ruleSet)
+	namespaced := func(namespace string) ruleSet {
+		if namespace == "" {
+			namespace = "default"
+		}
+		rules, found := namespaceRules[namespace]
+		if !found {
+			rules = ruleSet{}
+			namespaceRules[namespace] = rules
+		}
+		return rules
+	}
+
+	addObject := func(id string, obj *unstructured.Unstructured) error {
+		gvk := obj.GroupVersionKind()
+		mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+		if err != nil {
+			return fmt.Errorf("%s: %w", id, err)
+		}
+		if mapping.Scope.Name() == meta.RESTScopeNameRoot {
+			clusterRules.add(gvk.Group, mapping.Resource.Resource, manifestVerbs...)
+		} else {
+			namespaced(obj.GetNamespace()).add(gvk.Group, mapping.Resource.Resource, manifestVerbs...)
+		}
+		return nil
+	}
+
+	for _, instance := range instances {
+		switch instance := instance.(type) {
+		case *component.Manifest:
+			if err := addObject(instance.ID, &instance.Content); err != nil {
+				return Policy{}, err
+			}
+		case *component.Hook:
+			if err := addObject(instance.ID, &instance.Content); err != nil {
+				return Policy{}, err
+			}
+		case *helm.ReleaseComponent:
+			rules := namespaced(instance.Content.Namespace)
+			rules.add("*", "*", "*")
+			rules.add("", "secrets", releaseStorageVerbs...)
+			clusterRules.add("", "namespaces", "get", "create")
+		}
+	}
+
+	policy := Policy{
+		ClusterRules:   clusterRules.rules(),
+		NamespaceRules: make(map[string][]rbacv1.PolicyRule, len(namespaceRules)),
+	}
+	for namespace, rules := range namespaceRules {
+		policy.NamespaceRules[namespace] = rules.rules()
+	}
+	return policy, nil
+}
+
+// declaredMapper maps the kinds of the CustomResourceDefinitions declared by given components.
+func declaredMapper(instances []component.Instance) meta.RESTMapper {
+	mapper := meta.NewDefaultRESTMapper(nil)
+	for _, instance := range instances {
+		manifest, ok := instance.(*component.Manifest)
+		if !ok {
+			continue
+		}
+		crd := manifest.Content.Object
+		if manifest.Content.GetKind() != "CustomResourceDefinition" ||
+			manifest.Content.GroupVersionKind().Group != "apiextensions.k8s.io" {
+			continue
+		}
+		group, _, _ := unstructured.NestedString(crd, "spec", "group")
+		kind, _, _ := unstructured.NestedString(crd, "spec", "names", "kind")
+		plural, _, _ := unstructured.NestedString(crd, "spec", "names", "plural")
+		singular, _, _ := unstructured.NestedString(crd, "spec", "names", "singular")
+		scopeName, _, _ := unstructured.NestedString(crd, "spec", "scope")
+		versions, _, _ := unstructured.NestedSlice(crd, "spec", "versions")
+		scope := meta.RESTScopeNamespace
+		if scopeName == "Cluster" {
+			scope = meta.RESTScopeRoot
+		}
+		for _, version := range versions {
+			versionName, _, _ := unstructured.NestedString(version.(map[string]interface{}), "name")
+			gvk := schema.GroupVersionKind{Group: group, Version: versionName, Kind: kind}
+			mapper.AddSpecific(
+				gvk,
+				gvk.GroupVersion().WithResource(plural),
+				gvk.GroupVersion().WithResource(singular),
+				scope,
+			)
+		}
+	}
+	return mapper
+}
+
+// ServiceAccount identifies the impersonated ServiceAccount of a GitOpsProject.
+type ServiceAccount struct {
+	Name      string
+	Namespace string
+}
+
+// CUE renders the policy as a Declcd RBAC Policy in given CUE package,
+// which declares the ClusterRole, Roles and their bindings to given ServiceAccount as Manifest Components.
+func (policy Policy) CUE(pkg string, serviceAccount ServiceAccount) ([]byte, error) {
+	clusterRules, err := json.Marshal(policy.ClusterRules)
+	if err != nil {
+		return nil, err
+	}
+	namespaceRules, err := json.Marshal(policy.NamespaceRules)
+	if err != nil {
+		return nil, err
+	}
+
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, "package %s\n\n", pkg)
+	buf.WriteString("import (\n\t\"github.com/kharf/declcd/schema/rbac\"\n)\n\n")
+	buf.WriteString("policy: rbac.#Policy & {\n")
+	fmt.Fprintf(buf, "\tserviceAccount: {name: %q, namespace: %q}\n", serviceAccount.Name, serviceAccount.Namespace)
+	fmt.Fprintf(buf, "\tclusterRules: %s\n", clusterRules)
+	fmt.Fprintf(buf, "\tnamespaceRules: %s\n", namespaceRules)
+	buf.WriteString("}\n")
+
+	return format.Source(buf.Bytes())
+}
@@ -0,0 +1,230 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rbac_test
+
+import (
+	"testing"
+
+	"github.com/kharf/declcd/pkg/component"
+	"github.com/kharf/declcd/pkg/helm"
+	"github.com/kharf/declcd/pkg/rbac"
+	"gotest.tools/v3/assert"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func clusterMapper() meta.RESTMapper {
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "Namespace"}, meta.RESTScopeRoot)
+	mapper.Add(schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}, meta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}, meta.RESTScopeNamespace)
+	mapper.Add(
+		schema.GroupVersionKind{Group: "apiextensions.k8s.io", Version: "v1", Kind: "CustomResourceDefinition"},
+		meta.RESTScopeRoot,
+	)
+	return mapper
+}
+
+func object(apiVersion string, kind string, name string, namespace string) unstructured.Unstructured {
+	obj := unstructured.Unstructured{}
+	obj.SetAPIVersion(apiVersion)
+	obj.SetKind(kind)
+	obj.SetName(name)
+	obj.SetNamespace(namespace)
+	return obj
+}
+
+func crd(scope string) unstructured.Unstructured {
+	obj := object("apiextensions.k8s.io/v1", "CustomResourceDefinition", "alerts.monitoring.declcd.io", "")
+	obj.Object["spec"] = map[string]interface{}{
+		"group": "monitoring.declcd.io",
+		"names": map[string]interface{}{
+			"kind":     "Alert",
+			"plural":   "alerts",
+			"singular": "alert",
+		},
+		"scope": scope,
+		"versions": []interface{}{
+			map[string]interface{}{"name": "v1"},
+		},
+	}
+	return obj
+}
+
+var manifestVerbs = []string{"create", "delete", "get", "patch", "update"}
+
+func TestGenerate(t *testing.T) {
+	testCases := []struct {
+		name                   string
+		instances              []component.Instance
+		expectedClusterRules   []rbacv1.PolicyRule
+		expectedNamespaceRules map[string][]rbacv1.PolicyRule
+		expectedErr            string
+	}{
+		{
+			name: "Manifests",
+			instances: []component.Instance{
+				&component.Manifest{
+					ID:      "prometheus___Namespace",
+					Content: object("v1", "Namespace", "prometheus", ""),
+				},
+				&component.Manifest{
+					ID:      "prometheus_prometheus_apps_Deployment",
+					Content: object("apps/v1", "Deployment", "prometheus", "prometheus"),
+				},
+				&component.Manifest{
+					ID:      "config___ConfigMap",
+					Content: object("v1", "ConfigMap", "config", ""),
+				},
+			},
+			expectedClusterRules: []rbacv1.PolicyRule{
+				{APIGroups: []string{""}, Resources: []string{"namespaces"}, Verbs: manifestVerbs},
+			},
+			expectedNamespaceRules: map[string][]rbacv1.PolicyRule{
+				"default": {
+					{APIGroups: []string{""}, Resources: []string{"configmaps"}, Verbs: manifestVerbs},
+				},
+				"prometheus": {
+					{APIGroups: []string{"apps"}, Resources: []string{"deployments"}, Verbs: manifestVerbs},
+				},
+			},
+		},
+		{
+			name: "DeclaredCustomResourceDefinition",
+			instances: []component.Instance{
+				&component.Manifest{
+					ID:      "alerts.monitoring.declcd.io__apiextensions.k8s.io_CustomResourceDefinition",
+					Content: crd("Namespaced"),
+				},
+				&component.Manifest{
+					ID:      "cpu_monitoring_monitoring.declcd.io_Alert",
+					Content: object("monitoring.declcd.io/v1", "Alert", "cpu", "monitoring"),
+				},
+			},
+			expectedClusterRules: []rbacv1.PolicyRule{
+				{
+					APIGroups: []string{"apiextensions.k8s.io"},
+					Resources: []string{"customresourcedefinitions"},
+					Verbs:     manifestVerbs,
+				},
+			},
+			expectedNamespaceRules: map[string][]rbacv1.PolicyRule{
+				"monitoring": {
+					{APIGroups: []string{"monitoring.declcd.io"}, Resources: []string{"alerts"}, Verbs: manifestVerbs},
+				},
+			},
+		},
+		{
+			name: "DeclaredClusterScopedCustomResourceDefinition",
+			instances: []component.Instance{
+				&component.Manifest{
+					ID:      "alerts.monitoring.declcd.io__apiextensions.k8s.io_CustomResourceDefinition",
+					Content: crd("Cluster"),
+				},
+				&component.Manifest{
+					ID:      "cpu__monitoring.declcd.io_Alert",
+					Content: object("monitoring.declcd.io/v1", "Alert", "cpu", ""),
+				},
+			},
+			expectedClusterRules: []rbacv1.PolicyRule{
+				{
+					APIGroups: []string{"apiextensions.k8s.io"},
+					Resources: []string{"customresourcedefinitions"},
+					Verbs:     manifestVerbs,
+				},
+				{APIGroups: []string{"monitoring.declcd.io"}, Resources: []string{"alerts"}, Verbs: manifestVerbs},
+			},
+			expectedNamespaceRules: map[string][]rbacv1.PolicyRule{},
+		},
+		{
+			name: "HelmReleaseOnlyGetsNamespacedRights",
+			instances: []component.Instance{
+				&helm.ReleaseComponent{
+					ID: "grafana_monitoring_HelmRelease",
+					Content: helm.ReleaseDeclaration{
+						Name:      "grafana",
+						Namespace: "monitoring",
+					},
+				},
+			},
+			expectedClusterRules: []rbacv1.PolicyRule{
+				{APIGroups: []string{""}, Resources: []string{"namespaces"}, Verbs: []string{"create", "get"}},
+			},
+			expectedNamespaceRules: map[string][]rbacv1.PolicyRule{
+				"monitoring": {
+					{
+						APIGroups: []string{""},
+						Resources: []string{"secrets"},
+						Verbs:     []string{"create", "delete", "get", "list", "patch", "update"},
+					},
+					{APIGroups: []string{"*"}, Resources: []string{"*"}, Verbs: []string{"*"}},
+				},
+			},
+		},
+		{
+			name: "UnknownKind",
+			instances: []component.Instance{
+				&component.Manifest{
+					ID:      "cpu_monitoring_monitoring.declcd.io_Alert",
+					Content: object("monitoring.declcd.io/v1", "Alert", "cpu", "monitoring"),
+				},
+			},
+			expectedErr: "cpu_monitoring_monitoring.declcd.io_Alert: no matches for kind \"Alert\" in version \"monitoring.declcd.io/v1\"",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			policy, err := rbac.Generate(tc.instances, clusterMapper())
+			if tc.expectedErr != "" {
+				assert.ErrorContains(t, err, tc.expectedErr)
+				return
+			}
+			assert.NilError(t, err)
+			assert.DeepEqual(t, policy.ClusterRules, tc.expectedClusterRules)
+			assert.DeepEqual(t, policy.NamespaceRules, tc.expectedNamespaceRules)
+		})
+	}
+}
+
+func TestPolicy_CUE(t *testing.T) {
+	policy := rbac.Policy{
+		ClusterRules: []rbacv1.PolicyRule{
+			{APIGroups: []string{""}, Resources: []string{"namespaces"}, Verbs: []string{"create", "get"}},
+		},
+		NamespaceRules: map[string][]rbacv1.PolicyRule{
+			"monitoring": {
+				{APIGroups: []string{"apps"}, Resources: []string{"deployments"}, Verbs: []string{"get"}},
+			},
+		},
+	}
+
+	content, err := policy.CUE("rbac", rbac.ServiceAccount{Name: "tenant", Namespace: "declcd-system"})
+	assert.NilError(t, err)
+	assert.Equal(t, string(content), `package rbac
+
+import (
+	"github.com/kharf/declcd/schema/rbac"
+)
+
+policy: rbac.#Policy & {
+	serviceAccount: {name: "tenant", namespace: "declcd-system"}
+	clusterRules: [{"verbs": ["create", "get"], "apiGroups": [""], "resources": ["namespaces"]}]
+	namespaceRules: {"monitoring": [{"verbs": ["get"], "apiGroups": ["apps"], "resources": ["deployments"]}]}
+}
+`)
+}
@@ -0,0 +1,233 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	htmlTemplate "html/template"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	textTemplate "text/template"
+	"time"
+)
+
+var (
+	ErrUnknownFormat        = errors.New("Unknown report format")
+	ErrUnexpectedStatusCode = errors.New("Unexpected status code")
+)
+
+// Format of a rendered report.
+type Format string
+
+const (
+	Markdown Format = "markdown"
+	HTML     Format = "html"
+)
+
+func (format Format) extension() string {
+	if format == HTML {
+		return "html"
+	}
+	return "md"
+}
+
+func (format Format) contentType() string {
+	if format == HTML {
+		return "text/html; charset=utf-8"
+	}
+	return "text/markdown; charset=utf-8"
+}
+
+// Finding is the outcome of reconciling all components targeting a namespace.
+type Finding struct {
+	// Namespace is empty for cluster scoped components.
+	Namespace string
+	Succeeded bool
+	Message   string
+}
+
+// Report summarizes a reconciliation of a GitOpsProject for compliance reporting.
+type Report struct {
+	Project  string
+	URL      string
+	Revision string
+	Time     time.Time
+	Findings []Finding
+}
+
+// Failed returns the number of findings, which did not succeed.
+func (report Report) Failed() int {
+	failed := 0
+	for _, finding := range report.Findings {
+		if !finding.Succeeded {
+			failed++
+		}
+	}
+	return failed
+}
+
+const markdownTemplate = `# Declcd Report: {{ .Project }}
+
+| Repository | Revision | Time | Failed |
+| --- | --- | --- | --- |
+| {{ .URL }} | {{ .Revision }} | {{ .Time.UTC.Format "2006-01-02T15:04:05Z07:00" }} | {{ .Failed }} |
+
+## Namespaces
+
+| Namespace | Status | Message |
+| --- | --- | --- |
+{{- range .Findings }}
+| {{ namespace .Namespace }} | {{ status .Succeeded }} | {{ cell .Message }} |
+{{- end }}
+`
+
+const htmlTemplateContent = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Declcd Report: {{ .Project }}</title>
+</head>
+<body>
+<h1>Declcd Report: {{ .Project }}</h1>
+<table>
+<tr><th>Repository</th><th>Revision</th><th>Time</th><th>Failed</th></tr>
+<tr><td>{{ .URL }}</td><td>{{ .Revision }}</td><td>{{ .Time.UTC.Format "2006-01-02T15:04:05Z07:00" }}</td><td>{{ .Failed }}</td></tr>
+</table>
+<h2>Namespaces</h2>
+<table>
+<tr><th>Namespace</th><th>Status</th><th>Message</th></tr>
+{{- range .Findings }}
+<tr><td>{{ namespace .Namespace }}</td><td>{{ status .Succeeded }}</td><td>{{ .Message }}</td></tr>
+{{- end }}
+</table>
+</body>
+</html>
+`
+
+var templateFuncs = map[string]interface{}{
+	"namespace": func(namespace string) string {
+		if namespace == "" {
+			return "(cluster)"
+		}
+		return namespace
+	},
+	"status": func(succeeded bool) string {
+		if succeeded {
+			return "Succeeded"
+		}
+		return "Failed"
+	},
+	// cell keeps a message within a single markdown table cell.
+	"cell": func(message string) string {
+		message = strings.ReplaceAll(message, "|", "\\|")
+		return strings.ReplaceAll(message, "\n", " ")
+	},
+}
+
+var (
+	markdown = textTemplate.Must(textTemplate.New("markdown").Funcs(templateFuncs).Parse(markdownTemplate))
+	html     = htmlTemplate.Must(htmlTemplate.New("html").Funcs(templateFuncs).Parse(htmlTemplateContent))
+)
+
+// Render writes the report in the given format.
+func Render(w io.Writer, report Report, format Format) error {
+	switch format {
+	case Markdown:
+		return markdown.Execute(w, report)
+	case HTML:
+		return html.Execute(w, report)
+	}
+	return fmt.Errorf("%w: %s", ErrUnknownFormat, format)
+}
+
+// Sink stores or forwards a rendered report.
+type Sink interface {
+	Write(ctx context.Context, report Report, format Format, content []byte) error
+}
+
+// DirectorySink writes reports to a directory, e.g. a mounted PVC.
+// Every report is stored as <project>/<time>.<ext> and additionally as <project>/latest.<ext>.
+type DirectorySink struct {
+	Path string
+}
+
+var _ Sink = (*DirectorySink)(nil)
+
+func (sink *DirectorySink) Write(ctx context.Context, report Report, format Format, content []byte) error {
+	dir := filepath.Join(sink.Path, report.Project)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	name := report.Time.UTC().Format("20060102T150405Z")
+	if err := os.WriteFile(filepath.Join(dir, name+"."+format.extension()), content, 0600); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, "latest."+format.extension()), content, 0600)
+}
+
+// WebhookSink posts reports to an url.
+type WebhookSink struct {
+	HTTPClient *http.Client
+	URL        string
+}
+
+var _ Sink = (*WebhookSink)(nil)
+
+func (sink *WebhookSink) Write(ctx context.Context, report Report, format Format, content []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sink.URL, bytes.NewReader(content))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", format.contentType())
+
+	resp, err := sink.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%w: %d", ErrUnexpectedStatusCode, resp.StatusCode)
+	}
+	return nil
+}
+
+// Reporter renders reports and hands them to all sinks.
+type Reporter struct {
+	Format Format
+	Sinks  []Sink
+}
+
+// Publish renders the report once and writes it to every sink.
+// All sinks are tried, even if one of them fails.
+func (reporter *Reporter) Publish(ctx context.Context, report Report) error {
+	var buf bytes.Buffer
+	if err := Render(&buf, report, reporter.Format); err != nil {
+		return err
+	}
+	var errs []error
+	for _, sink := range reporter.Sinks {
+		if err := sink.Write(ctx, report, reporter.Format, buf.Bytes()); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,145 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kharf/declcd/pkg/report"
+	"gotest.tools/v3/assert"
+)
+
+var testReport = report.Report{
+	Project:  "test",
+	URL:      "git@github.com:kharf/declcd.git",
+	Revision: "abc",
+	Time:     time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC),
+	Findings: []report.Finding{
+		{
+			Namespace: "",
+			Succeeded: true,
+		},
+		{
+			Namespace: "prometheus",
+			Succeeded: false,
+			Message:   "apply failed: <conflict> | field manager",
+		},
+	},
+}
+
+func TestRender(t *testing.T) {
+	testCases := []struct {
+		name     string
+		format   report.Format
+		contains []string
+		err      error
+	}{
+		{
+			name:   "Markdown",
+			format: report.Markdown,
+			contains: []string{
+				"# Declcd Report: test",
+				"| git@github.com:kharf/declcd.git | abc | 2024-01-01T12:00:00Z | 1 |",
+				"| (cluster) | Succeeded |  |",
+				"| prometheus | Failed | apply failed: <conflict> \\| field manager |",
+			},
+		},
+		{
+			name:   "HTML",
+			format: report.HTML,
+			contains: []string{
+				"<h1>Declcd Report: test</h1>",
+				"<td>prometheus</td><td>Failed</td><td>apply failed: &lt;conflict&gt; | field manager</td>",
+			},
+		},
+		{
+			name:   "Unknown",
+			format: report.Format("pdf"),
+			err:    report.ErrUnknownFormat,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := report.Render(&buf, testReport, tc.format)
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+				return
+			}
+			assert.NilError(t, err)
+			for _, expected := range tc.contains {
+				assert.Assert(t, strings.Contains(buf.String(), expected), expected)
+			}
+		})
+	}
+}
+
+func TestReporter_Publish(t *testing.T) {
+	var received string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, r.Header.Get("Content-Type"), "text/markdown; charset=utf-8")
+		body, err := io.ReadAll(r.Body)
+		assert.NilError(t, err)
+		received = string(body)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	reporter := report.Reporter{
+		Format: report.Markdown,
+		Sinks: []report.Sink{
+			&report.DirectorySink{Path: dir},
+			&report.WebhookSink{HTTPClient: server.Client(), URL: server.URL},
+		},
+	}
+	err := reporter.Publish(context.Background(), testReport)
+	assert.NilError(t, err)
+
+	stored, err := os.ReadFile(filepath.Join(dir, "test", "20240101T120000Z.md"))
+	assert.NilError(t, err)
+	latest, err := os.ReadFile(filepath.Join(dir, "test", "latest.md"))
+	assert.NilError(t, err)
+	assert.Equal(t, string(stored), string(latest))
+	assert.Equal(t, received, string(stored))
+}
+
+func TestWebhookSink_Write_Timeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	client := server.Client()
+	client.Timeout = 100 * time.Millisecond
+	sink := &report.WebhookSink{HTTPClient: client, URL: server.URL}
+	start := time.Now()
+	err := sink.Write(context.Background(), testReport, report.Markdown, []byte("report"))
+	assert.ErrorContains(t, err, "Client.Timeout exceeded")
+	assert.Assert(t, time.Since(start) < 5*time.Second)
+}
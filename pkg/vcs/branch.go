@@ -0,0 +1,172 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vcs
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/storage/memory"
+)
+
+// ScratchBranch is a branch of a remote repository, whose content is written by Declcd, e.g. for smoke tests.
+// It does not share any history with the other branches of the repository.
+type ScratchBranch struct {
+	Name string
+
+	gitRepository *git.Repository
+	path          string
+	authMethod    transport.AuthMethod
+}
+
+// NewScratchBranch prepares a branch of a remote repository with a local repository at targetPath.
+// The branch is pushed with the auth Secret of the project on its first commit.
+func (manager RepositoryManager) NewScratchBranch(
+	ctx context.Context,
+	remoteURL string,
+	targetPath string,
+	projectName string,
+	branch string,
+) (*ScratchBranch, error) {
+	authMethod, err := manager.authMethod(ctx, projectName)
+	if err != nil {
+		return nil, err
+	}
+	gitRepository, err := git.PlainInit(targetPath, false)
+	if err != nil {
+		return nil, gitError(err)
+	}
+	if _, err := gitRepository.CreateRemote(&config.RemoteConfig{
+		Name: git.DefaultRemoteName,
+		URLs: []string{remoteURL},
+	}); err != nil {
+		return nil, gitError(err)
+	}
+	return &ScratchBranch{
+		Name:          branch,
+		gitRepository: gitRepository,
+		path:          targetPath,
+		authMethod:    authMethod,
+	}, nil
+}
+
+// Commit replaces the content of the branch with the files, which are keyed by their path relative to the repository,
+// and pushes it. It returns the hash of the commit.
+func (branch *ScratchBranch) Commit(ctx context.Context, files map[string][]byte, message string) (string, error) {
+	entries, err := os.ReadDir(branch.path)
+	if err != nil {
+		return "", err
+	}
+	for _, entry := range entries {
+		if entry.Name() == git.GitDirName {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(branch.path, entry.Name())); err != nil {
+			return "", err
+		}
+	}
+	for file, content := range files {
+		path := filepath.Join(branch.path, filepath.FromSlash(file))
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			return "", err
+		}
+		if err := os.WriteFile(path, content, 0600); err != nil {
+			return "", err
+		}
+	}
+
+	worktree, err := branch.gitRepository.Worktree()
+	if err != nil {
+		return "", gitError(err)
+	}
+	if err := worktree.AddWithOptions(&git.AddOptions{All: true}); err != nil {
+		return "", gitError(err)
+	}
+	hash, err := worktree.Commit(message, &git.CommitOptions{
+		Author: &object.Signature{
+			Name:  "declcd",
+			Email: "declcd@declcd.io",
+			When:  time.Now(),
+		},
+		AllowEmptyCommits: true,
+	})
+	if err != nil {
+		return "", gitError(err)
+	}
+
+	head, err := branch.gitRepository.Head()
+	if err != nil {
+		return "", gitError(err)
+	}
+	if err := branch.push(ctx, config.RefSpec(fmt.Sprintf("%s:refs/heads/%s", head.Name(), branch.Name))); err != nil {
+		return "", err
+	}
+	return hash.String(), nil
+}
+
+// Delete removes the branch from the remote repository.
+func (branch *ScratchBranch) Delete(ctx context.Context) error {
+	return branch.push(ctx, config.RefSpec(fmt.Sprintf(":refs/heads/%s", branch.Name)))
+}
+
+func (branch *ScratchBranch) push(ctx context.Context, refSpec config.RefSpec) error {
+	err := branch.gitRepository.PushContext(ctx, &git.PushOptions{
+		RemoteName: git.DefaultRemoteName,
+		RefSpecs:   []config.RefSpec{refSpec},
+		Auth:       branch.authMethod,
+	})
+	if err != nil && err != git.NoErrAlreadyUpToDate {
+		return gitError(err)
+	}
+	return nil
+}
+
+// ListBranches returns the names of all branches of a remote vcs repository without cloning it.
+func (manager RepositoryManager) ListBranches(
+	ctx context.Context,
+	remoteURL string,
+	projectName string,
+) ([]string, error) {
+	authMethod, err := manager.authMethod(ctx, projectName)
+	if err != nil {
+		return nil, err
+	}
+
+	remote := git.NewRemote(memory.NewStorage(), &config.RemoteConfig{
+		Name: git.DefaultRemoteName,
+		URLs: []string{remoteURL},
+	})
+	refs, err := remote.ListContext(ctx, &git.ListOptions{
+		Auth: authMethod,
+	})
+	if err != nil {
+		return nil, gitError(err)
+	}
+
+	branches := make([]string, 0, len(refs))
+	for _, ref := range refs {
+		if ref.Name().IsBranch() {
+			branches = append(branches, ref.Name().Short())
+		}
+	}
+	return branches, nil
+}
@@ -0,0 +1,60 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vcs_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/kharf/declcd/internal/gittest"
+	"github.com/kharf/declcd/internal/projecttest"
+	"gotest.tools/v3/assert"
+)
+
+func TestRepositoryManager_ListBranches(t *testing.T) {
+	remoteRepository, err := gittest.SetupGitRepository()
+	assert.NilError(t, err)
+	defer remoteRepository.Clean()
+
+	remoteGitRepository, err := git.PlainOpen(remoteRepository.Directory)
+	assert.NilError(t, err)
+	head, err := remoteGitRepository.Head()
+	assert.NilError(t, err)
+	for _, ref := range []*plumbing.Reference{
+		plumbing.NewHashReference(plumbing.NewBranchReferenceName("preview/login"), head.Hash()),
+		plumbing.NewHashReference(plumbing.NewBranchReferenceName("feature"), head.Hash()),
+		plumbing.NewHashReference(plumbing.NewTagReferenceName("v1.0.0"), head.Hash()),
+	} {
+		assert.NilError(t, remoteGitRepository.Storer.SetReference(ref))
+	}
+
+	env := projecttest.StartProjectEnv(t)
+	defer env.Stop()
+	branches, err := env.RepositoryManager.ListBranches(env.Ctx, remoteRepository.Directory, "branches")
+	assert.NilError(t, err)
+	slices.Sort(branches)
+	expected := []string{"feature", head.Name().Short(), "preview/login"}
+	slices.Sort(expected)
+	assert.DeepEqual(t, branches, expected)
+}
+
+func TestRepositoryManager_ListBranches_UnknownRepository(t *testing.T) {
+	env := projecttest.StartProjectEnv(t)
+	defer env.Stop()
+	_, err := env.RepositoryManager.ListBranches(env.Ctx, t.TempDir(), "branches")
+	assert.Assert(t, err != nil)
+}
@@ -0,0 +1,165 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vcs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	gogithub "github.com/google/go-github/v62/github"
+	gogitlab "github.com/xanzy/go-gitlab"
+)
+
+var (
+	ErrUnsupportedCheckProvider = errors.New("Unsupported check provider")
+)
+
+// CheckState is the state of a commit status or check run reported on a Git provider.
+type CheckState string
+
+const (
+	CheckSucceeded CheckState = "Succeeded"
+	CheckPending   CheckState = "Pending"
+	CheckFailed    CheckState = "Failed"
+	// CheckMissing means no status or check run with the name has been reported for the commit.
+	CheckMissing CheckState = "Missing"
+)
+
+// CheckReader reads the state of a named commit check, e.g. a CI job validating the commit.
+type CheckReader interface {
+	ReadCheck(ctx context.Context, repoID string, commit string, name string) (CheckState, error)
+}
+
+// NewCheckReader returns a [CheckReader] for GitHub or GitLab.
+// The base url optionally points to a GitHub Enterprise or self-managed GitLab instance.
+func NewCheckReader(httpClient *http.Client, provider string, baseURL string, token string) (CheckReader, error) {
+	switch provider {
+	case GitHub:
+		client := NewGithubClient(httpClient, token)
+		if baseURL != "" {
+			enterpriseClient, err := client.client.WithEnterpriseURLs(baseURL, baseURL)
+			if err != nil {
+				return nil, err
+			}
+			client.client = enterpriseClient
+		}
+		return client, nil
+	case GitLab:
+		opts := []gogitlab.ClientOptionFunc{gogitlab.WithHTTPClient(httpClient)}
+		if baseURL != "" {
+			opts = append(opts, gogitlab.WithBaseURL(baseURL))
+		}
+		client, err := gogitlab.NewClient(token, opts...)
+		if err != nil {
+			return nil, err
+		}
+		return &gitlabClient{client: client}, nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedCheckProvider, provider)
+	}
+}
+
+var _ CheckReader = (*githubClient)(nil)
+
+// ReadCheck considers both commit statuses and check runs, as CI systems report either of them.
+func (g *githubClient) ReadCheck(
+	ctx context.Context,
+	id string,
+	commit string,
+	name string,
+) (CheckState, error) {
+	owner, repo, found := strings.Cut(id, "/")
+	if !found {
+		return "", fmt.Errorf(
+			"%w: %s doesn't correspond to the owner/repo format",
+			ErrRepositoryID,
+			id,
+		)
+	}
+
+	checkRuns, _, err := g.client.Checks.ListCheckRunsForRef(ctx, owner, repo, commit, &gogithub.ListCheckRunsOptions{
+		CheckName: &name,
+	})
+	if err != nil {
+		return "", err
+	}
+	// Check runs are sorted from newest to oldest.
+	for _, checkRun := range checkRuns.CheckRuns {
+		if checkRun.GetStatus() != "completed" {
+			return CheckPending, nil
+		}
+		if checkRun.GetConclusion() == "success" {
+			return CheckSucceeded, nil
+		}
+		return CheckFailed, nil
+	}
+
+	statuses, _, err := g.client.Repositories.ListStatuses(ctx, owner, repo, commit, &gogithub.ListOptions{
+		PerPage: 100,
+	})
+	if err != nil {
+		return "", err
+	}
+	// Statuses are sorted from newest to oldest.
+	for _, status := range statuses {
+		if status.GetContext() != name {
+			continue
+		}
+		switch status.GetState() {
+		case "success":
+			return CheckSucceeded, nil
+		case "pending":
+			return CheckPending, nil
+		default:
+			return CheckFailed, nil
+		}
+	}
+
+	return CheckMissing, nil
+}
+
+var _ CheckReader = (*gitlabClient)(nil)
+
+// ReadCheck reads the latest commit status with the name, e.g. of a pipeline job.
+func (g *gitlabClient) ReadCheck(
+	ctx context.Context,
+	id string,
+	commit string,
+	name string,
+) (CheckState, error) {
+	statuses, _, err := g.client.Commits.GetCommitStatuses(id, commit, &gogitlab.GetCommitStatusesOptions{
+		Name: &name,
+	}, gogitlab.WithContext(ctx))
+	if err != nil {
+		return "", err
+	}
+	for _, status := range statuses {
+		if status.Name != name {
+			continue
+		}
+		switch status.Status {
+		case "success":
+			return CheckSucceeded, nil
+		case "created", "waiting_for_resource", "preparing", "pending", "running", "scheduled", "manual":
+			return CheckPending, nil
+		default:
+			return CheckFailed, nil
+		}
+	}
+	return CheckMissing, nil
+}
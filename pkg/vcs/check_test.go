@@ -0,0 +1,159 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vcs_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/kharf/declcd/pkg/vcs"
+	"gotest.tools/v3/assert"
+)
+
+func TestCheckReader_ReadCheck(t *testing.T) {
+	testCases := []struct {
+		name      string
+		provider  string
+		checkRuns string
+		statuses  string
+		expected  vcs.CheckState
+	}{
+		{
+			name:      "GitHubCheckRunSucceeded",
+			provider:  vcs.GitHub,
+			checkRuns: `{"total_count":1,"check_runs":[{"name":"ci","status":"completed","conclusion":"success"}]}`,
+			expected:  vcs.CheckSucceeded,
+		},
+		{
+			name:      "GitHubCheckRunFailed",
+			provider:  vcs.GitHub,
+			checkRuns: `{"total_count":1,"check_runs":[{"name":"ci","status":"completed","conclusion":"failure"}]}`,
+			expected:  vcs.CheckFailed,
+		},
+		{
+			name:      "GitHubCheckRunPending",
+			provider:  vcs.GitHub,
+			checkRuns: `{"total_count":1,"check_runs":[{"name":"ci","status":"in_progress"}]}`,
+			expected:  vcs.CheckPending,
+		},
+		{
+			name:      "GitHubStatusSucceeded",
+			provider:  vcs.GitHub,
+			checkRuns: `{"total_count":0,"check_runs":[]}`,
+			statuses:  `[{"context":"lint","state":"failure"},{"context":"ci","state":"success"}]`,
+			expected:  vcs.CheckSucceeded,
+		},
+		{
+			name:      "GitHubStatusPending",
+			provider:  vcs.GitHub,
+			checkRuns: `{"total_count":0,"check_runs":[]}`,
+			statuses:  `[{"context":"ci","state":"pending"},{"context":"ci","state":"failure"}]`,
+			expected:  vcs.CheckPending,
+		},
+		{
+			name:      "GitHubStatusFailed",
+			provider:  vcs.GitHub,
+			checkRuns: `{"total_count":0,"check_runs":[]}`,
+			statuses:  `[{"context":"ci","state":"error"}]`,
+			expected:  vcs.CheckFailed,
+		},
+		{
+			name:      "GitHubMissing",
+			provider:  vcs.GitHub,
+			checkRuns: `{"total_count":0,"check_runs":[]}`,
+			statuses:  `[{"context":"lint","state":"success"}]`,
+			expected:  vcs.CheckMissing,
+		},
+		{
+			name:     "GitLabSucceeded",
+			provider: vcs.GitLab,
+			statuses: `[{"name":"ci","status":"success"}]`,
+			expected: vcs.CheckSucceeded,
+		},
+		{
+			name:     "GitLabPending",
+			provider: vcs.GitLab,
+			statuses: `[{"name":"ci","status":"running"}]`,
+			expected: vcs.CheckPending,
+		},
+		{
+			name:     "GitLabFailed",
+			provider: vcs.GitLab,
+			statuses: `[{"name":"ci","status":"failed"}]`,
+			expected: vcs.CheckFailed,
+		},
+		{
+			name:     "GitLabMissing",
+			provider: vcs.GitLab,
+			statuses: `[]`,
+			expected: vcs.CheckMissing,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mux := http.NewServeMux()
+			mux.HandleFunc("/api/v3/repos/owner/repo/commits/abc/check-runs", func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, r.URL.Query().Get("check_name"), "ci")
+				w.Write([]byte(tc.checkRuns))
+			})
+			mux.HandleFunc("/api/v3/repos/owner/repo/commits/abc/statuses", func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(tc.statuses))
+			})
+			mux.HandleFunc("/api/v4/projects/", func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, r.URL.EscapedPath(), "/api/v4/projects/owner%2Frepo/repository/commits/abc/statuses")
+				assert.Equal(t, r.URL.Query().Get("name"), "ci")
+				w.Write([]byte(tc.statuses))
+			})
+			server := httptest.NewServer(mux)
+			defer server.Close()
+
+			reader, err := vcs.NewCheckReader(server.Client(), tc.provider, server.URL, "token")
+			assert.NilError(t, err)
+			state, err := reader.ReadCheck(context.Background(), "owner/repo", "abc", "ci")
+			assert.NilError(t, err)
+			assert.Equal(t, state, tc.expected)
+		})
+	}
+}
+
+func TestCheckReader_ReadCheck_Timeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	client := server.Client()
+	client.Timeout = 100 * time.Millisecond
+	reader, err := vcs.NewCheckReader(client, vcs.GitHub, server.URL, "token")
+	assert.NilError(t, err)
+	start := time.Now()
+	_, err = reader.ReadCheck(context.Background(), "owner/repo", "abc", "ci")
+	assert.ErrorContains(t, err, "Client.Timeout exceeded")
+	assert.Assert(t, time.Since(start) < 5*time.Second)
+}
+
+func TestNewCheckReader_UnsupportedProvider(t *testing.T) {
+	_, err := vcs.NewCheckReader(http.DefaultClient, "bitbucket", "", "token")
+	assert.ErrorIs(t, err, vcs.ErrUnsupportedCheckProvider)
+}
@@ -0,0 +1,113 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vcs
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/format/index"
+	"github.com/go-git/go-git/v5/plumbing/format/packfile"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+)
+
+// FailureClass categorizes why a Git operation failed.
+type FailureClass string
+
+const (
+	// FailureAuth indicates missing or rejected credentials.
+	FailureAuth FailureClass = "Auth"
+	// FailureNetwork indicates that the remote could not be reached.
+	FailureNetwork FailureClass = "Network"
+	// FailureRefNotFound indicates that the remote repository or branch does not exist.
+	FailureRefNotFound FailureClass = "RefNotFound"
+	// FailureCorruption indicates a broken local clone, which is recloned automatically.
+	FailureCorruption FailureClass = "Corruption"
+	// FailureUnknown is any other failure.
+	FailureUnknown FailureClass = "Unknown"
+)
+
+// GitError is returned by a [Repository] and its [RepositoryManager] for failed Git operations.
+type GitError struct {
+	Class FailureClass
+	Err   error
+}
+
+var _ error = (*GitError)(nil)
+
+func (e *GitError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *GitError) Unwrap() error {
+	return e.Err
+}
+
+// gitError classifies err, unless it is nil or already classified.
+func gitError(err error) error {
+	var gitErr *GitError
+	if err == nil || errors.As(err, &gitErr) {
+		return err
+	}
+	return &GitError{
+		Class: Classify(err),
+		Err:   err,
+	}
+}
+
+// Classify determines the class of an error returned by go-git.
+func Classify(err error) FailureClass {
+	var gitErr *GitError
+	if errors.As(err, &gitErr) {
+		return gitErr.Class
+	}
+
+	switch {
+	case errors.Is(err, transport.ErrAuthenticationRequired),
+		errors.Is(err, transport.ErrAuthorizationFailed),
+		errors.Is(err, transport.ErrInvalidAuthMethod),
+		strings.Contains(err.Error(), "unable to authenticate"):
+		return FailureAuth
+
+	case errors.Is(err, transport.ErrRepositoryNotFound),
+		errors.Is(err, transport.ErrEmptyRemoteRepository),
+		errors.Is(err, plumbing.ErrReferenceNotFound),
+		errors.Is(err, git.NoMatchingRefSpecError{}):
+		return FailureRefNotFound
+
+	case errors.Is(err, plumbing.ErrObjectNotFound),
+		errors.Is(err, packfile.ErrInvalidObject),
+		errors.Is(err, packfile.ErrZLib),
+		errors.Is(err, packfile.ErrBadSignature),
+		errors.Is(err, index.ErrMalformedSignature),
+		errors.Is(err, index.ErrInvalidChecksum),
+		errors.Is(err, git.ErrRepositoryIncomplete),
+		errors.Is(err, git.ErrNonFastForwardUpdate),
+		errors.Is(err, git.ErrUnstagedChanges),
+		errors.Is(err, git.ErrWorktreeNotClean):
+		return FailureCorruption
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) || errors.Is(err, context.DeadlineExceeded) {
+		return FailureNetwork
+	}
+
+	return FailureUnknown
+}
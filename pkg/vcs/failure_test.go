@@ -0,0 +1,87 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vcs_test
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/kharf/declcd/pkg/vcs"
+	"gotest.tools/v3/assert"
+)
+
+func TestClassify(t *testing.T) {
+	testCases := []struct {
+		name     string
+		err      error
+		expected vcs.FailureClass
+	}{
+		{
+			name:     "Auth",
+			err:      fmt.Errorf("pull: %w", transport.ErrAuthenticationRequired),
+			expected: vcs.FailureAuth,
+		},
+		{
+			name:     "SSHAuth",
+			err:      errors.New("ssh: handshake failed: ssh: unable to authenticate, attempted methods [none publickey]"),
+			expected: vcs.FailureAuth,
+		},
+		{
+			name:     "RepositoryNotFound",
+			err:      transport.ErrRepositoryNotFound,
+			expected: vcs.FailureRefNotFound,
+		},
+		{
+			name:     "RefNotFound",
+			err:      plumbing.ErrReferenceNotFound,
+			expected: vcs.FailureRefNotFound,
+		},
+		{
+			name:     "Network",
+			err:      &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")},
+			expected: vcs.FailureNetwork,
+		},
+		{
+			name:     "Corruption",
+			err:      fmt.Errorf("pull: %w", plumbing.ErrObjectNotFound),
+			expected: vcs.FailureCorruption,
+		},
+		{
+			name:     "DivergedClone",
+			err:      git.ErrNonFastForwardUpdate,
+			expected: vcs.FailureCorruption,
+		},
+		{
+			name:     "Classified",
+			err:      fmt.Errorf("load: %w", &vcs.GitError{Class: vcs.FailureNetwork, Err: errors.New("timeout")}),
+			expected: vcs.FailureNetwork,
+		},
+		{
+			name:     "Unknown",
+			err:      errors.New("something"),
+			expected: vcs.FailureUnknown,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, vcs.Classify(tc.err), tc.expected)
+		})
+	}
+}
@@ -15,6 +15,7 @@
 package vcs
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
@@ -23,10 +24,13 @@ import (
 	"strings"
 
 	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/transport"
 	"github.com/go-git/go-git/v5/plumbing/transport/ssh"
 	"github.com/go-logr/logr"
 	"github.com/kharf/declcd/pkg/kube"
+	cryptoSSH "golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
 	v1 "k8s.io/api/core/v1"
 	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -39,24 +43,38 @@ const (
 	K8sSecretDataAuthTypeSSH = "ssh"
 	SSHKey                   = "identity"
 	SSHPubKey                = "identity.pub"
+	// SSHKeyPassphrase optionally decrypts a passphrase protected key stored under [SSHKey].
+	SSHKeyPassphrase = "passphrase"
+	// SSHKnownHosts optionally holds known_hosts entries, which the host key of the Git server is verified against.
+	// Without them, the known_hosts files of the controller are used.
+	SSHKnownHosts = "known_hosts"
 )
 
 // A vcs Repository.
 type Repository struct {
-	Path string
-	pull PullFunc
+	Path     string
+	pull     PullFunc
+	checkout CheckoutFunc
 }
 
 type PullFunc = func() (string, error)
 
-func NewRepository(path string, pull PullFunc) Repository {
-	return Repository{Path: path, pull: pull}
+type CheckoutFunc = func(revision string) (string, error)
+
+func NewRepository(path string, pull PullFunc, checkout CheckoutFunc) Repository {
+	return Repository{Path: path, pull: pull, checkout: checkout}
 }
 
 func (repository *Repository) Pull() (string, error) {
 	return repository.pull()
 }
 
+// Checkout fetches the remote repository and checks out the given commit SHA or tag,
+// regardless of the branch head. It returns the hash of the checked out commit.
+func (repository *Repository) Checkout(revision string) (string, error) {
+	return repository.checkout(revision)
+}
+
 // RepositoryManager clones a remote vcs repository to a local path.
 type RepositoryManager struct {
 	controllerNamespace string
@@ -82,8 +100,11 @@ func (manager RepositoryManager) getAuthMethodFromSecret(
 	var authMethod transport.AuthMethod
 	switch string(secret.Data[K8sSecretDataAuthType]) {
 	case "ssh":
-		priv := secret.Data[SSHKey]
-		public, err := ssh.NewPublicKeys("git", priv, "")
+		public, err := sshAuthMethod(
+			secret.Data[SSHKey],
+			secret.Data[SSHKeyPassphrase],
+			secret.Data[SSHKnownHosts],
+		)
 		if err != nil {
 			return nil, err
 		}
@@ -92,6 +113,42 @@ func (manager RepositoryManager) getAuthMethodFromSecret(
 	return authMethod, nil
 }
 
+func sshAuthMethod(privateKey []byte, passphrase []byte, knownHosts []byte) (*ssh.PublicKeys, error) {
+	public, err := ssh.NewPublicKeys("git", privateKey, string(passphrase))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidSSHKey, err)
+	}
+	if len(knownHosts) != 0 {
+		public.HostKeyCallback, err = knownHostsCallback(knownHosts)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return public, nil
+}
+
+// knownHostsCallback verifies host keys against known_hosts entries.
+func knownHostsCallback(knownHosts []byte) (cryptoSSH.HostKeyCallback, error) {
+	// knownhosts only reads files, which are parsed immediately.
+	file, err := os.CreateTemp("", "known_hosts")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(file.Name())
+	_, err = file.Write(knownHosts)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	callback, err := knownhosts.New(file.Name())
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidKnownHosts, err)
+	}
+	return callback, nil
+}
+
 // Load loads a remote vcs repository to a local path or opens it if it exists.
 func (manager RepositoryManager) Load(
 	ctx context.Context,
@@ -104,34 +161,14 @@ func (manager RepositoryManager) Load(
 		"target path", targetPath,
 	)
 
-	projectName = strings.ToLower(projectName)
-	secret, err := getAuthSecret(ctx, manager.kubeClient, manager.controllerNamespace, projectName)
+	authMethod, err := manager.authMethod(ctx, projectName)
 	if err != nil {
-		if k8sErrors.ReasonForError(err) != metav1.StatusReasonNotFound {
-			return nil, err
-		}
-	}
-
-	var authMethod transport.AuthMethod
-	if secret != nil {
-		authMethod, err = manager.getAuthMethodFromSecret(*secret)
-		if err != nil {
-			return nil, err
-		}
-	}
-
-	log.V(1).Info("Opening repository")
-
-	gitRepository, err := git.PlainOpen(targetPath)
-	if err != nil && err != git.ErrRepositoryNotExists {
 		return nil, err
 	}
 
-	if err == git.ErrRepositoryNotExists {
-		log.V(1).Info("Repository not cloned yet")
+	clone := func() (*git.Repository, error) {
 		log.V(1).Info("Cloning repository")
-
-		gitRepository, err = git.PlainClone(
+		gitRepository, err := git.PlainClone(
 			targetPath, false,
 			&git.CloneOptions{
 				URL:      remoteURL,
@@ -140,33 +177,104 @@ func (manager RepositoryManager) Load(
 			},
 		)
 		if err != nil {
+			// A partial clone would be mistaken for a corrupted one by the next attempt.
+			_ = os.RemoveAll(targetPath)
+			return nil, gitError(err)
+		}
+		return gitRepository, nil
+	}
+
+	// reclone replaces a corrupted local clone, which can never recover on its own.
+	reclone := func(cause error) (*git.Repository, error) {
+		log.Info("Recloning corrupted repository", "cause", cause.Error())
+		if err := os.RemoveAll(targetPath); err != nil {
 			return nil, err
 		}
+		return clone()
 	}
 
-	worktree, err := gitRepository.Worktree()
+	log.V(1).Info("Opening repository")
+
+	gitRepository, err := git.PlainOpen(targetPath)
+	switch {
+	case err == git.ErrRepositoryNotExists:
+		log.V(1).Info("Repository not cloned yet")
+		gitRepository, err = clone()
+	case err != nil && Classify(err) == FailureCorruption:
+		gitRepository, err = reclone(err)
+	}
 	if err != nil {
-		return nil, err
+		return nil, gitError(err)
 	}
 
 	pullFunc := func() (string, error) {
-		err := worktree.Pull(&git.PullOptions{
+		worktree, err := gitRepository.Worktree()
+		if err != nil {
+			return "", gitError(err)
+		}
+		err = worktree.Pull(&git.PullOptions{
 			Auth: authMethod,
 		})
 		if err != nil && err != git.NoErrAlreadyUpToDate {
-			return "", err
+			if Classify(err) != FailureCorruption {
+				return "", gitError(err)
+			}
+			if gitRepository, err = reclone(err); err != nil {
+				return "", gitError(err)
+			}
 		}
 		ref, err := gitRepository.Head()
 		if err != nil {
-			return "", err
+			return "", gitError(err)
 		}
 		return ref.Hash().String(), nil
 	}
 
-	repository := NewRepository(targetPath, pullFunc)
+	checkoutFunc := func(revision string) (string, error) {
+		err := gitRepository.Fetch(&git.FetchOptions{
+			Auth: authMethod,
+			Tags: git.AllTags,
+		})
+		if err != nil && err != git.NoErrAlreadyUpToDate {
+			return "", gitError(err)
+		}
+		hash, err := gitRepository.ResolveRevision(plumbing.Revision(revision))
+		if err != nil {
+			return "", gitError(fmt.Errorf("%w: %s", err, revision))
+		}
+		worktree, err := gitRepository.Worktree()
+		if err != nil {
+			return "", gitError(err)
+		}
+		if err := worktree.Checkout(&git.CheckoutOptions{
+			Hash:  *hash,
+			Force: true,
+		}); err != nil {
+			return "", gitError(err)
+		}
+		return hash.String(), nil
+	}
+
+	repository := NewRepository(targetPath, pullFunc, checkoutFunc)
 	return &repository, nil
 }
 
+// authMethod reads the auth Secret of a project. Projects without a Secret are accessed anonymously.
+func (manager RepositoryManager) authMethod(
+	ctx context.Context,
+	projectName string,
+) (transport.AuthMethod, error) {
+	projectName = strings.ToLower(projectName)
+	secret, err := getAuthSecret(ctx, manager.kubeClient, manager.controllerNamespace, projectName)
+	if err != nil {
+		if k8sErrors.ReasonForError(err) != metav1.StatusReasonNotFound {
+			return nil, err
+		}
+		return nil, nil
+	}
+	return manager.getAuthMethodFromSecret(*secret)
+}
+
 func getAuthSecret(
 	ctx context.Context,
 	kubeClient kube.Client[unstructured.Unstructured],
@@ -202,7 +310,9 @@ type RepositoryConfigurator struct {
 }
 
 var (
-	ErrUnknownURLFormat = errors.New("Unknown git url format")
+	ErrUnknownURLFormat  = errors.New("Unknown git url format")
+	ErrInvalidSSHKey     = errors.New("Invalid SSH key")
+	ErrInvalidKnownHosts = errors.New("Invalid known_hosts")
 )
 
 func NewRepositoryConfigurator(
@@ -281,18 +391,7 @@ func (config RepositoryConfigurator) CreateDeployKeySecretIfNotExists(
 	}
 
 	if depKey != nil {
-		unstr := &unstructured.Unstructured{}
-		unstr.SetName(SecretName(projectName))
-		unstr.SetNamespace(config.controllerNamespace)
-		unstr.SetKind("Secret")
-		unstr.SetAPIVersion("v1")
-		unstr.Object["data"] = map[string][]byte{
-			SSHKey:                []byte(depKey.privateKeyOpenSSH),
-			SSHPubKey:             []byte(depKey.publicKeyOpenSSH),
-			K8sSecretDataAuthType: []byte(K8sSecretDataAuthTypeSSH),
-		}
-
-		err = config.kubeClient.Apply(ctx, unstr, fieldManager)
+		err = config.kubeClient.Apply(ctx, deployKeySecret(config.controllerNamespace, projectName, depKey), fieldManager)
 		if err != nil {
 			return err
 		}
@@ -301,6 +400,68 @@ func (config RepositoryConfigurator) CreateDeployKeySecretIfNotExists(
 	return nil
 }
 
+// GenerateDeployKeySecret returns a Secret holding a freshly generated deploy key of a project,
+// without registering the key at the Git provider or applying the Secret.
+// The public key stored under [SSHPubKey] has to be added to the repository as a deploy key manually.
+func GenerateDeployKeySecret(controllerNamespace string, projectName string) (*unstructured.Unstructured, error) {
+	projectName = strings.ToLower(projectName)
+	depKey, err := genDeployKey(WithKeySuffix(projectName))
+	if err != nil {
+		return nil, err
+	}
+	return deployKeySecret(controllerNamespace, projectName, depKey), nil
+}
+
+func deployKeySecret(controllerNamespace string, projectName string, depKey *deployKey) *unstructured.Unstructured {
+	unstr := &unstructured.Unstructured{}
+	unstr.SetName(SecretName(projectName))
+	unstr.SetNamespace(controllerNamespace)
+	unstr.SetKind("Secret")
+	unstr.SetAPIVersion("v1")
+	unstr.Object["data"] = map[string][]byte{
+		SSHKey:                []byte(depKey.privateKeyOpenSSH),
+		SSHPubKey:             []byte(depKey.publicKeyOpenSSH),
+		K8sSecretDataAuthType: []byte(K8sSecretDataAuthTypeSSH),
+	}
+	return unstr
+}
+
+// SSHKeySecret returns a Secret holding an existing SSH key of a project, e.g. of a machine user,
+// instead of a deploy key generated by Declcd.
+// The key is optionally protected by a passphrase and the host key of the Git server is verified against knownHosts, when given.
+func SSHKeySecret(
+	controllerNamespace string,
+	projectName string,
+	privateKey []byte,
+	passphrase []byte,
+	knownHosts []byte,
+) (*unstructured.Unstructured, error) {
+	public, err := sshAuthMethod(privateKey, passphrase, knownHosts)
+	if err != nil {
+		return nil, err
+	}
+
+	data := map[string][]byte{
+		SSHKey:                privateKey,
+		SSHPubKey:             bytes.TrimSpace(cryptoSSH.MarshalAuthorizedKey(public.Signer.PublicKey())),
+		K8sSecretDataAuthType: []byte(K8sSecretDataAuthTypeSSH),
+	}
+	if len(passphrase) != 0 {
+		data[SSHKeyPassphrase] = passphrase
+	}
+	if len(knownHosts) != 0 {
+		data[SSHKnownHosts] = knownHosts
+	}
+
+	unstr := &unstructured.Unstructured{}
+	unstr.SetName(SecretName(strings.ToLower(projectName)))
+	unstr.SetNamespace(controllerNamespace)
+	unstr.SetKind("Secret")
+	unstr.SetAPIVersion("v1")
+	unstr.Object["data"] = data
+	return unstr, nil
+}
+
 func SecretName(projectName string) string {
 	return fmt.Sprintf("%s-%s", "vcs-auth", projectName)
 }
@@ -15,6 +15,8 @@
 package vcs_test
 
 import (
+	"crypto/ed25519"
+	"encoding/pem"
 	"fmt"
 	"net/http"
 	"os"
@@ -22,11 +24,16 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
 	"github.com/kharf/declcd/internal/gittest"
 	"github.com/kharf/declcd/internal/kubetest"
 	"github.com/kharf/declcd/internal/projecttest"
 	"github.com/kharf/declcd/pkg/kube"
 	"github.com/kharf/declcd/pkg/vcs"
+	cryptoSSH "golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
 	"gotest.tools/v3/assert"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/types"
@@ -133,6 +140,55 @@ func TestRepositoryManager_Load(t *testing.T) {
 	}
 }
 
+func TestRepository_Checkout(t *testing.T) {
+	localRepository, err := os.MkdirTemp("", "")
+	assert.NilError(t, err)
+	defer os.RemoveAll(localRepository)
+	remoteRepository, err := gittest.SetupGitRepository()
+	assert.NilError(t, err)
+	defer remoteRepository.Clean()
+
+	env := projecttest.StartProjectEnv(t)
+	defer env.Stop()
+	repository, err := env.RepositoryManager.Load(
+		env.Ctx,
+		remoteRepository.Directory,
+		localRepository,
+		"checkout",
+	)
+	assert.NilError(t, err)
+
+	pinnedHash, err := remoteRepository.CommitNewFile("pinned", "pinned commit")
+	assert.NilError(t, err)
+	remoteGitRepository, err := git.PlainOpen(remoteRepository.Directory)
+	assert.NilError(t, err)
+	_, err = remoteGitRepository.CreateTag("v1.0.0", plumbing.NewHash(pinnedHash), nil)
+	assert.NilError(t, err)
+	headHash, err := remoteRepository.CommitNewFile("head", "head commit")
+	assert.NilError(t, err)
+
+	checkedOutHash, err := repository.Checkout(pinnedHash)
+	assert.NilError(t, err)
+	assert.Equal(t, checkedOutHash, pinnedHash)
+	_, err = os.Stat(filepath.Join(localRepository, "pinned"))
+	assert.NilError(t, err)
+	_, err = os.Stat(filepath.Join(localRepository, "head"))
+	assert.Assert(t, os.IsNotExist(err))
+
+	checkedOutHash, err = repository.Checkout("v1.0.0")
+	assert.NilError(t, err)
+	assert.Equal(t, checkedOutHash, pinnedHash)
+
+	_, err = repository.Checkout("v2.0.0")
+	assert.Equal(t, vcs.Classify(err), vcs.FailureRefNotFound)
+
+	pulledHash, err := repository.Pull()
+	assert.NilError(t, err)
+	assert.Equal(t, pulledHash, headHash)
+	_, err = os.Stat(filepath.Join(localRepository, "head"))
+	assert.NilError(t, err)
+}
+
 func TestNewRepositoryConfigurator(t *testing.T) {
 	ns := "test"
 	testCases := []struct {
@@ -313,3 +369,105 @@ func TestRepositoryConfigurator_CreateDeployKeySecretIfNotExists(t *testing.T) {
 		})
 	}
 }
+
+func TestSSHKeySecret(t *testing.T) {
+	_, privateKey, err := ed25519.GenerateKey(nil)
+	assert.NilError(t, err)
+	encryptedBlock, err := cryptoSSH.MarshalPrivateKeyWithPassphrase(privateKey, "", []byte("passphrase"))
+	assert.NilError(t, err)
+	encryptedKey := pem.EncodeToMemory(encryptedBlock)
+
+	hostPublicKey, _, err := ed25519.GenerateKey(nil)
+	assert.NilError(t, err)
+	hostKey, err := cryptoSSH.NewPublicKey(hostPublicKey)
+	assert.NilError(t, err)
+	knownHosts := []byte(knownhosts.Line([]string{"github.com"}, hostKey) + "\n")
+
+	testCases := []struct {
+		name        string
+		passphrase  []byte
+		knownHosts  []byte
+		expectedErr error
+	}{
+		{
+			name:       "PassphraseProtected",
+			passphrase: []byte("passphrase"),
+			knownHosts: knownHosts,
+		},
+		{
+			name:        "WrongPassphrase",
+			passphrase:  []byte("wrong"),
+			expectedErr: vcs.ErrInvalidSSHKey,
+		},
+		{
+			name:        "InvalidKnownHosts",
+			passphrase:  []byte("passphrase"),
+			knownHosts:  []byte("github.com ssh-ed25519 invalid\n"),
+			expectedErr: vcs.ErrInvalidKnownHosts,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			secret, err := vcs.SSHKeySecret("test", "Project", encryptedKey, tc.passphrase, tc.knownHosts)
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+				return
+			}
+			assert.NilError(t, err)
+			assert.Equal(t, secret.GetName(), vcs.SecretName("project"))
+			data := secret.Object["data"].(map[string][]byte)
+			assert.DeepEqual(t, data[vcs.SSHKey], encryptedKey)
+			assert.DeepEqual(t, data[vcs.SSHKeyPassphrase], tc.passphrase)
+			assert.DeepEqual(t, data[vcs.SSHKnownHosts], tc.knownHosts)
+			assert.Assert(t, strings.HasPrefix(string(data[vcs.SSHPubKey]), "ssh-ed25519 AAAA"))
+			assert.Equal(t, string(data[vcs.K8sSecretDataAuthType]), vcs.K8sSecretDataAuthTypeSSH)
+		})
+	}
+}
+
+func TestRepositoryManager_NewScratchBranch(t *testing.T) {
+	remoteRepository, err := gittest.SetupGitRepository()
+	assert.NilError(t, err)
+	defer remoteRepository.Clean()
+	localRepository, err := os.MkdirTemp("", "")
+	assert.NilError(t, err)
+	defer os.RemoveAll(localRepository)
+
+	env := projecttest.StartProjectEnv(t)
+	defer env.Stop()
+	branch, err := env.RepositoryManager.NewScratchBranch(
+		env.Ctx,
+		remoteRepository.Directory,
+		localRepository,
+		"scratch",
+		"smoke-test",
+	)
+	assert.NilError(t, err)
+
+	remoteGitRepository, err := git.PlainOpen(remoteRepository.Directory)
+	assert.NilError(t, err)
+	firstHash, err := branch.Commit(env.Ctx, map[string][]byte{
+		"apps/a.cue": []byte("a"),
+		"b.cue":      []byte("b"),
+	}, "first")
+	assert.NilError(t, err)
+	ref, err := remoteGitRepository.Reference(plumbing.NewBranchReferenceName("smoke-test"), false)
+	assert.NilError(t, err)
+	assert.Equal(t, ref.Hash().String(), firstHash)
+
+	secondHash, err := branch.Commit(env.Ctx, map[string][]byte{
+		"b.cue": []byte("b"),
+	}, "second")
+	assert.NilError(t, err)
+	commit, err := remoteGitRepository.CommitObject(plumbing.NewHash(secondHash))
+	assert.NilError(t, err)
+	assert.Equal(t, commit.ParentHashes[0].String(), firstHash)
+	tree, err := commit.Tree()
+	assert.NilError(t, err)
+	_, err = tree.File("apps/a.cue")
+	assert.ErrorIs(t, err, object.ErrFileNotFound)
+
+	assert.NilError(t, branch.Delete(env.Ctx))
+	_, err = remoteGitRepository.Reference(plumbing.NewBranchReferenceName("smoke-test"), false)
+	assert.ErrorIs(t, err, plumbing.ErrReferenceNotFound)
+}
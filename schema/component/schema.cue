@@ -1,8 +1,14 @@
 package component
 
-import "strings"
+import (
+	"strings"
+	"time"
+)
 
 #Manifest: {
+	#Promotion
+	#FieldManager
+	#ServiceAccount
 	type:          "Manifest"
 	_groupVersion: strings.Split(content.apiVersion, "/")
 	_group:        string | *""
@@ -11,6 +17,7 @@ import "strings"
 	}
 	id: "\(content.metadata.name)_\(content.metadata.namespace)_\(_group)_\(content.kind)"
 	dependencies: [...string]
+	wait?: #Wait
 	content: {
 		apiVersion!: string & strings.MinRunes(1)
 		kind!:       string & strings.MinRunes(1)
@@ -23,7 +30,205 @@ import "strings"
 	}
 }
 
+// A GeneratedManifest is a namespaced Kubernetes object named by the API server through metadata.generateName.
+// It is created instead of applied and replaces the object generated by the previous reconciliation.
+#GeneratedManifest: {
+	#FieldManager
+	#ServiceAccount
+	type:          "Manifest"
+	_groupVersion: strings.Split(content.apiVersion, "/")
+	_group:        string | *""
+	if len(_groupVersion) >= 2 {
+		_group: _groupVersion[0]
+	}
+	id: "\(content.metadata.generateName)_\(content.metadata.namespace)_\(_group)_\(content.kind)"
+	dependencies: [...string]
+	content: {
+		apiVersion!: string & strings.MinRunes(1)
+		kind!:       string & strings.MinRunes(1)
+		metadata: {
+			namespace!:    string & strings.MinRunes(1)
+			generateName!: string & strings.MinRunes(1)
+			name?:         _|_
+			...
+		}
+		...
+	}
+}
+
+// A Hook is a Kubernetes object applied before or after all other components on every reconciliation.
+// Jobs are recreated each time and awaited until they complete.
+#Hook: {
+	#FieldManager
+	#ServiceAccount
+	type:          "Hook"
+	_groupVersion: strings.Split(content.apiVersion, "/")
+	_group:        string | *""
+	if len(_groupVersion) >= 2 {
+		_group: _groupVersion[0]
+	}
+	id: "\(content.metadata.name)_\(content.metadata.namespace)_\(_group)_\(content.kind)"
+	dependencies: [...string]
+	phase!:        "PreApply" | "PostApply"
+	failurePolicy: *"Abort" | "Ignore"
+	timeout:       string | *"5m"
+	content: {
+		apiVersion!: string & strings.MinRunes(1)
+		kind!:       string & strings.MinRunes(1)
+		metadata: {
+			namespace: string | *""
+			name!:     string & strings.MinRunes(1)
+			...
+		}
+		...
+	}
+}
+
+// An HTTPHook is a request sent before or after all other components on every reconciliation, e.g. to flush a cache.
+// Responses with a status outside of 2xx fail the hook.
+#HTTPHook: {
+	type: "HTTPHook"
+	id:   "\(name)_\(type)"
+	dependencies: [...string]
+	name!:         string & strings.MinRunes(1)
+	phase!:        "PreApply" | "PostApply"
+	failurePolicy: *"Abort" | "Ignore"
+	timeout:       string | *"5m"
+	request: {
+		url!:     string & strings.HasPrefix("http://") | strings.HasPrefix("https://")
+		method:   *"POST" | "GET" | "PUT" | "PATCH" | "DELETE"
+		headers?: [string]: string
+		body?:    string
+	}
+}
+
+// Promotion defers applying new versions of a component, which have already been committed to Git.
+// A version is applied once promoteAfter has passed or an operator ran 'declcd promote <component id>'.
+// With promotion set to "manual", every version has to be promoted by an operator.
+// Until then, the previously applied version stays in the cluster.
+#Promotion: {
+	promoteAfter?: time.Time
+	promotion?:    "manual"
+}
+
+// FieldManager applies a component with the given server-side apply field manager instead of the one of the controller,
+// e.g. to distinguish the ownership of platform and tenant packages in managedFields.
+// A package declares it for all of its components by unifying them with a shared value.
+#FieldManager: {
+	fieldManager?: string & strings.MinRunes(1)
+}
+
+// ServiceAccount restricts a component to the permissions of a service account in the namespace of the project,
+// which is impersonated instead of the service account of the project,
+// e.g. a broad one for an infra package and namespace-scoped ones for app packages.
+// A package declares it for all of its components by unifying them with a shared value.
+#ServiceAccount: {
+	serviceAccountName?: string & strings.MinRunes(1)
+}
+
+// A Matrix expands its template component for every combination of its dimensions, e.g. clusters x environments.
+// Every combination is injected into the template as parameters and has to result in a distinct component id.
+#Matrix: {
+	type: "Matrix"
+	dimensions: [string]: [...string & strings.MinRunes(1)]
+	template: {
+		parameters: [string]: string
+		...
+	}
+}
+
+// A Bundle distributes a set of components, e.g. a monitoring stack of Manifests and HelmReleases,
+// as a package of a versioned CUE module, so that platform add-ons are imported, declared and upgraded as a unit.
+// Its components are applied as if they were declared in the importing package and their ids have to be unique in the project.
+// A Bundle declares its parameters, e.g. a namespace, next to its components.
+#Bundle: {
+	type: "Bundle"
+	components: [string]: {
+		type: string
+		...
+	}
+	...
+}
+
+// A Kustomization builds a kustomize directory of the project, e.g. an overlay, and applies every resulting object like a Manifest.
+// The path is relative to the project root. Components depending on a Kustomization wait until all of its objects are applied.
+#Kustomization: {
+	#Promotion
+	#FieldManager
+	#ServiceAccount
+	type: "Kustomization"
+	id:   "\(name)_\(type)"
+	dependencies: [...string]
+	name!: string & strings.MinRunes(1)
+	path!: string & strings.MinRunes(1)
+	wait?: #Wait
+}
+
+// A ManifestSequence applies its manifests one after another, e.g. a CustomResourceDefinition before its custom resource
+// or a Secret before the Deployment mounting it. Every manifest is applied like a Manifest once its predecessor is ready,
+// unless waiting is disabled. Components depending on a ManifestSequence wait until all of its manifests are applied.
+#ManifestSequence: {
+	#Promotion
+	#FieldManager
+	#ServiceAccount
+	type: "ManifestSequence"
+	id:   "\(name)_\(type)"
+	dependencies: [...string]
+	name!: string & strings.MinRunes(1)
+	wait:  #Wait
+	manifests!: [_, ...] & [...{
+		apiVersion!: string & strings.MinRunes(1)
+		kind!:       string & strings.MinRunes(1)
+		metadata: {
+			namespace: string | *""
+			name!:     string & strings.MinRunes(1)
+			...
+		}
+		...
+	}]
+}
+
+// A Notification posts the outcome of reconciliations to Slack, a generic webhook or an SMTP server.
+// It is not applied to the cluster. The controller shard reconciling the declaring project delivers status transitions
+// of all projects it reconciles, which match the filters. Without projects, only the declaring project is matched.
+#Notification: {
+	type: "Notification"
+	id:   "\(name)_\(type)"
+	dependencies: [...string]
+	name!: string & strings.MinRunes(1)
+	// "Error" only delivers failed and partially failed reconciliations.
+	severity: *"Info" | "Error"
+	// Glob patterns matching project names, e.g. "*" for all projects.
+	projects?: [...string & strings.MinRunes(1)]
+	shards?: [...string & strings.MinRunes(1)]
+	// The referenced Secret has to contain the incoming webhook url under "url".
+	slack?: {
+		secretRef!: #SecretRef
+	}
+	// The referenced Secret optionally contains a secret under "secret" to sign the request body with HMAC-SHA256.
+	webhook?: {
+		url!:       string & strings.HasPrefix("http://") | strings.HasPrefix("https://")
+		secretRef?: #SecretRef
+	}
+	// The referenced Secret optionally contains the credentials under "username" and "password".
+	email?: {
+		host!: string & strings.MinRunes(1)
+		port:  int & >0 | *587
+		from!: string & strings.MinRunes(1)
+		to!: [string & strings.MinRunes(1), ...string & strings.MinRunes(1)]
+		secretRef?: #SecretRef
+	}
+}
+
+#SecretRef: {
+	name!:      string & strings.MinRunes(1)
+	namespace!: string & strings.MinRunes(1)
+}
+
 #HelmRelease: {
+	#Promotion
+	#FieldManager
+	#ServiceAccount
 	type: "HelmRelease"
 	id:   "\(name)_\(namespace)_\(type)"
 	dependencies: [...string]
@@ -31,6 +236,87 @@ import "strings"
 	namespace!: string
 	chart!:     #HelmChart
 	values: {...}
+	// Paths of CUE, YAML or JSON files relative to the project root, whose contents are unified with the values at build time,
+	// e.g. to keep large values out of the component. CUE files have to be self-contained, as imports are not resolved.
+	valuesFiles?: [...string & strings.MinRunes(1)]
+	// Path of a self-contained CUE file relative to the project root, which the unified values are validated against at build time.
+	valuesSchema?:      string & strings.MinRunes(1)
+	capabilities?:      #Capabilities
+	wait?:              #Wait
+	namespaceMetadata?: #NamespaceMetadata
+	valuesFrom?: [...#ValuesReference]
+	patches?: [...#HelmPatch]
+	hookResources?: #HookResources
+}
+
+// HookResources tracks the objects of Helm hooks, which Helm leaves in the cluster, so that they are deleted with the release.
+// Objects created by hooks at runtime, like generated Secrets, are tracked, when their kind is declared
+// and they are labeled with "declcd/hook-release" and the name of the release.
+#HookResources: {
+	kinds: [...{
+		apiVersion!: string & strings.MinRunes(1)
+		kind!:       string & strings.MinRunes(1)
+	}]
+}
+
+// HelmPatch modifies every rendered object of a release matching its target before it is applied.
+// Exactly one of merge, strategicMerge and jsonPatch has to be declared.
+#HelmPatch: {
+	target: {
+		apiVersion?: string & strings.MinRunes(1)
+		kind?:       string & strings.MinRunes(1)
+		name?:       string & strings.MinRunes(1)
+	}
+	{
+		// Merged with JSON merge patch semantics (RFC 7386). Lists are replaced and null removes a field.
+		merge!: {...}
+	} | {
+		// Merged with strategic merge patch semantics, which merges lists by key, e.g. containers by name.
+		// '$patch: delete' removes a list entry. Custom resources are merged like merge patches.
+		strategicMerge!: {...}
+	} | {
+		// RFC 6902 operations, e.g. {op: "remove", path: "/spec/template/spec/tolerations/0"}.
+		jsonPatch!: [...#JSONPatchOperation]
+	}
+}
+
+#JSONPatchOperation: {
+	op!:    "add" | "remove" | "replace" | "move" | "copy" | "test"
+	path!:  string
+	from?:  string
+	value?: _
+}
+
+// ValuesReference reads values from a key of a Secret or ConfigMap in the cluster.
+// Referenced values are merged in order and overridden by the declared values.
+#ValuesReference: {
+	kind!:      "Secret" | "ConfigMap"
+	name!:      string & strings.MinRunes(1)
+	namespace?: string
+	key?:       string
+	// Skips the reference, when the object or the key does not exist, e.g. in environments without overrides.
+	optional?: bool
+}
+
+// NamespaceMetadata is applied to the release namespace before the chart is installed,
+// because many charts fail when e.g. PodSecurity labels arrive later.
+#NamespaceMetadata: {
+	labels?: [string]:      string
+	annotations?: [string]: string
+}
+
+// Wait blocks dependent components until all objects of a component are ready,
+// e.g. a Deployment serving the webhook of a following component.
+#Wait: {
+	enabled: bool | *true
+	timeout: string | *"5m"
+	// Maps an object kind to the status condition type, which has to be True for objects of that kind to be ready.
+	rules: [string]: string & strings.MinRunes(1)
+}
+
+#Capabilities: {
+	kubeVersion?: string & strings.MinRunes(1)
+	apiVersions?: [...string & strings.MinRunes(1)]
 }
 
 #HelmChart: {
@@ -38,6 +324,19 @@ import "strings"
 	repoURL!: string & strings.HasPrefix("oci://") | strings.HasPrefix("http://") | strings.HasPrefix("https://")
 	version!: string & strings.MinRunes(1)
 	auth?:    #Auth
+	verify?:  #Verify
+}
+
+// Verify refuses to install a chart, unless it is signed by a trusted key.
+// Charts from OCI registries are verified against their cosign signatures with the PEM encoded public key
+// the referenced Secret contains under "cosign.pub".
+// Charts from index.yaml repositories are verified against their provenance file with the OpenPGP keyring
+// the referenced Secret contains under "pubring.gpg".
+#Verify: {
+	secretRef: {
+		name:      string & strings.MinRunes(1)
+		namespace: string & strings.MinRunes(1)
+	}
 }
 
 #Auth: {
@@ -49,4 +348,31 @@ import "strings"
 		name:      string & strings.MinRunes(1)
 		namespace: string & strings.MinRunes(1)
 	}
+} | {
+	githubApp: {
+		appID:          int & >0
+		installationID: int & >0
+		secretRef: {
+			name:      string & strings.MinRunes(1)
+			namespace: string & strings.MinRunes(1)
+		}
+		apiURL?: string & strings.HasPrefix("https://")
+	}
+} | {
+	// Only supported by index.yaml repositories. The referenced Secret has to contain the token under "token".
+	bearerToken: {
+		secretRef: {
+			name:      string & strings.MinRunes(1)
+			namespace: string & strings.MinRunes(1)
+		}
+	}
+} | {
+	// Only supported by index.yaml repositories. The referenced Secret has to contain the client certificate under "tls.crt",
+	// its key under "tls.key" and optionally a CA bundle verifying the repository under "ca.crt".
+	tls: {
+		secretRef: {
+			name:      string & strings.MinRunes(1)
+			namespace: string & strings.MinRunes(1)
+		}
+	}
 }
@@ -0,0 +1,89 @@
+package rbac
+
+import (
+	"strings"
+
+	"github.com/kharf/declcd/schema/component"
+)
+
+// A Rule grants verbs on resources of API groups like the rule of a Kubernetes Role.
+#Rule: {
+	apiGroups: [...string]
+	resources: [...string & strings.MinRunes(1)]
+	verbs: [...string & strings.MinRunes(1)]
+}
+
+// A Policy grants the ServiceAccount impersonated by a GitOps Project the rules it needs to reconcile the components of the project,
+// through a ClusterRole for cluster scoped objects and a Role per namespace, which are bound to the ServiceAccount.
+// Its rules are generated by 'declcd rbac' and have to be regenerated whenever the components of the project change.
+// HelmReleases are only granted rights in their release namespace, so charts containing cluster scoped objects need additional clusterRules.
+#Policy: component.#Bundle & {
+	serviceAccount: {
+		name!:     string & strings.MinRunes(1)
+		namespace: string | *"declcd-system"
+	}
+	clusterRules: [...#Rule]
+	namespaceRules: [string]: [...#Rule]
+
+	_name: "declcd-\(serviceAccount.name)"
+	_subjects: [{
+		kind:      "ServiceAccount"
+		name:      serviceAccount.name
+		namespace: serviceAccount.namespace
+	}]
+
+	components: {
+		if len(clusterRules) > 0 {
+			clusterRole: component.#Manifest & {
+				content: {
+					apiVersion: "rbac.authorization.k8s.io/v1"
+					kind:       "ClusterRole"
+					metadata: name: _name
+					rules: clusterRules
+				}
+			}
+			clusterRoleBinding: component.#Manifest & {
+				content: {
+					apiVersion: "rbac.authorization.k8s.io/v1"
+					kind:       "ClusterRoleBinding"
+					metadata: name: _name
+					roleRef: {
+						apiGroup: "rbac.authorization.k8s.io"
+						kind:     "ClusterRole"
+						name:     _name
+					}
+					subjects: _subjects
+				}
+			}
+		}
+		for ns, nsRules in namespaceRules {
+			"role_\(ns)": component.#Manifest & {
+				content: {
+					apiVersion: "rbac.authorization.k8s.io/v1"
+					kind:       "Role"
+					metadata: {
+						name:      _name
+						namespace: ns
+					}
+					rules: nsRules
+				}
+			}
+			"roleBinding_\(ns)": component.#Manifest & {
+				content: {
+					apiVersion: "rbac.authorization.k8s.io/v1"
+					kind:       "RoleBinding"
+					metadata: {
+						name:      _name
+						namespace: ns
+					}
+					roleRef: {
+						apiGroup: "rbac.authorization.k8s.io"
+						kind:     "Role"
+						name:     _name
+					}
+					subjects: _subjects
+				}
+			}
+		}
+	}
+}
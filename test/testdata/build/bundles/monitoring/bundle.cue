@@ -0,0 +1,33 @@
+package monitoring
+
+import (
+	"github.com/kharf/declcd/schema/component"
+)
+
+#Monitoring: component.#Bundle & {
+	namespace: string
+	let bundleNamespace = namespace
+
+	components: {
+		ns: component.#Manifest & {
+			content: {
+				apiVersion: "v1"
+				kind:       "Namespace"
+				metadata: name: bundleNamespace
+			}
+		}
+
+		config: component.#Manifest & {
+			dependencies: [components.ns.id]
+			content: {
+				apiVersion: "v1"
+				kind:       "ConfigMap"
+				metadata: {
+					name:      "scrape-config"
+					namespace: bundleNamespace
+				}
+				data: interval: "30s"
+			}
+		}
+	}
+}
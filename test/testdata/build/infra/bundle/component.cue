@@ -0,0 +1,9 @@
+package bundle
+
+import (
+	"github.com/kharf/declcd/test/testdata/build/bundles/monitoring"
+)
+
+stack: monitoring.#Monitoring & {
+	namespace: "monitoring"
+}
@@ -0,0 +1,32 @@
+package fieldmanager
+
+import (
+	"github.com/kharf/declcd/schema/component"
+)
+
+// Definitions are closed, so the shared value is a plain struct.
+_tenant: {
+	fieldManager: "tenant"
+}
+
+config: component.#Manifest & _tenant & {
+	content: {
+		apiVersion: "v1"
+		kind:       "ConfigMap"
+		metadata: {
+			name:      "config"
+			namespace: "tenant"
+		}
+	}
+}
+
+release: component.#HelmRelease & _tenant & {
+	name:      "test"
+	namespace: "tenant"
+	chart: {
+		name:    "test"
+		repoURL: "oci://test"
+		version: "test"
+	}
+	values: {}
+}
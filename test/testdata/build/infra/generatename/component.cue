@@ -0,0 +1,23 @@
+package generatename
+
+import (
+	"github.com/kharf/declcd/schema/component"
+)
+
+migration: component.#GeneratedManifest & {
+	content: {
+		apiVersion: "batch/v1"
+		kind:       "Job"
+		metadata: {
+			generateName: "migration-"
+			namespace:    "prometheus"
+		}
+		spec: template: spec: {
+			restartPolicy: "Never"
+			containers: [{
+				name:  "migration"
+				image: "busybox"
+			}]
+		}
+	}
+}
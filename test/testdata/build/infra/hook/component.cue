@@ -0,0 +1,26 @@
+package hook
+
+import (
+	"github.com/kharf/declcd/schema/component"
+)
+
+backup: component.#Hook & {
+	phase:         "PreApply"
+	failurePolicy: "Ignore"
+	timeout:       "10m"
+	content: {
+		apiVersion: "batch/v1"
+		kind:       "Job"
+		metadata: {
+			name:      "backup"
+			namespace: "prometheus"
+		}
+		spec: template: spec: {
+			restartPolicy: "Never"
+			containers: [{
+				name:  "backup"
+				image: "busybox"
+			}]
+		}
+	}
+}
@@ -0,0 +1,19 @@
+package hookresources
+
+import (
+	"github.com/kharf/declcd/schema/component"
+)
+
+release: component.#HelmRelease & {
+	name:      "test"
+	namespace: "test"
+	chart: {
+		name:    "test"
+		repoURL: "oci://test"
+		version: "test"
+	}
+	hookResources: kinds: [{
+		apiVersion: "v1"
+		kind:       "Secret"
+	}]
+}
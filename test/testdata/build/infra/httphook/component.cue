@@ -0,0 +1,15 @@
+package httphook
+
+import (
+	"github.com/kharf/declcd/schema/component"
+)
+
+flush: component.#HTTPHook & {
+	name:  "flush"
+	phase: "PostApply"
+	request: {
+		url: "https://cache.example.com/flush"
+		headers: "Content-Type": "application/json"
+		body: "{\"cache\":\"all\"}"
+	}
+}
@@ -0,0 +1,10 @@
+package kustomization
+
+import (
+	"github.com/kharf/declcd/schema/component"
+)
+
+overlay: component.#Kustomization & {
+	name: "overlay"
+	path: "infra/kustomization/overlay"
+}
@@ -0,0 +1,10 @@
+package kustomizationoutsideproject
+
+import (
+	"github.com/kharf/declcd/schema/component"
+)
+
+outside: component.#Kustomization & {
+	name: "outside"
+	path: "../simple/infra"
+}
@@ -0,0 +1,28 @@
+package matrixduplicateid
+
+import (
+	"github.com/kharf/declcd/schema/component"
+)
+
+config: component.#Matrix & {
+	dimensions: {
+		cluster: ["eu", "us"]
+		env: ["dev", "prod"]
+	}
+	template: {
+		parameters: {
+			cluster: string
+			env:     string
+		}
+		component.#Manifest & {
+			content: {
+				apiVersion: "v1"
+				kind:       "ConfigMap"
+				metadata: {
+					name:      "config-\(parameters.env)"
+					namespace: "prometheus"
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,21 @@
+package migrate
+
+import (
+	"github.com/kharf/declcd/schema/component"
+)
+
+crd: component.#Manifest & {
+	content: {
+		apiVersion: "apiextensions.k8s.io/v1"
+		kind:       "CustomResourceDefinition"
+		metadata: name: "tests.declcd.io"
+		spec: {
+			group: "declcd.io"
+			names: {
+				kind:   "Test"
+				plural: "tests"
+			}
+			scope: "Namespaced"
+		}
+	}
+} @migrate(storage)
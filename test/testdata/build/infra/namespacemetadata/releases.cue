@@ -0,0 +1,19 @@
+package namespacemetadata
+
+import (
+	"github.com/kharf/declcd/schema/component"
+)
+
+release: component.#HelmRelease & {
+	name:      "test"
+	namespace: "restricted"
+	chart: {
+		name:    "test"
+		repoURL: "oci://test"
+		version: "test"
+	}
+	namespaceMetadata: {
+		labels: "pod-security.kubernetes.io/enforce": "baseline"
+		annotations: owner:                           "declcd"
+	}
+}
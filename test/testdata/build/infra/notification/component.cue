@@ -0,0 +1,20 @@
+package notification
+
+import (
+	"github.com/kharf/declcd/schema/component"
+)
+
+platform: component.#Notification & {
+	name:     "platform"
+	severity: "Error"
+	projects: ["*"]
+	slack: secretRef: {
+		name:      "slack"
+		namespace: "declcd-system"
+	}
+	email: {
+		host: "smtp.example.com"
+		from: "declcd@example.com"
+		to: ["platform@example.com"]
+	}
+}
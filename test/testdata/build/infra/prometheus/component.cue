@@ -87,3 +87,43 @@ releaseWorkloadIdentity: component.#HelmRelease & {
 		autoscaling: enabled: true
 	}
 }
+
+releaseCapabilities: component.#HelmRelease & {
+	dependencies: [
+		ns.id,
+	]
+	name:      "test-capabilities"
+	namespace: #namespace.metadata.name
+	chart: {
+		name:    "test"
+		repoURL: "oci://test"
+		version: "test"
+	}
+	values: {
+		autoscaling: enabled: true
+	}
+	capabilities: {
+		kubeVersion: "1.29.0"
+		apiVersions: ["monitoring.coreos.com/v1"]
+	}
+}
+
+releaseWait: component.#HelmRelease & {
+	dependencies: [
+		ns.id,
+	]
+	name:      "test-wait"
+	namespace: #namespace.metadata.name
+	chart: {
+		name:    "test"
+		repoURL: "oci://test"
+		version: "test"
+	}
+	values: {}
+	wait: {
+		timeout: "10m"
+		rules: {
+			Prometheus: "Available"
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package promotion
+
+import (
+	"github.com/kharf/declcd/schema/component"
+)
+
+config: component.#Manifest & {
+	promoteAfter: "2024-07-01T00:00:00Z"
+	content: {
+		apiVersion: "v1"
+		kind:       "ConfigMap"
+		metadata: {
+			name:      "config"
+			namespace: "prod"
+		}
+	}
+}
+
+release: component.#HelmRelease & {
+	promotion: "manual"
+	name:      "test"
+	namespace: "prod"
+	chart: {
+		name:    "test"
+		repoURL: "oci://test"
+		version: "test"
+	}
+	values: {}
+}
@@ -0,0 +1,27 @@
+package pruneorphan
+
+import (
+	"github.com/kharf/declcd/schema/component"
+)
+
+config: component.#Manifest & {
+	content: {
+		apiVersion: "v1"
+		kind:       "ConfigMap"
+		metadata: {
+			name:      "config"
+			namespace: "prometheus"
+		}
+	}
+} @prune(orphan)
+
+release: component.#HelmRelease & {
+	name:      "test"
+	namespace: "prometheus"
+	chart: {
+		name:    "test"
+		repoURL: "oci://test"
+		version: "test"
+	}
+	values: {}
+} @prune(orphan)
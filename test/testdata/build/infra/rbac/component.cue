@@ -0,0 +1,11 @@
+package rbac
+
+import (
+	"github.com/kharf/declcd/schema/rbac"
+)
+
+policy: rbac.#Policy & {
+	serviceAccount: {name: "tenant", namespace: "declcd-system"}
+	clusterRules: [{"verbs": ["create", "get"], "apiGroups": [""], "resources": ["namespaces"]}]
+	namespaceRules: {"monitoring": [{"verbs": ["get"], "apiGroups": ["apps"], "resources": ["deployments"]}]}
+}
@@ -0,0 +1,41 @@
+package sequence
+
+import (
+	"github.com/kharf/declcd/schema/component"
+)
+
+ns: component.#Manifest & {
+	content: {
+		apiVersion: "v1"
+		kind:       "Namespace"
+		metadata: {
+			name: "prod"
+		}
+	}
+}
+
+app: component.#ManifestSequence & {
+	dependencies: [
+		ns.id,
+	]
+	name: "app"
+	wait: timeout: "1m"
+	manifests: [
+		{
+			apiVersion: "v1"
+			kind:       "Secret"
+			metadata: {
+				name:      "credentials"
+				namespace: "prod"
+			}
+		},
+		{
+			apiVersion: "apps/v1"
+			kind:       "Deployment"
+			metadata: {
+				name:      "app"
+				namespace: "prod"
+			}
+		},
+	]
+}
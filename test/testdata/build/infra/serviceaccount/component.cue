@@ -0,0 +1,32 @@
+package serviceaccount
+
+import (
+	"github.com/kharf/declcd/schema/component"
+)
+
+// Definitions are closed, so the shared value is a plain struct.
+_apps: {
+	serviceAccountName: "apps"
+}
+
+config: component.#Manifest & _apps & {
+	content: {
+		apiVersion: "v1"
+		kind:       "ConfigMap"
+		metadata: {
+			name:      "config"
+			namespace: "apps"
+		}
+	}
+}
+
+release: component.#HelmRelease & _apps & {
+	name:      "test"
+	namespace: "apps"
+	chart: {
+		name:    "test"
+		repoURL: "oci://test"
+		version: "test"
+	}
+	values: {}
+}
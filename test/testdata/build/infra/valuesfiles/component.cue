@@ -0,0 +1,23 @@
+package valuesfiles
+
+import (
+	"github.com/kharf/declcd/schema/component"
+)
+
+release: component.#HelmRelease & {
+	name:      "test"
+	namespace: "prometheus"
+	chart: {
+		name:    "test"
+		repoURL: "oci://test"
+		version: "test"
+	}
+	values: {
+		autoscaling: enabled: true
+	}
+	valuesFiles: [
+		"infra/valuesfiles/values/image.yaml",
+		"infra/valuesfiles/values/service.cue",
+	]
+	valuesSchema: "infra/valuesfiles/values/schema.cue"
+}
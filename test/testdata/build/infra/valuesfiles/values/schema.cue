@@ -0,0 +1,8 @@
+package values
+
+autoscaling: enabled: bool
+image: {
+	repository: string
+	tag:        string
+}
+service: type: "ClusterIP" | "NodePort" | "LoadBalancer"
@@ -0,0 +1,3 @@
+package values
+
+service: type: "ClusterIP"
@@ -0,0 +1,32 @@
+package valuesfrom
+
+import (
+	"github.com/kharf/declcd/schema/component"
+)
+
+release: component.#HelmRelease & {
+	name:      "test"
+	namespace: "test"
+	chart: {
+		name:    "test"
+		repoURL: "oci://test"
+		version: "test"
+	}
+	valuesFrom: [
+		{
+			kind: "Secret"
+			name: "credentials"
+		},
+		{
+			kind:      "ConfigMap"
+			name:      "defaults"
+			namespace: "shared"
+			key:       "test.yaml"
+		},
+		{
+			kind:     "Secret"
+			name:     "overrides"
+			optional: true
+		},
+	]
+}
@@ -0,0 +1,19 @@
+package valuesschemaviolation
+
+import (
+	"github.com/kharf/declcd/schema/component"
+)
+
+release: component.#HelmRelease & {
+	name:      "test"
+	namespace: "prometheus"
+	chart: {
+		name:    "test"
+		repoURL: "oci://test"
+		version: "test"
+	}
+	values: {
+		service: type: "External"
+	}
+	valuesSchema: "infra/valuesfiles/values/schema.cue"
+}
@@ -0,0 +1,54 @@
+package versioned
+
+import (
+	"github.com/kharf/declcd/schema/component"
+)
+
+config: component.#Manifest & {
+	content: {
+		apiVersion: "v1"
+		kind:       "ConfigMap"
+		metadata: {
+			name:      "config"
+			namespace: "test"
+		}
+		data: LOG_LEVEL: "debug"
+	}
+} @versioned(retention=30m)
+
+deployment: component.#Manifest & {
+	dependencies: [
+		config.id,
+	]
+	content: {
+		apiVersion: "apps/v1"
+		kind:       "Deployment"
+		metadata: {
+			name:      "test"
+			namespace: "test"
+		}
+		spec: template: spec: {
+			containers: [
+				{
+					name:  "test"
+					image: "test"
+					envFrom: [
+						{
+							configMapRef: name: "config"
+						},
+					]
+				},
+			]
+			volumes: [
+				{
+					name: "config"
+					configMap: name: "config"
+				},
+				{
+					name: "other"
+					configMap: name: "other"
+				},
+			]
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package wait
+
+import (
+	"github.com/kharf/declcd/schema/component"
+)
+
+crd: component.#Manifest & {
+	wait: {}
+	content: {
+		apiVersion: "apiextensions.k8s.io/v1"
+		kind:       "CustomResourceDefinition"
+		metadata: {
+			name: "tests.declcd.io"
+		}
+	}
+}
+
+overlay: component.#Kustomization & {
+	dependencies: [
+		crd.id,
+	]
+	name: "overlay"
+	path: "infra/kustomization/overlay"
+	wait: {
+		timeout: "1m"
+		rules: {
+			ConfigMap: "Ready"
+		}
+	}
+}
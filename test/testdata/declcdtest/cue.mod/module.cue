@@ -0,0 +1,4 @@
+module: "github.com/kharf/declcd/test/testdata/declcdtest@v0"
+language: {
+	version: "v0.9.0"
+}
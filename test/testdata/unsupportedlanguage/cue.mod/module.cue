@@ -0,0 +1,4 @@
+module: "github.com/kharf/declcd/test/testdata/unsupportedlanguage@v0"
+language: {
+	version: "v0.99.0"
+}
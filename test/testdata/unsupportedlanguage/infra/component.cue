@@ -0,0 +1,15 @@
+package infra
+
+config: {
+	type: "Manifest"
+	id:   "config_default__ConfigMap"
+	dependencies: []
+	content: {
+		apiVersion: "v1"
+		kind:       "ConfigMap"
+		metadata: {
+			name:      "config"
+			namespace: "default"
+		}
+	}
+}